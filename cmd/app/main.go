@@ -2,9 +2,19 @@ package main
 
 import (
 	_ "go-averroes/docs"
+	"go-averroes/internal/accesstoken"
+	"go-averroes/internal/authenticator"
 	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
+	"go-averroes/internal/event_reminder"
+	"go-averroes/internal/mailer"
 	"go-averroes/internal/middleware"
+	"go-averroes/internal/oidc"
 	"go-averroes/internal/routes"
+	"go-averroes/internal/session"
+	"go-averroes/internal/storage"
+	"go-averroes/internal/webauthn"
+	"go-averroes/internal/webpush"
 	"log"
 	"log/slog"
 
@@ -24,6 +34,50 @@ func main() {
 		log.Fatalf(common.ErrDatabaseConnection, err)
 	}
 
+	slog.Info(common.LogStorageInit)
+	if err := storage.Init(common.LoadStorageConfig()); err != nil {
+		log.Fatalf(common.ErrStorageInit, err)
+	}
+
+	slog.Info(common.LogWebPushInit)
+	if err := webpush.Init(common.LoadWebPushConfig()); err != nil {
+		log.Fatalf(common.ErrWebPushInit, err)
+	}
+
+	slog.Info(common.LogMailInit)
+	if err := mailer.Init(common.LoadMailConfig()); err != nil {
+		log.Fatalf(common.ErrMailInit, err)
+	}
+
+	slog.Info(common.LogEmailVerificationInit)
+	if err := email_verification.Init(common.LoadEmailVerificationPolicy()); err != nil {
+		log.Fatalf(common.ErrEmailVerificationInit, err)
+	}
+
+	slog.Info(common.LogWebAuthnInit)
+	if err := webauthn.Init(common.LoadWebAuthnConfig()); err != nil {
+		log.Fatalf(common.ErrWebAuthnInit, err)
+	}
+
+	slog.Info(common.LogOIDCInit)
+	if err := oidc.Init(common.LoadOIDCConfig()); err != nil {
+		log.Fatalf(common.ErrOIDCInit, err)
+	}
+
+	slog.Info(common.LogAuthenticatorInit)
+	if err := authenticator.Init(common.LoadLDAPConfig()); err != nil {
+		log.Fatalf(common.ErrAuthenticatorInit, err)
+	}
+
+	slog.Info(common.LogAccessTokenInit)
+	if err := accesstoken.Init(common.LoadAccessTokenConfig()); err != nil {
+		log.Fatalf(common.ErrAccessTokenInit, err)
+	}
+	if accesstoken.Default != nil {
+		accesstoken.Default.StartRevocationSync(session.RevokedSessions, session.ChangedUsers)
+	}
+	event_reminder.Start(common.LoadReminderInterval())
+
 	// Configurer Gin en mode debug pour plus de logs
 	gin.SetMode(gin.DebugMode)
 	router := gin.Default()
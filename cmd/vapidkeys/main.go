@@ -0,0 +1,19 @@
+// Génère une paire de clés VAPID pour les notifications Web Push.
+// Usage : go run ./cmd/vapidkeys, puis définir VAPID_PRIVATE_KEY avec la clé privée affichée.
+package main
+
+import (
+	"fmt"
+	"go-averroes/internal/webpush"
+	"log"
+)
+
+func main() {
+	key, err := webpush.GenerateKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := webpush.Client{PrivateKey: key}
+	fmt.Println("VAPID_PRIVATE_KEY=" + webpush.EncodePrivateKey(key))
+	fmt.Println("Clé publique (applicationServerKey) : " + client.PublicKey())
+}
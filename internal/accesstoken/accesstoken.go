@@ -0,0 +1,256 @@
+// Package accesstoken internal/accesstoken/accesstoken.go
+// Tokens d'accès signés (JWT EdDSA ou PASETO v4.public), vérifiés sans accès à la base de données.
+// Ils portent l'identité de l'utilisateur, ses rôles et la session dont ils sont issus ; leur durée de vie est courte
+// et la déconnexion est prise en compte par une liste de sessions révoquées tenue en mémoire. Un changement de rôles
+// ou de vérification de l'adresse y inscrit l'utilisateur : ses tokens émis auparavant sont refusés.
+package accesstoken
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTTL est la durée de vie maximale d'un token d'accès : au-delà, la révocation par liste en mémoire n'a plus de sens
+const maxTTL = time.Hour
+
+// ErrInvalidToken est retournée pour un token mal formé, mal signé, expiré ou émis par un autre émetteur
+var ErrInvalidToken = errors.New("token d'accès invalide")
+
+// ErrRevoked est retournée pour un token dont la session a été fermée
+var ErrRevoked = errors.New("session du token d'accès révoquée")
+
+// Claims sont les informations portées par un token d'accès
+type Claims struct {
+	UserID          int
+	SessionID       int
+	Email           string
+	Firstname       string
+	Lastname        string
+	EmailVerifiedAt *time.Time
+	Roles           []string
+	ExpiresAt       time.Time
+}
+
+// payload est le contenu signé d'un token ; iat et exp sont des dates numériques (JWT) ou RFC 3339 (PASETO)
+type payload struct {
+	Issuer          string          `json:"iss"`
+	Subject         string          `json:"sub"`
+	ID              string          `json:"jti"`
+	SessionID       int             `json:"sid"`
+	Email           string          `json:"email"`
+	Firstname       string          `json:"given_name"`
+	Lastname        string          `json:"family_name"`
+	EmailVerifiedAt *time.Time      `json:"email_verified_at,omitempty"`
+	Roles           []string        `json:"roles"`
+	IssuedAt        json.RawMessage `json:"iat"`
+	ExpiresAt       json.RawMessage `json:"exp"`
+}
+
+// Issuer émet et vérifie les tokens d'accès
+type Issuer struct {
+	Format         string
+	TTL            time.Duration
+	Issuer         string
+	RevocationSync time.Duration
+	signingKeyID   string
+	signingKey     ed25519.PrivateKey
+	keys           map[string]ed25519.PublicKey
+	revoked        *revocationList
+}
+
+// Default est l'émetteur de l'application, initialisé par Init ; nil : tokens de session opaques
+var Default *Issuer
+
+// Init valide la configuration et initialise l'émetteur par défaut
+func Init(cfg common.AccessTokenConfig) error {
+	if cfg.Format == "" || cfg.Format == common.AccessTokenFormatOpaque {
+		Default = nil
+		return nil
+	}
+	issuer, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Default = issuer
+	return nil
+}
+
+// New crée un émetteur ; sans clé configurée, une clé éphémère est générée et les tokens ne survivent pas au redémarrage
+func New(cfg common.AccessTokenConfig) (*Issuer, error) {
+	if cfg.Format != common.AccessTokenFormatJWT && cfg.Format != common.AccessTokenFormatPASETO {
+		return nil, fmt.Errorf("ACCESS_TOKEN_FORMAT inconnu : %q", cfg.Format)
+	}
+	if cfg.TTL <= 0 || cfg.TTL > maxTTL {
+		return nil, fmt.Errorf("ACCESS_TOKEN_TTL doit être compris entre 0 et %s : %s", maxTTL, cfg.TTL)
+	}
+	if cfg.Issuer == "" {
+		return nil, errors.New("ACCESS_TOKEN_ISSUER est vide")
+	}
+	issuer := &Issuer{
+		Format:         cfg.Format,
+		TTL:            cfg.TTL,
+		Issuer:         cfg.Issuer,
+		RevocationSync: cfg.RevocationSync,
+		keys:           map[string]ed25519.PublicKey{},
+		revoked:        newRevocationList(),
+	}
+
+	if len(cfg.Keys) == 0 {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn(common.LogAccessTokenEphemeralKey)
+		issuer.signingKeyID, issuer.signingKey = "ephemeral", private
+		issuer.keys["ephemeral"] = private.Public().(ed25519.PublicKey)
+		return issuer, nil
+	}
+	for i, key := range cfg.Keys {
+		seed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.Seed, "="))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("ACCESS_TOKEN_KEYS : la clé %q doit être une graine Ed25519 de 32 octets en base64url", key.ID)
+		}
+		if _, exists := issuer.keys[key.ID]; exists {
+			return nil, fmt.Errorf("ACCESS_TOKEN_KEYS : identifiant %q en double", key.ID)
+		}
+		private := ed25519.NewKeyFromSeed(seed)
+		issuer.keys[key.ID] = private.Public().(ed25519.PublicKey)
+		if i == 0 {
+			issuer.signingKeyID, issuer.signingKey = key.ID, private
+		}
+	}
+	return issuer, nil
+}
+
+// Issue signe un token d'accès pour l'utilisateur et la session ; retourne le token et son expiration
+func (i *Issuer) Issue(claims Claims) (string, time.Time, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(i.TTL)
+	body, err := json.Marshal(payload{
+		Issuer:          i.Issuer,
+		Subject:         strconv.Itoa(claims.UserID),
+		ID:              hex.EncodeToString(id),
+		SessionID:       claims.SessionID,
+		Email:           claims.Email,
+		Firstname:       claims.Firstname,
+		Lastname:        claims.Lastname,
+		EmailVerifiedAt: claims.EmailVerifiedAt,
+		Roles:           claims.Roles,
+		IssuedAt:        i.encodeTime(now),
+		ExpiresAt:       i.encodeTime(expiresAt),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var token string
+	if i.Format == common.AccessTokenFormatPASETO {
+		token, err = signPASETO(i.signingKey, i.signingKeyID, body)
+	} else {
+		token, err = signJWT(i.signingKey, i.signingKeyID, body)
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Recognizes indique si le token a la forme d'un token d'accès signé, par opposition à un token de session opaque
+func (i *Issuer) Recognizes(token string) bool {
+	if i.Format == common.AccessTokenFormatPASETO {
+		return strings.HasPrefix(token, pasetoHeader)
+	}
+	return strings.Count(token, ".") == 2
+}
+
+// Verify vérifie la signature, l'émetteur et l'expiration du token, puis que sa session n'a pas été révoquée
+// et que les rôles et l'adresse qu'il porte n'ont pas changé depuis son émission
+func (i *Issuer) Verify(token string) (*Claims, error) {
+	var body []byte
+	var err error
+	if i.Format == common.AccessTokenFormatPASETO {
+		body, err = verifyPASETO(i.keys, token)
+	} else {
+		body, err = verifyJWT(i.keys, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var content payload
+	if err := json.Unmarshal(body, &content); err != nil {
+		return nil, fmt.Errorf("%w : contenu illisible", ErrInvalidToken)
+	}
+	if content.Issuer != i.Issuer {
+		return nil, fmt.Errorf("%w : émetteur %q inattendu", ErrInvalidToken, content.Issuer)
+	}
+	expiresAt, err := i.decodeTime(content.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(expiresAt) {
+		return nil, fmt.Errorf("%w : token expiré", ErrInvalidToken)
+	}
+	userID, err := strconv.Atoi(content.Subject)
+	if err != nil || userID <= 0 || content.SessionID <= 0 {
+		return nil, fmt.Errorf("%w : utilisateur ou session absent", ErrInvalidToken)
+	}
+	issuedAt, err := i.decodeTime(content.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if i.revoked.contains(content.SessionID) || i.revoked.staleFor(userID, issuedAt) {
+		return nil, ErrRevoked
+	}
+
+	return &Claims{
+		UserID:          userID,
+		SessionID:       content.SessionID,
+		Email:           content.Email,
+		Firstname:       content.Firstname,
+		Lastname:        content.Lastname,
+		EmailVerifiedAt: content.EmailVerifiedAt,
+		Roles:           content.Roles,
+		ExpiresAt:       expiresAt,
+	}, nil
+}
+
+// encodeTime encode une date selon le format : date numérique (RFC 7519) ou RFC 3339 (PASETO)
+func (i *Issuer) encodeTime(t time.Time) json.RawMessage {
+	if i.Format == common.AccessTokenFormatPASETO {
+		return json.RawMessage(strconv.Quote(t.UTC().Format(time.RFC3339)))
+	}
+	return json.RawMessage(strconv.FormatInt(t.Unix(), 10))
+}
+
+// decodeTime décode une date encodée par encodeTime
+func (i *Issuer) decodeTime(raw json.RawMessage) (time.Time, error) {
+	if i.Format == common.AccessTokenFormatPASETO {
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				return t, nil
+			}
+		}
+	} else {
+		var value int64
+		if err := json.Unmarshal(raw, &value); err == nil {
+			return time.Unix(value, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w : date invalide", ErrInvalidToken)
+}
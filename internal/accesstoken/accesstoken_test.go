@@ -0,0 +1,206 @@
+package accesstoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"go-averroes/internal/common"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// seed retourne une graine Ed25519 déterministe en base64url
+func seed(b byte) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), ed25519.SeedSize)))
+}
+
+// newIssuer crée un émetteur de test signant avec la première des clés indiquées
+func newIssuer(t *testing.T, format string, keys ...common.AccessTokenKey) *Issuer {
+	issuer, err := New(common.AccessTokenConfig{Format: format, Keys: keys, TTL: 5 * time.Minute, Issuer: "golendar", RevocationSync: time.Minute})
+	require.NoError(t, err)
+	return issuer
+}
+
+// TestPASETOVector vérifie le vecteur 4-S-1 de la spécification PASETO (v4.public, sans pied de page)
+func TestPASETOVector(t *testing.T) {
+	key, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+	message := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+	require.Equal(t, expected, signPASETOWithFooter(ed25519.PrivateKey(key), []byte(message), nil))
+
+	body, err := openPASETO(ed25519.PrivateKey(key).Public().(ed25519.PublicKey), strings.TrimPrefix(expected, pasetoHeader), nil)
+	require.NoError(t, err)
+	require.Equal(t, message, string(body))
+}
+
+// TestIssueVerify vérifie l'aller-retour des claims dans les deux formats
+func TestIssueVerify(t *testing.T) {
+	verifiedAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	for _, format := range []string{common.AccessTokenFormatJWT, common.AccessTokenFormatPASETO} {
+		issuer := newIssuer(t, format, common.AccessTokenKey{ID: "2026-01", Seed: seed(1)})
+		token, expiresAt, err := issuer.Issue(Claims{
+			UserID: 42, SessionID: 7, Email: "nour.haddad@example.com", Firstname: "Nour", Lastname: "Haddad",
+			EmailVerifiedAt: &verifiedAt, Roles: []string{"user", "editor"},
+		})
+		require.NoError(t, err, format)
+		require.True(t, issuer.Recognizes(token), format)
+		require.False(t, issuer.Recognizes(strings.Repeat("ab", 32)), format)
+		require.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Second, format)
+
+		claims, err := issuer.Verify(token)
+		require.NoError(t, err, format)
+		require.Equal(t, 42, claims.UserID)
+		require.Equal(t, 7, claims.SessionID)
+		require.Equal(t, "Nour", claims.Firstname)
+		require.Equal(t, []string{"user", "editor"}, claims.Roles)
+		require.True(t, verifiedAt.Equal(*claims.EmailVerifiedAt))
+
+		// Toute modification du contenu invalide la signature
+		tampered := []byte(token)
+		tampered[len(tampered)/2] ^= 1
+		_, err = issuer.Verify(string(tampered))
+		require.ErrorIs(t, err, ErrInvalidToken, format)
+
+		// Un autre émetteur, même avec la même clé, n'est pas accepté
+		other, err := New(common.AccessTokenConfig{Format: format, Keys: []common.AccessTokenKey{{ID: "2026-01", Seed: seed(1)}}, TTL: time.Minute, Issuer: "autre"})
+		require.NoError(t, err)
+		_, err = other.Verify(token)
+		require.ErrorIs(t, err, ErrInvalidToken, format)
+	}
+}
+
+// TestJWTRejected vérifie le refus des JWT non signés par l'application
+func TestJWTRejected(t *testing.T) {
+	issuer := newIssuer(t, common.AccessTokenFormatJWT, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+	token, _, err := issuer.Issue(Claims{UserID: 1, SessionID: 1})
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	// Algorithme "none"
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"k1"}`))
+	_, err = issuer.Verify(none + "." + parts[1] + ".")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// Token expiré, pourtant correctement signé
+	expired := newIssuer(t, common.AccessTokenFormatJWT, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+	expired.TTL = -time.Minute
+	token, _, err = expired.Issue(Claims{UserID: 1, SessionID: 1})
+	require.NoError(t, err)
+	_, err = issuer.Verify(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// TestKeyRotation vérifie que les tokens signés par une ancienne clé restent valides tant qu'elle est configurée
+func TestKeyRotation(t *testing.T) {
+	for _, format := range []string{common.AccessTokenFormatJWT, common.AccessTokenFormatPASETO} {
+		before := newIssuer(t, format, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+		token, _, err := before.Issue(Claims{UserID: 1, SessionID: 1})
+		require.NoError(t, err)
+
+		// Nouvelle clé en tête : elle signe, l'ancienne ne sert plus qu'à vérifier
+		during := newIssuer(t, format, common.AccessTokenKey{ID: "k2", Seed: seed(2)}, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+		_, err = during.Verify(token)
+		require.NoError(t, err, format)
+		rotated, _, err := during.Issue(Claims{UserID: 1, SessionID: 1})
+		require.NoError(t, err)
+		_, err = before.Verify(rotated)
+		require.ErrorIs(t, err, ErrInvalidToken, format)
+
+		// Ancienne clé retirée
+		after := newIssuer(t, format, common.AccessTokenKey{ID: "k2", Seed: seed(2)})
+		_, err = after.Verify(token)
+		require.ErrorIs(t, err, ErrInvalidToken, format)
+		_, err = after.Verify(rotated)
+		require.NoError(t, err, format)
+
+		// Une clé différente sous le même identifiant est refusée
+		impostor := newIssuer(t, format, common.AccessTokenKey{ID: "k2", Seed: seed(3)})
+		forged, _, err := impostor.Issue(Claims{UserID: 1, SessionID: 1})
+		require.NoError(t, err)
+		_, err = after.Verify(forged)
+		require.ErrorIs(t, err, ErrInvalidToken, format)
+	}
+}
+
+// TestRevocation vérifie le refus des tokens d'une session révoquée, localement ou par synchronisation
+func TestRevocation(t *testing.T) {
+	issuer := newIssuer(t, common.AccessTokenFormatJWT, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+	first, _, err := issuer.Issue(Claims{UserID: 1, SessionID: 1})
+	require.NoError(t, err)
+	second, _, err := issuer.Issue(Claims{UserID: 1, SessionID: 2})
+	require.NoError(t, err)
+
+	issuer.Revoke(1)
+	_, err = issuer.Verify(first)
+	require.ErrorIs(t, err, ErrRevoked)
+	_, err = issuer.Verify(second)
+	require.NoError(t, err)
+
+	var since time.Time
+	require.NoError(t, issuer.SyncRevocations(func(s time.Time) ([]int, error) {
+		since = s
+		return []int{2}, nil
+	}))
+	require.WithinDuration(t, time.Now().Add(-6*time.Minute), since, time.Second)
+	_, err = issuer.Verify(second)
+	require.ErrorIs(t, err, ErrRevoked)
+}
+
+// TestRevokeIssuedBefore vérifie le refus des tokens émis avant un changement des rôles ou de l'adresse de l'utilisateur
+func TestRevokeIssuedBefore(t *testing.T) {
+	issuer := newIssuer(t, common.AccessTokenFormatPASETO, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+	stale, _, err := issuer.Issue(Claims{UserID: 1, SessionID: 1})
+	require.NoError(t, err)
+	other, _, err := issuer.Issue(Claims{UserID: 2, SessionID: 2})
+	require.NoError(t, err)
+
+	// Changement postérieur à l'émission (iat est à la seconde près)
+	issuer.RevokeIssuedBefore(1, time.Now().Add(2*time.Second))
+	_, err = issuer.Verify(stale)
+	require.ErrorIs(t, err, ErrRevoked)
+	_, err = issuer.Verify(other)
+	require.NoError(t, err)
+
+	// Un token émis après le changement, comme au rafraîchissement, est accepté
+	issuer = newIssuer(t, common.AccessTokenFormatJWT, common.AccessTokenKey{ID: "k1", Seed: seed(1)})
+	var since time.Time
+	require.NoError(t, issuer.SyncClaimChanges(func(s time.Time) (map[int]time.Time, error) {
+		since = s
+		return map[int]time.Time{1: time.Now()}, nil
+	}))
+	require.WithinDuration(t, time.Now().Add(-6*time.Minute), since, time.Second)
+	fresh, _, err := issuer.Issue(Claims{UserID: 1, SessionID: 1})
+	require.NoError(t, err)
+	_, err = issuer.Verify(fresh)
+	require.NoError(t, err)
+}
+
+// TestInit vérifie la validation de la configuration
+func TestInit(t *testing.T) {
+	require.NoError(t, Init(common.AccessTokenConfig{Format: common.AccessTokenFormatOpaque}))
+	require.Nil(t, Default)
+
+	valid := common.AccessTokenConfig{Format: common.AccessTokenFormatPASETO, Keys: []common.AccessTokenKey{{ID: "k1", Seed: seed(1)}}, TTL: 5 * time.Minute, Issuer: "golendar"}
+	require.NoError(t, Init(valid))
+	require.NotNil(t, Default)
+
+	for name, mutate := range map[string]func(*common.AccessTokenConfig){
+		"format inconnu":  func(cfg *common.AccessTokenConfig) { cfg.Format = "saml" },
+		"durée nulle":     func(cfg *common.AccessTokenConfig) { cfg.TTL = 0 },
+		"durée excessive": func(cfg *common.AccessTokenConfig) { cfg.TTL = 2 * time.Hour },
+		"graine courte":   func(cfg *common.AccessTokenConfig) { cfg.Keys = []common.AccessTokenKey{{ID: "k1", Seed: "AAAA"}} },
+		"identifiant en double": func(cfg *common.AccessTokenConfig) {
+			cfg.Keys = []common.AccessTokenKey{{ID: "k1", Seed: seed(1)}, {ID: "k1", Seed: seed(2)}}
+		},
+	} {
+		cfg := valid
+		mutate(&cfg)
+		require.Error(t, Init(cfg), name)
+	}
+	require.NoError(t, Init(common.AccessTokenConfig{}))
+	require.Nil(t, Default)
+}
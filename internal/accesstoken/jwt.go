@@ -0,0 +1,61 @@
+// Package accesstoken internal/accesstoken/jwt.go
+package accesstoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jwtHeader est l'en-tête JOSE des tokens d'accès
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid"`
+}
+
+// signJWT signe le contenu en JWS compact EdDSA (RFC 8037)
+func signJWT(key ed25519.PrivateKey, keyID string, body []byte) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "EdDSA", Typ: "JWT", Kid: keyID})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	signature := ed25519.Sign(key, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyJWT vérifie la signature d'un JWS compact avec la clé désignée par son kid et retourne son contenu
+func verifyJWT(keys map[string]ed25519.PublicKey, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w : format JWS compact attendu", ErrInvalidToken)
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w : en-tête illisible", ErrInvalidToken)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, fmt.Errorf("%w : en-tête illisible", ErrInvalidToken)
+	}
+	// L'algorithme est imposé : ni "none" ni un autre algorithme choisi par le porteur du token
+	if header.Alg != "EdDSA" {
+		return nil, fmt.Errorf("%w : algorithme %q refusé", ErrInvalidToken, header.Alg)
+	}
+	key, ok := keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("%w : clé %q inconnue", ErrInvalidToken, header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w : signature incorrecte", ErrInvalidToken)
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w : contenu illisible", ErrInvalidToken)
+	}
+	return body, nil
+}
@@ -0,0 +1,87 @@
+// Package accesstoken internal/accesstoken/paseto.go
+package accesstoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// pasetoHeader est l'en-tête des tokens PASETO version 4, usage public (signature Ed25519)
+const pasetoHeader = "v4.public."
+
+// pasetoFooter est le pied de page, non chiffré mais signé, qui désigne la clé de signature
+type pasetoFooter struct {
+	Kid string `json:"kid"`
+}
+
+// signPASETO signe le contenu en PASETO v4.public, la clé étant désignée dans le pied de page
+func signPASETO(key ed25519.PrivateKey, keyID string, body []byte) (string, error) {
+	footer, err := json.Marshal(pasetoFooter{Kid: keyID})
+	if err != nil {
+		return "", err
+	}
+	return signPASETOWithFooter(key, body, footer), nil
+}
+
+// signPASETOWithFooter applique l'opération Sign de PASETO v4.public, sans assertion implicite
+func signPASETOWithFooter(key ed25519.PrivateKey, body, footer []byte) string {
+	signature := ed25519.Sign(key, pae([]byte(pasetoHeader), body, footer, nil))
+	token := pasetoHeader + base64.RawURLEncoding.EncodeToString(append(append([]byte(nil), body...), signature...))
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// verifyPASETO vérifie un token PASETO v4.public avec la clé désignée dans son pied de page et retourne son contenu
+func verifyPASETO(keys map[string]ed25519.PublicKey, token string) ([]byte, error) {
+	if !strings.HasPrefix(token, pasetoHeader) {
+		return nil, fmt.Errorf("%w : en-tête %q attendu", ErrInvalidToken, pasetoHeader)
+	}
+	parts := strings.Split(strings.TrimPrefix(token, pasetoHeader), ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w : pied de page attendu", ErrInvalidToken)
+	}
+	footer, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w : pied de page illisible", ErrInvalidToken)
+	}
+	// Le pied de page est lu avant la vérification pour choisir la clé ; il est ensuite couvert par la signature
+	var content pasetoFooter
+	if err := json.Unmarshal(footer, &content); err != nil {
+		return nil, fmt.Errorf("%w : pied de page illisible", ErrInvalidToken)
+	}
+	key, ok := keys[content.Kid]
+	if !ok {
+		return nil, fmt.Errorf("%w : clé %q inconnue", ErrInvalidToken, content.Kid)
+	}
+	return openPASETO(key, parts[0], footer)
+}
+
+// openPASETO vérifie la signature du corps encodé d'un token v4.public et retourne son contenu
+func openPASETO(key ed25519.PublicKey, encoded string, footer []byte) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) < ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w : corps illisible", ErrInvalidToken)
+	}
+	body, signature := decoded[:len(decoded)-ed25519.SignatureSize], decoded[len(decoded)-ed25519.SignatureSize:]
+	if !ed25519.Verify(key, pae([]byte(pasetoHeader), body, footer, nil), signature) {
+		return nil, fmt.Errorf("%w : signature incorrecte", ErrInvalidToken)
+	}
+	return body, nil
+}
+
+// pae est l'encodage pré-authentification de PASETO : nombre de pièces puis chaque pièce précédée de sa longueur,
+// en entiers de 64 bits petit-boutistes dont le bit de poids fort est nul
+func pae(pieces ...[]byte) []byte {
+	output := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces))&^(1<<63))
+	for _, piece := range pieces {
+		output = binary.LittleEndian.AppendUint64(output, uint64(len(piece))&^(1<<63))
+		output = append(output, piece...)
+	}
+	return output
+}
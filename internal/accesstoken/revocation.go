@@ -0,0 +1,157 @@
+// Package accesstoken internal/accesstoken/revocation.go
+package accesstoken
+
+import (
+	"database/sql"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RevokedSessions retourne les sessions fermées depuis since ; fournie par le package session
+type RevokedSessions func(since time.Time) ([]int, error)
+
+// ChangedUsers retourne, pour chaque utilisateur dont les rôles ou la vérification de l'adresse e-mail ont changé
+// depuis since, la date du changement ; fournie par le package session
+type ChangedUsers func(since time.Time) (map[int]time.Time, error)
+
+// execer est satisfait par *sql.DB et *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// revocationList est la liste des sessions fermées dont des tokens d'accès peuvent encore être en circulation.
+// Une session y reste le temps de vie d'un token : les tokens émis avant sa fermeture ont alors expiré.
+// Les utilisateurs dont les informations portées par les tokens ont changé y figurent avec la date du changement :
+// leurs tokens émis avant cette date sont refusés, ceux émis ensuite au rafraîchissement sont acceptés.
+type revocationList struct {
+	mu       sync.Mutex
+	sessions map[int]time.Time
+	users    map[int]time.Time
+}
+
+// newRevocationList crée une liste de révocation vide
+func newRevocationList() *revocationList {
+	return &revocationList{sessions: map[int]time.Time{}, users: map[int]time.Time{}}
+}
+
+// add ajoute une session jusqu'à la date indiquée et retire les sessions échues
+func (r *revocationList) add(sessionID int, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, expiry := range r.sessions {
+		if now.After(expiry) {
+			delete(r.sessions, id)
+		}
+	}
+	if until.After(r.sessions[sessionID]) {
+		r.sessions[sessionID] = until
+	}
+}
+
+// contains indique si la session est révoquée
+func (r *revocationList) contains(sessionID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	until, ok := r.sessions[sessionID]
+	return ok && time.Now().Before(until)
+}
+
+// addUser refuse les tokens de l'utilisateur émis avant changedAt ; passé ttl après le changement, ces tokens
+// ont expiré et l'entrée est retirée
+func (r *revocationList) addUser(userID int, changedAt time.Time, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, change := range r.users {
+		if now.After(change.Add(ttl)) {
+			delete(r.users, id)
+		}
+	}
+	if changedAt.After(r.users[userID]) && now.Before(changedAt.Add(ttl)) {
+		r.users[userID] = changedAt
+	}
+}
+
+// staleFor indique si un token de l'utilisateur émis à issuedAt précède un changement de ses informations.
+// iat est à la seconde près : un token émis dans la seconde du changement reste accepté.
+func (r *revocationList) staleFor(userID int, issuedAt time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changedAt, ok := r.users[userID]
+	return ok && issuedAt.Before(changedAt.Truncate(time.Second))
+}
+
+// Revoke refuse immédiatement, sur cette instance, les tokens d'accès de la session
+func (i *Issuer) Revoke(sessionID int) {
+	i.revoked.add(sessionID, time.Now().Add(i.TTL))
+}
+
+// RevokeIssuedBefore refuse immédiatement, sur cette instance, les tokens d'accès de l'utilisateur émis avant changedAt.
+// Le client obtient au rafraîchissement suivant un token reprenant ses rôles et son adresse à jour.
+func (i *Issuer) RevokeIssuedBefore(userID int, changedAt time.Time) {
+	i.revoked.addUser(userID, changedAt, i.TTL)
+}
+
+// ClaimsChanged enregistre que les rôles ou la vérification de l'adresse e-mail de l'utilisateur ont changé :
+// ses tokens d'accès signés émis auparavant sont refusés, immédiatement sur cette instance (si les tokens
+// signés sont activés) et à la synchronisation suivante sur les autres
+func ClaimsChanged(db execer, userID int) error {
+	now := time.Now()
+	if _, err := db.Exec("UPDATE user SET claims_changed_at = ? WHERE user_id = ?", now, userID); err != nil {
+		return err
+	}
+	if Default != nil {
+		Default.RevokeIssuedBefore(userID, now)
+	}
+	return nil
+}
+
+// SyncRevocations ajoute à la liste de révocation les sessions fermées par les autres instances
+// depuis le temps de vie d'un token, augmenté de l'intervalle de synchronisation
+func (i *Issuer) SyncRevocations(source RevokedSessions) error {
+	sessions, err := source(time.Now().Add(-i.TTL - i.RevocationSync))
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessions {
+		i.Revoke(sessionID)
+	}
+	return nil
+}
+
+// SyncClaimChanges refuse les tokens émis avant les changements de rôles ou de vérification enregistrés
+// par les autres instances depuis le temps de vie d'un token, augmenté de l'intervalle de synchronisation
+func (i *Issuer) SyncClaimChanges(source ChangedUsers) error {
+	users, err := source(time.Now().Add(-i.TTL - i.RevocationSync))
+	if err != nil {
+		return err
+	}
+	for userID, changedAt := range users {
+		i.RevokeIssuedBefore(userID, changedAt)
+	}
+	return nil
+}
+
+// StartRevocationSync synchronise la liste de révocation à intervalle régulier, en arrière-plan
+func (i *Issuer) StartRevocationSync(sessions RevokedSessions, users ChangedUsers) {
+	if i.RevocationSync <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(i.RevocationSync)
+		defer ticker.Stop()
+		for {
+			if err := i.SyncRevocations(sessions); err != nil {
+				slog.Error(fmt.Sprintf(common.LogAccessTokenSyncError, err.Error()))
+			}
+			if err := i.SyncClaimChanges(users); err != nil {
+				slog.Error(fmt.Sprintf(common.LogAccessTokenSyncError, err.Error()))
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,378 @@
+// Package attachment internal/attachment/attachment.go
+package attachment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"go-averroes/internal/storage"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentStruct struct{}
+
+var Attachment = AttachmentStruct{}
+
+const (
+	// multipartOverhead laisse de la marge aux en-têtes multipart au-delà de la taille maximale du fichier
+	multipartOverhead = 1 << 20
+	// maxFilenameLength correspond à la taille de la colonne attachment.filename
+	maxFilenameLength = 255
+)
+
+// Upload ajoute une pièce jointe à un événement
+// @Summary Ajouter une pièce jointe
+// @Description Ajoute un fichier (ordre du jour, présentation...) à un événement via un upload multipart (champ "file"). La taille et le type MIME sont limités par la configuration.
+// @Tags Pièce jointe
+// @Accept multipart/form-data
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param file formData file true "Fichier à joindre"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 413 {object} common.JSONErrorResponse
+// @Failure 415 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/attachments [post]
+func (AttachmentStruct) Upload(c *gin.Context) {
+	slog.Info(common.LogAttachmentUpload)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	maxSize := storage.Config.MaxAttachmentSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			slog.Error(common.LogAttachmentUpload + " - requête trop volumineuse")
+			c.JSON(http.StatusRequestEntityTooLarge, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrAttachmentTooLarge,
+			})
+			return
+		}
+		slog.Error(common.LogAttachmentUpload + " - fichier manquant : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentMissing,
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		slog.Error(fmt.Sprintf("%s - fichier trop volumineux (%d octets)", common.LogAttachmentUpload, fileHeader.Size))
+		c.JSON(http.StatusRequestEntityTooLarge, common.JSONResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s (maximum %d octets)", common.ErrAttachmentTooLarge, maxSize),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		slog.Error(common.LogAttachmentUpload + " - erreur lors de l'ouverture du fichier : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentMissing,
+		})
+		return
+	}
+	defer file.Close()
+
+	// Le type annoncé par le client n'est utilisé que s'il est précis, sinon on détecte d'après le contenu
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := DetectContentType(fileHeader.Header.Get("Content-Type"), head[:n])
+	if !IsAllowedType(contentType, storage.Config.AllowedMimeTypes) {
+		slog.Error(common.LogAttachmentUpload + " - type non autorisé : " + contentType)
+		c.JSON(http.StatusUnsupportedMediaType, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentTypeNotAllowed + ": " + contentType,
+		})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		slog.Error(common.LogAttachmentUpload + " - erreur lors de la lecture du fichier : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentCreation,
+		})
+		return
+	}
+
+	storageKey, err := newStorageKey(eventData.EventID)
+	if err != nil {
+		slog.Error(common.LogAttachmentUpload + " - erreur lors de la génération de la clé : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentCreation,
+		})
+		return
+	}
+	if err := storage.Default.Put(c.Request.Context(), storageKey, file, fileHeader.Size, contentType); err != nil {
+		slog.Error(common.LogAttachmentUpload + " - erreur lors de l'écriture dans le stockage : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentStorage,
+		})
+		return
+	}
+
+	filename := SanitizeFilename(fileHeader.Filename)
+	result, err := common.DB.Exec(`
+		INSERT INTO attachment (event_id, user_id, filename, content_type, size, storage_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())
+	`, eventData.EventID, userData.UserID, filename, contentType, fileHeader.Size, storageKey)
+	if err != nil {
+		slog.Error(common.LogAttachmentUpload + " - erreur lors de l'enregistrement des métadonnées : " + err.Error())
+		// Ne pas laisser d'objet orphelin dans le stockage
+		if errDelete := storage.Default.Delete(c.Request.Context(), storageKey); errDelete != nil {
+			slog.Error(common.LogAttachmentUpload + " - objet orphelin " + storageKey + " : " + errDelete.Error())
+		}
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentCreation,
+		})
+		return
+	}
+
+	attachmentID, _ := result.LastInsertId()
+
+	slog.Info(common.LogAttachmentUpload + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUploadAttachment,
+		Data: common.Attachment{
+			AttachmentID: int(attachmentID),
+			EventID:      eventData.EventID,
+			UserID:       userData.UserID,
+			Filename:     filename,
+			ContentType:  contentType,
+			Size:         fileHeader.Size,
+		},
+	})
+}
+
+// List liste les pièces jointes d'un événement
+// @Summary Lister les pièces jointes
+// @Description Liste les métadonnées des pièces jointes d'un événement
+// @Tags Pièce jointe
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/attachments [get]
+func (AttachmentStruct) List(c *gin.Context) {
+	slog.Info(common.LogAttachmentList)
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	attachmentsByEvent, err := LoadEventAttachments([]int{eventData.EventID})
+	if err != nil {
+		slog.Error(common.LogAttachmentList + " - erreur lors de la récupération des pièces jointes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentRetrieval,
+		})
+		return
+	}
+	attachments := attachmentsByEvent[eventData.EventID]
+	if attachments == nil {
+		attachments = []common.Attachment{}
+	}
+
+	slog.Info(common.LogAttachmentList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListAttachments,
+		Data:    attachments,
+	})
+}
+
+// Download renvoie le contenu d'une pièce jointe
+// @Summary Télécharger une pièce jointe
+// @Description Télécharge le fichier d'une pièce jointe ; l'accès est contrôlé comme pour l'événement (accès au calendrier requis)
+// @Tags Pièce jointe
+// @Produce octet-stream
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param attachment_id path int true "ID de la pièce jointe"
+// @Success 200 {file} file
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/attachments/{attachment_id} [get]
+func (AttachmentStruct) Download(c *gin.Context) {
+	slog.Info(common.LogAttachmentDownload)
+	attachmentData, ok := common.GetAttachmentFromContext(c)
+	if !ok {
+		return
+	}
+
+	reader, err := storage.Default.Get(c.Request.Context(), attachmentData.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			slog.Error(common.LogAttachmentDownload + " - objet absent du stockage : " + attachmentData.StorageKey)
+			c.JSON(http.StatusNotFound, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrAttachmentNotFound,
+			})
+			return
+		}
+		slog.Error(common.LogAttachmentDownload + " - erreur lors de la lecture du stockage : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentStorage,
+		})
+		return
+	}
+	defer reader.Close()
+
+	slog.Info(common.LogAttachmentDownload + " - succès")
+	c.DataFromReader(http.StatusOK, attachmentData.Size, attachmentData.ContentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachmentData.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Delete supprime une pièce jointe
+// @Summary Supprimer une pièce jointe
+// @Description Supprime une pièce jointe d'un événement (métadonnées et contenu stocké)
+// @Tags Pièce jointe
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param attachment_id path int true "ID de la pièce jointe"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/attachments/{attachment_id} [delete]
+func (AttachmentStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogAttachmentDelete)
+	attachmentData, ok := common.GetAttachmentFromContext(c)
+	if !ok {
+		return
+	}
+
+	if _, err := common.DB.Exec("UPDATE attachment SET deleted_at = NOW() WHERE attachment_id = ?", attachmentData.AttachmentID); err != nil {
+		slog.Error(common.LogAttachmentDelete + " - erreur lors de la suppression de la pièce jointe : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentDelete,
+		})
+		return
+	}
+
+	// Le contenu n'est plus accessible une fois les métadonnées supprimées : un échec ici laisse seulement un objet orphelin
+	if err := storage.Default.Delete(c.Request.Context(), attachmentData.StorageKey); err != nil {
+		slog.Error(common.LogAttachmentDelete + " - objet orphelin " + attachmentData.StorageKey + " : " + err.Error())
+	}
+
+	slog.Info(common.LogAttachmentDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteAttachment,
+	})
+}
+
+// Fonctions utilitaires
+
+// LoadEventAttachments récupère les pièces jointes actives des événements, indexées par event_id
+func LoadEventAttachments(eventIDs []int) (map[int][]common.Attachment, error) {
+	attachmentsByEvent := make(map[int][]common.Attachment)
+	if len(eventIDs) == 0 {
+		return attachmentsByEvent, nil
+	}
+
+	placeholders, args := common.InClause(eventIDs)
+	rows, err := common.DB.Query(`
+		SELECT attachment_id, event_id, user_id, filename, content_type, size, storage_key, created_at, updated_at, deleted_at
+		FROM attachment
+		WHERE deleted_at IS NULL AND event_id IN (`+placeholders+`)
+		ORDER BY created_at ASC, attachment_id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attachment common.Attachment
+		err := rows.Scan(&attachment.AttachmentID, &attachment.EventID, &attachment.UserID, &attachment.Filename,
+			&attachment.ContentType, &attachment.Size, &attachment.StorageKey,
+			&attachment.CreatedAt, &attachment.UpdatedAt, &attachment.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachmentsByEvent[attachment.EventID] = append(attachmentsByEvent[attachment.EventID], attachment)
+	}
+	return attachmentsByEvent, rows.Err()
+}
+
+// DetectContentType retient le type déclaré s'il est précis, sinon le type détecté à partir des premiers octets
+func DetectContentType(declared string, head []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return strings.ToLower(mediaType)
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return mediaType
+}
+
+// IsAllowedType indique si le type MIME figure dans la liste autorisée
+func IsAllowedType(contentType string, allowed []string) bool {
+	for _, allowedType := range allowed {
+		if strings.EqualFold(contentType, allowedType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeFilename ne conserve que le nom de base du fichier, sans caractères de contrôle, tronqué à 255 caractères
+func SanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "fichier"
+	}
+	if utf8.RuneCountInString(name) > maxFilenameLength {
+		name = string([]rune(name)[:maxFilenameLength])
+	}
+	return name
+}
+
+// newStorageKey génère une clé de stockage aléatoire ; le nom fourni par l'utilisateur n'y figure jamais
+func newStorageKey(eventID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("events/%d/%s", eventID, hex.EncodeToString(random)), nil
+}
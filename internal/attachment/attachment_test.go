@@ -0,0 +1,272 @@
+package attachment_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-averroes/internal/attachment"
+	"go-averroes/internal/common"
+	"go-averroes/internal/storage"
+	"go-averroes/testutils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// multipartBody construit un corps multipart avec un fichier dans le champ "file"
+func multipartBody(t *testing.T, filename, contentType string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+// TestUploadAttachmentRoute teste la route POST d'ajout d'une pièce jointe avec plusieurs cas
+func TestUploadAttachmentRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		SetupData        func() map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Ajout réussi d'un ordre du jour PDF",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"filename":    "ordre-du-jour.pdf",
+					"contentType": "application/pdf",
+					"content":     []byte("%PDF-1.4 ordre du jour"),
+				}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessUploadAttachment,
+		},
+		{
+			CaseName: "Échec d'ajout d'un type non autorisé",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"filename":    "script.sh",
+					"contentType": "application/x-sh",
+					"content":     []byte("#!/bin/sh\necho pwned"),
+				}
+			},
+			ExpectedHttpCode: http.StatusUnsupportedMediaType,
+			ExpectedError:    common.ErrAttachmentTypeNotAllowed,
+		},
+		{
+			CaseName: "Échec d'ajout d'un fichier trop volumineux",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"filename":    "enorme.txt",
+					"contentType": "text/plain",
+					"content":     bytes.Repeat([]byte("a"), int(storage.Config.MaxAttachmentSize)+1),
+				}
+			},
+			ExpectedHttpCode: http.StatusRequestEntityTooLarge,
+			ExpectedError:    common.ErrAttachmentTooLarge,
+		},
+		{
+			CaseName: "Échec d'ajout sans accès au calendrier",
+			SetupData: func() map[string]interface{} {
+				owner, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"owner":       owner,
+					"filename":    "slides.pdf",
+					"contentType": "application/pdf",
+					"content":     []byte("%PDF-1.4"),
+				}
+			},
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrNoAccessToCalendar,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			setupData := testCase.SetupData()
+			user := setupData["user"].(*testutils.AuthenticatedUser)
+			target := user
+			if owner, ok := setupData["owner"].(*testutils.AuthenticatedUser); ok {
+				target = owner
+			}
+
+			body, contentType := multipartBody(t, setupData["filename"].(string), setupData["contentType"].(string), setupData["content"].([]byte))
+			url := testServer.URL + "/calendar-event/" + strconv.Itoa(target.Calendar.CalendarID) + "/" + strconv.Itoa(target.Event.EventID) + "/attachments"
+			req, err := http.NewRequest("POST", url, body)
+			require.NoError(t, err, "Erreur lors de la création de la requête")
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+
+			resp, err := testClient.Do(req)
+			require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+			defer resp.Body.Close()
+
+			require.Equal(t, testCase.ExpectedHttpCode, resp.StatusCode, "Code de statut HTTP incorrect")
+
+			var response common.JSONResponse
+			err = json.NewDecoder(resp.Body).Decode(&response)
+			require.NoError(t, err, "Erreur lors du parsing de la réponse JSON")
+
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestDownloadAttachmentRoute vérifie le téléchargement, le contrôle d'accès et la suppression d'une pièce jointe
+func TestDownloadAttachmentRoute(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+	require.NoError(t, err)
+	other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+
+	content := []byte("Ordre du jour :\n1. Budget\n2. Planning")
+	body, contentType := multipartBody(t, "agenda.txt", "text/plain", content)
+	baseURL := testServer.URL + "/calendar-event/" + strconv.Itoa(user.Calendar.CalendarID) + "/" + strconv.Itoa(user.Event.EventID) + "/attachments"
+	req, err := http.NewRequest("POST", baseURL, body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+	resp, err := testClient.Do(req)
+	require.NoError(t, err)
+	var uploadResponse struct {
+		common.JSONResponse
+		Data common.Attachment `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&uploadResponse))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	attachmentURL := baseURL + "/" + strconv.Itoa(uploadResponse.Data.AttachmentID)
+
+	// Le propriétaire télécharge le contenu d'origine
+	req, _ = http.NewRequest("GET", attachmentURL, nil)
+	req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	downloaded, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, content, downloaded)
+	require.Contains(t, resp.Header.Get("Content-Disposition"), "agenda.txt")
+
+	// L'événement expose les métadonnées de ses pièces jointes
+	req, _ = http.NewRequest("GET", testServer.URL+"/calendar-event/"+strconv.Itoa(user.Calendar.CalendarID)+"/"+strconv.Itoa(user.Event.EventID), nil)
+	req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	var eventResponse struct {
+		common.JSONResponse
+		Data common.Event `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&eventResponse))
+	resp.Body.Close()
+	require.Len(t, eventResponse.Data.Attachments, 1)
+	require.Equal(t, int64(len(content)), eventResponse.Data.Attachments[0].Size)
+
+	// Un utilisateur sans accès au calendrier ne peut pas télécharger
+	req, _ = http.NewRequest("GET", attachmentURL, nil)
+	req.Header.Set("Authorization", "Bearer "+other.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Passer par un calendrier accessible ne donne pas accès aux pièces jointes d'un autre calendrier
+	foreignURL := strings.Replace(attachmentURL, "/calendar-event/"+strconv.Itoa(user.Calendar.CalendarID)+"/", "/calendar-event/"+strconv.Itoa(other.Calendar.CalendarID)+"/", 1)
+	req, _ = http.NewRequest("GET", foreignURL, nil)
+	req.Header.Set("Authorization", "Bearer "+other.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Après suppression, la pièce jointe n'est plus disponible
+	req, _ = http.NewRequest("DELETE", attachmentURL, nil)
+	req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, _ = http.NewRequest("GET", attachmentURL, nil)
+	req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+	resp, err = testClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestDetectContentType vérifie le choix entre type déclaré et type détecté
+func TestDetectContentType(t *testing.T) {
+	require.Equal(t, "application/pdf", attachment.DetectContentType("application/pdf", []byte("%PDF-1.4")))
+	require.Equal(t, "application/pdf", attachment.DetectContentType("application/octet-stream", []byte("%PDF-1.4")))
+	require.Equal(t, "image/png", attachment.DetectContentType("", []byte("\x89PNG\r\n\x1a\n")))
+	require.Equal(t, "text/plain", attachment.DetectContentType("Text/Plain; charset=utf-8", []byte("bonjour")))
+}
+
+// TestSanitizeFilename vérifie que seul le nom de base est conservé
+func TestSanitizeFilename(t *testing.T) {
+	require.Equal(t, "passwd", attachment.SanitizeFilename("../../etc/passwd"))
+	require.Equal(t, "slides.pptx", attachment.SanitizeFilename(`C:\Users\moi\slides.pptx`))
+	require.Equal(t, "fichier", attachment.SanitizeFilename(""))
+	require.Equal(t, "agenda.pdf", attachment.SanitizeFilename("agen\x00da.pdf"))
+	require.Len(t, []rune(attachment.SanitizeFilename(strings.Repeat("é", 300))), 255)
+}
@@ -0,0 +1,71 @@
+// Package authenticator internal/authenticator/authenticator.go
+// Sources d'identifiants (adresse e-mail et mot de passe) consultées par la connexion : base locale et annuaire LDAP.
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+)
+
+// Authenticator est l'interface commune aux sources d'identifiants
+type Authenticator interface {
+	// Authenticate vérifie les identifiants et retourne l'utilisateur authentifié.
+	// ErrInvalidCredentials est retournée quand la source ne reconnaît pas les identifiants.
+	Authenticate(ctx context.Context, email, password string) (int, error)
+}
+
+var (
+	// ErrInvalidCredentials est retournée pour un utilisateur inconnu ou un mot de passe incorrect
+	ErrInvalidCredentials = errors.New(common.ErrInvalidCredentials)
+	// ErrUnavailable est retournée quand une source d'identifiants ne peut pas être consultée
+	ErrUnavailable = errors.New(common.ErrAuthenticationUnavailable)
+)
+
+// Default est la source utilisée par la connexion, initialisée par Init
+var Default Authenticator = Local{}
+
+// Chain consulte les sources dans l'ordre et retient la première qui reconnaît les identifiants
+type Chain []Authenticator
+
+// Authenticate implémente Authenticator. Si aucune source ne reconnaît les identifiants,
+// l'erreur d'une source indisponible est préférée à ErrInvalidCredentials.
+func (chain Chain) Authenticate(ctx context.Context, email, password string) (int, error) {
+	failure := ErrInvalidCredentials
+	for _, source := range chain {
+		userID, err := source.Authenticate(ctx, email, password)
+		if err == nil {
+			return userID, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			slog.Error(common.LogAuthenticatorError + " - " + err.Error())
+			failure = err
+		}
+	}
+	return 0, failure
+}
+
+// New construit la source d'identifiants correspondant à la configuration :
+// l'annuaire LDAP s'il est configuré, puis la base locale (comptes locaux et administrateurs de secours)
+func New(cfg common.LDAPConfig) (Authenticator, error) {
+	if cfg.URL == "" {
+		return Local{}, nil
+	}
+	directory, err := NewLDAP(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("LDAP : %w", err)
+	}
+	return Chain{directory, Local{}}, nil
+}
+
+// Init initialise la source d'identifiants par défaut de l'application
+func Init(cfg common.LDAPConfig) error {
+	source, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Default = source
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package authenticator internal/authenticator/ldap.go
+package authenticator
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ldapTimeout est le délai maximal d'une authentification auprès de l'annuaire
+const ldapTimeout = 10 * time.Second
+
+// LDAP authentifie les utilisateurs auprès d'un annuaire : recherche de l'entrée par le compte de service,
+// puis liaison avec le DN trouvé et le mot de passe saisi. Le compte est créé à la première connexion
+// si AutoProvision est activé ; les groupes de l'entrée sont traduits en rôles.
+type LDAP struct {
+	Config common.LDAPConfig
+	// TLSConfig est la configuration TLS de ldaps:// et StartTLS ; nil : autorités du système
+	TLSConfig *tls.Config
+}
+
+// NewLDAP valide la configuration de l'annuaire
+func NewLDAP(cfg common.LDAPConfig) (*LDAP, error) {
+	address, err := url.Parse(cfg.URL)
+	if err != nil || address.Hostname() == "" || (address.Scheme != "ldap" && address.Scheme != "ldaps") {
+		return nil, fmt.Errorf("LDAP_URL invalide : %q", cfg.URL)
+	}
+	// Les mots de passe sont transmis en clair lors de la liaison : la connexion doit être chiffrée
+	if address.Scheme == "ldap" && !cfg.StartTLS && !isLoopback(address.Hostname()) {
+		return nil, fmt.Errorf("LDAP_URL %q : ldaps:// ou LDAP_START_TLS est obligatoire hors de localhost", cfg.URL)
+	}
+	if address.Scheme == "ldaps" && cfg.StartTLS {
+		return nil, errors.New("LDAP_START_TLS ne s'applique pas à ldaps://")
+	}
+	if cfg.BaseDN == "" || cfg.LoginAttribute == "" {
+		return nil, errors.New("LDAP_BASE_DN et LDAP_LOGIN_ATTRIBUTE sont obligatoires")
+	}
+	if (cfg.BindDN == "") != (cfg.BindPassword == "") {
+		return nil, errors.New("LDAP_BIND_DN et LDAP_BIND_PASSWORD vont de pair")
+	}
+	return &LDAP{Config: cfg}, nil
+}
+
+// Authenticate implémente Authenticator
+func (l *LDAP) Authenticate(ctx context.Context, email, password string) (int, error) {
+	identity, roles, err := l.Verify(ctx, email, password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := common.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := FindOrProvision(tx, identity, l.Config.AutoProvision)
+	if errors.Is(err, ErrAccountNotFound) {
+		// L'utilisateur de l'annuaire sans compte n'est pas distingué d'identifiants incorrects
+		return 0, ErrInvalidCredentials
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := GrantRoles(tx, userID, roles); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Verify vérifie les identifiants auprès de l'annuaire et retourne l'identité de l'utilisateur
+// et les rôles de l'application correspondant à ses groupes
+func (l *LDAP) Verify(ctx context.Context, email, password string) (Identity, []string, error) {
+	// Une liaison avec un mot de passe vide est anonyme et réussit sans rien vérifier (RFC 4513, 5.1.2)
+	if email == "" || password == "" {
+		return Identity{}, nil, ErrInvalidCredentials
+	}
+
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return Identity{}, nil, fmt.Errorf("%w : %v", ErrUnavailable, err)
+	}
+	defer conn.close()
+
+	if l.Config.BindDN != "" {
+		if err := conn.bind(l.Config.BindDN, l.Config.BindPassword); err != nil {
+			return Identity{}, nil, fmt.Errorf("%w : liaison du compte de service : %v", ErrUnavailable, err)
+		}
+	}
+	filter := equalityFilter(l.Config.LoginAttribute, email)
+	if l.Config.UserObjectClass != "" {
+		filter = andFilter(equalityFilter("objectClass", l.Config.UserObjectClass), filter)
+	}
+	attributes := []string{"mail", "givenName", "sn", "cn"}
+	if l.Config.GroupAttribute != "" {
+		attributes = append(attributes, l.Config.GroupAttribute)
+	}
+	entries, err := conn.search(l.Config.BaseDN, filter, 2, attributes)
+	if err != nil {
+		return Identity{}, nil, fmt.Errorf("%w : recherche de l'utilisateur : %v", ErrUnavailable, err)
+	}
+	// Une adresse partagée par plusieurs entrées ne désigne personne
+	if len(entries) != 1 {
+		if len(entries) > 1 {
+			slog.Warn(common.LogLDAPLogin+" - plusieurs entrées pour l'identifiant", "entries", len(entries))
+		}
+		return Identity{}, nil, ErrInvalidCredentials
+	}
+	entry := entries[0]
+
+	if err := conn.bind(entry.DN, password); errors.Is(err, errLDAPInvalidCredentials) {
+		return Identity{}, nil, ErrInvalidCredentials
+	} else if err != nil {
+		return Identity{}, nil, fmt.Errorf("%w : liaison de l'utilisateur : %v", ErrUnavailable, err)
+	}
+
+	identity := Identity{
+		Email:         entry.first("mail"),
+		Firstname:     entry.first("givenName"),
+		Lastname:      entry.first("sn"),
+		EmailVerified: true,
+	}
+	if identity.Email == "" {
+		identity.Email = email
+	}
+	if identity.Firstname == "" && identity.Lastname == "" {
+		identity.Firstname = entry.first("cn")
+	}
+	return identity, l.roles(entry), nil
+}
+
+// roles traduit les groupes de l'entrée en rôles de l'application ; les DN sont comparés sans tenir compte de la casse
+func (l *LDAP) roles(entry ldapEntry) []string {
+	if l.Config.GroupAttribute == "" {
+		return nil
+	}
+	var names []string
+	for _, group := range entry.Attributes[strings.ToLower(l.Config.GroupAttribute)] {
+		for dn, role := range l.Config.RoleMapping {
+			if strings.EqualFold(strings.TrimSpace(group), dn) {
+				names = append(names, role)
+			}
+		}
+	}
+	return names
+}
+
+// dial ouvre une connexion chiffrée (ldaps:// ou StartTLS) à l'annuaire, bornée par ldapTimeout
+func (l *LDAP) dial(ctx context.Context) (*ldapConn, error) {
+	address, err := url.Parse(l.Config.URL)
+	if err != nil {
+		return nil, err
+	}
+	host, port := address.Hostname(), address.Port()
+	if port == "" {
+		port = "389"
+		if address.Scheme == "ldaps" {
+			port = "636"
+		}
+	}
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if l.TLSConfig != nil {
+		tlsConfig = l.TLSConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ldapTimeout)
+	defer cancel()
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if address.Scheme == "ldaps" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	}
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c := newLDAPConn(conn)
+	if l.Config.StartTLS {
+		if err := c.startTLS(ctx, tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		c.conn.SetDeadline(deadline)
+	}
+	return c, nil
+}
+
+// isLoopback indique si l'hôte désigne la machine locale
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,143 @@
+package authenticator_test
+
+import (
+	"context"
+	"go-averroes/internal/authenticator"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newDirectory démarre un annuaire de test peuplé d'un compte de service et de deux utilisateurs
+func newDirectory(t *testing.T) (*testutils.MockLDAP, common.LDAPConfig) {
+	directory, err := testutils.NewMockLDAP()
+	require.NoError(t, err)
+	t.Cleanup(directory.Close)
+
+	directory.Add(testutils.MockLDAPEntry{DN: "cn=golendar,ou=services,dc=example,dc=com", Password: "service"})
+	directory.Add(testutils.MockLDAPEntry{
+		DN:       "uid=nour,ou=people,dc=example,dc=com",
+		Password: "secret-nour",
+		Attributes: map[string][]string{
+			"objectClass": {"top", "person", "inetOrgPerson"},
+			"mail":        {"nour.haddad@example.com"},
+			"givenName":   {"Nour"},
+			"sn":          {"Haddad"},
+			"memberOf":    {"CN=Planning-Editors,OU=Groups,DC=example,DC=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		},
+	})
+	directory.Add(testutils.MockLDAPEntry{
+		DN:       "uid=sacha,ou=people,dc=example,dc=com",
+		Password: "secret-sacha",
+		Attributes: map[string][]string{
+			"objectClass": {"person"},
+			"mail":        {"sacha@example.com"},
+			"cn":          {"Sacha"},
+		},
+	})
+
+	return directory, common.LDAPConfig{
+		URL:             directory.URL(),
+		BindDN:          "cn=golendar,ou=services,dc=example,dc=com",
+		BindPassword:    "service",
+		BaseDN:          "ou=people,dc=example,dc=com",
+		LoginAttribute:  "mail",
+		UserObjectClass: "person",
+		GroupAttribute:  "memberOf",
+		RoleMapping: map[string]string{
+			"cn=planning-editors,ou=groups,dc=example,dc=com": "editor",
+			"cn=planning-admins,ou=groups,dc=example,dc=com":  "admin",
+		},
+	}
+}
+
+// TestLDAPVerify vérifie la recherche de l'utilisateur, la liaison avec son mot de passe et la traduction des groupes
+func TestLDAPVerify(t *testing.T) {
+	_, cfg := newDirectory(t)
+	directory, err := authenticator.NewLDAP(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	identity, roles, err := directory.Verify(ctx, "nour.haddad@example.com", "secret-nour")
+	require.NoError(t, err)
+	require.Equal(t, authenticator.Identity{Email: "nour.haddad@example.com", Firstname: "Nour", Lastname: "Haddad", EmailVerified: true}, identity)
+	// Les DN sont comparés sans la casse ; les groupes sans correspondance sont ignorés
+	require.Equal(t, []string{"editor"}, roles)
+
+	// Sans prénom ni nom, le nom commun tient lieu de prénom
+	identity, roles, err = directory.Verify(ctx, "SACHA@example.com", "secret-sacha")
+	require.NoError(t, err)
+	require.Equal(t, "Sacha", identity.Firstname)
+	require.Equal(t, "sacha@example.com", identity.Email)
+	require.Empty(t, roles)
+
+	_, _, err = directory.Verify(ctx, "nour.haddad@example.com", "secret-sacha")
+	require.ErrorIs(t, err, authenticator.ErrInvalidCredentials)
+	_, _, err = directory.Verify(ctx, "inconnu@example.com", "secret-nour")
+	require.ErrorIs(t, err, authenticator.ErrInvalidCredentials)
+}
+
+// TestLDAPVerifyRejected vérifie les refus qui ne dépendent pas du mot de passe de l'utilisateur
+func TestLDAPVerifyRejected(t *testing.T) {
+	mock, cfg := newDirectory(t)
+	ctx := context.Background()
+
+	// Un mot de passe vide donnerait une liaison anonyme acceptée par l'annuaire : elle n'est pas tentée
+	directory, err := authenticator.NewLDAP(cfg)
+	require.NoError(t, err)
+	_, _, err = directory.Verify(ctx, "nour.haddad@example.com", "")
+	require.ErrorIs(t, err, authenticator.ErrInvalidCredentials)
+	require.NotContains(t, mock.Binds(), "uid=nour,ou=people,dc=example,dc=com")
+
+	// Une valeur de filtre LDAP n'est jamais interprétée
+	_, _, err = directory.Verify(ctx, "*", "secret-nour")
+	require.ErrorIs(t, err, authenticator.ErrInvalidCredentials)
+
+	// Une adresse partagée par plusieurs entrées ne désigne personne
+	mock.Add(testutils.MockLDAPEntry{
+		DN:         "uid=nour2,ou=people,dc=example,dc=com",
+		Password:   "secret-nour",
+		Attributes: map[string][]string{"objectClass": {"person"}, "mail": {"nour.haddad@example.com"}},
+	})
+	_, _, err = directory.Verify(ctx, "nour.haddad@example.com", "secret-nour")
+	require.ErrorIs(t, err, authenticator.ErrInvalidCredentials)
+
+	// Compte de service refusé : l'annuaire est considéré indisponible
+	cfg.BindPassword = "incorrect"
+	directory, err = authenticator.NewLDAP(cfg)
+	require.NoError(t, err)
+	_, _, err = directory.Verify(ctx, "sacha@example.com", "secret-sacha")
+	require.ErrorIs(t, err, authenticator.ErrUnavailable)
+
+	// Annuaire injoignable
+	mock.Close()
+	_, _, err = directory.Verify(ctx, "sacha@example.com", "secret-sacha")
+	require.ErrorIs(t, err, authenticator.ErrUnavailable)
+}
+
+// TestInit vérifie la validation de la configuration
+func TestInit(t *testing.T) {
+	require.NoError(t, authenticator.Init(common.LDAPConfig{}))
+	require.IsType(t, authenticator.Local{}, authenticator.Default)
+
+	valid := common.LDAPConfig{URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com", LoginAttribute: "mail"}
+	require.NoError(t, authenticator.Init(valid))
+	require.IsType(t, authenticator.Chain{}, authenticator.Default)
+
+	for name, cfg := range map[string]common.LDAPConfig{
+		"schéma inconnu":        {URL: "http://ldap.example.com", BaseDN: "dc=example,dc=com", LoginAttribute: "mail"},
+		"ldap:// non chiffré":   {URL: "ldap://ldap.example.com", BaseDN: "dc=example,dc=com", LoginAttribute: "mail"},
+		"sans base":             {URL: "ldaps://ldap.example.com", LoginAttribute: "mail"},
+		"compte de service":     {URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com", LoginAttribute: "mail", BindDN: "cn=golendar"},
+		"StartTLS sur ldaps://": {URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com", LoginAttribute: "mail", StartTLS: true},
+	} {
+		require.Error(t, authenticator.Init(cfg), name)
+	}
+
+	starttls := valid
+	starttls.URL, starttls.StartTLS = "ldap://ldap.example.com", true
+	require.NoError(t, authenticator.Init(starttls))
+	require.NoError(t, authenticator.Init(common.LDAPConfig{}))
+}
@@ -0,0 +1,399 @@
+// Package authenticator internal/authenticator/ldapconn.go
+// Client LDAPv3 minimal (RFC 4511) : liaison simple, recherche et StartTLS, messages encodés en BER.
+package authenticator
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Étiquettes BER et LDAP utilisées
+const (
+	berBoolean     = 0x01
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+
+	ldapBindRequest           = 0x60
+	ldapBindResponse          = 0x61
+	ldapUnbindRequest         = 0x42
+	ldapSearchRequest         = 0x63
+	ldapSearchResultEntry     = 0x64
+	ldapSearchResultDone      = 0x65
+	ldapSearchResultReference = 0x73
+	ldapExtendedRequest       = 0x77
+	ldapExtendedResponse      = 0x78
+
+	ldapAuthSimple          = 0x80 // [0] de AuthenticationChoice
+	ldapExtendedName        = 0x80 // [0] de ExtendedRequest
+	ldapFilterAnd           = 0xa0
+	ldapFilterEqualityMatch = 0xa3
+
+	ldapScopeWholeSubtree  = 2
+	ldapNeverDerefAliases  = 0
+	ldapResultSuccess      = 0
+	ldapResultSizeLimit    = 4
+	ldapInvalidCredentials = 49
+
+	// ldapStartTLSOID est l'opération étendue StartTLS (RFC 4511, 4.14)
+	ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+	// maxLDAPMessageSize est la taille maximale d'un message lu
+	maxLDAPMessageSize = 1 << 20
+)
+
+var (
+	// errLDAPProtocol est retournée pour une réponse mal formée ou inattendue
+	errLDAPProtocol = errors.New("réponse LDAP invalide")
+	// errLDAPInvalidCredentials est retournée par bind quand l'annuaire refuse le mot de passe
+	errLDAPInvalidCredentials = errors.New("identifiants refusés par l'annuaire")
+)
+
+// ldapEntry est une entrée retournée par une recherche ; les noms d'attributs sont en minuscules
+type ldapEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// first retourne la première valeur de l'attribut, vide s'il est absent
+func (e ldapEntry) first(attribute string) string {
+	if values := e.Attributes[strings.ToLower(attribute)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ldapConn est une connexion à l'annuaire ; les requêtes sont traitées l'une après l'autre
+type ldapConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	messageID int
+}
+
+// newLDAPConn prend en charge une connexion établie
+func newLDAPConn(conn net.Conn) *ldapConn {
+	return &ldapConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// close envoie la demande de déconnexion et ferme la connexion
+func (c *ldapConn) close() {
+	c.request([]byte{ldapUnbindRequest, 0})
+	c.conn.Close()
+}
+
+// startTLS chiffre la connexion (RFC 4513, 3)
+func (c *ldapConn) startTLS(ctx context.Context, config *tls.Config) error {
+	id, err := c.request(berEncode(ldapExtendedRequest, berString(ldapExtendedName, ldapStartTLSOID)))
+	if err != nil {
+		return err
+	}
+	op, err := c.response(id, ldapExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if code, message, err := ldapResult(op); err != nil || code != ldapResultSuccess {
+		return fmt.Errorf("StartTLS refusé (%d %s)", code, message)
+	}
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// bind effectue une liaison simple ; errLDAPInvalidCredentials si l'annuaire refuse le mot de passe
+func (c *ldapConn) bind(dn, password string) error {
+	id, err := c.request(berEncode(ldapBindRequest,
+		berInt(berInteger, 3),
+		berString(berOctetString, dn),
+		berString(ldapAuthSimple, password),
+	))
+	if err != nil {
+		return err
+	}
+	op, err := c.response(id, ldapBindResponse)
+	if err != nil {
+		return err
+	}
+	code, message, err := ldapResult(op)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case ldapResultSuccess:
+		return nil
+	case ldapInvalidCredentials:
+		return errLDAPInvalidCredentials
+	}
+	return fmt.Errorf("liaison refusée (%d %s)", code, message)
+}
+
+// search recherche les entrées de la branche correspondant au filtre, au plus sizeLimit.
+// Au-delà, les entrées déjà reçues sont retournées sans erreur.
+func (c *ldapConn) search(baseDN string, filter []byte, sizeLimit int, attributes []string) ([]ldapEntry, error) {
+	var selection [][]byte
+	for _, attribute := range attributes {
+		selection = append(selection, berString(berOctetString, attribute))
+	}
+	id, err := c.request(berEncode(ldapSearchRequest,
+		berString(berOctetString, baseDN),
+		berInt(berEnumerated, ldapScopeWholeSubtree),
+		berInt(berEnumerated, ldapNeverDerefAliases),
+		berInt(berInteger, sizeLimit),
+		berInt(berInteger, int(ldapTimeout.Seconds())),
+		berEncode(berBoolean, []byte{0}),
+		filter,
+		berEncode(berSequence, selection...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ldapEntry
+	for {
+		op, err := c.response(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case ldapSearchResultEntry:
+			entry, err := parseEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case ldapSearchResultReference:
+			// Les renvois vers d'autres annuaires ne sont pas suivis
+		case ldapSearchResultDone:
+			code, message, err := ldapResult(op)
+			if err != nil {
+				return nil, err
+			}
+			if code != ldapResultSuccess && code != ldapResultSizeLimit {
+				return nil, fmt.Errorf("recherche refusée (%d %s)", code, message)
+			}
+			return entries, nil
+		default:
+			return nil, errLDAPProtocol
+		}
+	}
+}
+
+// request envoie une opération et retourne l'identifiant de son message
+func (c *ldapConn) request(op []byte) (int, error) {
+	c.messageID++
+	_, err := c.conn.Write(berEncode(berSequence, berInt(berInteger, c.messageID), op))
+	return c.messageID, err
+}
+
+// response lit la réponse suivante à la requête id ; tag, s'il n'est pas nul, est l'opération attendue
+func (c *ldapConn) response(id int, tag byte) (berElement, error) {
+	message, err := readBER(c.reader)
+	if err != nil {
+		return berElement{}, err
+	}
+	parts, err := message.children()
+	if err != nil || message.tag != berSequence || len(parts) < 2 {
+		return berElement{}, errLDAPProtocol
+	}
+	// L'identifiant 0 est celui des notifications spontanées (déconnexion annoncée par l'annuaire)
+	if messageID, err := parts[0].int(); err != nil || messageID != id {
+		return berElement{}, errLDAPProtocol
+	}
+	if tag != 0 && parts[1].tag != tag {
+		return berElement{}, errLDAPProtocol
+	}
+	return parts[1], nil
+}
+
+// ldapResult décode le résultat d'une opération : code et message de diagnostic
+func ldapResult(op berElement) (int, string, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 {
+		return 0, "", errLDAPProtocol
+	}
+	code, err := parts[0].int()
+	if err != nil {
+		return 0, "", errLDAPProtocol
+	}
+	return code, string(parts[2].content), nil
+}
+
+// parseEntry décode une entrée SearchResultEntry
+func parseEntry(op berElement) (ldapEntry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) != 2 {
+		return ldapEntry{}, errLDAPProtocol
+	}
+	attributes, err := parts[1].children()
+	if err != nil {
+		return ldapEntry{}, errLDAPProtocol
+	}
+	entry := ldapEntry{DN: string(parts[0].content), Attributes: map[string][]string{}}
+	for _, attribute := range attributes {
+		fields, err := attribute.children()
+		if err != nil || len(fields) != 2 {
+			return ldapEntry{}, errLDAPProtocol
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return ldapEntry{}, errLDAPProtocol
+		}
+		name := strings.ToLower(string(fields[0].content))
+		for _, value := range values {
+			entry.Attributes[name] = append(entry.Attributes[name], string(value.content))
+		}
+	}
+	return entry, nil
+}
+
+// equalityFilter construit le filtre (attribute=value). Le filtre est encodé directement en BER :
+// la valeur n'est jamais interprétée, aucune injection de filtre n'est possible.
+func equalityFilter(attribute, value string) []byte {
+	return berEncode(ldapFilterEqualityMatch, berString(berOctetString, attribute), berString(berOctetString, value))
+}
+
+// andFilter construit le filtre (&...)
+func andFilter(filters ...[]byte) []byte {
+	return berEncode(ldapFilterAnd, filters...)
+}
+
+// berElement est un élément BER décodé
+type berElement struct {
+	tag     byte
+	content []byte
+}
+
+// children décode les éléments d'un élément construit
+func (e berElement) children() ([]berElement, error) {
+	var elements []berElement
+	data := e.content
+	for len(data) > 0 {
+		element, rest, err := parseBER(data)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		data = rest
+	}
+	return elements, nil
+}
+
+// int décode un entier ou une énumération
+func (e berElement) int() (int, error) {
+	if len(e.content) == 0 || len(e.content) > 4 {
+		return 0, errLDAPProtocol
+	}
+	value := int(int8(e.content[0]))
+	for _, b := range e.content[1:] {
+		value = value<<8 | int(b)
+	}
+	return value, nil
+}
+
+// berEncode encode un élément dont le contenu est la concaténation des parties
+func berEncode(tag byte, parts ...[]byte) []byte {
+	var content []byte
+	for _, part := range parts {
+		content = append(content, part...)
+	}
+	length := len(content)
+	encoded := []byte{tag}
+	switch {
+	case length < 0x80:
+		encoded = append(encoded, byte(length))
+	case length <= 0xff:
+		encoded = append(encoded, 0x81, byte(length))
+	case length <= 0xffff:
+		encoded = append(encoded, 0x82, byte(length>>8), byte(length))
+	default:
+		encoded = append(encoded, 0x83, byte(length>>16), byte(length>>8), byte(length))
+	}
+	return append(encoded, content...)
+}
+
+// berInt encode un entier positif
+func berInt(tag byte, value int) []byte {
+	var content []byte
+	for {
+		content = append([]byte{byte(value)}, content...)
+		value >>= 8
+		if value == 0 {
+			break
+		}
+	}
+	if content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+	return berEncode(tag, content)
+}
+
+// berString encode une chaîne
+func berString(tag byte, value string) []byte {
+	return berEncode(tag, []byte(value))
+}
+
+// parseBER décode le premier élément de data ; seules les longueurs définies sont acceptées (RFC 4511, 5.1)
+func parseBER(data []byte) (berElement, []byte, error) {
+	if len(data) < 2 || data[0]&0x1f == 0x1f {
+		return berElement{}, nil, errLDAPProtocol
+	}
+	length, header := int(data[1]), 2
+	if data[1] >= 0x80 {
+		size := int(data[1] & 0x7f)
+		if size == 0 || size > 3 || len(data) < 2+size {
+			return berElement{}, nil, errLDAPProtocol
+		}
+		length = 0
+		for _, b := range data[2 : 2+size] {
+			length = length<<8 | int(b)
+		}
+		header += size
+	}
+	if len(data) < header+length {
+		return berElement{}, nil, errLDAPProtocol
+	}
+	return berElement{tag: data[0], content: data[header : header+length]}, data[header+length:], nil
+}
+
+// readBER lit un élément complet sur la connexion
+func readBER(reader *bufio.Reader) (berElement, error) {
+	header := make([]byte, 2, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return berElement{}, err
+	}
+	if header[0]&0x1f == 0x1f {
+		return berElement{}, errLDAPProtocol
+	}
+	length := int(header[1])
+	if header[1] >= 0x80 {
+		size := int(header[1] & 0x7f)
+		if size == 0 || size > 3 {
+			return berElement{}, errLDAPProtocol
+		}
+		header = header[:2+size]
+		if _, err := io.ReadFull(reader, header[2:]); err != nil {
+			return berElement{}, err
+		}
+		length = 0
+		for _, b := range header[2:] {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxLDAPMessageSize {
+		return berElement{}, errLDAPProtocol
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return berElement{}, err
+	}
+	return berElement{tag: header[0], content: content}, nil
+}
@@ -0,0 +1,40 @@
+// Package authenticator internal/authenticator/local.go
+package authenticator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"log/slog"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Local vérifie le mot de passe enregistré dans la table user_password
+type Local struct{}
+
+// Authenticate implémente Authenticator
+func (Local) Authenticate(ctx context.Context, email, password string) (int, error) {
+	var userID int
+	var passwordHash string
+	err := common.DB.QueryRowContext(ctx, `
+		SELECT u.user_id, up.password_hash
+		FROM user u
+		INNER JOIN user_password up ON u.user_id = up.user_id
+		WHERE u.email = ? AND u.deleted_at IS NULL AND up.deleted_at IS NULL
+	`, email).Scan(&userID, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Error(common.ErrUserNotFound)
+		return 0, ErrInvalidCredentials
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		slog.Error(common.LogInvalidPassword)
+		return 0, ErrInvalidCredentials
+	}
+	return userID, nil
+}
@@ -0,0 +1,129 @@
+// Package authenticator internal/authenticator/provision.go
+package authenticator
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/accesstoken"
+	"go-averroes/internal/common"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxNameLength est la longueur maximale des colonnes firstname et lastname
+const maxNameLength = 100
+
+// ErrAccountNotFound est retournée par FindOrProvision pour une adresse sans compte actif, sans création automatique
+var ErrAccountNotFound = errors.New(common.ErrUserNotFound)
+
+// Identity est l'utilisateur décrit par une source d'identité externe (fournisseur SSO, annuaire)
+type Identity struct {
+	Email         string
+	Firstname     string
+	Lastname      string
+	EmailVerified bool // Adresse vérifiée par la source : vaut vérification pour l'application
+}
+
+// FindOrProvision retourne le compte associé à l'adresse de l'identité, ou le crée avec le rôle 'user'
+// si autoProvision est vrai. Un compte supprimé n'est ni réactivé ni recréé (l'adresse reste réservée).
+func FindOrProvision(tx *sql.Tx, identity Identity, autoProvision bool) (int, error) {
+	var userID int
+	var deletedAt *time.Time
+	err := tx.QueryRow(`SELECT user_id, deleted_at FROM user WHERE email = ?`, identity.Email).Scan(&userID, &deletedAt)
+	switch {
+	case err == nil && deletedAt != nil:
+		return 0, ErrAccountNotFound
+	case errors.Is(err, sql.ErrNoRows) && !autoProvision:
+		return 0, ErrAccountNotFound
+	case errors.Is(err, sql.ErrNoRows):
+		if userID, err = provision(tx, identity); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	}
+
+	if identity.EmailVerified {
+		result, err := tx.Exec(`
+			UPDATE user SET email_verified_at = NOW() WHERE user_id = ? AND email_verified_at IS NULL
+		`, userID)
+		if err != nil {
+			return 0, err
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			if err := accesstoken.ClaimsChanged(tx, userID); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return userID, nil
+}
+
+// provision crée le compte de l'identité, sans mot de passe : il se connecte uniquement par sa source d'identité
+func provision(tx *sql.Tx, identity Identity) (int, error) {
+	firstname := identity.Firstname
+	if firstname == "" && identity.Lastname == "" {
+		firstname, _, _ = strings.Cut(identity.Email, "@")
+	}
+	result, err := tx.Exec(`
+		INSERT INTO user (lastname, firstname, email, created_at)
+		VALUES (?, ?, ?, NOW())
+	`, truncate(identity.Lastname), truncate(firstname), identity.Email)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	userID := int(id)
+
+	if _, err := tx.Exec(`
+		INSERT INTO user_roles (user_id, role_id, created_at)
+		SELECT ?, role_id, NOW() FROM roles WHERE name = ? AND deleted_at IS NULL
+	`, userID, "user"); err != nil {
+		return 0, err
+	}
+	slog.Info(common.LogAccountProvision, "user_id", userID)
+	return userID, nil
+}
+
+// GrantRoles attribue les rôles nommés à l'utilisateur ; les rôles inconnus de l'application sont ignorés.
+// Les rôles ne sont jamais retirés : la révocation reste une décision de l'administrateur de l'application.
+func GrantRoles(tx *sql.Tx, userID int, names []string) error {
+	granted := false
+	for _, name := range names {
+		var roleID int
+		err := tx.QueryRow(`SELECT role_id FROM roles WHERE name = ? AND deleted_at IS NULL`, name).Scan(&roleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Warn(common.LogUnknownRole, "role", name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id, created_at)
+			VALUES (?, ?, NOW())
+			ON DUPLICATE KEY UPDATE deleted_at = NULL
+		`, userID, roleID)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			granted = true
+		}
+	}
+	// Les tokens d'accès signés des autres sessions ne portent pas les nouveaux rôles
+	if granted {
+		return accesstoken.ClaimsChanged(tx, userID)
+	}
+	return nil
+}
+
+// truncate limite un nom à la longueur de la colonne
+func truncate(name string) string {
+	if utf8.RuneCountInString(name) <= maxNameLength {
+		return name
+	}
+	return string([]rune(name)[:maxNameLength])
+}
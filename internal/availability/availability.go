@@ -0,0 +1,233 @@
+// Package availability internal/availability/availability.go
+// Calcul des disponibilités à partir de plages hebdomadaires et de créneaux occupés, sans accès à la base.
+package availability
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	// Base des fuseaux horaires embarquée : les images sans /usr/share/zoneinfo restent utilisables
+	_ "time/tzdata"
+)
+
+// MinutesPerDay est la borne haute d'une heure de fin ("24:00")
+const MinutesPerDay = 24 * 60
+
+// ErrInvalidClock est retournée pour une heure qui n'est pas au format HH:MM
+var ErrInvalidClock = errors.New("heure invalide, attendu: HH:MM")
+
+// Rule est une plage hebdomadaire de disponibilité, exprimée en minutes depuis minuit
+// dans le fuseau horaire de son propriétaire
+type Rule struct {
+	Weekday     time.Weekday
+	StartMinute int
+	EndMinute   int
+}
+
+// Interval est un intervalle [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps indique si les deux intervalles se chevauchent (des intervalles contigus ne se chevauchent pas)
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// SlotOptions paramètre le découpage des plages en créneaux
+type SlotOptions struct {
+	Duration     time.Duration  // Durée d'un créneau
+	Step         time.Duration  // Écart entre deux débuts de créneaux (Duration si nul)
+	BufferBefore time.Duration  // Temps libre exigé avant le créneau
+	BufferAfter  time.Duration  // Temps libre exigé après le créneau
+	Earliest     time.Time      // Premier début accepté (inclus)
+	Latest       time.Time      // Dernier début accepté (exclu)
+	MaxPerDay    int            // Nombre maximal de réservations par jour (0 : illimité)
+	PerDay       map[string]int // Réservations existantes par jour (clé DayKey)
+	Location     *time.Location // Fuseau utilisé pour les jours de MaxPerDay
+}
+
+// ParseClock convertit une heure HH:MM (00:00 à 24:00) en minutes depuis minuit
+func ParseClock(value string) (int, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, ErrInvalidClock
+	}
+	hours, errHours := strconv.Atoi(value[:2])
+	minutes, errMinutes := strconv.Atoi(value[3:])
+	if errHours != nil || errMinutes != nil || hours < 0 || minutes < 0 || minutes > 59 {
+		return 0, ErrInvalidClock
+	}
+	total := hours*60 + minutes
+	if total > MinutesPerDay {
+		return 0, ErrInvalidClock
+	}
+	return total, nil
+}
+
+// FormatClock convertit des minutes depuis minuit en heure HH:MM
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ValidateRules vérifie que chaque plage a un jour valide et se termine après son début
+func ValidateRules(rules []Rule) error {
+	for _, rule := range rules {
+		if rule.Weekday < time.Sunday || rule.Weekday > time.Saturday {
+			return fmt.Errorf("jour de la semaine invalide : %d", rule.Weekday)
+		}
+		if rule.StartMinute < 0 || rule.EndMinute > MinutesPerDay || rule.StartMinute >= rule.EndMinute {
+			return fmt.Errorf("plage horaire invalide : %s-%s", FormatClock(rule.StartMinute), FormatClock(rule.EndMinute))
+		}
+	}
+	return nil
+}
+
+// DayKey retourne le jour (YYYY-MM-DD) de l'instant dans le fuseau donné
+func DayKey(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
+
+// Windows déroule les plages hebdomadaires en intervalles concrets qui chevauchent [from, to).
+// Les heures sont interprétées dans loc : une plage 09:00-17:00 reste 09:00-17:00 heure locale
+// de part et d'autre d'un changement d'heure. Les intervalles sont triés et fusionnés.
+func Windows(rules []Rule, loc *time.Location, from, to time.Time) []Interval {
+	var windows []Interval
+	period := Interval{Start: from, End: to}
+
+	first := from.In(loc)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -1)
+	for !day.After(to) {
+		for _, rule := range rules {
+			if rule.Weekday != day.Weekday() {
+				continue
+			}
+			window := Interval{
+				Start: time.Date(day.Year(), day.Month(), day.Day(), 0, rule.StartMinute, 0, 0, loc),
+				End:   time.Date(day.Year(), day.Month(), day.Day(), 0, rule.EndMinute, 0, 0, loc),
+			}
+			if window.Overlaps(period) {
+				windows = append(windows, window)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return Merge(windows)
+}
+
+// Merge trie les intervalles et fusionne ceux qui se chevauchent ou se touchent
+func Merge(intervals []Interval) []Interval {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sorted := append([]Interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []Interval{sorted[0]}
+	for _, interval := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if interval.Start.After(last.End) {
+			merged = append(merged, interval)
+			continue
+		}
+		if interval.End.After(last.End) {
+			last.End = interval.End
+		}
+	}
+	return merged
+}
+
+// Subtract retire des intervalles les portions occupées et retourne les portions restantes, triées
+func Subtract(intervals, busy []Interval) []Interval {
+	busy = Merge(busy)
+	var free []Interval
+	for _, interval := range Merge(intervals) {
+		cursor := interval.Start
+		for _, b := range busy {
+			if !b.End.After(cursor) || !b.Start.Before(interval.End) {
+				continue
+			}
+			if b.Start.After(cursor) {
+				free = append(free, Interval{Start: cursor, End: b.Start})
+			}
+			cursor = b.End
+		}
+		if cursor.Before(interval.End) {
+			free = append(free, Interval{Start: cursor, End: interval.End})
+		}
+	}
+	return free
+}
+
+// Intersect retourne les portions communes aux deux ensembles d'intervalles, triées
+func Intersect(a, b []Interval) []Interval {
+	a, b = Merge(a), Merge(b)
+	var shared []Interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].Start, a[i].End
+		if b[j].Start.After(start) {
+			start = b[j].Start
+		}
+		if b[j].End.Before(end) {
+			end = b[j].End
+		}
+		if start.Before(end) {
+			shared = append(shared, Interval{Start: start, End: end})
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return shared
+}
+
+// Slots découpe les plages en créneaux de opts.Duration qui tiennent entièrement dans une plage,
+// commencent dans [opts.Earliest, opts.Latest) et ne chevauchent aucun créneau occupé,
+// tampons compris. Les jours ayant atteint opts.MaxPerDay réservations sont exclus.
+func Slots(windows, busy []Interval, opts SlotOptions) []Interval {
+	step := opts.Step
+	if step <= 0 {
+		step = opts.Duration
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	slots := []Interval{}
+	for _, window := range Merge(windows) {
+		for start := window.Start; !start.Add(opts.Duration).After(window.End); start = start.Add(step) {
+			if start.Before(opts.Earliest) {
+				continue
+			}
+			if !opts.Latest.IsZero() && !start.Before(opts.Latest) {
+				break
+			}
+			if opts.MaxPerDay > 0 && opts.PerDay[DayKey(start, loc)] >= opts.MaxPerDay {
+				continue
+			}
+			slot := Interval{Start: start, End: start.Add(opts.Duration)}
+			padded := Interval{Start: slot.Start.Add(-opts.BufferBefore), End: slot.End.Add(opts.BufferAfter)}
+			if overlapsAny(padded, busy) {
+				continue
+			}
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// overlapsAny indique si l'intervalle chevauche l'un des intervalles occupés
+func overlapsAny(interval Interval, busy []Interval) bool {
+	for _, b := range busy {
+		if interval.Overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
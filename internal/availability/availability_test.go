@@ -0,0 +1,160 @@
+package availability_test
+
+import (
+	"go-averroes/internal/availability"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// starts retourne les débuts des créneaux, au format "MM-DD HH:MM" dans le fuseau donné
+func starts(slots []availability.Interval, loc *time.Location) []string {
+	result := []string{}
+	for _, slot := range slots {
+		result = append(result, slot.Start.In(loc).Format("01-02 15:04"))
+	}
+	return result
+}
+
+// TestParseClock teste la lecture des heures HH:MM avec plusieurs cas
+func TestParseClock(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName        string
+		Value           string
+		ExpectedMinutes int
+		ExpectedError   bool
+	}{
+		{CaseName: "Heure du matin", Value: "09:30", ExpectedMinutes: 570},
+		{CaseName: "Minuit en fin de journée", Value: "24:00", ExpectedMinutes: 1440},
+		{CaseName: "Échec au-delà de 24:00", Value: "24:30", ExpectedError: true},
+		{CaseName: "Échec avec des minutes invalides", Value: "10:75", ExpectedError: true},
+		{CaseName: "Échec sans zéro initial", Value: "9:30", ExpectedError: true},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			minutes, err := availability.ParseClock(testCase.Value)
+			if testCase.ExpectedError {
+				require.ErrorIs(t, err, availability.ErrInvalidClock)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.ExpectedMinutes, minutes)
+			require.Equal(t, testCase.Value, availability.FormatClock(minutes))
+		})
+	}
+}
+
+// TestWindowsAcrossDST vérifie que les plages restent en heure locale lors du passage à l'heure d'été
+func TestWindowsAcrossDST(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	rules := []availability.Rule{
+		{Weekday: time.Saturday, StartMinute: 9 * 60, EndMinute: 12 * 60},
+		{Weekday: time.Monday, StartMinute: 9 * 60, EndMinute: 12 * 60},
+	}
+
+	// Passage à l'heure d'été dans la nuit du 29 au 30 mars 2025
+	windows := availability.Windows(rules, paris,
+		time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, windows, 2)
+	require.Equal(t, time.Date(2025, 3, 29, 8, 0, 0, 0, time.UTC), windows[0].Start.UTC())
+	require.Equal(t, time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC), windows[1].Start.UTC())
+	require.Equal(t, "09:00", windows[1].Start.In(paris).Format("15:04"))
+}
+
+// TestSlots vérifie le découpage en créneaux avec tampons, préavis et maximum par jour
+func TestSlots(t *testing.T) {
+	monday := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	rules := []availability.Rule{
+		{Weekday: time.Monday, StartMinute: 9 * 60, EndMinute: 12 * 60},
+		{Weekday: time.Tuesday, StartMinute: 9 * 60, EndMinute: 11 * 60},
+	}
+	windows := availability.Windows(rules, time.UTC, monday, monday.AddDate(0, 0, 2))
+	busy := []availability.Interval{
+		// Réunion de 10:30 à 10:50 le lundi
+		{Start: monday.Add(10*time.Hour + 30*time.Minute), End: monday.Add(10*time.Hour + 50*time.Minute)},
+	}
+
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName       string
+		Options        availability.SlotOptions
+		ExpectedStarts []string
+	}{
+		{
+			CaseName: "Créneaux d'une heure sans tampon",
+			Options:  availability.SlotOptions{Duration: time.Hour, Earliest: monday},
+			ExpectedStarts: []string{
+				"06-02 09:00", "06-02 11:00",
+				"06-03 09:00", "06-03 10:00",
+			},
+		},
+		{
+			CaseName: "Tampon de 15 minutes après le créneau",
+			Options:  availability.SlotOptions{Duration: 30 * time.Minute, BufferAfter: 15 * time.Minute, Earliest: monday, Latest: monday.AddDate(0, 0, 1)},
+			ExpectedStarts: []string{
+				"06-02 09:00", "06-02 09:30", "06-02 11:00", "06-02 11:30",
+			},
+		},
+		{
+			CaseName: "Tampon de 15 minutes avant le créneau",
+			Options:  availability.SlotOptions{Duration: 30 * time.Minute, BufferBefore: 15 * time.Minute, Earliest: monday, Latest: monday.AddDate(0, 0, 1)},
+			ExpectedStarts: []string{
+				"06-02 09:00", "06-02 09:30", "06-02 10:00", "06-02 11:30",
+			},
+		},
+		{
+			CaseName: "Préavis minimum",
+			Options:  availability.SlotOptions{Duration: time.Hour, Earliest: monday.Add(9*time.Hour + time.Minute)},
+			ExpectedStarts: []string{
+				"06-02 11:00",
+				"06-03 09:00", "06-03 10:00",
+			},
+		},
+		{
+			CaseName: "Maximum par jour atteint le lundi",
+			Options: availability.SlotOptions{
+				Duration:  time.Hour,
+				Earliest:  monday,
+				MaxPerDay: 1,
+				PerDay:    map[string]int{"2025-06-02": 1},
+			},
+			ExpectedStarts: []string{"06-03 09:00", "06-03 10:00"},
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			slots := availability.Slots(windows, busy, testCase.Options)
+			require.Equal(t, testCase.ExpectedStarts, starts(slots, time.UTC))
+		})
+	}
+}
+
+// TestSubtractAndIntersect vérifie le calcul des portions libres et communes
+func TestSubtractAndIntersect(t *testing.T) {
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	span := func(h1, m1, h2, m2 int) availability.Interval {
+		return availability.Interval{Start: at(h1, m1), End: at(h2, m2)}
+	}
+
+	// Journée 09:00-17:00 avec deux réunions, dont une qui déborde avant le début
+	free := availability.Subtract(
+		[]availability.Interval{span(9, 0, 17, 0)},
+		[]availability.Interval{span(8, 0, 9, 30), span(12, 0, 13, 0)},
+	)
+	require.Equal(t, []availability.Interval{span(9, 30, 12, 0), span(13, 0, 17, 0)}, free)
+
+	// Les portions communes avec une personne disponible de 11:00 à 14:00
+	shared := availability.Intersect(free, []availability.Interval{span(11, 0, 14, 0)})
+	require.Equal(t, []availability.Interval{span(11, 0, 12, 0), span(13, 0, 14, 0)}, shared)
+
+	require.Empty(t, availability.Intersect(free, nil))
+}
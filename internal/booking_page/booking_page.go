@@ -0,0 +1,1104 @@
+// Package booking_page internal/booking_page/booking_page.go
+package booking_page
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/availability"
+	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/common"
+	"go-averroes/internal/user_availability"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookingPageStruct struct{}
+
+var BookingPage = BookingPageStruct{}
+
+const (
+	// defaultMaxAdvanceDays est l'horizon de réservation d'une page créée sans max_advance_days
+	defaultMaxAdvanceDays = 60
+	// maxSlotsDays borne la période interrogée par Slots
+	maxSlotsDays = 62
+)
+
+// queryer est implémenté par *sql.DB et *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// List récupère les pages de réservation de l'utilisateur connecté
+// @Summary Lister mes pages de réservation
+// @Description Récupère les pages publiques de prise de rendez-vous de l'utilisateur connecté, avec leurs plages de disponibilité
+// @Tags Page de réservation
+// @Produce json
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /booking-pages [get]
+func (BookingPageStruct) List(c *gin.Context) {
+	slog.Info(common.LogBookingPageList)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	rows, err := common.DB.Query(`
+		SELECT booking_page_id, user_id, calendar_id, slug, title, description, duration, buffer_before, buffer_after,
+		       max_per_day, min_notice, max_advance_days, timezone, active, created_at, updated_at, deleted_at
+		FROM booking_page
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY created_at, booking_page_id
+	`, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogBookingPageList + " - erreur lors de la récupération des pages : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageRetrieval,
+		})
+		return
+	}
+
+	pages := []common.BookingPage{}
+	for rows.Next() {
+		var page common.BookingPage
+		if err := rows.Scan(&page.BookingPageID, &page.UserID, &page.CalendarID, &page.Slug, &page.Title, &page.Description,
+			&page.Duration, &page.BufferBefore, &page.BufferAfter, &page.MaxPerDay, &page.MinNotice, &page.MaxAdvanceDays,
+			&page.Timezone, &page.Active, &page.CreatedAt, &page.UpdatedAt, &page.DeletedAt); err != nil {
+			rows.Close()
+			slog.Error(common.LogBookingPageList + " - erreur lors de la lecture des pages : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingPageRetrieval,
+			})
+			return
+		}
+		pages = append(pages, page)
+	}
+	rows.Close()
+
+	for i := range pages {
+		rules, err := loadRules(common.DB, pages[i].BookingPageID)
+		if err != nil {
+			slog.Error(common.LogBookingPageList + " - erreur lors de la récupération des plages : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingPageRetrieval,
+			})
+			return
+		}
+		pages[i].Rules = rules
+	}
+
+	slog.Info(common.LogBookingPageList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListBookingPages,
+		Data:    pages,
+	})
+}
+
+// Get récupère une page de réservation de l'utilisateur connecté
+// @Summary Récupérer une page de réservation
+// @Description Récupère une page de réservation de l'utilisateur connecté avec ses plages de disponibilité
+// @Tags Page de réservation
+// @Produce json
+// @Param booking_page_id path int true "ID de la page de réservation"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking-pages/{booking_page_id} [get]
+func (BookingPageStruct) Get(c *gin.Context) {
+	slog.Info(common.LogBookingPageGet)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	rules, err := loadRules(common.DB, page.BookingPageID)
+	if err != nil {
+		slog.Error(common.LogBookingPageGet + " - erreur lors de la récupération des plages : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageRetrieval,
+		})
+		return
+	}
+	page.Rules = rules
+
+	slog.Info(common.LogBookingPageGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetBookingPage,
+		Data:    page,
+	})
+}
+
+// Add crée une page de réservation
+// @Summary Créer une page de réservation
+// @Description Publie une page de prise de rendez-vous. Les visiteurs réservent sans authentification les créneaux libres des plages hebdomadaires ; chaque rendez-vous crée un événement dans le calendrier choisi. Les événements de tous les calendriers de l'utilisateur rendent le créneau indisponible. Durées, tampons et préavis sont en minutes.
+// @Tags Page de réservation
+// @Accept json
+// @Produce json
+// @Param booking_page body common.CreateBookingPageRequest true "Données de la page de réservation"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking-pages [post]
+func (BookingPageStruct) Add(c *gin.Context) {
+	slog.Info(common.LogBookingPageAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateBookingPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogBookingPageAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if req.MaxAdvanceDays == 0 {
+		req.MaxAdvanceDays = defaultMaxAdvanceDays
+	}
+	if msg := validatePage(req.Timezone, req.Rules); msg != "" {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	slug, err := common.GenerateToken(12)
+	if err != nil {
+		slog.Error(common.LogBookingPageAdd + " - erreur lors de la génération de l'identifiant public : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageCreation,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogBookingPageAdd + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Les rendez-vous sont créés dans ce calendrier : l'utilisateur doit y avoir accès
+	if err := calendar_event.CheckCalendarAccess(tx, userData.UserID, req.CalendarID); err != nil {
+		status, msg := calendar_event.ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO booking_page (user_id, calendar_id, slug, title, description, duration, buffer_before, buffer_after,
+		                          max_per_day, min_notice, max_advance_days, timezone, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, TRUE, NOW())
+	`, userData.UserID, req.CalendarID, slug, req.Title, req.Description, req.Duration, req.BufferBefore, req.BufferAfter,
+		req.MaxPerDay, req.MinNotice, req.MaxAdvanceDays, req.Timezone)
+	if err != nil {
+		slog.Error(common.LogBookingPageAdd + " - erreur lors de la création de la page : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageCreation,
+		})
+		return
+	}
+	pageID, _ := result.LastInsertId()
+
+	if err := insertRules(tx, int(pageID), req.Rules); err != nil {
+		slog.Error(common.LogBookingPageAdd + " - erreur lors de l'ajout des plages : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageCreation,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogBookingPageAdd + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogBookingPageAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateBookingPage,
+		Data: gin.H{
+			"booking_page_id": pageID,
+			"slug":            slug,
+		},
+	})
+}
+
+// Update met à jour une page de réservation
+// @Summary Mettre à jour une page de réservation
+// @Description Met à jour une page de réservation ("max_per_day": 0 retire le maximum, "rules" remplace toutes les plages, "active": false dépublie la page). Les rendez-vous déjà pris sont conservés.
+// @Tags Page de réservation
+// @Accept json
+// @Produce json
+// @Param booking_page_id path int true "ID de la page de réservation"
+// @Param booking_page body common.UpdateBookingPageRequest true "Données de la page de réservation"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking-pages/{booking_page_id} [put]
+func (BookingPageStruct) Update(c *gin.Context) {
+	slog.Info(common.LogBookingPageUpdate)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateBookingPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogBookingPageUpdate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	timezone := page.Timezone
+	if req.Timezone != nil {
+		timezone = *req.Timezone
+	}
+	var rules []common.WeeklyRule
+	if req.Rules != nil {
+		rules = *req.Rules
+	}
+	if msg := validatePage(timezone, rules); msg != "" {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogBookingPageUpdate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if req.CalendarID != nil {
+		if err := calendar_event.CheckCalendarAccess(tx, page.UserID, *req.CalendarID); err != nil {
+			status, msg := calendar_event.ErrorResponse(err)
+			c.JSON(status, common.JSONResponse{
+				Success: false,
+				Error:   msg,
+			})
+			return
+		}
+	}
+
+	query := "UPDATE booking_page SET updated_at = NOW()"
+	var args []interface{}
+	if req.CalendarID != nil {
+		query += ", calendar_id = ?"
+		args = append(args, *req.CalendarID)
+	}
+	if req.Title != nil {
+		query += ", title = ?"
+		args = append(args, *req.Title)
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, *req.Description)
+	}
+	if req.Duration != nil {
+		query += ", duration = ?"
+		args = append(args, *req.Duration)
+	}
+	if req.BufferBefore != nil {
+		query += ", buffer_before = ?"
+		args = append(args, *req.BufferBefore)
+	}
+	if req.BufferAfter != nil {
+		query += ", buffer_after = ?"
+		args = append(args, *req.BufferAfter)
+	}
+	if req.MaxPerDay != nil {
+		query += ", max_per_day = ?"
+		if *req.MaxPerDay == 0 {
+			args = append(args, nil)
+		} else {
+			args = append(args, *req.MaxPerDay)
+		}
+	}
+	if req.MinNotice != nil {
+		query += ", min_notice = ?"
+		args = append(args, *req.MinNotice)
+	}
+	if req.MaxAdvanceDays != nil {
+		query += ", max_advance_days = ?"
+		args = append(args, *req.MaxAdvanceDays)
+	}
+	if req.Timezone != nil {
+		query += ", timezone = ?"
+		args = append(args, *req.Timezone)
+	}
+	if req.Active != nil {
+		query += ", active = ?"
+		args = append(args, *req.Active)
+	}
+	query += " WHERE booking_page_id = ?"
+	args = append(args, page.BookingPageID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		slog.Error(common.LogBookingPageUpdate + " - erreur lors de la mise à jour de la page : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageUpdate,
+		})
+		return
+	}
+
+	// Les plages fournies remplacent toutes les plages existantes
+	if req.Rules != nil {
+		_, err := tx.Exec("UPDATE booking_page_rule SET deleted_at = NOW() WHERE booking_page_id = ? AND deleted_at IS NULL", page.BookingPageID)
+		if err == nil {
+			err = insertRules(tx, page.BookingPageID, rules)
+		}
+		if err != nil {
+			slog.Error(common.LogBookingPageUpdate + " - erreur lors du remplacement des plages : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingPageUpdate,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogBookingPageUpdate + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogBookingPageUpdate + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateBookingPage,
+	})
+}
+
+// Delete supprime une page de réservation
+// @Summary Supprimer une page de réservation
+// @Description Supprime la page et ses plages. Les rendez-vous déjà pris et leurs événements sont conservés.
+// @Tags Page de réservation
+// @Produce json
+// @Param booking_page_id path int true "ID de la page de réservation"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking-pages/{booking_page_id} [delete]
+func (BookingPageStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogBookingPageDelete)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogBookingPageDelete + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"UPDATE booking_page SET deleted_at = NOW() WHERE booking_page_id = ?",
+		"UPDATE booking_page_rule SET deleted_at = NOW() WHERE booking_page_id = ? AND deleted_at IS NULL",
+	} {
+		if _, err := tx.Exec(query, page.BookingPageID); err != nil {
+			slog.Error(common.LogBookingPageDelete + " - erreur lors de la suppression de la page : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingPageDelete,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogBookingPageDelete + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogBookingPageDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteBookingPage,
+	})
+}
+
+// ListBookings liste les rendez-vous pris sur une page de réservation
+// @Summary Lister les rendez-vous d'une page
+// @Description Liste les rendez-vous pris par les visiteurs, annulés compris, triés par début
+// @Tags Page de réservation
+// @Produce json
+// @Param booking_page_id path int true "ID de la page de réservation"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking-pages/{booking_page_id}/bookings [get]
+func (BookingPageStruct) ListBookings(c *gin.Context) {
+	slog.Info(common.LogBookingPageListBookings)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	rows, err := common.DB.Query(`
+		SELECT booking_id, booking_page_id, event_id, guest_name, guest_email, notes, start, end, canceled_at, created_at
+		FROM booking
+		WHERE booking_page_id = ?
+		ORDER BY start, booking_id
+	`, page.BookingPageID)
+	if err != nil {
+		slog.Error(common.LogBookingPageListBookings + " - erreur lors de la récupération des rendez-vous : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	bookings := []common.Booking{}
+	for rows.Next() {
+		var booking common.Booking
+		if err := rows.Scan(&booking.BookingID, &booking.BookingPageID, &booking.EventID, &booking.GuestName, &booking.GuestEmail,
+			&booking.Notes, &booking.Start, &booking.End, &booking.CanceledAt, &booking.CreatedAt); err != nil {
+			slog.Error(common.LogBookingPageListBookings + " - erreur lors de la lecture des rendez-vous : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingRetrieval,
+			})
+			return
+		}
+		bookings = append(bookings, booking)
+	}
+
+	slog.Info(common.LogBookingPageListBookings + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListBookings,
+		Data:    bookings,
+	})
+}
+
+// Public retourne la présentation publique d'une page de réservation
+// @Summary Consulter une page de réservation
+// @Description Route publique : retourne le titre, la durée et le fuseau horaire d'une page de réservation active
+// @Tags Page de réservation
+// @Produce json
+// @Param slug path string true "Identifiant public de la page"
+// @Success 200 {object} common.JSONResponse{data=common.PublicBookingPage}
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking/{slug} [get]
+func (BookingPageStruct) Public(c *gin.Context) {
+	slog.Info(common.LogBookingPagePublic)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	var firstname, lastname string
+	err := common.DB.QueryRow("SELECT firstname, lastname FROM user WHERE user_id = ? AND deleted_at IS NULL", page.UserID).Scan(&firstname, &lastname)
+	if common.HandleDBError(c, err, http.StatusNotFound, common.ErrBookingPageNotFound, common.ErrBookingPageRetrieval) {
+		return
+	}
+
+	slog.Info(common.LogBookingPagePublic + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetBookingPage,
+		Data: common.PublicBookingPage{
+			Slug:        page.Slug,
+			Title:       page.Title,
+			Description: page.Description,
+			OwnerName:   strings.TrimSpace(firstname + " " + lastname),
+			Duration:    page.Duration,
+			Timezone:    page.Timezone,
+		},
+	})
+}
+
+// Slots liste les créneaux réservables d'une page sur une période
+// @Summary Créneaux disponibles
+// @Description Route publique : liste les créneaux libres de la page. Un créneau tient dans une plage hebdomadaire, respecte le préavis et l'horizon de réservation, ne chevauche aucun événement du propriétaire (tampons compris) et n'appartient pas à un jour ayant atteint le maximum de rendez-vous. Les dates au format jour sont interprétées dans le fuseau de la page.
+// @Tags Page de réservation
+// @Produce json
+// @Param slug path string true "Identifiant public de la page"
+// @Param start query string true "Début de la période (YYYY-MM-DD ou RFC3339)"
+// @Param end query string true "Fin de la période (YYYY-MM-DD inclus ou RFC3339 exclu), 62 jours au plus"
+// @Success 200 {object} common.JSONResponse{data=common.BookingSlotsResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /booking/{slug}/slots [get]
+func (BookingPageStruct) Slots(c *gin.Context) {
+	slog.Info(common.LogBookingPageSlots)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+	loc, err := time.LoadLocation(page.Timezone)
+	if err != nil {
+		slog.Error(common.LogBookingPageSlots + " - fuseau horaire invalide : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTimezone,
+		})
+		return
+	}
+
+	start, errStart := parseDate(c.Query("start"), false, loc)
+	end, errEnd := parseDate(c.Query("end"), true, loc)
+	if errStart != nil || errEnd != nil || !start.Before(end) || end.Sub(start) > maxSlotsDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidBookingRange,
+		})
+		return
+	}
+
+	slots, err := availableSlots(common.DB, page, loc, start, end, time.Now())
+	if err != nil {
+		slog.Error(common.LogBookingPageSlots + " - erreur lors du calcul des créneaux : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageRetrieval,
+		})
+		return
+	}
+
+	response := common.BookingSlotsResponse{
+		Slug:     page.Slug,
+		Timezone: page.Timezone,
+		Start:    start,
+		End:      end,
+		Slots:    []common.TimeRange{},
+	}
+	for _, slot := range slots {
+		response.Slots = append(response.Slots, common.TimeRange{Start: slot.Start.In(loc), End: slot.End.In(loc)})
+	}
+
+	slog.Info(common.LogBookingPageSlots + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessBookingSlots,
+		Data:    response,
+	})
+}
+
+// Book réserve un créneau pour un visiteur
+// @Summary Réserver un rendez-vous
+// @Description Route publique : réserve le créneau commençant à start et crée l'événement correspondant dans le calendrier de la page. Les réservations d'un même propriétaire sont traitées l'une après l'autre ; un créneau devenu indisponible est refusé (409). Le jeton d'annulation n'est renvoyé qu'une seule fois.
+// @Tags Page de réservation
+// @Accept json
+// @Produce json
+// @Param slug path string true "Identifiant public de la page"
+// @Param booking body common.CreateBookingRequest true "Créneau et coordonnées du visiteur"
+// @Success 201 {object} common.JSONResponse{data=common.BookingConfirmation}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /booking/{slug} [post]
+func (BookingPageStruct) Book(c *gin.Context) {
+	slog.Info(common.LogBookingPageBook)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogBookingPageBook + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	loc, err := time.LoadLocation(page.Timezone)
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - fuseau horaire invalide : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTimezone,
+		})
+		return
+	}
+
+	cancelToken, err := common.GenerateToken(32)
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors de la génération du jeton d'annulation : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCreation,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Verrouiller le propriétaire sérialise ses réservations, toutes pages confondues : les lectures
+	// suivantes voient les rendez-vous validés par les transactions concurrentes
+	var ownerID int
+	if err := tx.QueryRow("SELECT user_id FROM user WHERE user_id = ? AND deleted_at IS NULL FOR UPDATE", page.UserID).Scan(&ownerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrBookingPageNotFound,
+			})
+			return
+		}
+		slog.Error(common.LogBookingPageBook + " - erreur lors du verrouillage du propriétaire : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCreation,
+		})
+		return
+	}
+	if err := calendar_event.CheckCalendarAccess(tx, page.UserID, page.CalendarID); err != nil {
+		slog.Error(common.LogBookingPageBook + " - calendrier de la page inaccessible : " + err.Error())
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingPageNotFound,
+		})
+		return
+	}
+
+	// Le créneau demandé doit être l'un des créneaux disponibles
+	slots, err := availableSlots(tx, page, loc, req.Start, req.Start.Add(time.Minute), time.Now())
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors du calcul des créneaux : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCreation,
+		})
+		return
+	}
+	if len(slots) == 0 || !slots[0].Start.Equal(req.Start) {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingSlotUnavailable,
+		})
+		return
+	}
+	slot := slots[0]
+
+	description := "Rendez-vous réservé par " + req.Name + " <" + req.Email + ">"
+	if req.Notes != nil && *req.Notes != "" {
+		description += "\n\n" + *req.Notes
+	}
+	eventID, err := calendar_event.CreateEvent(tx, page.UserID, page.CalendarID, common.CreateEventRequest{
+		Title:       page.Title + " - " + req.Name,
+		Description: &description,
+		Start:       slot.Start,
+		Duration:    page.Duration,
+		CalendarID:  page.CalendarID,
+	})
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors de la création de l'événement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCreation,
+		})
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO booking (booking_page_id, event_id, guest_name, guest_email, notes, start, end, cancel_token_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`, page.BookingPageID, eventID, req.Name, req.Email, req.Notes, slot.Start, slot.End, common.HashToken(cancelToken))
+	if err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors de l'enregistrement du rendez-vous : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCreation,
+		})
+		return
+	}
+	bookingID, _ := result.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogBookingPageBook + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogBookingPageBook + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateBooking,
+		Data: common.BookingConfirmation{
+			BookingID:   int(bookingID),
+			Start:       slot.Start.In(loc),
+			End:         slot.End.In(loc),
+			CancelToken: cancelToken,
+		},
+	})
+}
+
+// Cancel annule un rendez-vous avec son jeton d'annulation
+// @Summary Annuler un rendez-vous
+// @Description Route publique : annule le rendez-vous correspondant au jeton reçu à la réservation et marque son événement comme annulé, ce qui libère le créneau. Un rendez-vous commencé ne peut plus être annulé.
+// @Tags Page de réservation
+// @Accept json
+// @Produce json
+// @Param slug path string true "Identifiant public de la page"
+// @Param cancel body common.CancelBookingRequest true "Jeton d'annulation"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /booking/{slug}/cancel [post]
+func (BookingPageStruct) Cancel(c *gin.Context) {
+	slog.Info(common.LogBookingPageCancel)
+	page, ok := common.GetBookingPageFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CancelBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogBookingPageCancel + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogBookingPageCancel + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var bookingID, eventID int
+	var start time.Time
+	err = tx.QueryRow(`
+		SELECT booking_id, event_id, start FROM booking
+		WHERE booking_page_id = ? AND cancel_token_hash = ? AND canceled_at IS NULL
+		FOR UPDATE
+	`, page.BookingPageID, common.HashToken(strings.ToLower(req.CancelToken))).Scan(&bookingID, &eventID, &start)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingNotFound,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogBookingPageCancel + " - erreur lors de la récupération du rendez-vous : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingRetrieval,
+		})
+		return
+	}
+	if !start.After(time.Now()) {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingAlreadyStarted,
+		})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE booking SET canceled_at = NOW() WHERE booking_id = ?", bookingID); err != nil {
+		slog.Error(common.LogBookingPageCancel + " - erreur lors de l'annulation du rendez-vous : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCancel,
+		})
+		return
+	}
+	if err := calendar_event.CancelEvent(tx, eventID); err != nil {
+		slog.Error(common.LogBookingPageCancel + " - erreur lors de l'annulation de l'événement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBookingCancel,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogBookingPageCancel + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogBookingPageCancel + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCancelBooking,
+	})
+}
+
+// availableSlots calcule les créneaux réservables de la page dont le début est dans [from, to)
+func availableSlots(db queryer, page common.BookingPage, loc *time.Location, from, to, now time.Time) ([]availability.Interval, error) {
+	rules, err := loadRules(db, page.BookingPageID)
+	if err != nil {
+		return nil, err
+	}
+	availabilityRules, err := user_availability.ToAvailabilityRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(page.Duration) * time.Minute
+	bufferBefore := time.Duration(page.BufferBefore) * time.Minute
+	bufferAfter := time.Duration(page.BufferAfter) * time.Minute
+
+	earliest := now.Add(time.Duration(page.MinNotice) * time.Minute)
+	if from.After(earliest) {
+		earliest = from
+	}
+	latest := now.AddDate(0, 0, page.MaxAdvanceDays)
+	if to.Before(latest) {
+		latest = to
+	}
+	if !earliest.Before(latest) {
+		return nil, nil
+	}
+
+	// Les plages qui commencent avant earliest peuvent contenir des créneaux valides plus loin
+	windows := availability.Windows(availabilityRules, loc, earliest, latest.Add(duration))
+	margin := bufferBefore + bufferAfter
+	busy, err := ownerBusy(db, page, earliest.Add(-margin), latest.Add(duration+margin))
+	if err != nil {
+		return nil, err
+	}
+
+	// Le propriétaire n'est pas réservable hors de ses heures de travail ni pendant ses absences
+	schedule, err := user_availability.LoadSchedule(db, page.UserID, earliest.Add(-margin), latest.Add(duration+margin))
+	if err != nil {
+		return nil, err
+	}
+	windows = schedule.WithinWorkingHours(windows)
+	busy = append(busy, schedule.OutOfOffice...)
+	opts := availability.SlotOptions{
+		Duration:     duration,
+		BufferBefore: bufferBefore,
+		BufferAfter:  bufferAfter,
+		Earliest:     earliest,
+		Latest:       latest,
+		Location:     loc,
+	}
+	if page.MaxPerDay != nil {
+		opts.MaxPerDay = *page.MaxPerDay
+		opts.PerDay, err = bookingsPerDay(db, page.BookingPageID, loc, earliest.AddDate(0, 0, -1), latest.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return availability.Slots(windows, busy, opts), nil
+}
+
+// ownerBusy retourne les événements non annulés des calendriers du propriétaire qui chevauchent la période.
+// Les rendez-vous déjà pris sur la page sont étendus de ses tampons, pour que le tampon qui suit
+// un rendez-vous ne soit pas réservé. Les calendriers de disponibilité des ressources ne rendent
+// pas le propriétaire indisponible.
+func ownerBusy(db queryer, page common.BookingPage, from, to time.Time) ([]availability.Interval, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT e.event_id, e.start, e.duration, b.booking_id IS NOT NULL
+		FROM user_calendar uc
+		INNER JOIN calendar c ON c.calendar_id = uc.calendar_id AND c.deleted_at IS NULL
+		INNER JOIN calendar_event ce ON ce.calendar_id = uc.calendar_id AND ce.deleted_at IS NULL
+		INNER JOIN event e ON e.event_id = ce.event_id
+		LEFT JOIN booking b ON b.event_id = e.event_id AND b.booking_page_id = ? AND b.canceled_at IS NULL
+		WHERE uc.user_id = ? AND uc.deleted_at IS NULL
+		AND c.calendar_id NOT IN (SELECT calendar_id FROM resource)
+		AND e.deleted_at IS NULL AND e.canceled = FALSE
+		AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+	`, page.BookingPageID, page.UserID, to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bufferBefore := time.Duration(page.BufferBefore) * time.Minute
+	bufferAfter := time.Duration(page.BufferAfter) * time.Minute
+	var busy []availability.Interval
+	for rows.Next() {
+		var eventID, duration int
+		var start time.Time
+		var booked bool
+		if err := rows.Scan(&eventID, &start, &duration, &booked); err != nil {
+			return nil, err
+		}
+		interval := availability.Interval{Start: start, End: start.Add(time.Duration(duration) * time.Minute)}
+		if booked {
+			interval.Start = interval.Start.Add(-bufferBefore)
+			interval.End = interval.End.Add(bufferAfter)
+		}
+		busy = append(busy, interval)
+	}
+	return busy, rows.Err()
+}
+
+// bookingsPerDay compte les rendez-vous non annulés de la page par jour, dans le fuseau de la page
+func bookingsPerDay(db queryer, pageID int, loc *time.Location, from, to time.Time) (map[string]int, error) {
+	rows, err := db.Query(`
+		SELECT start FROM booking
+		WHERE booking_page_id = ? AND canceled_at IS NULL AND start >= ? AND start < ?
+	`, pageID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perDay := map[string]int{}
+	for rows.Next() {
+		var start time.Time
+		if err := rows.Scan(&start); err != nil {
+			return nil, err
+		}
+		perDay[availability.DayKey(start, loc)]++
+	}
+	return perDay, rows.Err()
+}
+
+// loadRules lit les plages hebdomadaires de la page, triées par jour puis par heure de début
+func loadRules(db queryer, pageID int) ([]common.WeeklyRule, error) {
+	rows, err := db.Query(`
+		SELECT weekday, start_minute, end_minute FROM booking_page_rule
+		WHERE booking_page_id = ? AND deleted_at IS NULL
+		ORDER BY weekday, start_minute
+	`, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []common.WeeklyRule{}
+	for rows.Next() {
+		var weekday, startMinute, endMinute int
+		if err := rows.Scan(&weekday, &startMinute, &endMinute); err != nil {
+			return nil, err
+		}
+		rules = append(rules, common.WeeklyRule{
+			Weekday:   weekday,
+			StartTime: availability.FormatClock(startMinute),
+			EndTime:   availability.FormatClock(endMinute),
+		})
+	}
+	return rules, rows.Err()
+}
+
+// insertRules ajoute les plages hebdomadaires à la page dans la transaction
+func insertRules(tx *sql.Tx, pageID int, rules []common.WeeklyRule) error {
+	availabilityRules, err := user_availability.ToAvailabilityRules(rules)
+	if err != nil {
+		return err
+	}
+	for _, rule := range availabilityRules {
+		_, err := tx.Exec(`
+			INSERT INTO booking_page_rule (booking_page_id, weekday, start_minute, end_minute, created_at)
+			VALUES (?, ?, ?, ?, NOW())
+		`, pageID, int(rule.Weekday), rule.StartMinute, rule.EndMinute)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePage vérifie le fuseau horaire et les plages d'une page de réservation.
+// Retourne un message d'erreur non vide si les données sont invalides.
+func validatePage(timezone string, rules []common.WeeklyRule) string {
+	if !user_availability.ValidTimezone(timezone) {
+		return common.ErrInvalidTimezone
+	}
+	if _, err := user_availability.ToAvailabilityRules(rules); err != nil {
+		return common.ErrInvalidBookingRules
+	}
+	return ""
+}
+
+// parseDate accepte une date au format YYYY-MM-DD (interprétée dans loc) ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseDate(value string, isEnd bool, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,322 @@
+package booking_page_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// nextMonday retourne le premier lundi à minuit UTC situé au moins trois jours après maintenant
+func nextMonday() time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 3)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// createPage crée une page de réservation du lundi matin (09:00-12:00 UTC) et retourne son identifiant public
+func createPage(t *testing.T, owner *testutils.AuthenticatedUser, extra map[string]interface{}) (int, string) {
+	body := map[string]interface{}{
+		"calendar_id": owner.Calendar.CalendarID,
+		"title":       "Consultation",
+		"duration":    60,
+		"rules":       []map[string]interface{}{{"weekday": 1, "start_time": "09:00", "end_time": "12:00"}},
+	}
+	for key, value := range extra {
+		body[key] = value
+	}
+	status, response := testutils.DoRequest(t, testServer, owner.SessionToken, "POST", "/booking-pages", body)
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var data struct {
+		BookingPageID int    `json:"booking_page_id"`
+		Slug          string `json:"slug"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	return data.BookingPageID, data.Slug
+}
+
+// slotStarts retourne les heures de début (HH:MM UTC) des créneaux disponibles du jour
+func slotStarts(t *testing.T, slug string, day time.Time) []string {
+	status, response := testutils.DoRequest(t, testServer, "", "GET", "/booking/"+slug+"/slots?start="+day.Format("2006-01-02")+"&end="+day.Format("2006-01-02"), nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var data common.BookingSlotsResponse
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	starts := []string{}
+	for _, slot := range data.Slots {
+		starts = append(starts, slot.Start.UTC().Format("15:04"))
+	}
+	return starts
+}
+
+// book réserve un créneau sans authentification et retourne le code HTTP et la confirmation
+func book(t *testing.T, slug string, start time.Time) (int, common.BookingConfirmation) {
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/booking/"+slug, map[string]interface{}{
+		"start": start.Format(time.RFC3339),
+		"name":  "Leïla Benali",
+		"email": "leila.benali@example.com",
+	})
+	var confirmation common.BookingConfirmation
+	if status == http.StatusCreated {
+		require.NoError(t, json.Unmarshal(response.Data, &confirmation))
+	}
+	return status, confirmation
+}
+
+// TestAddBookingPageRoute teste la création d'une page de réservation avec plusieurs cas
+func TestAddBookingPageRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      map[string]interface{}
+		OtherCalendar    bool
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Création réussie avec fuseau horaire et tampons",
+			RequestData: map[string]interface{}{
+				"title": "Premier rendez-vous", "duration": 30, "buffer_after": 15, "max_per_day": 4, "timezone": "Europe/Paris",
+				"rules": []map[string]interface{}{{"weekday": 2, "start_time": "14:00", "end_time": "18:00"}},
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateBookingPage,
+		},
+		{
+			CaseName: "Échec de la création avec un fuseau horaire inconnu",
+			RequestData: map[string]interface{}{
+				"title": "Rendez-vous", "duration": 30, "timezone": "Europe/Atlantide",
+				"rules": []map[string]interface{}{{"weekday": 2, "start_time": "14:00", "end_time": "18:00"}},
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidTimezone,
+		},
+		{
+			CaseName: "Échec de la création avec une plage qui finit avant de commencer",
+			RequestData: map[string]interface{}{
+				"title": "Rendez-vous", "duration": 30,
+				"rules": []map[string]interface{}{{"weekday": 2, "start_time": "18:00", "end_time": "14:00"}},
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidBookingRules,
+		},
+		{
+			CaseName: "Échec de la création sans plage",
+			RequestData: map[string]interface{}{
+				"title": "Rendez-vous", "duration": 30, "rules": []map[string]interface{}{},
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+		{
+			CaseName: "Échec de la création sur un calendrier sans accès",
+			RequestData: map[string]interface{}{
+				"title": "Rendez-vous", "duration": 30,
+				"rules": []map[string]interface{}{{"weekday": 2, "start_time": "14:00", "end_time": "18:00"}},
+			},
+			OtherCalendar:    true,
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrNoAccessToCalendar,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+			require.NoError(t, err)
+
+			testCase.RequestData["calendar_id"] = user.Calendar.CalendarID
+			if testCase.OtherCalendar {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				testCase.RequestData["calendar_id"] = other.Calendar.CalendarID
+			}
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/booking-pages", testCase.RequestData)
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestPublicBookingFlow vérifie la réservation publique : tampons, maximum par jour, événements existants et annulation
+func TestPublicBookingFlow(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	pageID, slug := createPage(t, owner, map[string]interface{}{"buffer_after": 30, "max_per_day": 2})
+	monday := nextMonday()
+	at := func(hour int) time.Time { return monday.Add(time.Duration(hour) * time.Hour) }
+
+	// La page est consultable sans authentification
+	status, response := testutils.DoRequest(t, testServer, "", "GET", "/booking/"+slug, nil)
+	require.Equal(t, http.StatusOK, status)
+	var public common.PublicBookingPage
+	require.NoError(t, json.Unmarshal(response.Data, &public))
+	require.Equal(t, "Consultation", public.Title)
+	require.Equal(t, []string{"09:00", "10:00", "11:00"}, slotStarts(t, slug, monday))
+
+	// Un événement existant du propriétaire bloque le créneau de 11:00
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "POST", "/calendar-event/"+strconv.Itoa(owner.Calendar.CalendarID), map[string]interface{}{
+		"title": "Déjeuner", "start": at(11).Add(30 * time.Minute).Format(time.RFC3339), "duration": 60, "calendar_id": owner.Calendar.CalendarID,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	require.Equal(t, []string{"09:00", "10:00"}, slotStarts(t, slug, monday))
+
+	// Réserver 09:00 bloque aussi 10:00 à cause du tampon de 30 minutes
+	status, first := book(t, slug, at(9))
+	require.Equal(t, http.StatusCreated, status)
+	require.Len(t, first.CancelToken, 64)
+	require.Equal(t, []string{}, slotStarts(t, slug, monday))
+	status, _ = book(t, slug, at(10))
+	require.Equal(t, http.StatusConflict, status)
+
+	// Un créneau hors des plages est refusé
+	status, _ = book(t, slug, at(14))
+	require.Equal(t, http.StatusConflict, status)
+
+	// Le rendez-vous apparaît dans le calendrier du propriétaire
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "GET", "/booking-pages/"+strconv.Itoa(pageID)+"/bookings", nil)
+	require.Equal(t, http.StatusOK, status)
+	var bookings []common.Booking
+	require.NoError(t, json.Unmarshal(response.Data, &bookings))
+	require.Len(t, bookings, 1)
+	require.Equal(t, "leila.benali@example.com", bookings[0].GuestEmail)
+	status, _ = testutils.DoRequest(t, testServer, owner.SessionToken, "GET", "/calendar-event/"+strconv.Itoa(owner.Calendar.CalendarID)+"/"+strconv.Itoa(bookings[0].EventID), nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// Le maximum de deux rendez-vous par jour ferme la journée
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "PUT", "/calendar-event/"+strconv.Itoa(owner.Calendar.CalendarID)+"/"+strconv.Itoa(bookings[0].EventID), map[string]interface{}{
+		"start": at(9).Add(-time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, _ = book(t, slug, at(10))
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, []string{}, slotStarts(t, slug, monday))
+
+	// L'annulation libère le créneau et ne peut être faite qu'une fois
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/booking/"+slug+"/cancel", map[string]interface{}{"cancel_token": first.CancelToken})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, common.MsgSuccessCancelBooking, response.Message)
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/booking/"+slug+"/cancel", map[string]interface{}{"cancel_token": first.CancelToken})
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrBookingNotFound, response.Error)
+
+	// Une page désactivée n'est plus accessible publiquement
+	status, _ = testutils.DoRequest(t, testServer, owner.SessionToken, "PUT", "/booking-pages/"+strconv.Itoa(pageID), map[string]interface{}{"active": false})
+	require.Equal(t, http.StatusOK, status)
+	status, _ = testutils.DoRequest(t, testServer, "", "GET", "/booking/"+slug, nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestConcurrentPublicBooking vérifie qu'une seule de plusieurs réservations simultanées du même créneau aboutit
+func TestConcurrentPublicBooking(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	_, slug := createPage(t, owner, nil)
+	start := nextMonday().Add(9 * time.Hour)
+
+	const attempts = 5
+	statuses := make([]int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// require ne doit pas être appelé hors de la goroutine du test
+			payload, _ := json.Marshal(map[string]interface{}{
+				"start": start.Format(time.RFC3339),
+				"name":  "Visiteur " + strconv.Itoa(i),
+				"email": "visiteur" + strconv.Itoa(i) + "@example.com",
+			})
+			resp, err := testClient.Post(testServer.URL+"/booking/"+slug, "application/json", bytes.NewReader(payload))
+			if err != nil {
+				t.Errorf("Erreur lors de l'exécution de la requête: %v", err)
+				return
+			}
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, status := range statuses {
+		if status == http.StatusCreated {
+			created++
+		} else {
+			require.Equal(t, http.StatusConflict, status)
+		}
+	}
+	require.Equal(t, 1, created, "Une seule réservation doit aboutir")
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestBookingPageRespectsOwnerSchedule vérifie que les heures de travail et les absences du propriétaire restreignent les créneaux
+func TestBookingPageRespectsOwnerSchedule(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	_, slug := createPage(t, owner, nil)
+	monday := nextMonday()
+
+	// Le propriétaire ne commence qu'à 10:00 : le créneau de 09:00 disparaît
+	status, response := testutils.DoRequest(t, testServer, owner.SessionToken, "PUT", "/user/me/working-hours", map[string]interface{}{
+		"timezone": "UTC",
+		"rules":    []map[string]interface{}{{"weekday": 1, "start_time": "10:00", "end_time": "17:00"}},
+	})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, []string{"10:00", "11:00"}, slotStarts(t, slug, monday))
+
+	// Une absence à partir de 11:00 bloque le dernier créneau, y compris à la réservation
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": monday.Add(11 * time.Hour).Format(time.RFC3339), "end": monday.AddDate(0, 0, 1).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	require.Equal(t, []string{"10:00"}, slotStarts(t, slug, monday))
+	status, _ = book(t, slug, monday.Add(11*time.Hour))
+	require.Equal(t, http.StatusConflict, status)
+
+	testutils.PurgeAllTestUsers()
+}
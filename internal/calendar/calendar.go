@@ -2,8 +2,11 @@
 package calendar
 
 import (
+	"database/sql"
+	"go-averroes/internal/calendar_event"
 	"go-averroes/internal/common"
 	"net/http"
+	"time"
 
 	"log/slog"
 
@@ -85,6 +88,15 @@ func (CalendarStruct) Add(c *gin.Context) {
 
 	slog.Info("Calendar.Add: Données reçues", "title", req.Title, "description", req.Description)
 
+	if req.Color != nil && !common.IsValidHexColor(*req.Color) {
+		slog.Error(common.LogCalendarAdd + " - couleur invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidColor,
+		})
+		return
+	}
+
 	tx, err := common.DB.Begin()
 	if err != nil {
 		slog.Error(common.LogCalendarAdd + " - erreur lors du démarrage de la transaction : " + err.Error())
@@ -97,9 +109,9 @@ func (CalendarStruct) Add(c *gin.Context) {
 	defer tx.Rollback()
 
 	result, err := tx.Exec(`
-        INSERT INTO calendar (title, description, created_at) 
-        VALUES (?, ?, NOW())
-    `, req.Title, req.Description)
+        INSERT INTO calendar (title, description, color, created_at) 
+        VALUES (?, ?, ?, NOW())
+    `, req.Title, req.Description, req.Color)
 	if err != nil {
 		slog.Error(common.LogCalendarAdd + " - erreur lors de la création du calendrier : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -201,12 +213,30 @@ func (CalendarStruct) Update(c *gin.Context) {
 		return
 	}
 
+	if req.Color != nil && *req.Color != "" && !common.IsValidHexColor(*req.Color) {
+		slog.Error(common.LogCalendarUpdate + " - couleur invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidColor,
+		})
+		return
+	}
+
 	query := "UPDATE calendar SET updated_at = NOW(), title = ?"
 	args := []interface{}{*req.Title}
 	if req.Description != nil {
 		query += ", description = ?"
 		args = append(args, *req.Description)
 	}
+	if req.Color != nil {
+		// Une couleur vide retire la couleur du calendrier
+		if *req.Color == "" {
+			query += ", color = NULL"
+		} else {
+			query += ", color = ?"
+			args = append(args, *req.Color)
+		}
+	}
 	query += " WHERE calendar_id = ?"
 	args = append(args, calendarID)
 
@@ -314,3 +344,186 @@ func (CalendarStruct) Delete(c *gin.Context) {
 		Message: common.MsgSuccessDeleteCalendar,
 	})
 }
+
+// Clone duplique un calendrier avec ses événements et, en option, ses partages
+// @Summary Cloner un calendrier
+// @Description Crée un nouveau calendrier à partir d'un calendrier existant (titre, description, couleur), copie ses événements avec un décalage optionnel en jours et, si demandé, ses partages avec les autres utilisateurs. L'opération est réalisée en une seule transaction.
+// @Tags Calendrier
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier à cloner"
+// @Param clone body common.CloneCalendarRequest false "Options du clonage"
+// @Success 201 {object} common.JSONResponse{data=common.CloneCalendarResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar/{calendar_id}/clone [post]
+func (CalendarStruct) Clone(c *gin.Context) {
+	slog.Info(common.LogCalendarClone)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CloneCalendarRequest
+	// Le corps est optionnel : sans options, le calendrier est cloné avec ses événements, sans décalage
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.Error(common.LogCalendarClone + " - données invalides : " + err.Error())
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidData + ": " + err.Error(),
+			})
+			return
+		}
+	}
+
+	title := calendarData.Title + " (copie)"
+	if req.Title != nil {
+		title = *req.Title
+	}
+	description := calendarData.Description
+	if req.Description != nil {
+		description = req.Description
+	}
+	includeEvents := req.IncludeEvents == nil || *req.IncludeEvents
+	shift := time.Duration(req.ShiftDays) * 24 * time.Hour
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogCalendarClone + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO calendar (title, description, color, created_at) 
+		VALUES (?, ?, ?, NOW())
+	`, title, description, calendarData.Color)
+	if err != nil {
+		slog.Error(common.LogCalendarClone + " - erreur lors de la création du calendrier : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrCalendarCreation,
+		})
+		return
+	}
+	newCalendarID, _ := result.LastInsertId()
+
+	// L'auteur du clonage a toujours accès au nouveau calendrier
+	_, err = tx.Exec(`
+		INSERT INTO user_calendar (user_id, calendar_id, created_at) 
+		VALUES (?, ?, NOW())
+	`, userData.UserID, newCalendarID)
+	if err != nil {
+		slog.Error(common.LogCalendarClone + " - erreur lors de la création de la liaison user_calendar : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrUserCalendarLinkCreation,
+		})
+		return
+	}
+
+	response := common.CloneCalendarResponse{
+		CalendarID:       int(newCalendarID),
+		SourceCalendarID: calendarData.CalendarID,
+		Title:            title,
+		ShiftDays:        req.ShiftDays,
+	}
+
+	if includeEvents {
+		eventsCopied, err := cloneEvents(tx, userData.UserID, calendarData.CalendarID, int(newCalendarID), shift)
+		if err != nil {
+			slog.Error(common.LogCalendarClone + " - erreur lors de la copie des événements : " + err.Error())
+			status, errMsg := calendar_event.ErrorResponse(err)
+			c.JSON(status, common.JSONResponse{
+				Success: false,
+				Error:   errMsg,
+			})
+			return
+		}
+		response.EventsCopied = eventsCopied
+	}
+
+	if req.IncludeSharing {
+		result, err := tx.Exec(`
+			INSERT INTO user_calendar (user_id, calendar_id, created_at)
+			SELECT user_id, ?, NOW() FROM user_calendar
+			WHERE calendar_id = ? AND user_id <> ? AND deleted_at IS NULL
+		`, newCalendarID, calendarData.CalendarID, userData.UserID)
+		if err != nil {
+			slog.Error(common.LogCalendarClone + " - erreur lors de la copie des partages : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrUserCalendarLinkCreation,
+			})
+			return
+		}
+		sharesCopied, _ := result.RowsAffected()
+		response.SharesCopied = int(sharesCopied)
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogCalendarClone + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogCalendarClone + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCloneCalendar,
+		Data:    response,
+	})
+}
+
+// cloneEvents copie les événements actifs du calendrier source dans le calendrier cible, décalés de shift,
+// avec les étiquettes que l'utilisateur leur a attribuées
+func cloneEvents(tx *sql.Tx, userID, sourceID, targetID int, shift time.Duration) (int, error) {
+	rows, err := tx.Query(`
+		SELECT e.event_id FROM event e
+		INNER JOIN calendar_event ce ON e.event_id = ce.event_id
+		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+		ORDER BY e.start ASC
+	`, sourceID)
+	if err != nil {
+		return 0, err
+	}
+	var eventIDs []int
+	for rows.Next() {
+		var eventID int
+		if err := rows.Scan(&eventID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Les lignes doivent être fermées avant de réutiliser la connexion de la transaction
+	for _, eventID := range eventIDs {
+		newEventID, err := calendar_event.DuplicateEvent(tx, userID, eventID, shift)
+		if err != nil {
+			return 0, err
+		}
+		if err := calendar_event.LinkEvent(tx, targetID, newEventID); err != nil {
+			return 0, err
+		}
+	}
+	return len(eventIDs), nil
+}
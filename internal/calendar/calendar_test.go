@@ -171,8 +171,8 @@ func TestCreateCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -485,8 +485,8 @@ func TestGetCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -831,8 +831,8 @@ func TestUpdateCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -1263,8 +1263,8 @@ func TestDeleteCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -1496,3 +1496,137 @@ func TestDeleteCalendarRoute(t *testing.T) {
 		})
 	}
 }
+
+// TestCloneCalendarRoute teste la route POST de clonage d'un calendrier avec plusieurs cas
+func TestCloneCalendarRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		SetupData        func(owner *testutils.AuthenticatedUser) *testutils.AuthenticatedUser
+		RequestData      interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+		ExpectedEvents   int
+		ExpectedShares   int
+		ExpectedShift    int
+	}{
+		{
+			CaseName:         "Clonage réussi sans options",
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCloneCalendar,
+			ExpectedEvents:   1,
+		},
+		{
+			CaseName: "Clonage réussi avec décalage et partages",
+			SetupData: func(owner *testutils.AuthenticatedUser) *testutils.AuthenticatedUser {
+				member, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				_, err = common.DB.Exec("INSERT INTO user_calendar (user_id, calendar_id, created_at) VALUES (?, ?, NOW())", member.User.UserID, owner.Calendar.CalendarID)
+				require.NoError(t, err)
+				return nil
+			},
+			RequestData:      map[string]interface{}{"title": "Semestre 2", "shift_days": 182, "include_sharing": true},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCloneCalendar,
+			ExpectedEvents:   1,
+			ExpectedShares:   1,
+			ExpectedShift:    182,
+		},
+		{
+			CaseName:         "Clonage réussi sans les événements",
+			RequestData:      map[string]interface{}{"include_events": false},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCloneCalendar,
+		},
+		{
+			CaseName:         "Échec du clonage avec un décalage hors limites",
+			RequestData:      map[string]interface{}{"shift_days": 5000},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+		{
+			CaseName: "Échec du clonage sans accès au calendrier",
+			SetupData: func(owner *testutils.AuthenticatedUser) *testutils.AuthenticatedUser {
+				stranger, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return stranger
+			},
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrNoAccessToCalendar,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			owner, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+			require.NoError(t, err)
+			caller := owner
+			if testCase.SetupData != nil {
+				if user := testCase.SetupData(owner); user != nil {
+					caller = user
+				}
+			}
+
+			var body *bytes.Reader
+			if testCase.RequestData != nil {
+				jsonData, err := json.Marshal(testCase.RequestData)
+				require.NoError(t, err)
+				body = bytes.NewReader(jsonData)
+			} else {
+				body = bytes.NewReader(nil)
+			}
+			req, err := http.NewRequest("POST", testServer.URL+"/calendar/"+strconv.Itoa(owner.Calendar.CalendarID)+"/clone", body)
+			require.NoError(t, err, "Erreur lors de la création de la requête")
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+caller.SessionToken)
+
+			resp, err := testClient.Do(req)
+			require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+			defer resp.Body.Close()
+
+			require.Equal(t, testCase.ExpectedHttpCode, resp.StatusCode, "Code de statut HTTP incorrect")
+
+			var response struct {
+				common.JSONResponse
+				Data common.CloneCalendarResponse `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response), "Erreur lors du parsing de la réponse JSON")
+
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+			if testCase.ExpectedMessage == "" {
+				testutils.PurgeAllTestUsers()
+				return
+			}
+
+			require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			require.NotEqual(t, owner.Calendar.CalendarID, response.Data.CalendarID)
+			require.Equal(t, testCase.ExpectedEvents, response.Data.EventsCopied, "Nombre d'événements copiés incorrect")
+			require.Equal(t, testCase.ExpectedShares, response.Data.SharesCopied, "Nombre de partages copiés incorrect")
+
+			// Le nouveau calendrier est accessible à son auteur
+			var access int
+			require.NoError(t, common.DB.QueryRow("SELECT COUNT(*) FROM user_calendar WHERE calendar_id = ? AND deleted_at IS NULL", response.Data.CalendarID).Scan(&access))
+			require.Equal(t, 1+testCase.ExpectedShares, access)
+
+			// Les événements copiés sont décalés et distincts des originaux
+			if testCase.ExpectedEvents > 0 {
+				var copiedID int
+				var copiedStart time.Time
+				require.NoError(t, common.DB.QueryRow(`
+					SELECT e.event_id, e.start FROM event e
+					INNER JOIN calendar_event ce ON e.event_id = ce.event_id
+					WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL
+				`, response.Data.CalendarID).Scan(&copiedID, &copiedStart))
+				require.NotEqual(t, owner.Event.EventID, copiedID)
+				var originalStart time.Time
+				require.NoError(t, common.DB.QueryRow("SELECT start FROM event WHERE event_id = ?", owner.Event.EventID).Scan(&originalStart))
+				require.Equal(t, time.Duration(testCase.ExpectedShift)*24*time.Hour, copiedStart.Sub(originalStart), "Décalage incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
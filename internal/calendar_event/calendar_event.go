@@ -2,8 +2,13 @@
 package calendar_event
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"go-averroes/internal/attachment"
 	"go-averroes/internal/common"
+	"go-averroes/internal/resource"
+	"go-averroes/internal/tag"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -29,7 +34,8 @@ var CalendarEvent = CalendarEventStruct{}
 // @Router /calendar-event/{calendar_id}/{event_id} [get]
 func (CalendarEventStruct) Get(c *gin.Context) {
 	slog.Info(common.LogEventGet)
-	if _, ok := common.GetUserFromContext(c); !ok {
+	user, ok := common.GetUserFromContext(c)
+	if !ok {
 		return
 	}
 	if _, ok := common.GetCalendarFromContext(c); !ok {
@@ -42,6 +48,28 @@ func (CalendarEventStruct) Get(c *gin.Context) {
 		return
 	}
 
+	tagsByEvent, err := tag.LoadEventTags(user.UserID, []int{eventData.EventID})
+	if err != nil {
+		slog.Error(common.LogEventGet + " - erreur lors de la récupération des étiquettes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagRetrieval,
+		})
+		return
+	}
+	eventData.Tags = tagsByEvent[eventData.EventID]
+
+	attachmentsByEvent, err := attachment.LoadEventAttachments([]int{eventData.EventID})
+	if err != nil {
+		slog.Error(common.LogEventGet + " - erreur lors de la récupération des pièces jointes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAttachmentRetrieval,
+		})
+		return
+	}
+	eventData.Attachments = attachmentsByEvent[eventData.EventID]
+
 	slog.Info(common.LogEventGet + " - succès")
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
@@ -63,7 +91,8 @@ func (CalendarEventStruct) Get(c *gin.Context) {
 // @Router /calendar-event/{calendar_id} [post]
 func (CalendarEventStruct) Add(c *gin.Context) {
 	slog.Info(common.LogEventAdd)
-	if _, ok := common.GetUserFromContext(c); !ok {
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
 		return
 	}
 	calendarData, ok := common.GetCalendarFromContext(c)
@@ -82,23 +111,17 @@ func (CalendarEventStruct) Add(c *gin.Context) {
 		return
 	}
 
-	if req.Duration < 1 {
-		slog.Error(common.LogEventAdd + " - durée invalide")
+	if errMsg := ValidateCreateRequest(req); errMsg != "" {
+		slog.Error(common.LogEventAdd + " - " + errMsg)
 		c.JSON(http.StatusBadRequest, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrInvalidDuration,
+			Error:   errMsg,
 		})
 		return
 	}
 
 	// La vérification d'accès est maintenant gérée par le middleware UserCanAccessCalendarMiddleware
 
-	// Valeur par défaut pour canceled si non fournie
-	canceled := false
-	if req.Canceled != nil {
-		canceled = *req.Canceled
-	}
-
 	// Démarrer une transaction
 	tx, err := common.DB.Begin()
 	if err != nil {
@@ -111,32 +134,13 @@ func (CalendarEventStruct) Add(c *gin.Context) {
 	}
 	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
 
-	// Insérer l'événement
-	result, err := tx.Exec(`
-		INSERT INTO event (title, description, start, duration, canceled, created_at) 
-		VALUES (?, ?, ?, ?, ?, NOW())
-	`, req.Title, req.Description, req.Start, req.Duration, canceled)
-	if err != nil {
-		slog.Error(common.LogEventAdd + " - erreur lors de la création de l'événement : " + err.Error())
-		c.JSON(http.StatusInternalServerError, common.JSONResponse{
-			Success: false,
-			Error:   common.ErrEventCreation,
-		})
-		return
-	}
-
-	eventID, _ := result.LastInsertId()
-
-	// Créer la liaison calendar_event
-	_, err = tx.Exec(`
-		INSERT INTO calendar_event (calendar_id, event_id, created_at) 
-		VALUES (?, ?, NOW())
-	`, calendarID, eventID)
+	eventID, err := CreateEvent(tx, userData.UserID, calendarID, req)
 	if err != nil {
-		slog.Error(common.LogEventAdd + " - erreur lors de la création de la liaison calendar_event : " + err.Error())
-		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+		slog.Error(common.LogEventAdd + " - " + err.Error())
+		status, errMsg := ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrCalendarEventLink,
+			Error:   errMsg,
 		})
 		return
 	}
@@ -178,7 +182,8 @@ func (CalendarEventStruct) Add(c *gin.Context) {
 // @Router /calendar-event/{calendar_id}/{event_id} [put]
 func (CalendarEventStruct) Update(c *gin.Context) {
 	slog.Info(common.LogEventUpdate)
-	if _, ok := common.GetUserFromContext(c); !ok {
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
 		return
 	}
 	if _, ok := common.GetCalendarFromContext(c); !ok {
@@ -202,49 +207,43 @@ func (CalendarEventStruct) Update(c *gin.Context) {
 		return
 	}
 
-	if req.Duration != nil && *req.Duration < 1 {
-		slog.Error(common.LogEventUpdate + " - durée invalide")
+	if errMsg := ValidateUpdateRequest(req); errMsg != "" {
+		slog.Error(common.LogEventUpdate + " - " + errMsg)
 		c.JSON(http.StatusBadRequest, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrInvalidDuration,
+			Error:   errMsg,
 		})
 		return
 	}
 
-	// Construire la requête de mise à jour
-	query := "UPDATE event SET updated_at = NOW()"
-	var args []interface{}
-
-	if req.Title != nil {
-		query += ", title = ?"
-		args = append(args, *req.Title)
-	}
-	if req.Description != nil {
-		query += ", description = ?"
-		args = append(args, *req.Description)
-	}
-	if req.Start != nil {
-		query += ", start = ?"
-		args = append(args, *req.Start)
-	}
-	if req.Duration != nil {
-		query += ", duration = ?"
-		args = append(args, *req.Duration)
-	}
-	if req.Canceled != nil {
-		query += ", canceled = ?"
-		args = append(args, *req.Canceled)
+	// Démarrer une transaction
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogEventUpdate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
 	}
+	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
 
-	query += " WHERE event_id = ?"
-	args = append(args, eventID)
+	if err := UpdateEvent(tx, userData.UserID, eventID, req); err != nil {
+		slog.Error(common.LogEventUpdate + " - " + err.Error())
+		status, errMsg := ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
 
-	_, err := common.DB.Exec(query, args...)
-	if err != nil {
-		slog.Error(common.LogEventUpdate + " - erreur lors de la mise à jour de l'événement : " + err.Error())
+	// Valider la transaction
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEventUpdate + " - erreur lors du commit de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrEventUpdate,
+			Error:   common.ErrTransactionCommit,
 		})
 		return
 	}
@@ -258,7 +257,7 @@ func (CalendarEventStruct) Update(c *gin.Context) {
 
 // Delete supprime un événement de calendrier
 // @Summary Supprimer un événement
-// @Description Supprime un événement de calendrier par son ID
+// @Description Retire l'événement du calendrier. Il n'est supprimé que s'il n'apparaît plus dans aucun autre calendrier.
 // @Tags Événement
 // @Produce json
 // @Param calendar_id path int true "ID du calendrier"
@@ -272,7 +271,8 @@ func (CalendarEventStruct) Delete(c *gin.Context) {
 	if _, ok := common.GetUserFromContext(c); !ok {
 		return
 	}
-	if _, ok := common.GetCalendarFromContext(c); !ok {
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
 		return
 	}
 
@@ -295,31 +295,108 @@ func (CalendarEventStruct) Delete(c *gin.Context) {
 	}
 	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
 
-	// Soft delete de l'événement
-	_, err = tx.Exec("UPDATE event SET deleted_at = NOW() WHERE event_id = ?", eventID)
+	eventDeleted, err := DeleteEvent(tx, calendarData.CalendarID, eventID)
 	if err != nil {
-		slog.Error(common.LogEventDelete + " - erreur lors de la suppression de l'événement : " + err.Error())
+		slog.Error(common.LogEventDelete + " - " + err.Error())
+		status, errMsg := ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	// Valider la transaction
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEventDelete + " - erreur lors du commit de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrEventDelete,
+			Error:   common.ErrTransactionCommit,
 		})
 		return
 	}
 
-	// Soft delete des liaisons calendar_event
-	_, err = tx.Exec("UPDATE calendar_event SET deleted_at = NOW() WHERE event_id = ?", eventID)
+	// Un événement encore présent dans d'autres calendriers est seulement retiré de celui-ci
+	message := common.MsgSuccessDeleteEvent
+	if !eventDeleted {
+		message = common.MsgSuccessUnlinkEvent
+	}
+
+	slog.Info(common.LogEventDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: message,
+		Data: gin.H{
+			"event_id":      eventID,
+			"event_deleted": eventDeleted,
+		},
+	})
+}
+
+// Link rattache un événement existant à un calendrier supplémentaire
+// @Summary Partager un événement dans un autre calendrier
+// @Description Rattache l'événement à un calendrier supplémentaire : le même événement apparaît alors dans les deux calendriers. L'utilisateur doit avoir accès aux deux calendriers.
+// @Tags Événement
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID d'un calendrier contenant l'événement"
+// @Param event_id path int true "ID de l'événement"
+// @Param link body common.LinkEventRequest true "Calendrier à ajouter"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/link [post]
+func (CalendarEventStruct) Link(c *gin.Context) {
+	slog.Info(common.LogEventLink)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.LinkEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEventLink + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
 	if err != nil {
-		slog.Error(common.LogEventDelete + " - erreur lors de la suppression de la liaison calendar_event : " + err.Error())
+		slog.Error(common.LogEventLink + " - erreur lors du démarrage de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrCalendarEventDeleteLink,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
+
+	if err := linkToCalendar(tx, userData.UserID, calendarData.CalendarID, req.TargetCalendarID, eventData.EventID); err != nil {
+		slog.Error(common.LogEventLink + " - " + err.Error())
+		status, errMsg := ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
 		})
 		return
 	}
 
-	// Valider la transaction
 	if err := tx.Commit(); err != nil {
-		slog.Error(common.LogEventDelete + " - erreur lors du commit de la transaction : " + err.Error())
+		slog.Error(common.LogEventLink + " - erreur lors du commit de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
 			Success: false,
 			Error:   common.ErrTransactionCommit,
@@ -327,10 +404,78 @@ func (CalendarEventStruct) Delete(c *gin.Context) {
 		return
 	}
 
-	slog.Info(common.LogEventDelete + " - succès")
+	slog.Info(common.LogEventLink + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessLinkEvent,
+		Data: gin.H{
+			"event_id":    eventData.EventID,
+			"calendar_id": req.TargetCalendarID,
+		},
+	})
+}
+
+// Unlink retire un événement partagé d'un calendrier sans le supprimer des autres
+// @Summary Retirer un événement partagé d'un calendrier
+// @Description Retire l'événement du calendrier indiqué. L'événement doit rester rattaché à au moins un autre calendrier ; pour le retirer du dernier, utiliser la suppression.
+// @Tags Événement
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/link [delete]
+func (CalendarEventStruct) Unlink(c *gin.Context) {
+	slog.Info(common.LogEventUnlink)
+	if _, ok := common.GetUserFromContext(c); !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogEventUnlink + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
+
+	if err := unlinkFromCalendar(tx, calendarData.CalendarID, eventData.EventID); err != nil {
+		slog.Error(common.LogEventUnlink + " - " + err.Error())
+		status, errMsg := ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEventUnlink + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventUnlink + " - succès")
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
-		Message: common.MsgSuccessDeleteEvent,
+		Message: common.MsgSuccessUnlinkEvent,
 	})
 }
 
@@ -342,7 +487,10 @@ func (CalendarEventStruct) Delete(c *gin.Context) {
 // @Param calendar_id path int true "ID du calendrier"
 // @Param year path int true "Année"
 // @Param month path int true "Mois"
+// @Param tag_id query []int false "Filtrer par étiquette (au moins une)" collectionFormat(multi)
+// @Param has_location query bool false "Filtrer les événements avec (true) ou sans (false) lieu"
 // @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
 // @Failure 401 {object} common.JSONErrorResponse
 // @Router /calendar-event/{calendar_id}/month/{year}/{month} [get]
 func (CalendarEventStruct) ListByMonth(c *gin.Context) {
@@ -371,7 +519,10 @@ func (CalendarEventStruct) ListByMonth(c *gin.Context) {
 // @Param calendar_id path int true "ID du calendrier"
 // @Param year path int true "Année"
 // @Param week path int true "Numéro de la semaine"
+// @Param tag_id query []int false "Filtrer par étiquette (au moins une)" collectionFormat(multi)
+// @Param has_location query bool false "Filtrer les événements avec (true) ou sans (false) lieu"
 // @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
 // @Failure 401 {object} common.JSONErrorResponse
 // @Router /calendar-event/{calendar_id}/week/{year}/{week} [get]
 func (CalendarEventStruct) ListByWeek(c *gin.Context) {
@@ -407,7 +558,10 @@ func (CalendarEventStruct) ListByWeek(c *gin.Context) {
 // @Param year path int true "Année"
 // @Param month path int true "Mois"
 // @Param day path int true "Jour"
+// @Param tag_id query []int false "Filtrer par étiquette (au moins une)" collectionFormat(multi)
+// @Param has_location query bool false "Filtrer les événements avec (true) ou sans (false) lieu"
 // @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
 // @Failure 401 {object} common.JSONErrorResponse
 // @Router /calendar-event/{calendar_id}/day/{year}/{month}/{day} [get]
 func (CalendarEventStruct) ListByDay(c *gin.Context) {
@@ -437,7 +591,8 @@ func (CalendarEventStruct) ListByDay(c *gin.Context) {
 // listEventsWithRange est une fonction utilitaire pour factoriser la logique de récupération
 func listEventsWithRange(c *gin.Context, startDate, endDate time.Time) {
 	slog.Info(common.LogEventList)
-	if _, ok := common.GetUserFromContext(c); !ok {
+	user, ok := common.GetUserFromContext(c)
+	if !ok {
 		return
 	}
 	calendarData, ok := common.GetCalendarFromContext(c)
@@ -446,17 +601,42 @@ func listEventsWithRange(c *gin.Context, startDate, endDate time.Time) {
 	}
 	calendarID := calendarData.CalendarID
 
+	tagIDs, ok := tag.ParseTagFilter(c)
+	if !ok {
+		slog.Error(common.LogEventList + " - filtre d'étiquettes invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTagID,
+		})
+		return
+	}
+
+	hasLocation, ok := ParseHasLocationFilter(c)
+	if !ok {
+		slog.Error(common.LogEventList + " - filtre de lieu invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidLocationFilter,
+		})
+		return
+	}
+
 	query := `
 		SELECT e.event_id, e.title, e.description, e.start, e.duration, e.canceled, 
+		       e.location, e.latitude, e.longitude, e.meeting_url,
 		       e.created_at, e.updated_at, e.deleted_at 
 		FROM event e
 		INNER JOIN calendar_event ce ON e.event_id = ce.event_id
 		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL 
 		  AND e.deleted_at IS NULL 
-		  AND e.start >= ? AND e.start < ?
+		  AND e.start >= ? AND e.start < ?`
+	args := []interface{}{calendarID, startDate, endDate}
+	tagClause, tagArgs := tag.FilterClause("e.event_id", tagIDs)
+	query += tagClause + HasLocationClause("e", hasLocation) + `
 		ORDER BY e.start ASC
 	`
-	rows, err := common.DB.Query(query, calendarID, startDate, endDate)
+	args = append(args, tagArgs...)
+	rows, err := common.DB.Query(query, args...)
 	if err != nil {
 		slog.Error(common.LogEventList + " - erreur lors de la récupération des événements : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -470,7 +650,7 @@ func listEventsWithRange(c *gin.Context, startDate, endDate time.Time) {
 	var events []common.Event
 	for rows.Next() {
 		var event common.Event
-		err := rows.Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
+		err := rows.Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.Location, &event.Latitude, &event.Longitude, &event.MeetingURL, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
 		if err != nil {
 			slog.Error(common.LogEventList + " - erreur lors de la lecture des événements : " + err.Error())
 			c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -491,6 +671,15 @@ func listEventsWithRange(c *gin.Context, startDate, endDate time.Time) {
 		return
 	}
 
+	if err = tag.AttachTags(user.UserID, events); err != nil {
+		slog.Error(common.LogEventList + " - erreur lors de la récupération des étiquettes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagRetrieval,
+		})
+		return
+	}
+
 	slog.Info(fmt.Sprintf("%s - succès, %d événements trouvés", common.LogEventList, len(events)))
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
@@ -498,3 +687,437 @@ func listEventsWithRange(c *gin.Context, startDate, endDate time.Time) {
 		Data:    events,
 	})
 }
+
+// eventError associe une erreur technique au statut HTTP et au message renvoyés au client
+type eventError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *eventError) Error() string {
+	if e.err == nil {
+		return e.message
+	}
+	return e.message + " : " + e.err.Error()
+}
+
+func (e *eventError) Unwrap() error {
+	return e.err
+}
+
+// ErrorResponse retourne le statut HTTP et le message client correspondant à une erreur
+// des fonctions transactionnelles de ce package (CreateEvent, UpdateEvent, DeleteEvent...)
+func ErrorResponse(err error) (int, string) {
+	var evtErr *eventError
+	if errors.As(err, &evtErr) {
+		return evtErr.status, evtErr.message
+	}
+	return http.StatusInternalServerError, common.ErrEventOperation
+}
+
+// ValidateCreateRequest complète la validation du binding pour la création d'un événement.
+// Retourne un message d'erreur non vide si les données sont invalides.
+func ValidateCreateRequest(req common.CreateEventRequest) string {
+	if req.Duration < 1 {
+		return common.ErrInvalidDuration
+	}
+	return validateLocation(req.Latitude, req.Longitude, req.MeetingURL)
+}
+
+// ValidateUpdateRequest complète la validation du binding pour la mise à jour d'un événement.
+// Retourne un message d'erreur non vide si les données sont invalides.
+func ValidateUpdateRequest(req common.UpdateEventRequest) string {
+	if req.Duration != nil && *req.Duration < 1 {
+		return common.ErrInvalidDuration
+	}
+	return validateLocation(req.Latitude, req.Longitude, req.MeetingURL)
+}
+
+// CreateEvent insère l'événement, ses étiquettes et sa liaison au calendrier dans la transaction
+func CreateEvent(tx *sql.Tx, userID, calendarID int, req common.CreateEventRequest) (int64, error) {
+	// Valeur par défaut pour canceled si non fournie
+	canceled := false
+	if req.Canceled != nil {
+		canceled = *req.Canceled
+	}
+
+	// Insérer l'événement
+	result, err := tx.Exec(`
+		INSERT INTO event (title, description, start, duration, canceled, location, latitude, longitude, meeting_url, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`, req.Title, req.Description, req.Start, req.Duration, canceled,
+		common.NullIfEmpty(req.Location), req.Latitude, req.Longitude, common.NullIfEmpty(req.MeetingURL))
+	if err != nil {
+		return 0, &eventError{http.StatusInternalServerError, common.ErrEventCreation, err}
+	}
+
+	eventID, _ := result.LastInsertId()
+
+	// Associer les étiquettes de l'utilisateur
+	if len(req.TagIDs) > 0 {
+		if err := setEventTags(tx, userID, eventID, req.TagIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	// Créer la liaison calendar_event
+	if err := LinkEvent(tx, calendarID, eventID); err != nil {
+		return 0, err
+	}
+
+	// Réserver les ressources demandées sur le créneau de l'événement
+	if len(req.ResourceIDs) > 0 {
+		if err := reserveResources(tx, eventID, req.ResourceIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	return eventID, nil
+}
+
+// LinkEvent crée la liaison calendar_event entre le calendrier et l'événement
+func LinkEvent(tx *sql.Tx, calendarID int, eventID int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO calendar_event (calendar_id, event_id, created_at) 
+		VALUES (?, ?, NOW())
+	`, calendarID, eventID)
+	if err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrCalendarEventLink, err}
+	}
+	return nil
+}
+
+// UpdateEvent applique les champs fournis à l'événement dans la transaction
+func UpdateEvent(tx *sql.Tx, userID, eventID int, req common.UpdateEventRequest) error {
+	// Construire la requête de mise à jour
+	query := "UPDATE event SET updated_at = NOW()"
+	var args []interface{}
+
+	if req.Title != nil {
+		query += ", title = ?"
+		args = append(args, *req.Title)
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, *req.Description)
+	}
+	if req.Start != nil {
+		query += ", start = ?"
+		args = append(args, *req.Start)
+	}
+	if req.Duration != nil {
+		query += ", duration = ?"
+		args = append(args, *req.Duration)
+	}
+	if req.Canceled != nil {
+		query += ", canceled = ?"
+		args = append(args, *req.Canceled)
+	}
+	// Une adresse vide retire le lieu, coordonnées comprises (sauf si de nouvelles coordonnées sont fournies)
+	if req.Location != nil {
+		query += ", location = ?"
+		args = append(args, common.NullIfEmpty(req.Location))
+	}
+	if req.Latitude != nil {
+		query += ", latitude = ?, longitude = ?"
+		args = append(args, *req.Latitude, *req.Longitude)
+	} else if req.Location != nil && *req.Location == "" {
+		query += ", latitude = NULL, longitude = NULL"
+	}
+	if req.MeetingURL != nil {
+		query += ", meeting_url = ?"
+		args = append(args, common.NullIfEmpty(req.MeetingURL))
+	}
+
+	query += " WHERE event_id = ?"
+	args = append(args, eventID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrEventUpdate, err}
+	}
+
+	// Remplacer les étiquettes si elles sont fournies (une liste vide les retire toutes)
+	if req.TagIDs != nil {
+		if err := setEventTags(tx, userID, int64(eventID), *req.TagIDs); err != nil {
+			return err
+		}
+	}
+
+	// Remplacer les ressources si elles sont fournies, sinon revérifier les réservations si le créneau change
+	if req.ResourceIDs != nil {
+		return setEventResources(tx, int64(eventID), *req.ResourceIDs)
+	}
+	if req.Start != nil || req.Duration != nil || req.Canceled != nil {
+		return checkResourceConflicts(tx, int64(eventID))
+	}
+	return nil
+}
+
+// DeleteEvent retire l'événement du calendrier dans la transaction. L'événement lui-même n'est supprimé
+// (soft delete) que s'il n'est plus rattaché à aucun autre calendrier actif ; le booléen retourné l'indique.
+func DeleteEvent(tx *sql.Tx, calendarID, eventID int) (bool, error) {
+	if err := unlinkEvent(tx, calendarID, eventID); err != nil {
+		return false, err
+	}
+
+	remaining, err := countEventLinks(tx, eventID)
+	if err != nil {
+		return false, err
+	}
+	if remaining > 0 {
+		return false, nil
+	}
+
+	// Soft delete de l'événement
+	if _, err := tx.Exec("UPDATE event SET deleted_at = NOW() WHERE event_id = ?", eventID); err != nil {
+		return false, &eventError{http.StatusInternalServerError, common.ErrEventDelete, err}
+	}
+
+	// Soft delete des liaisons restantes (calendriers supprimés)
+	if _, err := tx.Exec("UPDATE calendar_event SET deleted_at = NOW() WHERE event_id = ? AND deleted_at IS NULL", eventID); err != nil {
+		return false, &eventError{http.StatusInternalServerError, common.ErrCalendarEventDeleteLink, err}
+	}
+
+	// Les tâches liées sont conservées sans leur événement
+	if _, err := tx.Exec("UPDATE task SET event_id = NULL WHERE event_id = ?", eventID); err != nil {
+		return false, &eventError{http.StatusInternalServerError, common.ErrEventDelete, err}
+	}
+
+	// Les ressources réservées sont libérées
+	if err := resource.ReleaseEvent(tx, eventID); err != nil {
+		return false, &eventError{http.StatusInternalServerError, common.ErrResourceBooking, err}
+	}
+	return true, nil
+}
+
+// unlinkEvent supprime (soft delete) la liaison entre le calendrier et l'événement
+func unlinkEvent(tx *sql.Tx, calendarID, eventID int) error {
+	result, err := tx.Exec(`
+		UPDATE calendar_event SET deleted_at = NOW()
+		WHERE calendar_id = ? AND event_id = ? AND deleted_at IS NULL
+	`, calendarID, eventID)
+	if err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrCalendarEventDeleteLink, err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &eventError{http.StatusNotFound, common.ErrEventNotFound, nil}
+	}
+	return nil
+}
+
+// countEventLinks compte les calendriers actifs auxquels l'événement est rattaché
+func countEventLinks(tx *sql.Tx, eventID int) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM calendar_event ce
+		INNER JOIN calendar c ON ce.calendar_id = c.calendar_id
+		WHERE ce.event_id = ? AND ce.deleted_at IS NULL AND c.deleted_at IS NULL
+	`, eventID).Scan(&count)
+	if err != nil {
+		return 0, &eventError{http.StatusInternalServerError, common.ErrEventRetrieval, err}
+	}
+	return count, nil
+}
+
+// IsEventLinked indique si l'événement, non supprimé, est rattaché au calendrier
+func IsEventLinked(tx *sql.Tx, calendarID, eventID int) (bool, error) {
+	var found int
+	err := tx.QueryRow(`
+		SELECT 1 FROM calendar_event ce
+		INNER JOIN event e ON ce.event_id = e.event_id
+		WHERE ce.calendar_id = ? AND ce.event_id = ?
+		AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+	`, calendarID, eventID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &eventError{http.StatusInternalServerError, common.ErrEventRetrieval, err}
+	}
+	return true, nil
+}
+
+// linkToCalendar rattache au calendrier cible un événement du calendrier source
+func linkToCalendar(tx *sql.Tx, userID, sourceID, targetID, eventID int) error {
+	linked, err := IsEventLinked(tx, sourceID, eventID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return &eventError{http.StatusNotFound, common.ErrEventNotFound, nil}
+	}
+	if err := CheckCalendarAccess(tx, userID, targetID); err != nil {
+		return err
+	}
+	linked, err = IsEventLinked(tx, targetID, eventID)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return &eventError{http.StatusConflict, common.ErrEventAlreadyLinked, nil}
+	}
+	return LinkEvent(tx, targetID, int64(eventID))
+}
+
+// unlinkFromCalendar retire l'événement du calendrier en refusant de retirer sa dernière liaison
+func unlinkFromCalendar(tx *sql.Tx, calendarID, eventID int) error {
+	linked, err := IsEventLinked(tx, calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return &eventError{http.StatusNotFound, common.ErrEventNotFound, nil}
+	}
+	remaining, err := countEventLinks(tx, eventID)
+	if err != nil {
+		return err
+	}
+	if remaining <= 1 {
+		return &eventError{http.StatusConflict, common.ErrEventLastLink, nil}
+	}
+	return unlinkEvent(tx, calendarID, eventID)
+}
+
+// CancelEvent marque l'événement comme annulé dans la transaction
+func CancelEvent(tx *sql.Tx, eventID int) error {
+	if _, err := tx.Exec("UPDATE event SET canceled = TRUE, updated_at = NOW() WHERE event_id = ?", eventID); err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrEventUpdate, err}
+	}
+	return nil
+}
+
+// DuplicateEvent crée dans la transaction une copie de l'événement, décalée de shift. Les étiquettes étant propres
+// à chaque utilisateur, seules celles de l'auteur de la copie (userID) sont reportées sur la copie.
+// La copie n'est liée à aucun calendrier : c'est à l'appelant de créer la liaison calendar_event.
+func DuplicateEvent(tx *sql.Tx, userID, eventID int, shift time.Duration) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO event (title, description, start, duration, canceled, location, latitude, longitude, meeting_url, created_at)
+		SELECT title, description, DATE_ADD(start, INTERVAL ? SECOND), duration, canceled, location, latitude, longitude, meeting_url, NOW()
+		FROM event WHERE event_id = ? AND deleted_at IS NULL
+	`, int64(shift/time.Second), eventID)
+	if err != nil {
+		return 0, &eventError{http.StatusInternalServerError, common.ErrEventCreation, err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return 0, &eventError{http.StatusNotFound, common.ErrEventNotFound, nil}
+	}
+	newEventID, _ := result.LastInsertId()
+
+	_, err = tx.Exec(`
+		INSERT INTO event_tag (event_id, tag_id, created_at)
+		SELECT ?, et.tag_id, NOW() FROM event_tag et
+		INNER JOIN tag t ON t.tag_id = et.tag_id AND t.user_id = ? AND t.deleted_at IS NULL
+		WHERE et.event_id = ? AND et.deleted_at IS NULL
+	`, newEventID, userID, eventID)
+	if err != nil {
+		return 0, &eventError{http.StatusInternalServerError, common.ErrEventTagLink, err}
+	}
+	return newEventID, nil
+}
+
+// CheckCalendarAccess vérifie dans la transaction que le calendrier existe et que l'utilisateur y a accès.
+// Utilisé quand un second calendrier (cible d'un déplacement, d'une copie...) n'est pas couvert par les middlewares.
+func CheckCalendarAccess(tx *sql.Tx, userID, calendarID int) error {
+	var found int
+	err := tx.QueryRow("SELECT 1 FROM calendar WHERE calendar_id = ? AND deleted_at IS NULL", calendarID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &eventError{http.StatusNotFound, common.ErrCalendarNotFound, err}
+	}
+	if err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrCalendarVerification, err}
+	}
+
+	err = tx.QueryRow(`
+		SELECT 1 FROM user_calendar
+		WHERE user_id = ? AND calendar_id = ? AND deleted_at IS NULL
+	`, userID, calendarID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &eventError{http.StatusForbidden, common.ErrNoAccessToCalendar, err}
+	}
+	if err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrCalendarAccessCheck, err}
+	}
+	return nil
+}
+
+// setEventTags vérifie que les étiquettes appartiennent à l'utilisateur puis les associe à l'événement
+func setEventTags(tx *sql.Tx, userID int, eventID int64, tagIDs []int) error {
+	if err := tag.ValidateUserTags(tx, userID, tagIDs); err != nil {
+		if errors.Is(err, tag.ErrUnknownTags) {
+			return &eventError{http.StatusBadRequest, common.ErrTagNotFound, err}
+		}
+		return &eventError{http.StatusInternalServerError, common.ErrTagRetrieval, err}
+	}
+	if err := tag.SetEventTags(tx, userID, eventID, tagIDs); err != nil {
+		return &eventError{http.StatusInternalServerError, common.ErrEventTagLink, err}
+	}
+	return nil
+}
+
+// reserveResources réserve les ressources pour l'événement créé
+func reserveResources(tx *sql.Tx, eventID int64, resourceIDs []int) error {
+	return resourceError(resource.Reserve(tx, eventID, resourceIDs))
+}
+
+// setEventResources remplace les ressources réservées par l'événement
+func setEventResources(tx *sql.Tx, eventID int64, resourceIDs []int) error {
+	return resourceError(resource.SetEventResources(tx, eventID, resourceIDs))
+}
+
+// checkResourceConflicts revérifie les réservations de l'événement après un changement de créneau
+func checkResourceConflicts(tx *sql.Tx, eventID int64) error {
+	return resourceError(resource.CheckConflicts(tx, eventID))
+}
+
+// resourceError convertit une erreur du package resource en erreur client
+func resourceError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, resource.ErrUnknownResources):
+		return &eventError{http.StatusBadRequest, common.ErrResourceNotFound, err}
+	case errors.Is(err, resource.ErrConflict):
+		return &eventError{http.StatusConflict, common.ErrResourceConflict, err}
+	}
+	return &eventError{http.StatusInternalServerError, common.ErrResourceBooking, err}
+}
+
+// validateLocation vérifie la cohérence des coordonnées et du lien de visioconférence.
+// Retourne un message d'erreur non vide si les données sont invalides.
+func validateLocation(latitude, longitude *float64, meetingURL *string) string {
+	if (latitude == nil) != (longitude == nil) {
+		return common.ErrInvalidCoordinates
+	}
+	if meetingURL != nil && *meetingURL != "" && !common.IsValidMeetingURL(*meetingURL) {
+		return common.ErrInvalidMeetingURL
+	}
+	return ""
+}
+
+// ParseHasLocationFilter lit le paramètre optionnel "has_location" (true/false) d'une requête de listing.
+// Retourne nil si le filtre est absent et false si sa valeur est invalide.
+func ParseHasLocationFilter(c *gin.Context) (*bool, bool) {
+	value := c.Query("has_location")
+	if value == "" {
+		return nil, true
+	}
+	hasLocation, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, false
+	}
+	return &hasLocation, true
+}
+
+// HasLocationClause retourne la condition SQL restreignant aux événements avec (ou sans) lieu.
+// Un événement a un lieu s'il possède une adresse, des coordonnées ou un lien de visioconférence.
+func HasLocationClause(alias string, hasLocation *bool) string {
+	if hasLocation == nil {
+		return ""
+	}
+	condition := "(" + alias + ".location IS NOT NULL OR " + alias + ".latitude IS NOT NULL OR " + alias + ".meeting_url IS NOT NULL)"
+	if *hasLocation {
+		return " AND " + condition
+	}
+	return " AND NOT " + condition
+}
@@ -218,8 +218,8 @@ func TestGetEventRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -488,6 +488,78 @@ func TestAddEventRoute(t *testing.T) {
 			ExpectedMessage:  common.MsgSuccessCreateEvent,
 			ExpectedError:    "",
 		},
+		{
+			CaseName: "Création réussie d'un événement avec lieu et lien de visioconférence",
+			CaseUrl:  "/calendar-event/1", // Sera remplacé par l'ID réel
+			RequestData: func() map[string]interface{} {
+				// DOIT CONTENIR L'ENSEMBLE DES INSTRUCTIONS QUI PREPARENT LE CAS A LA RECEPTION DE LA REQUEST POST/PUT
+				// Créer un utilisateur authentifié avec session active en base
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user": user,
+					"requestBody": map[string]interface{}{
+						"title":       "Atelier hybride",
+						"start":       time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+						"duration":    90,
+						"calendar_id": 1,
+						"location":    "10 rue de Rivoli, 75004 Paris",
+						"latitude":    48.8556,
+						"longitude":   2.3522,
+						"meeting_url": "https://meet.example.com/atelier",
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateEvent,
+			ExpectedError:    "",
+		},
+		{
+			CaseName: "Échec de création avec latitude sans longitude",
+			CaseUrl:  "/calendar-event/1", // Sera remplacé par l'ID réel
+			RequestData: func() map[string]interface{} {
+				// DOIT CONTENIR L'ENSEMBLE DES INSTRUCTIONS QUI PREPARENT LE CAS A LA RECEPTION DE LA REQUEST POST/PUT
+				// Créer un utilisateur authentifié avec session active en base
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user": user,
+					"requestBody": map[string]interface{}{
+						"title":       "Événement test",
+						"start":       time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+						"duration":    30,
+						"calendar_id": 1,
+						"latitude":    48.8556,
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedMessage:  "",
+			ExpectedError:    common.ErrInvalidCoordinates,
+		},
+		{
+			CaseName: "Échec de création avec un lien de visioconférence invalide",
+			CaseUrl:  "/calendar-event/1", // Sera remplacé par l'ID réel
+			RequestData: func() map[string]interface{} {
+				// DOIT CONTENIR L'ENSEMBLE DES INSTRUCTIONS QUI PREPARENT LE CAS A LA RECEPTION DE LA REQUEST POST/PUT
+				// Créer un utilisateur authentifié avec session active en base
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user": user,
+					"requestBody": map[string]interface{}{
+						"title":       "Événement test",
+						"start":       time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+						"duration":    30,
+						"calendar_id": 1,
+						"meeting_url": "javascript:alert(1)",
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedMessage:  "",
+			ExpectedError:    common.ErrInvalidMeetingURL,
+		},
 		{
 			CaseName: "Création réussie d'un événement avec données minimales",
 			CaseUrl:  "/calendar-event/1", // Sera remplacé par l'ID réel
@@ -682,8 +754,8 @@ func TestAddEventRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -1096,6 +1168,26 @@ func TestUpdateEventRoute(t *testing.T) {
 			ExpectedMessage:  common.MsgSuccessUpdateEvent,
 			ExpectedError:    "",
 		},
+		{
+			CaseName: "Mise à jour réussie du lieu",
+			CaseUrl:  "/calendar-event/1/1", // Sera remplacé par les IDs réels
+			RequestData: func() map[string]interface{} {
+				// DOIT CONTENIR L'ENSEMBLE DES INSTRUCTIONS QUI PREPARENT LE CAS A LA RECEPTION DE LA REQUEST POST/PUT
+				// Créer un utilisateur authentifié avec session active en base
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user": user,
+					"requestBody": map[string]interface{}{
+						"location":    "Salle Turing, 2e étage",
+						"meeting_url": "",
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessUpdateEvent,
+			ExpectedError:    "",
+		},
 		{
 			CaseName: "Mise à jour réussie de la description",
 			CaseUrl:  "/calendar-event/1/1", // Sera remplacé par les IDs réels
@@ -1382,8 +1474,8 @@ func TestUpdateEventRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -1876,8 +1968,8 @@ func TestDeleteEventRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -2542,8 +2634,8 @@ func TestGetEventsByMonthRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -3007,8 +3099,8 @@ func TestGetEventsByWeekRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -3472,8 +3564,8 @@ func TestGetEventsByDayRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"user": user,
@@ -3776,3 +3868,136 @@ func TestGetEventsByDayRoute(t *testing.T) {
 		})
 	}
 }
+
+// TestEventsHasLocationFilter vérifie le filtre has_location des listes d'événements
+func TestEventsHasLocationFilter(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	calendarID := user.Calendar.CalendarID
+
+	// Un événement avec lieu et un événement sans lieu le même jour
+	for _, location := range []interface{}{"Salle Lovelace", nil} {
+		result, err := common.DB.Exec(`
+			INSERT INTO event (title, start, duration, location, created_at)
+			VALUES (?, ?, ?, ?, NOW())
+		`, "Événement localisé", time.Date(2024, 12, 15, 10, 0, 0, 0, time.UTC), 60, location)
+		require.NoError(t, err)
+		eventID, err := result.LastInsertId()
+		require.NoError(t, err)
+		_, err = common.DB.Exec("INSERT INTO calendar_event (calendar_id, event_id, created_at) VALUES (?, ?, NOW())", calendarID, eventID)
+		require.NoError(t, err)
+	}
+
+	for _, filter := range []struct {
+		Value            string
+		ExpectedHttpCode int
+		ExpectedCount    int
+	}{
+		{Value: "true", ExpectedHttpCode: http.StatusOK, ExpectedCount: 1},
+		{Value: "false", ExpectedHttpCode: http.StatusOK, ExpectedCount: 1},
+		{Value: "", ExpectedHttpCode: http.StatusOK, ExpectedCount: 2},
+		{Value: "peut-être", ExpectedHttpCode: http.StatusBadRequest},
+	} {
+		url := testServer.URL + "/calendar-event/" + strconv.Itoa(calendarID) + "/day/2024/12/15?has_location=" + filter.Value
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err, "Erreur lors de la création de la requête")
+		req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+
+		resp, err := testClient.Do(req)
+		require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+		require.Equal(t, filter.ExpectedHttpCode, resp.StatusCode, "Code de statut HTTP incorrect")
+
+		var response struct {
+			common.JSONResponse
+			Data []common.Event `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		resp.Body.Close()
+
+		if filter.ExpectedHttpCode == http.StatusOK {
+			require.Len(t, response.Data, filter.ExpectedCount, "Nombre d'événements incorrect")
+		} else {
+			require.Equal(t, common.ErrInvalidLocationFilter, response.Error)
+		}
+	}
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestSharedEventLinks vérifie le partage d'un événement entre calendriers et la suppression d'un événement partagé
+func TestSharedEventLinks(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+	require.NoError(t, err)
+	teamCalendarID, err := testutils.CreateCalendarForUser(user.User.UserID, "Équipe", "")
+	require.NoError(t, err)
+	other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+
+	eventURL := func(calendarID int) string {
+		return testServer.URL + "/calendar-event/" + strconv.Itoa(calendarID) + "/" + strconv.Itoa(user.Event.EventID)
+	}
+	do := func(method, url string, body interface{}) (int, common.JSONResponse) {
+		var reader *bytes.Reader
+		if body != nil {
+			jsonData, err := json.Marshal(body)
+			require.NoError(t, err)
+			reader = bytes.NewReader(jsonData)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req, err := http.NewRequest(method, url, reader)
+		require.NoError(t, err, "Erreur lors de la création de la requête")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+		resp, err := testClient.Do(req)
+		require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+		defer resp.Body.Close()
+		var response common.JSONResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+	eventDeleted := func() bool {
+		var deletedAt *time.Time
+		require.NoError(t, common.DB.QueryRow("SELECT deleted_at FROM event WHERE event_id = ?", user.Event.EventID).Scan(&deletedAt))
+		return deletedAt != nil
+	}
+
+	// Le retrait du seul calendrier de l'événement est refusé
+	status, response := do("DELETE", eventURL(user.Calendar.CalendarID)+"/link", nil)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrEventLastLink, response.Error)
+
+	// Le partage vers un calendrier sans accès est refusé
+	status, _ = do("POST", eventURL(user.Calendar.CalendarID)+"/link", map[string]interface{}{"target_calendar_id": other.Calendar.CalendarID})
+	require.Equal(t, http.StatusForbidden, status)
+
+	// Partage vers le calendrier d'équipe, puis doublon refusé
+	status, response = do("POST", eventURL(user.Calendar.CalendarID)+"/link", map[string]interface{}{"target_calendar_id": teamCalendarID})
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, common.MsgSuccessLinkEvent, response.Message)
+	status, response = do("POST", eventURL(user.Calendar.CalendarID)+"/link", map[string]interface{}{"target_calendar_id": teamCalendarID})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrEventAlreadyLinked, response.Error)
+
+	// L'événement est visible depuis le calendrier d'équipe
+	status, _ = do("GET", eventURL(teamCalendarID), nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// Supprimer depuis le calendrier personnel ne fait que retirer la liaison
+	status, response = do("DELETE", eventURL(user.Calendar.CalendarID), nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessUnlinkEvent, response.Message)
+	require.False(t, eventDeleted(), "L'événement partagé ne doit pas être supprimé")
+
+	// Une seconde suppression depuis le même calendrier ne trouve plus l'événement
+	status, _ = do("DELETE", eventURL(user.Calendar.CalendarID), nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	// La suppression depuis le dernier calendrier supprime l'événement
+	status, response = do("DELETE", eventURL(teamCalendarID), nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessDeleteEvent, response.Message)
+	require.True(t, eventDeleted(), "L'événement doit être supprimé avec sa dernière liaison")
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,598 @@
+// Package calendar_template internal/calendar_template/calendar_template.go
+package calendar_template
+
+import (
+	"database/sql"
+	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarTemplateStruct struct{}
+
+var CalendarTemplate = CalendarTemplateStruct{}
+
+// List récupère les modèles de calendrier publiés
+// @Summary Lister les modèles de calendrier
+// @Description Récupère les modèles de calendrier publiés par les administrateurs (sans leurs événements)
+// @Tags Modèle de calendrier
+// @Produce json
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /templates [get]
+func (CalendarTemplateStruct) List(c *gin.Context) {
+	slog.Info(common.LogTemplateList)
+	if _, ok := common.GetUserFromContext(c); !ok {
+		return
+	}
+
+	rows, err := common.DB.Query(`
+		SELECT template_id, title, description, color, created_by, created_at, updated_at, deleted_at
+		FROM calendar_template
+		WHERE deleted_at IS NULL
+		ORDER BY title
+	`)
+	if err != nil {
+		slog.Error(common.LogTemplateList + " - erreur lors de la récupération des modèles : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	templates := []common.CalendarTemplate{}
+	for rows.Next() {
+		var template common.CalendarTemplate
+		if err := rows.Scan(&template.TemplateID, &template.Title, &template.Description, &template.Color, &template.CreatedBy, &template.CreatedAt, &template.UpdatedAt, &template.DeletedAt); err != nil {
+			slog.Error(common.LogTemplateList + " - erreur lors de la lecture des modèles : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTemplateRetrieval,
+			})
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	slog.Info(common.LogTemplateList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListTemplates,
+		Data:    templates,
+	})
+}
+
+// Get récupère un modèle de calendrier avec ses événements
+// @Summary Récupérer un modèle de calendrier
+// @Description Récupère un modèle de calendrier et ses événements (décalages relatifs à la date de début)
+// @Tags Modèle de calendrier
+// @Produce json
+// @Param template_id path int true "ID du modèle"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /templates/{template_id} [get]
+func (CalendarTemplateStruct) Get(c *gin.Context) {
+	slog.Info(common.LogTemplateGet)
+	templateData, ok := common.GetTemplateFromContext(c)
+	if !ok {
+		return
+	}
+
+	events, err := loadTemplateEvents(common.DB, templateData.TemplateID)
+	if err != nil {
+		slog.Error(common.LogTemplateGet + " - erreur lors de la récupération des événements du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateRetrieval,
+		})
+		return
+	}
+	templateData.Events = events
+
+	slog.Info(common.LogTemplateGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetTemplate,
+		Data:    templateData,
+	})
+}
+
+// Add publie un nouveau modèle de calendrier (admin)
+// @Summary Publier un modèle de calendrier
+// @Description Crée un modèle de calendrier réutilisable et ses événements, positionnés par un décalage en minutes depuis la date de début
+// @Tags Modèle de calendrier
+// @Accept json
+// @Produce json
+// @Param template body common.CreateCalendarTemplateRequest true "Données du modèle"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Router /templates [post]
+func (CalendarTemplateStruct) Add(c *gin.Context) {
+	slog.Info(common.LogTemplateAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateCalendarTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTemplateAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	if errMsg := validateTemplate(req.Color, req.Events); errMsg != "" {
+		slog.Error(common.LogTemplateAdd + " - " + errMsg)
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTemplateAdd + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO calendar_template (title, description, color, created_by, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, req.Title, req.Description, req.Color, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogTemplateAdd + " - erreur lors de la création du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateCreation,
+		})
+		return
+	}
+	templateID, _ := result.LastInsertId()
+
+	if err := insertTemplateEvents(tx, int(templateID), req.Events); err != nil {
+		slog.Error(common.LogTemplateAdd + " - erreur lors de la création des événements du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateCreation,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTemplateAdd + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTemplateAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateTemplate,
+		Data: gin.H{
+			"template_id": templateID,
+		},
+	})
+}
+
+// Update met à jour un modèle de calendrier (admin)
+// @Summary Mettre à jour un modèle de calendrier
+// @Description Met à jour les informations d'un modèle. Si "events" est fourni, il remplace la liste complète des événements du modèle. Les calendriers déjà instanciés ne sont pas modifiés.
+// @Tags Modèle de calendrier
+// @Accept json
+// @Produce json
+// @Param template_id path int true "ID du modèle"
+// @Param template body common.UpdateCalendarTemplateRequest true "Données du modèle"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /templates/{template_id} [put]
+func (CalendarTemplateStruct) Update(c *gin.Context) {
+	slog.Info(common.LogTemplateUpdate)
+	templateData, ok := common.GetTemplateFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateCalendarTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTemplateUpdate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	var events []common.TemplateEventRequest
+	if req.Events != nil {
+		events = *req.Events
+	}
+	// Une couleur vide retire la couleur du modèle
+	color := req.Color
+	if color != nil && *color == "" {
+		color = nil
+	}
+	if errMsg := validateTemplate(color, events); errMsg != "" {
+		slog.Error(common.LogTemplateUpdate + " - " + errMsg)
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTemplateUpdate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE calendar_template SET updated_at = NOW()"
+	var args []interface{}
+	if req.Title != nil {
+		query += ", title = ?"
+		args = append(args, *req.Title)
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, *req.Description)
+	}
+	if req.Color != nil {
+		query += ", color = ?"
+		args = append(args, color)
+	}
+	query += " WHERE template_id = ?"
+	args = append(args, templateData.TemplateID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		slog.Error(common.LogTemplateUpdate + " - erreur lors de la mise à jour du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateUpdate,
+		})
+		return
+	}
+
+	// Remplacer les événements s'ils sont fournis (une liste vide les retire tous)
+	if req.Events != nil {
+		_, err := tx.Exec("UPDATE calendar_template_event SET deleted_at = NOW() WHERE template_id = ? AND deleted_at IS NULL", templateData.TemplateID)
+		if err == nil {
+			err = insertTemplateEvents(tx, templateData.TemplateID, events)
+		}
+		if err != nil {
+			slog.Error(common.LogTemplateUpdate + " - erreur lors du remplacement des événements du modèle : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTemplateUpdate,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTemplateUpdate + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTemplateUpdate + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateTemplate,
+	})
+}
+
+// Delete supprime un modèle de calendrier (admin)
+// @Summary Supprimer un modèle de calendrier
+// @Description Supprime un modèle et ses événements. Les calendriers déjà instanciés ne sont pas modifiés.
+// @Tags Modèle de calendrier
+// @Produce json
+// @Param template_id path int true "ID du modèle"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /templates/{template_id} [delete]
+func (CalendarTemplateStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogTemplateDelete)
+	templateData, ok := common.GetTemplateFromContext(c)
+	if !ok {
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTemplateDelete + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE calendar_template SET deleted_at = NOW() WHERE template_id = ?", templateData.TemplateID)
+	if err == nil {
+		_, err = tx.Exec("UPDATE calendar_template_event SET deleted_at = NOW() WHERE template_id = ? AND deleted_at IS NULL", templateData.TemplateID)
+	}
+	if err != nil {
+		slog.Error(common.LogTemplateDelete + " - erreur lors de la suppression du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateDelete,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTemplateDelete + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTemplateDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteTemplate,
+	})
+}
+
+// Instantiate crée les événements d'un modèle dans un calendrier à partir d'une date de début
+// @Summary Appliquer un modèle de calendrier
+// @Description Crée les événements du modèle à partir de la date de début choisie, dans un calendrier existant (calendar_id, accès requis) ou dans un nouveau calendrier
+// @Tags Modèle de calendrier
+// @Accept json
+// @Produce json
+// @Param template_id path int true "ID du modèle"
+// @Param instantiate body common.InstantiateTemplateRequest true "Date de début et calendrier cible"
+// @Success 201 {object} common.JSONResponse{data=common.InstantiateTemplateResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /templates/{template_id}/instantiate [post]
+func (CalendarTemplateStruct) Instantiate(c *gin.Context) {
+	slog.Info(common.LogTemplateInstantiate)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	templateData, ok := common.GetTemplateFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.InstantiateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTemplateInstantiate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	startDate, err := ParseStartDate(req.StartDate)
+	if err != nil {
+		slog.Error(common.LogTemplateInstantiate + " - date de début invalide : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTemplateStartDate,
+		})
+		return
+	}
+
+	events, err := loadTemplateEvents(common.DB, templateData.TemplateID)
+	if err != nil {
+		slog.Error(common.LogTemplateInstantiate + " - erreur lors de la récupération des événements du modèle : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTemplateRetrieval,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTemplateInstantiate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	response := common.InstantiateTemplateResponse{
+		TemplateID: templateData.TemplateID,
+		EventIDs:   make([]int, 0, len(events)),
+	}
+
+	if req.CalendarID != nil {
+		// Calendrier existant : l'accès est vérifié ici, la route ne porte pas de calendar_id
+		if err := calendar_event.CheckCalendarAccess(tx, userData.UserID, *req.CalendarID); err != nil {
+			slog.Error(common.LogTemplateInstantiate + " - calendrier cible : " + err.Error())
+			status, errMsg := calendar_event.ErrorResponse(err)
+			c.JSON(status, common.JSONResponse{
+				Success: false,
+				Error:   errMsg,
+			})
+			return
+		}
+		response.CalendarID = *req.CalendarID
+	} else {
+		title := templateData.Title
+		if req.Title != nil {
+			title = *req.Title
+		}
+		calendarID, err := createCalendar(tx, userData.UserID, title, templateData.Description, templateData.Color)
+		if err != nil {
+			slog.Error(common.LogTemplateInstantiate + " - erreur lors de la création du calendrier : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrCalendarCreation,
+			})
+			return
+		}
+		response.CalendarID = calendarID
+		response.CalendarCreated = true
+	}
+
+	for _, event := range events {
+		eventID, err := calendar_event.CreateEvent(tx, userData.UserID, response.CalendarID, common.CreateEventRequest{
+			Title:       event.Title,
+			Description: event.Description,
+			Start:       startDate.Add(time.Duration(event.OffsetMinutes) * time.Minute),
+			Duration:    event.Duration,
+			CalendarID:  response.CalendarID,
+			Location:    event.Location,
+			MeetingURL:  event.MeetingURL,
+		})
+		if err != nil {
+			slog.Error(common.LogTemplateInstantiate + " - " + err.Error())
+			status, errMsg := calendar_event.ErrorResponse(err)
+			c.JSON(status, common.JSONResponse{
+				Success: false,
+				Error:   errMsg,
+			})
+			return
+		}
+		response.EventIDs = append(response.EventIDs, int(eventID))
+	}
+	response.EventsCreated = len(response.EventIDs)
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTemplateInstantiate + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTemplateInstantiate + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessInstantiateTemplate,
+		Data:    response,
+	})
+}
+
+// ParseStartDate accepte une date (YYYY-MM-DD, minuit) ou un horodatage RFC3339
+func ParseStartDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// validateTemplate vérifie la couleur et les liens de visioconférence d'un modèle.
+// Retourne un message d'erreur non vide si les données sont invalides.
+func validateTemplate(color *string, events []common.TemplateEventRequest) string {
+	if color != nil && !common.IsValidHexColor(*color) {
+		return common.ErrInvalidColor
+	}
+	for _, event := range events {
+		if event.MeetingURL != nil && *event.MeetingURL != "" && !common.IsValidMeetingURL(*event.MeetingURL) {
+			return common.ErrInvalidMeetingURL
+		}
+	}
+	return ""
+}
+
+// insertTemplateEvents ajoute les événements au modèle dans la transaction
+func insertTemplateEvents(tx *sql.Tx, templateID int, events []common.TemplateEventRequest) error {
+	for _, event := range events {
+		_, err := tx.Exec(`
+			INSERT INTO calendar_template_event (template_id, title, description, offset_minutes, duration, location, meeting_url, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, NOW())
+		`, templateID, event.Title, event.Description, event.OffsetMinutes, event.Duration, common.NullIfEmpty(event.Location), common.NullIfEmpty(event.MeetingURL))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryer est implémentée par *sql.DB et *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadTemplateEvents récupère les événements actifs d'un modèle, dans l'ordre chronologique
+func loadTemplateEvents(db queryer, templateID int) ([]common.CalendarTemplateEvent, error) {
+	rows, err := db.Query(`
+		SELECT template_event_id, template_id, title, description, offset_minutes, duration, location, meeting_url, created_at, updated_at, deleted_at
+		FROM calendar_template_event
+		WHERE template_id = ? AND deleted_at IS NULL
+		ORDER BY offset_minutes, template_event_id
+	`, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []common.CalendarTemplateEvent{}
+	for rows.Next() {
+		var event common.CalendarTemplateEvent
+		if err := rows.Scan(&event.TemplateEventID, &event.TemplateID, &event.Title, &event.Description, &event.OffsetMinutes, &event.Duration, &event.Location, &event.MeetingURL, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// createCalendar crée un calendrier et donne accès à l'utilisateur dans la transaction
+func createCalendar(tx *sql.Tx, userID int, title string, description, color *string) (int, error) {
+	result, err := tx.Exec(`
+		INSERT INTO calendar (title, description, color, created_at)
+		VALUES (?, ?, ?, NOW())
+	`, title, description, color)
+	if err != nil {
+		return 0, err
+	}
+	calendarID, _ := result.LastInsertId()
+	_, err = tx.Exec(`
+		INSERT INTO user_calendar (user_id, calendar_id, created_at)
+		VALUES (?, ?, NOW())
+	`, userID, calendarID)
+	if err != nil {
+		return 0, err
+	}
+	return int(calendarID), nil
+}
@@ -0,0 +1,219 @@
+package calendar_template_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// onboardingTemplate retourne un modèle de deux événements : J0 9h et J1 14h
+func onboardingTemplate() map[string]interface{} {
+	return map[string]interface{}{
+		"title": "Intégration",
+		"color": "#3366FF",
+		"events": []map[string]interface{}{
+			{"title": "Accueil", "offset_minutes": 9 * 60, "duration": 60},
+			{"title": "Point RH", "offset_minutes": 24*60 + 14*60, "duration": 30},
+		},
+	}
+}
+
+// createTemplate publie le modèle d'intégration avec un administrateur et retourne son identifiant
+func createTemplate(t *testing.T) int {
+	admin, err := testutils.GenerateAuthenticatedAdmin(true, true, false, false)
+	require.NoError(t, err)
+	status, response := testutils.DoRequest(t, testServer, admin.SessionToken, "POST", "/templates", onboardingTemplate())
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var data struct {
+		TemplateID int `json:"template_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	return data.TemplateID
+}
+
+// TestAddTemplateRoute teste la publication d'un modèle avec plusieurs cas
+func TestAddTemplateRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		Admin            bool
+		RequestData      func() map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName:         "Publication réussie par un administrateur",
+			Admin:            true,
+			RequestData:      onboardingTemplate,
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateTemplate,
+		},
+		{
+			CaseName:         "Échec de la publication par un utilisateur non administrateur",
+			Admin:            false,
+			RequestData:      onboardingTemplate,
+			ExpectedHttpCode: http.StatusForbidden,
+		},
+		{
+			CaseName: "Échec de la publication avec une couleur invalide",
+			Admin:    true,
+			RequestData: func() map[string]interface{} {
+				data := onboardingTemplate()
+				data["color"] = "bleu"
+				return data
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidColor,
+		},
+		{
+			CaseName: "Échec de la publication avec un décalage négatif",
+			Admin:    true,
+			RequestData: func() map[string]interface{} {
+				data := onboardingTemplate()
+				data["events"] = []map[string]interface{}{{"title": "Veille", "offset_minutes": -60, "duration": 30}}
+				return data
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			var user *testutils.AuthenticatedUser
+			var err error
+			if testCase.Admin {
+				user, err = testutils.GenerateAuthenticatedAdmin(true, true, false, false)
+			} else {
+				user, err = testutils.GenerateAuthenticatedUser(true, true, false, false)
+			}
+			require.NoError(t, err)
+
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/templates", testCase.RequestData())
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestInstantiateTemplateRoute teste l'application d'un modèle avec plusieurs cas
+func TestInstantiateTemplateRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      func(user *testutils.AuthenticatedUser) map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedCreated  bool
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Application dans un nouveau calendrier",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"start_date": "2025-09-01"}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedCreated:  true,
+		},
+		{
+			CaseName: "Application dans un calendrier existant",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"start_date": "2025-09-01", "calendar_id": user.Calendar.CalendarID}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+		},
+		{
+			CaseName: "Échec de l'application dans un calendrier sans accès",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return map[string]interface{}{"start_date": "2025-09-01", "calendar_id": other.Calendar.CalendarID}
+			},
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrNoAccessToCalendar,
+		},
+		{
+			CaseName: "Échec de l'application avec une date invalide",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"start_date": "01/09/2025"}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidTemplateStartDate,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			templateID := createTemplate(t)
+			user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+			require.NoError(t, err)
+
+			url := "/templates/" + strconv.Itoa(templateID) + "/instantiate"
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", url, testCase.RequestData(user))
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			} else {
+				require.Equal(t, common.MsgSuccessInstantiateTemplate, response.Message, "Message de succès incorrect")
+				var data common.InstantiateTemplateResponse
+				require.NoError(t, json.Unmarshal(response.Data, &data))
+				require.Equal(t, testCase.ExpectedCreated, data.CalendarCreated)
+				if !testCase.ExpectedCreated {
+					require.Equal(t, user.Calendar.CalendarID, data.CalendarID)
+				}
+				require.Equal(t, 2, data.EventsCreated)
+
+				// Les dates sont calculées à partir des décalages du modèle
+				expected := []time.Time{
+					time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC),
+					time.Date(2025, 9, 2, 14, 0, 0, 0, time.UTC),
+				}
+				for i, eventID := range data.EventIDs {
+					var start time.Time
+					require.NoError(t, common.DB.QueryRow("SELECT start FROM event WHERE event_id = ?", eventID).Scan(&start))
+					require.True(t, expected[i].Equal(start), "Date de début incorrecte pour l'événement %d", i)
+				}
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
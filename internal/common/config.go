@@ -2,6 +2,9 @@ package common
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DBConfig struct {
@@ -23,6 +26,287 @@ func LoadDBConfig() DBConfig {
 	}
 }
 
+// StorageConfig décrit le stockage des pièces jointes et les limites d'upload
+type StorageConfig struct {
+	Backend           string // "local" ou "s3"
+	LocalDir          string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKey       string
+	S3SecretKey       string
+	MaxAttachmentSize int64
+	AllowedMimeTypes  []string
+}
+
+// defaultAllowedMimeTypes liste les types acceptés par défaut (documents, présentations, images)
+var defaultAllowedMimeTypes = []string{
+	"application/pdf",
+	"text/plain",
+	"text/csv",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"application/msword",
+	"application/vnd.ms-excel",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.oasis.opendocument.text",
+	"application/vnd.oasis.opendocument.spreadsheet",
+	"application/vnd.oasis.opendocument.presentation",
+}
+
+// LoadStorageConfig charge la configuration du stockage des pièces jointes depuis les variables d'environnement
+func LoadStorageConfig() StorageConfig {
+	allowed := defaultAllowedMimeTypes
+	if value := getEnv("ATTACHMENT_ALLOWED_TYPES", ""); value != "" {
+		allowed = nil
+		for _, mimeType := range strings.Split(value, ",") {
+			if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+				allowed = append(allowed, strings.ToLower(mimeType))
+			}
+		}
+	}
+	maxSize, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_SIZE", "10485760"), 10, 64)
+	if err != nil || maxSize < 1 {
+		maxSize = 10 << 20
+	}
+	return StorageConfig{
+		Backend:           getEnv("STORAGE_BACKEND", "local"),
+		LocalDir:          getEnv("STORAGE_LOCAL_DIR", "data/attachments"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+		MaxAttachmentSize: maxSize,
+		AllowedMimeTypes:  allowed,
+	}
+}
+
+// LoadHolidayCountries retourne les codes pays (ISO 3166-1 alpha-2, en majuscules) dont les calendriers
+// de jours fériés sont proposés aux utilisateurs
+func LoadHolidayCountries() []string {
+	var countries []string
+	for _, code := range strings.Split(getEnv("HOLIDAY_COUNTRIES", "FR"), ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			countries = append(countries, code)
+		}
+	}
+	return countries
+}
+
+// WebPushConfig décrit la clé VAPID utilisée pour signer les notifications Web Push
+type WebPushConfig struct {
+	VAPIDPrivateKey string // Scalaire P-256 brut en base64url ; vide : clé éphémère
+	VAPIDSubject    string // Contact de l'exploitant transmis aux services de push (mailto: ou https:)
+}
+
+// LoadWebPushConfig charge la configuration Web Push depuis les variables d'environnement
+func LoadWebPushConfig() WebPushConfig {
+	return WebPushConfig{
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+	}
+}
+
+// MailConfig décrit l'envoi des e-mails transactionnels
+type MailConfig struct {
+	Backend      string // "log" (par défaut, e-mails écrits dans les logs), "smtp" ou "memory" (tests)
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	From         string // Adresse d'expédition
+	AppURL       string // URL du front-end utilisée dans les liens envoyés par e-mail
+}
+
+// LoadMailConfig charge la configuration de l'envoi des e-mails depuis les variables d'environnement
+func LoadMailConfig() MailConfig {
+	port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil || port < 1 {
+		port = 587
+	}
+	return MailConfig{
+		Backend:      getEnv("MAIL_BACKEND", "log"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     port,
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		From:         getEnv("MAIL_FROM", "GoLendar <noreply@localhost>"),
+		AppURL:       getEnv("MAIL_APP_URL", "http://localhost:3000"),
+	}
+}
+
+// Politiques de vérification de l'adresse e-mail (EMAIL_VERIFICATION_POLICY)
+const (
+	EmailVerificationPolicyNone   = "none"   // Le lien de vérification est envoyé mais rien n'est bloqué
+	EmailVerificationPolicyLogin  = "login"  // La connexion est refusée tant que l'adresse n'est pas vérifiée
+	EmailVerificationPolicyRoutes = "routes" // La connexion est permise, les routes métier sont refusées
+)
+
+// LoadEmailVerificationPolicy retourne la politique appliquée aux comptes dont l'adresse n'est pas vérifiée
+func LoadEmailVerificationPolicy() string {
+	return strings.ToLower(getEnv("EMAIL_VERIFICATION_POLICY", EmailVerificationPolicyNone))
+}
+
+// WebAuthnConfig décrit la partie de confiance (relying party) à laquelle les passkeys sont rattachées
+type WebAuthnConfig struct {
+	RPID    string   // Domaine des passkeys, sans schéma ni port (le domaine du front-end ou un domaine parent)
+	RPName  string   // Nom affiché par l'authentificateur lors de l'enregistrement
+	Origins []string // Origines autorisées du front-end (schéma, hôte et port)
+}
+
+// LoadWebAuthnConfig charge la configuration WebAuthn depuis les variables d'environnement
+func LoadWebAuthnConfig() WebAuthnConfig {
+	var origins []string
+	for _, origin := range strings.Split(getEnv("WEBAUTHN_ORIGINS", "http://localhost:3000"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return WebAuthnConfig{
+		RPID:    getEnv("WEBAUTHN_RP_ID", "localhost"),
+		RPName:  getEnv("WEBAUTHN_RP_NAME", "GoLendar"),
+		Origins: origins,
+	}
+}
+
+// OIDCConfig décrit le fournisseur d'identité OpenID Connect de la connexion SSO
+type OIDCConfig struct {
+	Issuer        string            // URL de l'émetteur (découverte sur /.well-known/openid-configuration) ; vide : SSO désactivé
+	ClientID      string            // Identifiant du client enregistré chez le fournisseur
+	ClientSecret  string            // Secret du client ; vide pour un client public (PKCE seul)
+	RedirectURL   string            // Page du front-end qui reçoit code et state et les transmet à /auth/oidc/callback
+	Scopes        []string          // Scopes demandés, openid compris
+	AutoProvision bool              // Crée le compte à la première connexion d'une adresse inconnue
+	TrustEmail    bool              // Considère l'adresse comme vérifiée quand le fournisseur ne transmet pas email_verified
+	RolesClaim    string            // Claim de l'ID token contenant les rôles ou groupes ; vide : aucun rôle attribué
+	RoleMapping   map[string]string // Valeur du claim -> rôle de l'application ; vide : valeurs utilisées comme noms de rôles
+}
+
+// LoadOIDCConfig charge la configuration OpenID Connect depuis les variables d'environnement
+func LoadOIDCConfig() OIDCConfig {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(getEnv("OIDC_ROLE_MAPPING", ""), ",") {
+		if claim, role, ok := strings.Cut(pair, "="); ok && strings.TrimSpace(claim) != "" && strings.TrimSpace(role) != "" {
+			mapping[strings.TrimSpace(claim)] = strings.TrimSpace(role)
+		}
+	}
+	autoProvision, _ := strconv.ParseBool(getEnv("OIDC_AUTO_PROVISION", "false"))
+	trustEmail, _ := strconv.ParseBool(getEnv("OIDC_TRUST_EMAIL", "false"))
+	return OIDCConfig{
+		Issuer:        getEnv("OIDC_ISSUER", ""),
+		ClientID:      getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:   getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/auth/callback"),
+		Scopes:        strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		AutoProvision: autoProvision,
+		TrustEmail:    trustEmail,
+		RolesClaim:    getEnv("OIDC_ROLES_CLAIM", ""),
+		RoleMapping:   mapping,
+	}
+}
+
+// LDAPConfig décrit l'annuaire LDAP consulté à la connexion par mot de passe
+type LDAPConfig struct {
+	URL             string            // ldaps://hôte[:port] ou ldap://hôte[:port] ; vide : authentification LDAP désactivée
+	StartTLS        bool              // Chiffre une connexion ldap:// par StartTLS avant toute liaison
+	BindDN          string            // Compte de service utilisé pour rechercher les utilisateurs ; vide : recherche anonyme
+	BindPassword    string            // Mot de passe du compte de service
+	BaseDN          string            // Branche de l'annuaire dans laquelle les utilisateurs sont recherchés
+	LoginAttribute  string            // Attribut comparé à l'adresse e-mail saisie
+	UserObjectClass string            // Classe d'objet des utilisateurs ; vide : aucune restriction
+	GroupAttribute  string            // Attribut de l'entrée listant les DN de ses groupes
+	RoleMapping     map[string]string // DN de groupe -> rôle de l'application
+	AutoProvision   bool              // Crée le compte à la première connexion d'un utilisateur de l'annuaire
+}
+
+// LoadLDAPConfig charge la configuration LDAP depuis les variables d'environnement
+func LoadLDAPConfig() LDAPConfig {
+	// Les DN contenant des virgules et des signes égal, les paires sont séparées par des points-virgules
+	// et le rôle suit le dernier signe égal
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(getEnv("LDAP_ROLE_MAPPING", ""), ";") {
+		if i := strings.LastIndex(pair, "="); i > 0 && strings.TrimSpace(pair[:i]) != "" && strings.TrimSpace(pair[i+1:]) != "" {
+			mapping[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+		}
+	}
+	startTLS, _ := strconv.ParseBool(getEnv("LDAP_START_TLS", "false"))
+	autoProvision, _ := strconv.ParseBool(getEnv("LDAP_AUTO_PROVISION", "false"))
+	return LDAPConfig{
+		URL:             getEnv("LDAP_URL", ""),
+		StartTLS:        startTLS,
+		BindDN:          getEnv("LDAP_BIND_DN", ""),
+		BindPassword:    getEnv("LDAP_BIND_PASSWORD", ""),
+		BaseDN:          getEnv("LDAP_BASE_DN", ""),
+		LoginAttribute:  getEnv("LDAP_LOGIN_ATTRIBUTE", "mail"),
+		UserObjectClass: getEnv("LDAP_USER_OBJECT_CLASS", "person"),
+		GroupAttribute:  getEnv("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+		RoleMapping:     mapping,
+		AutoProvision:   autoProvision,
+	}
+}
+
+// Formats des tokens d'accès (ACCESS_TOKEN_FORMAT)
+const (
+	AccessTokenFormatOpaque = "opaque" // Token aléatoire vérifié en base à chaque requête
+	AccessTokenFormatJWT    = "jwt"    // JWT signé (EdDSA), vérifié sans accès à la base
+	AccessTokenFormatPASETO = "paseto" // PASETO v4.public, vérifié sans accès à la base
+)
+
+// AccessTokenKey est une clé Ed25519 de signature des tokens d'accès
+type AccessTokenKey struct {
+	ID   string // Identifiant transmis dans le token (kid)
+	Seed string // Graine Ed25519 de 32 octets en base64url
+}
+
+// AccessTokenConfig décrit les tokens d'accès signés, remis à la place des tokens de session opaques
+type AccessTokenConfig struct {
+	Format         string           // "opaque" (par défaut), "jwt" ou "paseto"
+	Keys           []AccessTokenKey // La première clé signe, les suivantes ne servent qu'à vérifier ; vide : clé éphémère
+	TTL            time.Duration    // Durée de vie d'un token d'accès ; les rôles et la vérification de l'adresse qu'il porte ne sont rafraîchis qu'à l'émission
+	Issuer         string           // Émetteur (iss) des tokens
+	RevocationSync time.Duration    // Intervalle de synchronisation de la liste de révocation avec la base
+}
+
+// LoadAccessTokenConfig charge la configuration des tokens d'accès depuis les variables d'environnement
+func LoadAccessTokenConfig() AccessTokenConfig {
+	var keys []AccessTokenKey
+	for _, pair := range strings.Split(getEnv("ACCESS_TOKEN_KEYS", ""), ",") {
+		if id, seed, ok := strings.Cut(pair, "="); ok && strings.TrimSpace(id) != "" {
+			keys = append(keys, AccessTokenKey{ID: strings.TrimSpace(id), Seed: strings.TrimSpace(seed)})
+		}
+	}
+	ttl, err := time.ParseDuration(getEnv("ACCESS_TOKEN_TTL", "5m"))
+	if err != nil {
+		ttl = 5 * time.Minute
+	}
+	sync, err := time.ParseDuration(getEnv("ACCESS_TOKEN_REVOCATION_SYNC", "30s"))
+	if err != nil {
+		sync = 30 * time.Second
+	}
+	return AccessTokenConfig{
+		Format:         strings.ToLower(getEnv("ACCESS_TOKEN_FORMAT", AccessTokenFormatOpaque)),
+		Keys:           keys,
+		TTL:            ttl,
+		Issuer:         getEnv("ACCESS_TOKEN_ISSUER", "golendar"),
+		RevocationSync: sync,
+	}
+}
+
+// LoadReminderInterval retourne l'intervalle de recherche des rappels d'événements à envoyer (REMINDER_CHECK_INTERVAL) ;
+// 0 désactive l'envoi sur cette instance
+func LoadReminderInterval() time.Duration {
+	interval, err := time.ParseDuration(getEnv("REMINDER_CHECK_INTERVAL", "1m"))
+	if err != nil || interval < 0 {
+		return time.Minute
+	}
+	return interval
+}
+
 // getEnv retourne la valeur d'une variable d'environnement ou une valeur par défaut si elle n'est pas définie.
 func getEnv(key, defaultVal string) string {
 	if value := os.Getenv(key); value != "" {
@@ -77,3 +77,141 @@ func GetEventFromContext(c *gin.Context) (Event, bool) {
 	}
 	return eventData, true
 }
+
+// GetTagFromContext récupère l'étiquette du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetTagFromContext(c *gin.Context) (Tag, bool) {
+	tag, exists := c.Get("tag")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrTagNotFound,
+		})
+		return Tag{}, false
+	}
+	tagData, ok := tag.(Tag)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextTagType,
+		})
+		return Tag{}, false
+	}
+	return tagData, true
+}
+
+// GetAttachmentFromContext récupère la pièce jointe du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetAttachmentFromContext(c *gin.Context) (Attachment, bool) {
+	attachment, exists := c.Get("attachment")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrAttachmentNotFound,
+		})
+		return Attachment{}, false
+	}
+	attachmentData, ok := attachment.(Attachment)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextAttachmentType,
+		})
+		return Attachment{}, false
+	}
+	return attachmentData, true
+}
+
+// GetTemplateFromContext récupère le modèle de calendrier du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetTemplateFromContext(c *gin.Context) (CalendarTemplate, bool) {
+	template, exists := c.Get("template")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrTemplateNotFound,
+		})
+		return CalendarTemplate{}, false
+	}
+	templateData, ok := template.(CalendarTemplate)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextTemplateType,
+		})
+		return CalendarTemplate{}, false
+	}
+	return templateData, true
+}
+
+// GetTaskFromContext récupère la tâche du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetTaskFromContext(c *gin.Context) (Task, bool) {
+	task, exists := c.Get("task")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrTaskNotFound,
+		})
+		return Task{}, false
+	}
+	taskData, ok := task.(Task)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextTaskType,
+		})
+		return Task{}, false
+	}
+	return taskData, true
+}
+
+// GetResourceFromContext récupère la ressource réservable du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetResourceFromContext(c *gin.Context) (Resource, bool) {
+	resource, exists := c.Get("resource")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrResourceNotFound,
+		})
+		return Resource{}, false
+	}
+	resourceData, ok := resource.(Resource)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextResourceType,
+		})
+		return Resource{}, false
+	}
+	return resourceData, true
+}
+
+// GetBookingPageFromContext récupère la page de réservation du contexte Gin.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func GetBookingPageFromContext(c *gin.Context) (BookingPage, bool) {
+	page, exists := c.Get("booking_page")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrBookingPageNotFound,
+		})
+		return BookingPage{}, false
+	}
+	pageData, ok := page.(BookingPage)
+	if !ok {
+		// This case should ideally not happen if middleware is set correctly
+		c.JSON(http.StatusInternalServerError, JSONResponse{
+			Success: false,
+			Error:   ErrContextBookingPageType,
+		})
+		return BookingPage{}, false
+	}
+	return pageData, true
+}
@@ -1,192 +1,563 @@
 package common
 
 const (
-	MsgSuccessCreateUser         = "Utilisateur créé avec succès"
-	MsgSuccessGetUser            = "Utilisateur récupéré avec succès"
-	MsgSuccessUpdateUser         = "Utilisateur mis à jour avec succès"
-	MsgSuccessDeleteUser         = "Utilisateur supprimé avec succès"
-	MsgSuccessCreateCalendar     = "Calendrier créé avec succès"
-	MsgSuccessGetCalendar        = "Calendrier récupéré avec succès"
-	MsgSuccessUpdateCalendar     = "Calendrier mis à jour avec succès"
-	MsgSuccessDeleteCalendar     = "Calendrier supprimé avec succès"
-	MsgSuccessCreateEvent        = "Événement créé avec succès"
-	MsgSuccessUpdateEvent        = "Événement mis à jour avec succès"
-	MsgSuccessDeleteEvent        = "Événement supprimé avec succès"
-	MsgSuccessListEvents         = "Liste des événements récupérée avec succès"
-	MsgSuccessCreateUserCalendar = "Liaison utilisateur-calendrier créée avec succès"
-	MsgSuccessUpdateUserCalendar = "Liaison utilisateur-calendrier mise à jour avec succès"
-	MsgSuccessDeleteUserCalendar = "Liaison utilisateur-calendrier supprimée avec succès"
-	MsgSuccessListUserCalendars  = "Liste des calendriers récupérée avec succès"
-	MsgSuccessUserUpdate         = "Utilisateur mis à jour avec succès"
-	MsgSuccessUserDelete         = "Utilisateur supprimé avec succès"
-	MsgSuccessLogin              = "Connexion réussie"
-	MsgSuccessLogout             = "Déconnexion réussie"
-	MsgSuccessCreateRole         = "Rôle créé avec succès"
-	MsgSuccessUpdateRole         = "Rôle mis à jour avec succès"
-	MsgSuccessDeleteRole         = "Rôle supprimé avec succès"
-	MsgSuccessAssignRole         = "Rôle attribué avec succès"
-	MsgSuccessRevokeRole         = "Rôle révoqué avec succès"
-	MsgSuccessDeleteSession      = "Session supprimée avec succès"
-	MsgSuccessRefreshToken       = "Token rafraîchi avec succès"
+	MsgSuccessCreateUser                 = "Utilisateur créé avec succès"
+	MsgSuccessGetUser                    = "Utilisateur récupéré avec succès"
+	MsgSuccessUpdateUser                 = "Utilisateur mis à jour avec succès"
+	MsgSuccessDeleteUser                 = "Utilisateur supprimé avec succès"
+	MsgSuccessCreateCalendar             = "Calendrier créé avec succès"
+	MsgSuccessGetCalendar                = "Calendrier récupéré avec succès"
+	MsgSuccessUpdateCalendar             = "Calendrier mis à jour avec succès"
+	MsgSuccessDeleteCalendar             = "Calendrier supprimé avec succès"
+	MsgSuccessCreateEvent                = "Événement créé avec succès"
+	MsgSuccessUpdateEvent                = "Événement mis à jour avec succès"
+	MsgSuccessDeleteEvent                = "Événement supprimé avec succès"
+	MsgSuccessListEvents                 = "Liste des événements récupérée avec succès"
+	MsgSuccessCreateUserCalendar         = "Liaison utilisateur-calendrier créée avec succès"
+	MsgSuccessUpdateUserCalendar         = "Liaison utilisateur-calendrier mise à jour avec succès"
+	MsgSuccessDeleteUserCalendar         = "Liaison utilisateur-calendrier supprimée avec succès"
+	MsgSuccessListUserCalendars          = "Liste des calendriers récupérée avec succès"
+	MsgSuccessUserUpdate                 = "Utilisateur mis à jour avec succès"
+	MsgSuccessUserDelete                 = "Utilisateur supprimé avec succès"
+	MsgSuccessLogin                      = "Connexion réussie"
+	MsgSuccessLogout                     = "Déconnexion réussie"
+	MsgSuccessCreateRole                 = "Rôle créé avec succès"
+	MsgSuccessUpdateRole                 = "Rôle mis à jour avec succès"
+	MsgSuccessDeleteRole                 = "Rôle supprimé avec succès"
+	MsgSuccessAssignRole                 = "Rôle attribué avec succès"
+	MsgSuccessRevokeRole                 = "Rôle révoqué avec succès"
+	MsgSuccessDeleteSession              = "Session supprimée avec succès"
+	MsgSuccessRefreshToken               = "Token rafraîchi avec succès"
+	MsgSuccessSearchEvents               = "Recherche d'événements effectuée avec succès"
+	MsgSuccessCreateTag                  = "Étiquette créée avec succès"
+	MsgSuccessGetTag                     = "Étiquette récupérée avec succès"
+	MsgSuccessUpdateTag                  = "Étiquette mise à jour avec succès"
+	MsgSuccessDeleteTag                  = "Étiquette supprimée avec succès"
+	MsgSuccessListTags                   = "Liste des étiquettes récupérée avec succès"
+	MsgSuccessUploadAttachment           = "Pièce jointe ajoutée avec succès"
+	MsgSuccessListAttachments            = "Liste des pièces jointes récupérée avec succès"
+	MsgSuccessDeleteAttachment           = "Pièce jointe supprimée avec succès"
+	MsgSuccessBatchEvents                = "Opérations groupées appliquées avec succès"
+	MsgPartialBatchEvents                = "Opérations groupées appliquées partiellement"
+	MsgSuccessMoveEvents                 = "Événements déplacés avec succès"
+	MsgSuccessCopyEvents                 = "Événements copiés avec succès"
+	MsgSuccessLinkEvent                  = "Événement ajouté au calendrier avec succès"
+	MsgSuccessUnlinkEvent                = "Événement retiré du calendrier avec succès"
+	MsgSuccessCloneCalendar              = "Calendrier cloné avec succès"
+	MsgSuccessListTemplates              = "Liste des modèles de calendrier récupérée avec succès"
+	MsgSuccessGetTemplate                = "Modèle de calendrier récupéré avec succès"
+	MsgSuccessCreateTemplate             = "Modèle de calendrier publié avec succès"
+	MsgSuccessUpdateTemplate             = "Modèle de calendrier mis à jour avec succès"
+	MsgSuccessDeleteTemplate             = "Modèle de calendrier supprimé avec succès"
+	MsgSuccessInstantiateTemplate        = "Modèle de calendrier appliqué avec succès"
+	MsgSuccessListTasks                  = "Liste des tâches récupérée avec succès"
+	MsgSuccessGetTask                    = "Tâche récupérée avec succès"
+	MsgSuccessCreateTask                 = "Tâche créée avec succès"
+	MsgSuccessUpdateTask                 = "Tâche mise à jour avec succès"
+	MsgSuccessDeleteTask                 = "Tâche supprimée avec succès"
+	MsgSuccessListResources              = "Liste des ressources récupérée avec succès"
+	MsgSuccessGetResource                = "Ressource récupérée avec succès"
+	MsgSuccessCreateResource             = "Ressource créée avec succès"
+	MsgSuccessUpdateResource             = "Ressource mise à jour avec succès"
+	MsgSuccessDeleteResource             = "Ressource supprimée avec succès"
+	MsgSuccessResourceAvailability       = "Disponibilités de la ressource récupérées avec succès"
+	MsgSuccessListEventResources         = "Ressources réservées par l'événement récupérées avec succès"
+	MsgSuccessBookResource               = "Ressource réservée avec succès"
+	MsgSuccessReleaseResource            = "Réservation de la ressource annulée avec succès"
+	MsgSuccessListBookingPages           = "Liste des pages de réservation récupérée avec succès"
+	MsgSuccessGetBookingPage             = "Page de réservation récupérée avec succès"
+	MsgSuccessCreateBookingPage          = "Page de réservation créée avec succès"
+	MsgSuccessUpdateBookingPage          = "Page de réservation mise à jour avec succès"
+	MsgSuccessDeleteBookingPage          = "Page de réservation supprimée avec succès"
+	MsgSuccessListBookings               = "Liste des rendez-vous récupérée avec succès"
+	MsgSuccessBookingSlots               = "Créneaux disponibles récupérés avec succès"
+	MsgSuccessCreateBooking              = "Rendez-vous réservé avec succès"
+	MsgSuccessCancelBooking              = "Rendez-vous annulé avec succès"
+	MsgSuccessGetWorkingHours            = "Heures de travail récupérées avec succès"
+	MsgSuccessUpdateWorkingHours         = "Heures de travail mises à jour avec succès"
+	MsgSuccessListOutOfOffice            = "Liste des absences récupérée avec succès"
+	MsgSuccessCreateOutOfOffice          = "Absence enregistrée avec succès"
+	MsgSuccessDeleteOutOfOffice          = "Absence supprimée avec succès"
+	MsgSuccessCreateInvitations          = "Invitations envoyées avec succès"
+	MsgSuccessListInvitations            = "Liste des invitations récupérée avec succès"
+	MsgSuccessRespondInvitation          = "Réponse à l'invitation enregistrée avec succès"
+	MsgSuccessFreeBusy                   = "Disponibilités récupérées avec succès"
+	MsgSuccessFindSlots                  = "Créneaux communs récupérés avec succès"
+	MsgSuccessListHolidayCalendars       = "Calendriers de jours fériés récupérés avec succès"
+	MsgSuccessListHolidays               = "Jours fériés récupérés avec succès"
+	MsgSuccessSubscribeHolidayCalendar   = "Abonnement au calendrier de jours fériés effectué avec succès"
+	MsgSuccessUnsubscribeHolidayCalendar = "Désabonnement du calendrier de jours fériés effectué avec succès"
+	MsgSuccessGetAgenda                  = "Agenda récupéré avec succès"
+	MsgSuccessGetVAPIDPublicKey          = "Clé publique VAPID récupérée avec succès"
+	MsgSuccessListPushSubscriptions      = "Abonnements push récupérés avec succès"
+	MsgSuccessCreatePushSubscription     = "Abonnement push enregistré avec succès"
+	MsgSuccessDeletePushSubscription     = "Abonnement push supprimé avec succès"
+	MsgSuccessGetEventReminder           = "Rappel de l'événement récupéré avec succès"
+	MsgSuccessSetEventReminder           = "Rappel de l'événement enregistré avec succès"
+	MsgSuccessDeleteEventReminder        = "Rappel de l'événement supprimé avec succès"
+	MsgSuccessSendTestPush               = "Notification de test envoyée"
+	MsgPasswordResetRequested            = "Si un compte correspond à cette adresse, un e-mail de réinitialisation vient d'être envoyé"
+	MsgSuccessPasswordReset              = "Mot de passe réinitialisé avec succès, veuillez vous reconnecter"
+	MsgEmailVerificationResent           = "Si un compte non vérifié correspond à cette adresse, un nouveau lien de vérification vient d'être envoyé"
+	MsgSuccessEmailVerified              = "Adresse e-mail vérifiée avec succès"
+	MsgTwoFactorRequired                 = "Code d'authentification à deux facteurs requis"
+	MsgSuccessGetTwoFactorStatus         = "État de l'authentification à deux facteurs récupéré avec succès"
+	MsgSuccessTwoFactorSetup             = "Secret TOTP généré, confirmez l'activation avec un code de votre application"
+	MsgSuccessTwoFactorEnabled           = "Authentification à deux facteurs activée, conservez les codes de récupération en lieu sûr"
+	MsgSuccessTwoFactorDisabled          = "Authentification à deux facteurs désactivée"
+	MsgSuccessPasskeyOptions             = "Options de la cérémonie WebAuthn générées"
+	MsgSuccessPasskeyRegistered          = "Passkey enregistrée avec succès"
+	MsgSuccessListPasskeys               = "Passkeys récupérées avec succès"
+	MsgSuccessPasskeyDeleted             = "Passkey supprimée avec succès"
+	MsgSuccessOIDCAuthorize              = "URL de connexion au fournisseur d'identité générée"
+	MsgSuccessAccountUnlocked            = "Compte déverrouillé avec succès"
 )
 
 const (
-	LogAppStart                       = "[main][main]: Démarrage de l'application"
-	LogDBConnectionSuccess            = "[common][InitDB]: Connexion à la base de données réussie"
-	LogDBConnectionError              = "[common][InitDB]: Erreur de connexion à la base de données"
-	LogHTTPReceivedRequest            = "[http][middleware]: Requête reçue"
-	LogHTTPResponseSent               = "[http][middleware]: Réponse envoyée"
-	LogUserGet                        = "[user][Get]: Récupération d'un utilisateur"
-	LogUserAdd                        = "[user][Add]: Création d'un utilisateur"
-	LogUserUpdate                     = "[user][Update]: Mise à jour d'un utilisateur"
-	LogUserDelete                     = "[user][Delete]: Suppression d'un utilisateur"
-	LogCalendarGet                    = "[calendar][Get]: Récupération d'un calendrier"
-	LogCalendarAdd                    = "[calendar][Add]: Création d'un calendrier"
-	LogCalendarUpdate                 = "[calendar][Update]: Mise à jour d'un calendrier"
-	LogCalendarDelete                 = "[calendar][Delete]: Suppression d'un calendrier"
-	LogEventGet                       = "[calendar_event][Get]: Récupération d'un événement"
-	LogEventAdd                       = "[calendar_event][Add]: Création d'un événement"
-	LogEventUpdate                    = "[calendar_event][Update]: Mise à jour d'un événement"
-	LogEventDelete                    = "[calendar_event][Delete]: Suppression d'un événement"
-	LogEventList                      = "[calendar_event][List]: Récupération de la liste des événements d'un calendrier"
-	LogUserCalendarGet                = "[user_calendar][Get]: Récupération d'une liaison utilisateur-calendrier"
-	LogUserCalendarAdd                = "[user_calendar][Add]: Création d'une liaison utilisateur-calendrier"
-	LogUserCalendarUpdate             = "[user_calendar][Update]: Mise à jour d'une liaison utilisateur-calendrier"
-	LogUserCalendarDelete             = "[user_calendar][Delete]: Suppression d'une liaison utilisateur-calendrier"
-	LogUserCalendarList               = "[user_calendar][List]: Récupération de la liste des calendriers d'un utilisateur"
-	LogRoleGet                        = "[role][Get]: Récupération d'un rôle"
-	LogRoleList                       = "[role][List]: Récupération de la liste des rôles"
-	LogRolesRetrievalError            = "[role][List]: Erreur lors de la récupération des rôles: %v"
-	LogRoleCreate                     = "[role][Create]: Création d'un rôle"
-	LogInvalidData                    = "[common][Validation]: Données invalides: %v"
-	LogRoleCreateSuccess              = "[role][Create]: Rôle créé avec succès"
-	LogRoleUpdate                     = "[role][Update]: Mise à jour d'un rôle"
-	LogRoleUpdateSuccess              = "[role][Update]: Rôle mis à jour avec succès"
-	LogRoleDelete                     = "[role][Delete]: Suppression d'un rôle"
-	LogRoleDeleteSuccess              = "[role][Delete]: Rôle supprimé avec succès"
-	LogRoleAssign                     = "[role][Assign]: Attribution d'un rôle"
-	LogRoleAssignSuccess              = "[role][Assign]: Rôle attribué avec succès"
-	LogRoleRevoke                     = "[role][Revoke]: Révocation d'un rôle"
-	LogRoleRevokeSuccess              = "[role][Revoke]: Rôle révoqué avec succès"
-	LogRoleGetUserRoles               = "[role][GetUserRoles]: Récupération des rôles d'un utilisateur"
-	LogMissingUserID                  = "[role][GetUserRoles]: ID utilisateur manquant"
-	LogUserRolesRetrieved             = "[role][GetUserRoles]: Rôles récupérés avec succès"
-	LogLoginAttempt                   = "[session][Login]: Tentative de connexion"
-	LogInvalidLoginData               = "[session][Login]: Données de connexion invalides: %v"
-	LogInvalidPassword                = "[session][Login]: Mot de passe invalide"
-	LogLoginSuccess                   = "[session][Login]: Connexion réussie pour l'utilisateur %s"
-	LogLogoutAttempt                  = "[session][Logout]: Tentative de déconnexion"
-	LogMissingAuthHeader              = "[session][Auth]: Header Authorization manquant"
-	LogInvalidToken                   = "[session][Auth]: Token invalide"
-	LogLogoutSuccess                  = "[session][Logout]: Déconnexion réussie"
-	LogRefreshTokenAttempt            = "[session][RefreshToken]: Tentative de rafraîchissement du token"
-	LogInvalidRefreshToken            = "[session][RefreshToken]: Refresh token invalide"
-	LogRefreshTokenExpired            = "[session][RefreshToken]: Refresh token expiré"
-	LogTokenGenerationError           = "[session][Token]: Erreur lors de la génération du token: %v"
-	LogSessionUpdateError             = "[session][RefreshToken]: Erreur lors de la mise à jour de la session: %v"
-	LogSessionsRetrievalError         = "[session][GetUserSessions]: Erreur lors de la récupération des sessions: %v"
-	LogSessionReadingError            = "[session][GetUserSessions]: Erreur lors de la lecture d'une session: %v"
-	LogSessionsRetrieved              = "[session][GetUserSessions]: Sessions récupérées avec succès"
-	LogSessionNotFoundOrNotOwned      = "[session][DeleteSession]: Session non trouvée ou non détenue par l'utilisateur"
-	LogSessionDeletionError           = "[session][DeleteSession]: Erreur lors de la suppression de la session: %v"
-	LogSessionDeletedSuccess          = "[session][DeleteSession]: Session supprimée avec succès"
-	LogSessionInvalidOptional         = "[middleware][OptionalAuth]: Session invalide (optionnelle)"
-	LogUserNotFoundInContext          = "[session][GetUserSessions]: Utilisateur non trouvé dans le contexte"
-	LogGetUserSessions                = "[session][GetUserSessions]: Récupération des sessions utilisateur"
-	LogDeleteSession                  = "[session][DeleteSession]: Suppression d'une session utilisateur"
-	LogInvalidSession                 = "[session][Validation]: Session invalide"
-	LogUserMissingRole                = "[role][Validation]: Utilisateur sans le rôle requis : %s"
-	LogUserGetWithRoles               = "[user][GetWithRoles]: Récupération de l'utilisateur avec ses rôles"
-	LogUserGetWithRolesSuccess        = "[user][GetWithRoles]: Utilisateur et rôles récupérés avec succès"
-	LogUserCalendarConflict           = "[user_calendar][Add]: Conflit de liaison utilisateur-calendrier déjà existante"
-	LogUserCalendarUnauthorizedAccess = "[user_calendar][Access]: Accès non autorisé à la liaison utilisateur-calendrier"
-	LogTokenRefreshSuccess            = "[session][RefreshToken]: Rafraîchissement du token réussi"
-	LogMissingSessionID               = "[session][DeleteSession]: session_id manquant dans la requête"
+	LogAppStart                           = "[main][main]: Démarrage de l'application"
+	LogDBConnectionSuccess                = "[common][InitDB]: Connexion à la base de données réussie"
+	LogDBConnectionError                  = "[common][InitDB]: Erreur de connexion à la base de données"
+	LogHTTPReceivedRequest                = "[http][middleware]: Requête reçue"
+	LogHTTPResponseSent                   = "[http][middleware]: Réponse envoyée"
+	LogUserGet                            = "[user][Get]: Récupération d'un utilisateur"
+	LogUserAdd                            = "[user][Add]: Création d'un utilisateur"
+	LogUserUpdate                         = "[user][Update]: Mise à jour d'un utilisateur"
+	LogUserDelete                         = "[user][Delete]: Suppression d'un utilisateur"
+	LogCalendarGet                        = "[calendar][Get]: Récupération d'un calendrier"
+	LogCalendarAdd                        = "[calendar][Add]: Création d'un calendrier"
+	LogCalendarUpdate                     = "[calendar][Update]: Mise à jour d'un calendrier"
+	LogCalendarDelete                     = "[calendar][Delete]: Suppression d'un calendrier"
+	LogCalendarClone                      = "[calendar][Clone]: Clonage d'un calendrier"
+	LogTemplateList                       = "[calendar_template][List]: Récupération de la liste des modèles de calendrier"
+	LogTemplateGet                        = "[calendar_template][Get]: Récupération d'un modèle de calendrier"
+	LogTemplateAdd                        = "[calendar_template][Add]: Publication d'un modèle de calendrier"
+	LogTemplateUpdate                     = "[calendar_template][Update]: Mise à jour d'un modèle de calendrier"
+	LogTemplateDelete                     = "[calendar_template][Delete]: Suppression d'un modèle de calendrier"
+	LogTemplateInstantiate                = "[calendar_template][Instantiate]: Application d'un modèle de calendrier"
+	LogTaskList                           = "[task][List]: Récupération des tâches d'un calendrier"
+	LogTaskGet                            = "[task][Get]: Récupération d'une tâche"
+	LogTaskAdd                            = "[task][Add]: Création d'une tâche"
+	LogTaskUpdate                         = "[task][Update]: Mise à jour d'une tâche"
+	LogTaskDelete                         = "[task][Delete]: Suppression d'une tâche"
+	LogResourceList                       = "[resource][List]: Récupération de la liste des ressources"
+	LogResourceGet                        = "[resource][Get]: Récupération d'une ressource"
+	LogResourceAdd                        = "[resource][Add]: Création d'une ressource"
+	LogResourceUpdate                     = "[resource][Update]: Mise à jour d'une ressource"
+	LogResourceDelete                     = "[resource][Delete]: Suppression d'une ressource"
+	LogResourceAvailability               = "[resource][Availability]: Récupération des disponibilités d'une ressource"
+	LogResourceListForEvent               = "[resource][ListForEvent]: Récupération des ressources réservées par un événement"
+	LogResourceBook                       = "[resource][Book]: Réservation d'une ressource pour un événement"
+	LogResourceRelease                    = "[resource][Release]: Annulation de la réservation d'une ressource"
+	LogBookingPageList                    = "[booking_page][List]: Récupération des pages de réservation d'un utilisateur"
+	LogBookingPageGet                     = "[booking_page][Get]: Récupération d'une page de réservation"
+	LogBookingPageAdd                     = "[booking_page][Add]: Création d'une page de réservation"
+	LogBookingPageUpdate                  = "[booking_page][Update]: Mise à jour d'une page de réservation"
+	LogBookingPageDelete                  = "[booking_page][Delete]: Suppression d'une page de réservation"
+	LogBookingPageListBookings            = "[booking_page][ListBookings]: Récupération des rendez-vous d'une page de réservation"
+	LogBookingPagePublic                  = "[booking_page][Public]: Consultation publique d'une page de réservation"
+	LogBookingPageSlots                   = "[booking_page][Slots]: Calcul des créneaux disponibles d'une page de réservation"
+	LogBookingPageBook                    = "[booking_page][Book]: Réservation d'un rendez-vous par un visiteur"
+	LogBookingPageCancel                  = "[booking_page][Cancel]: Annulation d'un rendez-vous par un visiteur"
+	LogUserAvailabilityGetWorkingHours    = "[user_availability][GetWorkingHours]: Récupération des heures de travail"
+	LogUserAvailabilityUpdateWorkingHours = "[user_availability][UpdateWorkingHours]: Mise à jour des heures de travail"
+	LogUserAvailabilityListOutOfOffice    = "[user_availability][ListOutOfOffice]: Récupération des absences"
+	LogUserAvailabilityAddOutOfOffice     = "[user_availability][AddOutOfOffice]: Enregistrement d'une absence"
+	LogUserAvailabilityDeleteOutOfOffice  = "[user_availability][DeleteOutOfOffice]: Suppression d'une absence"
+	LogUserAvailabilityFreeBusy           = "[user_availability][FreeBusy]: Récupération des disponibilités d'utilisateurs"
+	LogUserAvailabilityFindSlots          = "[user_availability][FindSlots]: Recherche de créneaux communs"
+	LogEventInvitationCreate              = "[event_invitation][Invite]: Invitation d'utilisateurs à un événement"
+	LogEventInvitationList                = "[event_invitation][ListForEvent]: Récupération des invitations d'un événement"
+	LogEventInvitationListMine            = "[event_invitation][ListMine]: Récupération des invitations de l'utilisateur"
+	LogEventInvitationRespond             = "[event_invitation][Respond]: Réponse à une invitation"
+	LogHolidayCalendarList                = "[holiday_calendar][List]: Récupération des calendriers de jours fériés"
+	LogHolidayCalendarHolidays            = "[holiday_calendar][Holidays]: Récupération des jours fériés d'un pays"
+	LogHolidayCalendarSubscribe           = "[holiday_calendar][Subscribe]: Abonnement à un calendrier de jours fériés"
+	LogHolidayCalendarUnsubscribe         = "[holiday_calendar][Unsubscribe]: Désabonnement d'un calendrier de jours fériés"
+	LogHolidayCalendarAgenda              = "[holiday_calendar][Agenda]: Récupération de l'agenda de l'utilisateur"
+	LogEventGet                           = "[calendar_event][Get]: Récupération d'un événement"
+	LogEventAdd                           = "[calendar_event][Add]: Création d'un événement"
+	LogEventUpdate                        = "[calendar_event][Update]: Mise à jour d'un événement"
+	LogEventDelete                        = "[calendar_event][Delete]: Suppression d'un événement"
+	LogEventList                          = "[calendar_event][List]: Récupération de la liste des événements d'un calendrier"
+	LogUserCalendarGet                    = "[user_calendar][Get]: Récupération d'une liaison utilisateur-calendrier"
+	LogUserCalendarAdd                    = "[user_calendar][Add]: Création d'une liaison utilisateur-calendrier"
+	LogUserCalendarUpdate                 = "[user_calendar][Update]: Mise à jour d'une liaison utilisateur-calendrier"
+	LogUserCalendarDelete                 = "[user_calendar][Delete]: Suppression d'une liaison utilisateur-calendrier"
+	LogUserCalendarList                   = "[user_calendar][List]: Récupération de la liste des calendriers d'un utilisateur"
+	LogRoleGet                            = "[role][Get]: Récupération d'un rôle"
+	LogRoleList                           = "[role][List]: Récupération de la liste des rôles"
+	LogRolesRetrievalError                = "[role][List]: Erreur lors de la récupération des rôles: %v"
+	LogRoleCreate                         = "[role][Create]: Création d'un rôle"
+	LogInvalidData                        = "[common][Validation]: Données invalides: %v"
+	LogRoleCreateSuccess                  = "[role][Create]: Rôle créé avec succès"
+	LogRoleUpdate                         = "[role][Update]: Mise à jour d'un rôle"
+	LogRoleUpdateSuccess                  = "[role][Update]: Rôle mis à jour avec succès"
+	LogRoleDelete                         = "[role][Delete]: Suppression d'un rôle"
+	LogRoleDeleteSuccess                  = "[role][Delete]: Rôle supprimé avec succès"
+	LogRoleAssign                         = "[role][Assign]: Attribution d'un rôle"
+	LogRoleAssignSuccess                  = "[role][Assign]: Rôle attribué avec succès"
+	LogRoleRevoke                         = "[role][Revoke]: Révocation d'un rôle"
+	LogRoleRevokeSuccess                  = "[role][Revoke]: Rôle révoqué avec succès"
+	LogRoleGetUserRoles                   = "[role][GetUserRoles]: Récupération des rôles d'un utilisateur"
+	LogMissingUserID                      = "[role][GetUserRoles]: ID utilisateur manquant"
+	LogUserRolesRetrieved                 = "[role][GetUserRoles]: Rôles récupérés avec succès"
+	LogLoginAttempt                       = "[session][Login]: Tentative de connexion"
+	LogInvalidLoginData                   = "[session][Login]: Données de connexion invalides: %v"
+	LogInvalidPassword                    = "[session][Login]: Mot de passe invalide"
+	LogLoginSuccess                       = "[session][Login]: Connexion réussie pour l'utilisateur %s"
+	LogLogoutAttempt                      = "[session][Logout]: Tentative de déconnexion"
+	LogMissingAuthHeader                  = "[session][Auth]: Header Authorization manquant"
+	LogInvalidToken                       = "[session][Auth]: Token invalide"
+	LogLogoutSuccess                      = "[session][Logout]: Déconnexion réussie"
+	LogRefreshTokenAttempt                = "[session][RefreshToken]: Tentative de rafraîchissement du token"
+	LogInvalidRefreshToken                = "[session][RefreshToken]: Refresh token invalide"
+	LogRefreshTokenExpired                = "[session][RefreshToken]: Refresh token expiré"
+	LogRefreshTokenReuse                  = "[session][RefreshToken]: Refresh token déjà utilisé, session %d révoquée"
+	LogRefreshTokenError                  = "[session][RefreshToken]: Erreur lors de la rotation du refresh token: %v"
+	LogTokenGenerationError               = "[session][Token]: Erreur lors de la génération du token: %v"
+	LogSessionUpdateError                 = "[session][RefreshToken]: Erreur lors de la mise à jour de la session: %v"
+	LogSessionsRetrievalError             = "[session][GetUserSessions]: Erreur lors de la récupération des sessions: %v"
+	LogSessionReadingError                = "[session][GetUserSessions]: Erreur lors de la lecture d'une session: %v"
+	LogSessionsRetrieved                  = "[session][GetUserSessions]: Sessions récupérées avec succès"
+	LogSessionNotFoundOrNotOwned          = "[session][DeleteSession]: Session non trouvée ou non détenue par l'utilisateur"
+	LogSessionDeletionError               = "[session][DeleteSession]: Erreur lors de la suppression de la session: %v"
+	LogSessionDeletedSuccess              = "[session][DeleteSession]: Session supprimée avec succès"
+	LogSessionInvalidOptional             = "[middleware][OptionalAuth]: Session invalide (optionnelle)"
+	LogUserNotFoundInContext              = "[session][GetUserSessions]: Utilisateur non trouvé dans le contexte"
+	LogGetUserSessions                    = "[session][GetUserSessions]: Récupération des sessions utilisateur"
+	LogDeleteSession                      = "[session][DeleteSession]: Suppression d'une session utilisateur"
+	LogInvalidSession                     = "[session][Validation]: Session invalide"
+	LogUserMissingRole                    = "[role][Validation]: Utilisateur sans le rôle requis : %s"
+	LogUserGetWithRoles                   = "[user][GetWithRoles]: Récupération de l'utilisateur avec ses rôles"
+	LogUserGetWithRolesSuccess            = "[user][GetWithRoles]: Utilisateur et rôles récupérés avec succès"
+	LogUserCalendarConflict               = "[user_calendar][Add]: Conflit de liaison utilisateur-calendrier déjà existante"
+	LogUserCalendarUnauthorizedAccess     = "[user_calendar][Access]: Accès non autorisé à la liaison utilisateur-calendrier"
+	LogTokenRefreshSuccess                = "[session][RefreshToken]: Rafraîchissement du token réussi"
+	LogMissingSessionID                   = "[session][DeleteSession]: session_id manquant dans la requête"
+	LogEventSearch                        = "[event_search][Search]: Recherche plein texte d'événements"
+	LogTagGet                             = "[tag][Get]: Récupération d'une étiquette"
+	LogTagList                            = "[tag][List]: Récupération de la liste des étiquettes"
+	LogTagAdd                             = "[tag][Add]: Création d'une étiquette"
+	LogTagUpdate                          = "[tag][Update]: Mise à jour d'une étiquette"
+	LogTagDelete                          = "[tag][Delete]: Suppression d'une étiquette"
+	LogAttachmentUpload                   = "[attachment][Upload]: Ajout d'une pièce jointe à un événement"
+	LogAttachmentList                     = "[attachment][List]: Récupération des pièces jointes d'un événement"
+	LogAttachmentDownload                 = "[attachment][Download]: Téléchargement d'une pièce jointe"
+	LogAttachmentDelete                   = "[attachment][Delete]: Suppression d'une pièce jointe"
+	LogStorageInit                        = "[storage][Init]: Initialisation du stockage des pièces jointes"
+	LogWebPushInit                        = "[webpush][Init]: Initialisation de l'envoi des notifications Web Push"
+	LogWebPushEphemeralKey                = "[webpush][Init]: VAPID_PRIVATE_KEY absente, utilisation d'une clé VAPID éphémère : les abonnements push ne survivront pas au redémarrage"
+	LogPushSubscriptionList               = "[push_subscription][List]: Récupération des abonnements push"
+	LogPushSubscriptionAdd                = "[push_subscription][Add]: Enregistrement d'un abonnement push"
+	LogPushSubscriptionDelete             = "[push_subscription][Delete]: Suppression d'un abonnement push"
+	LogPushSubscriptionTest               = "[push_subscription][Test]: Envoi d'une notification de test"
+	LogPushSubscriptionNotify             = "[push_subscription][Notify]: Envoi d'une notification push"
+	LogEventReminderGet                   = "[event_reminder][Get]: Récupération du rappel d'un événement"
+	LogEventReminderSet                   = "[event_reminder][Set]: Enregistrement du rappel d'un événement"
+	LogEventReminderDelete                = "[event_reminder][Delete]: Suppression du rappel d'un événement"
+	LogEventReminderSend                  = "[event_reminder][SendDue]: Envoi des rappels d'événements arrivés à échéance"
+	LogMailInit                           = "[mailer][Init]: Initialisation de l'envoi des e-mails"
+	LogMailSend                           = "[mailer][Send]: Envoi d'un e-mail"
+	LogRateLimitExceeded                  = "[middleware][RateLimit]: Limite de requêtes atteinte"
+	LogPasswordResetRequest               = "[password_reset][Request]: Demande de réinitialisation du mot de passe"
+	LogPasswordResetConfirm               = "[password_reset][Confirm]: Réinitialisation du mot de passe"
+	LogEmailVerificationInit              = "[email_verification][Init]: Initialisation de la politique de vérification des adresses e-mail"
+	LogEmailVerificationSend              = "[email_verification][Send]: Envoi du lien de vérification de l'adresse e-mail"
+	LogEmailVerificationResend            = "[email_verification][Resend]: Nouvel envoi du lien de vérification"
+	LogEmailVerificationConfirm           = "[email_verification][Confirm]: Vérification de l'adresse e-mail"
+	LogEmailNotVerified                   = "[email_verification][Policy]: Accès refusé, adresse e-mail non vérifiée"
+	LogTwoFactorStatus                    = "[two_factor][Status]: Récupération de l'état de l'authentification à deux facteurs"
+	LogTwoFactorSetup                     = "[two_factor][Setup]: Génération d'un secret TOTP"
+	LogTwoFactorConfirm                   = "[two_factor][Confirm]: Activation de l'authentification à deux facteurs"
+	LogTwoFactorDisable                   = "[two_factor][Disable]: Désactivation de l'authentification à deux facteurs"
+	LogTwoFactorChallenge                 = "[session][Login]: Mot de passe valide, second facteur requis"
+	LogTwoFactorVerify                    = "[session][VerifyTwoFactor]: Vérification du second facteur"
+	LogWebAuthnInit                       = "[webauthn][Init]: Initialisation de la partie de confiance WebAuthn"
+	LogPasskeyRegisterOptions             = "[passkey][RegisterOptions]: Génération des options d'enregistrement d'une passkey"
+	LogPasskeyRegister                    = "[passkey][Register]: Enregistrement d'une passkey"
+	LogPasskeyList                        = "[passkey][List]: Récupération des passkeys"
+	LogPasskeyDelete                      = "[passkey][Delete]: Suppression d'une passkey"
+	LogPasskeyLoginOptions                = "[passkey][LoginOptions]: Génération des options de connexion par passkey"
+	LogPasskeyLogin                       = "[session][LoginWithPasskey]: Connexion par passkey"
+	LogPasskeySignCount                   = "[passkey][Authenticate]: Compteur de signatures en recul, passkey possiblement clonée"
+	LogOIDCInit                           = "[oidc][Init]: Initialisation de la connexion SSO OpenID Connect"
+	LogOIDCAuthorize                      = "[sso][Authorize]: Démarrage d'une connexion SSO"
+	LogOIDCLogin                          = "[session][LoginWithOIDC]: Connexion SSO"
+	LogAuthenticatorInit                  = "[authenticator][Init]: Initialisation des sources d'authentification"
+	LogAccountProvision                   = "[authenticator][FindOrProvision]: Création du compte à la première connexion"
+	LogUnknownRole                        = "[authenticator][GrantRoles]: Rôle inconnu de l'application, ignoré"
+	LogLDAPLogin                          = "[authenticator][LDAP]: Authentification auprès de l'annuaire LDAP"
+	LogAuthenticatorError                 = "[authenticator][Authenticate]: Source d'authentification en erreur"
+	LogAccessTokenInit                    = "[accesstoken][Init]: Initialisation des tokens d'accès"
+	LogAccessTokenEphemeralKey            = "[accesstoken][Init]: ACCESS_TOKEN_KEYS absente, utilisation d'une clé de signature éphémère : les tokens d'accès ne survivront pas au redémarrage"
+	LogAccessTokenSyncError               = "[accesstoken][SyncRevocations]: Erreur lors de la synchronisation de la liste de révocation: %v"
+	LogAccessTokenClaimsError             = "[accesstoken][ClaimsChanged]: Erreur lors de l'invalidation des tokens d'accès de l'utilisateur: %v"
+	LogAccessTokenError                   = "[session][AccessToken]: Erreur lors de l'émission du token d'accès: %v"
+	LogLoginThrottled                     = "[session][Login]: Tentative de connexion refusée après des échecs répétés"
+	LogAccountLocked                      = "[login_guard][RecordFailure]: Adresse verrouillée après des échecs de connexion répétés"
+	LogAccountUnlock                      = "[login_guard][Unlock]: Déverrouillage d'un compte"
+	LogSecurityEventList                  = "[login_guard][ListEvents]: Consultation du journal de sécurité d'un compte"
+	LogEventBatch                         = "[event_batch][Apply]: Application d'opérations groupées sur les événements d'un calendrier"
+	LogEventMove                          = "[event_transfer][Move]: Déplacement d'événements vers un autre calendrier"
+	LogEventCopy                          = "[event_transfer][Copy]: Copie d'événements vers un autre calendrier"
+	LogEventLink                          = "[calendar_event][Link]: Rattachement d'un événement à un calendrier supplémentaire"
+	LogEventUnlink                        = "[calendar_event][Unlink]: Retrait d'un événement partagé d'un calendrier"
 )
 
 const (
-	ErrInternalUserNotInContext     = "Erreur interne: utilisateur non trouvé dans le contexte"
-	ErrInternalCalendarNotInContext = "Erreur interne: calendrier non trouvé dans le contexte"
-	ErrInvalidUserID                = "ID utilisateur invalide"
-	ErrUserNotFound                 = "Utilisateur non trouvé"
-	ErrUserAlreadyExists            = "Un utilisateur avec cet email existe déjà"
-	ErrInvalidEmailFormat           = "Format d'email invalide"
-	ErrInvalidData                  = "Données invalides"
-	ErrCalendarNotFound             = "Calendrier non trouvé"
-	ErrEventNotFound                = "Événement non trouvé"
-	ErrNoAccessToCalendar           = "Vous n'avez pas accès à ce calendrier"
-	ErrUserCalendarConflict         = "Liaison utilisateur-calendrier déjà existante"
-	ErrUserCalendarNotFound         = "Liaison utilisateur-calendrier non trouvée"
-	ErrPasswordHashing              = "Erreur lors du hashage du mot de passe"
-	ErrTransactionStart             = "Erreur lors du démarrage de la transaction"
-	ErrUserCreation                 = "Erreur lors de la création de l'utilisateur"
-	ErrPasswordCreation             = "Erreur lors de la création du mot de passe"
-	ErrTransactionCommit            = "Erreur lors de la validation de la transaction"
-	ErrPasswordTooShort             = "Le mot de passe doit contenir au moins 6 caractères"
-	ErrUserUpdate                   = "Erreur lors de la mise à jour de l'utilisateur"
-	ErrPasswordUpdate               = "Erreur lors de la mise à jour du mot de passe"
-	ErrUserDelete                   = "Erreur lors de la suppression de l'utilisateur"
-	ErrPasswordDelete               = "Erreur lors de la suppression du mot de passe"
-	ErrInvalidEventID               = "EventID invalide"
-	ErrInvalidDuration              = "La durée doit être supérieure à 0"
-	ErrEventCreation                = "Erreur lors de la création de l'événement"
-	ErrCalendarEventLink            = "Erreur lors de la liaison calendrier-événement"
-	ErrEventUpdate                  = "Erreur lors de la mise à jour de l'événement"
-	ErrEventDelete                  = "Erreur lors de la suppression de l'événement"
-	ErrCalendarEventDeleteLink      = "Erreur lors de la suppression des liaisons calendrier-événement"
-	ErrCalendarCreation             = "Erreur lors de la création du calendrier"
-	ErrUserCalendarLinkCreation     = "Erreur lors de la création de la liaison utilisateur-calendrier"
-	ErrCalendarUpdate               = "Erreur lors de la mise à jour du calendrier"
-	ErrCalendarDelete               = "Erreur lors de la suppression du calendrier"
-	ErrUserCalendarDeleteLink       = "Erreur lors de la suppression des liaisons utilisateur-calendrier"
-	ErrUserCalendarUpdate           = "Erreur lors de la mise à jour de la liaison utilisateur-calendrier"
-	ErrUserCalendarDelete           = "Erreur lors de la suppression de la liaison utilisateur-calendrier"
-	ErrUserVerification             = "Erreur lors de la vérification de l'utilisateur"
-	ErrDatabaseConnection           = "Erreur de connexion à la base de données : %v"
-	ErrInvalidCalendarID            = "ID calendrier invalide"
-	ErrCalendarVerification         = "Erreur lors de la vérification du calendrier"
-	ErrCalendarAccessCheck          = "Erreur lors de la vérification de l'accès au calendrier"
-	ErrContextUserType              = "Erreur de type pour l'utilisateur dans le contexte"
-	ErrContextCalendarType          = "Erreur de type pour le calendrier dans le contexte"
-	ErrLoggerInit                   = "Erreur lors de l'initialisation du logger : %v"
-	ErrEventRetrieval               = "Erreur lors de la récupération de l'événement"
-	ErrEventsRetrieval              = "Erreur lors de la récupération des événements"
-	ErrEventsReading                = "Erreur lors de la lecture des événements"
-	ErrInvalidDateFormat            = "Format de date invalide : %v"
-	ErrInvalidDayFormat             = "format de date invalide pour le jour, attendu: YYYY-MM-DD"
-	ErrInvalidWeekFormat            = "format de semaine invalide, attendu: YYYY-WNN"
-	ErrInvalidMonthFormat           = "format de mois invalide, attendu: YYYY-MM"
-	ErrInvalidYear                  = "année invalide"
-	ErrInvalidMonth                 = "Mois invalide"
-	ErrInvalidDay                   = "Jour invalide"
-	ErrInvalidWeekNumber            = "numéro de semaine invalide"
-	ErrTestDBInit                   = "Erreur d'initialisation de la base de données: %v"
-	ErrMissingFilterParams          = "Les paramètres filter_type et date sont requis"
-	ErrInvalidFilterType            = "Le type de filtre doit être 'month', 'week' ou 'day'"
-	ErrUnsupportedFilterType        = "type de filtre non supporté"
-	ErrInvalidCredentials           = "Email ou mot de passe incorrect"
-	ErrUserNotAuthenticated         = "Utilisateur non authentifié"
-	ErrSessionNotFound              = "session non trouvée"
-	ErrSessionExpired               = "session expirée"
-	ErrSessionInvalid               = "Session invalide"
-	ErrTokenGeneration              = "Erreur lors de la génération du token"
-	ErrInsufficientPermissions      = "Permissions insuffisantes"
-	ErrRoleNotFound                 = "Rôle non trouvé"
-	ErrRoleAlreadyExists            = "Un rôle avec ce nom existe déjà"
-	ErrRoleCreation                 = "Erreur lors de la création du rôle"
-	ErrRoleUpdate                   = "Erreur lors de la mise à jour du rôle"
-	ErrRoleDelete                   = "Erreur lors de la suppression du rôle"
-	ErrRoleAssignment               = "Erreur lors de l'attribution du rôle"
-	ErrRoleRevocation               = "Erreur lors de la révocation du rôle"
-	ErrSessionCreation              = "Erreur lors de la création de la session"
-	ErrSessionDeletion              = "Erreur lors de la suppression de la session"
-	ErrSessionUpdate                = "Erreur lors de la mise à jour de la session"
-	ErrMissingRoleID                = "ID de rôle manquant"
-	ErrRoleNameAlreadyUsed          = "Nom de rôle déjà utilisé"
-	ErrRoleAlreadyAssigned          = "Ce rôle est déjà attribué à cet utilisateur"
-	ErrRoleUpdateFailed             = "Erreur lors de la mise à jour du rôle"
-	ErrRoleDeleteFailed             = "Erreur lors de la suppression du rôle"
-	ErrRoleAssignmentFailed         = "Erreur lors de l'attribution du rôle"
-	ErrRoleRevocationFailed         = "Erreur lors de la révocation du rôle"
-	ErrRoleAttributionConflict      = "Rôle déjà attribué à cet utilisateur"
+	ErrInternalUserNotInContext         = "Erreur interne: utilisateur non trouvé dans le contexte"
+	ErrInternalCalendarNotInContext     = "Erreur interne: calendrier non trouvé dans le contexte"
+	ErrInvalidUserID                    = "ID utilisateur invalide"
+	ErrUserNotFound                     = "Utilisateur non trouvé"
+	ErrUserAlreadyExists                = "Un utilisateur avec cet email existe déjà"
+	ErrInvalidEmailFormat               = "Format d'email invalide"
+	ErrInvalidData                      = "Données invalides"
+	ErrCalendarNotFound                 = "Calendrier non trouvé"
+	ErrEventNotFound                    = "Événement non trouvé"
+	ErrNoAccessToCalendar               = "Vous n'avez pas accès à ce calendrier"
+	ErrUserCalendarConflict             = "Liaison utilisateur-calendrier déjà existante"
+	ErrUserCalendarNotFound             = "Liaison utilisateur-calendrier non trouvée"
+	ErrPasswordHashing                  = "Erreur lors du hashage du mot de passe"
+	ErrTransactionStart                 = "Erreur lors du démarrage de la transaction"
+	ErrUserCreation                     = "Erreur lors de la création de l'utilisateur"
+	ErrPasswordCreation                 = "Erreur lors de la création du mot de passe"
+	ErrTransactionCommit                = "Erreur lors de la validation de la transaction"
+	ErrPasswordTooShort                 = "Le mot de passe doit contenir au moins 6 caractères"
+	ErrUserUpdate                       = "Erreur lors de la mise à jour de l'utilisateur"
+	ErrPasswordUpdate                   = "Erreur lors de la mise à jour du mot de passe"
+	ErrUserDelete                       = "Erreur lors de la suppression de l'utilisateur"
+	ErrPasswordDelete                   = "Erreur lors de la suppression du mot de passe"
+	ErrInvalidEventID                   = "EventID invalide"
+	ErrInvalidDuration                  = "La durée doit être supérieure à 0"
+	ErrEventCreation                    = "Erreur lors de la création de l'événement"
+	ErrCalendarEventLink                = "Erreur lors de la liaison calendrier-événement"
+	ErrEventUpdate                      = "Erreur lors de la mise à jour de l'événement"
+	ErrEventDelete                      = "Erreur lors de la suppression de l'événement"
+	ErrCalendarEventDeleteLink          = "Erreur lors de la suppression des liaisons calendrier-événement"
+	ErrCalendarCreation                 = "Erreur lors de la création du calendrier"
+	ErrUserCalendarLinkCreation         = "Erreur lors de la création de la liaison utilisateur-calendrier"
+	ErrCalendarUpdate                   = "Erreur lors de la mise à jour du calendrier"
+	ErrCalendarDelete                   = "Erreur lors de la suppression du calendrier"
+	ErrUserCalendarDeleteLink           = "Erreur lors de la suppression des liaisons utilisateur-calendrier"
+	ErrUserCalendarUpdate               = "Erreur lors de la mise à jour de la liaison utilisateur-calendrier"
+	ErrUserCalendarDelete               = "Erreur lors de la suppression de la liaison utilisateur-calendrier"
+	ErrUserVerification                 = "Erreur lors de la vérification de l'utilisateur"
+	ErrDatabaseConnection               = "Erreur de connexion à la base de données : %v"
+	ErrInvalidCalendarID                = "ID calendrier invalide"
+	ErrCalendarVerification             = "Erreur lors de la vérification du calendrier"
+	ErrCalendarAccessCheck              = "Erreur lors de la vérification de l'accès au calendrier"
+	ErrContextUserType                  = "Erreur de type pour l'utilisateur dans le contexte"
+	ErrContextCalendarType              = "Erreur de type pour le calendrier dans le contexte"
+	ErrLoggerInit                       = "Erreur lors de l'initialisation du logger : %v"
+	ErrEventRetrieval                   = "Erreur lors de la récupération de l'événement"
+	ErrEventsRetrieval                  = "Erreur lors de la récupération des événements"
+	ErrEventsReading                    = "Erreur lors de la lecture des événements"
+	ErrInvalidDateFormat                = "Format de date invalide : %v"
+	ErrInvalidDayFormat                 = "format de date invalide pour le jour, attendu: YYYY-MM-DD"
+	ErrInvalidWeekFormat                = "format de semaine invalide, attendu: YYYY-WNN"
+	ErrInvalidMonthFormat               = "format de mois invalide, attendu: YYYY-MM"
+	ErrInvalidYear                      = "année invalide"
+	ErrInvalidMonth                     = "Mois invalide"
+	ErrInvalidDay                       = "Jour invalide"
+	ErrInvalidWeekNumber                = "numéro de semaine invalide"
+	ErrTestDBInit                       = "Erreur d'initialisation de la base de données: %v"
+	ErrMissingFilterParams              = "Les paramètres filter_type et date sont requis"
+	ErrInvalidFilterType                = "Le type de filtre doit être 'month', 'week' ou 'day'"
+	ErrUnsupportedFilterType            = "type de filtre non supporté"
+	ErrInvalidCredentials               = "Email ou mot de passe incorrect"
+	ErrUserNotAuthenticated             = "Utilisateur non authentifié"
+	ErrSessionNotFound                  = "session non trouvée"
+	ErrSessionExpired                   = "session expirée"
+	ErrSessionInvalid                   = "Session invalide"
+	ErrRefreshTokenReused               = "Refresh token déjà utilisé : la session a été révoquée"
+	ErrTokenGeneration                  = "Erreur lors de la génération du token"
+	ErrInsufficientPermissions          = "Permissions insuffisantes"
+	ErrRoleNotFound                     = "Rôle non trouvé"
+	ErrRoleAlreadyExists                = "Un rôle avec ce nom existe déjà"
+	ErrRoleCreation                     = "Erreur lors de la création du rôle"
+	ErrRoleUpdate                       = "Erreur lors de la mise à jour du rôle"
+	ErrRoleDelete                       = "Erreur lors de la suppression du rôle"
+	ErrRoleAssignment                   = "Erreur lors de l'attribution du rôle"
+	ErrRoleRevocation                   = "Erreur lors de la révocation du rôle"
+	ErrSessionCreation                  = "Erreur lors de la création de la session"
+	ErrSessionDeletion                  = "Erreur lors de la suppression de la session"
+	ErrSessionUpdate                    = "Erreur lors de la mise à jour de la session"
+	ErrMissingRoleID                    = "ID de rôle manquant"
+	ErrRoleNameAlreadyUsed              = "Nom de rôle déjà utilisé"
+	ErrRoleAlreadyAssigned              = "Ce rôle est déjà attribué à cet utilisateur"
+	ErrRoleUpdateFailed                 = "Erreur lors de la mise à jour du rôle"
+	ErrRoleDeleteFailed                 = "Erreur lors de la suppression du rôle"
+	ErrRoleAssignmentFailed             = "Erreur lors de l'attribution du rôle"
+	ErrRoleRevocationFailed             = "Erreur lors de la révocation du rôle"
+	ErrRoleAttributionConflict          = "Rôle déjà attribué à cet utilisateur"
+	ErrSearchQueryRequired              = "Le paramètre de recherche q est requis"
+	ErrInvalidSearchDate                = "Date de recherche invalide, attendu: YYYY-MM-DD ou RFC3339"
+	ErrInvalidSearchRange               = "La date de début doit précéder la date de fin"
+	ErrInvalidSearchLimit               = "Limite de résultats invalide"
+	ErrInvalidColor                     = "Couleur invalide, attendu: #RRGGBB"
+	ErrInvalidTagID                     = "ID étiquette invalide"
+	ErrTagNotFound                      = "Étiquette non trouvée"
+	ErrTagAlreadyExists                 = "Une étiquette avec ce nom existe déjà"
+	ErrTagRetrieval                     = "Erreur lors de la récupération des étiquettes"
+	ErrTagCreation                      = "Erreur lors de la création de l'étiquette"
+	ErrTagUpdate                        = "Erreur lors de la mise à jour de l'étiquette"
+	ErrTagDelete                        = "Erreur lors de la suppression de l'étiquette"
+	ErrEventTagLink                     = "Erreur lors de la liaison événement-étiquette"
+	ErrContextTagType                   = "Erreur de type pour l'étiquette dans le contexte"
+	ErrInvalidCoordinates               = "Coordonnées invalides, latitude et longitude doivent être fournies ensemble"
+	ErrInvalidMeetingURL                = "Lien de visioconférence invalide, attendu: URL http(s)"
+	ErrInvalidLocationFilter            = "Filtre has_location invalide, attendu: true ou false"
+	ErrInvalidAttachmentID              = "ID pièce jointe invalide"
+	ErrAttachmentNotFound               = "Pièce jointe non trouvée"
+	ErrAttachmentMissing                = "Fichier manquant, attendu dans le champ multipart \"file\""
+	ErrAttachmentTooLarge               = "Fichier trop volumineux"
+	ErrAttachmentTypeNotAllowed         = "Type de fichier non autorisé"
+	ErrAttachmentRetrieval              = "Erreur lors de la récupération des pièces jointes"
+	ErrAttachmentCreation               = "Erreur lors de l'enregistrement de la pièce jointe"
+	ErrAttachmentDelete                 = "Erreur lors de la suppression de la pièce jointe"
+	ErrAttachmentStorage                = "Erreur lors de l'accès au stockage des pièces jointes"
+	ErrContextAttachmentType            = "Erreur de type pour la pièce jointe dans le contexte"
+	ErrStorageInit                      = "Erreur lors de l'initialisation du stockage : %v"
+	ErrWebPushInit                      = "Erreur lors de l'initialisation de Web Push : %v"
+	ErrMailInit                         = "Erreur lors de l'initialisation de l'envoi des e-mails : %v"
+	ErrEmailVerificationInit            = "Politique de vérification des adresses e-mail inconnue (none, login ou routes) : %v"
+	ErrEventOperation                   = "Erreur lors de l'opération sur l'événement"
+	ErrBatchEmpty                       = "Aucune opération fournie"
+	ErrBatchTooLarge                    = "Trop d'opérations dans le lot"
+	ErrBatchInvalidAction               = "Action invalide, attendu: create, update, delete ou cancel"
+	ErrBatchMissingEventID              = "event_id requis pour cette action"
+	ErrBatchMissingEvent                = "event requis pour cette action"
+	ErrBatchFailed                      = "Lot annulé : une opération a échoué"
+	ErrBatchNotApplied                  = "Opération non appliquée suite à l'échec du lot"
+	ErrTransferSameCalendar             = "Le calendrier cible doit être différent du calendrier source"
+	ErrTransferNoSelection              = "Aucun critère de sélection : event_ids, start, end ou tag_ids requis"
+	ErrTransferInvalidRange             = "La date de début doit précéder la date de fin"
+	ErrEventMove                        = "Erreur lors du déplacement de l'événement"
+	ErrEventAlreadyLinked               = "L'événement est déjà présent dans ce calendrier"
+	ErrEventLastLink                    = "Impossible de retirer l'événement de son dernier calendrier, utilisez la suppression"
+	ErrInvalidTemplateID                = "ID modèle de calendrier invalide"
+	ErrTemplateNotFound                 = "Modèle de calendrier non trouvé"
+	ErrTemplateRetrieval                = "Erreur lors de la récupération des modèles de calendrier"
+	ErrTemplateCreation                 = "Erreur lors de la création du modèle de calendrier"
+	ErrTemplateUpdate                   = "Erreur lors de la mise à jour du modèle de calendrier"
+	ErrTemplateDelete                   = "Erreur lors de la suppression du modèle de calendrier"
+	ErrContextTemplateType              = "Erreur de type pour le modèle de calendrier dans le contexte"
+	ErrInvalidTemplateStartDate         = "Date de début invalide, attendu: YYYY-MM-DD ou RFC3339"
+	ErrInvalidTaskID                    = "ID tâche invalide"
+	ErrTaskNotFound                     = "Tâche non trouvée"
+	ErrTaskRetrieval                    = "Erreur lors de la récupération des tâches"
+	ErrTaskCreation                     = "Erreur lors de la création de la tâche"
+	ErrTaskUpdate                       = "Erreur lors de la mise à jour de la tâche"
+	ErrTaskDelete                       = "Erreur lors de la suppression de la tâche"
+	ErrContextTaskType                  = "Erreur de type pour la tâche dans le contexte"
+	ErrTaskEventNotInCalendar           = "L'événement lié doit appartenir au calendrier de la tâche"
+	ErrTaskAssigneeNoAccess             = "La personne assignée doit avoir accès au calendrier"
+	ErrInvalidTaskDate                  = "Date d'échéance invalide, attendu: YYYY-MM-DD ou RFC3339"
+	ErrInvalidTaskRange                 = "La date de début doit précéder la date de fin"
+	ErrInvalidTaskCompletedFilter       = "Filtre completed invalide, attendu: true ou false"
+	ErrInvalidResourceID                = "ID ressource invalide"
+	ErrResourceNotFound                 = "Ressource non trouvée"
+	ErrResourceRetrieval                = "Erreur lors de la récupération des ressources"
+	ErrResourceCreation                 = "Erreur lors de la création de la ressource"
+	ErrResourceUpdate                   = "Erreur lors de la mise à jour de la ressource"
+	ErrResourceDelete                   = "Erreur lors de la suppression de la ressource"
+	ErrContextResourceType              = "Erreur de type pour la ressource dans le contexte"
+	ErrResourceConflict                 = "La ressource est déjà réservée ou indisponible sur ce créneau"
+	ErrResourceAlreadyBooked            = "La ressource est déjà réservée pour cet événement"
+	ErrResourceNotBooked                = "La ressource n'est pas réservée pour cet événement"
+	ErrResourceBooking                  = "Erreur lors de la réservation de la ressource"
+	ErrInvalidResourceKind              = "Type de ressource invalide, attendu: room ou equipment"
+	ErrInvalidCapacityFilter            = "Filtre min_capacity invalide"
+	ErrInvalidAvailabilityRange         = "Période invalide : start et end requis (YYYY-MM-DD ou RFC3339), start avant end, 366 jours au plus"
+	ErrInvalidBookingPageID             = "ID page de réservation invalide"
+	ErrBookingPageNotFound              = "Page de réservation non trouvée"
+	ErrBookingPageRetrieval             = "Erreur lors de la récupération des pages de réservation"
+	ErrBookingPageCreation              = "Erreur lors de la création de la page de réservation"
+	ErrBookingPageUpdate                = "Erreur lors de la mise à jour de la page de réservation"
+	ErrBookingPageDelete                = "Erreur lors de la suppression de la page de réservation"
+	ErrContextBookingPageType           = "Erreur de type pour la page de réservation dans le contexte"
+	ErrInvalidBookingRules              = "Plages invalides : weekday de 0 (dimanche) à 6 (samedi), heures HH:MM, début avant fin"
+	ErrInvalidTimezone                  = "Fuseau horaire invalide (ex: Europe/Paris)"
+	ErrInvalidBookingRange              = "Période invalide : start et end requis (YYYY-MM-DD ou RFC3339), start avant end, 62 jours au plus"
+	ErrBookingSlotUnavailable           = "Ce créneau n'est pas disponible"
+	ErrBookingRetrieval                 = "Erreur lors de la récupération des rendez-vous"
+	ErrBookingCreation                  = "Erreur lors de la réservation du rendez-vous"
+	ErrBookingNotFound                  = "Rendez-vous non trouvé ou déjà annulé"
+	ErrBookingCancel                    = "Erreur lors de l'annulation du rendez-vous"
+	ErrBookingAlreadyStarted            = "Le rendez-vous a déjà commencé et ne peut plus être annulé"
+	ErrWorkingHoursRetrieval            = "Erreur lors de la récupération des heures de travail"
+	ErrWorkingHoursUpdate               = "Erreur lors de la mise à jour des heures de travail"
+	ErrInvalidOutOfOfficeID             = "ID absence invalide"
+	ErrOutOfOfficeNotFound              = "Absence non trouvée"
+	ErrOutOfOfficeRetrieval             = "Erreur lors de la récupération des absences"
+	ErrOutOfOfficeCreation              = "Erreur lors de l'enregistrement de l'absence"
+	ErrOutOfOfficeDelete                = "Erreur lors de la suppression de l'absence"
+	ErrInvalidOutOfOfficeRange          = "La fin de l'absence doit suivre son début"
+	ErrInvalidInvitationID              = "ID invitation invalide"
+	ErrInvitationNotFound               = "Invitation non trouvée"
+	ErrInvitationSelf                   = "L'organisateur ne peut pas s'inviter lui-même"
+	ErrInvitationCreation               = "Erreur lors de l'envoi des invitations"
+	ErrInvitationRetrieval              = "Erreur lors de la récupération des invitations"
+	ErrInvitationResponse               = "Erreur lors de l'enregistrement de la réponse à l'invitation"
+	ErrInvitationOutOfOffice            = "Impossible d'accepter : l'événement chevauche une de vos absences"
+	ErrInvalidInvitationStatus          = "Le statut doit être 'pending', 'accepted', 'declined' ou 'tentative'"
+	ErrInvalidFreeBusyUsers             = "Paramètre user_ids invalide : identifiants séparés par des virgules, 20 au plus"
+	ErrInvalidFreeBusyRange             = "Période invalide : start et end requis (YYYY-MM-DD ou RFC3339), start avant end, 31 jours au plus"
+	ErrInvalidSlotDuration              = "Paramètre duration invalide : durée en minutes entre 5 et 480"
+	ErrFreeBusyRetrieval                = "Erreur lors de la récupération des disponibilités"
+	ErrHolidayCalendarNotFound          = "Calendrier de jours fériés non disponible pour ce pays"
+	ErrInvalidHolidayYear               = "Année invalide (1900 à 2200)"
+	ErrHolidayCalendarRetrieval         = "Erreur lors de la récupération des calendriers de jours fériés"
+	ErrHolidayCalendarSubscribe         = "Erreur lors de l'abonnement au calendrier de jours fériés"
+	ErrHolidayCalendarUnsubscribe       = "Erreur lors du désabonnement du calendrier de jours fériés"
+	ErrHolidayCalendarAlreadySubscribed = "Vous êtes déjà abonné à ce calendrier de jours fériés"
+	ErrHolidayCalendarNotSubscribed     = "Vous n'êtes pas abonné à ce calendrier de jours fériés"
+	ErrInvalidAgendaRange               = "Période invalide : start et end requis (YYYY-MM-DD ou RFC3339), end après start, 92 jours au plus"
+	ErrAgendaRetrieval                  = "Erreur lors de la récupération de l'agenda"
+	ErrInvalidPushSubscription          = "Abonnement push invalide : endpoint http(s), clé p256dh P-256 et secret auth de 16 octets en base64url"
+	ErrInvalidPushSubscriptionID        = "ID d'abonnement push invalide"
+	ErrPushSubscriptionNotFound         = "Abonnement push non trouvé"
+	ErrPushSubscriptionRetrieval        = "Erreur lors de la récupération des abonnements push"
+	ErrPushSubscriptionCreation         = "Erreur lors de l'enregistrement de l'abonnement push"
+	ErrPushSubscriptionDelete           = "Erreur lors de la suppression de l'abonnement push"
+	ErrNoPushSubscription               = "Aucun abonnement push actif"
+	ErrEventReminderNotFound            = "Aucun rappel pour cet événement"
+	ErrEventReminderRetrieval           = "Erreur lors de la récupération du rappel"
+	ErrEventReminderUpdate              = "Erreur lors de l'enregistrement du rappel"
+	ErrEventReminderDelete              = "Erreur lors de la suppression du rappel"
+	ErrTooManyRequests                  = "Trop de requêtes, veuillez réessayer plus tard"
+	ErrInvalidPasswordResetToken        = "Lien de réinitialisation invalide ou expiré"
+	ErrPasswordReset                    = "Erreur lors de la réinitialisation du mot de passe"
+	ErrPasswordManagedExternally        = "Ce compte n'a pas de mot de passe local : connectez-vous avec votre annuaire d'entreprise ou votre fournisseur SSO"
+	ErrEmailNotVerified                 = "Adresse e-mail non vérifiée, veuillez suivre le lien reçu par e-mail"
+	ErrInvalidEmailVerificationToken    = "Lien de vérification invalide ou expiré"
+	ErrTwoFactorAlreadyEnabled          = "L'authentification à deux facteurs est déjà activée"
+	ErrTwoFactorNotEnabled              = "L'authentification à deux facteurs n'est pas activée"
+	ErrTwoFactorSetupMissing            = "Aucune activation en cours, générez d'abord un secret avec /auth/2fa/setup"
+	ErrInvalidTwoFactorCode             = "Code d'authentification invalide"
+	ErrInvalidTwoFactorChallenge        = "Étape de connexion invalide ou expirée, veuillez vous reconnecter"
+	ErrInvalidPassword                  = "Mot de passe incorrect"
+	ErrTwoFactor                        = "Erreur lors de la gestion de l'authentification à deux facteurs"
+	ErrWebAuthnInit                     = "Configuration WebAuthn invalide : %v"
+	ErrInvalidPasskey                   = "Passkey invalide ou inconnue, ou cérémonie expirée"
+	ErrPasskeyAlreadyRegistered         = "Cette passkey est déjà enregistrée"
+	ErrPasskeyNotFound                  = "Passkey non trouvée"
+	ErrInvalidPasskeyID                 = "ID de passkey invalide"
+	ErrPasskey                          = "Erreur lors de la gestion des passkeys"
+	ErrOIDCInit                         = "Configuration OpenID Connect invalide : %v"
+	ErrOIDCNotConfigured                = "Connexion SSO non configurée"
+	ErrInvalidOIDCState                 = "Connexion SSO invalide ou expirée, veuillez recommencer"
+	ErrOIDCAuthentication               = "Échec de l'authentification auprès du fournisseur d'identité"
+	ErrOIDCAccountNotFound              = "Aucun compte actif n'est associé à cette adresse e-mail"
+	ErrOIDCEmailNotVerified             = "Adresse e-mail absente ou non vérifiée par le fournisseur d'identité"
+	ErrOIDC                             = "Erreur lors de la connexion SSO"
+	ErrAuthenticatorInit                = "Configuration de l'authentification invalide : %v"
+	ErrAuthenticationUnavailable        = "Service d'authentification indisponible, veuillez réessayer plus tard"
+	ErrAccessTokenInit                  = "Configuration des tokens d'accès invalide : %v"
+	ErrLoginThrottled                   = "Trop de tentatives de connexion échouées, veuillez réessayer plus tard"
+	ErrAccountLocked                    = "Compte temporairement verrouillé après des tentatives de connexion échouées répétées"
+	ErrLoginGuard                       = "Erreur lors du contrôle des tentatives de connexion"
+	ErrAccountUnlock                    = "Erreur lors du déverrouillage du compte"
+	ErrSecurityEventList                = "Erreur lors de la récupération du journal de sécurité"
+	ErrEmailVerification                = "Erreur lors de la vérification de l'adresse e-mail"
 )
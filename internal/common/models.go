@@ -1,16 +1,74 @@
 package common
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // User représente la table user
 type User struct {
-	UserID    int        `json:"user_id" db:"user_id"`
-	Lastname  string     `json:"lastname" db:"lastname"`
-	Firstname string     `json:"firstname" db:"firstname"`
-	Email     string     `json:"email" db:"email"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	UserID    int    `json:"user_id" db:"user_id"`
+	Lastname  string `json:"lastname" db:"lastname"`
+	Firstname string `json:"firstname" db:"firstname"`
+	Email     string `json:"email" db:"email"`
+	// EmailVerifiedAt est nul tant que l'utilisateur n'a pas suivi le lien de vérification reçu à l'inscription
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// WorkingHours regroupe les heures de travail hebdomadaires d'un utilisateur (table user_working_hours)
+// et le fuseau horaire dans lequel elles sont exprimées (colonne user.timezone).
+// Sans plage, l'utilisateur est considéré disponible à toute heure.
+type WorkingHours struct {
+	UserID   int          `json:"user_id"`
+	Timezone string       `json:"timezone"`
+	Rules    []WeeklyRule `json:"rules"`
+}
+
+// OutOfOffice représente la table user_out_of_office (absence de l'utilisateur sur [start, end))
+type OutOfOffice struct {
+	OutOfOfficeID int        `json:"out_of_office_id" db:"out_of_office_id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	Start         time.Time  `json:"start" db:"start"`
+	End           time.Time  `json:"end" db:"end"`
+	Message       *string    `json:"message,omitempty" db:"message"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Statuts d'une invitation (colonne event_invitation.status)
+const (
+	InvitationStatusPending   = "pending"
+	InvitationStatusAccepted  = "accepted"
+	InvitationStatusDeclined  = "declined"
+	InvitationStatusTentative = "tentative"
+)
+
+// EventInvitation représente la table event_invitation (invitation d'un utilisateur à un événement).
+// AutoDeclined indique un refus automatique dû à une absence de l'invité.
+type EventInvitation struct {
+	InvitationID int                     `json:"invitation_id" db:"invitation_id"`
+	EventID      int                     `json:"event_id" db:"event_id"`
+	UserID       int                     `json:"user_id" db:"user_id"`
+	InvitedBy    int                     `json:"invited_by" db:"invited_by"`
+	Status       string                  `json:"status" db:"status"`
+	AutoDeclined bool                    `json:"auto_declined" db:"auto_declined"`
+	Comment      *string                 `json:"comment,omitempty" db:"comment"`
+	RespondedAt  *time.Time              `json:"responded_at,omitempty" db:"responded_at"`
+	CreatedAt    time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time              `json:"updated_at,omitempty" db:"updated_at"`
+	Event        *Event                  `json:"event,omitempty" db:"-"`
+	Availability *InvitationAvailability `json:"availability,omitempty" db:"-"`
+}
+
+// InvitationAvailability décrit la disponibilité de l'invité sur le créneau de l'événement
+type InvitationAvailability struct {
+	WithinWorkingHours bool `json:"within_working_hours"`
+	OutOfOffice        bool `json:"out_of_office"`
+	Conflict           bool `json:"conflict"`
 }
 
 // UserPassword représente la table user_password
@@ -29,6 +87,7 @@ type Calendar struct {
 	CalendarID  int        `json:"calendar_id" db:"calendar_id"`
 	Title       string     `json:"title" db:"title"`
 	Description *string    `json:"description,omitempty" db:"description"`
+	Color       *string    `json:"color,omitempty" db:"color"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -36,17 +95,175 @@ type Calendar struct {
 
 // Event représente la table event
 type Event struct {
-	EventID     int        `json:"event_id" db:"event_id"`
+	EventID     int          `json:"event_id" db:"event_id"`
+	Title       string       `json:"title" db:"title"`
+	Description *string      `json:"description,omitempty" db:"description"`
+	Start       time.Time    `json:"start" db:"start"`
+	Duration    int          `json:"duration" db:"duration"`
+	Canceled    bool         `json:"canceled" db:"canceled"`
+	Location    *string      `json:"location,omitempty" db:"location"`
+	Latitude    *float64     `json:"latitude,omitempty" db:"latitude"`
+	Longitude   *float64     `json:"longitude,omitempty" db:"longitude"`
+	MeetingURL  *string      `json:"meeting_url,omitempty" db:"meeting_url"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time   `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty" db:"deleted_at"`
+	Tags        []Tag        `json:"tags,omitempty" db:"-"`
+	Attachments []Attachment `json:"attachments,omitempty" db:"-"`
+}
+
+// Types d'étiquettes possibles (colonne tag.kind)
+const (
+	TagKindTag      = "tag"
+	TagKindCategory = "category"
+)
+
+// Tag représente la table tag (étiquettes et catégories définies par un utilisateur)
+type Tag struct {
+	TagID     int        `json:"tag_id" db:"tag_id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Name      string     `json:"name" db:"name"`
+	Kind      string     `json:"kind" db:"kind"`
+	Color     *string    `json:"color,omitempty" db:"color"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// EventTag représente la table event_tag
+type EventTag struct {
+	EventTagID int        `json:"event_tag_id" db:"event_tag_id"`
+	EventID    int        `json:"event_id" db:"event_id"`
+	TagID      int        `json:"tag_id" db:"tag_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Attachment représente la table attachment (métadonnées d'un fichier joint à un événement)
+type Attachment struct {
+	AttachmentID int        `json:"attachment_id" db:"attachment_id"`
+	EventID      int        `json:"event_id" db:"event_id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	Filename     string     `json:"filename" db:"filename"`
+	ContentType  string     `json:"content_type" db:"content_type"`
+	Size         int64      `json:"size" db:"size"`
+	StorageKey   string     `json:"-" db:"storage_key"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// CalendarTemplate représente la table calendar_template (modèle de calendrier publié par un administrateur)
+type CalendarTemplate struct {
+	TemplateID  int                     `json:"template_id" db:"template_id"`
+	Title       string                  `json:"title" db:"title"`
+	Description *string                 `json:"description,omitempty" db:"description"`
+	Color       *string                 `json:"color,omitempty" db:"color"`
+	CreatedBy   int                     `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time              `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt   *time.Time              `json:"deleted_at,omitempty" db:"deleted_at"`
+	Events      []CalendarTemplateEvent `json:"events,omitempty"`
+}
+
+// CalendarTemplateEvent représente la table calendar_template_event.
+// OffsetMinutes est le décalage par rapport à la date de début choisie à l'instanciation.
+type CalendarTemplateEvent struct {
+	TemplateEventID int        `json:"template_event_id" db:"template_event_id"`
+	TemplateID      int        `json:"template_id" db:"template_id"`
+	Title           string     `json:"title" db:"title"`
+	Description     *string    `json:"description,omitempty" db:"description"`
+	OffsetMinutes   int        `json:"offset_minutes" db:"offset_minutes"`
+	Duration        int        `json:"duration" db:"duration"`
+	Location        *string    `json:"location,omitempty" db:"location"`
+	MeetingURL      *string    `json:"meeting_url,omitempty" db:"meeting_url"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Task représente la table task (tâche VTODO rattachée à un calendrier).
+// Priority suit la RFC 5545 : 0 non définie, 1 la plus haute, 9 la plus basse.
+type Task struct {
+	TaskID      int        `json:"task_id" db:"task_id"`
+	CalendarID  int        `json:"calendar_id" db:"calendar_id"`
+	EventID     *int       `json:"event_id,omitempty" db:"event_id"`
 	Title       string     `json:"title" db:"title"`
 	Description *string    `json:"description,omitempty" db:"description"`
-	Start       time.Time  `json:"start" db:"start"`
-	Duration    int        `json:"duration" db:"duration"`
-	Canceled    bool       `json:"canceled" db:"canceled"`
+	DueDate     *time.Time `json:"due_date,omitempty" db:"due_date"`
+	Completed   bool       `json:"completed" db:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	Priority    int        `json:"priority" db:"priority"`
+	AssigneeID  *int       `json:"assignee_id,omitempty" db:"assignee_id"`
+	CreatedBy   int        `json:"created_by" db:"created_by"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// Resource représente la table resource (salle ou équipement réservable).
+// CalendarID est le calendrier de disponibilité de la ressource : ses événements la rendent indisponible.
+type Resource struct {
+	ResourceID  int        `json:"resource_id" db:"resource_id"`
+	Name        string     `json:"name" db:"name"`
+	Kind        string     `json:"kind" db:"kind"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	Capacity    *int       `json:"capacity,omitempty" db:"capacity"`
+	Location    *string    `json:"location,omitempty" db:"location"`
+	CalendarID  int        `json:"calendar_id" db:"calendar_id"`
+	CreatedBy   int        `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// BookingPage représente la table booking_page (page publique de prise de rendez-vous).
+// Les durées et tampons sont en minutes ; les plages de Rules sont exprimées dans Timezone.
+type BookingPage struct {
+	BookingPageID  int          `json:"booking_page_id" db:"booking_page_id"`
+	UserID         int          `json:"user_id" db:"user_id"`
+	CalendarID     int          `json:"calendar_id" db:"calendar_id"`
+	Slug           string       `json:"slug" db:"slug"`
+	Title          string       `json:"title" db:"title"`
+	Description    *string      `json:"description,omitempty" db:"description"`
+	Duration       int          `json:"duration" db:"duration"`
+	BufferBefore   int          `json:"buffer_before" db:"buffer_before"`
+	BufferAfter    int          `json:"buffer_after" db:"buffer_after"`
+	MaxPerDay      *int         `json:"max_per_day,omitempty" db:"max_per_day"`
+	MinNotice      int          `json:"min_notice" db:"min_notice"`
+	MaxAdvanceDays int          `json:"max_advance_days" db:"max_advance_days"`
+	Timezone       string       `json:"timezone" db:"timezone"`
+	Active         bool         `json:"active" db:"active"`
+	Rules          []WeeklyRule `json:"rules,omitempty" db:"-"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      *time.Time   `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// WeeklyRule est une plage hebdomadaire (tables booking_page_rule et user_working_hours).
+// Weekday va de 0 (dimanche) à 6 (samedi), les heures sont au format HH:MM.
+type WeeklyRule struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// Booking représente la table booking (rendez-vous pris par un visiteur sur une page de réservation).
+// Le jeton d'annulation n'est conservé que sous forme d'empreinte SHA-256.
+type Booking struct {
+	BookingID     int        `json:"booking_id" db:"booking_id"`
+	BookingPageID int        `json:"booking_page_id" db:"booking_page_id"`
+	EventID       int        `json:"event_id" db:"event_id"`
+	GuestName     string     `json:"guest_name" db:"guest_name"`
+	GuestEmail    string     `json:"guest_email" db:"guest_email"`
+	Notes         *string    `json:"notes,omitempty" db:"notes"`
+	Start         time.Time  `json:"start" db:"start"`
+	End           time.Time  `json:"end" db:"end"`
+	CanceledAt    *time.Time `json:"canceled_at,omitempty" db:"canceled_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
 // UserCalendar représente la table user_calendar
 type UserCalendar struct {
 	UserCalendarID int        `json:"user_calendar_id" db:"user_calendar_id"`
@@ -74,6 +291,7 @@ type UserCalendarWithDetails struct {
 	CalendarID     int        `json:"calendar_id" db:"calendar_id"`
 	Title          string     `json:"title" db:"title"`
 	Description    *string    `json:"description,omitempty" db:"description"`
+	Color          *string    `json:"color,omitempty" db:"color"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -99,12 +317,10 @@ type UserRole struct {
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
-// UserSession représente la table user_session
+// UserSession représente la table user_session ; les empreintes des tokens n'en font pas partie et ne sont jamais exposées
 type UserSession struct {
 	UserSessionID int        `json:"user_session_id" db:"user_session_id"`
 	UserID        int        `json:"user_id" db:"user_id"`
-	SessionToken  string     `json:"session_token" db:"session_token"`
-	RefreshToken  *string    `json:"refresh_token,omitempty" db:"refresh_token"`
 	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
 	DeviceInfo    *string    `json:"device_info,omitempty" db:"device_info"`
 	IPAddress     *string    `json:"ip_address,omitempty" db:"ip_address"`
@@ -115,6 +331,20 @@ type UserSession struct {
 	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// EventSearchResult représente un événement trouvé par la recherche plein texte
+type EventSearchResult struct {
+	Event
+	CalendarID int             `json:"calendar_id"`
+	Score      float64         `json:"score"`
+	Highlights EventHighlights `json:"highlights"`
+}
+
+// EventHighlights contient les extraits de l'événement où les termes recherchés sont balisés par <mark>
+type EventHighlights struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+}
+
 // UserWithRoles représente un utilisateur avec ses rôles
 type UserWithRoles struct {
 	User
@@ -145,11 +375,329 @@ type UpdateUserRequest struct {
 type CreateCalendarRequest struct {
 	Title       string  `json:"title" binding:"required"`
 	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty"`
 }
 
 type UpdateCalendarRequest struct {
 	Title       *string `json:"title,omitempty"`
 	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty"`
+}
+
+type CloneCalendarRequest struct {
+	Title          *string `json:"title,omitempty" binding:"omitempty,min=1"`
+	Description    *string `json:"description,omitempty"`
+	IncludeEvents  *bool   `json:"include_events,omitempty"`
+	IncludeSharing bool    `json:"include_sharing,omitempty"`
+	ShiftDays      int     `json:"shift_days,omitempty" binding:"min=-3660,max=3660"`
+}
+
+type CloneCalendarResponse struct {
+	CalendarID       int    `json:"calendar_id"`
+	SourceCalendarID int    `json:"source_calendar_id"`
+	Title            string `json:"title"`
+	EventsCopied     int    `json:"events_copied"`
+	SharesCopied     int    `json:"shares_copied"`
+	ShiftDays        int    `json:"shift_days"`
+}
+
+type TemplateEventRequest struct {
+	Title         string  `json:"title" binding:"required,max=200"`
+	Description   *string `json:"description,omitempty"`
+	OffsetMinutes int     `json:"offset_minutes" binding:"min=0"`
+	Duration      int     `json:"duration" binding:"required,min=1"`
+	Location      *string `json:"location,omitempty" binding:"omitempty,max=500"`
+	MeetingURL    *string `json:"meeting_url,omitempty" binding:"omitempty,max=2048"`
+}
+
+type CreateCalendarTemplateRequest struct {
+	Title       string                 `json:"title" binding:"required,max=200"`
+	Description *string                `json:"description,omitempty"`
+	Color       *string                `json:"color,omitempty"`
+	Events      []TemplateEventRequest `json:"events" binding:"dive"`
+}
+
+type UpdateCalendarTemplateRequest struct {
+	Title       *string                 `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
+	Description *string                 `json:"description,omitempty"`
+	Color       *string                 `json:"color,omitempty"`
+	Events      *[]TemplateEventRequest `json:"events,omitempty" binding:"omitempty,dive"`
+}
+
+type InstantiateTemplateRequest struct {
+	StartDate  string  `json:"start_date" binding:"required"` // YYYY-MM-DD ou RFC3339
+	CalendarID *int    `json:"calendar_id,omitempty"`
+	Title      *string `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
+}
+
+type CreateTaskRequest struct {
+	Title       string  `json:"title" binding:"required,max=200"`
+	Description *string `json:"description,omitempty"`
+	DueDate     *string `json:"due_date,omitempty"` // YYYY-MM-DD ou RFC3339
+	Completed   bool    `json:"completed,omitempty"`
+	Priority    int     `json:"priority,omitempty" binding:"min=0,max=9"`
+	AssigneeID  *int    `json:"assignee_id,omitempty" binding:"omitempty,min=1"`
+	EventID     *int    `json:"event_id,omitempty" binding:"omitempty,min=1"`
+}
+
+// UpdateTaskRequest : "due_date": "" retire l'échéance, "assignee_id": 0 et "event_id": 0 retirent l'assignation et l'événement lié
+type UpdateTaskRequest struct {
+	Title       *string `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
+	Description *string `json:"description,omitempty"`
+	DueDate     *string `json:"due_date,omitempty"`
+	Completed   *bool   `json:"completed,omitempty"`
+	Priority    *int    `json:"priority,omitempty" binding:"omitempty,min=0,max=9"`
+	AssigneeID  *int    `json:"assignee_id,omitempty" binding:"omitempty,min=0"`
+	EventID     *int    `json:"event_id,omitempty" binding:"omitempty,min=0"`
+}
+
+type CreateResourceRequest struct {
+	Name        string  `json:"name" binding:"required,max=200"`
+	Kind        string  `json:"kind" binding:"required,oneof=room equipment"`
+	Description *string `json:"description,omitempty"`
+	Capacity    *int    `json:"capacity,omitempty" binding:"omitempty,min=1"`
+	Location    *string `json:"location,omitempty" binding:"omitempty,max=500"`
+}
+
+type UpdateResourceRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=200"`
+	Kind        *string `json:"kind,omitempty" binding:"omitempty,oneof=room equipment"`
+	Description *string `json:"description,omitempty"`
+	Capacity    *int    `json:"capacity,omitempty" binding:"omitempty,min=0"` // 0 retire la capacité
+	Location    *string `json:"location,omitempty" binding:"omitempty,max=500"`
+}
+
+type BookResourceRequest struct {
+	ResourceID int `json:"resource_id" binding:"required,min=1"`
+}
+
+// ResourceBusySlot est un créneau pendant lequel une ressource n'est pas disponible.
+// Source vaut "booking" (réservation par un événement) ou "blocked" (événement du calendrier de disponibilité).
+type ResourceBusySlot struct {
+	EventID int       `json:"event_id"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Source  string    `json:"source"`
+}
+
+type ResourceAvailabilityResponse struct {
+	ResourceID int                `json:"resource_id"`
+	Start      time.Time          `json:"start"`
+	End        time.Time          `json:"end"`
+	Busy       []ResourceBusySlot `json:"busy"`
+}
+
+// UpdateWorkingHoursRequest : "rules": [] retire les heures de travail
+type UpdateWorkingHoursRequest struct {
+	Timezone string       `json:"timezone" binding:"required"`
+	Rules    []WeeklyRule `json:"rules" binding:"dive"`
+}
+
+type CreateOutOfOfficeRequest struct {
+	Start   time.Time `json:"start" binding:"required"`
+	End     time.Time `json:"end" binding:"required"`
+	Message *string   `json:"message,omitempty" binding:"omitempty,max=500"`
+}
+
+type CreateInvitationsRequest struct {
+	UserIDs []int `json:"user_ids" binding:"required,min=1,max=50,dive,min=1"`
+}
+
+type RespondInvitationRequest struct {
+	Status  string  `json:"status" binding:"required,oneof=accepted declined tentative"`
+	Comment *string `json:"comment,omitempty" binding:"omitempty,max=500"`
+}
+
+// FreeBusyUser décrit les disponibilités d'un utilisateur sur la période demandée.
+// WorkingHours est absent si l'utilisateur n'a pas défini d'heures de travail.
+type FreeBusyUser struct {
+	UserID       int         `json:"user_id"`
+	Timezone     string      `json:"timezone"`
+	WorkingHours []TimeRange `json:"working_hours,omitempty"`
+	OutOfOffice  []TimeRange `json:"out_of_office"`
+	Busy         []TimeRange `json:"busy"`
+}
+
+type FreeBusyResponse struct {
+	Start time.Time      `json:"start"`
+	End   time.Time      `json:"end"`
+	Users []FreeBusyUser `json:"users"`
+}
+
+type FindSlotsResponse struct {
+	Start    time.Time   `json:"start"`
+	End      time.Time   `json:"end"`
+	Duration int         `json:"duration"`
+	UserIDs  []int       `json:"user_ids"`
+	Slots    []TimeRange `json:"slots"`
+}
+
+// HolidayCalendar est un calendrier de jours fériés intégré, calculé par l'application et en lecture seule
+type HolidayCalendar struct {
+	Country    string `json:"country"`
+	Name       string `json:"name"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+// Holiday est un jour férié d'un pays (date au format YYYY-MM-DD)
+type Holiday struct {
+	Country string `json:"country"`
+	Date    string `json:"date"`
+	Name    string `json:"name"`
+}
+
+// Types d'entrées de l'agenda
+const (
+	AgendaEntryEvent   = "event"
+	AgendaEntryHoliday = "holiday"
+)
+
+// AgendaEntry est un élément de l'agenda : un événement d'un calendrier de l'utilisateur
+// ou un jour férié d'un calendrier auquel il est abonné (journée entière, sans event_id)
+type AgendaEntry struct {
+	Type       string    `json:"type"`
+	EventID    *int      `json:"event_id,omitempty"`
+	CalendarID *int      `json:"calendar_id,omitempty"`
+	Country    *string   `json:"country,omitempty"`
+	Title      string    `json:"title"`
+	Start      time.Time `json:"start"`
+	Duration   int       `json:"duration"`
+	AllDay     bool      `json:"all_day"`
+	Canceled   bool      `json:"canceled"`
+}
+
+// PushSubscription est un abonnement Web Push d'un navigateur (table push_subscription)
+type PushSubscription struct {
+	PushSubscriptionID int        `json:"push_subscription_id" db:"push_subscription_id"`
+	UserID             int        `json:"user_id" db:"user_id"`
+	Endpoint           string     `json:"endpoint" db:"endpoint"`
+	UserAgent          *string    `json:"user_agent,omitempty" db:"user_agent"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastSuccessAt      *time.Time `json:"last_success_at,omitempty" db:"last_success_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreatePushSubscriptionRequest reprend le format de PushSubscription.toJSON() côté navigateur
+type CreatePushSubscriptionRequest struct {
+	Endpoint       string `json:"endpoint" binding:"required,max=2048"`
+	ExpirationTime *int64 `json:"expirationTime"` // Millisecondes depuis l'époque Unix, null si l'abonnement n'expire pas
+	Keys           struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+// PushNotification est le contenu JSON chiffré envoyé au service worker
+type PushNotification struct {
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	URL     string `json:"url,omitempty"`
+	EventID *int   `json:"event_id,omitempty"`
+}
+
+// EventReminder est le rappel Web Push d'un événement pour un utilisateur (table event_reminder)
+type EventReminder struct {
+	EventReminderID int        `json:"event_reminder_id" db:"event_reminder_id"`
+	EventID         int        `json:"event_id" db:"event_id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	MinutesBefore   int        `json:"minutes_before" db:"minutes_before"`
+	RemindAt        time.Time  `json:"remind_at" db:"-"` // Début de l'événement moins minutes_before
+	NotifiedAt      *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// SetEventReminderRequest fixe le délai du rappel avant le début de l'événement (une semaine au plus)
+type SetEventReminderRequest struct {
+	MinutesBefore *int `json:"minutes_before" binding:"required,min=0,max=10080"`
+}
+
+// PushDeliveryReport résume l'envoi d'une notification aux abonnements d'un utilisateur
+type PushDeliveryReport struct {
+	Sent   int `json:"sent"`
+	Pruned int `json:"pruned"`
+	Failed int `json:"failed"`
+}
+
+type CreateBookingPageRequest struct {
+	CalendarID     int          `json:"calendar_id" binding:"required,min=1"`
+	Title          string       `json:"title" binding:"required,max=200"`
+	Description    *string      `json:"description,omitempty"`
+	Duration       int          `json:"duration" binding:"required,min=5,max=480"`
+	BufferBefore   int          `json:"buffer_before,omitempty" binding:"min=0,max=240"`
+	BufferAfter    int          `json:"buffer_after,omitempty" binding:"min=0,max=240"`
+	MaxPerDay      *int         `json:"max_per_day,omitempty" binding:"omitempty,min=1"`
+	MinNotice      int          `json:"min_notice,omitempty" binding:"min=0"`
+	MaxAdvanceDays int          `json:"max_advance_days,omitempty" binding:"min=0,max=365"` // 60 par défaut
+	Timezone       string       `json:"timezone,omitempty"`                                 // UTC par défaut
+	Rules          []WeeklyRule `json:"rules" binding:"required,min=1,dive"`
+}
+
+// UpdateBookingPageRequest : "max_per_day": 0 retire le maximum, "rules" remplace toutes les plages
+type UpdateBookingPageRequest struct {
+	CalendarID     *int          `json:"calendar_id,omitempty" binding:"omitempty,min=1"`
+	Title          *string       `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
+	Description    *string       `json:"description,omitempty"`
+	Duration       *int          `json:"duration,omitempty" binding:"omitempty,min=5,max=480"`
+	BufferBefore   *int          `json:"buffer_before,omitempty" binding:"omitempty,min=0,max=240"`
+	BufferAfter    *int          `json:"buffer_after,omitempty" binding:"omitempty,min=0,max=240"`
+	MaxPerDay      *int          `json:"max_per_day,omitempty" binding:"omitempty,min=0"`
+	MinNotice      *int          `json:"min_notice,omitempty" binding:"omitempty,min=0"`
+	MaxAdvanceDays *int          `json:"max_advance_days,omitempty" binding:"omitempty,min=1,max=365"`
+	Timezone       *string       `json:"timezone,omitempty"`
+	Active         *bool         `json:"active,omitempty"`
+	Rules          *[]WeeklyRule `json:"rules,omitempty" binding:"omitempty,min=1,dive"`
+}
+
+// PublicBookingPage est la vue d'une page de réservation exposée aux visiteurs
+type PublicBookingPage struct {
+	Slug        string  `json:"slug"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	OwnerName   string  `json:"owner_name"`
+	Duration    int     `json:"duration"`
+	Timezone    string  `json:"timezone"`
+}
+
+// TimeRange est un intervalle [start, end)
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type BookingSlotsResponse struct {
+	Slug     string      `json:"slug"`
+	Timezone string      `json:"timezone"`
+	Start    time.Time   `json:"start"`
+	End      time.Time   `json:"end"`
+	Slots    []TimeRange `json:"slots"`
+}
+
+type CreateBookingRequest struct {
+	Start time.Time `json:"start" binding:"required"`
+	Name  string    `json:"name" binding:"required,max=200"`
+	Email string    `json:"email" binding:"required,email,max=255"`
+	Notes *string   `json:"notes,omitempty" binding:"omitempty,max=2000"`
+}
+
+// BookingConfirmation est renvoyée au visiteur ; CancelToken n'est communiqué qu'une seule fois
+type BookingConfirmation struct {
+	BookingID   int       `json:"booking_id"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	CancelToken string    `json:"cancel_token"`
+}
+
+type CancelBookingRequest struct {
+	CancelToken string `json:"cancel_token" binding:"required,len=64,hexadecimal"`
+}
+
+type InstantiateTemplateResponse struct {
+	TemplateID      int   `json:"template_id"`
+	CalendarID      int   `json:"calendar_id"`
+	CalendarCreated bool  `json:"calendar_created"`
+	EventsCreated   int   `json:"events_created"`
+	EventIDs        []int `json:"event_ids"`
 }
 
 type CreateEventRequest struct {
@@ -159,6 +707,12 @@ type CreateEventRequest struct {
 	Duration    int       `json:"duration" binding:"required,min=1"`
 	CalendarID  int       `json:"calendar_id" binding:"required"`
 	Canceled    *bool     `json:"canceled,omitempty"`
+	TagIDs      []int     `json:"tag_ids,omitempty"`
+	ResourceIDs []int     `json:"resource_ids,omitempty"`
+	Location    *string   `json:"location,omitempty" binding:"omitempty,max=500"`
+	Latitude    *float64  `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude   *float64  `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	MeetingURL  *string   `json:"meeting_url,omitempty" binding:"omitempty,max=2048"`
 }
 
 type UpdateEventRequest struct {
@@ -167,6 +721,67 @@ type UpdateEventRequest struct {
 	Start       *time.Time `json:"start,omitempty"`
 	Duration    *int       `json:"duration,omitempty" binding:"omitempty,min=1"`
 	Canceled    *bool      `json:"canceled,omitempty"`
+	TagIDs      *[]int     `json:"tag_ids,omitempty"`
+	ResourceIDs *[]int     `json:"resource_ids,omitempty"`
+	Location    *string    `json:"location,omitempty" binding:"omitempty,max=500"`
+	Latitude    *float64   `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude   *float64   `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	MeetingURL  *string    `json:"meeting_url,omitempty" binding:"omitempty,max=2048"`
+}
+
+// Structures pour les opérations groupées sur les événements
+type BatchEventRequest struct {
+	Mode       string                `json:"mode,omitempty" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchEventOperation `json:"operations"`
+}
+
+type BatchEventOperation struct {
+	Action  string          `json:"action"`
+	EventID *int            `json:"event_id,omitempty"`
+	Event   json.RawMessage `json:"event,omitempty" swaggertype:"object"`
+}
+
+type BatchEventResult struct {
+	Index   int    `json:"index"`
+	Action  string `json:"action"`
+	EventID *int   `json:"event_id,omitempty"`
+	Success bool   `json:"success"`
+	Status  int    `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BatchEventResponse struct {
+	Mode      string             `json:"mode"`
+	Applied   bool               `json:"applied"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BatchEventResult `json:"results"`
+}
+
+type LinkEventRequest struct {
+	TargetCalendarID int `json:"target_calendar_id" binding:"required"`
+}
+
+// Structures pour le déplacement et la copie d'événements entre calendriers
+type TransferEventsRequest struct {
+	TargetCalendarID int        `json:"target_calendar_id" binding:"required"`
+	EventIDs         []int      `json:"event_ids,omitempty"`
+	Start            *time.Time `json:"start,omitempty"`
+	End              *time.Time `json:"end,omitempty"`
+	TagIDs           []int      `json:"tag_ids,omitempty"`
+}
+
+type TransferredEvent struct {
+	EventID    int  `json:"event_id"`
+	NewEventID *int `json:"new_event_id,omitempty"`
+}
+
+type TransferEventsResponse struct {
+	SourceCalendarID int                `json:"source_calendar_id"`
+	TargetCalendarID int                `json:"target_calendar_id"`
+	Count            int                `json:"count"`
+	Events           []TransferredEvent `json:"events"`
 }
 
 // Structures pour les requêtes de filtrage des événements
@@ -185,12 +800,164 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// PasswordResetRequest demande l'envoi d'un lien de réinitialisation du mot de passe
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// EmailVerificationResendRequest demande un nouvel envoi du lien de vérification de l'adresse e-mail
+type EmailVerificationResendRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// EmailVerificationConfirmRequest confirme l'adresse e-mail avec le jeton reçu
+type EmailVerificationConfirmRequest struct {
+	Token string `json:"token" binding:"required,len=64,hexadecimal"`
+}
+
+// PasswordResetConfirmRequest définit un nouveau mot de passe à partir du jeton reçu par e-mail
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token" binding:"required,len=64,hexadecimal"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
 type LoginResponse struct {
 	User         User      `json:"user"`
 	SessionToken string    `json:"session_token"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	ExpiresAt    time.Time `json:"expires_at"`
-	Roles        []Role    `json:"roles"`
+	// RefreshExpiresAt est l'échéance absolue de la session : la rotation du refresh token ne la repousse pas
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	Roles            []Role    `json:"roles"`
+}
+
+// LoginChallengeResponse est retourné par la connexion lorsque l'authentification à deux facteurs est activée :
+// le jeton est à échanger, avec un code, contre une session sur /auth/2fa/verify
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// TwoFactorVerifyRequest termine une connexion en deux étapes avec un code TOTP ou un code de récupération
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required,len=64,hexadecimal"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorStatus décrit l'authentification à deux facteurs de l'utilisateur connecté
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse contient le secret TOTP à enregistrer dans l'application d'authentification
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorConfirmRequest confirme l'activation avec un premier code généré par l'application
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorRecoveryCodesResponse contient les codes de récupération, affichés une seule fois
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorDisableRequest désactive l'authentification à deux facteurs (mot de passe et code TOTP ou de récupération).
+// Le mot de passe est ignoré pour un compte sans mot de passe local.
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// Passkey est une passkey (credential WebAuthn) enregistrée par l'utilisateur
+type Passkey struct {
+	PasskeyID  int        `json:"passkey_id" db:"passkey_id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PasskeyAttestationResponse est la réponse de l'authentificateur à navigator.credentials.create(), en base64url
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AttestationObject string `json:"attestationObject" binding:"required"`
+}
+
+// PasskeyRegistrationCredential est le résultat de navigator.credentials.create() (PublicKeyCredential.toJSON())
+type PasskeyRegistrationCredential struct {
+	ID       string                     `json:"id" binding:"required,max=1400"`
+	Type     string                     `json:"type" binding:"required,eq=public-key"`
+	Response PasskeyAttestationResponse `json:"response" binding:"required"`
+}
+
+// PasskeyRegisterRequest enregistre une passkey créée avec les options de /auth/passkeys/register/options
+type PasskeyRegisterRequest struct {
+	Name       string                        `json:"name,omitempty" binding:"max=100"`
+	Credential PasskeyRegistrationCredential `json:"credential" binding:"required"`
+}
+
+// PasskeyAssertionResponse est la réponse de l'authentificateur à navigator.credentials.get(), en base64url
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AuthenticatorData string `json:"authenticatorData" binding:"required"`
+	Signature         string `json:"signature" binding:"required"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// PasskeyLoginRequest est le résultat de navigator.credentials.get() (PublicKeyCredential.toJSON())
+type PasskeyLoginRequest struct {
+	ID       string                   `json:"id" binding:"required,max=1400"`
+	Type     string                   `json:"type" binding:"required,eq=public-key"`
+	Response PasskeyAssertionResponse `json:"response" binding:"required"`
+}
+
+// OIDCAuthorizeResponse contient l'URL du fournisseur d'identité vers laquelle rediriger l'utilisateur
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// SecurityEvent est une entrée du journal de sécurité : connexion réussie ou échouée, verrouillage, déverrouillage
+type SecurityEvent struct {
+	SecurityEventID int       `json:"security_event_id"`
+	EventType       string    `json:"event_type"`
+	UserID          *int      `json:"user_id,omitempty"`
+	Email           string    `json:"email"`
+	IPAddress       string    `json:"ip_address"`
+	Details         string    `json:"details,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SecurityEventsResponse contient l'état du verrouillage d'un compte et ses derniers événements de sécurité
+type SecurityEventsResponse struct {
+	FailedAttempts int             `json:"failed_attempts"`
+	LockedUntil    *time.Time      `json:"locked_until,omitempty"`
+	Events         []SecurityEvent `json:"events"`
+}
+
+// OIDCCallbackRequest transmet les paramètres code et state reçus par la page de retour du front-end
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required,max=2048"`
+	State string `json:"state" binding:"required,max=128"`
+}
+
+type CreateTagRequest struct {
+	Name  string  `json:"name" binding:"required,max=100"`
+	Kind  string  `json:"kind,omitempty" binding:"omitempty,oneof=tag category"`
+	Color *string `json:"color,omitempty"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Kind  *string `json:"kind,omitempty" binding:"omitempty,oneof=tag category"`
+	Color *string `json:"color,omitempty"`
 }
 
 type CreateRoleRequest struct {
@@ -0,0 +1,34 @@
+package common
+
+import "strings"
+
+// InClause construit la liste de placeholders et d'arguments d'une clause IN (ex: "?,?,?")
+func InClause(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// UniqueIDs retire les doublons en conservant l'ordre
+func UniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var unique []int
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// NullIfEmpty convertit une chaîne vide en NULL SQL
+func NullIfEmpty(value *string) interface{} {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInClause(t *testing.T) {
+	tests := []struct {
+		name         string
+		ids          []int
+		placeholders string
+		args         []interface{}
+	}{
+		{
+			name:         "Liste vide",
+			ids:          nil,
+			placeholders: "",
+			args:         []interface{}{},
+		},
+		{
+			name:         "Un identifiant",
+			ids:          []int{4},
+			placeholders: "?",
+			args:         []interface{}{4},
+		},
+		{
+			name:         "Plusieurs identifiants",
+			ids:          []int{4, 8, 15},
+			placeholders: "?,?,?",
+			args:         []interface{}{4, 8, 15},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placeholders, args := InClause(tt.ids)
+			if placeholders != tt.placeholders {
+				t.Errorf("InClause(%v) placeholders = %q, attendu %q", tt.ids, placeholders, tt.placeholders)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("InClause(%v) args = %v, attendu %v", tt.ids, args, tt.args)
+			}
+		})
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	result := UniqueIDs([]int{3, 1, 3, 2, 1})
+	if !reflect.DeepEqual(result, []int{3, 1, 2}) {
+		t.Errorf("UniqueIDs = %v, attendu [3 1 2]", result)
+	}
+	if result := UniqueIDs(nil); len(result) != 0 {
+		t.Errorf("UniqueIDs(nil) = %v, attendu une liste vide", result)
+	}
+}
+
+func TestNullIfEmpty(t *testing.T) {
+	if NullIfEmpty(nil) != nil {
+		t.Error("NullIfEmpty(nil) devrait retourner nil")
+	}
+	if NullIfEmpty(StringPtr("")) != nil {
+		t.Error("NullIfEmpty(\"\") devrait retourner nil")
+	}
+	if value := NullIfEmpty(StringPtr("salle A")); value != "salle A" {
+		t.Errorf("NullIfEmpty = %v, attendu \"salle A\"", value)
+	}
+}
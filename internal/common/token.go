@@ -0,0 +1,23 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// GenerateToken génère un jeton aléatoire hexadécimal de size octets
+func GenerateToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// HashToken retourne l'empreinte SHA-256 (hexadécimale) d'un jeton, forme sous laquelle il est conservé
+// et recherché en base (sessions, vérifications, challenges, endpoints de push, etc.)
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package common
+
+import "testing"
+
+func TestGenerateToken(t *testing.T) {
+	token, err := GenerateToken(32)
+	if err != nil {
+		t.Fatalf("GenerateToken a échoué : %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("GenerateToken(32) = %d caractères, attendu 64", len(token))
+	}
+	other, err := GenerateToken(32)
+	if err != nil {
+		t.Fatalf("GenerateToken a échoué : %v", err)
+	}
+	if token == other {
+		t.Error("deux jetons générés successivement ne devraient pas être identiques")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	// Empreinte SHA-256 de "abc" (FIPS 180-2)
+	expected := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if hash := HashToken("abc"); hash != expected {
+		t.Errorf("HashToken(\"abc\") = %s, attendu %s", hash, expected)
+	}
+}
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// hexColorRegex valide une couleur au format hexadécimal #RRGGBB
+var hexColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// IsValidHexColor indique si la couleur est au format #RRGGBB (ex: "#3788D8")
+func IsValidHexColor(color string) bool {
+	return hexColorRegex.MatchString(color)
+}
+
+// IsValidMeetingURL indique si le lien est une URL absolue http(s) (ex: "https://meet.example.com/abc")
+func IsValidMeetingURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestIsValidHexColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    string
+		expected bool
+	}{
+		{name: "Couleur minuscule", color: "#3788d8", expected: true},
+		{name: "Couleur majuscule", color: "#FF0000", expected: true},
+		{name: "Sans dièse", color: "3788d8", expected: false},
+		{name: "Format court", color: "#fff", expected: false},
+		{name: "Caractère invalide", color: "#12345G", expected: false},
+		{name: "Chaîne vide", color: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsValidHexColor(tt.color); result != tt.expected {
+				t.Errorf("IsValidHexColor(%q) = %v, want %v", tt.color, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsValidMeetingURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected bool
+	}{
+		{name: "Lien https", url: "https://meet.example.com/abc-defg-hij", expected: true},
+		{name: "Lien http avec port", url: "http://localhost:8443/room", expected: true},
+		{name: "Schéma non supporté", url: "javascript:alert(1)", expected: false},
+		{name: "Sans hôte", url: "https:///room", expected: false},
+		{name: "Chemin relatif", url: "/room/42", expected: false},
+		{name: "Chaîne vide", url: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsValidMeetingURL(tt.url); result != tt.expected {
+				t.Errorf("IsValidMeetingURL(%q) = %v, want %v", tt.url, result, tt.expected)
+			}
+		})
+	}
+}
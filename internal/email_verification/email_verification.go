@@ -0,0 +1,238 @@
+// Package email_verification internal/email_verification/email_verification.go
+package email_verification
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/accesstoken"
+	"go-averroes/internal/common"
+	"go-averroes/internal/mailer"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailVerificationStruct struct{}
+
+var EmailVerification = EmailVerificationStruct{}
+
+// Policy est la politique appliquée aux comptes non vérifiés (common.EmailVerificationPolicy*), initialisée par Init
+var Policy = common.EmailVerificationPolicyNone
+
+const (
+	// tokenTTL est la durée de validité d'un lien de vérification
+	tokenTTL = 24 * time.Hour
+	// maxResendPerHour borne le nombre de liens envoyés à un même compte, en plus de la limite par IP
+	maxResendPerHour = 3
+)
+
+// execer est satisfait par *sql.DB et *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Init valide et applique la politique de vérification des adresses e-mail
+func Init(policy string) error {
+	switch policy {
+	case common.EmailVerificationPolicyNone, common.EmailVerificationPolicyLogin, common.EmailVerificationPolicyRoutes:
+		Policy = policy
+		return nil
+	default:
+		return errors.New(policy)
+	}
+}
+
+// IssueToken crée un jeton de vérification pour l'utilisateur et retourne sa valeur en clair, à envoyer avec Send
+func IssueToken(db execer, userID int) (string, error) {
+	token, err := common.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	if _, err := db.Exec(`
+		INSERT INTO email_verification_token (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, NOW())
+	`, userID, common.HashToken(token), time.Now().Add(tokenTTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Send envoie le lien de vérification à l'adresse de l'utilisateur
+func Send(email, firstname, token string) {
+	slog.Info(common.LogEmailVerificationSend)
+	mailer.SendAsync(mailer.Message{
+		To:      email,
+		Subject: "Vérifiez votre adresse e-mail",
+		Body: "Bonjour " + firstname + ",\n\n" +
+			"Pour confirmer votre adresse e-mail, ouvrez le lien suivant (valable 24 heures) :\n" +
+			mailer.Link("/verify-email", token) + "\n\n" +
+			"Si vous n'avez pas créé de compte GoLendar, ignorez cet e-mail.\n",
+	})
+}
+
+// Resend envoie un nouveau lien de vérification
+// @Summary Renvoyer le lien de vérification de l'adresse e-mail
+// @Description Envoie un nouveau lien (valable 24 heures) si un compte non vérifié correspond à l'adresse. La réponse est identique dans tous les cas.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.EmailVerificationResendRequest true "Adresse e-mail du compte"
+// @Success 202 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/email-verification/resend [post]
+func (EmailVerificationStruct) Resend(c *gin.Context) {
+	slog.Info(common.LogEmailVerificationResend)
+	var req common.EmailVerificationResendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEmailVerificationResend + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	// Les erreurs sont journalisées mais jamais renvoyées : la réponse ne doit pas dépendre de l'existence du compte
+	if err := resend(req.Email); err != nil {
+		slog.Error(common.LogEmailVerificationResend + " - erreur lors de la création du jeton : " + err.Error())
+	}
+
+	c.JSON(http.StatusAccepted, common.JSONResponse{
+		Success: true,
+		Message: common.MsgEmailVerificationResent,
+	})
+}
+
+// Confirm marque l'adresse e-mail comme vérifiée à partir du jeton reçu
+// @Summary Vérifier l'adresse e-mail
+// @Description Marque l'adresse du compte associé au jeton comme vérifiée. Le jeton est consommé, ainsi que les autres liens en attente.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.EmailVerificationConfirmRequest true "Jeton reçu par e-mail"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/email-verification/confirm [post]
+func (EmailVerificationStruct) Confirm(c *gin.Context) {
+	slog.Info(common.LogEmailVerificationConfirm)
+	var req common.EmailVerificationConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailVerification,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		SELECT evt.user_id
+		FROM email_verification_token evt
+		INNER JOIN user u ON u.user_id = evt.user_id
+		WHERE evt.token_hash = ? AND evt.used_at IS NULL AND evt.expires_at > NOW() AND u.deleted_at IS NULL
+		FOR UPDATE
+	`, common.HashToken(req.Token)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Error(common.LogEmailVerificationConfirm + " - jeton invalide, expiré ou déjà utilisé")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidEmailVerificationToken,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - erreur lors de la vérification du jeton : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailVerification,
+		})
+		return
+	}
+
+	// Les tokens d'accès signés émis auparavant portent une adresse non vérifiée
+	_, err = tx.Exec(`UPDATE user SET email_verified_at = NOW() WHERE user_id = ? AND email_verified_at IS NULL`, userID)
+	if err == nil {
+		err = accesstoken.ClaimsChanged(tx, userID)
+	}
+	if err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - erreur lors de la mise à jour de l'utilisateur : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailVerification,
+		})
+		return
+	}
+	if _, err := tx.Exec(`UPDATE email_verification_token SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL`, userID); err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - erreur lors de la consommation du jeton : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailVerification,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEmailVerificationConfirm + " - erreur lors du commit : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailVerification,
+		})
+		return
+	}
+
+	slog.Info(common.LogEmailVerificationConfirm + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessEmailVerified,
+	})
+}
+
+// resend crée et envoie un nouveau jeton si l'adresse correspond à un compte non vérifié.
+// Rien n'est fait si trop de liens ont été envoyés au compte dans l'heure.
+func resend(email string) error {
+	var userID int
+	var firstname string
+	err := common.DB.QueryRow(`
+		SELECT user_id, firstname FROM user WHERE email = ? AND deleted_at IS NULL AND email_verified_at IS NULL
+	`, email).Scan(&userID, &firstname)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var recent int
+	if err := common.DB.QueryRow(`
+		SELECT COUNT(*) FROM email_verification_token WHERE user_id = ? AND created_at > ?
+	`, userID, time.Now().Add(-time.Hour)).Scan(&recent); err != nil {
+		return err
+	}
+	if recent >= maxResendPerHour {
+		slog.Info(common.LogEmailVerificationResend+" - limite d'envoi atteinte pour ce compte", "user_id", userID)
+		return nil
+	}
+
+	token, err := IssueToken(common.DB, userID)
+	if err != nil {
+		return err
+	}
+	Send(email, firstname, token)
+	return nil
+}
@@ -0,0 +1,152 @@
+package email_verification_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
+	"go-averroes/internal/mailer"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// tokenPattern extrait le jeton du lien envoyé par e-mail
+var tokenPattern = regexp.MustCompile(`token=([0-9a-f]{64})`)
+
+// setPolicy change la politique de vérification le temps du test
+func setPolicy(t *testing.T, policy string) {
+	previous := email_verification.Policy
+	require.NoError(t, email_verification.Init(policy))
+	t.Cleanup(func() { email_verification.Policy = previous })
+}
+
+// sentMessages retourne les e-mails envoyés à l'adresse par le mailer de test
+func sentMessages(email string) []mailer.Message {
+	return mailer.Default.(*mailer.MemoryMailer).Messages(email)
+}
+
+// login retourne le code HTTP de la connexion et le jeton de session obtenu
+func login(t *testing.T, email, password string) (int, testutils.APIResponse, string) {
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/login", gin.H{"email": email, "password": password})
+	var data common.LoginResponse
+	if status == http.StatusOK {
+		require.NoError(t, json.Unmarshal(response.Data, &data))
+	}
+	return status, response, data.SessionToken
+}
+
+// TestEmailVerificationFlow vérifie l'envoi du lien à l'inscription, les politiques et la confirmation
+func TestEmailVerificationFlow(t *testing.T) {
+	email := testutils.GenerateUniqueEmail("signup")
+	password := "MotDePasse123!"
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/user", gin.H{"lastname": "Haddad", "firstname": "Nour", "email": email, "password": password})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	require.Eventually(t, func() bool { return len(sentMessages(email)) == 1 }, 2*time.Second, 20*time.Millisecond)
+	match := tokenPattern.FindStringSubmatch(sentMessages(email)[0].Body)
+	require.NotNil(t, match, "lien de vérification absent de l'e-mail")
+	token := match[1]
+
+	// Politique "login" : la connexion est refusée, mais seulement avec des identifiants valides
+	setPolicy(t, common.EmailVerificationPolicyLogin)
+	status, _, _ = login(t, email, "MauvaisMotDePasse")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, response, _ = login(t, email, password)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrEmailNotVerified, response.Error)
+
+	// Politique "routes" : connexion permise, routes métier refusées, profil accessible
+	setPolicy(t, common.EmailVerificationPolicyRoutes)
+	status, response, sessionToken := login(t, email, password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, sessionToken, "GET", "/tags", nil)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrEmailNotVerified, response.Error)
+	status, _ = testutils.DoRequest(t, testServer, sessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// Confirmation : le jeton est à usage unique
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/confirm", gin.H{"token": strings.Repeat("ef", 32)})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidEmailVerificationToken, response.Error)
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/confirm", gin.H{"token": token})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/confirm", gin.H{"token": token})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// La session existante bénéficie immédiatement de la vérification
+	status, response = testutils.DoRequest(t, testServer, sessionToken, "GET", "/tags", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	setPolicy(t, common.EmailVerificationPolicyLogin)
+	status, response, _ = login(t, email, password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Adresse déjà vérifiée : même réponse, aucun nouvel e-mail
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/resend", gin.H{"email": email})
+	require.Equal(t, http.StatusAccepted, status)
+	require.Never(t, func() bool { return len(sentMessages(email)) > 1 }, 300*time.Millisecond, 20*time.Millisecond)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestEmailVerificationResend vérifie le renvoi du lien et l'invalidation par changement d'adresse
+func TestEmailVerificationResend(t *testing.T) {
+	email := testutils.GenerateUniqueEmail("signup")
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/user", gin.H{"lastname": "Haddad", "firstname": "Nour", "email": email, "password": "MotDePasse123!"})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/resend", gin.H{"email": email})
+	require.Equal(t, http.StatusAccepted, status)
+	require.Eventually(t, func() bool { return len(sentMessages(email)) == 2 }, 2*time.Second, 20*time.Millisecond)
+	first := tokenPattern.FindStringSubmatch(sentMessages(email)[0].Body)[1]
+
+	// Un utilisateur vérifié qui change d'adresse doit vérifier la nouvelle
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(response.Data), "email_verified_at")
+	newEmail := testutils.GenerateUniqueEmail("changed")
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", "/user/me", gin.H{"email": newEmail})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Eventually(t, func() bool { return len(sentMessages(newEmail)) == 1 }, 2*time.Second, 20*time.Millisecond)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, string(response.Data), "email_verified_at")
+
+	// Le premier lien reste valable après un renvoi
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/email-verification/confirm", gin.H{"token": first})
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
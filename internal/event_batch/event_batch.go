@@ -0,0 +1,289 @@
+// Package event_batch internal/event_batch/event_batch.go
+package event_batch
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type EventBatchStruct struct{}
+
+var EventBatch = EventBatchStruct{}
+
+const (
+	// ModeAtomic applique toutes les opérations ou aucune
+	ModeAtomic = "atomic"
+	// ModeBestEffort applique chaque opération indépendamment des échecs des autres
+	ModeBestEffort = "best_effort"
+
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionCancel = "cancel"
+
+	// maxBatchOperations borne le nombre d'opérations d'un lot pour limiter la durée de la transaction
+	maxBatchOperations = 1000
+)
+
+// Apply applique un lot d'opérations sur les événements d'un calendrier
+// @Summary Opérations groupées sur les événements
+// @Description Crée, met à jour, supprime ou annule plusieurs événements en une seule requête et une seule transaction. En mode "atomic" (défaut), le premier échec annule tout le lot ; en mode "best_effort", chaque opération est appliquée indépendamment. Chaque opération reçoit un résultat individuel.
+// @Tags Événement
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param batch body common.BatchEventRequest true "Opérations à appliquer"
+// @Success 200 {object} common.JSONResponse{data=common.BatchEventResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/batch [post]
+func (EventBatchStruct) Apply(c *gin.Context) {
+	slog.Info(common.LogEventBatch)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.BatchEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEventBatch + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = ModeAtomic
+	}
+	if len(req.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBatchEmpty,
+		})
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBatchTooLarge,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogEventBatch + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
+
+	response := common.BatchEventResponse{
+		Mode:    req.Mode,
+		Total:   len(req.Operations),
+		Results: make([]common.BatchEventResult, 0, len(req.Operations)),
+	}
+	failedIndex := -1
+
+	for i, op := range req.Operations {
+		// En mode best_effort, un point de sauvegarde isole chaque opération
+		if req.Mode == ModeBestEffort {
+			if _, err := tx.Exec("SAVEPOINT batch_item"); err != nil {
+				slog.Error(common.LogEventBatch + " - erreur lors de la création du point de sauvegarde : " + err.Error())
+				c.JSON(http.StatusInternalServerError, common.JSONResponse{
+					Success: false,
+					Error:   common.ErrTransactionStart,
+				})
+				return
+			}
+		}
+
+		result := applyOperation(tx, userData.UserID, calendarData.CalendarID, i, op)
+		response.Results = append(response.Results, result)
+		if result.Success {
+			continue
+		}
+
+		slog.Error(common.LogEventBatch + " - échec de l'opération : " + result.Error)
+		if req.Mode == ModeAtomic {
+			failedIndex = i
+			break
+		}
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+			slog.Error(common.LogEventBatch + " - erreur lors du retour au point de sauvegarde : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrEventOperation,
+			})
+			return
+		}
+	}
+
+	// En mode atomic, un échec annule l'ensemble du lot : aucune autre opération n'est appliquée
+	if failedIndex >= 0 {
+		failed := response.Results[failedIndex]
+		markNotApplied(&response, req.Operations, failedIndex)
+		c.JSON(failed.Status, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrBatchFailed,
+			Data:    response,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEventBatch + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	response.Applied = true
+	for _, result := range response.Results {
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	message := common.MsgSuccessBatchEvents
+	if response.Failed > 0 {
+		message = common.MsgPartialBatchEvents
+	}
+
+	slog.Info(common.LogEventBatch + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: message,
+		Data:    response,
+	})
+}
+
+// applyOperation valide puis applique une opération dans la transaction et retourne son résultat
+func applyOperation(tx *sql.Tx, userID, calendarID, index int, op common.BatchEventOperation) common.BatchEventResult {
+	result := common.BatchEventResult{Index: index, Action: op.Action, EventID: op.EventID}
+	fail := func(status int, message string) common.BatchEventResult {
+		result.Status = status
+		result.Error = message
+		return result
+	}
+
+	switch op.Action {
+	case ActionCreate:
+		if len(op.Event) == 0 {
+			return fail(http.StatusBadRequest, common.ErrBatchMissingEvent)
+		}
+		var req common.CreateEventRequest
+		if err := json.Unmarshal(op.Event, &req); err != nil {
+			return fail(http.StatusBadRequest, common.ErrInvalidData+": "+err.Error())
+		}
+		// Le calendrier cible est toujours celui de l'URL
+		req.CalendarID = calendarID
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			return fail(http.StatusBadRequest, common.ErrInvalidData+": "+err.Error())
+		}
+		if errMsg := calendar_event.ValidateCreateRequest(req); errMsg != "" {
+			return fail(http.StatusBadRequest, errMsg)
+		}
+		eventID, err := calendar_event.CreateEvent(tx, userID, calendarID, req)
+		if err != nil {
+			return fail(calendar_event.ErrorResponse(err))
+		}
+		result.EventID = common.IntPtr(int(eventID))
+		result.Status = http.StatusCreated
+
+	case ActionUpdate, ActionDelete, ActionCancel:
+		if op.EventID == nil {
+			return fail(http.StatusBadRequest, common.ErrBatchMissingEventID)
+		}
+		var req common.UpdateEventRequest
+		if op.Action == ActionUpdate {
+			if len(op.Event) == 0 {
+				return fail(http.StatusBadRequest, common.ErrBatchMissingEvent)
+			}
+			if err := json.Unmarshal(op.Event, &req); err != nil {
+				return fail(http.StatusBadRequest, common.ErrInvalidData+": "+err.Error())
+			}
+			if err := binding.Validator.ValidateStruct(req); err != nil {
+				return fail(http.StatusBadRequest, common.ErrInvalidData+": "+err.Error())
+			}
+			if errMsg := calendar_event.ValidateUpdateRequest(req); errMsg != "" {
+				return fail(http.StatusBadRequest, errMsg)
+			}
+		}
+
+		// L'événement doit appartenir au calendrier de l'URL
+		linked, err := calendar_event.IsEventLinked(tx, calendarID, *op.EventID)
+		if err != nil {
+			return fail(calendar_event.ErrorResponse(err))
+		}
+		if !linked {
+			return fail(http.StatusNotFound, common.ErrEventNotFound)
+		}
+
+		switch op.Action {
+		case ActionUpdate:
+			err = calendar_event.UpdateEvent(tx, userID, *op.EventID, req)
+		case ActionDelete:
+			_, err = calendar_event.DeleteEvent(tx, calendarID, *op.EventID)
+		case ActionCancel:
+			err = calendar_event.CancelEvent(tx, *op.EventID)
+		}
+		if err != nil {
+			return fail(calendar_event.ErrorResponse(err))
+		}
+		result.Status = http.StatusOK
+
+	default:
+		return fail(http.StatusBadRequest, common.ErrBatchInvalidAction)
+	}
+
+	result.Success = true
+	return result
+}
+
+// markNotApplied complète les résultats d'un lot atomique annulé : les opérations déjà traitées
+// sont annulées et celles qui suivent l'échec ne sont pas exécutées
+func markNotApplied(response *common.BatchEventResponse, operations []common.BatchEventOperation, failedIndex int) {
+	for i := range response.Results {
+		if i == failedIndex {
+			continue
+		}
+		response.Results[i].Success = false
+		response.Results[i].Status = http.StatusFailedDependency
+		response.Results[i].Error = common.ErrBatchNotApplied
+		if response.Results[i].Action == ActionCreate {
+			// L'identifiant attribué n'existe plus après le rollback
+			response.Results[i].EventID = nil
+		}
+	}
+	for i := failedIndex + 1; i < len(operations); i++ {
+		response.Results = append(response.Results, common.BatchEventResult{
+			Index:   i,
+			Action:  operations[i].Action,
+			EventID: operations[i].EventID,
+			Status:  http.StatusFailedDependency,
+			Error:   common.ErrBatchNotApplied,
+		})
+	}
+	response.Failed = len(response.Results)
+}
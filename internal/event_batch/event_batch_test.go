@@ -0,0 +1,231 @@
+package event_batch_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// batchResponse est la réponse typée de la route d'opérations groupées
+type batchResponse struct {
+	common.JSONResponse
+	Data common.BatchEventResponse `json:"data"`
+}
+
+// postBatch envoie un lot d'opérations sur le calendrier de l'utilisateur
+func postBatch(t *testing.T, user *testutils.AuthenticatedUser, calendarID int, body interface{}) (int, batchResponse) {
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-event/"+strconv.Itoa(calendarID)+"/batch", body)
+	result := batchResponse{JSONResponse: response.JSONResponse}
+	require.NoError(t, json.Unmarshal(response.Data, &result.Data), "Erreur lors du parsing des données")
+	return status, result
+}
+
+// countCalendarEvents compte les événements actifs d'un calendrier
+func countCalendarEvents(t *testing.T, calendarID int) int {
+	var count int
+	err := common.DB.QueryRow(`
+		SELECT COUNT(*) FROM calendar_event ce
+		INNER JOIN event e ON ce.event_id = e.event_id
+		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+	`, calendarID).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+func newEvent(title string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":    title,
+		"start":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"duration": 60,
+	}
+}
+
+// TestBatchEventRoute teste la route POST d'opérations groupées avec plusieurs cas
+func TestBatchEventRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+		ExpectedCount    int // nombre d'événements du calendrier après le lot (1 événement initial)
+	}{
+		{
+			CaseName: "Lot atomique réussi : création, mise à jour et annulation",
+			RequestData: func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{
+					"operations": []map[string]interface{}{
+						{"action": "create", "event": newEvent("Réunion budget")},
+						{"action": "create", "event": newEvent("Point hebdo")},
+						{"action": "update", "event_id": user.Event.EventID, "event": map[string]interface{}{"title": "Titre modifié"}},
+						{"action": "cancel", "event_id": user.Event.EventID},
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessBatchEvents,
+			ExpectedCount:    3,
+		},
+		{
+			CaseName: "Lot atomique annulé par un événement d'un autre calendrier",
+			RequestData: func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{
+					"mode": "atomic",
+					"operations": []map[string]interface{}{
+						{"action": "create", "event": newEvent("Réunion budget")},
+						{"action": "delete", "event_id": other.Event.EventID},
+						{"action": "delete", "event_id": user.Event.EventID},
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusNotFound,
+			ExpectedError:    common.ErrBatchFailed,
+			ExpectedCount:    1,
+		},
+		{
+			CaseName: "Lot best_effort partiellement appliqué",
+			RequestData: func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{
+					"mode": "best_effort",
+					"operations": []map[string]interface{}{
+						{"action": "create", "event": newEvent("Réunion budget")},
+						{"action": "create", "event": map[string]interface{}{"title": "Sans date"}},
+						{"action": "archive", "event_id": user.Event.EventID},
+						{"action": "delete", "event_id": user.Event.EventID},
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgPartialBatchEvents,
+			ExpectedCount:    1,
+		},
+		{
+			CaseName: "Échec d'un lot vide",
+			RequestData: func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"operations": []map[string]interface{}{}}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrBatchEmpty,
+			ExpectedCount:    1,
+		},
+		{
+			CaseName: "Échec d'un mode inconnu",
+			RequestData: func(user *testutils.AuthenticatedUser, other *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{
+					"mode":       "partial",
+					"operations": []map[string]interface{}{{"action": "delete", "event_id": user.Event.EventID}},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+			ExpectedCount:    1,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+			require.NoError(t, err)
+			other, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+			require.NoError(t, err)
+
+			status, response := postBatch(t, user, user.Calendar.CalendarID, testCase.RequestData(user, other))
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+			require.Equal(t, testCase.ExpectedCount, countCalendarEvents(t, user.Calendar.CalendarID), "Nombre d'événements incorrect")
+			require.Equal(t, 1, countCalendarEvents(t, other.Calendar.CalendarID), "Le calendrier d'un autre utilisateur ne doit pas être modifié")
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestBatchEventResults vérifie le détail des résultats par opération
+func TestBatchEventResults(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+	require.NoError(t, err)
+
+	// Mode best_effort : chaque opération a son propre statut
+	status, response := postBatch(t, user, user.Calendar.CalendarID, map[string]interface{}{
+		"mode": "best_effort",
+		"operations": []map[string]interface{}{
+			{"action": "create", "event": newEvent("Réunion budget")},
+			{"action": "update", "event_id": user.Event.EventID, "event": map[string]interface{}{"duration": 0}},
+			{"action": "cancel"},
+		},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, response.Data.Applied)
+	require.Equal(t, 3, response.Data.Total)
+	require.Equal(t, 1, response.Data.Succeeded)
+	require.Equal(t, 2, response.Data.Failed)
+	require.Len(t, response.Data.Results, 3)
+	require.Equal(t, http.StatusCreated, response.Data.Results[0].Status)
+	require.NotNil(t, response.Data.Results[0].EventID)
+	require.Equal(t, http.StatusBadRequest, response.Data.Results[1].Status)
+	require.Equal(t, common.ErrBatchMissingEventID, response.Data.Results[2].Error)
+
+	// Mode atomic : l'échec de la deuxième opération annule la première et saute la troisième
+	status, response = postBatch(t, user, user.Calendar.CalendarID, map[string]interface{}{
+		"operations": []map[string]interface{}{
+			{"action": "create", "event": newEvent("Réunion budget")},
+			{"action": "update", "event_id": user.Event.EventID, "event": map[string]interface{}{"meeting_url": "ftp://visio"}},
+			{"action": "delete", "event_id": user.Event.EventID},
+		},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.False(t, response.Data.Applied)
+	require.Equal(t, 3, response.Data.Failed)
+	require.Len(t, response.Data.Results, 3)
+	require.Nil(t, response.Data.Results[0].EventID, "L'identifiant d'un événement annulé ne doit pas être retourné")
+	require.Equal(t, http.StatusFailedDependency, response.Data.Results[0].Status)
+	require.Equal(t, common.ErrInvalidMeetingURL, response.Data.Results[1].Error)
+	require.Equal(t, common.ErrBatchNotApplied, response.Data.Results[2].Error)
+	require.Equal(t, 2, countCalendarEvents(t, user.Calendar.CalendarID))
+
+	// Un utilisateur sans accès au calendrier ne peut pas soumettre de lot
+	other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	status, _ = postBatch(t, other, user.Calendar.CalendarID, map[string]interface{}{
+		"operations": []map[string]interface{}{{"action": "delete", "event_id": user.Event.EventID}},
+	})
+	require.Equal(t, http.StatusForbidden, status)
+
+	testutils.PurgeAllTestUsers()
+}
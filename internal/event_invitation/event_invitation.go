@@ -0,0 +1,376 @@
+// Package event_invitation internal/event_invitation/event_invitation.go
+// Invitations aux événements. Les réponses tiennent compte des heures de travail et des absences de l'invité ;
+// une invitation qui chevauche une absence est déclinée automatiquement.
+package event_invitation
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/user_availability"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventInvitationStruct struct{}
+
+var EventInvitation = EventInvitationStruct{}
+
+// invitationColumns sont les colonnes lues par scanInvitation
+const invitationColumns = `ei.invitation_id, ei.event_id, ei.user_id, ei.invited_by, ei.status, ei.auto_declined,
+	ei.comment, ei.responded_at, ei.created_at, ei.updated_at,
+	e.title, e.start, e.duration, e.canceled, e.location, e.meeting_url`
+
+// Invite invite des utilisateurs à un événement
+// @Summary Inviter des utilisateurs à un événement
+// @Description Invite les utilisateurs à l'événement. Pour chaque invité, la réponse indique si le créneau est dans ses heures de travail, s'il chevauche une de ses absences ou un autre de ses événements. Une invitation qui chevauche une absence de l'invité est déclinée automatiquement. Les utilisateurs déjà invités gardent leur réponse.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param invitations body common.CreateInvitationsRequest true "Utilisateurs à inviter"
+// @Success 201 {object} common.JSONResponse{data=[]common.EventInvitation}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/invitations [post]
+func (EventInvitationStruct) Invite(c *gin.Context) {
+	slog.Info(common.LogEventInvitationCreate)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateInvitationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEventInvitationCreate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	userIDs := common.UniqueIDs(req.UserIDs)
+	for _, userID := range userIDs {
+		if userID == userData.UserID {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvitationSelf,
+			})
+			return
+		}
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogEventInvitationCreate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	start, end := eventData.Start, eventData.Start.Add(time.Duration(eventData.Duration)*time.Minute)
+	for _, userID := range userIDs {
+		slot, err := user_availability.SlotAvailability(tx, userID, eventData.EventID, start, end)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrUserNotFound,
+			})
+			return
+		}
+		if err == nil {
+			status, autoDeclined, respondedAt := common.InvitationStatusPending, false, (*time.Time)(nil)
+			if slot.OutOfOffice {
+				now := time.Now()
+				status, autoDeclined, respondedAt = common.InvitationStatusDeclined, true, &now
+			}
+			// Une invitation existante, même retirée, est conservée avec sa réponse
+			_, err = tx.Exec(`
+				INSERT INTO event_invitation (event_id, user_id, invited_by, status, auto_declined, responded_at, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, NOW())
+				ON DUPLICATE KEY UPDATE deleted_at = NULL
+			`, eventData.EventID, userID, userData.UserID, status, autoDeclined, respondedAt)
+		}
+		if err != nil {
+			slog.Error(common.LogEventInvitationCreate + " - erreur lors de l'enregistrement de l'invitation : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvitationCreation,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogEventInvitationCreate + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	placeholders, args := common.InClause(userIDs)
+	invitations, err := listInvitations("ei.event_id = ? AND ei.user_id IN ("+placeholders+")",
+		append([]interface{}{eventData.EventID}, args...)...)
+	if err != nil {
+		slog.Error(common.LogEventInvitationCreate + " - erreur lors de la récupération des invitations : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventInvitationCreate + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateInvitations,
+		Data:    invitations,
+	})
+}
+
+// ListForEvent liste les invitations d'un événement
+// @Summary Lister les invitations d'un événement
+// @Description Liste les invités de l'événement, leur réponse et leur disponibilité sur le créneau (heures de travail, absence, autre événement)
+// @Tags Invitations
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse{data=[]common.EventInvitation}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/invitations [get]
+func (EventInvitationStruct) ListForEvent(c *gin.Context) {
+	slog.Info(common.LogEventInvitationList)
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := listInvitations("ei.event_id = ?", eventData.EventID)
+	if err != nil {
+		slog.Error(common.LogEventInvitationList + " - erreur lors de la récupération des invitations : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventInvitationList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListInvitations,
+		Data:    invitations,
+	})
+}
+
+// ListMine liste les invitations reçues par l'utilisateur connecté
+// @Summary Lister mes invitations
+// @Description Liste les invitations de l'utilisateur connecté aux événements à venir, avec l'événement et la disponibilité de l'utilisateur sur son créneau
+// @Tags Invitations
+// @Produce json
+// @Param status query string false "Filtrer par statut (pending, accepted, declined, tentative)"
+// @Param include_past query bool false "Inclure les événements passés"
+// @Success 200 {object} common.JSONResponse{data=[]common.EventInvitation}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /user/me/invitations [get]
+func (EventInvitationStruct) ListMine(c *gin.Context) {
+	slog.Info(common.LogEventInvitationListMine)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	condition, args := "ei.user_id = ?", []interface{}{userData.UserID}
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case common.InvitationStatusPending, common.InvitationStatusAccepted, common.InvitationStatusDeclined, common.InvitationStatusTentative:
+		default:
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidInvitationStatus,
+			})
+			return
+		}
+		condition += " AND ei.status = ?"
+		args = append(args, status)
+	}
+	if c.Query("include_past") != "true" {
+		condition += " AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > NOW()"
+	}
+
+	invitations, err := listInvitations(condition, args...)
+	if err != nil {
+		slog.Error(common.LogEventInvitationListMine + " - erreur lors de la récupération des invitations : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventInvitationListMine + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListInvitations,
+		Data:    invitations,
+	})
+}
+
+// Respond enregistre la réponse de l'utilisateur connecté à une invitation
+// @Summary Répondre à une invitation
+// @Description Accepte, décline ou accepte provisoirement (tentative) une invitation. L'acceptation est refusée (409) si l'événement chevauche une absence de l'utilisateur ; la réponse indique sa disponibilité sur le créneau (heures de travail, autre événement). Un événement accepté compte comme occupé dans les disponibilités.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param invitation_id path int true "ID de l'invitation"
+// @Param response body common.RespondInvitationRequest true "Réponse"
+// @Success 200 {object} common.JSONResponse{data=common.EventInvitation}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /user/me/invitations/{invitation_id} [put]
+func (EventInvitationStruct) Respond(c *gin.Context) {
+	slog.Info(common.LogEventInvitationRespond)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	invitationID, err := strconv.Atoi(c.Param("invitation_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidInvitationID,
+		})
+		return
+	}
+
+	var req common.RespondInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEventInvitationRespond + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	invitations, err := listInvitations("ei.invitation_id = ? AND ei.user_id = ?", invitationID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogEventInvitationRespond + " - erreur lors de la récupération de l'invitation : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationRetrieval,
+		})
+		return
+	}
+	if len(invitations) == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationNotFound,
+		})
+		return
+	}
+	invitation := invitations[0]
+	if req.Status == common.InvitationStatusAccepted && invitation.Availability.OutOfOffice {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationOutOfOffice,
+		})
+		return
+	}
+
+	now := time.Now()
+	_, err = common.DB.Exec(`
+		UPDATE event_invitation SET status = ?, auto_declined = FALSE, comment = ?, responded_at = ?
+		WHERE invitation_id = ? AND user_id = ? AND deleted_at IS NULL
+	`, req.Status, req.Comment, now, invitationID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogEventInvitationRespond + " - erreur lors de l'enregistrement de la réponse : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvitationResponse,
+		})
+		return
+	}
+	invitation.Status, invitation.AutoDeclined, invitation.Comment, invitation.RespondedAt = req.Status, false, req.Comment, &now
+
+	slog.Info(common.LogEventInvitationRespond + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessRespondInvitation,
+		Data:    invitation,
+	})
+}
+
+// listInvitations retourne les invitations actives aux événements non supprimés qui satisfont la condition,
+// avec leur événement et la disponibilité de l'invité sur son créneau
+func listInvitations(condition string, args ...interface{}) ([]common.EventInvitation, error) {
+	rows, err := common.DB.Query(`
+		SELECT `+invitationColumns+`
+		FROM event_invitation ei
+		INNER JOIN event e ON e.event_id = ei.event_id AND e.deleted_at IS NULL
+		WHERE ei.deleted_at IS NULL AND `+condition+`
+		ORDER BY e.start, ei.invitation_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	invitations := []common.EventInvitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Les lignes doivent être fermées avant les requêtes de disponibilité
+	for i := range invitations {
+		event := invitations[i].Event
+		slot, err := user_availability.SlotAvailability(common.DB, invitations[i].UserID, event.EventID,
+			event.Start, event.Start.Add(time.Duration(event.Duration)*time.Minute))
+		if err != nil {
+			return nil, err
+		}
+		invitations[i].Availability = &slot
+	}
+	return invitations, nil
+}
+
+// scanInvitation lit une invitation sélectionnée avec invitationColumns
+func scanInvitation(rows *sql.Rows) (common.EventInvitation, error) {
+	var invitation common.EventInvitation
+	event := &common.Event{}
+	err := rows.Scan(&invitation.InvitationID, &invitation.EventID, &invitation.UserID, &invitation.InvitedBy,
+		&invitation.Status, &invitation.AutoDeclined, &invitation.Comment, &invitation.RespondedAt,
+		&invitation.CreatedAt, &invitation.UpdatedAt,
+		&event.Title, &event.Start, &event.Duration, &event.Canceled, &event.Location, &event.MeetingURL)
+	event.EventID = invitation.EventID
+	invitation.Event = event
+	return invitation, err
+}
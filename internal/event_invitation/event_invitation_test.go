@@ -0,0 +1,235 @@
+package event_invitation_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// decodeInvitations décode une liste d'invitations
+func decodeInvitations(t *testing.T, response testutils.APIResponse) []common.EventInvitation {
+	var invitations []common.EventInvitation
+	require.NoError(t, json.Unmarshal(response.Data, &invitations))
+	return invitations
+}
+
+// nextMonday retourne le premier lundi à minuit UTC situé au moins trois jours après maintenant
+func nextMonday() time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 3)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// createEvent crée un événement dans le calendrier de l'utilisateur et retourne son chemin d'invitations
+func createEvent(t *testing.T, user *testutils.AuthenticatedUser, title string, start time.Time, duration int) string {
+	calendarID := strconv.Itoa(user.Calendar.CalendarID)
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-event/"+calendarID, map[string]interface{}{
+		"title": title, "start": start.Format(time.RFC3339), "duration": duration, "calendar_id": user.Calendar.CalendarID,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created struct {
+		EventID int `json:"event_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+	return "/calendar-event/" + calendarID + "/" + strconv.Itoa(created.EventID) + "/invitations"
+}
+
+// TestInviteAndRespond vérifie l'invitation, la disponibilité renvoyée et les réponses de l'invité
+func TestInviteAndRespond(t *testing.T) {
+	organizer, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	guest, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	outsider, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	monday := nextMonday()
+
+	// L'invité travaille de 09:00 à 12:00 et a déjà une réunion de 10:00 à 11:00
+	status, response := testutils.DoRequest(t, testServer, guest.SessionToken, "PUT", "/user/me/working-hours", map[string]interface{}{
+		"timezone": "UTC",
+		"rules":    []map[string]interface{}{{"weekday": 1, "start_time": "09:00", "end_time": "12:00"}},
+	})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	createEvent(t, guest, "Réunion", monday.Add(10*time.Hour), 60)
+
+	morning := createEvent(t, organizer, "Point projet", monday.Add(10*time.Hour+30*time.Minute), 30)
+	evening := createEvent(t, organizer, "Afterwork", monday.Add(18*time.Hour), 60)
+
+	// On ne s'invite pas soi-même ; les invités doivent exister
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", morning, map[string]interface{}{"user_ids": []int{organizer.User.UserID}})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvitationSelf, response.Error)
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", morning, map[string]interface{}{"user_ids": []int{999999999}})
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrUserNotFound, response.Error)
+
+	// Sans accès au calendrier, on ne peut ni inviter ni consulter les invités
+	status, _ = testutils.DoRequest(t, testServer, outsider.SessionToken, "POST", morning, map[string]interface{}{"user_ids": []int{guest.User.UserID}})
+	require.Equal(t, http.StatusForbidden, status)
+
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", morning, map[string]interface{}{"user_ids": []int{guest.User.UserID, guest.User.UserID}})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	invitations := decodeInvitations(t, response)
+	require.Len(t, invitations, 1)
+	require.Equal(t, common.InvitationStatusPending, invitations[0].Status)
+	require.NotNil(t, invitations[0].Availability)
+	require.True(t, invitations[0].Availability.WithinWorkingHours)
+	require.True(t, invitations[0].Availability.Conflict)
+	require.False(t, invitations[0].Availability.OutOfOffice)
+	morningID := strconv.Itoa(invitations[0].InvitationID)
+
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", evening, map[string]interface{}{"user_ids": []int{guest.User.UserID}})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.False(t, invitations[0].Availability.WithinWorkingHours)
+	require.False(t, invitations[0].Availability.Conflict)
+	eveningID := strconv.Itoa(invitations[0].InvitationID)
+
+	// L'invité voit ses invitations avec l'événement
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "GET", "/user/me/invitations?status=pending", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.Len(t, invitations, 2)
+	require.Equal(t, "Point projet", invitations[0].Event.Title)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "GET", "/user/me/invitations?status=inconnu", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidInvitationStatus, response.Error)
+
+	// Seul l'invité peut répondre
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "PUT", "/user/me/invitations/"+eveningID, map[string]interface{}{"status": "accepted"})
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrInvitationNotFound, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "PUT", "/user/me/invitations/"+eveningID, map[string]interface{}{"status": "accepted", "comment": "J'y serai"})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, common.MsgSuccessRespondInvitation, response.Message)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "PUT", "/user/me/invitations/"+morningID, map[string]interface{}{"status": "maybe"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// L'événement accepté compte désormais comme occupé pour l'invité
+	day := monday.Format("2006-01-02")
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "GET", "/availability/free-busy?user_ids="+strconv.Itoa(guest.User.UserID)+"&start="+day+"&end="+day, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var freeBusy common.FreeBusyResponse
+	require.NoError(t, json.Unmarshal(response.Data, &freeBusy))
+	require.Len(t, freeBusy.Users[0].Busy, 2)
+
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "GET", evening, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.Len(t, invitations, 1)
+	require.Equal(t, common.InvitationStatusAccepted, invitations[0].Status)
+	require.Equal(t, "J'y serai", *invitations[0].Comment)
+	require.NotNil(t, invitations[0].RespondedAt)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestOutOfOfficeAutoDecline vérifie le refus automatique des invitations qui chevauchent une absence
+func TestOutOfOfficeAutoDecline(t *testing.T) {
+	organizer, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	guest, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	monday := nextMonday()
+
+	before := createEvent(t, organizer, "Lancement", monday.Add(9*time.Hour), 60)
+	during := createEvent(t, organizer, "Revue", monday.Add(33*time.Hour), 60)
+	status, response := testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", before, map[string]interface{}{"user_ids": []int{guest.User.UserID}})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	beforeID := strconv.Itoa(decodeInvitations(t, response)[0].InvitationID)
+
+	// Une absence déclarée après l'invitation la décline
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": monday.Format(time.RFC3339), "end": monday.Add(48 * time.Hour).Format(time.RFC3339), "message": "Congés",
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created struct {
+		OutOfOfficeID       int `json:"out_of_office_id"`
+		InvitationsDeclined int `json:"invitations_declined"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+	require.Equal(t, 1, created.InvitationsDeclined)
+
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "GET", before, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	invitations := decodeInvitations(t, response)
+	require.Equal(t, common.InvitationStatusDeclined, invitations[0].Status)
+	require.True(t, invitations[0].AutoDeclined)
+	require.Equal(t, "Congés", *invitations[0].Comment)
+	require.True(t, invitations[0].Availability.OutOfOffice)
+
+	// Une invitation pendant l'absence est déclinée dès sa création, et ne peut pas être acceptée
+	status, response = testutils.DoRequest(t, testServer, organizer.SessionToken, "POST", during, map[string]interface{}{"user_ids": []int{guest.User.UserID}})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.Equal(t, common.InvitationStatusDeclined, invitations[0].Status)
+	require.True(t, invitations[0].AutoDeclined)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "PUT", "/user/me/invitations/"+strconv.Itoa(invitations[0].InvitationID), map[string]interface{}{"status": "accepted"})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrInvitationOutOfOffice, response.Error)
+
+	// Supprimer l'absence remet les invitations déclinées automatiquement en attente
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "DELETE", "/user/me/out-of-office/"+strconv.Itoa(created.OutOfOfficeID), nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "GET", "/user/me/invitations", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.Len(t, invitations, 2)
+	for _, invitation := range invitations {
+		require.Equal(t, common.InvitationStatusPending, invitation.Status)
+		require.False(t, invitation.AutoDeclined)
+		require.Nil(t, invitation.Comment)
+	}
+
+	// Une invitation déclinée volontairement n'est pas rétablie
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "PUT", "/user/me/invitations/"+beforeID, map[string]interface{}{"status": "declined"})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": monday.Format(time.RFC3339), "end": monday.Add(48 * time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+	require.Equal(t, 1, created.InvitationsDeclined)
+	status, _ = testutils.DoRequest(t, testServer, guest.SessionToken, "DELETE", "/user/me/out-of-office/"+strconv.Itoa(created.OutOfOfficeID), nil)
+	require.Equal(t, http.StatusOK, status)
+	status, response = testutils.DoRequest(t, testServer, guest.SessionToken, "GET", "/user/me/invitations?status=declined", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	invitations = decodeInvitations(t, response)
+	require.Len(t, invitations, 1)
+	require.Equal(t, "Lancement", invitations[0].Event.Title)
+
+	testutils.PurgeAllTestUsers()
+}
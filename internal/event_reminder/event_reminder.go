@@ -0,0 +1,324 @@
+// Package event_reminder internal/event_reminder/event_reminder.go
+// Rappels d'événements envoyés en notification Web Push aux navigateurs de l'utilisateur.
+package event_reminder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/push_subscription"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventReminderStruct struct{}
+
+var EventReminder = EventReminderStruct{}
+
+// batchSize est le nombre maximal de rappels envoyés par passage
+const batchSize = 500
+
+// Get retourne le rappel de l'utilisateur connecté pour l'événement
+// @Summary Récupérer mon rappel d'un événement
+// @Description Retourne le délai du rappel avant le début de l'événement, sa date d'envoi prévue et, s'il a été envoyé, sa date d'envoi
+// @Tags Rappels
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse{data=common.EventReminder}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/reminder [get]
+func (EventReminderStruct) Get(c *gin.Context) {
+	slog.Info(common.LogEventReminderGet)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	reminder, err := loadReminder(userData.UserID, eventData)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventReminderNotFound,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogEventReminderGet + " - erreur lors de la récupération du rappel : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventReminderRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventReminderGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetEventReminder,
+		Data:    reminder,
+	})
+}
+
+// Set crée ou modifie le rappel de l'utilisateur connecté pour l'événement
+// @Summary Programmer un rappel
+// @Description Programme une notification Web Push minutes_before minutes avant le début de l'événement (0 : au début), envoyée à tous les navigateurs abonnés de l'utilisateur. Modifier le rappel, ou déplacer l'événement, le réarme.
+// @Tags Rappels
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param reminder body common.SetEventReminderRequest true "Délai du rappel"
+// @Success 200 {object} common.JSONResponse{data=common.EventReminder}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/reminder [put]
+func (EventReminderStruct) Set(c *gin.Context) {
+	slog.Info(common.LogEventReminderSet)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.SetEventReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogEventReminderSet + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	_, err := common.DB.Exec(`
+		INSERT INTO event_reminder (event_id, user_id, minutes_before, created_at)
+		VALUES (?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE minutes_before = VALUES(minutes_before), notified_start = NULL, notified_at = NULL, updated_at = NOW()
+	`, eventData.EventID, userData.UserID, *req.MinutesBefore)
+	var reminder common.EventReminder
+	if err == nil {
+		reminder, err = loadReminder(userData.UserID, eventData)
+	}
+	if err != nil {
+		slog.Error(common.LogEventReminderSet + " - erreur lors de l'enregistrement du rappel : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventReminderUpdate,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventReminderSet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessSetEventReminder,
+		Data:    reminder,
+	})
+}
+
+// Delete supprime le rappel de l'utilisateur connecté pour l'événement
+// @Summary Supprimer un rappel
+// @Tags Rappels
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/reminder [delete]
+func (EventReminderStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogEventReminderDelete)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+
+	result, err := common.DB.Exec("DELETE FROM event_reminder WHERE event_id = ? AND user_id = ?", eventData.EventID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogEventReminderDelete + " - erreur lors de la suppression du rappel : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventReminderDelete,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventReminderNotFound,
+		})
+		return
+	}
+
+	slog.Info(common.LogEventReminderDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteEventReminder,
+	})
+}
+
+// dueReminder est un rappel arrivé à échéance
+type dueReminder struct {
+	reminderID int
+	userID     int
+	eventID    int
+	title      string
+	start      time.Time
+	// Réservation précédente, rétablie si aucun navigateur n'a pu être joint
+	notifiedStart sql.NullTime
+	notifiedAt    sql.NullTime
+}
+
+// SendDue envoie les rappels arrivés à échéance des événements non annulés qui ne sont pas terminés,
+// tant que l'utilisateur a toujours accès à l'un des calendriers de l'événement. Chaque rappel est
+// réservé avant l'envoi : plusieurs instances peuvent appeler SendDue sans notifier deux fois. Si l'envoi
+// échoue vers tous les navigateurs de l'utilisateur, la réservation est levée et le rappel retenté au passage
+// suivant ; l'échec d'un rappel n'empêche pas l'envoi des autres. Retourne le nombre de rappels remis à au moins
+// un navigateur ; seule une erreur de sélection des rappels est retournée.
+func SendDue(ctx context.Context) (int, error) {
+	rows, err := common.DB.QueryContext(ctx, `
+		SELECT r.event_reminder_id, r.user_id, e.event_id, e.title, e.start, r.notified_start, r.notified_at
+		FROM event_reminder r
+		INNER JOIN event e ON e.event_id = r.event_id AND e.deleted_at IS NULL AND e.canceled = FALSE
+		WHERE DATE_SUB(e.start, INTERVAL r.minutes_before MINUTE) <= NOW()
+		AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > NOW()
+		AND (r.notified_start IS NULL OR r.notified_start <> e.start)
+		AND EXISTS (
+			SELECT 1 FROM calendar_event ce
+			INNER JOIN user_calendar uc ON uc.calendar_id = ce.calendar_id AND uc.deleted_at IS NULL
+			WHERE ce.event_id = e.event_id AND ce.deleted_at IS NULL AND uc.user_id = r.user_id
+		)
+		ORDER BY e.start
+		LIMIT ?
+	`, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var due []dueReminder
+	for rows.Next() {
+		var r dueReminder
+		if err := rows.Scan(&r.reminderID, &r.userID, &r.eventID, &r.title, &r.start, &r.notifiedStart, &r.notifiedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, r := range due {
+		claimed, err := common.DB.ExecContext(ctx, `
+			UPDATE event_reminder SET notified_start = ?, notified_at = NOW()
+			WHERE event_reminder_id = ? AND (notified_start IS NULL OR notified_start <> ?)
+		`, r.start, r.reminderID, r.start)
+		if err != nil {
+			slog.Error(common.LogEventReminderSend+" - erreur lors de la réservation : "+err.Error(), "event_reminder_id", r.reminderID)
+			continue
+		}
+		if affected, _ := claimed.RowsAffected(); affected == 0 {
+			continue // Envoyé entre-temps par une autre instance
+		}
+		eventID := r.eventID
+		report, err := push_subscription.Notify(ctx, r.userID, common.PushNotification{
+			Title:   r.title,
+			Body:    reminderBody(time.Until(r.start)),
+			EventID: &eventID,
+		})
+		switch {
+		case err != nil:
+			slog.Error(common.LogEventReminderSend+" - erreur lors de l'envoi : "+err.Error(), "event_reminder_id", r.reminderID)
+			release(r)
+		case report.Sent > 0:
+			sent++
+		case report.Failed > 0:
+			// Aucun navigateur joint : le rappel sera retenté au passage suivant
+			slog.Warn(common.LogEventReminderSend+" - aucun envoi réussi", "event_reminder_id", r.reminderID, "failed", report.Failed)
+			release(r)
+		default:
+			// Sans abonnement valide, le rappel reste réservé : il n'y a personne à prévenir
+		}
+	}
+	return sent, nil
+}
+
+// release lève la réservation d'un rappel non remis, tant qu'une autre instance ne l'a pas reprise
+func release(r dueReminder) {
+	if _, err := common.DB.Exec(`
+		UPDATE event_reminder SET notified_start = ?, notified_at = ?
+		WHERE event_reminder_id = ? AND notified_start = ?
+	`, r.notifiedStart, r.notifiedAt, r.reminderID, r.start); err != nil {
+		slog.Error(common.LogEventReminderSend+" - erreur lors de la levée de la réservation : "+err.Error(), "event_reminder_id", r.reminderID)
+	}
+}
+
+// Start envoie les rappels arrivés à échéance à intervalle régulier, en arrière-plan
+func Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if _, err := SendDue(ctx); err != nil {
+				slog.Error(common.LogEventReminderSend + " - erreur : " + err.Error())
+			}
+			cancel()
+			<-ticker.C
+		}
+	}()
+}
+
+// reminderBody décrit le délai restant avant le début de l'événement
+func reminderBody(remaining time.Duration) string {
+	minutes := int(remaining.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes <= 0:
+		return "L'événement commence maintenant"
+	case minutes == 1:
+		return "L'événement commence dans 1 minute"
+	case minutes < 120:
+		return "L'événement commence dans " + strconv.Itoa(minutes) + " minutes"
+	default:
+		return "L'événement commence dans " + strconv.Itoa(minutes/60) + " heures"
+	}
+}
+
+// loadReminder lit le rappel de l'utilisateur pour l'événement
+func loadReminder(userID int, event common.Event) (common.EventReminder, error) {
+	var reminder common.EventReminder
+	err := common.DB.QueryRow(`
+		SELECT event_reminder_id, event_id, user_id, minutes_before, notified_at, created_at, updated_at
+		FROM event_reminder
+		WHERE event_id = ? AND user_id = ?
+	`, event.EventID, userID).Scan(&reminder.EventReminderID, &reminder.EventID, &reminder.UserID,
+		&reminder.MinutesBefore, &reminder.NotifiedAt, &reminder.CreatedAt, &reminder.UpdatedAt)
+	reminder.RemindAt = event.Start.Add(-time.Duration(reminder.MinutesBefore) * time.Minute)
+	return reminder, err
+}
@@ -0,0 +1,156 @@
+package event_reminder_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/event_reminder"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// browserSubscription simule pushManager.subscribe() : clés générées comme par le navigateur
+func browserSubscription(t *testing.T, endpoint string) map[string]interface{} {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+	return map[string]interface{}{
+		"endpoint": endpoint,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(auth),
+		},
+	}
+}
+
+// TestReminderDelivery vérifie la programmation d'un rappel et son envoi unique au service de push
+func TestReminderDelivery(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	outsider, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	var delivered atomic.Int32
+	var unavailable atomic.Bool
+	service := testutils.NewPushService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/push/subscriptions", browserSubscription(t, testutils.PushServiceURL+"/browser/1"))
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	// Événement dans 10 minutes
+	calendarID := strconv.Itoa(user.Calendar.CalendarID)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-event/"+calendarID, map[string]interface{}{
+		"title": "Comité", "start": time.Now().Add(10 * time.Minute).Format(time.RFC3339), "duration": 30, "calendar_id": user.Calendar.CalendarID,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created struct {
+		EventID int `json:"event_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+	path := "/calendar-event/" + calendarID + "/" + strconv.Itoa(created.EventID) + "/reminder"
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", path, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrEventReminderNotFound, response.Error)
+	status, _ = testutils.DoRequest(t, testServer, outsider.SessionToken, "PUT", path, map[string]interface{}{"minutes_before": 15})
+	require.Equal(t, http.StatusForbidden, status)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", path, map[string]interface{}{"minutes_before": -1})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Un rappel 5 minutes avant n'est pas encore dû
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", path, map[string]interface{}{"minutes_before": 5})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	sent, err := event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, sent)
+
+	// 15 minutes avant : le rappel est dû ; tant que le service de push est indisponible, il n'est pas compté
+	// comme envoyé et reste à envoyer
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", path, map[string]interface{}{"minutes_before": 15})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	unavailable.Store(true)
+	sent, err = event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, sent)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", path, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.NotContains(t, string(response.Data), "notified_at")
+
+	// Le service rétabli, le rappel n'est envoyé qu'une fois
+	unavailable.Store(false)
+	sent, err = event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+	require.Equal(t, int32(1), delivered.Load())
+	sent, err = event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, sent)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", path, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var reminder common.EventReminder
+	require.NoError(t, json.Unmarshal(response.Data, &reminder))
+	require.Equal(t, 15, reminder.MinutesBefore)
+	require.NotNil(t, reminder.NotifiedAt)
+
+	// Déplacer l'événement réarme le rappel ; l'annuler l'empêche
+	_, err = common.DB.Exec("UPDATE event SET start = DATE_ADD(start, INTERVAL 2 MINUTE) WHERE event_id = ?", created.EventID)
+	require.NoError(t, err)
+	sent, err = event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+	_, err = common.DB.Exec("UPDATE event SET start = DATE_ADD(start, INTERVAL 2 MINUTE), canceled = TRUE WHERE event_id = ?", created.EventID)
+	require.NoError(t, err)
+	sent, err = event_reminder.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, sent)
+	require.Equal(t, int32(2), delivered.Load())
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	testutils.PurgeAllTestUsers()
+}
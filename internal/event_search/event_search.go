@@ -0,0 +1,417 @@
+// Package event_search internal/event_search/event_search.go
+package event_search
+
+import (
+	"fmt"
+	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/common"
+	"go-averroes/internal/tag"
+	"html"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/unicode/norm"
+)
+
+type EventSearchStruct struct{}
+
+var EventSearch = EventSearchStruct{}
+
+const (
+	// defaultSearchLimit est le nombre de résultats retournés si aucune limite n'est fournie
+	defaultSearchLimit = 50
+	// maxSearchLimit borne le nombre de résultats pour éviter les réponses trop volumineuses
+	maxSearchLimit = 200
+	// maxSearchTerms borne le nombre de termes pris en compte dans la requête
+	maxSearchTerms = 10
+	// snippetLength est la longueur (en caractères) de l'extrait de description retourné
+	snippetLength = 200
+	// snippetLead est le nombre de caractères conservés avant la première occurrence dans l'extrait
+	snippetLead = 60
+)
+
+// SearchFilters regroupe les critères de la recherche plein texte
+type SearchFilters struct {
+	Terms       []string
+	Start       *time.Time
+	End         *time.Time
+	CalendarIDs []int
+	TagIDs      []int
+	HasLocation *bool
+	Limit       int
+}
+
+// Search recherche des événements par titre et description dans tous les calendriers accessibles
+// @Summary Rechercher des événements
+// @Description Recherche plein texte sur le titre et la description des événements de tous les calendriers accessibles par l'utilisateur. Les termes trouvés sont balisés par <mark> dans les extraits retournés.
+// @Tags Événement
+// @Produce json
+// @Param q query string true "Termes recherchés"
+// @Param start query string false "Date de début (YYYY-MM-DD ou RFC3339)"
+// @Param end query string false "Date de fin (YYYY-MM-DD inclus ou RFC3339 exclu)"
+// @Param calendar_id query []int false "Restreindre la recherche à un ou plusieurs calendriers" collectionFormat(multi)
+// @Param tag_id query []int false "Restreindre aux événements portant au moins une de ces étiquettes" collectionFormat(multi)
+// @Param has_location query bool false "Restreindre aux événements avec (true) ou sans (false) lieu"
+// @Param limit query int false "Nombre maximal de résultats (défaut 50, max 200)"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /events/search [get]
+func (EventSearchStruct) Search(c *gin.Context) {
+	slog.Info(common.LogEventSearch)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	filters, errMsg := ParseSearchFilters(c)
+	if errMsg != "" {
+		slog.Error(common.LogEventSearch + " - paramètres invalides : " + errMsg)
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	query, args := BuildSearchQuery(userData.UserID, filters)
+	rows, err := common.DB.Query(query, args...)
+	if err != nil {
+		slog.Error(common.LogEventSearch + " - erreur lors de la recherche : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventsRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	results := []common.EventSearchResult{}
+	for rows.Next() {
+		var result common.EventSearchResult
+		err := rows.Scan(
+			&result.EventID,
+			&result.Title,
+			&result.Description,
+			&result.Start,
+			&result.Duration,
+			&result.Canceled,
+			&result.Location,
+			&result.Latitude,
+			&result.Longitude,
+			&result.MeetingURL,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+			&result.DeletedAt,
+			&result.CalendarID,
+			&result.Score,
+		)
+		if err != nil {
+			slog.Error(common.LogEventSearch + " - erreur lors de la lecture des résultats : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrEventsReading,
+			})
+			return
+		}
+		result.Highlights.Title = Highlight(result.Title, filters.Terms)
+		if result.Description != nil {
+			snippet := Highlight(Snippet(*result.Description, filters.Terms), filters.Terms)
+			result.Highlights.Description = &snippet
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error(common.LogEventSearch + " - erreur lors de l'itération des résultats : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventsRetrieval,
+		})
+		return
+	}
+
+	eventIDs := make([]int, len(results))
+	for i, result := range results {
+		eventIDs[i] = result.EventID
+	}
+	tagsByEvent, err := tag.LoadEventTags(userData.UserID, eventIDs)
+	if err != nil {
+		slog.Error(common.LogEventSearch + " - erreur lors de la récupération des étiquettes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagRetrieval,
+		})
+		return
+	}
+	for i := range results {
+		results[i].Tags = tagsByEvent[results[i].EventID]
+	}
+
+	slog.Info(fmt.Sprintf("%s - succès, %d événements trouvés", common.LogEventSearch, len(results)))
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessSearchEvents,
+		Data:    results,
+	})
+}
+
+// ParseSearchFilters lit et valide les paramètres de la recherche.
+// Retourne un message d'erreur non vide si un paramètre est invalide.
+func ParseSearchFilters(c *gin.Context) (SearchFilters, string) {
+	filters := SearchFilters{Limit: defaultSearchLimit}
+
+	filters.Terms = ParseSearchTerms(c.Query("q"))
+	if len(filters.Terms) == 0 {
+		return filters, common.ErrSearchQueryRequired
+	}
+
+	if startStr := c.Query("start"); startStr != "" {
+		start, err := parseSearchDate(startStr, false)
+		if err != nil {
+			return filters, common.ErrInvalidSearchDate
+		}
+		filters.Start = &start
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		end, err := parseSearchDate(endStr, true)
+		if err != nil {
+			return filters, common.ErrInvalidSearchDate
+		}
+		filters.End = &end
+	}
+	if filters.Start != nil && filters.End != nil && !filters.Start.Before(*filters.End) {
+		return filters, common.ErrInvalidSearchRange
+	}
+
+	for _, idStr := range c.QueryArray("calendar_id") {
+		calendarID, err := strconv.Atoi(idStr)
+		if err != nil || calendarID < 1 {
+			return filters, common.ErrInvalidCalendarID
+		}
+		filters.CalendarIDs = append(filters.CalendarIDs, calendarID)
+	}
+
+	tagIDs, ok := tag.ParseTagFilter(c)
+	if !ok {
+		return filters, common.ErrInvalidTagID
+	}
+	filters.TagIDs = tagIDs
+
+	hasLocation, ok := calendar_event.ParseHasLocationFilter(c)
+	if !ok {
+		return filters, common.ErrInvalidLocationFilter
+	}
+	filters.HasLocation = hasLocation
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return filters, common.ErrInvalidSearchLimit
+		}
+		filters.Limit = limit
+	}
+
+	return filters, ""
+}
+
+// parseSearchDate accepte une date au format YYYY-MM-DD ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseSearchDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseSearchTerms découpe la saisie utilisateur en termes normalisés (minuscules, sans ponctuation).
+// Les opérateurs du mode booléen MySQL sont ainsi neutralisés.
+func ParseSearchTerms(q string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !isWordRune(r)
+	})
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range fields {
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		terms = append(terms, field)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// BooleanQuery construit l'expression AGAINST(... IN BOOLEAN MODE) : tous les termes sont requis
+// et recherchés en préfixe ("budget" trouve aussi "budgets").
+func BooleanQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "+" + term + "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+// BuildSearchQuery construit la requête SQL de recherche restreinte aux calendriers de l'utilisateur
+func BuildSearchQuery(userID int, filters SearchFilters) (string, []interface{}) {
+	against := BooleanQuery(filters.Terms)
+	query := `
+		SELECT e.event_id, e.title, e.description, e.start, e.duration, e.canceled,
+		       e.location, e.latitude, e.longitude, e.meeting_url,
+		       e.created_at, e.updated_at, e.deleted_at,
+		       MIN(ce.calendar_id) AS calendar_id,
+		       MATCH(e.title, e.description) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM event e
+		INNER JOIN calendar_event ce ON e.event_id = ce.event_id AND ce.deleted_at IS NULL
+		INNER JOIN calendar c ON ce.calendar_id = c.calendar_id AND c.deleted_at IS NULL
+		INNER JOIN user_calendar uc ON uc.calendar_id = ce.calendar_id AND uc.deleted_at IS NULL
+		WHERE uc.user_id = ? AND e.deleted_at IS NULL
+		  AND MATCH(e.title, e.description) AGAINST (? IN BOOLEAN MODE)`
+	args := []interface{}{against, userID, against}
+
+	if filters.Start != nil {
+		query += " AND e.start >= ?"
+		args = append(args, *filters.Start)
+	}
+	if filters.End != nil {
+		query += " AND e.start < ?"
+		args = append(args, *filters.End)
+	}
+	if len(filters.CalendarIDs) > 0 {
+		placeholders, calendarArgs := common.InClause(filters.CalendarIDs)
+		query += " AND ce.calendar_id IN (" + placeholders + ")"
+		args = append(args, calendarArgs...)
+	}
+	tagClause, tagArgs := tag.FilterClause("e.event_id", filters.TagIDs)
+	query += tagClause + calendar_event.HasLocationClause("e", filters.HasLocation)
+	args = append(args, tagArgs...)
+
+	query += `
+		GROUP BY e.event_id
+		ORDER BY score DESC, e.start DESC
+		LIMIT ?`
+	args = append(args, filters.Limit)
+
+	return query, args
+}
+
+// Highlight échappe le texte pour un rendu HTML et entoure de <mark> chaque mot commençant par un des termes.
+// Comme la collation des colonnes pour FULLTEXT, la comparaison ignore la casse et les accents.
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := fold(runes)
+	termRunes := foldTerms(terms)
+
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+		matchLen := longestMatch(lower[i:], termRunes)
+		if matchLen == 0 {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[last:i])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(string(runes[i : i+matchLen])))
+		sb.WriteString("</mark>")
+		last = i + matchLen
+		i = last - 1
+	}
+	sb.WriteString(html.EscapeString(string(runes[last:])))
+	return sb.String()
+}
+
+// Snippet retourne un extrait de la description centré sur la première occurrence d'un des termes
+func Snippet(text string, terms []string) string {
+	runes := []rune(text)
+	if len(runes) <= snippetLength {
+		return text
+	}
+	lower := fold(runes)
+	termRunes := foldTerms(terms)
+
+	first := 0
+	for i := range runes {
+		if (i == 0 || !isWordRune(runes[i-1])) && longestMatch(lower[i:], termRunes) > 0 {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetLead
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+		start = end - snippetLength
+	}
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// fold ramène chaque caractère à sa minuscule sans accent ("É" → "e"), caractère pour caractère,
+// afin que les positions trouvées dans le texte replié restent celles du texte d'origine
+func fold(runes []rune) []rune {
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = unicode.ToLower(r)
+		if decomposed := []rune(norm.NFD.String(string(folded[i]))); len(decomposed) > 0 && unicode.IsLetter(decomposed[0]) {
+			folded[i] = decomposed[0]
+		}
+	}
+	return folded
+}
+
+// foldTerms applique fold à chacun des termes recherchés
+func foldTerms(terms []string) [][]rune {
+	folded := make([][]rune, len(terms))
+	for i, term := range terms {
+		folded[i] = fold([]rune(term))
+	}
+	return folded
+}
+
+// longestMatch retourne la longueur du plus long terme qui préfixe text, ou 0
+func longestMatch(text []rune, terms [][]rune) int {
+	best := 0
+	for _, term := range terms {
+		if len(term) <= best || len(term) > len(text) {
+			continue
+		}
+		if string(text[:len(term)]) == string(term) {
+			best = len(term)
+		}
+	}
+	return best
+}
+
+// isWordRune indique si le caractère fait partie d'un mot
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,221 @@
+package event_search_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/event_search"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// TestSearchEventsRoute teste la route GET /events/search avec plusieurs cas
+func TestSearchEventsRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		SetupData        func() map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+		ExpectedCount    int
+	}{
+		{
+			CaseName: "Recherche réussie d'un événement de l'utilisateur",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				_, err = common.DB.Exec("UPDATE event SET title = ?, description = ? WHERE event_id = ?",
+					"Réunion budget trimestriel", "Revue du budget marketing", user.Event.EventID)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":  user,
+					"query": url.Values{"q": {"budget"}},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessSearchEvents,
+			ExpectedCount:    1,
+		},
+		{
+			CaseName: "Les événements d'un calendrier non partagé ne sont pas retournés",
+			SetupData: func() map[string]interface{} {
+				owner, err := testutils.GenerateAuthenticatedUser(false, true, true, true)
+				require.NoError(t, err)
+				_, err = common.DB.Exec("UPDATE event SET title = ? WHERE event_id = ?", "Atelier confidentiel", owner.Event.EventID)
+				require.NoError(t, err)
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":  user,
+					"query": url.Values{"q": {"confidentiel"}},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessSearchEvents,
+			ExpectedCount:    0,
+		},
+		{
+			CaseName: "Recherche filtrée sur une période sans événement",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				_, err = common.DB.Exec("UPDATE event SET title = ? WHERE event_id = ?", "Séminaire annuel", user.Event.EventID)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user": user,
+					"query": url.Values{
+						"q":           {"séminaire"},
+						"start":       {"2000-01-01"},
+						"end":         {"2000-12-31"},
+						"calendar_id": {strconv.Itoa(user.Calendar.CalendarID)},
+					},
+				}
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessSearchEvents,
+			ExpectedCount:    0,
+		},
+		{
+			CaseName: "Échec de recherche sans terme",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":  user,
+					"query": url.Values{"q": {"  ++ "}},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrSearchQueryRequired,
+		},
+		{
+			CaseName: "Échec de recherche avec une date invalide",
+			SetupData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":  user,
+					"query": url.Values{"q": {"budget"}, "start": {"15/01/2025"}},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidSearchDate,
+		},
+		{
+			CaseName: "Échec de recherche sans authentification",
+			SetupData: func() map[string]interface{} {
+				return map[string]interface{}{
+					"query": url.Values{"q": {"budget"}},
+				}
+			},
+			ExpectedHttpCode: http.StatusUnauthorized,
+			ExpectedError:    common.ErrUserNotAuthenticated,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			setupData := testCase.SetupData()
+
+			query, _ := setupData["query"].(url.Values)
+			req, err := http.NewRequest("GET", testServer.URL+"/events/search?"+query.Encode(), nil)
+			require.NoError(t, err, "Erreur lors de la création de la requête")
+
+			if user, ok := setupData["user"].(*testutils.AuthenticatedUser); ok {
+				req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+			}
+
+			resp, err := testClient.Do(req)
+			require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+			defer resp.Body.Close()
+
+			require.Equal(t, testCase.ExpectedHttpCode, resp.StatusCode, "Code de statut HTTP incorrect")
+
+			var response struct {
+				common.JSONResponse
+				Data []common.EventSearchResult `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&response)
+			require.NoError(t, err, "Erreur lors du parsing de la réponse JSON")
+
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			if testCase.ExpectedHttpCode == http.StatusOK {
+				require.True(t, response.Success, "La réponse devrait indiquer un succès")
+				require.Len(t, response.Data, testCase.ExpectedCount, "Nombre de résultats incorrect")
+				for _, result := range response.Data {
+					require.Contains(t, result.Highlights.Title, "<mark>", "Le titre devrait être surligné")
+				}
+			}
+		})
+	}
+}
+
+// TestParseSearchTerms vérifie la normalisation des termes de recherche
+func TestParseSearchTerms(t *testing.T) {
+	require.Equal(t, []string{"budget", "réunion"}, event_search.ParseSearchTerms("Budget +RÉUNION -budget"))
+	require.Empty(t, event_search.ParseSearchTerms(" *+- "))
+	require.Equal(t, "+budget* +2025*", event_search.BooleanQuery([]string{"budget", "2025"}))
+}
+
+// TestHighlight vérifie le balisage des termes trouvés et l'échappement HTML
+func TestHighlight(t *testing.T) {
+	require.Equal(t, "<mark>Budget</mark>s &amp; <mark>réu</mark>nion", event_search.Highlight("Budgets & réunion", []string{"budget", "réu"}))
+	require.Equal(t, "Sub-<mark>budget</mark>", event_search.Highlight("Sub-budget", []string{"budget"}))
+	require.Equal(t, "Abudget", event_search.Highlight("Abudget", []string{"budget"}))
+	require.Equal(t, "&lt;b&gt;", event_search.Highlight("<b>", []string{"budget"}))
+
+	// Comme la recherche FULLTEXT, le surlignage ignore la casse et les accents
+	require.Equal(t, "<mark>Réunion</mark> d&#39;équipe", event_search.Highlight("Réunion d'équipe", []string{"reunion"}))
+	require.Equal(t, "<mark>ÉTÉ</mark> 2026", event_search.Highlight("ÉTÉ 2026", []string{"ete"}))
+	require.Equal(t, "<mark>Noel</mark>", event_search.Highlight("Noel", []string{"noël"}))
+}
+
+// TestSnippet vérifie l'extraction d'un passage autour de la première occurrence
+func TestSnippet(t *testing.T) {
+	short := "Revue du budget"
+	require.Equal(t, short, event_search.Snippet(short, []string{"budget"}))
+
+	long := ""
+	for i := 0; i < 40; i++ {
+		long += "texte "
+	}
+	long += "budget final"
+	snippet := event_search.Snippet(long, []string{"budget"})
+	require.Contains(t, snippet, "budget")
+	require.True(t, len([]rune(snippet)) <= 202, "L'extrait ne devrait pas dépasser la longueur prévue")
+	require.Equal(t, "…", string([]rune(snippet)[0]))
+}
@@ -0,0 +1,283 @@
+// Package event_transfer internal/event_transfer/event_transfer.go
+package event_transfer
+
+import (
+	"database/sql"
+	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/common"
+	"go-averroes/internal/tag"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventTransferStruct struct{}
+
+var EventTransfer = EventTransferStruct{}
+
+// Move déplace des événements vers un autre calendrier
+// @Summary Déplacer des événements
+// @Description Déplace un événement (route avec event_id) ou un ensemble d'événements filtrés (event_ids, période, étiquettes) vers un autre calendrier. L'utilisateur doit avoir accès aux deux calendriers ; l'opération est réalisée en une seule transaction.
+// @Tags Événement
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier source"
+// @Param transfer body common.TransferEventsRequest true "Calendrier cible et sélection des événements"
+// @Success 200 {object} common.JSONResponse{data=common.TransferEventsResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/move [post]
+// @Router /calendar-event/{calendar_id}/{event_id}/move [post]
+func (EventTransferStruct) Move(c *gin.Context) {
+	transfer(c, common.LogEventMove, false)
+}
+
+// Copy copie des événements dans un autre calendrier
+// @Summary Copier des événements
+// @Description Duplique un événement (route avec event_id) ou un ensemble d'événements filtrés (event_ids, période, étiquettes) dans un autre calendrier. Les copies conservent les étiquettes de l'auteur de la copie (les étiquettes étant propres à chaque utilisateur, celles des autres ne sont pas reportées) mais pas les pièces jointes. L'utilisateur doit avoir accès aux deux calendriers ; l'opération est réalisée en une seule transaction.
+// @Tags Événement
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier source"
+// @Param transfer body common.TransferEventsRequest true "Calendrier cible et sélection des événements"
+// @Success 201 {object} common.JSONResponse{data=common.TransferEventsResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/copy [post]
+// @Router /calendar-event/{calendar_id}/{event_id}/copy [post]
+func (EventTransferStruct) Copy(c *gin.Context) {
+	transfer(c, common.LogEventCopy, true)
+}
+
+// transfer implémente le déplacement (duplicate = false) et la copie (duplicate = true)
+func transfer(c *gin.Context, logPrefix string, duplicate bool) {
+	slog.Info(logPrefix)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+	sourceID := calendarData.CalendarID
+
+	var req common.TransferEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(logPrefix + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	// Sur la route d'un événement, la sélection se limite à cet événement
+	if c.Param("event_id") != "" {
+		eventData, ok := common.GetEventFromContext(c)
+		if !ok {
+			return
+		}
+		req.EventIDs = []int{eventData.EventID}
+	}
+
+	if errMsg := validateRequest(req, sourceID); errMsg != "" {
+		slog.Error(logPrefix + " - " + errMsg)
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(logPrefix + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback() // Rollback par défaut, commit seulement si tout va bien
+
+	// Le calendrier source est vérifié par les middlewares, le calendrier cible ici
+	if err := calendar_event.CheckCalendarAccess(tx, userData.UserID, req.TargetCalendarID); err != nil {
+		slog.Error(logPrefix + " - calendrier cible : " + err.Error())
+		status, errMsg := calendar_event.ErrorResponse(err)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	eventIDs, err := selectEvents(tx, sourceID, req)
+	if err != nil {
+		slog.Error(logPrefix + " - erreur lors de la sélection des événements : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventsRetrieval,
+		})
+		return
+	}
+	// Une sélection explicite doit être entièrement présente dans le calendrier source
+	if len(req.EventIDs) > 0 && len(eventIDs) != len(common.UniqueIDs(req.EventIDs)) {
+		slog.Error(logPrefix + " - événement absent du calendrier source")
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventNotFound,
+		})
+		return
+	}
+
+	response := common.TransferEventsResponse{
+		SourceCalendarID: sourceID,
+		TargetCalendarID: req.TargetCalendarID,
+		Events:           make([]common.TransferredEvent, 0, len(eventIDs)),
+	}
+	for _, eventID := range eventIDs {
+		item := common.TransferredEvent{EventID: eventID}
+		if duplicate {
+			newEventID, err := copyEvent(tx, userData.UserID, eventID, req.TargetCalendarID)
+			if err != nil {
+				slog.Error(logPrefix + " - " + err.Error())
+				status, errMsg := calendar_event.ErrorResponse(err)
+				c.JSON(status, common.JSONResponse{
+					Success: false,
+					Error:   errMsg,
+				})
+				return
+			}
+			item.NewEventID = common.IntPtr(int(newEventID))
+		} else if err := moveEvent(tx, eventID, sourceID, req.TargetCalendarID); err != nil {
+			slog.Error(logPrefix + " - erreur lors du déplacement de l'événement : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrEventMove,
+			})
+			return
+		}
+		response.Events = append(response.Events, item)
+	}
+	response.Count = len(response.Events)
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(logPrefix + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(logPrefix + " - succès")
+	status, message := http.StatusOK, common.MsgSuccessMoveEvents
+	if duplicate {
+		status, message = http.StatusCreated, common.MsgSuccessCopyEvents
+	}
+	c.JSON(status, common.JSONResponse{
+		Success: true,
+		Message: message,
+		Data:    response,
+	})
+}
+
+// validateRequest vérifie la cohérence de la sélection. Retourne un message d'erreur non vide si elle est invalide.
+func validateRequest(req common.TransferEventsRequest, sourceID int) string {
+	if req.TargetCalendarID == sourceID {
+		return common.ErrTransferSameCalendar
+	}
+	if len(req.EventIDs) == 0 && req.Start == nil && req.End == nil && len(req.TagIDs) == 0 {
+		return common.ErrTransferNoSelection
+	}
+	if req.Start != nil && req.End != nil && !req.Start.Before(*req.End) {
+		return common.ErrTransferInvalidRange
+	}
+	for _, id := range append(append([]int{}, req.EventIDs...), req.TagIDs...) {
+		if id <= 0 {
+			return common.ErrInvalidData
+		}
+	}
+	return ""
+}
+
+// selectEvents retourne, en les verrouillant, les événements du calendrier source correspondant à la sélection
+func selectEvents(tx *sql.Tx, sourceID int, req common.TransferEventsRequest) ([]int, error) {
+	query := `
+		SELECT e.event_id FROM event e
+		INNER JOIN calendar_event ce ON e.event_id = ce.event_id
+		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL AND e.deleted_at IS NULL`
+	args := []interface{}{sourceID}
+
+	if len(req.EventIDs) > 0 {
+		placeholders, idArgs := common.InClause(common.UniqueIDs(req.EventIDs))
+		query += " AND e.event_id IN (" + placeholders + ")"
+		args = append(args, idArgs...)
+	}
+	if req.Start != nil {
+		query += " AND e.start >= ?"
+		args = append(args, *req.Start)
+	}
+	if req.End != nil {
+		query += " AND e.start < ?"
+		args = append(args, *req.End)
+	}
+	tagClause, tagArgs := tag.FilterClause("e.event_id", req.TagIDs)
+	query += tagClause + " ORDER BY e.start ASC FOR UPDATE"
+	args = append(args, tagArgs...)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var eventIDs []int
+	for rows.Next() {
+		var eventID int
+		if err := rows.Scan(&eventID); err != nil {
+			return nil, err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	return eventIDs, rows.Err()
+}
+
+// moveEvent rattache l'événement au calendrier cible à la place du calendrier source.
+// Si l'événement est déjà présent dans le calendrier cible, seule la liaison source est retirée.
+func moveEvent(tx *sql.Tx, eventID, sourceID, targetID int) error {
+	linked, err := calendar_event.IsEventLinked(tx, targetID, eventID)
+	if err != nil {
+		return err
+	}
+	if linked {
+		_, err = tx.Exec(`
+			UPDATE calendar_event SET deleted_at = NOW()
+			WHERE calendar_id = ? AND event_id = ? AND deleted_at IS NULL
+		`, sourceID, eventID)
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE calendar_event SET calendar_id = ?
+		WHERE calendar_id = ? AND event_id = ? AND deleted_at IS NULL
+	`, targetID, sourceID, eventID)
+	return err
+}
+
+// copyEvent duplique l'événement, avec les étiquettes de l'utilisateur, et lie la copie au calendrier cible
+func copyEvent(tx *sql.Tx, userID, eventID, targetID int) (int64, error) {
+	newEventID, err := calendar_event.DuplicateEvent(tx, userID, eventID, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err := calendar_event.LinkEvent(tx, targetID, newEventID); err != nil {
+		return 0, err
+	}
+	return newEventID, nil
+}
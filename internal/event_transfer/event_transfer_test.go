@@ -0,0 +1,259 @@
+package event_transfer_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// transferResponse est la réponse typée des routes de déplacement et de copie
+type transferResponse struct {
+	common.JSONResponse
+	Data common.TransferEventsResponse `json:"data"`
+}
+
+// postTransfer exécute une requête de déplacement ou de copie authentifiée
+func postTransfer(t *testing.T, user *testutils.AuthenticatedUser, url string, body interface{}) (int, transferResponse) {
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", url, body)
+	result := transferResponse{JSONResponse: response.JSONResponse}
+	require.NoError(t, json.Unmarshal(response.Data, &result.Data), "Erreur lors du parsing des données")
+	return status, result
+}
+
+// calendarEventIDs retourne les événements actifs d'un calendrier
+func calendarEventIDs(t *testing.T, calendarID int) []int {
+	rows, err := common.DB.Query(`
+		SELECT e.event_id FROM calendar_event ce
+		INNER JOIN event e ON ce.event_id = e.event_id
+		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+		ORDER BY e.event_id
+	`, calendarID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// addEvent crée un événement dans le calendrier à la date donnée
+func addEvent(t *testing.T, calendarID int, title string, start time.Time) int {
+	result, err := common.DB.Exec("INSERT INTO event (title, start, duration, canceled, created_at) VALUES (?, ?, 60, FALSE, NOW())", title, start)
+	require.NoError(t, err)
+	eventID, err := result.LastInsertId()
+	require.NoError(t, err)
+	_, err = common.DB.Exec("INSERT INTO calendar_event (calendar_id, event_id, created_at) VALUES (?, ?, NOW())", calendarID, eventID)
+	require.NoError(t, err)
+	return int(eventID)
+}
+
+// TestMoveEventRoute teste le déplacement d'un événement avec plusieurs cas
+func TestMoveEventRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		TargetCalendar   func(user *testutils.AuthenticatedUser) int
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Déplacement réussi vers un calendrier accessible",
+			TargetCalendar: func(user *testutils.AuthenticatedUser) int {
+				calendarID, err := testutils.CreateCalendarForUser(user.User.UserID, "Personnel", "")
+				require.NoError(t, err)
+				return calendarID
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedMessage:  common.MsgSuccessMoveEvents,
+		},
+		{
+			CaseName: "Échec du déplacement vers un calendrier sans accès",
+			TargetCalendar: func(user *testutils.AuthenticatedUser) int {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return other.Calendar.CalendarID
+			},
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrNoAccessToCalendar,
+		},
+		{
+			CaseName: "Échec du déplacement vers un calendrier inexistant",
+			TargetCalendar: func(user *testutils.AuthenticatedUser) int {
+				return 999999
+			},
+			ExpectedHttpCode: http.StatusNotFound,
+			ExpectedError:    common.ErrCalendarNotFound,
+		},
+		{
+			CaseName: "Échec du déplacement vers le même calendrier",
+			TargetCalendar: func(user *testutils.AuthenticatedUser) int {
+				return user.Calendar.CalendarID
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrTransferSameCalendar,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+			require.NoError(t, err)
+			targetID := testCase.TargetCalendar(user)
+
+			url := "/calendar-event/" + strconv.Itoa(user.Calendar.CalendarID) + "/" + strconv.Itoa(user.Event.EventID) + "/move"
+			status, response := postTransfer(t, user, url, map[string]interface{}{"target_calendar_id": targetID})
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+				require.Empty(t, calendarEventIDs(t, user.Calendar.CalendarID), "L'événement ne doit plus être dans le calendrier source")
+				require.Equal(t, []int{user.Event.EventID}, calendarEventIDs(t, targetID), "L'événement doit être dans le calendrier cible")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+				require.Equal(t, []int{user.Event.EventID}, calendarEventIDs(t, user.Calendar.CalendarID), "L'événement doit rester dans le calendrier source")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestTransferFilteredEvents vérifie le déplacement et la copie d'un ensemble filtré d'événements
+func TestTransferFilteredEvents(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	sourceID := user.Calendar.CalendarID
+	targetID, err := testutils.CreateCalendarForUser(user.User.UserID, "Archives", "")
+	require.NoError(t, err)
+
+	march := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	april := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+	marchEvent := addEvent(t, sourceID, "Réunion de mars", march)
+	aprilEvent := addEvent(t, sourceID, "Réunion d'avril", april)
+
+	// Sans critère de sélection, rien n'est déplacé
+	status, response := postTransfer(t, user, "/calendar-event/"+strconv.Itoa(sourceID)+"/move", map[string]interface{}{"target_calendar_id": targetID})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrTransferNoSelection, response.Error)
+
+	// Copie des événements de mars : l'original reste en place
+	status, response = postTransfer(t, user, "/calendar-event/"+strconv.Itoa(sourceID)+"/copy", map[string]interface{}{
+		"target_calendar_id": targetID,
+		"start":              "2025-03-01T00:00:00Z",
+		"end":                "2025-04-01T00:00:00Z",
+	})
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, 1, response.Data.Count)
+	require.Equal(t, marchEvent, response.Data.Events[0].EventID)
+	require.NotNil(t, response.Data.Events[0].NewEventID)
+	copyID := *response.Data.Events[0].NewEventID
+	require.Equal(t, []int{marchEvent, aprilEvent}, calendarEventIDs(t, sourceID))
+	require.Equal(t, []int{copyID}, calendarEventIDs(t, targetID))
+
+	var copiedTitle string
+	var copiedStart time.Time
+	require.NoError(t, common.DB.QueryRow("SELECT title, start FROM event WHERE event_id = ?", copyID).Scan(&copiedTitle, &copiedStart))
+	require.Equal(t, "Réunion de mars", copiedTitle)
+	require.True(t, march.Equal(copiedStart), "La copie doit conserver la date de l'original")
+
+	// Un event_id absent du calendrier source fait échouer tout le déplacement
+	status, response = postTransfer(t, user, "/calendar-event/"+strconv.Itoa(sourceID)+"/move", map[string]interface{}{
+		"target_calendar_id": targetID,
+		"event_ids":          []int{marchEvent, copyID},
+	})
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrEventNotFound, response.Error)
+	require.Equal(t, []int{marchEvent, aprilEvent}, calendarEventIDs(t, sourceID))
+
+	// Déplacement des deux événements par leurs identifiants
+	status, response = postTransfer(t, user, "/calendar-event/"+strconv.Itoa(sourceID)+"/move", map[string]interface{}{
+		"target_calendar_id": targetID,
+		"event_ids":          []int{marchEvent, aprilEvent},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 2, response.Data.Count)
+	require.Empty(t, calendarEventIDs(t, sourceID))
+	require.Equal(t, []int{marchEvent, aprilEvent, copyID}, calendarEventIDs(t, targetID))
+
+	testutils.PurgeAllTestUsers()
+}
+
+// addTag crée une étiquette de l'utilisateur et l'attribue à l'événement
+func addTag(t *testing.T, userID, eventID int, name string) int {
+	result, err := common.DB.Exec("INSERT INTO tag (user_id, name, created_at) VALUES (?, ?, NOW())", userID, name)
+	require.NoError(t, err)
+	tagID, err := result.LastInsertId()
+	require.NoError(t, err)
+	_, err = common.DB.Exec("INSERT INTO event_tag (event_id, tag_id, created_at) VALUES (?, ?, NOW())", eventID, tagID)
+	require.NoError(t, err)
+	return int(tagID)
+}
+
+// TestCopyKeepsOwnTagsOnly vérifie que la copie d'un événement partagé ne reporte que les étiquettes de son auteur
+func TestCopyKeepsOwnTagsOnly(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	member, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	sharedID := owner.Calendar.CalendarID
+	_, err = common.DB.Exec("INSERT INTO user_calendar (user_id, calendar_id, created_at) VALUES (?, ?, NOW())", member.User.UserID, sharedID)
+	require.NoError(t, err)
+
+	eventID := addEvent(t, sharedID, "Comité de pilotage", time.Date(2025, 5, 12, 14, 0, 0, 0, time.UTC))
+	addTag(t, owner.User.UserID, eventID, "Confidentiel")
+	memberTag := addTag(t, member.User.UserID, eventID, "Suivi")
+
+	status, response := postTransfer(t, member, "/calendar-event/"+strconv.Itoa(sharedID)+"/copy", map[string]interface{}{
+		"target_calendar_id": member.Calendar.CalendarID,
+		"event_ids":          []int{eventID},
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	require.NotNil(t, response.Data.Events[0].NewEventID)
+
+	rows, err := common.DB.Query("SELECT tag_id FROM event_tag WHERE event_id = ? AND deleted_at IS NULL", *response.Data.Events[0].NewEventID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var tagIDs []int
+	for rows.Next() {
+		var tagID int
+		require.NoError(t, rows.Scan(&tagID))
+		tagIDs = append(tagIDs, tagID)
+	}
+	require.Equal(t, []int{memberTag}, tagIDs, "Seules les étiquettes de l'auteur de la copie doivent être reportées")
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,177 @@
+// Package holiday internal/holiday/holiday.go
+// Calcul des jours fériés nationaux à partir de règles fixes, liées à Pâques ou au n-ième jour de la semaine d'un mois,
+// sans service externe.
+package holiday
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Holiday est un jour férié. Date est le jour à minuit UTC.
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// Country est un pays dont les jours fériés sont connus
+type Country struct {
+	Code string // Code ISO 3166-1 alpha-2
+	Name string
+}
+
+// rule calcule la date d'un jour férié pour une année donnée
+type rule struct {
+	name  string
+	date  func(year int) time.Time
+	since int // Première année d'application (0 : toujours)
+}
+
+// calendar regroupe le nom d'un pays et ses règles
+type calendar struct {
+	name  string
+	rules []rule
+}
+
+// calendars contient les jours fériés nationaux de chaque pays connu
+var calendars = map[string]calendar{
+	"FR": {name: "France", rules: []rule{
+		{name: "Jour de l'an", date: fixed(time.January, 1)},
+		{name: "Lundi de Pâques", date: easter(1)},
+		{name: "Fête du Travail", date: fixed(time.May, 1)},
+		{name: "Victoire 1945", date: fixed(time.May, 8)},
+		{name: "Ascension", date: easter(39)},
+		{name: "Lundi de Pentecôte", date: easter(50)},
+		{name: "Fête nationale", date: fixed(time.July, 14)},
+		{name: "Assomption", date: fixed(time.August, 15)},
+		{name: "Toussaint", date: fixed(time.November, 1)},
+		{name: "Armistice 1918", date: fixed(time.November, 11)},
+		{name: "Noël", date: fixed(time.December, 25)},
+	}},
+	"BE": {name: "Belgique", rules: []rule{
+		{name: "Jour de l'an", date: fixed(time.January, 1)},
+		{name: "Lundi de Pâques", date: easter(1)},
+		{name: "Fête du Travail", date: fixed(time.May, 1)},
+		{name: "Ascension", date: easter(39)},
+		{name: "Lundi de Pentecôte", date: easter(50)},
+		{name: "Fête nationale", date: fixed(time.July, 21)},
+		{name: "Assomption", date: fixed(time.August, 15)},
+		{name: "Toussaint", date: fixed(time.November, 1)},
+		{name: "Armistice 1918", date: fixed(time.November, 11)},
+		{name: "Noël", date: fixed(time.December, 25)},
+	}},
+	"DE": {name: "Allemagne", rules: []rule{
+		{name: "Jour de l'an", date: fixed(time.January, 1)},
+		{name: "Vendredi saint", date: easter(-2)},
+		{name: "Lundi de Pâques", date: easter(1)},
+		{name: "Fête du Travail", date: fixed(time.May, 1)},
+		{name: "Ascension", date: easter(39)},
+		{name: "Lundi de Pentecôte", date: easter(50)},
+		{name: "Jour de l'Unité allemande", date: fixed(time.October, 3), since: 1990},
+		{name: "Noël", date: fixed(time.December, 25)},
+		{name: "Saint-Étienne", date: fixed(time.December, 26)},
+	}},
+	"US": {name: "États-Unis", rules: []rule{
+		{name: "Jour de l'an", date: fixed(time.January, 1)},
+		{name: "Martin Luther King Jr. Day", date: nthWeekday(time.January, time.Monday, 3), since: 1986},
+		{name: "Presidents' Day", date: nthWeekday(time.February, time.Monday, 3)},
+		{name: "Memorial Day", date: nthWeekday(time.May, time.Monday, -1)},
+		{name: "Juneteenth", date: fixed(time.June, 19), since: 2021},
+		{name: "Independence Day", date: fixed(time.July, 4)},
+		{name: "Labor Day", date: nthWeekday(time.September, time.Monday, 1)},
+		{name: "Columbus Day", date: nthWeekday(time.October, time.Monday, 2)},
+		{name: "Veterans Day", date: fixed(time.November, 11)},
+		{name: "Thanksgiving", date: nthWeekday(time.November, time.Thursday, 4)},
+		{name: "Noël", date: fixed(time.December, 25)},
+	}},
+}
+
+// Countries retourne les pays connus, triés par code
+func Countries() []Country {
+	countries := make([]Country, 0, len(calendars))
+	for code, cal := range calendars {
+		countries = append(countries, Country{Code: code, Name: cal.name})
+	}
+	sort.Slice(countries, func(i, j int) bool { return countries[i].Code < countries[j].Code })
+	return countries
+}
+
+// Lookup retourne le pays correspondant au code (insensible à la casse)
+func Lookup(code string) (Country, bool) {
+	code = strings.ToUpper(code)
+	cal, ok := calendars[code]
+	return Country{Code: code, Name: cal.name}, ok
+}
+
+// ForYear retourne les jours fériés du pays pour l'année, triés par date.
+// Un code inconnu retourne une liste vide.
+func ForYear(code string, year int) []Holiday {
+	holidays := []Holiday{}
+	for _, r := range calendars[strings.ToUpper(code)].rules {
+		if r.since > year {
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: r.date(year), Name: r.name})
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays
+}
+
+// Between retourne les jours fériés du pays dont la journée chevauche [from, to), triés par date
+func Between(code string, from, to time.Time) []Holiday {
+	from, to = from.UTC(), to.UTC()
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	holidays := []Holiday{}
+	for year := firstDay.Year(); year <= to.Year(); year++ {
+		for _, h := range ForYear(code, year) {
+			if !h.Date.Before(firstDay) && h.Date.Before(to) {
+				holidays = append(holidays, h)
+			}
+		}
+	}
+	return holidays
+}
+
+// Easter retourne le dimanche de Pâques (calendrier grégorien) de l'année, à minuit UTC.
+// Algorithme anonyme grégorien (Meeus/Jones/Butcher).
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// fixed retourne une règle à date fixe
+func fixed(month time.Month, day int) func(int) time.Time {
+	return func(year int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// easter retourne une règle située offset jours après le dimanche de Pâques
+func easter(offset int) func(int) time.Time {
+	return func(year int) time.Time {
+		return Easter(year).AddDate(0, 0, offset)
+	}
+}
+
+// nthWeekday retourne une règle tombant le n-ième jour de la semaine du mois (n = -1 : le dernier)
+func nthWeekday(month time.Month, weekday time.Weekday, n int) func(int) time.Time {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+7*(n-1))
+	}
+}
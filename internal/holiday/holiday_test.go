@@ -0,0 +1,69 @@
+package holiday_test
+
+import (
+	"go-averroes/internal/holiday"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dates retourne les dates des jours fériés au format YYYY-MM-DD
+func dates(holidays []holiday.Holiday) []string {
+	result := []string{}
+	for _, h := range holidays {
+		result = append(result, h.Date.Format("2006-01-02"))
+	}
+	return result
+}
+
+// TestEaster teste le calcul du dimanche de Pâques avec plusieurs années
+func TestEaster(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		Year         int
+		ExpectedDate string
+	}{
+		{Year: 2000, ExpectedDate: "2000-04-23"},
+		{Year: 2024, ExpectedDate: "2024-03-31"},
+		{Year: 2025, ExpectedDate: "2025-04-20"},
+		{Year: 2026, ExpectedDate: "2026-04-05"},
+		{Year: 2038, ExpectedDate: "2038-04-25"},
+		{Year: 2285, ExpectedDate: "2285-03-22"},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.ExpectedDate, func(t *testing.T) {
+			require.Equal(t, testCase.ExpectedDate, holiday.Easter(testCase.Year).Format("2006-01-02"))
+		})
+	}
+}
+
+// TestForYear vérifie les jours fériés calculés pour plusieurs pays
+func TestForYear(t *testing.T) {
+	require.Equal(t, []string{
+		"2025-01-01", "2025-04-21", "2025-05-01", "2025-05-08", "2025-05-29", "2025-06-09",
+		"2025-07-14", "2025-08-15", "2025-11-01", "2025-11-11", "2025-12-25",
+	}, dates(holiday.ForYear("FR", 2025)))
+
+	us := holiday.ForYear("us", 2025)
+	require.Contains(t, dates(us), "2025-05-26", "Memorial Day : dernier lundi de mai")
+	require.Contains(t, dates(us), "2025-11-27", "Thanksgiving : quatrième jeudi de novembre")
+	require.Contains(t, dates(us), "2025-09-01", "Labor Day : premier lundi de septembre")
+	require.NotContains(t, dates(holiday.ForYear("US", 2020)), "2020-06-19", "Juneteenth n'est férié que depuis 2021")
+
+	require.Empty(t, holiday.ForYear("XX", 2025))
+}
+
+// TestBetween vérifie la sélection des jours fériés d'une période à cheval sur deux années
+func TestBetween(t *testing.T) {
+	holidays := holiday.Between("FR",
+		time.Date(2025, 12, 25, 15, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC))
+	require.Equal(t, []string{"2025-12-25", "2026-01-01"}, dates(holidays))
+	require.Equal(t, "Noël", holidays[0].Name)
+
+	country, ok := holiday.Lookup("fr")
+	require.True(t, ok)
+	require.Equal(t, holiday.Country{Code: "FR", Name: "France"}, country)
+}
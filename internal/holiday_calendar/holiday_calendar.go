@@ -0,0 +1,379 @@
+// Package holiday_calendar internal/holiday_calendar/holiday_calendar.go
+package holiday_calendar
+
+import (
+	"go-averroes/internal/common"
+	"go-averroes/internal/holiday"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HolidayCalendarStruct struct{}
+
+var HolidayCalendar = HolidayCalendarStruct{}
+
+// maxAgendaDays borne la période couverte par l'agenda
+const maxAgendaDays = 92
+
+// List liste les calendriers de jours fériés proposés
+// @Summary Lister les calendriers de jours fériés
+// @Description Liste les calendriers de jours fériés proposés par l'application (variable HOLIDAY_COUNTRIES) et indique ceux auxquels l'utilisateur connecté est abonné
+// @Tags Jours fériés
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=[]common.HolidayCalendar}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /holiday-calendars [get]
+func (HolidayCalendarStruct) List(c *gin.Context) {
+	slog.Info(common.LogHolidayCalendarList)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	subscribed, err := subscriptions(userData.UserID)
+	if err != nil {
+		slog.Error(common.LogHolidayCalendarList + " - erreur lors de la récupération des abonnements : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrHolidayCalendarRetrieval,
+		})
+		return
+	}
+
+	calendars := []common.HolidayCalendar{}
+	for _, country := range availableCountries() {
+		calendars = append(calendars, common.HolidayCalendar{
+			Country:    country.Code,
+			Name:       country.Name,
+			Subscribed: subscribed[country.Code],
+		})
+	}
+
+	slog.Info(common.LogHolidayCalendarList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListHolidayCalendars,
+		Data:    calendars,
+	})
+}
+
+// Holidays liste les jours fériés d'un pays pour une année
+// @Summary Lister les jours fériés d'un pays
+// @Description Liste les jours fériés nationaux du pays pour l'année demandée (année en cours par défaut)
+// @Tags Jours fériés
+// @Produce json
+// @Param country path string true "Code pays ISO 3166-1 alpha-2 (ex: FR)"
+// @Param year query int false "Année (1900 à 2200)"
+// @Success 200 {object} common.JSONResponse{data=[]common.Holiday}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /holiday-calendars/{country} [get]
+func (HolidayCalendarStruct) Holidays(c *gin.Context) {
+	slog.Info(common.LogHolidayCalendarHolidays)
+	country, ok := countryFromParam(c)
+	if !ok {
+		return
+	}
+
+	year := time.Now().Year()
+	if value := c.Query("year"); value != "" {
+		var err error
+		year, err = strconv.Atoi(value)
+		if err != nil || year < 1900 || year > 2200 {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidHolidayYear,
+			})
+			return
+		}
+	}
+
+	holidays := []common.Holiday{}
+	for _, h := range holiday.ForYear(country.Code, year) {
+		holidays = append(holidays, common.Holiday{Country: country.Code, Date: h.Date.Format("2006-01-02"), Name: h.Name})
+	}
+
+	slog.Info(common.LogHolidayCalendarHolidays + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListHolidays,
+		Data:    holidays,
+	})
+}
+
+// Subscribe abonne l'utilisateur connecté à un calendrier de jours fériés
+// @Summary S'abonner à un calendrier de jours fériés
+// @Description Abonne l'utilisateur connecté au calendrier de jours fériés du pays : ses jours fériés apparaissent dans l'agenda
+// @Tags Jours fériés
+// @Produce json
+// @Param country path string true "Code pays ISO 3166-1 alpha-2 (ex: FR)"
+// @Success 201 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /holiday-calendars/{country}/subscription [post]
+func (HolidayCalendarStruct) Subscribe(c *gin.Context) {
+	slog.Info(common.LogHolidayCalendarSubscribe)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	country, ok := countryFromParam(c)
+	if !ok {
+		return
+	}
+
+	// Un désabonnement antérieur est réactivé plutôt que dupliqué
+	result, err := common.DB.Exec(`
+		INSERT INTO user_holiday_calendar (user_id, country, created_at)
+		VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			updated_at = IF(deleted_at IS NULL, updated_at, NOW()),
+			deleted_at = NULL
+	`, userData.UserID, country.Code)
+	if err != nil {
+		slog.Error(common.LogHolidayCalendarSubscribe + " - erreur lors de l'abonnement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrHolidayCalendarSubscribe,
+		})
+		return
+	}
+	// MySQL compte 0 ligne affectée quand l'abonnement actif existait déjà et n'a pas changé
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrHolidayCalendarAlreadySubscribed,
+		})
+		return
+	}
+
+	slog.Info(common.LogHolidayCalendarSubscribe + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessSubscribeHolidayCalendar,
+	})
+}
+
+// Unsubscribe désabonne l'utilisateur connecté d'un calendrier de jours fériés
+// @Summary Se désabonner d'un calendrier de jours fériés
+// @Description Retire les jours fériés du pays de l'agenda de l'utilisateur connecté
+// @Tags Jours fériés
+// @Produce json
+// @Param country path string true "Code pays ISO 3166-1 alpha-2 (ex: FR)"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /holiday-calendars/{country}/subscription [delete]
+func (HolidayCalendarStruct) Unsubscribe(c *gin.Context) {
+	slog.Info(common.LogHolidayCalendarUnsubscribe)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	country, ok := countryFromParam(c)
+	if !ok {
+		return
+	}
+
+	result, err := common.DB.Exec(`
+		UPDATE user_holiday_calendar SET deleted_at = NOW(), updated_at = NOW()
+		WHERE user_id = ? AND country = ? AND deleted_at IS NULL
+	`, userData.UserID, country.Code)
+	if err != nil {
+		slog.Error(common.LogHolidayCalendarUnsubscribe + " - erreur lors du désabonnement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrHolidayCalendarUnsubscribe,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrHolidayCalendarNotSubscribed,
+		})
+		return
+	}
+
+	slog.Info(common.LogHolidayCalendarUnsubscribe + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUnsubscribeHolidayCalendar,
+	})
+}
+
+// Agenda regroupe les événements de tous les calendriers de l'utilisateur et les jours fériés de ses abonnements
+// @Summary Récupérer mon agenda
+// @Description Retourne, triés par début, les événements de tous les calendriers accessibles par l'utilisateur connecté qui chevauchent la période et les jours fériés des calendriers auxquels il est abonné (journées entières, à minuit UTC)
+// @Tags Jours fériés
+// @Produce json
+// @Param start query string true "Début de la période (YYYY-MM-DD ou RFC3339)"
+// @Param end query string true "Fin de la période (YYYY-MM-DD inclus ou RFC3339 exclu), 92 jours au plus"
+// @Success 200 {object} common.JSONResponse{data=[]common.AgendaEntry}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /agenda [get]
+func (HolidayCalendarStruct) Agenda(c *gin.Context) {
+	slog.Info(common.LogHolidayCalendarAgenda)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	start, errStart := parseDate(c.Query("start"), false)
+	end, errEnd := parseDate(c.Query("end"), true)
+	if errStart != nil || errEnd != nil || !start.Before(end) || end.Sub(start) > maxAgendaDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidAgendaRange,
+		})
+		return
+	}
+
+	rows, err := common.DB.Query(`
+		SELECT e.event_id, MIN(ce.calendar_id), e.title, e.start, e.duration, e.canceled
+		FROM event e
+		INNER JOIN calendar_event ce ON e.event_id = ce.event_id AND ce.deleted_at IS NULL
+		INNER JOIN calendar c ON ce.calendar_id = c.calendar_id AND c.deleted_at IS NULL
+		INNER JOIN user_calendar uc ON uc.calendar_id = ce.calendar_id AND uc.deleted_at IS NULL
+		WHERE uc.user_id = ? AND e.deleted_at IS NULL
+		  AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+		GROUP BY e.event_id
+	`, userData.UserID, end, start)
+	if err != nil {
+		slog.Error(common.LogHolidayCalendarAgenda + " - erreur lors de la récupération des événements : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAgendaRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	entries := []common.AgendaEntry{}
+	for rows.Next() {
+		var eventID, calendarID int
+		entry := common.AgendaEntry{Type: common.AgendaEntryEvent}
+		if err := rows.Scan(&eventID, &calendarID, &entry.Title, &entry.Start, &entry.Duration, &entry.Canceled); err != nil {
+			slog.Error(common.LogHolidayCalendarAgenda + " - erreur lors de la lecture des événements : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrAgendaRetrieval,
+			})
+			return
+		}
+		entry.EventID, entry.CalendarID = &eventID, &calendarID
+		entries = append(entries, entry)
+	}
+
+	subscribed, err := subscriptions(userData.UserID)
+	if err != nil {
+		slog.Error(common.LogHolidayCalendarAgenda + " - erreur lors de la récupération des abonnements : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAgendaRetrieval,
+		})
+		return
+	}
+	for _, country := range availableCountries() {
+		if !subscribed[country.Code] {
+			continue
+		}
+		for _, h := range holiday.Between(country.Code, start, end) {
+			code := country.Code
+			entries = append(entries, common.AgendaEntry{
+				Type:     common.AgendaEntryHoliday,
+				Country:  &code,
+				Title:    h.Name,
+				Start:    h.Date,
+				Duration: 24 * 60,
+				AllDay:   true,
+			})
+		}
+	}
+	// Les jours fériés précèdent les événements qui commencent au même instant
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].Start.Before(entries[j].Start)
+		}
+		return entries[i].AllDay && !entries[j].AllDay
+	})
+
+	slog.Info(common.LogHolidayCalendarAgenda + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetAgenda,
+		Data:    entries,
+	})
+}
+
+// availableCountries retourne les pays configurés (HOLIDAY_COUNTRIES) dont les jours fériés sont connus
+func availableCountries() []holiday.Country {
+	var countries []holiday.Country
+	seen := map[string]bool{}
+	for _, code := range common.LoadHolidayCountries() {
+		if country, ok := holiday.Lookup(code); ok && !seen[country.Code] {
+			seen[country.Code] = true
+			countries = append(countries, country)
+		}
+	}
+	return countries
+}
+
+// countryFromParam lit le pays du paramètre country et vérifie qu'il est proposé.
+// En cas d'échec, il envoie une réponse 404 et retourne false.
+func countryFromParam(c *gin.Context) (holiday.Country, bool) {
+	requested, _ := holiday.Lookup(c.Param("country"))
+	for _, country := range availableCountries() {
+		if country.Code == requested.Code {
+			return country, true
+		}
+	}
+	c.JSON(http.StatusNotFound, common.JSONResponse{
+		Success: false,
+		Error:   common.ErrHolidayCalendarNotFound,
+	})
+	return holiday.Country{}, false
+}
+
+// subscriptions retourne les codes pays des calendriers de jours fériés auxquels l'utilisateur est abonné
+func subscriptions(userID int) (map[string]bool, error) {
+	rows, err := common.DB.Query("SELECT country FROM user_holiday_calendar WHERE user_id = ? AND deleted_at IS NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscribed := map[string]bool{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		subscribed[code] = true
+	}
+	return subscribed, rows.Err()
+}
+
+// parseDate accepte une date au format YYYY-MM-DD ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
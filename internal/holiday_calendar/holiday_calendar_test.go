@@ -0,0 +1,133 @@
+package holiday_calendar_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	os.Setenv("HOLIDAY_COUNTRIES", "FR,US")
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// TestHolidaysRoute teste la liste des jours fériés d'un pays avec plusieurs cas
+func TestHolidaysRoute(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		URL              string
+		ExpectedHttpCode int
+		ExpectedError    string
+		ExpectedCount    int
+	}{
+		{CaseName: "Jours fériés français de 2025", URL: "/holiday-calendars/FR?year=2025", ExpectedHttpCode: http.StatusOK, ExpectedCount: 11},
+		{CaseName: "Code pays en minuscules", URL: "/holiday-calendars/us?year=2025", ExpectedHttpCode: http.StatusOK, ExpectedCount: 11},
+		{CaseName: "Échec avec un pays connu mais non proposé", URL: "/holiday-calendars/DE", ExpectedHttpCode: http.StatusNotFound, ExpectedError: common.ErrHolidayCalendarNotFound},
+		{CaseName: "Échec avec une année invalide", URL: "/holiday-calendars/FR?year=abc", ExpectedHttpCode: http.StatusBadRequest, ExpectedError: common.ErrInvalidHolidayYear},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "GET", testCase.URL, nil)
+			require.Equal(t, testCase.ExpectedHttpCode, status, response.Error)
+			if testCase.ExpectedError != "" {
+				require.Equal(t, testCase.ExpectedError, response.Error)
+				return
+			}
+			var holidays []common.Holiday
+			require.NoError(t, json.Unmarshal(response.Data, &holidays))
+			require.Len(t, holidays, testCase.ExpectedCount)
+		})
+	}
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestHolidayCalendarSubscription vérifie l'abonnement et l'apparition des jours fériés dans l'agenda
+func TestHolidayCalendarSubscription(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+
+	// Un événement le 14 juillet 2025 à 10:00
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-event/"+strconv.Itoa(user.Calendar.CalendarID), map[string]interface{}{
+		"title": "Feu d'artifice", "start": time.Date(2025, 7, 14, 10, 0, 0, 0, time.UTC).Format(time.RFC3339), "duration": 60, "calendar_id": user.Calendar.CalendarID,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	agenda := func() []common.AgendaEntry {
+		status, response := testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/agenda?start=2025-07-01&end=2025-07-31", nil)
+		require.Equal(t, http.StatusOK, status, response.Error)
+		var entries []common.AgendaEntry
+		require.NoError(t, json.Unmarshal(response.Data, &entries))
+		return entries
+	}
+	require.Len(t, agenda(), 1)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/holiday-calendars/FR/subscription", nil)
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/holiday-calendars/FR/subscription", nil)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrHolidayCalendarAlreadySubscribed, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/holiday-calendars", nil)
+	require.Equal(t, http.StatusOK, status)
+	var calendars []common.HolidayCalendar
+	require.NoError(t, json.Unmarshal(response.Data, &calendars))
+	require.Equal(t, []common.HolidayCalendar{
+		{Country: "FR", Name: "France", Subscribed: true},
+		{Country: "US", Name: "États-Unis", Subscribed: false},
+	}, calendars)
+
+	// Le 14 juillet apparaît avant l'événement du même jour
+	entries := agenda()
+	require.Len(t, entries, 2)
+	require.Equal(t, common.AgendaEntryHoliday, entries[0].Type)
+	require.Equal(t, "Fête nationale", entries[0].Title)
+	require.True(t, entries[0].AllDay)
+	require.Equal(t, common.AgendaEntryEvent, entries[1].Type)
+
+	// Le désabonnement retire les jours fériés, un nouvel abonnement les rétablit
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", "/holiday-calendars/FR/subscription", nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", "/holiday-calendars/FR/subscription", nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Len(t, agenda(), 1)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/holiday-calendars/FR/subscription", nil)
+	require.Equal(t, http.StatusCreated, status)
+	require.Len(t, agenda(), 2)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/agenda?start=2025-01-01&end=2025-12-31", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidAgendaRange, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,344 @@
+// Package login_guard internal/login_guard/login_guard.go
+// Protection de la connexion par mot de passe contre les attaques par force brute : échecs comptés par adresse e-mail
+// et par adresse IP, délais progressifs, verrouillage temporaire levé par un administrateur, journal des événements.
+package login_guard
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginGuardStruct struct{}
+
+var LoginGuard = LoginGuardStruct{}
+
+const (
+	// delayThreshold est le nombre d'échecs consécutifs à partir duquel chaque tentative est retardée
+	delayThreshold = 3
+	// maxDelay borne le délai progressif entre deux tentatives
+	maxDelay = time.Minute
+	// lockThreshold est le nombre d'échecs consécutifs qui verrouille le compte
+	lockThreshold = 10
+	// lockDuration est la durée du verrouillage, renouvelé à chaque nouvel échec
+	lockDuration = 15 * time.Minute
+	// failureWindow est la durée au-delà de laquelle le décompte des échecs d'une adresse repart de zéro
+	failureWindow = time.Hour
+	// ipWindow et ipMaxFailures bornent les échecs d'une même adresse IP, toutes adresses e-mail confondues
+	ipWindow      = 15 * time.Minute
+	ipMaxFailures = 30
+	// maxEvents borne le nombre d'événements retournés par la consultation du journal
+	maxEvents = 100
+)
+
+// Types d'événements du journal de sécurité (table security_event)
+const (
+	EventLoginFailed     = "login_failed"
+	EventLoginSucceeded  = "login_succeeded"
+	EventAccountLocked   = "account_locked"
+	EventAccountUnlocked = "account_unlocked"
+)
+
+// Moyens de connexion inscrits au journal de sécurité avec les connexions et les échecs
+const (
+	MethodPassword  = "mot de passe"
+	MethodTwoFactor = "second facteur"
+	MethodPasskey   = "passkey"
+	MethodOIDC      = "sso"
+)
+
+// ErrAccountLocked est retournée par Check pour une adresse verrouillée après trop d'échecs
+var ErrAccountLocked = errors.New(common.ErrAccountLocked)
+
+// ErrThrottled est retournée par Check lorsque le délai imposé depuis le dernier échec n'est pas écoulé
+var ErrThrottled = errors.New(common.ErrLoginThrottled)
+
+// normalize retourne la forme sous laquelle une adresse e-mail est suivie
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// delay retourne le délai imposé après failures échecs consécutifs : 1 s au seuil, doublé à chaque échec
+func delay(failures int) time.Duration {
+	if failures < delayThreshold {
+		return 0
+	}
+	d := time.Second << min(failures-delayThreshold, 10)
+	return min(d, maxDelay)
+}
+
+// Check indique si une tentative de connexion peut être vérifiée. L'adresse est suivie qu'un compte lui corresponde
+// ou non, pour ne pas révéler son existence. Retourne ErrAccountLocked ou ErrThrottled et le délai à attendre.
+func Check(email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	var failures int
+	var lastFailedAt time.Time
+	var lockedUntil sql.NullTime
+	err := common.DB.QueryRow(`
+		SELECT failed_count, last_failed_at, locked_until FROM login_lockout WHERE email = ?
+	`, normalize(email)).Scan(&failures, &lastFailedAt, &lockedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil {
+		if lockedUntil.Valid && now.Before(lockedUntil.Time) {
+			return lockedUntil.Time.Sub(now), ErrAccountLocked
+		}
+		if next := lastFailedAt.Add(delay(failures)); now.Sub(lastFailedAt) < failureWindow && now.Before(next) {
+			return next.Sub(now), ErrThrottled
+		}
+	}
+
+	// Une même adresse IP essayant de nombreux comptes est freinée, quelle que soit l'adresse visée
+	var ipFailures int
+	var oldest sql.NullTime
+	if err := common.DB.QueryRow(`
+		SELECT COUNT(*), MIN(created_at) FROM security_event
+		WHERE event_type = ? AND ip_address = ? AND created_at >= ?
+	`, EventLoginFailed, ip, now.Add(-ipWindow)).Scan(&ipFailures, &oldest); err != nil {
+		return 0, err
+	}
+	if ipFailures >= ipMaxFailures && oldest.Valid {
+		return max(oldest.Time.Add(ipWindow).Sub(now), time.Second), ErrThrottled
+	}
+	return 0, nil
+}
+
+// RecordFailure enregistre un échec de connexion par le moyen method et verrouille l'adresse
+// au-delà de lockThreshold échecs consécutifs
+func RecordFailure(email, ip, method string) error {
+	email = normalize(email)
+	now := time.Now()
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Le décompte repart de zéro après failureWindow sans échec ; les affectations sont évaluées dans l'ordre
+	if _, err := tx.Exec(`
+		INSERT INTO login_lockout (email, failed_count, last_failed_at) VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE
+			failed_count = IF(last_failed_at < ?, 1, failed_count + 1),
+			last_failed_at = VALUES(last_failed_at)
+	`, email, now, now.Add(-failureWindow)); err != nil {
+		return err
+	}
+	var failures int
+	if err := tx.QueryRow(`SELECT failed_count FROM login_lockout WHERE email = ?`, email).Scan(&failures); err != nil {
+		return err
+	}
+	userID := accountID(tx, email)
+	if err := record(tx, EventLoginFailed, userID, email, ip, method+" : échec n°"+strconv.Itoa(failures)); err != nil {
+		return err
+	}
+	if failures >= lockThreshold {
+		if _, err := tx.Exec(`UPDATE login_lockout SET locked_until = ? WHERE email = ?`, now.Add(lockDuration), email); err != nil {
+			return err
+		}
+		if err := record(tx, EventAccountLocked, userID, email, ip, "verrouillé "+lockDuration.String()+" après "+strconv.Itoa(failures)+" échecs"); err != nil {
+			return err
+		}
+		slog.Warn(common.LogAccountLocked, "email", email, "ip", ip, "failures", failures)
+	}
+	return tx.Commit()
+}
+
+// RecordUnidentifiedFailure enregistre un échec qui ne désigne aucun compte (passkey inconnue, refus du fournisseur
+// d'identité) : seul le décompte de l'adresse IP en tient compte
+func RecordUnidentifiedFailure(ip, method string) error {
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := record(tx, EventLoginFailed, nil, "", ip, method); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordSuccess enregistre une connexion réussie par le moyen method et remet à zéro le décompte des échecs de l'adresse.
+// Elle n'est appelée qu'une fois la session créée, second facteur compris.
+func RecordSuccess(email string, userID int, ip, method string) error {
+	email = normalize(email)
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM login_lockout WHERE email = ?`, email); err != nil {
+		return err
+	}
+	if err := record(tx, EventLoginSucceeded, &userID, email, ip, method); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// accountID retourne l'identifiant du compte de l'adresse, nil si aucun compte ne lui correspond
+func accountID(tx *sql.Tx, email string) *int {
+	var userID int
+	if err := tx.QueryRow(`SELECT user_id FROM user WHERE email = ? AND deleted_at IS NULL`, email).Scan(&userID); err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// record ajoute un événement au journal de sécurité
+func record(tx *sql.Tx, eventType string, userID *int, email, ip, details string) error {
+	_, err := tx.Exec(`
+		INSERT INTO security_event (event_type, user_id, email, ip_address, details, created_at) VALUES (?, ?, ?, ?, ?, ?)
+	`, eventType, userID, email, ip, details, time.Now())
+	return err
+}
+
+// RetryAfter ajoute l'en-tête Retry-After (en secondes, arrondi au supérieur) à la réponse
+func RetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+}
+
+// Unlock lève le verrouillage et remet à zéro le décompte des échecs d'un compte
+// @Summary Déverrouiller un compte (admin)
+// @Description Lève le verrouillage temporaire consécutif à des échecs de connexion répétés et remet à zéro le décompte des échecs du compte. L'opération est inscrite au journal de sécurité.
+// @Tags Utilisateurs
+// @Produce json
+// @Param user_id path int true "ID de l'utilisateur"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /user/{user_id}/lockout [delete]
+func (LoginGuardStruct) Unlock(c *gin.Context) {
+	slog.Info(common.LogAccountUnlock)
+	admin, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	target, ok := targetUser(c)
+	if !ok {
+		return
+	}
+
+	email := normalize(target.Email)
+	tx, err := common.DB.Begin()
+	if err == nil {
+		defer tx.Rollback()
+		var result sql.Result
+		if result, err = tx.Exec(`DELETE FROM login_lockout WHERE email = ?`, email); err == nil {
+			if removed, _ := result.RowsAffected(); removed > 0 {
+				err = record(tx, EventAccountUnlocked, &target.UserID, email, c.ClientIP(), "par l'administrateur "+strconv.Itoa(admin.UserID))
+			}
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		slog.Error(common.LogAccountUnlock + " - erreur : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAccountUnlock,
+		})
+		return
+	}
+
+	slog.Info(common.LogAccountUnlock+" - succès", "user_id", target.UserID, "admin_id", admin.UserID)
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessAccountUnlocked,
+	})
+}
+
+// ListEvents retourne les derniers événements de sécurité d'un compte
+// @Summary Journal de sécurité d'un compte (admin)
+// @Description Retourne les 100 derniers événements de sécurité du compte (connexions réussies ou échouées, verrouillages, déverrouillages), du plus récent au plus ancien, ainsi que l'état du verrouillage.
+// @Tags Utilisateurs
+// @Produce json
+// @Param user_id path int true "ID de l'utilisateur"
+// @Success 200 {object} common.JSONResponse{data=common.SecurityEventsResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /user/{user_id}/security-events [get]
+func (LoginGuardStruct) ListEvents(c *gin.Context) {
+	slog.Info(common.LogSecurityEventList)
+	target, ok := targetUser(c)
+	if !ok {
+		return
+	}
+
+	response := common.SecurityEventsResponse{Events: []common.SecurityEvent{}}
+	var lockedUntil sql.NullTime
+	err := common.DB.QueryRow(`
+		SELECT failed_count, locked_until FROM login_lockout WHERE email = ?
+	`, normalize(target.Email)).Scan(&response.FailedAttempts, &lockedUntil)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		response.LockedUntil = &lockedUntil.Time
+	}
+
+	var rows *sql.Rows
+	if err == nil {
+		rows, err = common.DB.Query(fmt.Sprintf(`
+			SELECT security_event_id, event_type, user_id, email, ip_address, details, created_at
+			FROM security_event
+			WHERE user_id = ? OR email = ?
+			ORDER BY created_at DESC, security_event_id DESC
+			LIMIT %d
+		`, maxEvents), target.UserID, normalize(target.Email))
+	}
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var event common.SecurityEvent
+			if err = rows.Scan(&event.SecurityEventID, &event.EventType, &event.UserID, &event.Email, &event.IPAddress, &event.Details, &event.CreatedAt); err != nil {
+				break
+			}
+			response.Events = append(response.Events, event)
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	if err != nil {
+		slog.Error(common.LogSecurityEventList + " - erreur : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrSecurityEventList,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// targetUser retourne l'utilisateur désigné par l'URL, placé dans le contexte par UserExistsMiddleware
+func targetUser(c *gin.Context) (common.User, bool) {
+	value, exists := c.Get("user")
+	target, ok := value.(common.User)
+	if !exists || !ok {
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInternalUserNotInContext,
+		})
+		return common.User{}, false
+	}
+	return target, true
+}
@@ -0,0 +1,196 @@
+package login_guard_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// login tente une connexion par mot de passe
+func login(t *testing.T, email, password string) (int, testutils.APIResponse) {
+	return testutils.DoRequest(t, testServer, "", http.MethodPost, "/auth/login", map[string]string{"email": email, "password": password})
+}
+
+// elapse fait comme si le dernier échec de l'adresse remontait à d, pour ne pas attendre le délai progressif
+func elapse(t *testing.T, email string, d time.Duration) {
+	_, err := common.DB.Exec(`UPDATE login_lockout SET last_failed_at = ? WHERE email = ?`, time.Now().Add(-d), strings.ToLower(email))
+	require.NoError(t, err)
+}
+
+// countEvents retourne le nombre d'événements de sécurité d'un type pour l'adresse
+func countEvents(t *testing.T, email, eventType string) int {
+	var count int
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT COUNT(*) FROM security_event WHERE email = ? AND event_type = ?
+	`, strings.ToLower(email), eventType).Scan(&count))
+	return count
+}
+
+// TestLoginFailureNotReflected vérifie que la réponse à un échec ne renvoie pas le mot de passe saisi
+func TestLoginFailureNotReflected(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+
+	status, response := login(t, user.User.Email, "MotDePasseSecret42!")
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrInvalidCredentials, response.Error)
+	require.Equal(t, "null", string(response.Data))
+	require.NotContains(t, response.Raw, "MotDePasseSecret42!")
+	require.Equal(t, 1, countEvents(t, user.User.Email, "login_failed"))
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestProgressiveDelay vérifie le délai imposé après des échecs répétés, y compris pour une adresse sans compte
+func TestProgressiveDelay(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+
+	for _, email := range []string{user.User.Email, "personne.inconnue@example.com"} {
+		for range 3 {
+			status, response := login(t, email, "incorrect")
+			require.Equal(t, http.StatusUnauthorized, status, email)
+			require.Equal(t, common.ErrInvalidCredentials, response.Error, email)
+		}
+
+		// Le délai s'applique avant toute vérification du mot de passe : la réponse ne dit rien du compte
+		status, response := login(t, email, user.Password)
+		require.Equal(t, http.StatusTooManyRequests, status, email)
+		require.Equal(t, common.ErrLoginThrottled, response.Error, email)
+		require.NotEmpty(t, response.Header.Get("Retry-After"), email)
+		require.Equal(t, 3, countEvents(t, email, "login_failed"), email)
+	}
+
+	// Une fois le délai écoulé, la connexion réussit et remet le décompte à zéro
+	elapse(t, user.User.Email, 2*time.Second)
+	status, response := login(t, user.User.Email, user.Password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, 1, countEvents(t, user.User.Email, "login_succeeded"))
+	var remaining int
+	require.NoError(t, common.DB.QueryRow(`SELECT COUNT(*) FROM login_lockout WHERE email = ?`, strings.ToLower(user.User.Email)).Scan(&remaining))
+	require.Zero(t, remaining)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestAccountLockout vérifie le verrouillage après 10 échecs, sa consultation et son levée par un administrateur
+func TestAccountLockout(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	admin, err := testutils.GenerateAuthenticatedAdmin(true, true, false, false)
+	require.NoError(t, err)
+
+	for range 10 {
+		elapse(t, user.User.Email, 2*time.Minute)
+		status, _ := login(t, user.User.Email, "incorrect")
+		require.Equal(t, http.StatusUnauthorized, status)
+	}
+	require.Equal(t, 1, countEvents(t, user.User.Email, "account_locked"))
+
+	// Le verrouillage ne dépend pas du délai progressif
+	elapse(t, user.User.Email, 2*time.Minute)
+	status, response := login(t, user.User.Email, user.Password)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, common.ErrAccountLocked, response.Error)
+	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	require.InDelta(t, 15*60, retryAfter, 5)
+
+	// Le journal et le déverrouillage sont réservés aux administrateurs
+	url := "/user/" + strconv.Itoa(user.User.UserID)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, http.MethodDelete, url+"/lockout", nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	status, response = testutils.DoRequest(t, testServer, admin.SessionToken, http.MethodGet, url+"/security-events", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var events common.SecurityEventsResponse
+	require.NoError(t, json.Unmarshal(response.Data, &events))
+	require.Equal(t, 10, events.FailedAttempts)
+	require.NotNil(t, events.LockedUntil)
+	require.Len(t, events.Events, 11)
+	require.Equal(t, "account_locked", events.Events[0].EventType)
+	require.NotNil(t, events.Events[0].UserID)
+	require.Equal(t, user.User.UserID, *events.Events[0].UserID)
+
+	status, response = testutils.DoRequest(t, testServer, admin.SessionToken, http.MethodDelete, url+"/lockout", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, common.MsgSuccessAccountUnlocked, response.Message)
+	require.Equal(t, 1, countEvents(t, user.User.Email, "account_unlocked"))
+
+	status, response = login(t, user.User.Email, user.Password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Déverrouiller un compte sans échec enregistré n'ajoute rien au journal
+	status, _ = testutils.DoRequest(t, testServer, admin.SessionToken, http.MethodDelete, url+"/lockout", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, countEvents(t, user.User.Email, "account_unlocked"))
+
+	status, _ = testutils.DoRequest(t, testServer, admin.SessionToken, http.MethodDelete, "/user/99999999/lockout", nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestIPThrottling vérifie qu'une adresse IP cumulant les échecs sur des comptes différents est freinée
+func TestIPThrottling(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+
+	status, _ := login(t, "premier.essai@example.com", "incorrect")
+	require.Equal(t, http.StatusUnauthorized, status)
+	var ip string
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT ip_address FROM security_event WHERE email = ? AND event_type = 'login_failed'
+	`, "premier.essai@example.com").Scan(&ip))
+
+	// 29 autres échecs récents de la même adresse IP, chacun sur une adresse différente
+	for i := range 29 {
+		_, err := common.DB.Exec(`
+			INSERT INTO security_event (event_type, email, ip_address, created_at) VALUES ('login_failed', ?, ?, NOW())
+		`, "essai"+strconv.Itoa(i)+"@example.com", ip)
+		require.NoError(t, err)
+	}
+
+	status, response := login(t, user.User.Email, user.Password)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, common.ErrLoginThrottled, response.Error)
+	require.NotEmpty(t, response.Header.Get("Retry-After"))
+
+	// Les échecs plus anciens que la fenêtre ne comptent plus
+	_, err = common.DB.Exec(`UPDATE security_event SET created_at = ? WHERE ip_address = ?`, time.Now().Add(-20*time.Minute), ip)
+	require.NoError(t, err)
+	status, response = login(t, user.User.Email, user.Password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
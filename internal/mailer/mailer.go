@@ -0,0 +1,166 @@
+// Package mailer internal/mailer/mailer.go
+// Envoi des e-mails transactionnels (réinitialisation de mot de passe, vérification d'adresse...).
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"go-averroes/internal/common"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message est un e-mail en texte brut
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer est l'interface commune aux backends d'envoi
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Default est le backend utilisé par l'application, initialisé par Init
+var Default Mailer
+
+// Config est la configuration courante (expéditeur, URL du front-end), initialisée par Init
+var Config common.MailConfig
+
+// New construit le backend correspondant à la configuration
+func New(cfg common.MailConfig) (Mailer, error) {
+	switch cfg.Backend {
+	case "", "log":
+		return LogMailer{}, nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST est requis pour le backend smtp")
+		}
+		return &SMTPMailer{Host: cfg.SMTPHost, Port: cfg.SMTPPort, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.From}, nil
+	default:
+		return nil, fmt.Errorf("backend d'envoi d'e-mails inconnu : %s", cfg.Backend)
+	}
+}
+
+// Init initialise le backend par défaut de l'application
+func Init(cfg common.MailConfig) error {
+	backend, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Default = backend
+	Config = cfg
+	return nil
+}
+
+// SendAsync envoie le message en arrière-plan : la durée de la requête HTTP ne dépend pas de l'envoi,
+// ce qui évite de révéler par le temps de réponse qu'un e-mail a été émis
+func SendAsync(msg Message) {
+	backend := Default
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := backend.Send(ctx, msg); err != nil {
+			slog.Error(common.LogMailSend + " - échec de l'envoi : " + err.Error())
+		}
+	}()
+}
+
+// Link construit un lien vers le front-end (MAIL_APP_URL) avec le jeton en paramètre
+func Link(path, token string) string {
+	return strings.TrimRight(Config.AppURL, "/") + path + "?token=" + token
+}
+
+// LogMailer écrit les e-mails dans les logs au lieu de les envoyer (développement)
+type LogMailer struct{}
+
+// Send journalise le message
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	slog.Info(common.LogMailSend, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// MemoryMailer conserve les e-mails en mémoire (tests)
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send conserve le message
+func (m *MemoryMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages retourne les messages envoyés au destinataire, du plus ancien au plus récent
+func (m *MemoryMailer) Messages(to string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []Message
+	for _, msg := range m.messages {
+		if strings.EqualFold(msg.To, to) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// SMTPMailer envoie les e-mails via un serveur SMTP (STARTTLS si le serveur le propose)
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send envoie le message
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(addr, auth, m.From, []string{msg.To}, Build(m.From, msg, time.Now()))
+}
+
+// Build construit le message au format RFC 5322 : en-têtes encodés pour l'UTF-8, corps en base64
+func Build(from string, msg Message, date time.Time) []byte {
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `text/plain; charset="utf-8"`},
+		{"Content-Transfer-Encoding", "base64"},
+	}
+	for _, header := range headers {
+		// Les retours à la ligne sont retirés pour empêcher l'injection d'en-têtes
+		value := strings.NewReplacer("\r", "", "\n", "").Replace(header[1])
+		buf.WriteString(header[0] + ": " + value + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,48 @@
+package mailer_test
+
+import (
+	"context"
+	"encoding/base64"
+	"go-averroes/internal/common"
+	"go-averroes/internal/mailer"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuild vérifie l'encodage des en-têtes UTF-8 et le refus de l'injection d'en-têtes
+func TestBuild(t *testing.T) {
+	date := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	raw := string(mailer.Build("GoLendar <noreply@example.com>", mailer.Message{
+		To:      "leila.benali@example.com\r\nBcc: intrus@example.com",
+		Subject: "Réinitialisation du mot de passe",
+		Body:    "Bonjour Leïla,\nVoici votre lien.",
+	}, date))
+
+	headers, body, found := strings.Cut(raw, "\r\n\r\n")
+	require.True(t, found)
+	require.Contains(t, headers, "Subject: =?utf-8?q?R=C3=A9initialisation_du_mot_de_passe?=")
+	require.Contains(t, headers, "Date: Mon, 02 Jun 2025 09:00:00 +0000")
+	require.NotContains(t, headers, "\r\nBcc:")
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	require.NoError(t, err)
+	require.Equal(t, "Bonjour Leïla,\nVoici votre lien.", string(decoded))
+}
+
+// TestMemoryMailer vérifie la conservation des messages par destinataire
+func TestMemoryMailer(t *testing.T) {
+	require.NoError(t, mailer.Init(common.MailConfig{Backend: "memory", AppURL: "https://app.example.com/"}))
+	memory, ok := mailer.Default.(*mailer.MemoryMailer)
+	require.True(t, ok)
+
+	require.NoError(t, memory.Send(context.Background(), mailer.Message{To: "a@example.com", Subject: "1"}))
+	require.NoError(t, memory.Send(context.Background(), mailer.Message{To: "b@example.com", Subject: "2"}))
+	require.Len(t, memory.Messages("A@example.com"), 1)
+	require.Equal(t, "https://app.example.com/reset-password?token=abc", mailer.Link("/reset-password", "abc"))
+
+	_, err := mailer.New(common.MailConfig{Backend: "smtp"})
+	require.Error(t, err)
+}
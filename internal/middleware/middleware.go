@@ -3,10 +3,13 @@ package middleware
 import (
 	"fmt"
 	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
 	"go-averroes/internal/session"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"log/slog"
 	"time"
@@ -96,12 +99,13 @@ func CalendarExistsMiddleware(paramName string) gin.HandlerFunc {
 
 		var calendar common.Calendar
 		err = common.DB.QueryRow(
-			"SELECT calendar_id, title, description, created_at, updated_at, deleted_at FROM calendar WHERE calendar_id = ? AND deleted_at IS NULL",
+			"SELECT calendar_id, title, description, color, created_at, updated_at, deleted_at FROM calendar WHERE calendar_id = ? AND deleted_at IS NULL",
 			calendarID,
 		).Scan(
 			&calendar.CalendarID,
 			&calendar.Title,
 			&calendar.Description,
+			&calendar.Color,
 			&calendar.CreatedAt,
 			&calendar.UpdatedAt,
 			&calendar.DeletedAt,
@@ -257,8 +261,8 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Valider la session
-		user, err := session.Session.ValidateSession(token)
+		// Valider le token : token d'accès signé, sans accès à la base, ou session opaque
+		user, roles, err := session.Session.ValidateAccessToken(token)
 		if err != nil {
 			slog.Error(common.LogInvalidSession + ": " + err.Error())
 			c.JSON(http.StatusUnauthorized, common.JSONResponse{
@@ -269,8 +273,37 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Ajouter l'utilisateur au contexte
+		// Ajouter l'utilisateur au contexte, ainsi que ses rôles s'ils sont portés par le token
 		c.Set("auth_user", *user)
+		if roles != nil {
+			c.Set(tokenRolesKey, roles)
+		}
+		c.Next()
+	}
+}
+
+// EmailVerifiedMiddleware refuse l'accès aux utilisateurs dont l'adresse e-mail n'est pas vérifiée,
+// lorsque la politique EMAIL_VERIFICATION_POLICY vaut "routes" (à placer après AuthMiddleware)
+func EmailVerifiedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if email_verification.Policy != common.EmailVerificationPolicyRoutes {
+			c.Next()
+			return
+		}
+		userData, ok := common.GetUserFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if userData.EmailVerifiedAt == nil {
+			slog.Error(common.LogEmailNotVerified, "user_id", userData.UserID, "path", c.FullPath())
+			c.JSON(http.StatusForbidden, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrEmailNotVerified,
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -289,6 +322,21 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 			return
 		}
 
+		// Rôles portés par un token d'accès signé : aucun accès à la base
+		if roles, ok := tokenRoles(c); ok {
+			if !slices.Contains(roles, requiredRole) {
+				slog.Error(fmt.Sprintf(common.LogUserMissingRole, requiredRole))
+				c.JSON(http.StatusForbidden, common.JSONResponse{
+					Success: false,
+					Error:   common.ErrInsufficientPermissions,
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// Vérifier si l'utilisateur a le rôle requis
 		var roleID int
 		err := common.DB.QueryRow(`
@@ -326,6 +374,21 @@ func RolesMiddleware(requiredRoles ...string) gin.HandlerFunc {
 			return
 		}
 
+		// Rôles portés par un token d'accès signé : aucun accès à la base
+		if roles, ok := tokenRoles(c); ok {
+			if !slices.ContainsFunc(requiredRoles, func(role string) bool { return slices.Contains(roles, role) }) {
+				slog.Error("Utilisateur n'a aucun des rôles requis: " + strings.Join(requiredRoles, ", "))
+				c.JSON(http.StatusForbidden, common.JSONResponse{
+					Success: false,
+					Error:   common.ErrInsufficientPermissions,
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// Construire la requête pour vérifier si l'utilisateur a au moins un des rôles
 		placeholders := make([]string, len(requiredRoles))
 		args := make([]interface{}, len(requiredRoles)+1)
@@ -366,6 +429,11 @@ func AdminMiddleware() gin.HandlerFunc {
 	return RoleMiddleware("admin")
 }
 
+// ResourceAdminMiddleware vérifie que l'utilisateur administre les ressources réservables
+func ResourceAdminMiddleware() gin.HandlerFunc {
+	return RoleMiddleware("resource_admin")
+}
+
 // OptionalAuthMiddleware vérifie l'authentification si un token est fourni, sinon continue sans authentification
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -385,8 +453,8 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Valider la session
-		user, err := session.Session.ValidateSession(token)
+		// Valider le token
+		user, roles, err := session.Session.ValidateAccessToken(token)
 		if err != nil {
 			// Session invalide, on continue sans authentification
 			slog.Warn(common.LogSessionInvalidOptional + ": " + err.Error())
@@ -394,12 +462,28 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Ajouter l'utilisateur au contexte
+		// Ajouter l'utilisateur au contexte, ainsi que ses rôles s'ils sont portés par le token
 		c.Set("auth_user", *user)
+		if roles != nil {
+			c.Set(tokenRolesKey, roles)
+		}
 		c.Next()
 	}
 }
 
+// tokenRolesKey est la clé du contexte contenant les rôles portés par un token d'accès signé
+const tokenRolesKey = "auth_roles"
+
+// tokenRoles retourne les rôles portés par le token d'accès de la requête, s'il s'agit d'un token signé
+func tokenRoles(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(tokenRolesKey)
+	if !exists {
+		return nil, false
+	}
+	roles, ok := value.([]string)
+	return roles, ok
+}
+
 // extractTokenFromHeader extrait le token du header Authorization
 func extractTokenFromHeader(authHeader string) string {
 	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
@@ -408,10 +492,17 @@ func extractTokenFromHeader(authHeader string) string {
 	return ""
 }
 
-// EventExistsMiddleware vérifie l'existence d'un événement à partir d'un paramètre dans l'URL
+// EventExistsMiddleware vérifie l'existence d'un événement rattaché au calendrier du contexte.
+// Doit être placé après CalendarExistsMiddleware et UserCanAccessCalendarMiddleware.
 // paramName: nom du paramètre à vérifier (ex: "id", "event_id")
 func EventExistsMiddleware(paramName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		calendarData, ok := common.GetCalendarFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
 		eventIDStr := c.Param(paramName)
 		eventID, err := strconv.Atoi(eventIDStr)
 		if err != nil {
@@ -424,9 +515,14 @@ func EventExistsMiddleware(paramName string) gin.HandlerFunc {
 		}
 
 		var event common.Event
-		err = common.DB.QueryRow(
-			"SELECT event_id, title, description, start, duration, canceled, created_at, updated_at, deleted_at FROM event WHERE event_id = ? AND deleted_at IS NULL",
-			eventID,
+		err = common.DB.QueryRow(`
+			SELECT e.event_id, e.title, e.description, e.start, e.duration, e.canceled, e.location, e.latitude, e.longitude,
+			       e.meeting_url, e.created_at, e.updated_at, e.deleted_at
+			FROM event e
+			INNER JOIN calendar_event ce ON ce.event_id = e.event_id
+			WHERE e.event_id = ? AND ce.calendar_id = ? AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+			LIMIT 1`,
+			eventID, calendarData.CalendarID,
 		).Scan(
 			&event.EventID,
 			&event.Title,
@@ -434,6 +530,10 @@ func EventExistsMiddleware(paramName string) gin.HandlerFunc {
 			&event.Start,
 			&event.Duration,
 			&event.Canceled,
+			&event.Location,
+			&event.Latitude,
+			&event.Longitude,
+			&event.MeetingURL,
 			&event.CreatedAt,
 			&event.UpdatedAt,
 			&event.DeletedAt,
@@ -448,3 +548,368 @@ func EventExistsMiddleware(paramName string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// TagExistsMiddleware vérifie l'existence d'une étiquette appartenant à l'utilisateur connecté
+// paramName: nom du paramètre à vérifier (ex: "tag_id")
+func TagExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData, ok := common.GetUserFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		tagID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTagID,
+			})
+			c.Abort()
+			return
+		}
+
+		// Une étiquette d'un autre utilisateur est traitée comme inexistante
+		var tag common.Tag
+		err = common.DB.QueryRow(
+			"SELECT tag_id, user_id, name, kind, color, created_at, updated_at, deleted_at FROM tag WHERE tag_id = ? AND user_id = ? AND deleted_at IS NULL",
+			tagID, userData.UserID,
+		).Scan(
+			&tag.TagID,
+			&tag.UserID,
+			&tag.Name,
+			&tag.Kind,
+			&tag.Color,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+			&tag.DeletedAt,
+		)
+
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrTagNotFound, common.ErrTagRetrieval) {
+			return
+		}
+
+		// L'étiquette existe, on l'ajoute au contexte et on continue
+		c.Set("tag", tag)
+		c.Next()
+	}
+}
+
+// AttachmentExistsMiddleware vérifie l'existence d'une pièce jointe de l'événement du contexte.
+// L'événement doit appartenir au calendrier du contexte, dont l'accès a déjà été vérifié.
+// paramName: nom du paramètre à vérifier (ex: "attachment_id")
+func AttachmentExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		calendarData, ok := common.GetCalendarFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		eventData, ok := common.GetEventFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		attachmentID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidAttachmentID,
+			})
+			c.Abort()
+			return
+		}
+
+		var attachment common.Attachment
+		err = common.DB.QueryRow(`
+			SELECT a.attachment_id, a.event_id, a.user_id, a.filename, a.content_type, a.size, a.storage_key,
+			       a.created_at, a.updated_at, a.deleted_at
+			FROM attachment a
+			INNER JOIN calendar_event ce ON ce.event_id = a.event_id AND ce.calendar_id = ? AND ce.deleted_at IS NULL
+			WHERE a.attachment_id = ? AND a.event_id = ? AND a.deleted_at IS NULL`,
+			calendarData.CalendarID, attachmentID, eventData.EventID,
+		).Scan(
+			&attachment.AttachmentID,
+			&attachment.EventID,
+			&attachment.UserID,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.StorageKey,
+			&attachment.CreatedAt,
+			&attachment.UpdatedAt,
+			&attachment.DeletedAt,
+		)
+
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrAttachmentNotFound, common.ErrAttachmentRetrieval) {
+			return
+		}
+
+		// La pièce jointe existe, on l'ajoute au contexte et on continue
+		c.Set("attachment", attachment)
+		c.Next()
+	}
+}
+
+// TemplateExistsMiddleware vérifie l'existence d'un modèle de calendrier.
+// Les modèles publiés sont visibles par tous les utilisateurs authentifiés.
+// paramName: nom du paramètre à vérifier (ex: "template_id")
+func TemplateExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templateID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTemplateID,
+			})
+			c.Abort()
+			return
+		}
+
+		var template common.CalendarTemplate
+		err = common.DB.QueryRow(
+			"SELECT template_id, title, description, color, created_by, created_at, updated_at, deleted_at FROM calendar_template WHERE template_id = ? AND deleted_at IS NULL",
+			templateID,
+		).Scan(
+			&template.TemplateID,
+			&template.Title,
+			&template.Description,
+			&template.Color,
+			&template.CreatedBy,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+			&template.DeletedAt,
+		)
+
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrTemplateNotFound, common.ErrTemplateRetrieval) {
+			return
+		}
+
+		// Le modèle existe, on l'ajoute au contexte et on continue
+		c.Set("template", template)
+		c.Next()
+	}
+}
+
+// TaskExistsMiddleware vérifie l'existence d'une tâche dans le calendrier du contexte.
+// Doit être placé après CalendarExistsMiddleware et UserCanAccessCalendarMiddleware.
+// paramName: nom du paramètre à vérifier (ex: "task_id")
+func TaskExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		calendarData, ok := common.GetCalendarFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		taskID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTaskID,
+			})
+			c.Abort()
+			return
+		}
+
+		var task common.Task
+		err = common.DB.QueryRow(`
+			SELECT task_id, calendar_id, event_id, title, description, due_date, completed, completed_at,
+			       priority, assignee_id, created_by, created_at, updated_at, deleted_at
+			FROM task
+			WHERE task_id = ? AND calendar_id = ? AND deleted_at IS NULL`,
+			taskID, calendarData.CalendarID,
+		).Scan(
+			&task.TaskID,
+			&task.CalendarID,
+			&task.EventID,
+			&task.Title,
+			&task.Description,
+			&task.DueDate,
+			&task.Completed,
+			&task.CompletedAt,
+			&task.Priority,
+			&task.AssigneeID,
+			&task.CreatedBy,
+			&task.CreatedAt,
+			&task.UpdatedAt,
+			&task.DeletedAt,
+		)
+
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrTaskNotFound, common.ErrTaskRetrieval) {
+			return
+		}
+
+		// La tâche existe, on l'ajoute au contexte et on continue
+		c.Set("task", task)
+		c.Next()
+	}
+}
+
+// ResourceExistsMiddleware vérifie l'existence d'une ressource réservable.
+// Les ressources sont visibles par tous les utilisateurs authentifiés.
+// paramName: nom du paramètre à vérifier (ex: "resource_id")
+func ResourceExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourceID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidResourceID,
+			})
+			c.Abort()
+			return
+		}
+
+		var resource common.Resource
+		err = common.DB.QueryRow(`
+			SELECT resource_id, name, kind, description, capacity, location, calendar_id, created_by, created_at, updated_at, deleted_at
+			FROM resource WHERE resource_id = ? AND deleted_at IS NULL`,
+			resourceID,
+		).Scan(
+			&resource.ResourceID,
+			&resource.Name,
+			&resource.Kind,
+			&resource.Description,
+			&resource.Capacity,
+			&resource.Location,
+			&resource.CalendarID,
+			&resource.CreatedBy,
+			&resource.CreatedAt,
+			&resource.UpdatedAt,
+			&resource.DeletedAt,
+		)
+
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrResourceNotFound, common.ErrResourceRetrieval) {
+			return
+		}
+
+		// La ressource existe, on l'ajoute au contexte et on continue
+		c.Set("resource", resource)
+		c.Next()
+	}
+}
+
+// bookingPageColumns liste les colonnes lues pour une page de réservation, dans l'ordre de loadBookingPage
+const bookingPageColumns = `booking_page_id, user_id, calendar_id, slug, title, description, duration, buffer_before, buffer_after,
+	max_per_day, min_notice, max_advance_days, timezone, active, created_at, updated_at, deleted_at`
+
+// loadBookingPage lit une page de réservation selon la condition donnée
+func loadBookingPage(condition string, args ...interface{}) (common.BookingPage, error) {
+	var page common.BookingPage
+	err := common.DB.QueryRow("SELECT "+bookingPageColumns+" FROM booking_page WHERE "+condition+" AND deleted_at IS NULL", args...).Scan(
+		&page.BookingPageID,
+		&page.UserID,
+		&page.CalendarID,
+		&page.Slug,
+		&page.Title,
+		&page.Description,
+		&page.Duration,
+		&page.BufferBefore,
+		&page.BufferAfter,
+		&page.MaxPerDay,
+		&page.MinNotice,
+		&page.MaxAdvanceDays,
+		&page.Timezone,
+		&page.Active,
+		&page.CreatedAt,
+		&page.UpdatedAt,
+		&page.DeletedAt,
+	)
+	return page, err
+}
+
+// BookingPageExistsMiddleware vérifie l'existence d'une page de réservation appartenant à l'utilisateur connecté.
+// Doit être placé après AuthMiddleware.
+// paramName: nom du paramètre à vérifier (ex: "booking_page_id")
+func BookingPageExistsMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData, ok := common.GetUserFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		pageID, err := strconv.Atoi(c.Param(paramName))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidBookingPageID,
+			})
+			c.Abort()
+			return
+		}
+
+		page, err := loadBookingPage("booking_page_id = ? AND user_id = ?", pageID, userData.UserID)
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrBookingPageNotFound, common.ErrBookingPageRetrieval) {
+			return
+		}
+
+		// La page existe, on l'ajoute au contexte et on continue
+		c.Set("booking_page", page)
+		c.Next()
+	}
+}
+
+// PublicBookingPageMiddleware charge une page de réservation active à partir de son identifiant public.
+// Utilisé sur les routes sans authentification : une page désactivée est traitée comme inexistante.
+// paramName: nom du paramètre à vérifier (ex: "slug")
+func PublicBookingPageMiddleware(paramName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := loadBookingPage("slug = ? AND active = TRUE", c.Param(paramName))
+		if common.HandleDBError(c, err, http.StatusNotFound, common.ErrBookingPageNotFound, common.ErrBookingPageRetrieval) {
+			return
+		}
+
+		c.Set("booking_page", page)
+		c.Next()
+	}
+}
+
+// rateLimitWindow compte les requêtes d'un client sur la fenêtre en cours
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimitMiddleware limite le nombre de requêtes par adresse IP sur une fenêtre fixe.
+// Les compteurs sont conservés en mémoire, propres à chaque route qui utilise le middleware
+// et donc à chaque instance de l'application.
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	windows := make(map[string]*rateLimitWindow)
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Purge des fenêtres expirées pour borner la mémoire utilisée
+		for key, w := range windows {
+			if now.Sub(w.start) >= window {
+				delete(windows, key)
+			}
+		}
+		w, ok := windows[ip]
+		if !ok {
+			w = &rateLimitWindow{start: now}
+			windows[ip] = w
+		}
+		w.count++
+		exceeded := w.count > limit
+		retryAfter := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			slog.Error(common.LogRateLimitExceeded, "ip", ip, "path", c.FullPath())
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTooManyRequests,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -609,40 +609,50 @@ func TestEventExistsMiddleware(t *testing.T) {
 	// TestCases contient les cas qui seront testés
 	var TestCases = []struct {
 		CaseName         string
-		SetupData        func() map[string]interface{}
-		URL              string
+		SetupData        func() (common.Calendar, string)
 		ExpectedHttpCode int
 		ExpectedError    string
 	}{
 		{
 			CaseName: "Événement existant",
-			SetupData: func() map[string]interface{} {
+			SetupData: func() (common.Calendar, string) {
 				// Créer un utilisateur avec calendrier et événement
 				user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
 				require.NoError(t, err)
-				return map[string]interface{}{
-					"user": user,
-				}
+				return *user.Calendar, fmt.Sprintf("%d", user.Event.EventID)
 			},
-			URL:              "/test-event/1",
 			ExpectedHttpCode: http.StatusOK,
 			ExpectedError:    "",
 		},
+		{
+			CaseName: "Événement d'un autre calendrier",
+			SetupData: func() (common.Calendar, string) {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return *user.Calendar, fmt.Sprintf("%d", other.Event.EventID)
+			},
+			ExpectedHttpCode: http.StatusNotFound,
+			ExpectedError:    common.ErrEventNotFound,
+		},
 		{
 			CaseName: "Événement inexistant",
-			SetupData: func() map[string]interface{} {
-				return map[string]interface{}{}
+			SetupData: func() (common.Calendar, string) {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return *user.Calendar, "99999"
 			},
-			URL:              "/test-event/99999",
 			ExpectedHttpCode: http.StatusNotFound,
 			ExpectedError:    common.ErrEventNotFound,
 		},
 		{
 			CaseName: "ID événement invalide",
-			SetupData: func() map[string]interface{} {
-				return map[string]interface{}{}
+			SetupData: func() (common.Calendar, string) {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				return *user.Calendar, "invalid"
 			},
-			URL:              "/test-event/invalid",
 			ExpectedHttpCode: http.StatusBadRequest,
 			ExpectedError:    common.ErrInvalidEventID,
 		},
@@ -651,11 +661,15 @@ func TestEventExistsMiddleware(t *testing.T) {
 	for _, testCase := range TestCases {
 		t.Run(testCase.CaseName, func(t *testing.T) {
 			// Préparer les données
-			testCase.SetupData()
+			calendar, eventID := testCase.SetupData()
 
-			// Créer un routeur de test avec le middleware
+			// Créer un routeur de test avec le middleware, le calendrier étant placé dans le contexte
+			// comme le ferait CalendarExistsMiddleware
 			router := gin.New()
-			router.GET("/test-event/:event_id", middleware.EventExistsMiddleware("event_id"), func(c *gin.Context) {
+			router.GET("/test-event/:event_id", func(c *gin.Context) {
+				c.Set("calendar", calendar)
+				c.Next()
+			}, middleware.EventExistsMiddleware("event_id"), func(c *gin.Context) {
 				event, exists := c.Get("event")
 				if exists {
 					c.JSON(http.StatusOK, gin.H{"event": event})
@@ -669,7 +683,7 @@ func TestEventExistsMiddleware(t *testing.T) {
 			defer server.Close()
 
 			// Faire la requête
-			req, err := http.NewRequest("GET", server.URL+testCase.URL, nil)
+			req, err := http.NewRequest("GET", server.URL+"/test-event/"+eventID, nil)
 			require.NoError(t, err)
 
 			resp, err := testClient.Do(req)
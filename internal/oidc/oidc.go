@@ -0,0 +1,247 @@
+// Package oidc internal/oidc/oidc.go
+// Client OpenID Connect : flux authorization code avec PKCE (RFC 7636) et vérification des ID tokens
+// signés par le fournisseur (RS256 ou ES256), dont les clés sont lues sur son point jwks_uri.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// httpTimeout est le délai maximal des appels au fournisseur
+	httpTimeout = 10 * time.Second
+	// maxResponseSize est la taille maximale lue dans une réponse du fournisseur
+	maxResponseSize = 1 << 20
+	// keysRefreshInterval est le délai minimal entre deux lectures des clés, pour suivre leur rotation
+	// sans qu'un jeton au kid inconnu ne déclenche un appel à chaque requête
+	keysRefreshInterval = time.Minute
+	// clockSkew est la tolérance appliquée aux dates des ID tokens
+	clockSkew = time.Minute
+)
+
+var (
+	// ErrInvalidToken est retournée pour un ID token mal formé, mal signé, expiré ou destiné à un autre client
+	ErrInvalidToken = errors.New("ID token invalide")
+	// ErrProvider est retournée quand le fournisseur est injoignable ou répond de façon inattendue
+	ErrProvider = errors.New("fournisseur d'identité indisponible ou réponse invalide")
+)
+
+// Provider est le fournisseur d'identité configuré
+type Provider struct {
+	Config common.OIDCConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// Default est le fournisseur de l'application ; nil quand la connexion SSO n'est pas configurée
+var Default *Provider
+
+// metadata est le document de découverte du fournisseur (OpenID Connect Discovery 1.0)
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// Init valide la configuration et initialise le fournisseur par défaut.
+// Le document de découverte n'est lu qu'à la première connexion : un fournisseur indisponible n'empêche pas le démarrage.
+func Init(cfg common.OIDCConfig) error {
+	if cfg.Issuer == "" {
+		Default = nil
+		return nil
+	}
+	issuer, err := url.Parse(cfg.Issuer)
+	if err != nil || issuer.Host == "" {
+		return fmt.Errorf("OIDC_ISSUER invalide : %q", cfg.Issuer)
+	}
+	if issuer.Scheme != "https" && !(issuer.Scheme == "http" && isLoopback(issuer.Hostname())) {
+		return fmt.Errorf("OIDC_ISSUER %q : https est obligatoire hors de localhost", cfg.Issuer)
+	}
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return errors.New("OIDC_CLIENT_ID et OIDC_REDIRECT_URL sont obligatoires")
+	}
+	if !containsString(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	Default = New(cfg)
+	return nil
+}
+
+// New crée un fournisseur pour la configuration, sans la valider
+func New(cfg common.OIDCConfig) *Provider {
+	return &Provider{Config: cfg, client: &http.Client{Timeout: httpTimeout}}
+}
+
+// AuthRequest regroupe les valeurs à usage unique d'une connexion, à conserver jusqu'au retour du fournisseur
+type AuthRequest struct {
+	State        string // Protège le retour contre la falsification de requête (CSRF)
+	Nonce        string // Lie l'ID token à cette connexion
+	CodeVerifier string // Secret PKCE dont l'empreinte est envoyée dans la requête d'autorisation
+}
+
+// NewAuthRequest génère les valeurs aléatoires d'une nouvelle connexion
+func NewAuthRequest() (AuthRequest, error) {
+	var values [3]string
+	for i := range values {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return AuthRequest{}, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(random)
+	}
+	return AuthRequest{State: values[0], Nonce: values[1], CodeVerifier: values[2]}, nil
+}
+
+// CodeChallenge retourne l'empreinte S256 du code verifier (RFC 7636)
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthorizationURL retourne l'URL du fournisseur vers laquelle rediriger l'utilisateur
+func (p *Provider) AuthorizationURL(ctx context.Context, req AuthRequest) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.Config.ClientID)
+	query.Set("redirect_uri", p.Config.RedirectURL)
+	query.Set("scope", strings.Join(p.Config.Scopes, " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", CodeChallenge(req.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange échange le code d'autorisation contre les jetons et retourne l'ID token, à vérifier avec VerifyIDToken
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if p.Config.ClientSecret == "" {
+		form.Set("client_id", p.Config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.Config.ClientSecret != "" {
+		// client_secret_basic : identifiant et secret encodés en application/x-www-form-urlencoded (RFC 6749, 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokens)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK || tokens.IDToken == "" {
+		return "", fmt.Errorf("%w : échange du code refusé (%d %s %s)", ErrProvider, status, tokens.Error, tokens.ErrorDescription)
+	}
+	return tokens.IDToken, nil
+}
+
+// discover lit et conserve le document de découverte du fournisseur
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	endpoint := strings.TrimRight(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	status, err := p.doJSON(req, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w : découverte en erreur (%d)", ErrProvider, status)
+	}
+	// L'émetteur annoncé doit être exactement celui configuré (OpenID Connect Discovery, 4.3)
+	if meta.Issuer != p.Config.Issuer || meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w : document de découverte incohérent", ErrProvider)
+	}
+	if len(meta.CodeChallengeMethods) > 0 && !containsString(meta.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("%w : PKCE S256 non pris en charge", ErrProvider)
+	}
+	p.metadata = &meta
+	return p.metadata, nil
+}
+
+// doJSON exécute la requête et décode la réponse JSON, quel que soit son code HTTP
+func (p *Provider) doJSON(req *http.Request, target interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w : %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, fmt.Errorf("%w : %v", ErrProvider, err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return 0, fmt.Errorf("%w : réponse illisible (%d)", ErrProvider, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// isLoopback indique si l'hôte désigne la machine locale
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// containsString indique si values contient value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
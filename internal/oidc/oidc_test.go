@@ -0,0 +1,140 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/base64"
+	"go-averroes/internal/common"
+	"go-averroes/internal/oidc"
+	"go-averroes/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newProvider démarre un fournisseur d'identité de test et retourne le client configuré pour lui
+func newProvider(t *testing.T) (*testutils.MockIdP, *oidc.Provider) {
+	idp, err := testutils.NewMockIdP("golendar", "secret")
+	require.NoError(t, err)
+	t.Cleanup(idp.Close)
+	return idp, oidc.New(common.OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     "golendar",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/auth/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+}
+
+// claims retourne des claims valides pour le fournisseur, complétés par extra
+func claims(idp *testutils.MockIdP, nonce string, extra map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	result := map[string]interface{}{
+		"iss":   idp.Issuer(),
+		"aud":   idp.ClientID,
+		"sub":   "42",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range extra {
+		result[name] = value
+	}
+	return result
+}
+
+// TestAuthorizationCodeFlow vérifie le flux complet : autorisation avec PKCE, échange du code et vérification de l'ID token
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp, provider := newProvider(t)
+	ctx := context.Background()
+
+	request, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	authorizationURL, err := provider.AuthorizationURL(ctx, request)
+	require.NoError(t, err)
+	require.Contains(t, authorizationURL, "code_challenge="+oidc.CodeChallenge(request.CodeVerifier))
+	require.NotContains(t, authorizationURL, request.CodeVerifier)
+
+	code, state, err := idp.Authorize(authorizationURL, map[string]interface{}{
+		"email":          "nour.haddad@example.com",
+		"email_verified": true,
+		"given_name":     "Nour",
+		"groups":         []string{"staff", "planning"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, request.State, state)
+
+	// Le code n'est échangeable qu'avec le bon code verifier
+	other, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, code, other.CodeVerifier)
+	require.ErrorIs(t, err, oidc.ErrProvider)
+
+	code, _, err = idp.Authorize(authorizationURL, map[string]interface{}{"email": "nour.haddad@example.com", "email_verified": true, "groups": []string{"staff", "planning"}})
+	require.NoError(t, err)
+	idToken, err := provider.Exchange(ctx, code, request.CodeVerifier)
+	require.NoError(t, err)
+
+	// Un ID token obtenu pour une autre connexion (nonce différent) est refusé
+	_, err = provider.VerifyIDToken(ctx, idToken, other.Nonce)
+	require.ErrorIs(t, err, oidc.ErrInvalidToken)
+
+	verified, err := provider.VerifyIDToken(ctx, idToken, request.Nonce)
+	require.NoError(t, err)
+	require.Equal(t, "nour.haddad@example.com", verified.Email)
+	require.True(t, *verified.EmailVerified)
+	require.Equal(t, []string{"staff", "planning"}, verified.Strings("groups"))
+	require.Nil(t, verified.Strings("roles"))
+}
+
+// TestVerifyIDTokenRejected vérifie le refus des ID tokens falsifiés, expirés ou destinés à un autre client
+func TestVerifyIDTokenRejected(t *testing.T) {
+	idp, provider := newProvider(t)
+	ctx := context.Background()
+
+	valid, err := idp.Sign(claims(idp, "n", nil))
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, valid, "n")
+	require.NoError(t, err)
+
+	for name, extra := range map[string]map[string]interface{}{
+		"autre client":    {"aud": "autre"},
+		"autre émetteur":  {"iss": "https://idp.example.com"},
+		"expiré":          {"exp": time.Now().Add(-time.Hour).Unix()},
+		"sans expiration": {"exp": nil},
+		"émis plus tard":  {"iat": time.Now().Add(time.Hour).Unix()},
+		"sans sub":        {"sub": ""},
+		"azp inattendu":   {"aud": []string{"golendar", "autre"}, "azp": "autre"},
+	} {
+		token, err := idp.Sign(claims(idp, "n", extra))
+		require.NoError(t, err)
+		_, err = provider.VerifyIDToken(ctx, token, "n")
+		require.ErrorIs(t, err, oidc.ErrInvalidToken, name)
+	}
+
+	// Contenu modifié après signature
+	parts := strings.Split(valid, ".")
+	forged, err := idp.Sign(claims(idp, "n", map[string]interface{}{"email": "admin@example.com"}))
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, parts[0]+"."+strings.Split(forged, ".")[1]+"."+parts[2], "n")
+	require.ErrorIs(t, err, oidc.ErrInvalidToken)
+
+	// Jeton non signé (alg none)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	_, err = provider.VerifyIDToken(ctx, header+"."+parts[1]+".", "n")
+	require.ErrorIs(t, err, oidc.ErrInvalidToken)
+}
+
+// TestInit vérifie la validation de la configuration
+func TestInit(t *testing.T) {
+	require.NoError(t, oidc.Init(common.OIDCConfig{}))
+	require.Nil(t, oidc.Default)
+
+	require.NoError(t, oidc.Init(common.OIDCConfig{Issuer: "https://idp.example.com", ClientID: "golendar", RedirectURL: "https://app.example.com/callback", Scopes: []string{"email"}}))
+	require.Equal(t, []string{"openid", "email"}, oidc.Default.Config.Scopes)
+
+	require.Error(t, oidc.Init(common.OIDCConfig{Issuer: "http://idp.example.com", ClientID: "golendar", RedirectURL: "https://app.example.com/callback"}))
+	require.Error(t, oidc.Init(common.OIDCConfig{Issuer: "https://idp.example.com", RedirectURL: "https://app.example.com/callback"}))
+	oidc.Default = nil
+}
@@ -0,0 +1,302 @@
+// Package oidc internal/oidc/token.go
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Claims sont les informations sur l'utilisateur contenues dans un ID token vérifié
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // nil si le fournisseur ne transmet pas le claim email_verified
+	GivenName     string
+	FamilyName    string
+	Name          string
+	raw           map[string]interface{}
+}
+
+// Strings retourne les valeurs textuelles d'un claim, chaîne ou tableau de chaînes (rôles, groupes)
+func (c *Claims) Strings(name string) []string {
+	switch value := c.raw[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var result []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// jwk est une clé publique du fournisseur (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// VerifyIDToken vérifie la signature et les claims de l'ID token (OpenID Connect Core, 3.1.3.7) :
+// émetteur, destinataire, expiration et nonce de la connexion en cours
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w : format JWS compact attendu", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	// Seuls les algorithmes asymétriques sont acceptés : ni "none" ni HMAC avec une clé publique
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return nil, fmt.Errorf("%w : algorithme %q refusé", ErrInvalidToken, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w : signature illisible", ErrInvalidToken)
+	}
+
+	key, err := p.key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verify(key, header.Alg, digest[:], signature) {
+		return nil, fmt.Errorf("%w : signature incorrecte", ErrInvalidToken)
+	}
+
+	var payload map[string]interface{}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, err
+	}
+	if err := p.verifyClaims(payload, nonce); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{raw: payload}
+	claims.Subject, _ = payload["sub"].(string)
+	claims.Email, _ = payload["email"].(string)
+	claims.GivenName, _ = payload["given_name"].(string)
+	claims.FamilyName, _ = payload["family_name"].(string)
+	claims.Name, _ = payload["name"].(string)
+	// Certains fournisseurs transmettent email_verified sous forme de chaîne
+	switch verified := payload["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = &verified
+	case string:
+		value := verified == "true"
+		claims.EmailVerified = &value
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w : claim sub absent", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// verifyClaims vérifie l'émetteur, le destinataire, les dates et le nonce
+func (p *Provider) verifyClaims(payload map[string]interface{}, nonce string) error {
+	if iss, _ := payload["iss"].(string); iss != p.Config.Issuer {
+		return fmt.Errorf("%w : émetteur %q inattendu", ErrInvalidToken, iss)
+	}
+
+	var audiences []string
+	switch aud := payload["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []interface{}:
+		for _, item := range aud {
+			if s, ok := item.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	if !containsString(audiences, p.Config.ClientID) {
+		return fmt.Errorf("%w : jeton destiné à un autre client", ErrInvalidToken)
+	}
+	if azp, ok := payload["azp"].(string); (ok || len(audiences) > 1) && azp != p.Config.ClientID {
+		return fmt.Errorf("%w : partie autorisée (azp) inattendue", ErrInvalidToken)
+	}
+
+	now := time.Now()
+	exp, ok := payload["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return fmt.Errorf("%w : jeton expiré", ErrInvalidToken)
+	}
+	if iat, ok := payload["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w : jeton émis dans le futur", ErrInvalidToken)
+	}
+
+	tokenNonce, _ := payload["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return fmt.Errorf("%w : nonce inattendu", ErrInvalidToken)
+	}
+	return nil
+}
+
+// key retourne la clé de vérification désignée par kid, en relisant les clés du fournisseur
+// si elle est inconnue (rotation), au plus une fois par keysRefreshInterval
+func (p *Provider) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	if key := p.cachedKey(kid, alg); key != nil {
+		return key, nil
+	}
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	refresh := time.Since(p.keysFetchedAt) >= keysRefreshInterval || p.keys == nil
+	p.mu.Unlock()
+	if !refresh {
+		return nil, fmt.Errorf("%w : clé %q inconnue", ErrInvalidToken, kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w : lecture des clés en erreur (%d)", ErrProvider, status)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key := parseJWK(k); key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	p.mu.Unlock()
+
+	if key := p.cachedKey(kid, alg); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w : clé %q inconnue", ErrInvalidToken, kid)
+}
+
+// cachedKey retourne la clé connue désignée par kid, du type attendu par l'algorithme.
+// Sans kid, la clé est retenue si elle est la seule de ce type.
+func (p *Provider) cachedKey(kid, alg string) crypto.PublicKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	matches := func(key crypto.PublicKey) bool {
+		switch key.(type) {
+		case *rsa.PublicKey:
+			return alg == "RS256"
+		case *ecdsa.PublicKey:
+			return alg == "ES256"
+		}
+		return false
+	}
+	if kid != "" {
+		if key, ok := p.keys[kid]; ok && matches(key) {
+			return key
+		}
+		return nil
+	}
+	var found crypto.PublicKey
+	for _, key := range p.keys {
+		if matches(key) {
+			if found != nil {
+				return nil
+			}
+			found = key
+		}
+	}
+	return found
+}
+
+// parseJWK convertit une clé RSA ou EC P-256 ; les autres types sont ignorés
+func parseJWK(k jwk) crypto.PublicKey {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < 2048 {
+			return nil
+		}
+		return &rsa.PublicKey{N: modulus, E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+		y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+		if err1 != nil || err2 != nil || k.Crv != "P-256" || len(x) != 32 || len(y) != 32 {
+			return nil
+		}
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{0x04}, x...), y...)); err != nil {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	}
+	return nil
+}
+
+// verify vérifie la signature JWS du condensé SHA-256
+func verify(key crypto.PublicKey, alg string, digest, signature []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// Signature JWS ES256 : r et s concaténés sur 32 octets chacun (RFC 7518, 3.4)
+		if alg != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+// decodeSegment décode un segment base64url JSON du jeton
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w : segment illisible", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%w : segment illisible", ErrInvalidToken)
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+// Package passkey internal/passkey/passkey.go
+package passkey
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/webauthn"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasskeyStruct struct{}
+
+var Passkey = PasskeyStruct{}
+
+const (
+	// ceremonyRegistration et ceremonyAuthentication sont les cérémonies enregistrées dans passkey_challenge
+	ceremonyRegistration   = "registration"
+	ceremonyAuthentication = "authentication"
+	// defaultName est le nom donné à une passkey enregistrée sans nom
+	defaultName = "Passkey"
+)
+
+// ErrInvalidAssertion est retournée pour une réponse d'authentificateur invalide, une passkey inconnue ou un challenge expiré
+var ErrInvalidAssertion = errors.New(common.ErrInvalidPasskey)
+
+// Authenticate vérifie la réponse à navigator.credentials.get() et retourne l'utilisateur authentifié.
+// Le challenge est consommé même en cas d'échec ; le compteur de signatures de la passkey est mis à jour.
+// Pour une passkey connue dont la signature est refusée, ErrInvalidAssertion accompagne l'utilisateur de la passkey.
+func Authenticate(req common.PasskeyLoginRequest) (int, error) {
+	credentialID, err1 := webauthn.Encoding.DecodeString(req.ID)
+	clientDataJSON, err2 := webauthn.Encoding.DecodeString(req.Response.ClientDataJSON)
+	authenticatorData, err3 := webauthn.Encoding.DecodeString(req.Response.AuthenticatorData)
+	signature, err4 := webauthn.Encoding.DecodeString(req.Response.Signature)
+	userHandle, err5 := webauthn.Encoding.DecodeString(req.Response.UserHandle)
+	if err := errors.Join(err1, err2, err3, err4, err5); err != nil {
+		return 0, ErrInvalidAssertion
+	}
+
+	challenge, err := consumeChallenge(clientDataJSON, ceremonyAuthentication, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var passkeyID, userID int
+	var credential webauthn.Credential
+	err = common.DB.QueryRow(`
+		SELECT p.passkey_id, p.user_id, p.public_key, p.sign_count
+		FROM passkey p
+		INNER JOIN user u ON u.user_id = p.user_id
+		WHERE p.credential_id = ? AND u.deleted_at IS NULL
+	`, credentialID).Scan(&passkeyID, &userID, &credential.PublicKey, &credential.SignCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidAssertion
+	}
+	if err != nil {
+		return 0, err
+	}
+	// L'identifiant de compte renvoyé par l'authentificateur doit être celui de la passkey
+	if len(userHandle) > 0 && !bytes.Equal(userHandle, handle(userID)) {
+		return userID, ErrInvalidAssertion
+	}
+
+	signCount, err := webauthn.Default.VerifyAssertion(challenge, credential, clientDataJSON, authenticatorData, signature)
+	if errors.Is(err, webauthn.ErrSignCount) {
+		slog.Warn(common.LogPasskeySignCount, "passkey_id", passkeyID, "user_id", userID)
+		return userID, ErrInvalidAssertion
+	}
+	if err != nil {
+		slog.Error(common.LogPasskeyLogin + " - " + err.Error())
+		return userID, ErrInvalidAssertion
+	}
+
+	// Le compteur lu est comparé pour que deux assertions simultanées ne soient pas acceptées toutes les deux
+	result, err := common.DB.Exec(`
+		UPDATE passkey SET sign_count = ?, last_used_at = NOW() WHERE passkey_id = ? AND sign_count = ?
+	`, signCount, passkeyID, credential.SignCount)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 && signCount != 0 {
+		return userID, ErrInvalidAssertion
+	}
+	return userID, nil
+}
+
+// LoginOptions génère les options de connexion par passkey
+// @Summary Options de connexion par passkey
+// @Description Génère un challenge (valable 5 minutes, usage unique) et les options à passer à navigator.credentials.get(). La réponse du navigateur est à envoyer sur /auth/passkeys/login.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=webauthn.RequestOptions}
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/passkeys/login/options [post]
+func (PasskeyStruct) LoginOptions(c *gin.Context) {
+	slog.Info(common.LogPasskeyLoginOptions)
+	challenge, err := issueChallenge(ceremonyAuthentication, nil)
+	if err != nil {
+		slog.Error(common.LogPasskeyLoginOptions + " - erreur lors de la création du challenge : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+
+	slog.Info(common.LogPasskeyLoginOptions + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessPasskeyOptions,
+		Data:    webauthn.Default.RequestOptions(challenge),
+	})
+}
+
+// RegisterOptions génère les options d'enregistrement d'une passkey pour l'utilisateur connecté
+// @Summary Options d'enregistrement d'une passkey
+// @Description Génère un challenge (valable 5 minutes, usage unique) et les options à passer à navigator.credentials.create(). Les passkeys déjà enregistrées sont exclues.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=webauthn.CreationOptions}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /auth/passkeys/register/options [post]
+func (PasskeyStruct) RegisterOptions(c *gin.Context) {
+	slog.Info(common.LogPasskeyRegisterOptions)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	rows, err := common.DB.Query(`SELECT credential_id FROM passkey WHERE user_id = ?`, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPasskeyRegisterOptions + " - erreur lors de la récupération des passkeys : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+	defer rows.Close()
+	var exclude [][]byte
+	for rows.Next() {
+		var credentialID []byte
+		if err := rows.Scan(&credentialID); err != nil {
+			slog.Error(common.LogPasskeyRegisterOptions + " - erreur lors de la lecture des passkeys : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrPasskey,
+			})
+			return
+		}
+		exclude = append(exclude, credentialID)
+	}
+
+	challenge, err := issueChallenge(ceremonyRegistration, &userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPasskeyRegisterOptions + " - erreur lors de la création du challenge : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+
+	displayName := strings.TrimSpace(userData.Firstname + " " + userData.Lastname)
+	slog.Info(common.LogPasskeyRegisterOptions + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessPasskeyOptions,
+		Data:    webauthn.Default.CreationOptions(challenge, handle(userData.UserID), userData.Email, displayName, exclude),
+	})
+}
+
+// Register enregistre la passkey créée par le navigateur
+// @Summary Enregistrer une passkey
+// @Description Vérifie la réponse de navigator.credentials.create() obtenue avec les options de /auth/passkeys/register/options et enregistre la passkey, utilisable ensuite pour se connecter sans mot de passe.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.PasskeyRegisterRequest true "Nom et réponse du navigateur"
+// @Success 201 {object} common.JSONResponse{data=common.Passkey}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /auth/passkeys/register [post]
+func (PasskeyStruct) Register(c *gin.Context) {
+	slog.Info(common.LogPasskeyRegister)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogPasskeyRegister + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	clientDataJSON, err1 := webauthn.Encoding.DecodeString(req.Credential.Response.ClientDataJSON)
+	attestationObject, err2 := webauthn.Encoding.DecodeString(req.Credential.Response.AttestationObject)
+	if err := errors.Join(err1, err2); err != nil {
+		slog.Error(common.LogPasskeyRegister + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	challenge, err := consumeChallenge(clientDataJSON, ceremonyRegistration, &userData.UserID)
+	if errors.Is(err, ErrInvalidAssertion) {
+		slog.Error(common.LogPasskeyRegister + " - challenge invalide ou expiré")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPasskey,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogPasskeyRegister + " - erreur lors de la consommation du challenge : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+
+	credential, err := webauthn.Default.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err == nil && webauthn.Encoding.EncodeToString(credential.ID) != req.Credential.ID {
+		err = errors.New("identifiant différent de celui de authenticatorData")
+	}
+	if err != nil {
+		slog.Error(common.LogPasskeyRegister + " - " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPasskey,
+		})
+		return
+	}
+
+	passkey := common.Passkey{
+		UserID:    userData.UserID,
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now(),
+	}
+	if passkey.Name == "" {
+		passkey.Name = defaultName
+	}
+	result, err := common.DB.Exec(`
+		INSERT INTO passkey (user_id, credential_id, public_key, sign_count, name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, passkey.UserID, credential.ID, credential.PublicKey, credential.SignCount, passkey.Name, passkey.CreatedAt)
+	if err != nil && strings.Contains(err.Error(), "Duplicate entry") {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskeyAlreadyRegistered,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogPasskeyRegister + " - erreur lors de l'enregistrement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+	passkey.PasskeyID = int(id)
+
+	slog.Info(common.LogPasskeyRegister + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessPasskeyRegistered,
+		Data:    passkey,
+	})
+}
+
+// List retourne les passkeys de l'utilisateur connecté
+// @Summary Lister ses passkeys
+// @Description Retourne les passkeys enregistrées par l'utilisateur connecté, avec leur date de dernière utilisation
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=[]common.Passkey}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /auth/passkeys [get]
+func (PasskeyStruct) List(c *gin.Context) {
+	slog.Info(common.LogPasskeyList)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	rows, err := common.DB.Query(`
+		SELECT passkey_id, user_id, name, last_used_at, created_at
+		FROM passkey
+		WHERE user_id = ?
+		ORDER BY created_at, passkey_id
+	`, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPasskeyList + " - erreur lors de la récupération des passkeys : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+	defer rows.Close()
+
+	passkeys := []common.Passkey{}
+	for rows.Next() {
+		var passkey common.Passkey
+		if err := rows.Scan(&passkey.PasskeyID, &passkey.UserID, &passkey.Name, &passkey.LastUsedAt, &passkey.CreatedAt); err != nil {
+			slog.Error(common.LogPasskeyList + " - erreur lors de la lecture des passkeys : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrPasskey,
+			})
+			return
+		}
+		passkeys = append(passkeys, passkey)
+	}
+
+	slog.Info(common.LogPasskeyList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListPasskeys,
+		Data:    passkeys,
+	})
+}
+
+// Delete supprime une passkey de l'utilisateur connecté
+// @Summary Supprimer une passkey
+// @Description Supprime la passkey, qui ne permet plus de se connecter. Elle reste à supprimer de l'authentificateur.
+// @Tags Auth
+// @Produce json
+// @Param passkey_id path int true "ID de la passkey"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /auth/passkeys/{passkey_id} [delete]
+func (PasskeyStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogPasskeyDelete)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	passkeyID, err := strconv.Atoi(c.Param("passkey_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPasskeyID,
+		})
+		return
+	}
+
+	result, err := common.DB.Exec("DELETE FROM passkey WHERE passkey_id = ? AND user_id = ?", passkeyID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPasskeyDelete + " - erreur lors de la suppression de la passkey : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskeyNotFound,
+		})
+		return
+	}
+
+	slog.Info(common.LogPasskeyDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessPasskeyDeleted,
+	})
+}
+
+// issueChallenge crée un challenge pour la cérémonie ; userID est nil pour une connexion (utilisateur encore inconnu)
+func issueChallenge(ceremony string, userID *int) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	_, err = common.DB.Exec(`
+		INSERT INTO passkey_challenge (user_id, ceremony, challenge_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, userID, ceremony, common.HashToken(challenge), time.Now().Add(webauthn.Timeout))
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge marque comme utilisé le challenge signé dans clientDataJSON et le retourne.
+// ErrInvalidAssertion est retournée si le challenge est inconnu, expiré, déjà utilisé ou émis pour une autre cérémonie.
+func consumeChallenge(clientDataJSON []byte, ceremony string, userID *int) (string, error) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return "", ErrInvalidAssertion
+	}
+	result, err := common.DB.Exec(`
+		UPDATE passkey_challenge SET used_at = NOW()
+		WHERE challenge_hash = ? AND ceremony = ? AND user_id <=> ? AND used_at IS NULL AND expires_at > NOW()
+	`, common.HashToken(challenge), ceremony, userID)
+	if err != nil {
+		return "", err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return "", ErrInvalidAssertion
+	}
+	return challenge, nil
+}
+
+// handle retourne l'identifiant de compte (user handle) transmis à l'authentificateur : l'ID de l'utilisateur
+// sur 8 octets, qui ne contient aucune donnée personnelle
+func handle(userID int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
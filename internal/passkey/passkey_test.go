@@ -0,0 +1,203 @@
+package passkey_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/webauthn"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// registrationOptions demande les options d'enregistrement d'une passkey
+func registrationOptions(t *testing.T, token string) webauthn.CreationOptions {
+	status, response := testutils.DoRequest(t, testServer, token, "POST", "/auth/passkeys/register/options", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var options webauthn.CreationOptions
+	require.NoError(t, json.Unmarshal(response.Data, &options))
+	return options
+}
+
+// registrationRequest construit la requête d'enregistrement à partir de la réponse de l'authentificateur
+func registrationRequest(authenticator *testutils.SoftAuthenticator, challenge string) gin.H {
+	clientDataJSON, attestationObject := authenticator.Register(challenge)
+	return gin.H{
+		"name": "Clé de sécurité",
+		"credential": gin.H{
+			"id":   authenticator.CredentialID(),
+			"type": "public-key",
+			"response": gin.H{
+				"clientDataJSON":    clientDataJSON,
+				"attestationObject": attestationObject,
+			},
+		},
+	}
+}
+
+// loginRequest demande un challenge de connexion et construit la requête signée par l'authentificateur
+func loginRequest(t *testing.T, authenticator *testutils.SoftAuthenticator, userHandle string) gin.H {
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login/options", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var options webauthn.RequestOptions
+	require.NoError(t, json.Unmarshal(response.Data, &options))
+	require.Equal(t, "localhost", options.RPID)
+	require.Empty(t, options.AllowCredentials)
+
+	clientDataJSON, authenticatorData, signature, err := authenticator.Assert(options.Challenge)
+	require.NoError(t, err)
+	return gin.H{
+		"id":   authenticator.CredentialID(),
+		"type": "public-key",
+		"response": gin.H{
+			"clientDataJSON":    clientDataJSON,
+			"authenticatorData": authenticatorData,
+			"signature":         signature,
+			"userHandle":        userHandle,
+		},
+	}
+}
+
+// TestPasskeyLifecycle vérifie l'enregistrement, la connexion sans mot de passe et la suppression d'une passkey
+func TestPasskeyLifecycle(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	authenticator, err := testutils.NewSoftAuthenticator("localhost", "http://localhost:3000")
+	require.NoError(t, err)
+
+	// Enregistrement
+	options := registrationOptions(t, user.SessionToken)
+	require.Equal(t, "localhost", options.RP.ID)
+	require.Equal(t, user.User.Email, options.User.Name)
+	require.Empty(t, options.ExcludeCredentials)
+	require.Equal(t, "required", options.AuthenticatorSelection.UserVerification)
+
+	request := registrationRequest(authenticator, options.Challenge)
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/passkeys/register", request)
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created common.Passkey
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+	require.Equal(t, "Clé de sécurité", created.Name)
+
+	// Le challenge est à usage unique
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/passkeys/register", request)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidPasskey, response.Error)
+
+	// Une passkey déjà enregistrée est exclue puis refusée
+	options = registrationOptions(t, user.SessionToken)
+	require.Len(t, options.ExcludeCredentials, 1)
+	require.Equal(t, authenticator.CredentialID(), options.ExcludeCredentials[0].ID)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/passkeys/register", registrationRequest(authenticator, options.Challenge))
+	require.Equal(t, http.StatusConflict, status)
+
+	// Connexion sans mot de passe
+	userHandle := options.User.ID
+	request = loginRequest(t, authenticator, userHandle)
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", request)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var session common.LoginResponse
+	require.NoError(t, json.Unmarshal(response.Data, &session))
+	require.NotEmpty(t, session.SessionToken)
+	require.Equal(t, user.User.UserID, session.User.UserID)
+
+	// Rejeu de la même assertion
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", request)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrInvalidPasskey, response.Error)
+
+	// Compteur de signatures en recul : passkey clonée
+	clone := *authenticator
+	clone.SignCount = 0
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", loginRequest(t, &clone, userHandle))
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// Identifiant de compte d'un autre utilisateur
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", loginRequest(t, authenticator, webauthn.Encoding.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0})))
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// Les deux signatures refusées d'une passkey connue comptent parmi les échecs du compte
+	var failures, successes int
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT COUNT(*) FROM security_event WHERE user_id = ? AND event_type = 'login_failed' AND details LIKE 'passkey%'
+	`, user.User.UserID).Scan(&failures))
+	require.Equal(t, 2, failures)
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT COUNT(*) FROM security_event WHERE user_id = ? AND event_type = 'login_succeeded' AND details = 'passkey'
+	`, user.User.UserID).Scan(&successes))
+	require.Equal(t, 1, successes)
+
+	status, response = testutils.DoRequest(t, testServer, session.SessionToken, "GET", "/auth/passkeys", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var passkeys []common.Passkey
+	require.NoError(t, json.Unmarshal(response.Data, &passkeys))
+	require.Len(t, passkeys, 1)
+	require.NotNil(t, passkeys[0].LastUsedAt)
+
+	// Suppression : réservée au propriétaire, la passkey ne permet plus de se connecter
+	other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	url := "/auth/passkeys/" + testutils.Itoa(created.PasskeyID)
+	status, _ = testutils.DoRequest(t, testServer, other.SessionToken, "DELETE", url, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", url, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", loginRequest(t, authenticator, userHandle))
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestPasskeyChallengeCeremony vérifie qu'un challenge d'enregistrement ne permet pas de se connecter
+func TestPasskeyChallengeCeremony(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	authenticator, err := testutils.NewSoftAuthenticator("localhost", "http://localhost:3000")
+	require.NoError(t, err)
+
+	options := registrationOptions(t, user.SessionToken)
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/passkeys/register", registrationRequest(authenticator, options.Challenge))
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	options = registrationOptions(t, user.SessionToken)
+	clientDataJSON, authenticatorData, signature, err := authenticator.Assert(options.Challenge)
+	require.NoError(t, err)
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/passkeys/login", gin.H{
+		"id":   authenticator.CredentialID(),
+		"type": "public-key",
+		"response": gin.H{
+			"clientDataJSON":    clientDataJSON,
+			"authenticatorData": authenticatorData,
+			"signature":         signature,
+		},
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrInvalidPasskey, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,230 @@
+// Package password_reset internal/password_reset/password_reset.go
+package password_reset
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/mailer"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordResetStruct struct{}
+
+var PasswordReset = PasswordResetStruct{}
+
+const (
+	// tokenTTL est la durée de validité d'un lien de réinitialisation
+	tokenTTL = time.Hour
+	// maxRequestsPerHour borne le nombre d'e-mails envoyés à un même compte, en plus de la limite par IP
+	maxRequestsPerHour = 3
+)
+
+// Request envoie un lien de réinitialisation du mot de passe
+// @Summary Demander la réinitialisation du mot de passe
+// @Description Envoie un lien de réinitialisation (valable 1 heure, à usage unique) si un compte correspond à l'adresse. La réponse est identique que le compte existe ou non.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.PasswordResetRequest true "Adresse e-mail du compte"
+// @Success 202 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/password-reset/request [post]
+func (PasswordResetStruct) Request(c *gin.Context) {
+	slog.Info(common.LogPasswordResetRequest)
+	var req common.PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogPasswordResetRequest + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	// Les erreurs sont journalisées mais jamais renvoyées : la réponse ne doit pas dépendre de l'existence du compte
+	if err := issueToken(req.Email); err != nil {
+		slog.Error(common.LogPasswordResetRequest + " - erreur lors de la création du jeton : " + err.Error())
+	}
+
+	c.JSON(http.StatusAccepted, common.JSONResponse{
+		Success: true,
+		Message: common.MsgPasswordResetRequested,
+	})
+}
+
+// Confirm définit un nouveau mot de passe à partir du jeton reçu par e-mail
+// @Summary Réinitialiser le mot de passe
+// @Description Remplace le mot de passe du compte associé au jeton. Le jeton est consommé et toutes les sessions de l'utilisateur sont révoquées. Un compte sans mot de passe local (annuaire LDAP ou SSO) est refusé (409) sans consommer le jeton.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.PasswordResetConfirmRequest true "Jeton reçu par e-mail et nouveau mot de passe"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/password-reset/confirm [post]
+func (PasswordResetStruct) Confirm(c *gin.Context) {
+	slog.Info(common.LogPasswordResetConfirm)
+	var req common.PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogPasswordResetConfirm + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogPasswordResetConfirm + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasswordReset,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Le verrou empêche deux requêtes concurrentes de consommer le même jeton
+	var userID int
+	err = tx.QueryRow(`
+		SELECT prt.user_id
+		FROM password_reset_token prt
+		INNER JOIN user u ON u.user_id = prt.user_id
+		WHERE prt.token_hash = ? AND prt.used_at IS NULL AND prt.expires_at > NOW() AND u.deleted_at IS NULL
+		FOR UPDATE
+	`, common.HashToken(req.Token)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Error(common.LogPasswordResetConfirm + " - jeton invalide, expiré ou déjà utilisé")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPasswordResetToken,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogPasswordResetConfirm + " - erreur lors de la vérification du jeton : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasswordReset,
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.Error(common.LogPasswordResetConfirm + " - erreur lors du hashage du mot de passe : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasswordHashing,
+		})
+		return
+	}
+
+	// Nouveau mot de passe, jetons de l'utilisateur consommés (y compris les liens plus anciens), sessions révoquées
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE user_password SET password_hash = ?, updated_at = NOW() WHERE user_id = ? AND deleted_at IS NULL`, []interface{}{string(hashedPassword), userID}},
+		{`UPDATE password_reset_token SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL`, []interface{}{userID}},
+		{`UPDATE user_session SET is_active = FALSE, updated_at = NOW() WHERE user_id = ? AND is_active = TRUE`, []interface{}{userID}},
+	}
+	for i, statement := range statements {
+		result, err := tx.Exec(statement.query, statement.args...)
+		if err != nil {
+			slog.Error(common.LogPasswordResetConfirm + " - erreur lors de la mise à jour : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrPasswordReset,
+			})
+			return
+		}
+		// Compte sans mot de passe local (annuaire LDAP ou SSO) : le jeton n'est pas consommé
+		if affected, _ := result.RowsAffected(); i == 0 && affected == 0 {
+			slog.Error(common.LogPasswordResetConfirm + " - compte sans mot de passe local")
+			c.JSON(http.StatusConflict, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrPasswordManagedExternally,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogPasswordResetConfirm + " - erreur lors du commit : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasswordReset,
+		})
+		return
+	}
+
+	slog.Info(common.LogPasswordResetConfirm + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessPasswordReset,
+	})
+}
+
+// issueToken crée un jeton pour le compte correspondant à l'adresse et envoie le lien par e-mail.
+// Rien n'est fait si le compte n'existe pas, n'a pas de mot de passe local (annuaire LDAP ou SSO)
+// ou si trop de liens ont été envoyés dans l'heure.
+func issueToken(email string) error {
+	var userID int
+	var firstname string
+	err := common.DB.QueryRow(`
+		SELECT u.user_id, u.firstname
+		FROM user u
+		INNER JOIN user_password up ON up.user_id = u.user_id AND up.deleted_at IS NULL
+		WHERE u.email = ? AND u.deleted_at IS NULL
+	`, email).Scan(&userID, &firstname)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var recent int
+	if err := common.DB.QueryRow(`
+		SELECT COUNT(*) FROM password_reset_token WHERE user_id = ? AND created_at > ?
+	`, userID, time.Now().Add(-time.Hour)).Scan(&recent); err != nil {
+		return err
+	}
+	if recent >= maxRequestsPerHour {
+		slog.Info(common.LogPasswordResetRequest+" - limite d'envoi atteinte pour ce compte", "user_id", userID)
+		return nil
+	}
+
+	token, err := common.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	if _, err := common.DB.Exec(`
+		INSERT INTO password_reset_token (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, NOW())
+	`, userID, common.HashToken(token), time.Now().Add(tokenTTL)); err != nil {
+		return err
+	}
+
+	mailer.SendAsync(mailer.Message{
+		To:      email,
+		Subject: "Réinitialisation de votre mot de passe",
+		Body: "Bonjour " + firstname + ",\n\n" +
+			"Pour choisir un nouveau mot de passe, ouvrez le lien suivant (valable 1 heure) :\n" +
+			mailer.Link("/reset-password", token) + "\n\n" +
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet e-mail : votre mot de passe reste inchangé.\n",
+	})
+	slog.Info(common.LogPasswordResetRequest+" - lien envoyé", "user_id", userID)
+	return nil
+}
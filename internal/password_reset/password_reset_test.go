@@ -0,0 +1,171 @@
+package password_reset_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-averroes/internal/common"
+	"go-averroes/internal/mailer"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// tokenPattern extrait le jeton du lien envoyé par e-mail
+var tokenPattern = regexp.MustCompile(`token=([0-9a-f]{64})`)
+
+// sentMessages retourne les e-mails envoyés à l'adresse par le mailer de test
+func sentMessages(email string) []mailer.Message {
+	return mailer.Default.(*mailer.MemoryMailer).Messages(email)
+}
+
+// insertToken enregistre directement en base l'empreinte d'un jeton pour l'utilisateur
+func insertToken(t *testing.T, userID int, token string, expiresAt time.Time) {
+	sum := sha256.Sum256([]byte(token))
+	_, err := common.DB.Exec(`
+		INSERT INTO password_reset_token (user_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, NOW())
+	`, userID, hex.EncodeToString(sum[:]), expiresAt)
+	require.NoError(t, err)
+}
+
+// TestPasswordResetFlow vérifie la demande, l'usage unique du jeton et la révocation des sessions
+func TestPasswordResetFlow(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	// Adresse inconnue : même réponse, aucun e-mail
+	unknown := "inconnu-" + user.User.Email
+	status, unknownResponse := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": unknown})
+	require.Equal(t, http.StatusAccepted, status)
+
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": user.User.Email})
+	require.Equal(t, http.StatusAccepted, status)
+	require.Equal(t, unknownResponse, response)
+
+	require.Eventually(t, func() bool { return len(sentMessages(user.User.Email)) == 1 }, 2*time.Second, 20*time.Millisecond)
+	require.Empty(t, sentMessages(unknown))
+	match := tokenPattern.FindStringSubmatch(sentMessages(user.User.Email)[0].Body)
+	require.NotNil(t, match, "lien de réinitialisation absent de l'e-mail")
+	token := match[1]
+
+	// Jeton inconnu
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/confirm", gin.H{"token": strings.Repeat("ab", 32), "password": "NouveauMotDePasse1!"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidPasswordResetToken, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/confirm", gin.H{"token": token, "password": "NouveauMotDePasse1!"})
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Les sessions ouvertes avant la réinitialisation sont révoquées
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/login", gin.H{"email": user.User.Email, "password": user.Password})
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/login", gin.H{"email": user.User.Email, "password": "NouveauMotDePasse1!"})
+	require.Equal(t, http.StatusOK, status)
+
+	// Le jeton est à usage unique
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/confirm", gin.H{"token": token, "password": "EncoreUnAutre2!"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidPasswordResetToken, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestPasswordResetExpiredToken vérifie le refus d'un jeton expiré
+func TestPasswordResetExpiredToken(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	token := strings.Repeat("cd", 32)
+	insertToken(t, user.User.UserID, token, time.Now().Add(-time.Minute))
+
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/confirm", gin.H{"token": token, "password": "NouveauMotDePasse1!"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidPasswordResetToken, response.Error)
+
+	// La session n'est pas touchée
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestPasswordResetWithoutLocalPassword vérifie qu'un compte sans mot de passe local (annuaire ou SSO)
+// ne reçoit pas de mot de passe par réinitialisation et que le jeton n'est pas consommé
+func TestPasswordResetWithoutLocalPassword(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	_, err = common.DB.Exec("DELETE FROM user_password WHERE user_id = ?", user.User.UserID)
+	require.NoError(t, err)
+	token := strings.Repeat("ef", 32)
+	insertToken(t, user.User.UserID, token, time.Now().Add(time.Hour))
+
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/confirm", gin.H{"token": token, "password": "NouveauMotDePasse1!"})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrPasswordManagedExternally, response.Error)
+
+	var unused int
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT COUNT(*) FROM password_reset_token WHERE user_id = ? AND used_at IS NULL
+	`, user.User.UserID).Scan(&unused))
+	require.Equal(t, 1, unused)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestPasswordResetRateLimit vérifie la limite par compte (silencieuse) puis la limite par adresse IP
+// (exécuté en dernier : il épuise le quota de l'adresse IP des tests)
+func TestPasswordResetRateLimit(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		insertToken(t, user.User.UserID, strings.Repeat(string(rune('0'+i)), 64), time.Now().Add(time.Hour))
+	}
+
+	// Quota du compte atteint : la réponse est inchangée mais aucun e-mail n'est envoyé
+	status, _ := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": user.User.Email})
+	require.Equal(t, http.StatusAccepted, status)
+	require.Never(t, func() bool { return len(sentMessages(user.User.Email)) > 0 }, 300*time.Millisecond, 20*time.Millisecond)
+
+	// Quota de l'adresse IP : 5 demandes par fenêtre, déjà 3 consommées par les tests précédents
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": "quota1@example.com"})
+	require.Equal(t, http.StatusAccepted, status)
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": "quota2@example.com"})
+	require.Equal(t, http.StatusAccepted, status)
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/password-reset/request", gin.H{"email": "quota3@example.com"})
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, common.ErrTooManyRequests, response.Error)
+	require.NotEmpty(t, response.Header.Get("Retry-After"))
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,336 @@
+// Package push_subscription internal/push_subscription/push_subscription.go
+package push_subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/webpush"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PushSubscriptionStruct struct{}
+
+var PushSubscription = PushSubscriptionStruct{}
+
+// defaultTTL est la durée de conservation d'une notification par le service de push si le navigateur est hors ligne
+const defaultTTL = 24 * time.Hour
+
+// VAPIDPublicKey retourne la clé publique VAPID à utiliser comme applicationServerKey
+// @Summary Récupérer la clé publique VAPID
+// @Description Retourne la clé publique (base64url) à passer à pushManager.subscribe({applicationServerKey}) côté navigateur
+// @Tags Notifications push
+// @Produce json
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /push/vapid-public-key [get]
+func (PushSubscriptionStruct) VAPIDPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetVAPIDPublicKey,
+		Data: gin.H{
+			"public_key": webpush.Default.PublicKey(),
+		},
+	})
+}
+
+// List liste les abonnements push de l'utilisateur connecté
+// @Summary Lister mes abonnements push
+// @Description Liste les navigateurs abonnés aux notifications de l'utilisateur connecté
+// @Tags Notifications push
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=[]common.PushSubscription}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /push/subscriptions [get]
+func (PushSubscriptionStruct) List(c *gin.Context) {
+	slog.Info(common.LogPushSubscriptionList)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := loadSubscriptions(userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPushSubscriptionList + " - erreur lors de la récupération des abonnements : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionRetrieval,
+		})
+		return
+	}
+	result := []common.PushSubscription{}
+	for _, subscription := range subscriptions {
+		result = append(result, subscription.PushSubscription)
+	}
+
+	slog.Info(common.LogPushSubscriptionList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListPushSubscriptions,
+		Data:    result,
+	})
+}
+
+// Add enregistre l'abonnement push du navigateur
+// @Summary Enregistrer un abonnement push
+// @Description Enregistre l'abonnement retourné par pushManager.subscribe() (PushSubscription.toJSON()). Un abonnement déjà connu (même endpoint) est mis à jour et rattaché à l'utilisateur connecté. L'endpoint doit être une URL https publique : localhost, boucle locale, réseaux privés et lien local sont refusés.
+// @Tags Notifications push
+// @Accept json
+// @Produce json
+// @Param subscription body common.CreatePushSubscriptionRequest true "Abonnement du navigateur"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /push/subscriptions [post]
+func (PushSubscriptionStruct) Add(c *gin.Context) {
+	slog.Info(common.LogPushSubscriptionAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreatePushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogPushSubscriptionAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	if err := webpush.ValidateSubscription(webpush.Subscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}); err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPushSubscription,
+		})
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpirationTime != nil {
+		t := time.UnixMilli(*req.ExpirationTime)
+		expiresAt = &t
+	}
+	var userAgent *string
+	if value := c.GetHeader("User-Agent"); value != "" {
+		if len(value) > 255 {
+			value = value[:255]
+		}
+		userAgent = &value
+	}
+
+	_, err := common.DB.Exec(`
+		INSERT INTO push_subscription (user_id, endpoint, endpoint_hash, p256dh, auth, user_agent, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			user_id = VALUES(user_id), p256dh = VALUES(p256dh), auth = VALUES(auth),
+			user_agent = VALUES(user_agent), expires_at = VALUES(expires_at)
+	`, userData.UserID, req.Endpoint, common.HashToken(req.Endpoint), req.Keys.P256dh, req.Keys.Auth, userAgent, expiresAt)
+	if err != nil {
+		slog.Error(common.LogPushSubscriptionAdd + " - erreur lors de l'enregistrement de l'abonnement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionCreation,
+		})
+		return
+	}
+
+	var subscriptionID int
+	if err := common.DB.QueryRow("SELECT push_subscription_id FROM push_subscription WHERE endpoint_hash = ?", common.HashToken(req.Endpoint)).Scan(&subscriptionID); err != nil {
+		slog.Error(common.LogPushSubscriptionAdd + " - erreur lors de la relecture de l'abonnement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionCreation,
+		})
+		return
+	}
+
+	slog.Info(common.LogPushSubscriptionAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreatePushSubscription,
+		Data: gin.H{
+			"push_subscription_id": subscriptionID,
+		},
+	})
+}
+
+// Delete supprime un abonnement push de l'utilisateur connecté
+// @Summary Supprimer un abonnement push
+// @Description Supprime l'abonnement (à appeler après PushSubscription.unsubscribe() côté navigateur)
+// @Tags Notifications push
+// @Produce json
+// @Param push_subscription_id path int true "ID de l'abonnement"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /push/subscriptions/{push_subscription_id} [delete]
+func (PushSubscriptionStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogPushSubscriptionDelete)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	subscriptionID, err := strconv.Atoi(c.Param("push_subscription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPushSubscriptionID,
+		})
+		return
+	}
+
+	result, err := common.DB.Exec("DELETE FROM push_subscription WHERE push_subscription_id = ? AND user_id = ?", subscriptionID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogPushSubscriptionDelete + " - erreur lors de la suppression de l'abonnement : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionDelete,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionNotFound,
+		})
+		return
+	}
+
+	slog.Info(common.LogPushSubscriptionDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeletePushSubscription,
+	})
+}
+
+// Test envoie une notification de test à tous les navigateurs de l'utilisateur connecté
+// @Summary Envoyer une notification de test
+// @Description Envoie une notification à chaque abonnement de l'utilisateur connecté ; les abonnements expirés sont supprimés
+// @Tags Notifications push
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=common.PushDeliveryReport}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /push/test [post]
+func (PushSubscriptionStruct) Test(c *gin.Context) {
+	slog.Info(common.LogPushSubscriptionTest)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	report, err := Notify(c.Request.Context(), userData.UserID, common.PushNotification{
+		Title: "GoLendar",
+		Body:  "Les notifications sont activées sur ce navigateur",
+	})
+	if err != nil {
+		slog.Error(common.LogPushSubscriptionTest + " - erreur lors de l'envoi : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPushSubscriptionRetrieval,
+		})
+		return
+	}
+	if report.Sent+report.Pruned+report.Failed == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrNoPushSubscription,
+		})
+		return
+	}
+
+	slog.Info(common.LogPushSubscriptionTest + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessSendTestPush,
+		Data:    report,
+	})
+}
+
+// Notify envoie la notification à tous les abonnements push de l'utilisateur. Les abonnements expirés
+// (date d'expiration dépassée, ou 404/410 du service de push) et ceux dont le point de terminaison
+// n'est plus accepté (http, adresse non publique) sont supprimés. L'échec d'un envoi
+// n'empêche pas les suivants : seule une erreur de base de données est retournée.
+func Notify(ctx context.Context, userID int, notification common.PushNotification) (common.PushDeliveryReport, error) {
+	var report common.PushDeliveryReport
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return report, err
+	}
+
+	pruned, err := common.DB.Exec("DELETE FROM push_subscription WHERE user_id = ? AND expires_at <= NOW()", userID)
+	if err != nil {
+		return report, err
+	}
+	if affected, _ := pruned.RowsAffected(); affected > 0 {
+		report.Pruned += int(affected)
+	}
+
+	subscriptions, err := loadSubscriptions(userID)
+	if err != nil {
+		return report, err
+	}
+	message := webpush.Message{Payload: payload, TTL: defaultTTL}
+	if notification.EventID != nil {
+		message.Topic = "event-" + strconv.Itoa(*notification.EventID)
+	}
+	for _, subscription := range subscriptions {
+		err := webpush.Default.Send(ctx, subscription.target, message)
+		switch {
+		case errors.Is(err, webpush.ErrSubscriptionGone), errors.Is(err, webpush.ErrInvalidSubscription):
+			if _, err := common.DB.Exec("DELETE FROM push_subscription WHERE push_subscription_id = ?", subscription.PushSubscriptionID); err != nil {
+				return report, err
+			}
+			report.Pruned++
+		case err != nil:
+			slog.Error(common.LogPushSubscriptionNotify + " - échec de l'envoi à l'abonnement " + strconv.Itoa(subscription.PushSubscriptionID) + " : " + err.Error())
+			report.Failed++
+		default:
+			if _, err := common.DB.Exec("UPDATE push_subscription SET last_success_at = NOW() WHERE push_subscription_id = ?", subscription.PushSubscriptionID); err != nil {
+				return report, err
+			}
+			report.Sent++
+		}
+	}
+	return report, nil
+}
+
+// storedSubscription associe un abonnement enregistré à ses clés de chiffrement
+type storedSubscription struct {
+	common.PushSubscription
+	target webpush.Subscription
+}
+
+// loadSubscriptions lit les abonnements de l'utilisateur, du plus récent au plus ancien
+func loadSubscriptions(userID int) ([]storedSubscription, error) {
+	rows, err := common.DB.Query(`
+		SELECT push_subscription_id, user_id, endpoint, p256dh, auth, user_agent, expires_at, last_success_at, created_at
+		FROM push_subscription
+		WHERE user_id = ?
+		ORDER BY created_at DESC, push_subscription_id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []storedSubscription
+	for rows.Next() {
+		var s storedSubscription
+		if err := rows.Scan(&s.PushSubscriptionID, &s.UserID, &s.Endpoint, &s.target.P256dh, &s.target.Auth,
+			&s.UserAgent, &s.ExpiresAt, &s.LastSuccessAt, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.target.Endpoint = s.Endpoint
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
@@ -0,0 +1,198 @@
+package push_subscription_test
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// browserSubscription simule pushManager.subscribe() : clés générées comme par le navigateur
+func browserSubscription(t *testing.T, endpoint string) map[string]interface{} {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+	return map[string]interface{}{
+		"endpoint":       endpoint,
+		"expirationTime": nil,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(auth),
+		},
+	}
+}
+
+// TestAddPushSubscriptionRoute teste l'enregistrement d'un abonnement push avec plusieurs cas
+func TestAddPushSubscriptionRoute(t *testing.T) {
+	valid := browserSubscription(t, "https://push.example.net/send/abc")
+
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedError    string
+	}{
+		{CaseName: "Enregistrement réussi", RequestData: valid, ExpectedHttpCode: http.StatusCreated},
+		{
+			CaseName:         "Échec avec une clé p256dh qui n'est pas un point P-256",
+			RequestData:      map[string]interface{}{"endpoint": "https://push.example.net/send/def", "keys": map[string]string{"p256dh": "AAAA", "auth": "BTBZMqHH6r4Tts7J_aSIgg"}},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint qui n'est pas une URL https",
+			RequestData:      browserSubscription(t, "ftp://push.example.net/send/ghi"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint en http",
+			RequestData:      browserSubscription(t, "http://push.example.net/send/jkl"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint vers la machine elle-même",
+			RequestData:      browserSubscription(t, "https://127.0.0.1:8080/admin"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint vers localhost",
+			RequestData:      browserSubscription(t, "https://localhost/send/mno"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint vers le service de métadonnées",
+			RequestData:      browserSubscription(t, "https://169.254.169.254/latest/meta-data"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint vers un réseau privé",
+			RequestData:      browserSubscription(t, "https://10.0.0.12/send/pqr"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+		{
+			CaseName:         "Échec avec un endpoint vers une adresse IPv6 locale",
+			RequestData:      browserSubscription(t, "https://[::1]/send/stu"),
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidPushSubscription,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+			require.NoError(t, err)
+
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/push/subscriptions", testCase.RequestData)
+			require.Equal(t, testCase.ExpectedHttpCode, status, response.Error)
+			if testCase.ExpectedError != "" {
+				require.Equal(t, testCase.ExpectedError, response.Error)
+			}
+		})
+	}
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestPushDeliveryAndPruning vérifie l'envoi vers un service de push local et la suppression des abonnements expirés
+func TestPushDeliveryAndPruning(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	// Service de push local : /gone/* répond 410 comme pour un navigateur désinscrit
+	var delivered atomic.Int32
+	service := testutils.NewPushService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/gone/") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || !strings.HasPrefix(r.Header.Get("Authorization"), "vapid t=") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		delivered.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/push/vapid-public-key", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(response.Data), "public_key")
+
+	// Aucun abonnement : rien à envoyer
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/push/test", nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrNoPushSubscription, response.Error)
+
+	for _, endpoint := range []string{testutils.PushServiceURL + "/active/1", testutils.PushServiceURL + "/gone/2"} {
+		status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/push/subscriptions", browserSubscription(t, endpoint))
+		require.Equal(t, http.StatusCreated, status, response.Error)
+	}
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/push/test", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var report common.PushDeliveryReport
+	require.NoError(t, json.Unmarshal(response.Data, &report))
+	require.Equal(t, common.PushDeliveryReport{Sent: 1, Pruned: 1}, report)
+	require.Equal(t, int32(1), delivered.Load())
+
+	// Seul l'abonnement actif subsiste
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/push/subscriptions", nil)
+	require.Equal(t, http.StatusOK, status)
+	var subscriptions []common.PushSubscription
+	require.NoError(t, json.Unmarshal(response.Data, &subscriptions))
+	require.Len(t, subscriptions, 1)
+	require.Equal(t, testutils.PushServiceURL+"/active/1", subscriptions[0].Endpoint)
+	require.NotNil(t, subscriptions[0].LastSuccessAt)
+
+	// Un autre utilisateur ne peut pas supprimer l'abonnement
+	path := "/push/subscriptions/" + strconv.Itoa(subscriptions[0].PushSubscriptionID)
+	status, _ = testutils.DoRequest(t, testServer, other.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,843 @@
+// Package resource internal/resource/resource.go
+package resource
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ResourceStruct struct{}
+
+var Resource = ResourceStruct{}
+
+// ErrUnknownResources est retournée quand une ressource à réserver n'existe pas
+var ErrUnknownResources = errors.New(common.ErrResourceNotFound)
+
+// ErrConflict est retournée quand une ressource est déjà réservée ou bloquée sur le créneau de l'événement
+var ErrConflict = errors.New(common.ErrResourceConflict)
+
+const (
+	// SourceBooking désigne un créneau occupé par la réservation d'un événement
+	SourceBooking = "booking"
+	// SourceBlocked désigne un créneau bloqué par un événement du calendrier de disponibilité
+	SourceBlocked = "blocked"
+
+	// maxAvailabilityDays borne la période interrogée par Availability
+	maxAvailabilityDays = 366
+)
+
+// resourceColumns liste les colonnes lues pour une ressource, dans l'ordre de scanResource
+const resourceColumns = "r.resource_id, r.name, r.kind, r.description, r.capacity, r.location, r.calendar_id, r.created_by, r.created_at, r.updated_at, r.deleted_at"
+
+// List récupère les ressources réservables
+// @Summary Lister les ressources
+// @Description Récupère les salles et équipements réservables, triés par nom
+// @Tags Ressource
+// @Produce json
+// @Param kind query string false "Type de ressource (room ou equipment)"
+// @Param min_capacity query int false "Capacité minimale"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /resources [get]
+func (ResourceStruct) List(c *gin.Context) {
+	slog.Info(common.LogResourceList)
+
+	query := "SELECT " + resourceColumns + " FROM resource r WHERE r.deleted_at IS NULL"
+	var args []interface{}
+	if kind := c.Query("kind"); kind != "" {
+		if kind != "room" && kind != "equipment" {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidResourceKind,
+			})
+			return
+		}
+		query += " AND r.kind = ?"
+		args = append(args, kind)
+	}
+	if capacityStr := c.Query("min_capacity"); capacityStr != "" {
+		capacity, err := strconv.Atoi(capacityStr)
+		if err != nil || capacity < 1 {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidCapacityFilter,
+			})
+			return
+		}
+		query += " AND r.capacity >= ?"
+		args = append(args, capacity)
+	}
+	query += " ORDER BY r.name, r.resource_id"
+
+	rows, err := common.DB.Query(query, args...)
+	if err != nil {
+		slog.Error(common.LogResourceList + " - erreur lors de la récupération des ressources : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	resources := []common.Resource{}
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			slog.Error(common.LogResourceList + " - erreur lors de la lecture des ressources : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrResourceRetrieval,
+			})
+			return
+		}
+		resources = append(resources, resource)
+	}
+
+	slog.Info(common.LogResourceList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListResources,
+		Data:    resources,
+	})
+}
+
+// Get récupère une ressource réservable
+// @Summary Récupérer une ressource
+// @Description Récupère une salle ou un équipement réservable par son ID
+// @Tags Ressource
+// @Produce json
+// @Param resource_id path int true "ID de la ressource"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /resources/{resource_id} [get]
+func (ResourceStruct) Get(c *gin.Context) {
+	slog.Info(common.LogResourceGet)
+	resourceData, ok := common.GetResourceFromContext(c)
+	if !ok {
+		return
+	}
+
+	slog.Info(common.LogResourceGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetResource,
+		Data:    resourceData,
+	})
+}
+
+// Add crée une ressource réservable (administrateur des ressources)
+// @Summary Créer une ressource
+// @Description Crée une salle ou un équipement réservable ainsi que son calendrier de disponibilité, partagé avec le créateur. Les événements de ce calendrier rendent la ressource indisponible.
+// @Tags Ressource
+// @Accept json
+// @Produce json
+// @Param resource body common.CreateResourceRequest true "Données de la ressource"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Router /resources [post]
+func (ResourceStruct) Add(c *gin.Context) {
+	slog.Info(common.LogResourceAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogResourceAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogResourceAdd + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Calendrier de disponibilité de la ressource, partagé avec son créateur
+	calendarID, err := createAvailabilityCalendar(tx, userData.UserID, req.Name)
+	if err != nil {
+		slog.Error(common.LogResourceAdd + " - erreur lors de la création du calendrier de disponibilité : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceCreation,
+		})
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO resource (name, kind, description, capacity, location, calendar_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NOW())
+	`, req.Name, req.Kind, req.Description, req.Capacity, common.NullIfEmpty(req.Location), calendarID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogResourceAdd + " - erreur lors de la création de la ressource : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceCreation,
+		})
+		return
+	}
+	resourceID, _ := result.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogResourceAdd + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateResource,
+		Data: gin.H{
+			"resource_id": resourceID,
+			"calendar_id": calendarID,
+		},
+	})
+}
+
+// Update met à jour une ressource réservable (administrateur des ressources)
+// @Summary Mettre à jour une ressource
+// @Description Met à jour une salle ou un équipement réservable ("capacity": 0 retire la capacité, "location": "" retire le lieu)
+// @Tags Ressource
+// @Accept json
+// @Produce json
+// @Param resource_id path int true "ID de la ressource"
+// @Param resource body common.UpdateResourceRequest true "Données de la ressource"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /resources/{resource_id} [put]
+func (ResourceStruct) Update(c *gin.Context) {
+	slog.Info(common.LogResourceUpdate)
+	resourceData, ok := common.GetResourceFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogResourceUpdate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	query := "UPDATE resource SET updated_at = NOW()"
+	var args []interface{}
+	if req.Name != nil {
+		query += ", name = ?"
+		args = append(args, *req.Name)
+	}
+	if req.Kind != nil {
+		query += ", kind = ?"
+		args = append(args, *req.Kind)
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, *req.Description)
+	}
+	if req.Capacity != nil {
+		query += ", capacity = ?"
+		if *req.Capacity == 0 {
+			args = append(args, nil)
+		} else {
+			args = append(args, *req.Capacity)
+		}
+	}
+	if req.Location != nil {
+		query += ", location = ?"
+		args = append(args, common.NullIfEmpty(req.Location))
+	}
+	query += " WHERE resource_id = ?"
+	args = append(args, resourceData.ResourceID)
+
+	if _, err := common.DB.Exec(query, args...); err != nil {
+		slog.Error(common.LogResourceUpdate + " - erreur lors de la mise à jour de la ressource : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceUpdate,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceUpdate + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateResource,
+	})
+}
+
+// Delete supprime une ressource réservable (administrateur des ressources)
+// @Summary Supprimer une ressource
+// @Description Supprime la ressource, ses réservations et son calendrier de disponibilité. Les événements qui la réservaient sont conservés.
+// @Tags Ressource
+// @Produce json
+// @Param resource_id path int true "ID de la ressource"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /resources/{resource_id} [delete]
+func (ResourceStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogResourceDelete)
+	resourceData, ok := common.GetResourceFromContext(c)
+	if !ok {
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogResourceDelete + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		arg   int
+	}{
+		{"UPDATE resource SET deleted_at = NOW() WHERE resource_id = ?", resourceData.ResourceID},
+		{"UPDATE event_resource SET deleted_at = NOW() WHERE resource_id = ? AND deleted_at IS NULL", resourceData.ResourceID},
+		{"UPDATE calendar SET deleted_at = NOW() WHERE calendar_id = ?", resourceData.CalendarID},
+		{"UPDATE user_calendar SET deleted_at = NOW() WHERE calendar_id = ? AND deleted_at IS NULL", resourceData.CalendarID},
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.arg); err != nil {
+			slog.Error(common.LogResourceDelete + " - erreur lors de la suppression de la ressource : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrResourceDelete,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogResourceDelete + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteResource,
+	})
+}
+
+// Availability retourne les créneaux occupés d'une ressource sur une période
+// @Summary Disponibilités d'une ressource
+// @Description Liste les créneaux pendant lesquels la ressource est réservée par un événement (source "booking") ou bloquée par son calendrier de disponibilité (source "blocked"). Les titres des événements ne sont pas exposés.
+// @Tags Ressource
+// @Produce json
+// @Param resource_id path int true "ID de la ressource"
+// @Param start query string true "Début de la période (YYYY-MM-DD ou RFC3339)"
+// @Param end query string true "Fin de la période (YYYY-MM-DD inclus ou RFC3339 exclu)"
+// @Success 200 {object} common.JSONResponse{data=common.ResourceAvailabilityResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /resources/{resource_id}/availability [get]
+func (ResourceStruct) Availability(c *gin.Context) {
+	slog.Info(common.LogResourceAvailability)
+	resourceData, ok := common.GetResourceFromContext(c)
+	if !ok {
+		return
+	}
+
+	start, errStart := parseDate(c.Query("start"), false)
+	end, errEnd := parseDate(c.Query("end"), true)
+	if errStart != nil || errEnd != nil || !start.Before(end) || end.Sub(start) > maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidAvailabilityRange,
+		})
+		return
+	}
+
+	busy, err := busySlots(resourceData, start, end)
+	if err != nil {
+		slog.Error(common.LogResourceAvailability + " - erreur lors de la récupération des créneaux : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceAvailability + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessResourceAvailability,
+		Data: common.ResourceAvailabilityResponse{
+			ResourceID: resourceData.ResourceID,
+			Start:      start,
+			End:        end,
+			Busy:       busy,
+		},
+	})
+}
+
+// ListForEvent liste les ressources réservées par un événement
+// @Summary Lister les ressources d'un événement
+// @Description Liste les salles et équipements réservés par l'événement
+// @Tags Ressource
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/resources [get]
+func (ResourceStruct) ListForEvent(c *gin.Context) {
+	slog.Info(common.LogResourceListForEvent)
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+	eventID := eventData.EventID
+
+	rows, err := common.DB.Query(`
+		SELECT `+resourceColumns+`
+		FROM resource r
+		INNER JOIN event_resource er ON er.resource_id = r.resource_id AND er.deleted_at IS NULL
+		WHERE er.event_id = ? AND r.deleted_at IS NULL
+		ORDER BY r.name, r.resource_id
+	`, eventID)
+	if err != nil {
+		slog.Error(common.LogResourceListForEvent + " - erreur lors de la récupération des ressources : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	resources := []common.Resource{}
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			slog.Error(common.LogResourceListForEvent + " - erreur lors de la lecture des ressources : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrResourceRetrieval,
+			})
+			return
+		}
+		resources = append(resources, resource)
+	}
+
+	slog.Info(common.LogResourceListForEvent + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListEventResources,
+		Data:    resources,
+	})
+}
+
+// Book réserve une ressource pour un événement
+// @Summary Réserver une ressource
+// @Description Réserve une salle ou un équipement sur le créneau de l'événement. La réservation est refusée (409) si la ressource est déjà réservée par un autre événement ou bloquée par son calendrier de disponibilité sur ce créneau.
+// @Tags Ressource
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param booking body common.BookResourceRequest true "Ressource à réserver"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/resources [post]
+func (ResourceStruct) Book(c *gin.Context) {
+	slog.Info(common.LogResourceBook)
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+	eventID := eventData.EventID
+
+	var req common.BookResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogResourceBook + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogResourceBook + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRow(`
+		SELECT 1 FROM event_resource
+		WHERE event_id = ? AND resource_id = ? AND deleted_at IS NULL
+	`, eventID, req.ResourceID).Scan(&found)
+	if err == nil {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceAlreadyBooked,
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		slog.Error(common.LogResourceBook + " - erreur lors de la vérification de la réservation : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceBooking,
+		})
+		return
+	}
+
+	if err := Reserve(tx, int64(eventID), []int{req.ResourceID}); err != nil {
+		slog.Error(common.LogResourceBook + " - " + err.Error())
+		status, errMsg := http.StatusInternalServerError, common.ErrResourceBooking
+		switch {
+		case errors.Is(err, ErrUnknownResources):
+			status, errMsg = http.StatusNotFound, common.ErrResourceNotFound
+		case errors.Is(err, ErrConflict):
+			status, errMsg = http.StatusConflict, common.ErrResourceConflict
+		}
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogResourceBook + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceBook + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessBookResource,
+		Data: gin.H{
+			"event_id":    eventID,
+			"resource_id": req.ResourceID,
+		},
+	})
+}
+
+// Release annule la réservation d'une ressource par un événement
+// @Summary Annuler la réservation d'une ressource
+// @Description Libère la ressource réservée par l'événement
+// @Tags Ressource
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param event_id path int true "ID de l'événement"
+// @Param resource_id path int true "ID de la ressource"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-event/{calendar_id}/{event_id}/resources/{resource_id} [delete]
+func (ResourceStruct) Release(c *gin.Context) {
+	slog.Info(common.LogResourceRelease)
+	eventData, ok := common.GetEventFromContext(c)
+	if !ok {
+		return
+	}
+	eventID := eventData.EventID
+	resourceID, err := strconv.Atoi(c.Param("resource_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidResourceID,
+		})
+		return
+	}
+
+	result, err := common.DB.Exec(`
+		UPDATE event_resource SET deleted_at = NOW()
+		WHERE event_id = ? AND resource_id = ? AND deleted_at IS NULL
+	`, eventID, resourceID)
+	if err != nil {
+		slog.Error(common.LogResourceRelease + " - erreur lors de l'annulation de la réservation : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceBooking,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrResourceNotBooked,
+		})
+		return
+	}
+
+	slog.Info(common.LogResourceRelease + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessReleaseResource,
+	})
+}
+
+// Reserve ajoute les réservations de l'événement pour les ressources données puis vérifie l'absence de conflit.
+// Les ressources déjà réservées par l'événement sont conservées telles quelles.
+func Reserve(tx *sql.Tx, eventID int64, resourceIDs []int) error {
+	resourceIDs = common.UniqueIDs(resourceIDs)
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+	// Le verrou sur les ressources sérialise les réservations concurrentes d'une même ressource
+	placeholders, args := common.InClause(resourceIDs)
+	locked, err := lockResources(tx, "r.resource_id IN ("+placeholders+")", args...)
+	if err != nil {
+		return err
+	}
+	if locked != len(resourceIDs) {
+		return ErrUnknownResources
+	}
+
+	for _, resourceID := range resourceIDs {
+		// Une réservation annulée précédemment est réactivée plutôt que dupliquée
+		_, err := tx.Exec(`
+			INSERT INTO event_resource (event_id, resource_id, created_at)
+			VALUES (?, ?, NOW())
+			ON DUPLICATE KEY UPDATE deleted_at = NULL, updated_at = NOW()
+		`, eventID, resourceID)
+		if err != nil {
+			return err
+		}
+	}
+	return CheckConflicts(tx, eventID)
+}
+
+// SetEventResources remplace l'ensemble des ressources réservées par l'événement par resourceIDs
+func SetEventResources(tx *sql.Tx, eventID int64, resourceIDs []int) error {
+	resourceIDs = common.UniqueIDs(resourceIDs)
+
+	query := "UPDATE event_resource SET deleted_at = NOW() WHERE event_id = ? AND deleted_at IS NULL"
+	args := []interface{}{eventID}
+	if len(resourceIDs) > 0 {
+		placeholders, inArgs := common.InClause(resourceIDs)
+		query += " AND resource_id NOT IN (" + placeholders + ")"
+		args = append(args, inArgs...)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return Reserve(tx, eventID, resourceIDs)
+}
+
+// CheckConflicts vérifie que les ressources réservées par l'événement sont libres sur son créneau :
+// ni réservées par un autre événement actif, ni bloquées par leur calendrier de disponibilité.
+// Un événement annulé ne bloque rien et n'est jamais en conflit.
+func CheckConflicts(tx *sql.Tx, eventID int64) error {
+	locked, err := lockResources(tx, `EXISTS (SELECT 1 FROM event_resource er
+		WHERE er.resource_id = r.resource_id AND er.event_id = ? AND er.deleted_at IS NULL)`, eventID)
+	if err != nil || locked == 0 {
+		return err
+	}
+
+	// Lectures verrouillantes : elles voient les réservations validées par les transactions concurrentes
+	var conflictID int
+	err = tx.QueryRow(`
+		SELECT mine.resource_id
+		FROM event_resource mine
+		INNER JOIN event me ON me.event_id = mine.event_id
+		INNER JOIN event_resource other ON other.resource_id = mine.resource_id AND other.event_id <> mine.event_id AND other.deleted_at IS NULL
+		INNER JOIN event o ON o.event_id = other.event_id
+		WHERE mine.event_id = ? AND mine.deleted_at IS NULL
+		AND me.deleted_at IS NULL AND me.canceled = FALSE
+		AND o.deleted_at IS NULL AND o.canceled = FALSE
+		AND o.start < DATE_ADD(me.start, INTERVAL me.duration MINUTE)
+		AND me.start < DATE_ADD(o.start, INTERVAL o.duration MINUTE)
+		LIMIT 1 LOCK IN SHARE MODE
+	`, eventID).Scan(&conflictID)
+	if err == nil {
+		return ErrConflict
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		SELECT mine.resource_id
+		FROM event_resource mine
+		INNER JOIN event me ON me.event_id = mine.event_id
+		INNER JOIN resource r ON r.resource_id = mine.resource_id
+		INNER JOIN calendar_event ce ON ce.calendar_id = r.calendar_id AND ce.deleted_at IS NULL
+		INNER JOIN event b ON b.event_id = ce.event_id
+		WHERE mine.event_id = ? AND mine.deleted_at IS NULL
+		AND me.deleted_at IS NULL AND me.canceled = FALSE
+		AND b.event_id <> me.event_id AND b.deleted_at IS NULL AND b.canceled = FALSE
+		AND b.start < DATE_ADD(me.start, INTERVAL me.duration MINUTE)
+		AND me.start < DATE_ADD(b.start, INTERVAL b.duration MINUTE)
+		LIMIT 1 LOCK IN SHARE MODE
+	`, eventID).Scan(&conflictID)
+	if err == nil {
+		return ErrConflict
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+// ReleaseEvent annule toutes les réservations de l'événement
+func ReleaseEvent(tx *sql.Tx, eventID int) error {
+	_, err := tx.Exec("UPDATE event_resource SET deleted_at = NOW() WHERE event_id = ? AND deleted_at IS NULL", eventID)
+	return err
+}
+
+// createAvailabilityCalendar crée le calendrier de disponibilité d'une ressource et y donne accès à l'utilisateur
+func createAvailabilityCalendar(tx *sql.Tx, userID int, name string) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO calendar (title, description, created_at)
+		VALUES (?, ?, NOW())
+	`, "Disponibilités - "+name, "Les événements de ce calendrier rendent la ressource indisponible")
+	if err != nil {
+		return 0, err
+	}
+	calendarID, _ := result.LastInsertId()
+	_, err = tx.Exec(`
+		INSERT INTO user_calendar (user_id, calendar_id, created_at)
+		VALUES (?, ?, NOW())
+	`, userID, calendarID)
+	return calendarID, err
+}
+
+// lockResources verrouille, par ordre d'identifiant pour éviter les interblocages, les ressources actives
+// correspondant à la condition et retourne leur nombre
+func lockResources(tx *sql.Tx, condition string, args ...interface{}) (int, error) {
+	rows, err := tx.Query(`
+		SELECT r.resource_id FROM resource r
+		WHERE r.deleted_at IS NULL AND `+condition+`
+		ORDER BY r.resource_id FOR UPDATE
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
+
+// busySlots retourne les créneaux occupés de la ressource qui chevauchent la période, triés par début
+func busySlots(resourceData common.Resource, start, end time.Time) ([]common.ResourceBusySlot, error) {
+	rows, err := common.DB.Query(`
+		SELECT e.event_id, e.start, e.duration, ? AS source
+		FROM event_resource er
+		INNER JOIN event e ON e.event_id = er.event_id
+		WHERE er.resource_id = ? AND er.deleted_at IS NULL
+		AND e.deleted_at IS NULL AND e.canceled = FALSE
+		AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+		UNION ALL
+		SELECT e.event_id, e.start, e.duration, ? AS source
+		FROM calendar_event ce
+		INNER JOIN event e ON e.event_id = ce.event_id
+		WHERE ce.calendar_id = ? AND ce.deleted_at IS NULL
+		AND e.deleted_at IS NULL AND e.canceled = FALSE
+		AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+	`, SourceBooking, resourceData.ResourceID, end, start,
+		SourceBlocked, resourceData.CalendarID, end, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	busy := []common.ResourceBusySlot{}
+	for rows.Next() {
+		var slot common.ResourceBusySlot
+		var duration int
+		if err := rows.Scan(&slot.EventID, &slot.Start, &duration, &slot.Source); err != nil {
+			return nil, err
+		}
+		slot.End = slot.Start.Add(time.Duration(duration) * time.Minute)
+		busy = append(busy, slot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	return busy, nil
+}
+
+// scanResource lit une ressource sélectionnée avec resourceColumns
+func scanResource(rows *sql.Rows) (common.Resource, error) {
+	var resource common.Resource
+	err := rows.Scan(&resource.ResourceID, &resource.Name, &resource.Kind, &resource.Description, &resource.Capacity,
+		&resource.Location, &resource.CalendarID, &resource.CreatedBy, &resource.CreatedAt, &resource.UpdatedAt, &resource.DeletedAt)
+	return resource, err
+}
+
+// parseDate accepte une date au format YYYY-MM-DD ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
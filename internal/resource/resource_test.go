@@ -0,0 +1,236 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// createRoom crée une salle avec un administrateur des ressources et retourne l'administrateur et la ressource
+func createRoom(t *testing.T) (*testutils.AuthenticatedUser, int, int) {
+	manager, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	require.NoError(t, testutils.GrantRole(manager.User.UserID, "resource_admin"))
+
+	status, response := testutils.DoRequest(t, testServer, manager.SessionToken, "POST", "/resources", map[string]interface{}{
+		"name":     "Salle Ibn Rushd",
+		"kind":     "room",
+		"capacity": 12,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var data struct {
+		ResourceID int `json:"resource_id"`
+		CalendarID int `json:"calendar_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	return manager, data.ResourceID, data.CalendarID
+}
+
+// addEvent crée un événement via l'API et retourne le code HTTP et la réponse
+func addEvent(t *testing.T, user *testutils.AuthenticatedUser, calendarID int, start time.Time, duration int, resourceIDs []int) (int, testutils.APIResponse) {
+	return testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-event/"+strconv.Itoa(calendarID), map[string]interface{}{
+		"title":        "Réunion",
+		"start":        start.Format(time.RFC3339),
+		"duration":     duration,
+		"calendar_id":  calendarID,
+		"resource_ids": resourceIDs,
+	})
+}
+
+// TestAddResourceRoute teste la création d'une ressource avec plusieurs cas
+func TestAddResourceRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		Role             string
+		RequestData      map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName:         "Création réussie par un administrateur des ressources",
+			Role:             "resource_admin",
+			RequestData:      map[string]interface{}{"name": "Vidéoprojecteur", "kind": "equipment"},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateResource,
+		},
+		{
+			CaseName:         "Échec de la création sans le rôle resource_admin",
+			RequestData:      map[string]interface{}{"name": "Vidéoprojecteur", "kind": "equipment"},
+			ExpectedHttpCode: http.StatusForbidden,
+			ExpectedError:    common.ErrInsufficientPermissions,
+		},
+		{
+			CaseName:         "Échec de la création avec un type invalide",
+			Role:             "resource_admin",
+			RequestData:      map[string]interface{}{"name": "Voiture", "kind": "vehicle"},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+			require.NoError(t, err)
+			if testCase.Role != "" {
+				require.NoError(t, testutils.GrantRole(user.User.UserID, testCase.Role))
+			}
+
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/resources", testCase.RequestData)
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestResourceBooking vérifie le refus des doubles réservations et le blocage par le calendrier de disponibilité
+func TestResourceBooking(t *testing.T) {
+	manager, roomID, availabilityCalendarID := createRoom(t)
+	alice, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	bob, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+
+	nine := time.Date(2025, 5, 12, 9, 0, 0, 0, time.UTC)
+
+	// Première réservation acceptée
+	status, response := addEvent(t, alice, alice.Calendar.CalendarID, nine, 60, []int{roomID})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created struct {
+		EventID int `json:"event_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+
+	// Un créneau qui chevauche est refusé, l'événement n'est pas créé
+	status, response = addEvent(t, bob, bob.Calendar.CalendarID, nine.Add(30*time.Minute), 60, []int{roomID})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, common.ErrResourceConflict, response.Error)
+
+	// Un créneau contigu est accepté
+	status, response = addEvent(t, bob, bob.Calendar.CalendarID, nine.Add(time.Hour), 30, []int{roomID})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	// Le calendrier de disponibilité bloque la ressource
+	status, response = addEvent(t, manager, availabilityCalendarID, nine.Add(2*time.Hour), 120, nil)
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	status, response = addEvent(t, bob, bob.Calendar.CalendarID, nine.Add(3*time.Hour), 30, []int{roomID})
+	require.Equal(t, http.StatusConflict, status)
+
+	// Déplacer la première réunion sur le créneau bloqué est refusé
+	status, response = testutils.DoRequest(t, testServer, alice.SessionToken, "PUT", "/calendar-event/"+strconv.Itoa(alice.Calendar.CalendarID)+"/"+strconv.Itoa(created.EventID), map[string]interface{}{
+		"start": nine.Add(2 * time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusConflict, status, response.Error)
+
+	// Les disponibilités listent les deux réservations et le blocage
+	status, response = testutils.DoRequest(t, testServer, bob.SessionToken, "GET", "/resources/"+strconv.Itoa(roomID)+"/availability?start=2025-05-12&end=2025-05-12", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var availability common.ResourceAvailabilityResponse
+	require.NoError(t, json.Unmarshal(response.Data, &availability))
+	require.Len(t, availability.Busy, 3)
+	require.Equal(t, "booking", availability.Busy[0].Source)
+	require.Equal(t, "blocked", availability.Busy[2].Source)
+
+	// Annuler la réservation libère le créneau
+	status, _ = testutils.DoRequest(t, testServer, alice.SessionToken, "DELETE", "/calendar-event/"+strconv.Itoa(alice.Calendar.CalendarID)+"/"+strconv.Itoa(created.EventID)+"/resources/"+strconv.Itoa(roomID), nil)
+	require.Equal(t, http.StatusOK, status)
+	status, response = addEvent(t, bob, bob.Calendar.CalendarID, nine, 30, []int{roomID})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestConcurrentBooking vérifie qu'une seule de plusieurs réservations simultanées du même créneau aboutit
+func TestConcurrentBooking(t *testing.T) {
+	_, roomID, _ := createRoom(t)
+	start := time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC)
+
+	const attempts = 5
+	users := make([]*testutils.AuthenticatedUser, attempts)
+	for i := range users {
+		user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+		require.NoError(t, err)
+		users[i] = user
+	}
+
+	statuses := make([]int, attempts)
+	var wg sync.WaitGroup
+	for i, user := range users {
+		wg.Add(1)
+		go func(i int, user *testutils.AuthenticatedUser) {
+			defer wg.Done()
+			// require ne doit pas être appelé hors de la goroutine du test
+			payload, _ := json.Marshal(map[string]interface{}{
+				"title":        "Réunion",
+				"start":        start.Format(time.RFC3339),
+				"duration":     60,
+				"calendar_id":  user.Calendar.CalendarID,
+				"resource_ids": []int{roomID},
+			})
+			req, _ := http.NewRequest("POST", testServer.URL+"/calendar-event/"+strconv.Itoa(user.Calendar.CalendarID), bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+user.SessionToken)
+			resp, err := testClient.Do(req)
+			if err != nil {
+				t.Errorf("Erreur lors de l'exécution de la requête: %v", err)
+				return
+			}
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}(i, user)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, status := range statuses {
+		if status == http.StatusCreated {
+			created++
+		} else {
+			require.Equal(t, http.StatusConflict, status)
+		}
+	}
+	require.Equal(t, 1, created, "Une seule réservation doit aboutir")
+
+	testutils.PurgeAllTestUsers()
+}
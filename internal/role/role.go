@@ -4,6 +4,7 @@ package role
 import (
 	"database/sql"
 	"fmt"
+	"go-averroes/internal/accesstoken"
 	"go-averroes/internal/common"
 	"log/slog"
 	"net/http"
@@ -346,12 +347,21 @@ func (RoleStruct) DeleteRole(c *gin.Context) {
 		return
 	}
 
+	// Les tokens d'accès des détenteurs du rôle ne doivent plus le porter
+	holders, err := roleHolders(roleID)
+	if err != nil {
+		slog.Error("Erreur lors de la récupération des détenteurs du rôle: " + err.Error())
+	}
+
 	// Supprimer aussi les attributions de rôles
 	_, err = common.DB.Exec("UPDATE user_roles SET deleted_at = NOW() WHERE role_id = ?", roleID)
 	if err != nil {
 		slog.Error("Erreur lors de la suppression des attributions de rôles: " + err.Error())
 		// On continue quand même car le rôle a été supprimé
 	}
+	for _, userID := range holders {
+		claimsChanged(userID)
+	}
 
 	slog.Info(common.LogRoleDeleteSuccess)
 	c.JSON(http.StatusOK, common.JSONResponse{
@@ -434,6 +444,8 @@ func (RoleStruct) AssignRole(c *gin.Context) {
 		return
 	}
 
+	claimsChanged(req.UserID)
+
 	slog.Info(common.LogRoleAssignSuccess)
 	c.JSON(http.StatusCreated, common.JSONResponse{
 		Success: true,
@@ -488,6 +500,8 @@ func (RoleStruct) RevokeRole(c *gin.Context) {
 		return
 	}
 
+	claimsChanged(req.UserID)
+
 	slog.Info(common.LogRoleRevokeSuccess)
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
@@ -565,3 +579,30 @@ func (RoleStruct) GetUserRoles(c *gin.Context) {
 		Data:    roles,
 	})
 }
+
+// roleHolders retourne les utilisateurs auxquels le rôle est attribué
+func roleHolders(roleID string) ([]int, error) {
+	rows, err := common.DB.Query("SELECT user_id FROM user_roles WHERE role_id = ? AND deleted_at IS NULL", roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+// claimsChanged invalide les tokens d'accès signés de l'utilisateur, qui portent ses anciens rôles.
+// L'attribution est déjà enregistrée : un échec est journalisé, les tokens expirant d'eux-mêmes.
+func claimsChanged(userID int) {
+	if err := accesstoken.ClaimsChanged(common.DB, userID); err != nil {
+		slog.Error(fmt.Sprintf(common.LogAccessTokenClaimsError, err.Error()))
+	}
+}
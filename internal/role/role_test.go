@@ -157,8 +157,8 @@ func TestListRolesRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				return map[string]interface{}{
@@ -441,8 +441,8 @@ func TestGetRoleRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un rôle
@@ -784,8 +784,8 @@ func TestCreateRoleRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"admin": admin,
@@ -1166,8 +1166,8 @@ func TestUpdateRoleRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"admin": admin,
@@ -1397,8 +1397,8 @@ func TestDeleteRoleRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 				return map[string]interface{}{
 					"admin": admin,
@@ -1,14 +1,35 @@
 package routes
 
 import (
+	"go-averroes/internal/attachment"
+	"go-averroes/internal/booking_page"
 	"go-averroes/internal/calendar"
 	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/calendar_template"
+	"go-averroes/internal/email_verification"
+	"go-averroes/internal/event_batch"
+	"go-averroes/internal/event_invitation"
+	"go-averroes/internal/event_reminder"
+	"go-averroes/internal/event_search"
+	"go-averroes/internal/event_transfer"
+	"go-averroes/internal/holiday_calendar"
+	"go-averroes/internal/login_guard"
 	"go-averroes/internal/middleware"
+	"go-averroes/internal/passkey"
+	"go-averroes/internal/password_reset"
+	"go-averroes/internal/push_subscription"
+	"go-averroes/internal/resource"
 	"go-averroes/internal/role"
 	"go-averroes/internal/session"
+	"go-averroes/internal/sso"
+	"go-averroes/internal/tag"
+	"go-averroes/internal/task"
+	"go-averroes/internal/two_factor"
 	"go-averroes/internal/user"
+	"go-averroes/internal/user_availability"
 	"go-averroes/internal/user_calendar"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -33,6 +54,47 @@ func RegisterRoutes(router *gin.Engine) {
 	{
 		authGroup.POST("/login", func(c *gin.Context) { session.Session.Login(c) })
 		authGroup.POST("/refresh", func(c *gin.Context) { session.Session.RefreshToken(c) })
+		// Réinitialisation du mot de passe, limitée par adresse IP contre l'énumération et le spam
+		authGroup.POST("/password-reset/request",
+			middleware.RateLimitMiddleware(5, 15*time.Minute),
+			func(c *gin.Context) { password_reset.PasswordReset.Request(c) },
+		)
+		authGroup.POST("/password-reset/confirm",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { password_reset.PasswordReset.Confirm(c) },
+		)
+		// Vérification de l'adresse e-mail (lien envoyé à l'inscription)
+		authGroup.POST("/email-verification/resend",
+			middleware.RateLimitMiddleware(5, 15*time.Minute),
+			func(c *gin.Context) { email_verification.EmailVerification.Resend(c) },
+		)
+		authGroup.POST("/email-verification/confirm",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { email_verification.EmailVerification.Confirm(c) },
+		)
+		// Seconde étape de la connexion lorsque l'authentification à deux facteurs est activée
+		authGroup.POST("/2fa/verify",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.VerifyTwoFactor(c) },
+		)
+		// Connexion sans mot de passe par passkey (WebAuthn)
+		authGroup.POST("/passkeys/login/options",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { passkey.Passkey.LoginOptions(c) },
+		)
+		authGroup.POST("/passkeys/login",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.LoginWithPasskey(c) },
+		)
+		// Connexion SSO par le fournisseur d'identité OpenID Connect
+		authGroup.GET("/oidc/authorize",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { sso.SSO.Authorize(c) },
+		)
+		authGroup.POST("/oidc/callback",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.LoginWithOIDC(c) },
+		)
 	}
 
 	// ===== ROUTES D'AUTHENTIFICATION (protégées) =====
@@ -43,6 +105,18 @@ func RegisterRoutes(router *gin.Engine) {
 		authProtectedGroup.GET("/me", func(c *gin.Context) { user.User.GetAuthMe(c) })
 		authProtectedGroup.GET("/sessions", func(c *gin.Context) { session.Session.GetUserSessions(c) })
 		authProtectedGroup.DELETE("/sessions/:session_id", func(c *gin.Context) { session.Session.DeleteSession(c) })
+
+		// Authentification à deux facteurs (TOTP)
+		authProtectedGroup.GET("/2fa", func(c *gin.Context) { two_factor.TwoFactor.Status(c) })
+		authProtectedGroup.POST("/2fa/setup", func(c *gin.Context) { two_factor.TwoFactor.Setup(c) })
+		authProtectedGroup.POST("/2fa/confirm", func(c *gin.Context) { two_factor.TwoFactor.Confirm(c) })
+		authProtectedGroup.POST("/2fa/disable", func(c *gin.Context) { two_factor.TwoFactor.Disable(c) })
+
+		// Passkeys (WebAuthn)
+		authProtectedGroup.GET("/passkeys", func(c *gin.Context) { passkey.Passkey.List(c) })
+		authProtectedGroup.POST("/passkeys/register/options", func(c *gin.Context) { passkey.Passkey.RegisterOptions(c) })
+		authProtectedGroup.POST("/passkeys/register", func(c *gin.Context) { passkey.Passkey.Register(c) })
+		authProtectedGroup.DELETE("/passkeys/:passkey_id", func(c *gin.Context) { passkey.Passkey.Delete(c) })
 	}
 
 	// ===== ROUTES DE GESTION DES UTILISATEURS =====
@@ -59,6 +133,17 @@ func RegisterRoutes(router *gin.Engine) {
 			userProtectedGroup.GET("/me", func(c *gin.Context) { user.User.Get(c) })
 			userProtectedGroup.PUT("/me", func(c *gin.Context) { user.User.Update(c) })
 			userProtectedGroup.DELETE("/me", func(c *gin.Context) { user.User.Delete(c) })
+
+			// Heures de travail et absences de l'utilisateur connecté
+			userProtectedGroup.GET("/me/working-hours", func(c *gin.Context) { user_availability.UserAvailability.GetWorkingHours(c) })
+			userProtectedGroup.PUT("/me/working-hours", func(c *gin.Context) { user_availability.UserAvailability.UpdateWorkingHours(c) })
+			userProtectedGroup.GET("/me/out-of-office", func(c *gin.Context) { user_availability.UserAvailability.ListOutOfOffice(c) })
+			userProtectedGroup.POST("/me/out-of-office", func(c *gin.Context) { user_availability.UserAvailability.AddOutOfOffice(c) })
+			userProtectedGroup.DELETE("/me/out-of-office/:out_of_office_id", func(c *gin.Context) { user_availability.UserAvailability.DeleteOutOfOffice(c) })
+
+			// Invitations reçues par l'utilisateur connecté
+			userProtectedGroup.GET("/me/invitations", func(c *gin.Context) { event_invitation.EventInvitation.ListMine(c) })
+			userProtectedGroup.PUT("/me/invitations/:invitation_id", func(c *gin.Context) { event_invitation.EventInvitation.Respond(c) })
 		}
 
 		// Routes admin pour gérer tous les utilisateurs
@@ -69,6 +154,8 @@ func RegisterRoutes(router *gin.Engine) {
 			userAdminGroup.PUT("/:user_id", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.Update(c) })
 			userAdminGroup.DELETE("/:user_id", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.Delete(c) })
 			userAdminGroup.GET("/:user_id/with-roles", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.GetUserWithRoles(c) })
+			userAdminGroup.GET("/:user_id/security-events", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { login_guard.LoginGuard.ListEvents(c) })
+			userAdminGroup.DELETE("/:user_id/lockout", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { login_guard.LoginGuard.Unlock(c) })
 		}
 	}
 
@@ -118,14 +205,14 @@ func RegisterRoutes(router *gin.Engine) {
 
 	// ===== ROUTE USER-CALENDAR : liste de mes calendriers (utilisateur connecté) =====
 	userCalendarMineGroup := router.Group("/user-calendar")
-	userCalendarMineGroup.Use(middleware.AuthMiddleware())
+	userCalendarMineGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
 	{
 		userCalendarMineGroup.GET("/me", func(c *gin.Context) { user_calendar.UserCalendar.ListMine(c) })
 	}
 
 	// ===== ROUTES DE GESTION DES CALENDRERS =====
 	calendarGroup := router.Group("/calendar")
-	calendarGroup.Use(middleware.AuthMiddleware())
+	calendarGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
 	{
 		// L'utilisateur peut créer des calendriers
 		calendarGroup.POST("", func(c *gin.Context) { calendar.Calendar.Add(c) })
@@ -146,11 +233,16 @@ func RegisterRoutes(router *gin.Engine) {
 			middleware.UserCanAccessCalendarMiddleware(),
 			func(c *gin.Context) { calendar.Calendar.Delete(c) },
 		)
+		calendarGroup.POST("/:calendar_id/clone",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { calendar.Calendar.Clone(c) },
+		)
 	}
 
 	// ===== ROUTES DE GESTION DES ÉVÉNEMENTS =====
 	calendarEventGroup := router.Group("/calendar-event")
-	calendarEventGroup.Use(middleware.AuthMiddleware())
+	calendarEventGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
 	{
 		// Toutes les routes d'événements nécessitent l'accès au calendrier
 		calendarEventGroup.GET("/:calendar_id/:event_id",
@@ -191,5 +283,283 @@ func RegisterRoutes(router *gin.Engine) {
 			middleware.EventExistsMiddleware("event_id"),
 			func(c *gin.Context) { calendar_event.CalendarEvent.Delete(c) },
 		)
+
+		// Opérations groupées sur les événements du calendrier
+		calendarEventGroup.POST("/:calendar_id/batch",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_batch.EventBatch.Apply(c) },
+		)
+
+		// Partage d'un même événement entre plusieurs calendriers
+		calendarEventGroup.POST("/:calendar_id/:event_id/link",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { calendar_event.CalendarEvent.Link(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/link",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { calendar_event.CalendarEvent.Unlink(c) },
+		)
+
+		// Déplacement et copie vers un autre calendrier (accès requis aux deux calendriers)
+		calendarEventGroup.POST("/:calendar_id/move",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_transfer.EventTransfer.Move(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/move",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_transfer.EventTransfer.Move(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/copy",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_transfer.EventTransfer.Copy(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/copy",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_transfer.EventTransfer.Copy(c) },
+		)
+
+		// Pièces jointes : mêmes contrôles d'accès que l'événement
+		calendarEventGroup.POST("/:calendar_id/:event_id/attachments",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { attachment.Attachment.Upload(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/attachments",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { attachment.Attachment.List(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/attachments/:attachment_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			middleware.AttachmentExistsMiddleware("attachment_id"),
+			func(c *gin.Context) { attachment.Attachment.Download(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/attachments/:attachment_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			middleware.AttachmentExistsMiddleware("attachment_id"),
+			func(c *gin.Context) { attachment.Attachment.Delete(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/resources",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.ListForEvent(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/resources",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.Book(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/resources/:resource_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.Release(c) },
+		)
+		// Invitations : la disponibilité de chaque invité est calculée sur le créneau de l'événement
+		calendarEventGroup.GET("/:calendar_id/:event_id/invitations",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_invitation.EventInvitation.ListForEvent(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/invitations",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_invitation.EventInvitation.Invite(c) },
+		)
+		// Rappel Web Push de l'utilisateur connecté
+		calendarEventGroup.GET("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Get(c) },
+		)
+		calendarEventGroup.PUT("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Set(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Delete(c) },
+		)
+	}
+
+	// ===== ROUTES DE RECHERCHE D'ÉVÉNEMENTS =====
+	eventsGroup := router.Group("/events")
+	eventsGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		// La recherche est restreinte aux calendriers accessibles via user_calendar
+		eventsGroup.GET("/search", func(c *gin.Context) { event_search.EventSearch.Search(c) })
+	}
+
+	// ===== ROUTES DE GESTION DES ÉTIQUETTES =====
+	tagGroup := router.Group("/tags")
+	tagGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		tagGroup.GET("", func(c *gin.Context) { tag.Tag.List(c) })
+		tagGroup.POST("", func(c *gin.Context) { tag.Tag.Add(c) })
+		// Une étiquette n'est visible que par son propriétaire
+		tagGroup.GET("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Get(c) })
+		tagGroup.PUT("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Update(c) })
+		tagGroup.DELETE("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Delete(c) })
+	}
+
+	// ===== ROUTES DES MODÈLES DE CALENDRIER =====
+	templateGroup := router.Group("/templates")
+	templateGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		templateGroup.GET("", func(c *gin.Context) { calendar_template.CalendarTemplate.List(c) })
+		templateGroup.GET("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Get(c) })
+		templateGroup.POST("/:template_id/instantiate", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Instantiate(c) })
+
+		// La publication des modèles est réservée aux administrateurs
+		templateAdminGroup := templateGroup.Group("")
+		templateAdminGroup.Use(middleware.AdminMiddleware())
+		{
+			templateAdminGroup.POST("", func(c *gin.Context) { calendar_template.CalendarTemplate.Add(c) })
+			templateAdminGroup.PUT("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Update(c) })
+			templateAdminGroup.DELETE("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Delete(c) })
+		}
+	}
+
+	// ===== ROUTES DE GESTION DES TÂCHES =====
+	taskGroup := router.Group("/calendar-task")
+	taskGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		taskGroup.GET("/:calendar_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { task.Task.List(c) },
+		)
+		taskGroup.POST("/:calendar_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { task.Task.Add(c) },
+		)
+		taskGroup.GET("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Get(c) },
+		)
+		taskGroup.PUT("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Update(c) },
+		)
+		taskGroup.DELETE("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Delete(c) },
+		)
+	}
+
+	// ===== ROUTES DES RESSOURCES RÉSERVABLES =====
+	resourceGroup := router.Group("/resources")
+	resourceGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		resourceGroup.GET("", func(c *gin.Context) { resource.Resource.List(c) })
+		resourceGroup.GET("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Get(c) })
+		resourceGroup.GET("/:resource_id/availability", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Availability(c) })
+
+		// La gestion des ressources est réservée à leurs administrateurs
+		resourceAdminGroup := resourceGroup.Group("")
+		resourceAdminGroup.Use(middleware.ResourceAdminMiddleware())
+		{
+			resourceAdminGroup.POST("", func(c *gin.Context) { resource.Resource.Add(c) })
+			resourceAdminGroup.PUT("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Update(c) })
+			resourceAdminGroup.DELETE("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Delete(c) })
+		}
+	}
+
+	// ===== ROUTES DES DISPONIBILITÉS DES UTILISATEURS =====
+	availabilityGroup := router.Group("/availability")
+	availabilityGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		availabilityGroup.GET("/free-busy", func(c *gin.Context) { user_availability.UserAvailability.FreeBusy(c) })
+		availabilityGroup.GET("/slots", func(c *gin.Context) { user_availability.UserAvailability.FindSlots(c) })
+	}
+
+	// ===== ROUTES DES CALENDRIERS DE JOURS FÉRIÉS =====
+	holidayCalendarGroup := router.Group("/holiday-calendars")
+	holidayCalendarGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		holidayCalendarGroup.GET("", func(c *gin.Context) { holiday_calendar.HolidayCalendar.List(c) })
+		holidayCalendarGroup.GET("/:country", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Holidays(c) })
+		holidayCalendarGroup.POST("/:country/subscription", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Subscribe(c) })
+		holidayCalendarGroup.DELETE("/:country/subscription", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Unsubscribe(c) })
+	}
+
+	// ===== ROUTE DE L'AGENDA (événements et jours fériés) =====
+	agendaGroup := router.Group("/agenda")
+	agendaGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		agendaGroup.GET("", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Agenda(c) })
+	}
+
+	// ===== ROUTES DES NOTIFICATIONS WEB PUSH =====
+	pushGroup := router.Group("/push")
+	pushGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		pushGroup.GET("/vapid-public-key", func(c *gin.Context) { push_subscription.PushSubscription.VAPIDPublicKey(c) })
+		pushGroup.GET("/subscriptions", func(c *gin.Context) { push_subscription.PushSubscription.List(c) })
+		pushGroup.POST("/subscriptions", func(c *gin.Context) { push_subscription.PushSubscription.Add(c) })
+		pushGroup.DELETE("/subscriptions/:push_subscription_id", func(c *gin.Context) { push_subscription.PushSubscription.Delete(c) })
+		pushGroup.POST("/test", func(c *gin.Context) { push_subscription.PushSubscription.Test(c) })
+	}
+
+	// ===== ROUTES DES PAGES DE RÉSERVATION =====
+	bookingPageGroup := router.Group("/booking-pages")
+	bookingPageGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		bookingPageGroup.GET("", func(c *gin.Context) { booking_page.BookingPage.List(c) })
+		bookingPageGroup.POST("", func(c *gin.Context) { booking_page.BookingPage.Add(c) })
+		bookingPageGroup.GET("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Get(c) })
+		bookingPageGroup.PUT("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Update(c) })
+		bookingPageGroup.DELETE("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Delete(c) })
+		bookingPageGroup.GET("/:booking_page_id/bookings", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.ListBookings(c) })
+	}
+
+	// ===== ROUTES PUBLIQUES DE PRISE DE RENDEZ-VOUS (sans authentification) =====
+	publicBookingGroup := router.Group("/booking/:slug")
+	publicBookingGroup.Use(middleware.PublicBookingPageMiddleware("slug"))
+	{
+		publicBookingGroup.GET("", func(c *gin.Context) { booking_page.BookingPage.Public(c) })
+		publicBookingGroup.GET("/slots", func(c *gin.Context) { booking_page.BookingPage.Slots(c) })
+		// Réservation et annulation sans compte, limitées par adresse IP : elles créent et annulent des événements dans le calendrier du propriétaire
+		publicBookingGroup.POST("",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { booking_page.BookingPage.Book(c) },
+		)
+		publicBookingGroup.POST("/cancel",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { booking_page.BookingPage.Cancel(c) },
+		)
 	}
 }
@@ -2,27 +2,38 @@
 package session
 
 import (
-	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
+	"go-averroes/internal/accesstoken"
+	"go-averroes/internal/authenticator"
 	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
+	"go-averroes/internal/login_guard"
+	"go-averroes/internal/passkey"
+	"go-averroes/internal/sso"
+	"go-averroes/internal/two_factor"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type SessionStruct struct{}
 
 var Session = SessionStruct{}
 
+const (
+	// sessionTokenTTL est la durée de vie d'un token de session
+	sessionTokenTTL = 1 * time.Hour
+	// refreshTokenTTL est la durée de vie absolue d'une session : au-delà, une nouvelle connexion est nécessaire
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
 // Login authentifie un utilisateur et crée une session
 // @Summary Connexion utilisateur
-// @Description Authentifie un utilisateur et crée une session. Retourne un token de session, un refresh token, l'utilisateur et ses rôles.
+// @Description Authentifie un utilisateur et crée une session. Les identifiants sont vérifiés auprès de l'annuaire LDAP s'il est configuré, puis de la base locale. Après 3 échecs consécutifs pour une adresse, chaque tentative est retardée (délai doublé à chaque échec) ; après 10, l'adresse est verrouillée 15 minutes ou jusqu'au déverrouillage par un administrateur. Une adresse IP cumulant 30 échecs en 15 minutes est également freinée. Une tentative qui ne peut être vérifiée faute d'annuaire joignable (503) compte comme un échec. Retourne un token de session, un refresh token, l'utilisateur et ses rôles. Si l'authentification à deux facteurs est activée, retourne à la place un challenge_token à échanger contre la session sur /auth/2fa/verify.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -30,6 +41,10 @@ var Session = SessionStruct{}
 // @Success 200 {object} common.JSONResponse
 // @Failure 400 {object} common.JSONErrorResponse
 // @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Failure 500 {object} common.JSONErrorResponse
+// @Failure 503 {object} common.JSONErrorResponse
 // @Router /auth/login [post]
 func (SessionStruct) Login(c *gin.Context) {
 	slog.Info(common.LogLoginAttempt)
@@ -46,47 +61,372 @@ func (SessionStruct) Login(c *gin.Context) {
 		return
 	}
 
-	// Récupérer l'utilisateur et son mot de passe
-	var user common.User
-	var passwordHash string
-	err := common.DB.QueryRow(`
-		SELECT u.user_id, u.lastname, u.firstname, u.email, u.created_at, u.updated_at, u.deleted_at, up.password_hash
-		FROM user u
-		INNER JOIN user_password up ON u.user_id = up.user_id
-		WHERE u.email = ? AND u.deleted_at IS NULL AND up.deleted_at IS NULL
-	`, req.Email).Scan(
-		&user.UserID,
-		&user.Lastname,
-		&user.Firstname,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.DeletedAt,
-		&passwordHash,
-	)
+	// Refuser la tentative, sans vérifier le mot de passe, si l'adresse ou l'adresse IP a trop d'échecs récents
+	if !loginAllowed(c, req.Email) {
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		slog.Error(common.ErrUserNotFound)
+	// Vérifier les identifiants auprès des sources configurées (base locale, annuaire LDAP)
+	userID, err := authenticator.Default.Authenticate(c.Request.Context(), req.Email, req.Password)
+	if errors.Is(err, authenticator.ErrInvalidCredentials) {
+		loginFailed(c, req.Email)
+		return
+	}
+	if err != nil {
+		// Annuaire injoignable : la tentative est comptée, sans quoi les mots de passe locaux
+		// pourraient être essayés sans limite pendant l'indisponibilité
+		slog.Error(common.LogLoginAttempt + " - " + err.Error())
+		recordFailure(c, req.Email, login_guard.MethodPassword)
+		c.JSON(http.StatusServiceUnavailable, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrAuthenticationUnavailable,
+		})
+		return
+	}
+	user, err := findUser(userID)
+	if err != nil {
+		slog.Error(common.LogLoginAttempt + " - utilisateur introuvable : " + err.Error())
+		loginFailed(c, req.Email)
+		return
+	}
+
+	// Selon la politique configurée, l'adresse e-mail doit avoir été vérifiée.
+	// Le contrôle suit celui du mot de passe pour ne rien révéler sans identifiants valides.
+	if email_verification.Policy == common.EmailVerificationPolicyLogin && user.EmailVerifiedAt == nil {
+		slog.Error(common.LogEmailNotVerified, "user_id", user.UserID)
+		c.JSON(http.StatusForbidden, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailNotVerified,
+		})
+		return
+	}
+
+	// Avec l'authentification à deux facteurs, la session n'est créée qu'après vérification du code
+	twoFactorEnabled, err := two_factor.Enabled(user.UserID)
+	if err != nil {
+		slog.Error(common.LogTwoFactorChallenge + " - erreur lors de la vérification : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	if twoFactorEnabled {
+		challengeToken, expiresAt, err := two_factor.IssueChallenge(user.UserID)
+		if err != nil {
+			slog.Error(common.LogTwoFactorChallenge + " - erreur lors de la création du challenge : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTokenGeneration,
+			})
+			return
+		}
+		slog.Info(common.LogTwoFactorChallenge, "user_id", user.UserID)
+		c.JSON(http.StatusOK, common.JSONResponse{
+			Success: true,
+			Message: common.MsgTwoFactorRequired,
+			Data: common.LoginChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    challengeToken,
+				ExpiresAt:         expiresAt,
+			},
+		})
+		return
+	}
+
+	startSession(c, user, login_guard.MethodPassword)
+}
+
+// loginAllowed applique le contrôle des échecs récents de l'adresse et de l'adresse IP ;
+// si la tentative est refusée, la réponse (429 ou 500) est envoyée et false est retourné
+func loginAllowed(c *gin.Context, email string) bool {
+	wait, err := login_guard.Check(email, c.ClientIP())
+	if errors.Is(err, login_guard.ErrAccountLocked) || errors.Is(err, login_guard.ErrThrottled) {
+		slog.Warn(common.LogLoginThrottled, "ip", c.ClientIP(), "retry_after", wait.String())
+		login_guard.RetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, common.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return false
+	}
+	if err != nil {
+		slog.Error(common.LogLoginAttempt + " - erreur lors du contrôle des échecs : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrLoginGuard,
+		})
+		return false
+	}
+	return true
+}
+
+// loginFailed enregistre l'échec d'une connexion par mot de passe et répond 401.
+// La requête n'est pas renvoyée : elle contient le mot de passe saisi.
+func loginFailed(c *gin.Context, email string) {
+	recordFailure(c, email, login_guard.MethodPassword)
+	c.JSON(http.StatusUnauthorized, common.JSONResponse{
+		Success: false,
+		Error:   common.ErrInvalidCredentials,
+	})
+}
+
+// recordFailure inscrit l'échec d'une connexion au décompte de l'adresse, ou de la seule adresse IP si email est vide
+func recordFailure(c *gin.Context, email, method string) {
+	var err error
+	if email == "" {
+		err = login_guard.RecordUnidentifiedFailure(c.ClientIP(), method)
+	} else {
+		err = login_guard.RecordFailure(email, c.ClientIP(), method)
+	}
+	if err != nil {
+		slog.Error(common.LogLoginAttempt + " - erreur lors de l'enregistrement de l'échec : " + err.Error())
+	}
+}
+
+// VerifyTwoFactor termine une connexion en deux étapes
+// @Summary Connexion : second facteur
+// @Description Échange le challenge_token retourné par /auth/login et un code TOTP (ou un code de récupération, consommé) contre une session. Après 5 codes erronés, il faut se reconnecter. Chaque code erroné compte parmi les échecs de connexion du compte, dont le verrouillage s'applique avant la vérification du code ; la connexion n'est inscrite au journal de sécurité qu'une fois le code accepté.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.TwoFactorVerifyRequest true "Challenge et code"
+// @Success 200 {object} common.JSONResponse{data=common.LoginResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/2fa/verify [post]
+func (SessionStruct) VerifyTwoFactor(c *gin.Context) {
+	slog.Info(common.LogTwoFactorVerify)
+	var req common.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTwoFactorVerify + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	// Le jeton désigne le compte : son verrouillage s'applique avant la vérification du code
+	userID, err := two_factor.PendingChallenge(req.ChallengeToken)
+	if err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+	user, err := findUser(userID)
+	if err != nil {
+		slog.Error(common.LogTwoFactorVerify + " - utilisateur introuvable : " + err.Error())
+		twoFactorFailed(c, two_factor.ErrInvalidChallenge)
+		return
+	}
+	if !loginAllowed(c, user.Email) {
+		return
+	}
+
+	// Un code erroné compte parmi les échecs de connexion du compte
+	if _, err := two_factor.ConsumeChallenge(req.ChallengeToken, req.Code); err != nil {
+		if errors.Is(err, two_factor.ErrInvalidCode) {
+			recordFailure(c, user.Email, login_guard.MethodTwoFactor)
+		}
+		twoFactorFailed(c, err)
+		return
+	}
+
+	startSession(c, user, login_guard.MethodTwoFactor)
+}
+
+// twoFactorFailed répond 401 pour un jeton ou un code refusé, 500 pour toute autre erreur
+func twoFactorFailed(c *gin.Context, err error) {
+	if errors.Is(err, two_factor.ErrInvalidChallenge) || errors.Is(err, two_factor.ErrInvalidCode) {
+		slog.Error(common.LogTwoFactorVerify + " - " + err.Error())
+		c.JSON(http.StatusUnauthorized, common.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	slog.Error(common.LogTwoFactorVerify + " - erreur lors de la vérification : " + err.Error())
+	c.JSON(http.StatusInternalServerError, common.JSONResponse{
+		Success: false,
+		Error:   common.ErrTwoFactor,
+	})
+}
+
+// LoginWithPasskey authentifie un utilisateur par passkey et crée une session
+// @Summary Connexion par passkey
+// @Description Vérifie la réponse de navigator.credentials.get() obtenue avec les options de /auth/passkeys/login/options et crée une session, sans mot de passe. La passkey exigeant la vérification de l'utilisateur (code ou biométrie), aucun second facteur n'est demandé. Le verrouillage du compte s'applique ; une signature refusée compte parmi ses échecs de connexion.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.PasskeyLoginRequest true "Réponse du navigateur"
+// @Success 200 {object} common.JSONResponse{data=common.LoginResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/passkeys/login [post]
+func (SessionStruct) LoginWithPasskey(c *gin.Context) {
+	slog.Info(common.LogPasskeyLogin)
+	var req common.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogPasskeyLogin + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	userID, err := passkey.Authenticate(req)
+	if errors.Is(err, passkey.ErrInvalidAssertion) {
+		// Une passkey connue dont la signature est refusée compte parmi les échecs de son compte
+		email := ""
+		if userID != 0 {
+			if user, findErr := findUser(userID); findErr == nil {
+				email = user.Email
+			}
+		}
+		recordFailure(c, email, login_guard.MethodPasskey)
 		c.JSON(http.StatusUnauthorized, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrInvalidCredentials,
-			Data:    req,
+			Error:   common.ErrInvalidPasskey,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogPasskeyLogin + " - erreur lors de la vérification : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrPasskey,
 		})
 		return
 	}
 
-	// Vérifier le mot de passe
-	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password))
+	user, err := findUser(userID)
 	if err != nil {
-		slog.Error(common.LogInvalidPassword)
+		slog.Error(common.LogPasskeyLogin + " - utilisateur introuvable : " + err.Error())
 		c.JSON(http.StatusUnauthorized, common.JSONResponse{
 			Success: false,
-			Error:   common.ErrInvalidCredentials,
-			Data:    req,
+			Error:   common.ErrInvalidPasskey,
+		})
+		return
+	}
+	if !loginAllowed(c, user.Email) {
+		return
+	}
+	if email_verification.Policy == common.EmailVerificationPolicyLogin && user.EmailVerifiedAt == nil {
+		slog.Error(common.LogEmailNotVerified, "user_id", user.UserID)
+		c.JSON(http.StatusForbidden, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailNotVerified,
 		})
 		return
 	}
 
+	startSession(c, user, login_guard.MethodPasskey)
+}
+
+// LoginWithOIDC termine une connexion SSO et crée une session
+// @Summary Connexion SSO (OpenID Connect)
+// @Description Échange le code reçu du fournisseur d'identité (avec le code verifier PKCE conservé par /auth/oidc/authorize) et crée une session pour le compte dont l'adresse e-mail figure dans l'ID token. Le compte est créé à la première connexion si OIDC_AUTO_PROVISION est activé ; les rôles du claim OIDC_ROLES_CLAIM sont attribués. Le second facteur relève du fournisseur d'identité. Le verrouillage du compte s'applique ; un refus du fournisseur compte parmi les échecs de l'adresse IP.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.OIDCCallbackRequest true "Paramètres code et state reçus par la page de retour"
+// @Success 200 {object} common.JSONResponse{data=common.LoginResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Router /auth/oidc/callback [post]
+func (SessionStruct) LoginWithOIDC(c *gin.Context) {
+	slog.Info(common.LogOIDCLogin)
+	var req common.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogOIDCLogin + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	userID, err := sso.Authenticate(c.Request.Context(), req.Code, req.State)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := common.ErrOIDC
+		switch {
+		case errors.Is(err, sso.ErrNotConfigured):
+			status, message = http.StatusNotFound, common.ErrOIDCNotConfigured
+		case errors.Is(err, sso.ErrInvalidState):
+			status, message = http.StatusBadRequest, common.ErrInvalidOIDCState
+		case errors.Is(err, sso.ErrAuthentication):
+			status, message = http.StatusUnauthorized, common.ErrOIDCAuthentication
+			recordFailure(c, "", login_guard.MethodOIDC)
+		case errors.Is(err, sso.ErrEmailNotVerified):
+			status, message = http.StatusForbidden, common.ErrOIDCEmailNotVerified
+		case errors.Is(err, sso.ErrAccountNotFound):
+			status, message = http.StatusForbidden, common.ErrOIDCAccountNotFound
+		default:
+			slog.Error(common.LogOIDCLogin + " - erreur lors de la connexion : " + err.Error())
+		}
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	user, err := findUser(userID)
+	if err != nil {
+		slog.Error(common.LogOIDCLogin + " - utilisateur introuvable : " + err.Error())
+		c.JSON(http.StatusForbidden, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOIDCAccountNotFound,
+		})
+		return
+	}
+	if !loginAllowed(c, user.Email) {
+		return
+	}
+	if email_verification.Policy == common.EmailVerificationPolicyLogin && user.EmailVerifiedAt == nil {
+		slog.Error(common.LogEmailNotVerified, "user_id", user.UserID)
+		c.JSON(http.StatusForbidden, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEmailNotVerified,
+		})
+		return
+	}
+
+	slog.Info(common.LogOIDCLogin+" - succès", "user_id", user.UserID)
+	startSession(c, user, login_guard.MethodOIDC)
+}
+
+// findUser retourne l'utilisateur actif authentifié par un moyen autre que le mot de passe
+func findUser(userID int) (common.User, error) {
+	var user common.User
+	err := common.DB.QueryRow(`
+		SELECT user_id, lastname, firstname, email, email_verified_at, created_at, updated_at, deleted_at
+		FROM user
+		WHERE user_id = ? AND deleted_at IS NULL
+	`, userID).Scan(
+		&user.UserID,
+		&user.Lastname,
+		&user.Firstname,
+		&user.Email,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	return user, err
+}
+
+// startSession crée une session pour l'utilisateur authentifié par le moyen method, inscrit la connexion
+// au journal de sécurité et retourne les jetons
+func startSession(c *gin.Context, user common.User, method string) {
 	// Récupérer les rôles de l'utilisateur
 	roles, err := GetUserRoles(user.UserID)
 	if err != nil {
@@ -99,7 +439,7 @@ func (SessionStruct) Login(c *gin.Context) {
 	}
 
 	// Générer les tokens
-	sessionToken, err := generateToken()
+	sessionToken, err := common.GenerateToken(32)
 	if err != nil {
 		slog.Error("Erreur lors de la génération du token de session: " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -109,7 +449,7 @@ func (SessionStruct) Login(c *gin.Context) {
 		return
 	}
 
-	refreshToken, err := generateToken()
+	refreshToken, err := common.GenerateToken(32)
 	if err != nil {
 		slog.Error("Erreur lors de la génération du refresh token: " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -119,8 +459,9 @@ func (SessionStruct) Login(c *gin.Context) {
 		return
 	}
 
-	// Définir l'expiration (1 heure pour le session token)
-	sessionExpiresAt := time.Now().Add(1 * time.Hour)
+	// Définir les expirations : le session token est renouvelé par le refresh token jusqu'à l'échéance de la session
+	sessionExpiresAt := time.Now().Add(sessionTokenTTL)
+	refreshExpiresAt := time.Now().Add(refreshTokenTTL)
 
 	// Récupérer les informations de l'appareil
 	deviceInfo := c.GetHeader("User-Agent")
@@ -136,11 +477,15 @@ func (SessionStruct) Login(c *gin.Context) {
 		"location", location,
 	)
 
-	// Créer la session en base
-	_, err = common.DB.Exec(`
-		INSERT INTO user_session (user_id, session_token, refresh_token, expires_at, device_info, ip_address, location, is_active, created_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, TRUE, NOW())
-	`, user.UserID, sessionToken, refreshToken, sessionExpiresAt, deviceInfo, ipAddress, location)
+	// Créer la session en base ; seules les empreintes des tokens sont conservées
+	result, err := common.DB.Exec(`
+		INSERT INTO user_session (user_id, session_token_hash, refresh_token_hash, expires_at, refresh_expires_at, device_info, ip_address, location, is_active, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, TRUE, NOW())
+	`, user.UserID, common.HashToken(sessionToken), common.HashToken(refreshToken), sessionExpiresAt, refreshExpiresAt, deviceInfo, ipAddress, location)
+	var sessionID int64
+	if err == nil {
+		sessionID, err = result.LastInsertId()
+	}
 	if err != nil {
 		slog.Error("Erreur lors de la création de la session: " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -150,12 +495,29 @@ func (SessionStruct) Login(c *gin.Context) {
 		return
 	}
 
+	// En mode tokens signés, le token de session opaque n'est pas remis : il identifie seulement la session en base
+	sessionToken, sessionExpiresAt, err = issueAccessToken(user, roles, int(sessionID), sessionToken, sessionExpiresAt)
+	if err != nil {
+		slog.Error(fmt.Sprintf(common.LogAccessTokenError, err.Error()))
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTokenGeneration,
+		})
+		return
+	}
+
 	response := common.LoginResponse{
-		User:         user,
-		SessionToken: sessionToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    sessionExpiresAt,
-		Roles:        roles,
+		User:             user,
+		SessionToken:     sessionToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        sessionExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+		Roles:            roles,
+	}
+
+	// La connexion n'est inscrite, et le décompte des échecs remis à zéro, qu'une fois la session créée
+	if err := login_guard.RecordSuccess(user.Email, user.UserID, ipAddress, method); err != nil {
+		slog.Error(common.LogLoginAttempt + " - erreur lors de l'enregistrement de la connexion : " + err.Error())
 	}
 
 	slog.Info(fmt.Sprintf(common.LogLoginSuccess, user.Email))
@@ -200,12 +562,31 @@ func (SessionStruct) Logout(c *gin.Context) {
 		return
 	}
 
-	// Désactiver la session
-	_, err := common.DB.Exec(`
-		UPDATE user_session 
-		SET is_active = FALSE, updated_at = NOW() 
-		WHERE session_token = ? AND is_active = TRUE
-	`, token)
+	// Désactiver la session : un token d'accès signé la désigne par son identifiant, un token opaque par son empreinte
+	var err error
+	if accesstoken.Default != nil && accesstoken.Default.Recognizes(token) {
+		claims, verifyErr := accesstoken.Default.Verify(token)
+		if verifyErr != nil {
+			slog.Error(common.LogInvalidToken + ": " + verifyErr.Error())
+			c.JSON(http.StatusUnauthorized, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrSessionInvalid,
+			})
+			return
+		}
+		_, err = common.DB.Exec(`
+			UPDATE user_session 
+			SET is_active = FALSE, updated_at = NOW() 
+			WHERE user_session_id = ? AND is_active = TRUE
+		`, claims.SessionID)
+		revokeAccessTokens(claims.SessionID)
+	} else {
+		_, err = common.DB.Exec(`
+			UPDATE user_session 
+			SET is_active = FALSE, updated_at = NOW() 
+			WHERE session_token_hash = ? AND is_active = TRUE
+		`, common.HashToken(token))
+	}
 	if err != nil {
 		slog.Error("Erreur lors de la déconnexion: " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -224,7 +605,7 @@ func (SessionStruct) Logout(c *gin.Context) {
 
 // RefreshToken rafraîchit un token de session
 // @Summary Rafraîchissement de token
-// @Description Rafraîchit un token de session à partir d'un refresh token.
+// @Description Rafraîchit un token de session à partir d'un refresh token. Le refresh token est à usage unique : un nouveau refresh token est retourné à chaque rafraîchissement, jusqu'à l'échéance absolue de la session (refresh_expires_at). Présenter à nouveau un refresh token déjà utilisé révoque la session.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -232,6 +613,7 @@ func (SessionStruct) Logout(c *gin.Context) {
 // @Success 200 {object} common.JSONResponse
 // @Failure 400 {object} common.JSONErrorResponse
 // @Failure 401 {object} common.JSONErrorResponse
+// @Failure 500 {object} common.JSONErrorResponse
 // @Router /auth/refresh [post]
 func (SessionStruct) RefreshToken(c *gin.Context) {
 	slog.Info(common.LogRefreshTokenAttempt)
@@ -248,28 +630,47 @@ func (SessionStruct) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Vérifier le refresh token
-	var session common.UserSession
-	err := common.DB.QueryRow(`
-		SELECT user_session_id, user_id, session_token, refresh_token, expires_at, device_info, ip_address, location, is_active, created_at, updated_at, deleted_at
+	tx, err := common.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		slog.Error(fmt.Sprintf(common.LogRefreshTokenError, err.Error()))
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrSessionUpdate,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Vérifier le refresh token, recherché par son empreinte ; le verrou sérialise les rafraîchissements concurrents d'une même session
+	refreshTokenHash := common.HashToken(req.RefreshToken)
+	var sessionID, userID int
+	var refreshExpiresAt time.Time
+	err = tx.QueryRow(`
+		SELECT user_session_id, user_id, refresh_expires_at
 		FROM user_session 
-		WHERE refresh_token = ? AND is_active = TRUE AND deleted_at IS NULL
-	`, req.RefreshToken).Scan(
-		&session.UserSessionID,
-		&session.UserID,
-		&session.SessionToken,
-		&session.RefreshToken,
-		&session.ExpiresAt,
-		&session.DeviceInfo,
-		&session.IPAddress,
-		&session.Location,
-		&session.IsActive,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-		&session.DeletedAt,
-	)
+		WHERE refresh_token_hash = ? AND is_active = TRUE AND deleted_at IS NULL
+		FOR UPDATE
+	`, refreshTokenHash).Scan(&sessionID, &userID, &refreshExpiresAt)
 
 	if err == sql.ErrNoRows {
+		// Un refresh token déjà utilisé a pu être dérobé : toute la session est révoquée,
+		// ce qui invalide aussi bien le voleur que l'utilisateur légitime
+		reused, err := revokeReusedRefreshToken(tx, refreshTokenHash)
+		if err != nil {
+			slog.Error(fmt.Sprintf(common.LogRefreshTokenError, err.Error()))
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrSessionUpdate,
+			})
+			return
+		}
+		if reused {
+			c.JSON(http.StatusUnauthorized, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrRefreshTokenReused,
+			})
+			return
+		}
 		slog.Error(common.LogInvalidRefreshToken)
 		c.JSON(http.StatusUnauthorized, common.JSONResponse{
 			Success: false,
@@ -277,9 +678,17 @@ func (SessionStruct) RefreshToken(c *gin.Context) {
 		})
 		return
 	}
+	if err != nil {
+		slog.Error(fmt.Sprintf(common.LogRefreshTokenError, err.Error()))
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrSessionUpdate,
+		})
+		return
+	}
 
-	// Vérifier si le refresh token n'est pas expiré
-	if time.Now().After(session.ExpiresAt) {
+	// Vérifier si le refresh token n'est pas expiré : l'échéance de la session ne recule jamais
+	if time.Now().After(refreshExpiresAt) {
 		slog.Error(common.LogRefreshTokenExpired)
 		c.JSON(http.StatusUnauthorized, common.JSONResponse{
 			Success: false,
@@ -288,8 +697,17 @@ func (SessionStruct) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Générer un nouveau session token
-	newSessionToken, err := generateToken()
+	// Générer un nouveau session token et un nouveau refresh token
+	newSessionToken, err := common.GenerateToken(32)
+	if err != nil {
+		slog.Error(fmt.Sprintf(common.LogTokenGenerationError, err.Error()))
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTokenGeneration,
+		})
+		return
+	}
+	newRefreshToken, err := common.GenerateToken(32)
 	if err != nil {
 		slog.Error(fmt.Sprintf(common.LogTokenGenerationError, err.Error()))
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -299,13 +717,50 @@ func (SessionStruct) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Mettre à jour la session avec le nouveau token
-	newExpiresAt := time.Now().Add(1 * time.Hour)
-	_, err = common.DB.Exec(`
-		UPDATE user_session 
-		SET session_token = ?, expires_at = ?, updated_at = NOW() 
-		WHERE user_session_id = ?
-	`, newSessionToken, newExpiresAt, session.UserSessionID)
+	// En mode tokens signés, le token d'accès reprend l'utilisateur et ses rôles actuels
+	newExpiresAt := time.Now().Add(sessionTokenTTL)
+	accessToken, accessExpiresAt := newSessionToken, newExpiresAt
+	if accesstoken.Default != nil {
+		user, err := findUser(userID)
+		if err == sql.ErrNoRows {
+			slog.Error(common.LogInvalidRefreshToken)
+			c.JSON(http.StatusUnauthorized, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrSessionInvalid,
+			})
+			return
+		}
+		var roles []common.Role
+		if err == nil {
+			roles, err = GetUserRoles(userID)
+		}
+		if err == nil {
+			accessToken, accessExpiresAt, err = issueAccessToken(user, roles, sessionID, newSessionToken, newExpiresAt)
+		}
+		if err != nil {
+			slog.Error(fmt.Sprintf(common.LogAccessTokenError, err.Error()))
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTokenGeneration,
+			})
+			return
+		}
+	}
+
+	// Conserver l'ancien refresh token pour détecter sa réutilisation, puis mettre à jour la session
+	if _, err = tx.Exec(`
+		INSERT INTO used_refresh_token (user_session_id, refresh_token_hash, used_at)
+		VALUES (?, ?, NOW())
+	`, sessionID, refreshTokenHash); err == nil {
+		_, err = tx.Exec(`
+			UPDATE user_session 
+			SET session_token_hash = ?, refresh_token_hash = ?, expires_at = ?, updated_at = NOW() 
+			WHERE user_session_id = ?
+		`, common.HashToken(newSessionToken), common.HashToken(newRefreshToken), newExpiresAt, sessionID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		slog.Error(fmt.Sprintf(common.LogSessionUpdateError, err.Error()))
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -320,12 +775,43 @@ func (SessionStruct) RefreshToken(c *gin.Context) {
 		Success: true,
 		Message: common.MsgSuccessRefreshToken,
 		Data: gin.H{
-			"session_token": newSessionToken,
-			"expires_at":    newExpiresAt,
+			"session_token":      accessToken,
+			"refresh_token":      newRefreshToken,
+			"expires_at":         accessExpiresAt,
+			"refresh_expires_at": refreshExpiresAt,
 		},
 	})
 }
 
+// revokeReusedRefreshToken révoque la session à laquelle appartient un refresh token déjà utilisé,
+// désigné par son empreinte ; retourne false si le token n'a jamais été émis
+func revokeReusedRefreshToken(tx *sql.Tx, refreshTokenHash string) (bool, error) {
+	var sessionID int
+	err := tx.QueryRow(`
+		SELECT user_session_id FROM used_refresh_token WHERE refresh_token_hash = ?
+	`, refreshTokenHash).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE user_session 
+		SET is_active = FALSE, updated_at = NOW() 
+		WHERE user_session_id = ? AND is_active = TRUE
+	`, sessionID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	revokeAccessTokens(sessionID)
+	slog.Warn(fmt.Sprintf(common.LogRefreshTokenReuse, sessionID))
+	return true, nil
+}
+
 // GetUserSessions récupère toutes les sessions d'un utilisateur
 // @Summary Liste des sessions utilisateur
 // @Description Récupère toutes les sessions actives de l'utilisateur connecté.
@@ -347,8 +833,9 @@ func (SessionStruct) GetUserSessions(c *gin.Context) {
 		return
 	}
 
+	// Les empreintes des tokens ne sont jamais retournées
 	rows, err := common.DB.Query(`
-		SELECT user_session_id, user_id, session_token, refresh_token, expires_at, device_info, ip_address, location, is_active, created_at, updated_at, deleted_at
+		SELECT user_session_id, user_id, expires_at, device_info, ip_address, location, is_active, created_at, updated_at, deleted_at
 		FROM user_session 
 		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -366,14 +853,11 @@ func (SessionStruct) GetUserSessions(c *gin.Context) {
 	var sessions []common.UserSession
 	for rows.Next() {
 		var session common.UserSession
-		err := rows.Scan(&session.UserSessionID, &session.UserID, &session.SessionToken, &session.RefreshToken, &session.ExpiresAt, &session.DeviceInfo, &session.IPAddress, &session.Location, &session.IsActive, &session.CreatedAt, &session.UpdatedAt, &session.DeletedAt)
+		err := rows.Scan(&session.UserSessionID, &session.UserID, &session.ExpiresAt, &session.DeviceInfo, &session.IPAddress, &session.Location, &session.IsActive, &session.CreatedAt, &session.UpdatedAt, &session.DeletedAt)
 		if err != nil {
 			slog.Error(fmt.Sprintf(common.LogSessionReadingError, err.Error()))
 			continue
 		}
-		// Masquer les tokens pour la sécurité
-		session.SessionToken = "***"
-		session.RefreshToken = nil
 		sessions = append(sessions, session)
 	}
 
@@ -441,6 +925,8 @@ func (SessionStruct) DeleteSession(c *gin.Context) {
 		return
 	}
 
+	revokeAccessTokens(existingSessionID)
+
 	slog.Info(common.LogSessionDeletedSuccess)
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
@@ -453,15 +939,16 @@ func (SessionStruct) ValidateSession(token string) (*common.User, error) {
 	var user common.User
 	var expiresAt time.Time
 	err := common.DB.QueryRow(`
-		SELECT u.user_id, u.lastname, u.firstname, u.email, u.created_at, u.updated_at, u.deleted_at, us.expires_at
+		SELECT u.user_id, u.lastname, u.firstname, u.email, u.email_verified_at, u.created_at, u.updated_at, u.deleted_at, us.expires_at
 		FROM user u
 		INNER JOIN user_session us ON u.user_id = us.user_id
-		WHERE us.session_token = ? AND us.is_active = TRUE AND us.deleted_at IS NULL AND u.deleted_at IS NULL
-	`, token).Scan(
+		WHERE us.session_token_hash = ? AND us.is_active = TRUE AND us.deleted_at IS NULL AND u.deleted_at IS NULL
+	`, common.HashToken(token)).Scan(
 		&user.UserID,
 		&user.Lastname,
 		&user.Firstname,
 		&user.Email,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -479,16 +966,106 @@ func (SessionStruct) ValidateSession(token string) (*common.User, error) {
 	return &user, nil
 }
 
-// Fonctions utilitaires
+// ValidateAccessToken valide le token présenté au middleware : token d'accès signé, vérifié sans accès à la base,
+// ou token de session opaque. Les rôles, portés par le token, ne sont retournés que pour un token signé.
+func (s SessionStruct) ValidateAccessToken(token string) (*common.User, []string, error) {
+	if accesstoken.Default == nil || !accesstoken.Default.Recognizes(token) {
+		user, err := s.ValidateSession(token)
+		return user, nil, err
+	}
+	claims, err := accesstoken.Default.Verify(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	roles := claims.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return &common.User{
+		UserID:          claims.UserID,
+		Lastname:        claims.Lastname,
+		Firstname:       claims.Firstname,
+		Email:           claims.Email,
+		EmailVerifiedAt: claims.EmailVerifiedAt,
+	}, roles, nil
+}
+
+// RevokedSessions retourne les sessions fermées (déconnexion, suppression, révocation, compte supprimé) depuis since ;
+// source de la synchronisation de la liste de révocation des tokens d'accès
+func RevokedSessions(since time.Time) ([]int, error) {
+	rows, err := common.DB.Query(`
+		SELECT us.user_session_id
+		FROM user_session us
+		INNER JOIN user u ON u.user_id = us.user_id
+		WHERE ((us.is_active = FALSE OR us.deleted_at IS NOT NULL) AND us.updated_at >= ?)
+			OR u.deleted_at >= ?
+	`, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []int
+	for rows.Next() {
+		var sessionID int
+		if err := rows.Scan(&sessionID); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sessionID)
+	}
+	return sessions, rows.Err()
+}
 
-// generateToken génère un token aléatoire
-func generateToken() (string, error) {
-	bytes := make([]byte, 32)
-	_, err := rand.Read(bytes)
+// ChangedUsers retourne les utilisateurs dont les rôles ou la vérification de l'adresse e-mail ont changé depuis since,
+// avec la date du changement ; source de la synchronisation de la liste de révocation des tokens d'accès
+func ChangedUsers(since time.Time) (map[int]time.Time, error) {
+	rows, err := common.DB.Query(`SELECT user_id, claims_changed_at FROM user WHERE claims_changed_at >= ?`, since)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := map[int]time.Time{}
+	for rows.Next() {
+		var userID int
+		var changedAt time.Time
+		if err := rows.Scan(&userID, &changedAt); err != nil {
+			return nil, err
+		}
+		users[userID] = changedAt
+	}
+	return users, rows.Err()
+}
+
+// Fonctions utilitaires
+
+// issueAccessToken signe un token d'accès pour la session lorsque les tokens signés sont activés ;
+// sinon retourne le token de session opaque et son expiration
+func issueAccessToken(user common.User, roles []common.Role, sessionID int, opaque string, opaqueExpiresAt time.Time) (string, time.Time, error) {
+	if accesstoken.Default == nil {
+		return opaque, opaqueExpiresAt, nil
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return accesstoken.Default.Issue(accesstoken.Claims{
+		UserID:          user.UserID,
+		SessionID:       sessionID,
+		Email:           user.Email,
+		Firstname:       user.Firstname,
+		Lastname:        user.Lastname,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Roles:           names,
+	})
+}
+
+// revokeAccessTokens refuse immédiatement les tokens d'accès signés de la session fermée ;
+// les autres instances l'apprennent à la synchronisation suivante
+func revokeAccessTokens(sessionID int) {
+	if accesstoken.Default != nil {
+		accesstoken.Default.Revoke(sessionID)
 	}
-	return hex.EncodeToString(bytes), nil
 }
 
 // extractTokenFromHeader extrait le token du header Authorization
@@ -10,7 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"go-averroes/internal/accesstoken"
+	"go-averroes/internal/authenticator"
 	"go-averroes/internal/common"
+	"go-averroes/internal/session"
 	"go-averroes/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -339,8 +342,8 @@ func TestRefreshTokenRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE refresh_token = ?
-				`, user.RefreshToken)
+					WHERE refresh_token_hash = ?
+				`, common.HashToken(user.RefreshToken))
 				require.NoError(t, err)
 
 				// Retourner les données de requête avec le refresh token de la session désactivée
@@ -399,6 +402,7 @@ func TestRefreshTokenRoute(t *testing.T) {
 
 					// Vérifier la présence des champs attendus
 					require.Contains(t, dataMap, "session_token", "Nouveau token de session manquant")
+					require.Contains(t, dataMap, "refresh_token", "Nouveau refresh token manquant")
 					require.Contains(t, dataMap, "expires_at", "Nouvelle date d'expiration manquante")
 
 					// Vérifier que le nouveau session token est différent de l'ancien
@@ -564,8 +568,8 @@ func TestLogoutRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de requête avec l'utilisateur pour le nettoyage et les headers
@@ -810,8 +814,8 @@ func TestGetUserSessionsRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur et les headers
@@ -883,7 +887,6 @@ func TestGetUserSessionsRoute(t *testing.T) {
 						// Vérifier la présence des champs attendus
 						require.Contains(t, session, "user_session_id", "ID de session manquant")
 						require.Contains(t, session, "user_id", "ID utilisateur manquant")
-						require.Contains(t, session, "session_token", "Token de session manquant")
 						require.Contains(t, session, "expires_at", "Date d'expiration manquante")
 						require.Contains(t, session, "device_info", "Informations appareil manquantes")
 						require.Contains(t, session, "ip_address", "Adresse IP manquante")
@@ -891,14 +894,10 @@ func TestGetUserSessionsRoute(t *testing.T) {
 						require.Contains(t, session, "is_active", "Statut actif manquant")
 						require.Contains(t, session, "created_at", "Date de création manquante")
 
-						// Vérifier que les tokens sont masqués pour la sécurité
-						sessionToken, ok := session["session_token"].(string)
-						require.True(t, ok, "Le token de session devrait être une chaîne")
-						require.Equal(t, "***", sessionToken, "Le token de session devrait être masqué")
-
-						// Vérifier que le refresh token est nil (masqué)
-						refreshToken := session["refresh_token"]
-						require.Nil(t, refreshToken, "Le refresh token devrait être masqué (nil)")
+						// Vérifier que ni les tokens ni leurs empreintes ne sont exposés
+						for _, field := range []string{"session_token", "refresh_token", "session_token_hash", "refresh_token_hash"} {
+							require.NotContains(t, session, field, "Le champ %s ne devrait pas être exposé", field)
+						}
 					}
 				}
 			} else {
@@ -941,7 +940,7 @@ func TestDeleteSessionRoute(t *testing.T) {
 
 				// Récupérer l'ID de la session à supprimer
 				var sessionID int
-				err = common.DB.QueryRow("SELECT user_session_id FROM user_session WHERE session_token = ?", sessionToken2).Scan(&sessionID)
+				err = common.DB.QueryRow("SELECT user_session_id FROM user_session WHERE session_token_hash = ?", common.HashToken(sessionToken2)).Scan(&sessionID)
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur, l'ID de session et les headers
@@ -1064,8 +1063,8 @@ func TestDeleteSessionRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur et les headers
@@ -1146,7 +1145,7 @@ func TestDeleteSessionRoute(t *testing.T) {
 
 				// Récupérer l'ID de la session du deuxième utilisateur
 				var sessionID int
-				err = common.DB.QueryRow("SELECT user_session_id FROM user_session WHERE session_token = ?", sessionToken2).Scan(&sessionID)
+				err = common.DB.QueryRow("SELECT user_session_id FROM user_session WHERE session_token_hash = ?", common.HashToken(sessionToken2)).Scan(&sessionID)
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec les utilisateurs, l'ID de session et les headers
@@ -1247,3 +1246,274 @@ func TestDeleteSessionRoute(t *testing.T) {
 		})
 	}
 }
+
+// TestLoginWithLDAP vérifie la connexion par l'annuaire LDAP : création du compte à la première connexion,
+// attribution des rôles des groupes et repli sur la base locale
+func TestLoginWithLDAP(t *testing.T) {
+	directory, err := testutils.NewMockLDAP()
+	require.NoError(t, err)
+	defer directory.Close()
+	directory.Add(testutils.MockLDAPEntry{DN: "cn=golendar,dc=example,dc=com", Password: "service"})
+	directory.Add(testutils.MockLDAPEntry{
+		DN:       "uid=camille,ou=people,dc=example,dc=com",
+		Password: "secret-camille",
+		Attributes: map[string][]string{
+			"objectClass": {"person"},
+			"mail":        {"camille.ldap@example.com"},
+			"givenName":   {"Camille"},
+			"sn":          {"Martin"},
+			"memberOf":    {"cn=planning-editors,ou=groups,dc=example,dc=com"},
+		},
+	})
+	cfg := common.LDAPConfig{
+		URL:             directory.URL(),
+		BindDN:          "cn=golendar,dc=example,dc=com",
+		BindPassword:    "service",
+		BaseDN:          "ou=people,dc=example,dc=com",
+		LoginAttribute:  "mail",
+		UserObjectClass: "person",
+		GroupAttribute:  "memberOf",
+		RoleMapping:     map[string]string{"cn=planning-editors,ou=groups,dc=example,dc=com": "editor"},
+		AutoProvision:   true,
+	}
+	require.NoError(t, authenticator.Init(cfg))
+	defer authenticator.Init(common.LDAPConfig{})
+
+	login := func(email, password string) (int, common.JSONResponse, common.LoginResponse) {
+		status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/login", map[string]string{"email": email, "password": password})
+		var session common.LoginResponse
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal(response.Data, &session))
+		}
+		return status, response.JSONResponse, session
+	}
+
+	// Première connexion : compte créé, adresse vérifiée par l'annuaire, rôles des groupes attribués
+	status, response, session := login("camille.ldap@example.com", "secret-camille")
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, "Camille", session.User.Firstname)
+	require.NotNil(t, session.User.EmailVerifiedAt)
+	var roles []string
+	for _, role := range session.Roles {
+		roles = append(roles, role.Name)
+	}
+	require.ElementsMatch(t, []string{"user", "editor"}, roles)
+
+	// Connexion suivante : même compte
+	status, response, again := login("camille.ldap@example.com", "secret-camille")
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.Equal(t, session.User.UserID, again.User.UserID)
+
+	status, response, _ = login("camille.ldap@example.com", "incorrect")
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrInvalidCredentials, response.Error)
+
+	// Les comptes locaux restent utilisables
+	local, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+	status, response, _ = login(local.User.Email, local.Password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Annuaire injoignable : repli sur la base locale, sinon service indisponible
+	directory.Close()
+	status, response, _ = login(local.User.Email, local.Password)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response, _ = login("camille.ldap@example.com", "secret-camille")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, common.ErrAuthenticationUnavailable, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestRefreshTokenRotation vérifie la rotation du refresh token et la révocation de la session à la réutilisation d'un ancien token
+func TestRefreshTokenRotation(t *testing.T) {
+	defer testutils.PurgeAllTestUsers()
+
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	refresh := func(refreshToken string) (int, common.JSONResponse, map[string]string) {
+		status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/refresh", map[string]string{"refresh_token": refreshToken})
+		var tokens map[string]string
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal(response.Data, &tokens))
+		}
+		return status, response.JSONResponse, tokens
+	}
+
+	// Chaque rafraîchissement émet un nouveau refresh token, sans repousser l'échéance de la session
+	status, response, first := refresh(user.RefreshToken)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.NotEqual(t, user.RefreshToken, first["refresh_token"])
+	require.NotEqual(t, user.SessionToken, first["session_token"])
+
+	status, response, second := refresh(first["refresh_token"])
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.NotEqual(t, first["refresh_token"], second["refresh_token"])
+	require.Equal(t, first["refresh_expires_at"], second["refresh_expires_at"])
+
+	// Réutilisation d'un refresh token déjà échangé : la session est révoquée
+	status, response, _ = refresh(user.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrRefreshTokenReused, response.Error)
+
+	// Le dernier refresh token émis et le session token associé ne sont plus valides
+	status, response, _ = refresh(second["refresh_token"])
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrSessionInvalid, response.Error)
+
+	req, err := http.NewRequest("GET", testServer.URL+"/auth/sessions", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+second["session_token"])
+	resp, err := testClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+// TestSessionTokensStoredHashed vérifie que seules les empreintes des tokens émis à la connexion sont conservées
+func TestSessionTokensStoredHashed(t *testing.T) {
+	defer testutils.PurgeAllTestUsers()
+
+	user, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(map[string]string{"email": user.User.Email, "password": user.Password})
+	require.NoError(t, err)
+	resp, err := testClient.Post(testServer.URL+"/auth/login", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var response struct {
+		common.JSONResponse
+		Data common.LoginResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+
+	var sessionTokenHash, refreshTokenHash string
+	err = common.DB.QueryRow(`
+		SELECT session_token_hash, refresh_token_hash FROM user_session WHERE user_id = ?
+	`, user.User.UserID).Scan(&sessionTokenHash, &refreshTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, common.HashToken(response.Data.SessionToken), sessionTokenHash)
+	require.Equal(t, common.HashToken(response.Data.RefreshToken), refreshTokenHash)
+
+	// Le token présenté est bien accepté, son empreinte ne l'est pas
+	for token, expected := range map[string]int{response.Data.SessionToken: http.StatusOK, sessionTokenHash: http.StatusUnauthorized} {
+		req, err := http.NewRequest("GET", testServer.URL+"/auth/sessions", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := testClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, expected, resp.StatusCode)
+	}
+}
+
+// TestSignedAccessTokens vérifie le mode tokens d'accès signés : rôles portés par le token, rotation et déconnexion
+func TestSignedAccessTokens(t *testing.T) {
+	defer testutils.PurgeAllTestUsers()
+	require.NoError(t, accesstoken.Init(common.AccessTokenConfig{
+		Format: common.AccessTokenFormatJWT,
+		Keys:   []common.AccessTokenKey{{ID: "test", Seed: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"}},
+		TTL:    5 * time.Minute,
+		Issuer: "golendar",
+	}))
+	defer accesstoken.Init(common.AccessTokenConfig{})
+
+	admin, err := testutils.GenerateAuthenticatedAdmin(false, true, false, false)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, body interface{}) (int, json.RawMessage) {
+		status, response := testutils.DoRequest(t, testServer, token, method, path, body)
+		return status, response.Data
+	}
+
+	status, data := call("POST", "/auth/login", "", map[string]string{"email": admin.User.Email, "password": admin.Password})
+	require.Equal(t, http.StatusOK, status)
+	var login common.LoginResponse
+	require.NoError(t, json.Unmarshal(data, &login))
+	claims, err := accesstoken.Default.Verify(login.SessionToken)
+	require.NoError(t, err)
+	require.Contains(t, claims.Roles, "admin")
+
+	// Les rôles sont ceux du token jusqu'à son renouvellement
+	_, err = common.DB.Exec("UPDATE user_roles SET deleted_at = NOW() WHERE user_id = ?", admin.User.UserID)
+	require.NoError(t, err)
+	status, _ = call("GET", "/roles", login.SessionToken, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	status, data = call("POST", "/auth/refresh", "", map[string]string{"refresh_token": login.RefreshToken})
+	require.Equal(t, http.StatusOK, status)
+	var refreshed map[string]string
+	require.NoError(t, json.Unmarshal(data, &refreshed))
+	status, _ = call("GET", "/roles", refreshed["session_token"], nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// Déconnexion : les tokens d'accès de la session sont refusés et la session figure dans la liste de révocation
+	status, _ = call("POST", "/auth/logout", refreshed["session_token"], nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = call("GET", "/auth/sessions", refreshed["session_token"], nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = call("GET", "/auth/sessions", login.SessionToken, nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	revoked, err := session.RevokedSessions(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Contains(t, revoked, claims.SessionID)
+}
+
+// TestSignedAccessTokensClaimsChanged vérifie qu'un changement de rôles par l'API invalide les tokens d'accès
+// signés émis auparavant, et que le token obtenu au rafraîchissement porte les rôles à jour
+func TestSignedAccessTokensClaimsChanged(t *testing.T) {
+	defer testutils.PurgeAllTestUsers()
+	require.NoError(t, accesstoken.Init(common.AccessTokenConfig{
+		Format: common.AccessTokenFormatJWT,
+		Keys:   []common.AccessTokenKey{{ID: "test", Seed: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"}},
+		TTL:    5 * time.Minute,
+		Issuer: "golendar",
+	}))
+	defer accesstoken.Init(common.AccessTokenConfig{})
+
+	admin, err := testutils.GenerateAuthenticatedAdmin(false, true, false, false)
+	require.NoError(t, err)
+	target, err := testutils.GenerateAuthenticatedAdmin(false, true, false, false)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, body interface{}) (int, json.RawMessage) {
+		status, response := testutils.DoRequest(t, testServer, token, method, path, body)
+		return status, response.Data
+	}
+	login := func(user *testutils.AuthenticatedUser) common.LoginResponse {
+		status, data := call("POST", "/auth/login", "", map[string]string{"email": user.User.Email, "password": user.Password})
+		require.Equal(t, http.StatusOK, status)
+		var response common.LoginResponse
+		require.NoError(t, json.Unmarshal(data, &response))
+		return response
+	}
+	adminLogin, targetLogin := login(admin), login(target)
+	status, _ := call("GET", "/roles", targetLogin.SessionToken, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// iat est à la seconde près : le retrait doit intervenir après la seconde d'émission
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	var adminRoleID int
+	require.NoError(t, common.DB.QueryRow("SELECT role_id FROM roles WHERE name = 'admin' AND deleted_at IS NULL").Scan(&adminRoleID))
+	status, _ = call("POST", "/roles/revoke", adminLogin.SessionToken, map[string]int{"user_id": target.User.UserID, "role_id": adminRoleID})
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = call("GET", "/roles", targetLogin.SessionToken, nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+	changed, err := session.ChangedUsers(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Contains(t, changed, target.User.UserID)
+
+	status, data := call("POST", "/auth/refresh", "", map[string]string{"refresh_token": targetLogin.RefreshToken})
+	require.Equal(t, http.StatusOK, status)
+	var refreshed map[string]string
+	require.NoError(t, json.Unmarshal(data, &refreshed))
+	status, _ = call("GET", "/auth/sessions", refreshed["session_token"], nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = call("GET", "/roles", refreshed["session_token"], nil)
+	require.Equal(t, http.StatusForbidden, status)
+}
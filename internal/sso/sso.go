@@ -0,0 +1,216 @@
+// Package sso internal/sso/sso.go
+// Connexion SSO par un fournisseur d'identité OpenID Connect : l'adresse e-mail de l'ID token désigne le compte,
+// créé à la première connexion si OIDC_AUTO_PROVISION est activé.
+package sso
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-averroes/internal/authenticator"
+	"go-averroes/internal/common"
+	"go-averroes/internal/oidc"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SSOStruct struct{}
+
+var SSO = SSOStruct{}
+
+// stateTTL est la durée laissée à l'utilisateur pour se connecter chez le fournisseur
+const stateTTL = 10 * time.Minute
+
+var (
+	// ErrNotConfigured est retournée quand aucun fournisseur d'identité n'est configuré
+	ErrNotConfigured = errors.New(common.ErrOIDCNotConfigured)
+	// ErrInvalidState est retournée pour un state inconnu, expiré ou déjà utilisé
+	ErrInvalidState = errors.New(common.ErrInvalidOIDCState)
+	// ErrAuthentication est retournée quand l'échange du code ou la vérification de l'ID token échoue
+	ErrAuthentication = errors.New(common.ErrOIDCAuthentication)
+	// ErrAccountNotFound est retournée pour une adresse sans compte actif, sans création automatique
+	ErrAccountNotFound = errors.New(common.ErrOIDCAccountNotFound)
+	// ErrEmailNotVerified est retournée quand l'ID token ne contient pas d'adresse vérifiée
+	ErrEmailNotVerified = errors.New(common.ErrOIDCEmailNotVerified)
+)
+
+// Authorize démarre une connexion SSO
+// @Summary Démarrer une connexion SSO
+// @Description Génère l'URL du fournisseur d'identité (flux authorization code avec PKCE) vers laquelle rediriger l'utilisateur. Le state est valable 10 minutes et à usage unique ; le code et le state reçus par la page de retour sont à envoyer sur /auth/oidc/callback.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=common.OIDCAuthorizeResponse}
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 429 {object} common.JSONErrorResponse
+// @Failure 502 {object} common.JSONErrorResponse
+// @Router /auth/oidc/authorize [get]
+func (SSOStruct) Authorize(c *gin.Context) {
+	slog.Info(common.LogOIDCAuthorize)
+	provider := oidc.Default
+	if provider == nil {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOIDCNotConfigured,
+		})
+		return
+	}
+
+	request, err := oidc.NewAuthRequest()
+	if err != nil {
+		slog.Error(common.LogOIDCAuthorize + " - erreur lors de la génération du state : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOIDC,
+		})
+		return
+	}
+	authorizationURL, err := provider.AuthorizationURL(c.Request.Context(), request)
+	if err != nil {
+		slog.Error(common.LogOIDCAuthorize + " - fournisseur d'identité : " + err.Error())
+		c.JSON(http.StatusBadGateway, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOIDCAuthentication,
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(stateTTL)
+	_, err = common.DB.Exec(`
+		INSERT INTO oidc_login_state (state_hash, nonce, code_verifier, expires_at, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, common.HashToken(request.State), request.Nonce, request.CodeVerifier, expiresAt)
+	if err != nil {
+		slog.Error(common.LogOIDCAuthorize + " - erreur lors de l'enregistrement du state : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOIDC,
+		})
+		return
+	}
+
+	slog.Info(common.LogOIDCAuthorize + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessOIDCAuthorize,
+		Data: common.OIDCAuthorizeResponse{
+			AuthorizationURL: authorizationURL,
+			ExpiresAt:        expiresAt,
+		},
+	})
+}
+
+// Authenticate termine la connexion SSO : le state est consommé, le code échangé contre l'ID token,
+// puis le compte correspondant à l'adresse e-mail est retourné, créé si besoin, avec les rôles issus des claims.
+func Authenticate(ctx context.Context, code, state string) (int, error) {
+	provider := oidc.Default
+	if provider == nil {
+		return 0, ErrNotConfigured
+	}
+
+	nonce, codeVerifier, err := consumeState(state)
+	if err != nil {
+		return 0, err
+	}
+
+	idToken, err := provider.Exchange(ctx, code, codeVerifier)
+	if err != nil {
+		slog.Error(common.LogOIDCLogin + " - " + err.Error())
+		return 0, ErrAuthentication
+	}
+	claims, err := provider.VerifyIDToken(ctx, idToken, nonce)
+	if err != nil {
+		slog.Error(common.LogOIDCLogin + " - " + err.Error())
+		return 0, ErrAuthentication
+	}
+	// Seule une adresse vérifiée par le fournisseur peut désigner un compte ; l'absence du claim email_verified
+	// ne vaut vérification que si la configuration fait confiance au fournisseur (OIDC_TRUST_EMAIL)
+	emailVerified := provider.Config.TrustEmail
+	if claims.EmailVerified != nil {
+		emailVerified = *claims.EmailVerified
+	}
+	if claims.Email == "" || !emailVerified {
+		return 0, ErrEmailNotVerified
+	}
+
+	firstname, lastname := claims.GivenName, claims.FamilyName
+	if firstname == "" && lastname == "" {
+		firstname = claims.Name
+	}
+	identity := authenticator.Identity{
+		Email:         claims.Email,
+		Firstname:     firstname,
+		Lastname:      lastname,
+		EmailVerified: true,
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := authenticator.FindOrProvision(tx, identity, provider.Config.AutoProvision)
+	if errors.Is(err, authenticator.ErrAccountNotFound) {
+		return 0, ErrAccountNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := authenticator.GrantRoles(tx, userID, roles(provider.Config, claims)); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// consumeState marque le state comme utilisé et retourne le nonce et le code verifier associés
+func consumeState(state string) (string, string, error) {
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var stateID int
+	var nonce, codeVerifier string
+	err = tx.QueryRow(`
+		SELECT oidc_login_state_id, nonce, code_verifier
+		FROM oidc_login_state
+		WHERE state_hash = ? AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, common.HashToken(state)).Scan(&stateID, &nonce, &codeVerifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrInvalidState
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := tx.Exec(`UPDATE oidc_login_state SET used_at = NOW() WHERE oidc_login_state_id = ?`, stateID); err != nil {
+		return "", "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", "", err
+	}
+	return nonce, codeVerifier, nil
+}
+
+// roles retourne les rôles de l'application correspondant au claim configuré
+func roles(cfg common.OIDCConfig, claims *oidc.Claims) []string {
+	if cfg.RolesClaim == "" {
+		return nil
+	}
+	var names []string
+	for _, value := range claims.Strings(cfg.RolesClaim) {
+		if len(cfg.RoleMapping) == 0 {
+			names = append(names, value)
+		} else if name, ok := cfg.RoleMapping[value]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
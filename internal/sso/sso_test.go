@@ -0,0 +1,191 @@
+package sso_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/oidc"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+var testIdP *testutils.MockIdP // Fournisseur d'identité en mémoire
+
+// TestMain configure l'environnement de test global et le fournisseur d'identité de test
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	var err error
+	testIdP, err = testutils.NewMockIdP("golendar", "secret")
+	if err != nil {
+		panic("Impossible de démarrer le fournisseur d'identité de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	testIdP.Close()
+	os.Exit(code)
+}
+
+// configure active la connexion SSO avec le fournisseur de test
+func configure(t *testing.T, autoProvision bool, roleMapping map[string]string) {
+	require.NoError(t, oidc.Init(common.OIDCConfig{
+		Issuer:        testIdP.Issuer(),
+		ClientID:      testIdP.ClientID,
+		ClientSecret:  testIdP.ClientSecret,
+		RedirectURL:   "http://localhost:3000/auth/callback",
+		Scopes:        []string{"openid", "email", "profile"},
+		AutoProvision: autoProvision,
+		RolesClaim:    "groups",
+		RoleMapping:   roleMapping,
+	}))
+	t.Cleanup(func() { oidc.Default = nil })
+}
+
+// authorize démarre une connexion SSO et simule la connexion de l'utilisateur chez le fournisseur
+func authorize(t *testing.T, claims map[string]interface{}) gin.H {
+	status, response := testutils.DoRequest(t, testServer, "", "GET", "/auth/oidc/authorize", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var authorization common.OIDCAuthorizeResponse
+	require.NoError(t, json.Unmarshal(response.Data, &authorization))
+
+	code, state, err := testIdP.Authorize(authorization.AuthorizationURL, claims)
+	require.NoError(t, err)
+	return gin.H{"code": code, "state": state}
+}
+
+// login termine la connexion SSO et retourne la session créée
+func login(t *testing.T, claims map[string]interface{}) common.LoginResponse {
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", authorize(t, claims))
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var session common.LoginResponse
+	require.NoError(t, json.Unmarshal(response.Data, &session))
+	require.NotEmpty(t, session.SessionToken)
+	return session
+}
+
+// roleNames retourne les noms des rôles de la session
+func roleNames(session common.LoginResponse) []string {
+	var names []string
+	for _, role := range session.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// TestOIDCLoginExistingUser vérifie la connexion SSO d'un compte existant et l'usage unique du state
+func TestOIDCLoginExistingUser(t *testing.T) {
+	configure(t, false, nil)
+	user, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
+	require.NoError(t, err)
+
+	session := login(t, map[string]interface{}{"email": user.User.Email, "email_verified": true})
+	require.Equal(t, user.User.UserID, session.User.UserID)
+	require.Equal(t, []string{"user"}, roleNames(session))
+
+	// La session ouvre l'accès aux routes protégées
+	status, response := testutils.DoRequest(t, testServer, session.SessionToken, "GET", "/auth/me", nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Le state est à usage unique
+	callback := authorize(t, map[string]interface{}{"email": user.User.Email, "email_verified": true})
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", callback)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", callback)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidOIDCState, response.Error)
+
+	// State inconnu
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", gin.H{"code": "code", "state": "inconnu"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Code refusé par le fournisseur
+	callback = authorize(t, map[string]interface{}{"email": user.User.Email})
+	callback["code"] = "falsifié"
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", callback)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, common.ErrOIDCAuthentication, response.Error)
+
+	// Adresse déclarée non vérifiée par le fournisseur
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", authorize(t, map[string]interface{}{"email": user.User.Email, "email_verified": false}))
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrOIDCEmailNotVerified, response.Error)
+
+	// Claim email_verified absent : refusé, sauf si la configuration fait confiance au fournisseur
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", authorize(t, map[string]interface{}{"email": user.User.Email}))
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrOIDCEmailNotVerified, response.Error)
+	oidc.Default.Config.TrustEmail = true
+	session = login(t, map[string]interface{}{"email": user.User.Email})
+	require.Equal(t, user.User.UserID, session.User.UserID)
+	oidc.Default.Config.TrustEmail = false
+
+	// Adresse inconnue sans création automatique
+	status, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", authorize(t, map[string]interface{}{"email": "inconnu.sso@example.com", "email_verified": true}))
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrOIDCAccountNotFound, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestOIDCAutoProvision vérifie la création du compte à la première connexion et l'attribution des rôles du claim
+func TestOIDCAutoProvision(t *testing.T) {
+	configure(t, true, map[string]string{"planning-editors": "editor", "planning-admins": "inexistant"})
+	claims := map[string]interface{}{
+		"email":          "camille.provision@example.com",
+		"email_verified": true,
+		"given_name":     "Camille",
+		"family_name":    "Martin",
+		"groups":         []string{"planning-editors", "planning-admins", "admin"},
+	}
+
+	session := login(t, claims)
+	require.Equal(t, "camille.provision@example.com", session.User.Email)
+	require.Equal(t, "Camille", session.User.Firstname)
+	require.Equal(t, "Martin", session.User.Lastname)
+	require.NotNil(t, session.User.EmailVerifiedAt)
+	// Seules les valeurs prévues par la correspondance sont attribuées : "admin" est ignoré
+	require.ElementsMatch(t, []string{"user", "editor"}, roleNames(session))
+
+	// Seconde connexion : le même compte, sans doublon de rôle
+	again := login(t, claims)
+	require.Equal(t, session.User.UserID, again.User.UserID)
+	require.ElementsMatch(t, []string{"user", "editor"}, roleNames(again))
+
+	// Un compte supprimé n'est pas recréé
+	_, err := common.DB.Exec("UPDATE user SET deleted_at = NOW() WHERE user_id = ?", session.User.UserID)
+	require.NoError(t, err)
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", authorize(t, claims))
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, common.ErrOIDCAccountNotFound, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestOIDCNotConfigured vérifie les réponses quand aucun fournisseur n'est configuré
+func TestOIDCNotConfigured(t *testing.T) {
+	oidc.Default = nil
+	status, response := testutils.DoRequest(t, testServer, "", "GET", "/auth/oidc/authorize", nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrOIDCNotConfigured, response.Error)
+
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", gin.H{"code": "code", "state": "state"})
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, _ = testutils.DoRequest(t, testServer, "", "POST", "/auth/oidc/callback", gin.H{"code": "code"})
+	require.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,55 @@
+// Package storage internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"io"
+)
+
+// Storage est l'interface commune aux backends de stockage des pièces jointes
+type Storage interface {
+	// Put enregistre le contenu sous la clé donnée (écrase un objet existant)
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get ouvre le contenu associé à la clé ; l'appelant doit fermer le lecteur
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete supprime l'objet ; supprimer une clé absente n'est pas une erreur
+	Delete(ctx context.Context, key string) error
+}
+
+// ErrObjectNotFound est retournée par Get quand la clé n'existe pas
+var ErrObjectNotFound = errors.New("objet introuvable dans le stockage")
+
+// ErrInvalidKey est retournée quand une clé tente de sortir de l'espace de stockage
+var ErrInvalidKey = errors.New("clé de stockage invalide")
+
+// Default est le stockage utilisé par l'application, initialisé par Init
+var Default Storage
+
+// Config est la configuration courante (limites d'upload comprises), initialisée par Init
+var Config common.StorageConfig
+
+// New construit le backend correspondant à la configuration
+func New(cfg common.StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir)
+	case "s3":
+		return NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+	default:
+		return nil, fmt.Errorf("backend de stockage inconnu : %s", cfg.Backend)
+	}
+}
+
+// Init initialise le stockage par défaut de l'application
+func Init(cfg common.StorageConfig) error {
+	backend, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Default = backend
+	Config = cfg
+	return nil
+}
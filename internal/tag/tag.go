@@ -0,0 +1,493 @@
+// Package tag internal/tag/tag.go
+package tag
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagStruct struct{}
+
+var Tag = TagStruct{}
+
+// ErrUnknownTags est retournée quand une étiquette à associer n'existe pas ou n'appartient pas à l'utilisateur
+var ErrUnknownTags = errors.New(common.ErrTagNotFound)
+
+// List récupère les étiquettes de l'utilisateur connecté
+// @Summary Lister mes étiquettes
+// @Description Récupère les étiquettes et catégories de l'utilisateur connecté, éventuellement filtrées par type
+// @Tags Étiquette
+// @Produce json
+// @Param kind query string false "Type d'étiquette (tag ou category)"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /tags [get]
+func (TagStruct) List(c *gin.Context) {
+	slog.Info(common.LogTagList)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT tag_id, user_id, name, kind, color, created_at, updated_at, deleted_at
+		FROM tag
+		WHERE user_id = ? AND deleted_at IS NULL`
+	args := []interface{}{userData.UserID}
+	if kind := c.Query("kind"); kind != "" {
+		if kind != common.TagKindTag && kind != common.TagKindCategory {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidData,
+			})
+			return
+		}
+		query += " AND kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY kind, name"
+
+	rows, err := common.DB.Query(query, args...)
+	if err != nil {
+		slog.Error(common.LogTagList + " - erreur lors de la récupération des étiquettes : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	tags := []common.Tag{}
+	for rows.Next() {
+		var tag common.Tag
+		if err := rows.Scan(&tag.TagID, &tag.UserID, &tag.Name, &tag.Kind, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt, &tag.DeletedAt); err != nil {
+			slog.Error(common.LogTagList + " - erreur lors de la lecture des étiquettes : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTagRetrieval,
+			})
+			return
+		}
+		tags = append(tags, tag)
+	}
+
+	slog.Info(common.LogTagList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListTags,
+		Data:    tags,
+	})
+}
+
+// Get récupère une étiquette de l'utilisateur connecté
+// @Summary Récupérer une étiquette
+// @Description Récupère une étiquette ou une catégorie de l'utilisateur connecté par son ID
+// @Tags Étiquette
+// @Produce json
+// @Param tag_id path int true "ID de l'étiquette"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /tags/{tag_id} [get]
+func (TagStruct) Get(c *gin.Context) {
+	slog.Info(common.LogTagGet)
+	tagData, ok := common.GetTagFromContext(c)
+	if !ok {
+		return
+	}
+
+	slog.Info(common.LogTagGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetTag,
+		Data:    tagData,
+	})
+}
+
+// Add crée une étiquette pour l'utilisateur connecté
+// @Summary Créer une étiquette
+// @Description Crée une étiquette (kind=tag, par défaut) ou une catégorie (kind=category) avec une couleur optionnelle
+// @Tags Étiquette
+// @Accept json
+// @Produce json
+// @Param tag body common.CreateTagRequest true "Données de l'étiquette"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /tags [post]
+func (TagStruct) Add(c *gin.Context) {
+	slog.Info(common.LogTagAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTagAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData,
+		})
+		return
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = common.TagKindTag
+	}
+	if req.Color != nil && !common.IsValidHexColor(*req.Color) {
+		slog.Error(common.LogTagAdd + " - couleur invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidColor,
+		})
+		return
+	}
+
+	if nameTaken(userData.UserID, kind, name, 0) {
+		slog.Error(common.LogTagAdd + " - étiquette déjà existante")
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagAlreadyExists,
+		})
+		return
+	}
+
+	result, err := common.DB.Exec(`
+		INSERT INTO tag (user_id, name, kind, color, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, userData.UserID, name, kind, req.Color)
+	if err != nil {
+		slog.Error(common.LogTagAdd + " - erreur lors de la création de l'étiquette : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagCreation,
+		})
+		return
+	}
+
+	tagID, _ := result.LastInsertId()
+
+	slog.Info(common.LogTagAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateTag,
+		Data:    gin.H{"tag_id": tagID},
+	})
+}
+
+// Update met à jour une étiquette de l'utilisateur connecté
+// @Summary Mettre à jour une étiquette
+// @Description Met à jour le nom, le type ou la couleur d'une étiquette. Une couleur vide retire la couleur.
+// @Tags Étiquette
+// @Accept json
+// @Produce json
+// @Param tag_id path int true "ID de l'étiquette"
+// @Param tag body common.UpdateTagRequest true "Données de l'étiquette"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /tags/{tag_id} [put]
+func (TagStruct) Update(c *gin.Context) {
+	slog.Info(common.LogTagUpdate)
+	tagData, ok := common.GetTagFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTagUpdate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	if req.Color != nil && *req.Color != "" && !common.IsValidHexColor(*req.Color) {
+		slog.Error(common.LogTagUpdate + " - couleur invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidColor,
+		})
+		return
+	}
+
+	name := tagData.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidData,
+			})
+			return
+		}
+	}
+	kind := tagData.Kind
+	if req.Kind != nil {
+		kind = *req.Kind
+	}
+	if nameTaken(tagData.UserID, kind, name, tagData.TagID) {
+		slog.Error(common.LogTagUpdate + " - étiquette déjà existante")
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagAlreadyExists,
+		})
+		return
+	}
+
+	query := "UPDATE tag SET updated_at = NOW(), name = ?, kind = ?"
+	args := []interface{}{name, kind}
+	if req.Color != nil {
+		if *req.Color == "" {
+			query += ", color = NULL"
+		} else {
+			query += ", color = ?"
+			args = append(args, *req.Color)
+		}
+	}
+	query += " WHERE tag_id = ?"
+	args = append(args, tagData.TagID)
+
+	if _, err := common.DB.Exec(query, args...); err != nil {
+		slog.Error(common.LogTagUpdate + " - erreur lors de la mise à jour de l'étiquette : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagUpdate,
+		})
+		return
+	}
+
+	slog.Info(common.LogTagUpdate + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateTag,
+	})
+}
+
+// Delete supprime une étiquette de l'utilisateur connecté ainsi que ses liaisons aux événements
+// @Summary Supprimer une étiquette
+// @Description Supprime (soft delete) une étiquette et la retire de tous les événements
+// @Tags Étiquette
+// @Produce json
+// @Param tag_id path int true "ID de l'étiquette"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /tags/{tag_id} [delete]
+func (TagStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogTagDelete)
+	tagData, ok := common.GetTagFromContext(c)
+	if !ok {
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTagDelete + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("UPDATE tag SET deleted_at = NOW() WHERE tag_id = ?", tagData.TagID); err != nil {
+		slog.Error(common.LogTagDelete + " - erreur lors de la suppression de l'étiquette : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTagDelete,
+		})
+		return
+	}
+
+	if _, err = tx.Exec("UPDATE event_tag SET deleted_at = NOW() WHERE tag_id = ? AND deleted_at IS NULL", tagData.TagID); err != nil {
+		slog.Error(common.LogTagDelete + " - erreur lors de la suppression des liaisons event_tag : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrEventTagLink,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTagDelete + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTagDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteTag,
+	})
+}
+
+// Fonctions utilitaires
+
+// nameTaken indique si l'utilisateur possède déjà une étiquette du même type portant ce nom
+func nameTaken(userID int, kind, name string, excludeTagID int) bool {
+	var existingID int
+	err := common.DB.QueryRow(
+		"SELECT tag_id FROM tag WHERE user_id = ? AND kind = ? AND name = ? AND tag_id != ? AND deleted_at IS NULL",
+		userID, kind, name, excludeTagID,
+	).Scan(&existingID)
+	return err != sql.ErrNoRows
+}
+
+// ValidateUserTags vérifie que toutes les étiquettes existent et appartiennent à l'utilisateur
+func ValidateUserTags(tx *sql.Tx, userID int, tagIDs []int) error {
+	tagIDs = common.UniqueIDs(tagIDs)
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	placeholders, args := common.InClause(tagIDs)
+	args = append([]interface{}{userID}, args...)
+	var count int
+	err := tx.QueryRow(
+		"SELECT COUNT(*) FROM tag WHERE user_id = ? AND deleted_at IS NULL AND tag_id IN ("+placeholders+")",
+		args...,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count != len(tagIDs) {
+		return ErrUnknownTags
+	}
+	return nil
+}
+
+// SetEventTags remplace les étiquettes de l'utilisateur sur un événement par tagIDs.
+// Les étiquettes posées par les autres utilisateurs partageant l'événement sont conservées.
+func SetEventTags(tx *sql.Tx, userID int, eventID int64, tagIDs []int) error {
+	tagIDs = common.UniqueIDs(tagIDs)
+
+	query := `UPDATE event_tag SET deleted_at = NOW()
+		WHERE event_id = ? AND deleted_at IS NULL
+		  AND tag_id IN (SELECT tag_id FROM tag WHERE user_id = ?)`
+	args := []interface{}{eventID, userID}
+	if len(tagIDs) > 0 {
+		placeholders, inArgs := common.InClause(tagIDs)
+		query += " AND tag_id NOT IN (" + placeholders + ")"
+		args = append(args, inArgs...)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	for _, tagID := range tagIDs {
+		// Une liaison supprimée précédemment est réactivée plutôt que dupliquée
+		_, err := tx.Exec(`
+			INSERT INTO event_tag (event_id, tag_id, created_at)
+			VALUES (?, ?, NOW())
+			ON DUPLICATE KEY UPDATE deleted_at = NULL, updated_at = NOW()
+		`, eventID, tagID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadEventTags récupère les étiquettes actives de l'utilisateur sur les événements, indexées par event_id.
+// Une étiquette n'est visible que par son propriétaire, même sur un événement partagé.
+func LoadEventTags(userID int, eventIDs []int) (map[int][]common.Tag, error) {
+	tagsByEvent := make(map[int][]common.Tag)
+	eventIDs = common.UniqueIDs(eventIDs)
+	if len(eventIDs) == 0 {
+		return tagsByEvent, nil
+	}
+
+	placeholders, args := common.InClause(eventIDs)
+	args = append([]interface{}{userID}, args...)
+	rows, err := common.DB.Query(`
+		SELECT et.event_id, t.tag_id, t.user_id, t.name, t.kind, t.color, t.created_at, t.updated_at, t.deleted_at
+		FROM event_tag et
+		INNER JOIN tag t ON et.tag_id = t.tag_id
+		WHERE et.deleted_at IS NULL AND t.deleted_at IS NULL AND t.user_id = ?
+		  AND et.event_id IN (`+placeholders+`)
+		ORDER BY t.kind, t.name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID int
+		var tag common.Tag
+		if err := rows.Scan(&eventID, &tag.TagID, &tag.UserID, &tag.Name, &tag.Kind, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt, &tag.DeletedAt); err != nil {
+			return nil, err
+		}
+		tagsByEvent[eventID] = append(tagsByEvent[eventID], tag)
+	}
+	return tagsByEvent, rows.Err()
+}
+
+// AttachTags renseigne le champ Tags de chaque événement avec les étiquettes de l'utilisateur
+func AttachTags(userID int, events []common.Event) error {
+	eventIDs := make([]int, len(events))
+	for i, event := range events {
+		eventIDs[i] = event.EventID
+	}
+	tagsByEvent, err := LoadEventTags(userID, eventIDs)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		events[i].Tags = tagsByEvent[events[i].EventID]
+	}
+	return nil
+}
+
+// ParseTagFilter lit les paramètres de filtre "tag_id" (répétables) d'une requête de listing
+func ParseTagFilter(c *gin.Context) ([]int, bool) {
+	var tagIDs []int
+	for _, idStr := range c.QueryArray("tag_id") {
+		tagID, err := strconv.Atoi(idStr)
+		if err != nil || tagID < 1 {
+			return nil, false
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+	return common.UniqueIDs(tagIDs), true
+}
+
+// FilterClause retourne la condition SQL restreignant aux événements portant au moins une des étiquettes.
+// eventColumn est la colonne contenant l'identifiant de l'événement dans la requête englobante (ex: "e.event_id").
+func FilterClause(eventColumn string, tagIDs []int) (string, []interface{}) {
+	if len(tagIDs) == 0 {
+		return "", nil
+	}
+	placeholders, args := common.InClause(tagIDs)
+	clause := " AND EXISTS (SELECT 1 FROM event_tag et WHERE et.event_id = " + eventColumn +
+		" AND et.deleted_at IS NULL AND et.tag_id IN (" + placeholders + "))"
+	return clause, args
+}
@@ -0,0 +1,272 @@
+package tag_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// createTag insère directement une étiquette en base pour un utilisateur
+func createTag(t *testing.T, userID int, name, kind string) int {
+	result, err := common.DB.Exec("INSERT INTO tag (user_id, name, kind, color, created_at) VALUES (?, ?, ?, '#3366FF', NOW())", userID, name, kind)
+	require.NoError(t, err)
+	tagID, err := result.LastInsertId()
+	require.NoError(t, err)
+	return int(tagID)
+}
+
+// TestCreateTagRoute teste la route POST /tags avec plusieurs cas
+func TestCreateTagRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      func() map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Création réussie d'une étiquette colorée",
+			RequestData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"requestBody": map[string]interface{}{"name": "Urgent", "color": "#FF0000"},
+				}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateTag,
+		},
+		{
+			CaseName: "Création réussie d'une catégorie",
+			RequestData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"requestBody": map[string]interface{}{"name": "Travail", "kind": "category"},
+				}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateTag,
+		},
+		{
+			CaseName: "Échec de création avec une couleur invalide",
+			RequestData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"requestBody": map[string]interface{}{"name": "Perso", "color": "rouge"},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidColor,
+		},
+		{
+			CaseName: "Échec de création avec un type inconnu",
+			RequestData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{
+					"user":        user,
+					"requestBody": map[string]interface{}{"name": "Perso", "kind": "label"},
+				}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+		{
+			CaseName: "Échec de création d'une étiquette déjà existante",
+			RequestData: func() map[string]interface{} {
+				user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				createTag(t, user.User.UserID, "Sport", common.TagKindTag)
+				return map[string]interface{}{
+					"user":        user,
+					"requestBody": map[string]interface{}{"name": "Sport"},
+				}
+			},
+			ExpectedHttpCode: http.StatusConflict,
+			ExpectedError:    common.ErrTagAlreadyExists,
+		},
+		{
+			CaseName: "Échec de création sans authentification",
+			RequestData: func() map[string]interface{} {
+				return map[string]interface{}{
+					"requestBody": map[string]interface{}{"name": "Urgent"},
+				}
+			},
+			ExpectedHttpCode: http.StatusUnauthorized,
+			ExpectedError:    common.ErrUserNotAuthenticated,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			requestData := testCase.RequestData()
+			token := ""
+			if user, ok := requestData["user"].(*testutils.AuthenticatedUser); ok {
+				token = user.SessionToken
+			}
+
+			status, response := testutils.DoRequest(t, testServer, token, "POST", "/tags", requestData["requestBody"])
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+		})
+	}
+}
+
+// TestTagOwnershipRoutes vérifie qu'une étiquette n'est accessible qu'à son propriétaire
+func TestTagOwnershipRoutes(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	tagID := createTag(t, owner.User.UserID, "Projet", common.TagKindCategory)
+	url := "/tags/" + strconv.Itoa(tagID)
+
+	status, response := testutils.DoRequest(t, testServer, owner.SessionToken, "GET", url, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessGetTag, response.Message)
+
+	status, response = testutils.DoRequest(t, testServer, other.SessionToken, "GET", url, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrTagNotFound, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "PUT", url, map[string]interface{}{"color": ""})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessUpdateTag, response.Message)
+
+	status, response = testutils.DoRequest(t, testServer, other.SessionToken, "DELETE", url, nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, response = testutils.DoRequest(t, testServer, owner.SessionToken, "DELETE", url, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessDeleteTag, response.Message)
+
+	status, _ = testutils.DoRequest(t, testServer, owner.SessionToken, "GET", url, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+// TestEventTagFilter vérifie l'association d'étiquettes à un événement et le filtrage des listes
+func TestEventTagFilter(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+	require.NoError(t, err)
+	tagID := createTag(t, user.User.UserID, "Client", common.TagKindTag)
+	otherTagID := createTag(t, user.User.UserID, "Interne", common.TagKindTag)
+	stranger, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	strangerTagID := createTag(t, stranger.User.UserID, "Client", common.TagKindTag)
+
+	calendarID := strconv.Itoa(user.Calendar.CalendarID)
+	eventURL := "/calendar-event/" + calendarID + "/" + strconv.Itoa(user.Event.EventID)
+
+	// Une étiquette d'un autre utilisateur est refusée
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "PUT", eventURL, map[string]interface{}{"tag_ids": []int{strangerTagID}})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrTagNotFound, response.Error)
+
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", eventURL, map[string]interface{}{"tag_ids": []int{tagID}})
+	require.Equal(t, http.StatusOK, status)
+
+	month := user.Event.Start.Format("2006/1")
+	listURL := "/calendar-event/" + calendarID + "/month/" + month
+
+	for _, filter := range []struct {
+		TagID         int
+		ExpectedCount int
+	}{{tagID, 1}, {otherTagID, 0}} {
+		status, response := testutils.DoRequest(t, testServer, user.SessionToken, "GET", listURL+"?tag_id="+strconv.Itoa(filter.TagID), nil)
+		require.Equal(t, http.StatusOK, status, response.Error)
+		var events []common.Event
+		require.NoError(t, json.Unmarshal(response.Data, &events))
+		require.Len(t, events, filter.ExpectedCount)
+		if filter.ExpectedCount > 0 {
+			require.Len(t, events[0].Tags, 1)
+			require.Equal(t, "Client", events[0].Tags[0].Name)
+		}
+	}
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", listURL+"?tag_id=abc", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidTagID, response.Error)
+}
+
+// TestSharedEventTagsArePrivate vérifie que deux utilisateurs d'un calendrier partagé étiquettent le même événement
+// sans voir ni retirer les étiquettes de l'autre
+func TestSharedEventTagsArePrivate(t *testing.T) {
+	owner, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+	require.NoError(t, err)
+	member, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	_, err = common.DB.Exec("INSERT INTO user_calendar (user_id, calendar_id, created_at) VALUES (?, ?, NOW())", member.User.UserID, owner.Calendar.CalendarID)
+	require.NoError(t, err)
+	ownerTagID := createTag(t, owner.User.UserID, "Confidentiel", common.TagKindTag)
+	memberTagID := createTag(t, member.User.UserID, "Suivi", common.TagKindTag)
+
+	eventURL := "/calendar-event/" + strconv.Itoa(owner.Calendar.CalendarID) + "/" + strconv.Itoa(owner.Event.EventID)
+	status, response := testutils.DoRequest(t, testServer, owner.SessionToken, "PUT", eventURL, map[string]interface{}{"tag_ids": []int{ownerTagID}})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, member.SessionToken, "PUT", eventURL, map[string]interface{}{"tag_ids": []int{memberTagID}})
+	require.Equal(t, http.StatusOK, status, response.Error)
+
+	// Chacun ne voit que ses propres étiquettes sur l'événement
+	for _, expected := range []struct {
+		User *testutils.AuthenticatedUser
+		Name string
+	}{{owner, "Confidentiel"}, {member, "Suivi"}} {
+		status, response = testutils.DoRequest(t, testServer, expected.User.SessionToken, "GET", eventURL, nil)
+		require.Equal(t, http.StatusOK, status, response.Error)
+		var event common.Event
+		require.NoError(t, json.Unmarshal(response.Data, &event))
+		require.Len(t, event.Tags, 1)
+		require.Equal(t, expected.Name, event.Tags[0].Name)
+	}
+
+	// Retirer ses étiquettes ne touche pas à celles de l'autre utilisateur
+	status, response = testutils.DoRequest(t, testServer, member.SessionToken, "PUT", eventURL, map[string]interface{}{"tag_ids": []int{}})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var active int
+	require.NoError(t, common.DB.QueryRow(
+		"SELECT COUNT(*) FROM event_tag WHERE event_id = ? AND tag_id = ? AND deleted_at IS NULL", owner.Event.EventID, ownerTagID,
+	).Scan(&active))
+	require.Equal(t, 1, active)
+
+	testutils.PurgeAllTestUsers()
+}
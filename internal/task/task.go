@@ -0,0 +1,495 @@
+// Package task internal/task/task.go
+package task
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaskStruct struct{}
+
+var Task = TaskStruct{}
+
+// taskColumns liste les colonnes lues pour une tâche, dans l'ordre de scanTask
+const taskColumns = `task_id, calendar_id, event_id, title, description, due_date, completed, completed_at,
+	priority, assignee_id, created_by, created_at, updated_at, deleted_at`
+
+// List liste les tâches d'un calendrier
+// @Summary Lister les tâches d'un calendrier
+// @Description Liste les tâches d'un calendrier, triées par échéance puis par priorité. Les paramètres start et end filtrent sur la date d'échéance (les tâches sans échéance sont alors exclues).
+// @Tags Tâche
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param start query string false "Échéance minimale (YYYY-MM-DD ou RFC3339)"
+// @Param end query string false "Échéance maximale (YYYY-MM-DD inclus ou RFC3339 exclu)"
+// @Param completed query bool false "Restreindre aux tâches terminées (true) ou à faire (false)"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-task/{calendar_id} [get]
+func (TaskStruct) List(c *gin.Context) {
+	slog.Info(common.LogTaskList)
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+
+	query := "SELECT " + taskColumns + " FROM task WHERE calendar_id = ? AND deleted_at IS NULL"
+	args := []interface{}{calendarData.CalendarID}
+
+	var start, end *time.Time
+	if startStr := c.Query("start"); startStr != "" {
+		t, err := parseTaskDate(startStr, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTaskDate,
+			})
+			return
+		}
+		start = &t
+		query += " AND due_date >= ?"
+		args = append(args, t)
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		t, err := parseTaskDate(endStr, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTaskDate,
+			})
+			return
+		}
+		end = &t
+		query += " AND due_date < ?"
+		args = append(args, t)
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTaskRange,
+		})
+		return
+	}
+	if completedStr := c.Query("completed"); completedStr != "" {
+		completed, err := strconv.ParseBool(completedStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTaskCompletedFilter,
+			})
+			return
+		}
+		query += " AND completed = ?"
+		args = append(args, completed)
+	}
+	// Les tâches sans échéance viennent en dernier ; la priorité 0 (non définie) après la priorité 9
+	query += " ORDER BY due_date IS NULL, due_date ASC, CASE WHEN priority = 0 THEN 10 ELSE priority END ASC, task_id ASC"
+
+	rows, err := common.DB.Query(query, args...)
+	if err != nil {
+		slog.Error(common.LogTaskList + " - erreur lors de la récupération des tâches : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTaskRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	tasks := []common.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			slog.Error(common.LogTaskList + " - erreur lors de la lecture des tâches : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTaskRetrieval,
+			})
+			return
+		}
+		tasks = append(tasks, task)
+	}
+
+	slog.Info(common.LogTaskList + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListTasks,
+		Data:    tasks,
+	})
+}
+
+// Get récupère une tâche
+// @Summary Récupérer une tâche
+// @Description Récupère une tâche d'un calendrier par son ID
+// @Tags Tâche
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param task_id path int true "ID de la tâche"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-task/{calendar_id}/{task_id} [get]
+func (TaskStruct) Get(c *gin.Context) {
+	slog.Info(common.LogTaskGet)
+	taskData, ok := common.GetTaskFromContext(c)
+	if !ok {
+		return
+	}
+
+	slog.Info(common.LogTaskGet + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetTask,
+		Data:    taskData,
+	})
+}
+
+// Add crée une tâche dans un calendrier
+// @Summary Créer une tâche
+// @Description Crée une tâche dans un calendrier. L'événement lié doit appartenir au calendrier et la personne assignée doit y avoir accès.
+// @Tags Tâche
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param task body common.CreateTaskRequest true "Données de la tâche"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-task/{calendar_id} [post]
+func (TaskStruct) Add(c *gin.Context) {
+	slog.Info(common.LogTaskAdd)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+	calendarData, ok := common.GetCalendarFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTaskAdd + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != nil && *req.DueDate != "" {
+		t, err := parseTaskDate(*req.DueDate, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidTaskDate,
+			})
+			return
+		}
+		dueDate = &t
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTaskAdd + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if status, errMsg := checkReferences(tx, calendarData.CalendarID, req.EventID, req.AssigneeID); errMsg != "" {
+		slog.Error(common.LogTaskAdd + " - " + errMsg)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	var completedAt interface{}
+	if req.Completed {
+		completedAt = time.Now().UTC()
+	}
+	result, err := tx.Exec(`
+		INSERT INTO task (calendar_id, event_id, title, description, due_date, completed, completed_at, priority, assignee_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`, calendarData.CalendarID, req.EventID, req.Title, req.Description, dueDate, req.Completed, completedAt, req.Priority, req.AssigneeID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogTaskAdd + " - erreur lors de la création de la tâche : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTaskCreation,
+		})
+		return
+	}
+	taskID, _ := result.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTaskAdd + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTaskAdd + " - succès")
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateTask,
+		Data: gin.H{
+			"task_id":     taskID,
+			"calendar_id": calendarData.CalendarID,
+		},
+	})
+}
+
+// Update met à jour une tâche
+// @Summary Mettre à jour une tâche
+// @Description Met à jour une tâche. "due_date": "" retire l'échéance, "assignee_id": 0 et "event_id": 0 retirent l'assignation et l'événement lié. Passer completed à true enregistre la date d'achèvement.
+// @Tags Tâche
+// @Accept json
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param task_id path int true "ID de la tâche"
+// @Param task body common.UpdateTaskRequest true "Données de la tâche"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-task/{calendar_id}/{task_id} [put]
+func (TaskStruct) Update(c *gin.Context) {
+	slog.Info(common.LogTaskUpdate)
+	taskData, ok := common.GetTaskFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTaskUpdate + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	query := "UPDATE task SET updated_at = NOW()"
+	var args []interface{}
+	if req.Title != nil {
+		query += ", title = ?"
+		args = append(args, *req.Title)
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, *req.Description)
+	}
+	if req.DueDate != nil {
+		var dueDate *time.Time
+		if *req.DueDate != "" {
+			t, err := parseTaskDate(*req.DueDate, false)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, common.JSONResponse{
+					Success: false,
+					Error:   common.ErrInvalidTaskDate,
+				})
+				return
+			}
+			dueDate = &t
+		}
+		query += ", due_date = ?"
+		args = append(args, dueDate)
+	}
+	if req.Completed != nil && *req.Completed != taskData.Completed {
+		query += ", completed = ?, completed_at = ?"
+		if *req.Completed {
+			args = append(args, true, time.Now().UTC())
+		} else {
+			args = append(args, false, nil)
+		}
+	}
+	if req.Priority != nil {
+		query += ", priority = ?"
+		args = append(args, *req.Priority)
+	}
+	// 0 retire la liaison, une autre valeur est vérifiée avant la mise à jour
+	var eventID, assigneeID *int
+	if req.EventID != nil {
+		query += ", event_id = ?"
+		if *req.EventID == 0 {
+			args = append(args, nil)
+		} else {
+			eventID = req.EventID
+			args = append(args, *req.EventID)
+		}
+	}
+	if req.AssigneeID != nil {
+		query += ", assignee_id = ?"
+		if *req.AssigneeID == 0 {
+			args = append(args, nil)
+		} else {
+			assigneeID = req.AssigneeID
+			args = append(args, *req.AssigneeID)
+		}
+	}
+	query += " WHERE task_id = ?"
+	args = append(args, taskData.TaskID)
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTaskUpdate + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if status, errMsg := checkReferences(tx, taskData.CalendarID, eventID, assigneeID); errMsg != "" {
+		slog.Error(common.LogTaskUpdate + " - " + errMsg)
+		c.JSON(status, common.JSONResponse{
+			Success: false,
+			Error:   errMsg,
+		})
+		return
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		slog.Error(common.LogTaskUpdate + " - erreur lors de la mise à jour de la tâche : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTaskUpdate,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTaskUpdate + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogTaskUpdate + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateTask,
+	})
+}
+
+// Delete supprime une tâche
+// @Summary Supprimer une tâche
+// @Description Supprime une tâche d'un calendrier
+// @Tags Tâche
+// @Produce json
+// @Param calendar_id path int true "ID du calendrier"
+// @Param task_id path int true "ID de la tâche"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 403 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /calendar-task/{calendar_id}/{task_id} [delete]
+func (TaskStruct) Delete(c *gin.Context) {
+	slog.Info(common.LogTaskDelete)
+	taskData, ok := common.GetTaskFromContext(c)
+	if !ok {
+		return
+	}
+
+	if _, err := common.DB.Exec("UPDATE task SET deleted_at = NOW() WHERE task_id = ?", taskData.TaskID); err != nil {
+		slog.Error(common.LogTaskDelete + " - erreur lors de la suppression de la tâche : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTaskDelete,
+		})
+		return
+	}
+
+	slog.Info(common.LogTaskDelete + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteTask,
+	})
+}
+
+// checkReferences vérifie que l'événement lié appartient au calendrier et que la personne assignée y a accès.
+// Retourne un code HTTP et un message d'erreur non vide si une référence est invalide.
+func checkReferences(tx *sql.Tx, calendarID int, eventID, assigneeID *int) (int, string) {
+	var found int
+	if eventID != nil {
+		err := tx.QueryRow(`
+			SELECT 1 FROM calendar_event ce
+			INNER JOIN event e ON ce.event_id = e.event_id
+			WHERE ce.calendar_id = ? AND ce.event_id = ?
+			AND ce.deleted_at IS NULL AND e.deleted_at IS NULL
+		`, calendarID, *eventID).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return http.StatusBadRequest, common.ErrTaskEventNotInCalendar
+		}
+		if err != nil {
+			return http.StatusInternalServerError, common.ErrEventRetrieval
+		}
+	}
+	if assigneeID != nil {
+		err := tx.QueryRow(`
+			SELECT 1 FROM user_calendar uc
+			INNER JOIN user u ON uc.user_id = u.user_id
+			WHERE uc.user_id = ? AND uc.calendar_id = ?
+			AND uc.deleted_at IS NULL AND u.deleted_at IS NULL
+		`, *assigneeID, calendarID).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return http.StatusBadRequest, common.ErrTaskAssigneeNoAccess
+		}
+		if err != nil {
+			return http.StatusInternalServerError, common.ErrCalendarAccessCheck
+		}
+	}
+	return http.StatusOK, ""
+}
+
+// scanTask lit une tâche sélectionnée avec taskColumns
+func scanTask(rows *sql.Rows) (common.Task, error) {
+	var task common.Task
+	err := rows.Scan(&task.TaskID, &task.CalendarID, &task.EventID, &task.Title, &task.Description, &task.DueDate,
+		&task.Completed, &task.CompletedAt, &task.Priority, &task.AssigneeID, &task.CreatedBy,
+		&task.CreatedAt, &task.UpdatedAt, &task.DeletedAt)
+	return task, err
+}
+
+// parseTaskDate accepte une date au format YYYY-MM-DD ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseTaskDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
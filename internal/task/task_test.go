@@ -0,0 +1,191 @@
+package task_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// createTask crée une tâche via l'API et retourne son identifiant
+func createTask(t *testing.T, user *testutils.AuthenticatedUser, body map[string]interface{}) int {
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-task/"+strconv.Itoa(user.Calendar.CalendarID), body)
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var data struct {
+		TaskID int `json:"task_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	return data.TaskID
+}
+
+// TestAddTaskRoute teste la création d'une tâche avec plusieurs cas
+func TestAddTaskRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      func(user *testutils.AuthenticatedUser) map[string]interface{}
+		OtherCalendar    bool
+		ExpectedHttpCode int
+		ExpectedMessage  string
+		ExpectedError    string
+	}{
+		{
+			CaseName: "Création réussie avec échéance, événement et assignation",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{
+					"title":       "Préparer l'ordre du jour",
+					"due_date":    "2025-06-01T09:00:00Z",
+					"priority":    1,
+					"event_id":    user.Event.EventID,
+					"assignee_id": user.User.UserID,
+				}
+			},
+			ExpectedHttpCode: http.StatusCreated,
+			ExpectedMessage:  common.MsgSuccessCreateTask,
+		},
+		{
+			CaseName: "Échec de la création avec un événement d'un autre calendrier",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+				require.NoError(t, err)
+				return map[string]interface{}{"title": "Tâche", "event_id": other.Event.EventID}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrTaskEventNotInCalendar,
+		},
+		{
+			CaseName: "Échec de la création avec une personne assignée sans accès",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+				require.NoError(t, err)
+				return map[string]interface{}{"title": "Tâche", "assignee_id": other.User.UserID}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrTaskAssigneeNoAccess,
+		},
+		{
+			CaseName: "Échec de la création avec une priorité hors limites",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"title": "Tâche", "priority": 12}
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidData,
+		},
+		{
+			CaseName: "Échec de la création dans un calendrier sans accès",
+			RequestData: func(user *testutils.AuthenticatedUser) map[string]interface{} {
+				return map[string]interface{}{"title": "Tâche"}
+			},
+			OtherCalendar:    true,
+			ExpectedHttpCode: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, true, true)
+			require.NoError(t, err)
+
+			calendarID := user.Calendar.CalendarID
+			if testCase.OtherCalendar {
+				other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+				require.NoError(t, err)
+				calendarID = other.Calendar.CalendarID
+			}
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/calendar-task/"+strconv.Itoa(calendarID), testCase.RequestData(user))
+
+			require.Equal(t, testCase.ExpectedHttpCode, status, "Code de statut HTTP incorrect")
+			if testCase.ExpectedMessage != "" {
+				require.Equal(t, testCase.ExpectedMessage, response.Message, "Message de succès incorrect")
+			}
+			if testCase.ExpectedError != "" {
+				require.Contains(t, response.Error, testCase.ExpectedError, "Message d'erreur incorrect")
+			}
+
+			testutils.PurgeAllTestUsers()
+		})
+	}
+}
+
+// TestTaskLifecycle vérifie la liste par échéance, l'achèvement et la suppression d'une tâche
+func TestTaskLifecycle(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	base := "/calendar-task/" + strconv.Itoa(user.Calendar.CalendarID)
+
+	juneTask := createTask(t, user, map[string]interface{}{"title": "Réserver la salle", "due_date": "2025-06-10"})
+	julyTask := createTask(t, user, map[string]interface{}{"title": "Envoyer le compte rendu", "due_date": "2025-07-02"})
+	createTask(t, user, map[string]interface{}{"title": "Sans échéance"})
+
+	// Filtre sur la période d'échéance (fin au format jour incluse)
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "GET", base+"?start=2025-06-01&end=2025-06-30", nil)
+	require.Equal(t, http.StatusOK, status)
+	var tasks []common.Task
+	require.NoError(t, json.Unmarshal(response.Data, &tasks))
+	require.Len(t, tasks, 1)
+	require.Equal(t, juneTask, tasks[0].TaskID)
+
+	// Sans filtre, les tâches sans échéance viennent en dernier
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", base, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(response.Data, &tasks))
+	require.Len(t, tasks, 3)
+	require.Equal(t, []int{juneTask, julyTask}, []int{tasks[0].TaskID, tasks[1].TaskID})
+	require.Nil(t, tasks[2].DueDate)
+
+	// Achèvement de la tâche de juin
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "PUT", base+"/"+strconv.Itoa(juneTask), map[string]interface{}{"completed": true})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", base+"/"+strconv.Itoa(juneTask), nil)
+	require.Equal(t, http.StatusOK, status)
+	var task common.Task
+	require.NoError(t, json.Unmarshal(response.Data, &task))
+	require.True(t, task.Completed)
+	require.NotNil(t, task.CompletedAt)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", base+"?completed=false", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(response.Data, &tasks))
+	require.Len(t, tasks, 2)
+
+	// Une tâche n'est accessible que par son calendrier
+	other, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	status, _ = testutils.DoRequest(t, testServer, other.SessionToken, "GET", "/calendar-task/"+strconv.Itoa(other.Calendar.CalendarID)+"/"+strconv.Itoa(julyTask), nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", base+"/"+strconv.Itoa(julyTask), nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, common.MsgSuccessDeleteTask, response.Message)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "GET", base+"/"+strconv.Itoa(julyTask), nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	testutils.PurgeAllTestUsers()
+}
@@ -0,0 +1,106 @@
+// Package totp internal/totp/totp.go
+// Mots de passe à usage unique basés sur le temps (RFC 6238, HMAC-SHA1, 6 chiffres, pas de 30 secondes),
+// compatibles avec les applications d'authentification courantes.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits est le nombre de chiffres des codes
+	Digits = 6
+	// Period est la durée de validité d'un code
+	Period = 30 * time.Second
+	// Skew est le nombre de pas tolérés avant et après le pas courant (décalage d'horloge du téléphone)
+	Skew = 1
+	// secretSize est la taille du secret partagé, en octets (recommandation de la RFC 4226)
+	secretSize = 20
+)
+
+// encoding est le base32 sans remplissage attendu dans les URI otpauth://
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret génère un secret partagé aléatoire, encodé en base32
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI retourne l'URI otpauth:// à afficher en QR code pour enregistrer le secret
+func ProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Counter retourne le numéro du pas de temps contenant t
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code retourne le code attendu à l'instant t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, Counter(t), Digits), nil
+}
+
+// Validate vérifie le code à l'instant t, avec une tolérance de Skew pas.
+// Elle retourne le pas correspondant, que l'appelant conserve pour refuser le rejeu d'un code déjà accepté.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+	current := Counter(t)
+	for counter := current - Skew; counter <= current+Skew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter, Digits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// decodeSecret décode un secret base32 (casse et espaces ignorés)
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// hotp calcule le code HOTP (RFC 4226) du compteur
+func hotp(key []byte, counter int64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Troncature dynamique
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRFC6238Vectors vérifie les vecteurs SHA-1 de l'annexe B de la RFC 6238 (codes à 8 chiffres)
+func TestRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := []struct {
+		Unix int64
+		Code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, vector := range vectors {
+		require.Equal(t, vector.Code, hotp(key, Counter(time.Unix(vector.Unix, 0)), 8), "t=%d", vector.Unix)
+	}
+}
+
+// TestValidate vérifie la tolérance d'un pas et le pas retourné pour la protection contre le rejeu
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	require.Equal(t, "050471", code)
+
+	counter, ok := Validate(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, Counter(now), counter)
+
+	// Code du pas précédent : accepté, avec son propre numéro de pas
+	previous, err := Code(secret, now.Add(-Period))
+	require.NoError(t, err)
+	counter, ok = Validate(secret, previous, now)
+	require.True(t, ok)
+	require.Equal(t, Counter(now)-1, counter)
+
+	// Deux pas d'écart : refusé
+	old, err := Code(secret, now.Add(-2*Period))
+	require.NoError(t, err)
+	_, ok = Validate(secret, old, now)
+	require.False(t, ok)
+
+	// Secret en minuscules avec espaces, tel que recopié à la main
+	_, ok = Validate(strings.ToLower(secret[:4])+" "+secret[4:], code, now)
+	require.True(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	require.False(t, ok)
+}
+
+// TestProvisioningURI vérifie le format de l'URI otpauth://
+func TestProvisioningURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	uri := ProvisioningURI("GoLendar", "nour.haddad@example.com", secret)
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/GoLendar:nour.haddad@example.com?"), uri)
+	require.Contains(t, uri, "secret="+secret)
+	require.Contains(t, uri, "issuer=GoLendar")
+	require.Contains(t, uri, "digits=6")
+	require.Contains(t, uri, "period=30")
+}
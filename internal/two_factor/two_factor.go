@@ -0,0 +1,522 @@
+// Package two_factor internal/two_factor/two_factor.go
+package two_factor
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"go-averroes/internal/common"
+	"go-averroes/internal/totp"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type TwoFactorStruct struct{}
+
+var TwoFactor = TwoFactorStruct{}
+
+const (
+	// issuer est le nom affiché par les applications d'authentification
+	issuer = "GoLendar"
+	// challengeTTL est la durée laissée pour saisir le code après le mot de passe
+	challengeTTL = 5 * time.Minute
+	// maxChallengeAttempts est le nombre de codes erronés au-delà duquel il faut se reconnecter
+	maxChallengeAttempts = 5
+	// recoveryCodeCount est le nombre de codes de récupération générés à l'activation
+	recoveryCodeCount = 10
+	// recoveryAlphabet exclut les caractères ambigus (0/o, 1/l/i)
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	// ErrInvalidChallenge est retournée pour un jeton de connexion inconnu, expiré, consommé ou épuisé
+	ErrInvalidChallenge = errors.New(common.ErrInvalidTwoFactorChallenge)
+	// ErrInvalidCode est retournée pour un code TOTP ou de récupération incorrect
+	ErrInvalidCode = errors.New(common.ErrInvalidTwoFactorCode)
+)
+
+// Enabled indique si l'utilisateur a activé l'authentification à deux facteurs
+func Enabled(userID int) (bool, error) {
+	var count int
+	err := common.DB.QueryRow(`SELECT COUNT(*) FROM user_totp WHERE user_id = ? AND confirmed_at IS NOT NULL`, userID).Scan(&count)
+	return count > 0, err
+}
+
+// IssueChallenge crée le jeton de connexion à échanger contre une session avec ConsumeChallenge
+func IssueChallenge(userID int) (string, time.Time, error) {
+	token, err := common.GenerateToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(challengeTTL)
+	_, err = common.DB.Exec(`
+		INSERT INTO login_challenge (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, NOW())
+	`, userID, common.HashToken(token), expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// PendingChallenge retourne l'utilisateur d'un jeton de connexion encore utilisable, sans le consommer,
+// pour appliquer les contrôles de connexion avant de vérifier le code
+func PendingChallenge(token string) (int, error) {
+	var userID int
+	err := common.DB.QueryRow(`
+		SELECT user_id FROM login_challenge
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > NOW() AND attempts < ?
+	`, common.HashToken(token), maxChallengeAttempts).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidChallenge
+	}
+	return userID, err
+}
+
+// ConsumeChallenge vérifie le code pour le jeton de connexion et retourne l'utilisateur authentifié.
+// Un jeton n'est utilisable qu'une fois et n'accepte que maxChallengeAttempts codes erronés.
+func ConsumeChallenge(token, code string) (int, error) {
+	tx, err := common.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var challengeID, userID int
+	err = tx.QueryRow(`
+		SELECT login_challenge_id, user_id
+		FROM login_challenge
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > NOW() AND attempts < ?
+		FOR UPDATE
+	`, common.HashToken(token), maxChallengeAttempts).Scan(&challengeID, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ok, err := verifyCode(tx, userID, code)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if _, err := tx.Exec(`UPDATE login_challenge SET attempts = attempts + 1 WHERE login_challenge_id = ?`, challengeID); err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return 0, ErrInvalidCode
+	}
+
+	if _, err := tx.Exec(`UPDATE login_challenge SET used_at = NOW() WHERE login_challenge_id = ?`, challengeID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Status retourne l'état de l'authentification à deux facteurs de l'utilisateur connecté
+// @Summary État de l'authentification à deux facteurs
+// @Description Indique si l'authentification à deux facteurs est activée et combien de codes de récupération restent utilisables
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=common.TwoFactorStatus}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /auth/2fa [get]
+func (TwoFactorStruct) Status(c *gin.Context) {
+	slog.Info(common.LogTwoFactorStatus)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var status common.TwoFactorStatus
+	err := common.DB.QueryRow(`
+		SELECT confirmed_at FROM user_totp WHERE user_id = ? AND confirmed_at IS NOT NULL
+	`, userData.UserID).Scan(&status.EnabledAt)
+	if err == nil {
+		status.Enabled = true
+		err = common.DB.QueryRow(`
+			SELECT COUNT(*) FROM user_recovery_code WHERE user_id = ? AND used_at IS NULL
+		`, userData.UserID).Scan(&status.RecoveryCodesRemaining)
+	} else if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		slog.Error(common.LogTwoFactorStatus + " - erreur lors de la récupération : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	slog.Info(common.LogTwoFactorStatus + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetTwoFactorStatus,
+		Data:    status,
+	})
+}
+
+// Setup génère un nouveau secret TOTP, à confirmer avec Confirm
+// @Summary Générer un secret TOTP
+// @Description Génère le secret à enregistrer dans l'application d'authentification (URI otpauth:// à afficher en QR code). L'authentification à deux facteurs n'est active qu'après confirmation ; un nouvel appel remplace le secret non confirmé.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=common.TwoFactorSetupResponse}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 409 {object} common.JSONErrorResponse
+// @Router /auth/2fa/setup [post]
+func (TwoFactorStruct) Setup(c *gin.Context) {
+	slog.Info(common.LogTwoFactorSetup)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		slog.Error(common.LogTwoFactorSetup + " - erreur lors de la génération du secret : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	// Le secret n'est remplacé que s'il n'a pas encore été confirmé
+	result, err := common.DB.Exec(`
+		INSERT INTO user_totp (user_id, secret, created_at) VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			secret = IF(confirmed_at IS NULL, VALUES(secret), secret),
+			created_at = IF(confirmed_at IS NULL, NOW(), created_at)
+	`, userData.UserID, secret)
+	if err != nil {
+		slog.Error(common.LogTwoFactorSetup + " - erreur lors de l'enregistrement du secret : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusConflict, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactorAlreadyEnabled,
+		})
+		return
+	}
+
+	slog.Info(common.LogTwoFactorSetup + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessTwoFactorSetup,
+		Data: common.TwoFactorSetupResponse{
+			Secret:     secret,
+			OTPAuthURI: totp.ProvisioningURI(issuer, userData.Email, secret),
+		},
+	})
+}
+
+// Confirm active l'authentification à deux facteurs avec un premier code et retourne les codes de récupération
+// @Summary Activer l'authentification à deux facteurs
+// @Description Vérifie un code généré par l'application avec le secret de /auth/2fa/setup puis active l'authentification à deux facteurs. Les codes de récupération (usage unique) ne sont retournés qu'une fois.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.TwoFactorConfirmRequest true "Code à 6 chiffres"
+// @Success 200 {object} common.JSONResponse{data=common.TwoFactorRecoveryCodesResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /auth/2fa/confirm [post]
+func (TwoFactorStruct) Confirm(c *gin.Context) {
+	slog.Info(common.LogTwoFactorConfirm)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var secret string
+	err = tx.QueryRow(`SELECT secret FROM user_totp WHERE user_id = ? AND confirmed_at IS NULL FOR UPDATE`, userData.UserID).Scan(&secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactorSetupMissing,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - erreur lors de la récupération du secret : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	counter, valid := totp.Validate(secret, normalizeCode(req.Code), time.Now())
+	if !valid {
+		slog.Error(common.LogTwoFactorConfirm + " - code invalide")
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTwoFactorCode,
+		})
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE user_totp SET confirmed_at = NOW(), last_counter = ? WHERE user_id = ?`, counter, userData.UserID); err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - erreur lors de l'activation : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	codes, err := replaceRecoveryCodes(tx, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - erreur lors de la génération des codes de récupération : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTwoFactorConfirm + " - erreur lors du commit : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	slog.Info(common.LogTwoFactorConfirm + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessTwoFactorEnabled,
+		Data:    common.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// Disable désactive l'authentification à deux facteurs
+// @Summary Désactiver l'authentification à deux facteurs
+// @Description Désactive l'authentification à deux facteurs et supprime les codes de récupération. Le mot de passe et un code (TOTP ou de récupération) sont exigés ; un compte sans mot de passe local (annuaire LDAP ou SSO) ne fournit que le code.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body common.TwoFactorDisableRequest true "Mot de passe (si le compte en a un) et code"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /auth/2fa/disable [post]
+func (TwoFactorStruct) Disable(c *gin.Context) {
+	slog.Info(common.LogTwoFactorDisable)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogTwoFactorDisable + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+
+	// Un compte sans mot de passe local (annuaire LDAP ou SSO) ne prouve son identité que par le code
+	var passwordHash string
+	err := common.DB.QueryRow(`SELECT password_hash FROM user_password WHERE user_id = ? AND deleted_at IS NULL`, userData.UserID).Scan(&passwordHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error(common.LogTwoFactorDisable + " - erreur lors de la récupération du mot de passe : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	if err == nil && bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)) != nil {
+		slog.Error(common.LogTwoFactorDisable + " - mot de passe invalide")
+		c.JSON(http.StatusUnauthorized, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidPassword,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogTwoFactorDisable + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	valid, err := verifyCode(tx, userData.UserID, req.Code)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactorNotEnabled,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error(common.LogTwoFactorDisable + " - erreur lors de la vérification du code : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+	if !valid {
+		slog.Error(common.LogTwoFactorDisable + " - code invalide")
+		c.JSON(http.StatusUnauthorized, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTwoFactorCode,
+		})
+		return
+	}
+
+	for _, query := range []string{
+		`DELETE FROM user_totp WHERE user_id = ?`,
+		`DELETE FROM user_recovery_code WHERE user_id = ?`,
+		`UPDATE login_challenge SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL`,
+	} {
+		if _, err := tx.Exec(query, userData.UserID); err != nil {
+			slog.Error(common.LogTwoFactorDisable + " - erreur lors de la désactivation : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrTwoFactor,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogTwoFactorDisable + " - erreur lors du commit : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTwoFactor,
+		})
+		return
+	}
+
+	slog.Info(common.LogTwoFactorDisable + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessTwoFactorDisabled,
+	})
+}
+
+// verifyCode vérifie un code TOTP (6 chiffres) ou un code de récupération, qui est alors consommé.
+// Un code TOTP déjà accepté (même pas de temps ou antérieur) est refusé pour empêcher son rejeu.
+// sql.ErrNoRows est retournée si l'authentification à deux facteurs n'est pas activée.
+func verifyCode(tx *sql.Tx, userID int, code string) (bool, error) {
+	var secret string
+	var lastCounter sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT secret, last_counter FROM user_totp WHERE user_id = ? AND confirmed_at IS NOT NULL FOR UPDATE
+	`, userID).Scan(&secret, &lastCounter)
+	if err != nil {
+		return false, err
+	}
+
+	code = normalizeCode(code)
+	if len(code) == totp.Digits {
+		counter, valid := totp.Validate(secret, code, time.Now())
+		if !valid || (lastCounter.Valid && counter <= lastCounter.Int64) {
+			return false, nil
+		}
+		_, err := tx.Exec(`UPDATE user_totp SET last_counter = ? WHERE user_id = ?`, counter, userID)
+		return err == nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE user_recovery_code SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, userID, common.HashToken(code))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// replaceRecoveryCodes remplace les codes de récupération de l'utilisateur et retourne les nouveaux, en clair
+func replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_code WHERE user_id = ?`, userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO user_recovery_code (user_id, code_hash, created_at) VALUES (?, ?, NOW())
+		`, userID, common.HashToken(normalizeCode(code))); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode génère un code de récupération au format xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	var code strings.Builder
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(recoveryAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// normalizeCode retire les espaces et tirets saisis par l'utilisateur et met le code en minuscules
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
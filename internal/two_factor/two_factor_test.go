@@ -0,0 +1,223 @@
+package two_factor_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/internal/totp"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// loginChallenge se connecte avec le mot de passe et retourne le challenge du second facteur
+func loginChallenge(t *testing.T, user *testutils.AuthenticatedUser) string {
+	status, response := testutils.DoRequest(t, testServer, "", "POST", "/auth/login", gin.H{"email": user.User.Email, "password": user.Password})
+	require.Equal(t, http.StatusOK, status, response.Error)
+	var challenge common.LoginChallengeResponse
+	require.NoError(t, json.Unmarshal(response.Data, &challenge))
+	require.True(t, challenge.TwoFactorRequired)
+	require.Len(t, challenge.ChallengeToken, 64)
+	require.NotContains(t, string(response.Data), "session_token")
+	return challenge.ChallengeToken
+}
+
+// elapse fait comme si le dernier échec de connexion de l'utilisateur remontait à 2 minutes,
+// pour ne pas attendre le délai progressif imposé après des codes erronés
+func elapse(t *testing.T, user *testutils.AuthenticatedUser) {
+	_, err := common.DB.Exec(`UPDATE login_lockout SET last_failed_at = ? WHERE email = ?`, time.Now().Add(-2*time.Minute), strings.ToLower(user.User.Email))
+	require.NoError(t, err)
+}
+
+// loginEvents retourne le nombre d'événements de sécurité d'un type pour l'utilisateur
+func loginEvents(t *testing.T, user *testutils.AuthenticatedUser, eventType string) int {
+	var count int
+	require.NoError(t, common.DB.QueryRow(`
+		SELECT COUNT(*) FROM security_event WHERE user_id = ? AND event_type = ?
+	`, user.User.UserID, eventType).Scan(&count))
+	return count
+}
+
+// status retourne l'état de l'authentification à deux facteurs de l'utilisateur
+func status(t *testing.T, token string) common.TwoFactorStatus {
+	code, response := testutils.DoRequest(t, testServer, token, "GET", "/auth/2fa", nil)
+	require.Equal(t, http.StatusOK, code, response.Error)
+	var result common.TwoFactorStatus
+	require.NoError(t, json.Unmarshal(response.Data, &result))
+	return result
+}
+
+// totpCode calcule le code attendu par le serveur
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.Code(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+// TestTwoFactorLifecycle vérifie l'activation, la connexion en deux étapes, les codes de récupération et la désactivation
+func TestTwoFactorLifecycle(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	now := time.Now()
+	require.False(t, status(t, user.SessionToken).Enabled)
+
+	// Activation : secret puis confirmation par un premier code
+	code, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/confirm", gin.H{"code": "123456"})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, common.ErrTwoFactorSetupMissing, response.Error)
+
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/setup", nil)
+	require.Equal(t, http.StatusOK, code, response.Error)
+	var setup common.TwoFactorSetupResponse
+	require.NoError(t, json.Unmarshal(response.Data, &setup))
+	require.True(t, strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/GoLendar:"+user.User.Email+"?"), setup.OTPAuthURI)
+
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/confirm", gin.H{"code": totpCode(t, setup.Secret, now.Add(10*totp.Period))})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, common.ErrInvalidTwoFactorCode, response.Error)
+
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/confirm", gin.H{"code": totpCode(t, setup.Secret, now)})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	var recovery common.TwoFactorRecoveryCodesResponse
+	require.NoError(t, json.Unmarshal(response.Data, &recovery))
+	require.Len(t, recovery.RecoveryCodes, 10)
+
+	code, _ = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/setup", nil)
+	require.Equal(t, http.StatusConflict, code)
+
+	// Connexion : le code déjà utilisé à l'activation est refusé (rejeu), le suivant est accepté
+	challenge := loginChallenge(t, user)
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": totpCode(t, setup.Secret, now)})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidTwoFactorCode, response.Error)
+	require.Zero(t, loginEvents(t, user, "login_succeeded"))
+	require.Equal(t, 1, loginEvents(t, user, "login_failed"))
+
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": totpCode(t, setup.Secret, now.Add(totp.Period))})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	require.Equal(t, 1, loginEvents(t, user, "login_succeeded"))
+	var session common.LoginResponse
+	require.NoError(t, json.Unmarshal(response.Data, &session))
+	require.NotEmpty(t, session.SessionToken)
+	require.Equal(t, user.User.UserID, session.User.UserID)
+
+	// Le challenge est à usage unique
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": totpCode(t, setup.Secret, now.Add(totp.Period))})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidTwoFactorChallenge, response.Error)
+
+	current := status(t, session.SessionToken)
+	require.True(t, current.Enabled)
+	require.Equal(t, 10, current.RecoveryCodesRemaining)
+
+	// Code de récupération saisi en majuscules avec des espaces : accepté une seule fois
+	challenge = loginChallenge(t, user)
+	typed := strings.ToUpper(strings.ReplaceAll(recovery.RecoveryCodes[0], "-", " "))
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": typed})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	require.Equal(t, 9, status(t, session.SessionToken).RecoveryCodesRemaining)
+
+	// Après 5 codes erronés, le challenge n'est plus utilisable ; chaque code erroné compte comme un échec de connexion
+	challenge = loginChallenge(t, user)
+	for i := 0; i < 5; i++ {
+		elapse(t, user)
+		code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": "aaaaa-aaaaa"})
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, common.ErrInvalidTwoFactorCode, response.Error)
+	}
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/2fa/verify", gin.H{"challenge_token": challenge, "code": recovery.RecoveryCodes[2]})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidTwoFactorChallenge, response.Error)
+	var failures int
+	require.NoError(t, common.DB.QueryRow(`SELECT failed_count FROM login_lockout WHERE email = ?`, strings.ToLower(user.User.Email)).Scan(&failures))
+	require.Equal(t, 5, failures)
+
+	// Désactivation : mot de passe et code valide exigés
+	code, response = testutils.DoRequest(t, testServer, session.SessionToken, "POST", "/auth/2fa/disable", gin.H{"password": "mauvais", "code": recovery.RecoveryCodes[1]})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidPassword, response.Error)
+	code, response = testutils.DoRequest(t, testServer, session.SessionToken, "POST", "/auth/2fa/disable", gin.H{"code": recovery.RecoveryCodes[1]})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidPassword, response.Error)
+	code, response = testutils.DoRequest(t, testServer, session.SessionToken, "POST", "/auth/2fa/disable", gin.H{"password": user.Password, "code": recovery.RecoveryCodes[0]})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidTwoFactorCode, response.Error)
+	code, response = testutils.DoRequest(t, testServer, session.SessionToken, "POST", "/auth/2fa/disable", gin.H{"password": user.Password, "code": recovery.RecoveryCodes[1]})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	require.False(t, status(t, session.SessionToken).Enabled)
+
+	// La connexion redevient directe
+	elapse(t, user)
+	code, response = testutils.DoRequest(t, testServer, "", "POST", "/auth/login", gin.H{"email": user.User.Email, "password": user.Password})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	require.Contains(t, string(response.Data), "session_token")
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestTwoFactorDisableWhenNotEnabled vérifie le refus de désactiver une authentification à deux facteurs inexistante
+func TestTwoFactorDisableWhenNotEnabled(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+
+	code, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/disable", gin.H{"password": user.Password, "code": "123456"})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, common.ErrTwoFactorNotEnabled, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestTwoFactorDisableWithoutLocalPassword vérifie qu'un compte sans mot de passe local (LDAP ou SSO)
+// désactive l'authentification à deux facteurs avec le seul code
+func TestTwoFactorDisableWithoutLocalPassword(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	_, err = common.DB.Exec(`DELETE FROM user_password WHERE user_id = ?`, user.User.UserID)
+	require.NoError(t, err)
+	now := time.Now()
+
+	code, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/setup", nil)
+	require.Equal(t, http.StatusOK, code, response.Error)
+	var setup common.TwoFactorSetupResponse
+	require.NoError(t, json.Unmarshal(response.Data, &setup))
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/confirm", gin.H{"code": totpCode(t, setup.Secret, now)})
+	require.Equal(t, http.StatusOK, code, response.Error)
+
+	// Le code reste exigé
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/disable", gin.H{"code": "000000"})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, common.ErrInvalidTwoFactorCode, response.Error)
+	require.True(t, status(t, user.SessionToken).Enabled)
+
+	code, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/auth/2fa/disable", gin.H{"code": totpCode(t, setup.Secret, now.Add(totp.Period))})
+	require.Equal(t, http.StatusOK, code, response.Error)
+	require.False(t, status(t, user.SessionToken).Enabled)
+
+	testutils.PurgeAllTestUsers()
+}
@@ -4,7 +4,9 @@ package user
 import (
 	"database/sql"
 	"fmt"
+	"go-averroes/internal/accesstoken"
 	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
 	"log/slog"
 	"net/http"
 	"regexp"
@@ -49,7 +51,7 @@ func (UserStruct) Get(c *gin.Context) {
 
 // Add crée un nouvel utilisateur
 // @Summary Créer un utilisateur
-// @Description Crée un nouvel utilisateur (inscription)
+// @Description Crée un nouvel utilisateur (inscription). L'adresse e-mail reste non vérifiée jusqu'à ce que le lien envoyé par e-mail soit suivi.
 // @Tags Utilisateur
 // @Accept json
 // @Produce json
@@ -157,6 +159,17 @@ func (UserStruct) Add(c *gin.Context) {
 		return
 	}
 
+	// Le compte reste non vérifié jusqu'à ce que l'utilisateur suive le lien envoyé par e-mail
+	verificationToken, err := email_verification.IssueToken(tx, int(userID))
+	if err != nil {
+		slog.Error(common.LogUserAdd + " - erreur lors de la création du jeton de vérification : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrUserCreation,
+		})
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		slog.Error(common.LogUserAdd + " - erreur lors du commit de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -166,6 +179,8 @@ func (UserStruct) Add(c *gin.Context) {
 		return
 	}
 
+	email_verification.Send(req.Email, req.Firstname, verificationToken)
+
 	slog.Info(common.LogUserAdd + " - succès")
 	c.JSON(http.StatusCreated, common.JSONResponse{
 		Success: true,
@@ -247,6 +262,7 @@ func (UserStruct) Update(c *gin.Context) {
 
 	query := "UPDATE user SET updated_at = NOW()"
 	var args []interface{}
+	emailChanged := false
 
 	if req.Lastname != nil {
 		query += ", lastname = ?"
@@ -267,8 +283,12 @@ func (UserStruct) Update(c *gin.Context) {
 			})
 			return
 		}
-		query += ", email = ?"
-		args = append(args, *req.Email)
+		// Une nouvelle adresse doit être vérifiée à son tour
+		if *req.Email != userData.Email {
+			query += ", email = ?, email_verified_at = NULL"
+			args = append(args, *req.Email)
+			emailChanged = true
+		}
 	}
 
 	query += " WHERE user_id = ?"
@@ -310,6 +330,27 @@ func (UserStruct) Update(c *gin.Context) {
 		}
 	}
 
+	var verificationToken string
+	if emailChanged {
+		// Les liens envoyés à l'ancienne adresse ne doivent pas valider la nouvelle
+		_, err = tx.Exec(`UPDATE email_verification_token SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL`, userID)
+		if err == nil {
+			// Les tokens d'accès signés émis auparavant portent l'ancienne adresse, vérifiée
+			err = accesstoken.ClaimsChanged(tx, userID)
+		}
+		if err == nil {
+			verificationToken, err = email_verification.IssueToken(tx, userID)
+		}
+		if err != nil {
+			slog.Error(common.LogUserUpdate + " - erreur lors de la création du jeton de vérification : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrUserUpdate,
+			})
+			return
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		slog.Error(common.LogUserUpdate + " - erreur lors du commit de la transaction : " + err.Error())
 		c.JSON(http.StatusInternalServerError, common.JSONResponse{
@@ -319,6 +360,14 @@ func (UserStruct) Update(c *gin.Context) {
 		return
 	}
 
+	if emailChanged {
+		firstname := userData.Firstname
+		if req.Firstname != nil {
+			firstname = *req.Firstname
+		}
+		email_verification.Send(*req.Email, firstname, verificationToken)
+	}
+
 	slog.Info(common.LogUserUpdate + " - succès")
 	c.JSON(http.StatusOK, common.JSONResponse{
 		Success: true,
@@ -437,8 +437,8 @@ func TestGetUserMeRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur et les headers
@@ -1082,8 +1082,8 @@ func TestDeleteUserMeRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur et les headers
@@ -1420,8 +1420,8 @@ func TestGetUserByIDRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, adminUser.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(adminUser.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible à récupérer
@@ -2377,8 +2377,8 @@ func TestDeleteUserByIDRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, adminUser.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(adminUser.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible à supprimer
@@ -2689,8 +2689,8 @@ func TestGetUserByIDWithRolesRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, adminUser.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(adminUser.SessionToken))
 				require.NoError(t, err)
 				targetUser, err := testutils.GenerateAuthenticatedUser(false, true, false, false)
 				require.NoError(t, err)
@@ -2960,8 +2960,8 @@ func TestGetAuthMeRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE, updated_at = NOW() 
-					WHERE session_token = ?
-				`, user.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(user.SessionToken))
 				require.NoError(t, err)
 
 				// Retourner les données de préparation avec l'utilisateur et les headers
@@ -0,0 +1,792 @@
+// Package user_availability internal/user_availability/user_availability.go
+package user_availability
+
+import (
+	"database/sql"
+	"errors"
+	"go-averroes/internal/availability"
+	"go-averroes/internal/common"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserAvailabilityStruct struct{}
+
+var UserAvailability = UserAvailabilityStruct{}
+
+const (
+	// maxFreeBusyDays borne la période interrogée par FreeBusy et FindSlots
+	maxFreeBusyDays = 31
+	// maxFreeBusyUsers borne le nombre d'utilisateurs interrogés en une requête
+	maxFreeBusyUsers = 20
+)
+
+// queryer est implémenté par *sql.DB et *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Schedule regroupe les contraintes de disponibilité d'un utilisateur sur une période :
+// ses heures de travail (si HasWorkingHours) et ses absences, dans son fuseau horaire
+type Schedule struct {
+	Location        *time.Location
+	HasWorkingHours bool
+	WorkingHours    []availability.Interval
+	OutOfOffice     []availability.Interval
+}
+
+// WithinWorkingHours restreint les intervalles aux heures de travail, si l'utilisateur en a défini
+func (s Schedule) WithinWorkingHours(intervals []availability.Interval) []availability.Interval {
+	if !s.HasWorkingHours {
+		return intervals
+	}
+	return availability.Intersect(intervals, s.WorkingHours)
+}
+
+// GetWorkingHours récupère les heures de travail de l'utilisateur connecté
+// @Summary Récupérer mes heures de travail
+// @Description Récupère les plages hebdomadaires de travail de l'utilisateur connecté et leur fuseau horaire
+// @Tags Disponibilités
+// @Produce json
+// @Success 200 {object} common.JSONResponse{data=common.WorkingHours}
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /user/me/working-hours [get]
+func (UserAvailabilityStruct) GetWorkingHours(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityGetWorkingHours)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	workingHours, err := loadWorkingHours(common.DB, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityGetWorkingHours + " - erreur lors de la récupération des heures de travail : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrWorkingHoursRetrieval,
+		})
+		return
+	}
+
+	slog.Info(common.LogUserAvailabilityGetWorkingHours + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessGetWorkingHours,
+		Data:    workingHours,
+	})
+}
+
+// UpdateWorkingHours remplace les heures de travail de l'utilisateur connecté
+// @Summary Mettre à jour mes heures de travail
+// @Description Remplace les plages hebdomadaires de travail et le fuseau horaire de l'utilisateur connecté ("rules": [] retire les heures de travail). Hors de ces plages, l'utilisateur apparaît indisponible dans les disponibilités, la recherche de créneaux et ses pages de réservation.
+// @Tags Disponibilités
+// @Accept json
+// @Produce json
+// @Param working_hours body common.UpdateWorkingHoursRequest true "Heures de travail"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /user/me/working-hours [put]
+func (UserAvailabilityStruct) UpdateWorkingHours(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityUpdateWorkingHours)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.UpdateWorkingHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogUserAvailabilityUpdateWorkingHours + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	if !ValidTimezone(req.Timezone) {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidTimezone,
+		})
+		return
+	}
+	rules, err := ToAvailabilityRules(req.Rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidBookingRules,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityUpdateWorkingHours + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE user SET timezone = ?, updated_at = NOW() WHERE user_id = ?", []interface{}{req.Timezone, userData.UserID}},
+		{"UPDATE user_working_hours SET deleted_at = NOW() WHERE user_id = ? AND deleted_at IS NULL", []interface{}{userData.UserID}},
+	}
+	for _, rule := range rules {
+		statements = append(statements, struct {
+			query string
+			args  []interface{}
+		}{
+			"INSERT INTO user_working_hours (user_id, weekday, start_minute, end_minute, created_at) VALUES (?, ?, ?, ?, NOW())",
+			[]interface{}{userData.UserID, int(rule.Weekday), rule.StartMinute, rule.EndMinute},
+		})
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			slog.Error(common.LogUserAvailabilityUpdateWorkingHours + " - erreur lors de la mise à jour des heures de travail : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrWorkingHoursUpdate,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogUserAvailabilityUpdateWorkingHours + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogUserAvailabilityUpdateWorkingHours + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessUpdateWorkingHours,
+	})
+}
+
+// ListOutOfOffice liste les absences de l'utilisateur connecté
+// @Summary Lister mes absences
+// @Description Liste les absences en cours et à venir de l'utilisateur connecté, triées par début
+// @Tags Disponibilités
+// @Produce json
+// @Param include_past query bool false "Inclure les absences terminées"
+// @Success 200 {object} common.JSONResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /user/me/out-of-office [get]
+func (UserAvailabilityStruct) ListOutOfOffice(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityListOutOfOffice)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT out_of_office_id, user_id, start, end, message, created_at, updated_at, deleted_at
+		FROM user_out_of_office
+		WHERE user_id = ? AND deleted_at IS NULL`
+	if c.Query("include_past") != "true" {
+		query += " AND end > NOW()"
+	}
+	query += " ORDER BY start, out_of_office_id"
+
+	rows, err := common.DB.Query(query, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityListOutOfOffice + " - erreur lors de la récupération des absences : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeRetrieval,
+		})
+		return
+	}
+	defer rows.Close()
+
+	periods := []common.OutOfOffice{}
+	for rows.Next() {
+		var period common.OutOfOffice
+		if err := rows.Scan(&period.OutOfOfficeID, &period.UserID, &period.Start, &period.End, &period.Message,
+			&period.CreatedAt, &period.UpdatedAt, &period.DeletedAt); err != nil {
+			slog.Error(common.LogUserAvailabilityListOutOfOffice + " - erreur lors de la lecture des absences : " + err.Error())
+			c.JSON(http.StatusInternalServerError, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrOutOfOfficeRetrieval,
+			})
+			return
+		}
+		periods = append(periods, period)
+	}
+
+	slog.Info(common.LogUserAvailabilityListOutOfOffice + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessListOutOfOffice,
+		Data:    periods,
+	})
+}
+
+// AddOutOfOffice enregistre une absence de l'utilisateur connecté
+// @Summary Enregistrer une absence
+// @Description Enregistre une période d'absence : l'utilisateur y apparaît indisponible, ses pages de réservation n'y proposent aucun créneau et ses invitations aux événements qui la chevauchent, même acceptées, sont déclinées automatiquement (invitations_declined dans la réponse). Le message de l'absence accompagne ces refus.
+// @Tags Disponibilités
+// @Accept json
+// @Produce json
+// @Param out_of_office body common.CreateOutOfOfficeRequest true "Période d'absence"
+// @Success 201 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Router /user/me/out-of-office [post]
+func (UserAvailabilityStruct) AddOutOfOffice(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityAddOutOfOffice)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req common.CreateOutOfOfficeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error(common.LogUserAvailabilityAddOutOfOffice + " - données invalides : " + err.Error())
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidData + ": " + err.Error(),
+		})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidOutOfOfficeRange,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityAddOutOfOffice + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO user_out_of_office (user_id, start, end, message, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, userData.UserID, req.Start, req.End, req.Message)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityAddOutOfOffice + " - erreur lors de l'enregistrement de l'absence : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeCreation,
+		})
+		return
+	}
+	periodID, _ := result.LastInsertId()
+
+	// Les invitations aux événements qui chevauchent l'absence sont déclinées, y compris celles déjà acceptées
+	result, err = tx.Exec(`
+		UPDATE event_invitation ei
+		INNER JOIN event e ON e.event_id = ei.event_id
+		SET ei.status = 'declined', ei.auto_declined = TRUE, ei.comment = ?, ei.responded_at = NOW()
+		WHERE ei.user_id = ? AND ei.deleted_at IS NULL AND ei.status <> 'declined'
+		AND e.deleted_at IS NULL AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+	`, req.Message, userData.UserID, req.End, req.Start)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityAddOutOfOffice + " - erreur lors du refus des invitations : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeCreation,
+		})
+		return
+	}
+	declined, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogUserAvailabilityAddOutOfOffice + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogUserAvailabilityAddOutOfOffice+" - succès", "invitations_declined", declined)
+	c.JSON(http.StatusCreated, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessCreateOutOfOffice,
+		Data: gin.H{
+			"out_of_office_id":     periodID,
+			"invitations_declined": declined,
+		},
+	})
+}
+
+// DeleteOutOfOffice supprime une absence de l'utilisateur connecté
+// @Summary Supprimer une absence
+// @Description Supprime une période d'absence de l'utilisateur connecté. Les invitations qu'elle avait déclinées automatiquement, et qu'aucune autre absence ne couvre, repassent en attente de réponse.
+// @Tags Disponibilités
+// @Produce json
+// @Param out_of_office_id path int true "ID de l'absence"
+// @Success 200 {object} common.JSONResponse
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /user/me/out-of-office/{out_of_office_id} [delete]
+func (UserAvailabilityStruct) DeleteOutOfOffice(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityDeleteOutOfOffice)
+	userData, ok := common.GetUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	periodID, err := strconv.Atoi(c.Param("out_of_office_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidOutOfOfficeID,
+		})
+		return
+	}
+
+	tx, err := common.DB.Begin()
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityDeleteOutOfOffice + " - erreur lors du démarrage de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionStart,
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_out_of_office SET deleted_at = NOW()
+		WHERE out_of_office_id = ? AND user_id = ? AND deleted_at IS NULL
+	`, periodID, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityDeleteOutOfOffice + " - erreur lors de la suppression de l'absence : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeDelete,
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeNotFound,
+		})
+		return
+	}
+
+	// Les invitations déclinées automatiquement qu'aucune autre absence ne couvre attendent à nouveau une réponse
+	_, err = tx.Exec(`
+		UPDATE event_invitation ei
+		INNER JOIN event e ON e.event_id = ei.event_id
+		SET ei.status = 'pending', ei.auto_declined = FALSE, ei.comment = NULL, ei.responded_at = NULL
+		WHERE ei.user_id = ? AND ei.auto_declined = TRUE AND ei.deleted_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM user_out_of_office o
+			WHERE o.user_id = ei.user_id AND o.deleted_at IS NULL
+			AND o.start < DATE_ADD(e.start, INTERVAL e.duration MINUTE) AND o.end > e.start
+		)
+	`, userData.UserID)
+	if err != nil {
+		slog.Error(common.LogUserAvailabilityDeleteOutOfOffice + " - erreur lors du rétablissement des invitations : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrOutOfOfficeDelete,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error(common.LogUserAvailabilityDeleteOutOfOffice + " - erreur lors du commit de la transaction : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrTransactionCommit,
+		})
+		return
+	}
+
+	slog.Info(common.LogUserAvailabilityDeleteOutOfOffice + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessDeleteOutOfOffice,
+	})
+}
+
+// FreeBusy retourne les disponibilités d'un ou plusieurs utilisateurs sur une période
+// @Summary Disponibilités d'utilisateurs
+// @Description Pour chaque utilisateur : ses heures de travail sur la période (absentes s'il n'en a pas défini), ses absences et ses créneaux occupés par des événements non annulés, de ses calendriers ou dont il a accepté l'invitation. Les titres des événements ne sont pas exposés.
+// @Tags Disponibilités
+// @Produce json
+// @Param user_ids query string false "IDs des utilisateurs séparés par des virgules (utilisateur connecté par défaut, 20 au plus)"
+// @Param start query string true "Début de la période (YYYY-MM-DD ou RFC3339)"
+// @Param end query string true "Fin de la période (YYYY-MM-DD inclus ou RFC3339 exclu), 31 jours au plus"
+// @Success 200 {object} common.JSONResponse{data=common.FreeBusyResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /availability/free-busy [get]
+func (UserAvailabilityStruct) FreeBusy(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityFreeBusy)
+	userIDs, start, end, ok := parseQuery(c)
+	if !ok {
+		return
+	}
+	period := []availability.Interval{{Start: start, End: end}}
+
+	response := common.FreeBusyResponse{Start: start, End: end, Users: []common.FreeBusyUser{}}
+	for _, userID := range userIDs {
+		schedule, busy, ok := loadUser(c, common.LogUserAvailabilityFreeBusy, userID, start, end)
+		if !ok {
+			return
+		}
+		user := common.FreeBusyUser{
+			UserID:      userID,
+			Timezone:    schedule.Location.String(),
+			OutOfOffice: toRanges(availability.Intersect(schedule.OutOfOffice, period), schedule.Location),
+			Busy:        toRanges(availability.Intersect(busy, period), schedule.Location),
+		}
+		if schedule.HasWorkingHours {
+			user.WorkingHours = toRanges(schedule.WithinWorkingHours(period), schedule.Location)
+		}
+		response.Users = append(response.Users, user)
+	}
+
+	slog.Info(common.LogUserAvailabilityFreeBusy + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessFreeBusy,
+		Data:    response,
+	})
+}
+
+// FindSlots recherche les créneaux où tous les utilisateurs sont disponibles
+// @Summary Rechercher des créneaux communs
+// @Description Retourne les plages à venir d'au moins duration minutes pendant lesquelles tous les utilisateurs sont dans leurs heures de travail, ne sont pas absents et n'ont aucun événement non annulé
+// @Tags Disponibilités
+// @Produce json
+// @Param user_ids query string false "IDs des utilisateurs séparés par des virgules (utilisateur connecté par défaut, 20 au plus)"
+// @Param start query string true "Début de la période (YYYY-MM-DD ou RFC3339)"
+// @Param end query string true "Fin de la période (YYYY-MM-DD inclus ou RFC3339 exclu), 31 jours au plus"
+// @Param duration query int true "Durée minimale en minutes (5 à 480)"
+// @Success 200 {object} common.JSONResponse{data=common.FindSlotsResponse}
+// @Failure 400 {object} common.JSONErrorResponse
+// @Failure 401 {object} common.JSONErrorResponse
+// @Failure 404 {object} common.JSONErrorResponse
+// @Router /availability/slots [get]
+func (UserAvailabilityStruct) FindSlots(c *gin.Context) {
+	slog.Info(common.LogUserAvailabilityFindSlots)
+	duration, err := strconv.Atoi(c.Query("duration"))
+	if err != nil || duration < 5 || duration > 480 {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidSlotDuration,
+		})
+		return
+	}
+	userIDs, start, end, ok := parseQuery(c)
+	if !ok {
+		return
+	}
+
+	// Seules les plages à venir sont proposées
+	from := start
+	if now := time.Now(); now.After(from) {
+		from = now
+	}
+	free := []availability.Interval{{Start: from, End: end}}
+	if !from.Before(end) {
+		free = nil
+	}
+	for _, userID := range userIDs {
+		schedule, busy, ok := loadUser(c, common.LogUserAvailabilityFindSlots, userID, from, end)
+		if !ok {
+			return
+		}
+		free = availability.Subtract(schedule.WithinWorkingHours(free), append(busy, schedule.OutOfOffice...))
+	}
+
+	slots := []common.TimeRange{}
+	for _, interval := range free {
+		if interval.End.Sub(interval.Start) >= time.Duration(duration)*time.Minute {
+			slots = append(slots, common.TimeRange{Start: interval.Start, End: interval.End})
+		}
+	}
+
+	slog.Info(common.LogUserAvailabilityFindSlots + " - succès")
+	c.JSON(http.StatusOK, common.JSONResponse{
+		Success: true,
+		Message: common.MsgSuccessFindSlots,
+		Data: common.FindSlotsResponse{
+			Start:    start,
+			End:      end,
+			Duration: duration,
+			UserIDs:  userIDs,
+			Slots:    slots,
+		},
+	})
+}
+
+// LoadSchedule lit le fuseau horaire, les heures de travail et les absences de l'utilisateur sur [from, to).
+// Retourne sql.ErrNoRows si l'utilisateur n'existe pas.
+func LoadSchedule(db queryer, userID int, from, to time.Time) (Schedule, error) {
+	workingHours, err := loadWorkingHours(db, userID)
+	if err != nil {
+		return Schedule{}, err
+	}
+	schedule := Schedule{Location: time.UTC, HasWorkingHours: len(workingHours.Rules) > 0}
+	if loc, err := time.LoadLocation(workingHours.Timezone); err == nil {
+		schedule.Location = loc
+	}
+	if schedule.HasWorkingHours {
+		rules, err := ToAvailabilityRules(workingHours.Rules)
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.WorkingHours = availability.Windows(rules, schedule.Location, from, to)
+	}
+
+	rows, err := db.Query(`
+		SELECT start, end FROM user_out_of_office
+		WHERE user_id = ? AND deleted_at IS NULL AND start < ? AND end > ?
+	`, userID, to, from)
+	if err != nil {
+		return Schedule{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period availability.Interval
+		if err := rows.Scan(&period.Start, &period.End); err != nil {
+			return Schedule{}, err
+		}
+		schedule.OutOfOffice = append(schedule.OutOfOffice, period)
+	}
+	return schedule, rows.Err()
+}
+
+// SlotAvailability indique si le créneau [start, end) est dans les heures de travail de l'utilisateur, chevauche
+// une de ses absences ou un autre de ses événements. L'événement eventID lui-même n'est pas compté comme conflit.
+func SlotAvailability(db queryer, userID, eventID int, start, end time.Time) (common.InvitationAvailability, error) {
+	schedule, err := LoadSchedule(db, userID, start, end)
+	if err != nil {
+		return common.InvitationAvailability{}, err
+	}
+	busy, err := busyEvents(db, userID, start, end, eventID)
+	if err != nil {
+		return common.InvitationAvailability{}, err
+	}
+	slot := []availability.Interval{{Start: start, End: end}}
+	return common.InvitationAvailability{
+		WithinWorkingHours: len(availability.Subtract(slot, schedule.WithinWorkingHours(slot))) == 0,
+		OutOfOffice:        len(availability.Intersect(slot, schedule.OutOfOffice)) > 0,
+		Conflict:           len(busy) > 0,
+	}, nil
+}
+
+// ToAvailabilityRules convertit des plages hebdomadaires de l'API (heures HH:MM) en plages de calcul validées
+func ToAvailabilityRules(rules []common.WeeklyRule) ([]availability.Rule, error) {
+	result := make([]availability.Rule, 0, len(rules))
+	for _, rule := range rules {
+		startMinute, err := availability.ParseClock(rule.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		endMinute, err := availability.ParseClock(rule.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, availability.Rule{Weekday: time.Weekday(rule.Weekday), StartMinute: startMinute, EndMinute: endMinute})
+	}
+	return result, availability.ValidateRules(result)
+}
+
+// ValidTimezone indique si le fuseau horaire est un nom IANA connu (ex: Europe/Paris)
+func ValidTimezone(timezone string) bool {
+	if timezone == "" || timezone == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+// loadWorkingHours lit le fuseau horaire et les plages de travail de l'utilisateur
+func loadWorkingHours(db queryer, userID int) (common.WorkingHours, error) {
+	workingHours := common.WorkingHours{UserID: userID, Rules: []common.WeeklyRule{}}
+	err := db.QueryRow("SELECT timezone FROM user WHERE user_id = ? AND deleted_at IS NULL", userID).Scan(&workingHours.Timezone)
+	if err != nil {
+		return common.WorkingHours{}, err
+	}
+
+	rows, err := db.Query(`
+		SELECT weekday, start_minute, end_minute FROM user_working_hours
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY weekday, start_minute
+	`, userID)
+	if err != nil {
+		return common.WorkingHours{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var weekday, startMinute, endMinute int
+		if err := rows.Scan(&weekday, &startMinute, &endMinute); err != nil {
+			return common.WorkingHours{}, err
+		}
+		workingHours.Rules = append(workingHours.Rules, common.WeeklyRule{
+			Weekday:   weekday,
+			StartTime: availability.FormatClock(startMinute),
+			EndTime:   availability.FormatClock(endMinute),
+		})
+	}
+	return workingHours, rows.Err()
+}
+
+// loadUser lit les contraintes et les créneaux occupés d'un utilisateur.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func loadUser(c *gin.Context, logPrefix string, userID int, from, to time.Time) (Schedule, []availability.Interval, bool) {
+	schedule, err := LoadSchedule(common.DB, userID, from, to)
+	var busy []availability.Interval
+	if err == nil {
+		busy, err = busyEvents(common.DB, userID, from, to, 0)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrUserNotFound,
+		})
+		return Schedule{}, nil, false
+	}
+	if err != nil {
+		slog.Error(logPrefix + " - erreur lors de la récupération des disponibilités : " + err.Error())
+		c.JSON(http.StatusInternalServerError, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrFreeBusyRetrieval,
+		})
+		return Schedule{}, nil, false
+	}
+	return schedule, busy, true
+}
+
+// busyEvents retourne les événements non annulés qui chevauchent la période et occupent l'utilisateur : ceux de ses
+// calendriers, hors calendriers de disponibilité des ressources, et ceux dont il a accepté l'invitation.
+// L'événement excludeEventID (0 pour aucun) n'est pas compté.
+func busyEvents(db queryer, userID int, from, to time.Time, excludeEventID int) ([]availability.Interval, error) {
+	rows, err := db.Query(`
+		SELECT e.event_id, e.start, e.duration
+		FROM event e
+		WHERE e.deleted_at IS NULL AND e.canceled = FALSE AND e.event_id <> ?
+		AND e.start < ? AND DATE_ADD(e.start, INTERVAL e.duration MINUTE) > ?
+		AND (e.event_id IN (
+			SELECT ce.event_id
+			FROM user_calendar uc
+			INNER JOIN calendar c ON c.calendar_id = uc.calendar_id AND c.deleted_at IS NULL
+			INNER JOIN calendar_event ce ON ce.calendar_id = uc.calendar_id AND ce.deleted_at IS NULL
+			WHERE uc.user_id = ? AND uc.deleted_at IS NULL
+			AND c.calendar_id NOT IN (SELECT calendar_id FROM resource)
+		) OR e.event_id IN (
+			SELECT event_id FROM event_invitation
+			WHERE user_id = ? AND status = 'accepted' AND deleted_at IS NULL
+		))
+	`, excludeEventID, to, from, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var busy []availability.Interval
+	for rows.Next() {
+		var eventID, duration int
+		var start time.Time
+		if err := rows.Scan(&eventID, &start, &duration); err != nil {
+			return nil, err
+		}
+		busy = append(busy, availability.Interval{Start: start, End: start.Add(time.Duration(duration) * time.Minute)})
+	}
+	return availability.Merge(busy), rows.Err()
+}
+
+// parseQuery lit les paramètres user_ids, start et end communs à FreeBusy et FindSlots.
+// En cas d'échec, il envoie une réponse d'erreur et retourne false.
+func parseQuery(c *gin.Context) ([]int, time.Time, time.Time, bool) {
+	start, errStart := parseDate(c.Query("start"), false)
+	end, errEnd := parseDate(c.Query("end"), true)
+	if errStart != nil || errEnd != nil || !start.Before(end) || end.Sub(start) > maxFreeBusyDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, common.JSONResponse{
+			Success: false,
+			Error:   common.ErrInvalidFreeBusyRange,
+		})
+		return nil, time.Time{}, time.Time{}, false
+	}
+
+	var userIDs []int
+	seen := map[int]bool{}
+	if value := c.Query("user_ids"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			userID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || userID < 1 {
+				userIDs = nil
+				break
+			}
+			if !seen[userID] {
+				seen[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+		if len(userIDs) == 0 || len(userIDs) > maxFreeBusyUsers {
+			c.JSON(http.StatusBadRequest, common.JSONResponse{
+				Success: false,
+				Error:   common.ErrInvalidFreeBusyUsers,
+			})
+			return nil, time.Time{}, time.Time{}, false
+		}
+	} else {
+		userData, ok := common.GetUserFromContext(c)
+		if !ok {
+			return nil, time.Time{}, time.Time{}, false
+		}
+		userIDs = []int{userData.UserID}
+	}
+	return userIDs, start, end, true
+}
+
+// toRanges convertit des intervalles de calcul en intervalles de l'API, exprimés dans le fuseau donné
+func toRanges(intervals []availability.Interval, loc *time.Location) []common.TimeRange {
+	ranges := []common.TimeRange{}
+	for _, interval := range intervals {
+		ranges = append(ranges, common.TimeRange{Start: interval.Start.In(loc), End: interval.End.In(loc)})
+	}
+	return ranges
+}
+
+// parseDate accepte une date au format YYYY-MM-DD ou RFC3339.
+// Pour une borne de fin au format jour, le jour est inclus (on retourne le lendemain à minuit).
+func parseDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
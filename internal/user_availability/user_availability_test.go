@@ -0,0 +1,238 @@
+package user_availability_test
+
+import (
+	"encoding/json"
+	"go-averroes/internal/common"
+	"go-averroes/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+var testGinRouter *gin.Engine // Routeur de test global
+var testServer *httptest.Server
+var testClient *http.Client
+
+// TestMain configure l'environnement de test global
+func TestMain(m *testing.M) {
+	if err := testutils.SetupTestEnvironment(); err != nil {
+		panic("Impossible d'initialiser l'environnement de test: " + err.Error())
+	}
+	testGinRouter = testutils.CreateTestRouter()
+	testServer = httptest.NewServer(testGinRouter)
+	testClient = testServer.Client()
+	code := m.Run()
+	if err := testutils.TeardownTestEnvironment(); err != nil {
+		panic("Impossible de nettoyer l'environnement de test: " + err.Error())
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
+// nextMonday retourne le premier lundi à minuit UTC situé au moins trois jours après maintenant
+func nextMonday() time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 3)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// setWorkingHours définit une plage de travail unique le lundi, en UTC
+func setWorkingHours(t *testing.T, user *testutils.AuthenticatedUser, startTime, endTime string) {
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "PUT", "/user/me/working-hours", map[string]interface{}{
+		"timezone": "UTC",
+		"rules":    []map[string]interface{}{{"weekday": 1, "start_time": startTime, "end_time": endTime}},
+	})
+	require.Equal(t, http.StatusOK, status, response.Error)
+}
+
+// ranges retourne les intervalles au format "HH:MM-HH:MM" UTC
+func ranges(values []common.TimeRange) []string {
+	result := []string{}
+	for _, value := range values {
+		result = append(result, value.Start.UTC().Format("15:04")+"-"+value.End.UTC().Format("15:04"))
+	}
+	return result
+}
+
+// TestUpdateWorkingHoursRoute teste la mise à jour des heures de travail avec plusieurs cas
+func TestUpdateWorkingHoursRoute(t *testing.T) {
+	// TestCases contient les cas qui seront testés
+	var TestCases = []struct {
+		CaseName         string
+		RequestData      map[string]interface{}
+		ExpectedHttpCode int
+		ExpectedError    string
+		ExpectedRules    int
+	}{
+		{
+			CaseName: "Mise à jour réussie avec deux plages",
+			RequestData: map[string]interface{}{
+				"timezone": "Europe/Paris",
+				"rules": []map[string]interface{}{
+					{"weekday": 1, "start_time": "09:00", "end_time": "12:00"},
+					{"weekday": 1, "start_time": "14:00", "end_time": "18:00"},
+				},
+			},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedRules:    2,
+		},
+		{
+			CaseName:         "Mise à jour réussie sans plage",
+			RequestData:      map[string]interface{}{"timezone": "UTC", "rules": []map[string]interface{}{}},
+			ExpectedHttpCode: http.StatusOK,
+			ExpectedRules:    0,
+		},
+		{
+			CaseName:         "Échec avec un fuseau horaire inconnu",
+			RequestData:      map[string]interface{}{"timezone": "Mars/Olympus", "rules": []map[string]interface{}{}},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidTimezone,
+		},
+		{
+			CaseName: "Échec avec une plage qui se termine avant son début",
+			RequestData: map[string]interface{}{
+				"timezone": "UTC",
+				"rules":    []map[string]interface{}{{"weekday": 2, "start_time": "17:00", "end_time": "09:00"}},
+			},
+			ExpectedHttpCode: http.StatusBadRequest,
+			ExpectedError:    common.ErrInvalidBookingRules,
+		},
+	}
+
+	for _, testCase := range TestCases {
+		t.Run(testCase.CaseName, func(t *testing.T) {
+			user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+			require.NoError(t, err)
+
+			status, response := testutils.DoRequest(t, testServer, user.SessionToken, "PUT", "/user/me/working-hours", testCase.RequestData)
+			require.Equal(t, testCase.ExpectedHttpCode, status, response.Error)
+			if testCase.ExpectedError != "" {
+				require.Equal(t, testCase.ExpectedError, response.Error)
+				return
+			}
+
+			status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/user/me/working-hours", nil)
+			require.Equal(t, http.StatusOK, status)
+			var workingHours common.WorkingHours
+			require.NoError(t, json.Unmarshal(response.Data, &workingHours))
+			require.Equal(t, testCase.RequestData["timezone"], workingHours.Timezone)
+			require.Len(t, workingHours.Rules, testCase.ExpectedRules)
+		})
+	}
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestOutOfOfficeRoutes vérifie l'enregistrement, la liste et la suppression des absences
+func TestOutOfOfficeRoutes(t *testing.T) {
+	user, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	other, err := testutils.GenerateAuthenticatedUser(true, true, false, false)
+	require.NoError(t, err)
+	monday := nextMonday()
+
+	// Une absence qui se termine avant son début est refusée
+	status, response := testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": monday.Add(48 * time.Hour).Format(time.RFC3339), "end": monday.Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidOutOfOfficeRange, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": monday.Format(time.RFC3339), "end": monday.Add(48 * time.Hour).Format(time.RFC3339), "message": "Congés",
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	var created struct {
+		OutOfOfficeID int `json:"out_of_office_id"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &created))
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "GET", "/user/me/out-of-office", nil)
+	require.Equal(t, http.StatusOK, status)
+	var periods []common.OutOfOffice
+	require.NoError(t, json.Unmarshal(response.Data, &periods))
+	require.Len(t, periods, 1)
+	require.Equal(t, "Congés", *periods[0].Message)
+
+	// Un autre utilisateur ne peut pas supprimer l'absence
+	path := "/user/me/out-of-office/" + strconv.Itoa(created.OutOfOfficeID)
+	status, response = testutils.DoRequest(t, testServer, other.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrOutOfOfficeNotFound, response.Error)
+
+	status, response = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	status, _ = testutils.DoRequest(t, testServer, user.SessionToken, "DELETE", path, nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	testutils.PurgeAllTestUsers()
+}
+
+// TestFreeBusyAndFindSlots vérifie les disponibilités de deux utilisateurs et la recherche de créneaux communs
+func TestFreeBusyAndFindSlots(t *testing.T) {
+	alice, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	bob, err := testutils.GenerateAuthenticatedUser(true, true, true, false)
+	require.NoError(t, err)
+	monday := nextMonday()
+	at := func(hour, minute int) time.Time {
+		return monday.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	day := monday.Format("2006-01-02")
+	users := strconv.Itoa(alice.User.UserID) + "," + strconv.Itoa(bob.User.UserID)
+
+	// Alice travaille de 09:00 à 12:00, Bob de 10:00 à 17:00 avec une réunion de 10:30 à 11:00
+	setWorkingHours(t, alice, "09:00", "12:00")
+	setWorkingHours(t, bob, "10:00", "17:00")
+	status, response := testutils.DoRequest(t, testServer, bob.SessionToken, "POST", "/calendar-event/"+strconv.Itoa(bob.Calendar.CalendarID), map[string]interface{}{
+		"title": "Réunion", "start": at(10, 30).Format(time.RFC3339), "duration": 30, "calendar_id": bob.Calendar.CalendarID,
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+
+	findSlots := func(duration int) []string {
+		status, response := testutils.DoRequest(t, testServer, alice.SessionToken, "GET", "/availability/slots?user_ids="+users+"&start="+day+"&end="+day+"&duration="+strconv.Itoa(duration), nil)
+		require.Equal(t, http.StatusOK, status, response.Error)
+		var data common.FindSlotsResponse
+		require.NoError(t, json.Unmarshal(response.Data, &data))
+		return ranges(data.Slots)
+	}
+	require.Equal(t, []string{"10:00-10:30", "11:00-12:00"}, findSlots(30))
+	require.Equal(t, []string{"11:00-12:00"}, findSlots(45))
+
+	// L'absence de Bob à partir de 11:30 réduit les créneaux communs
+	status, response = testutils.DoRequest(t, testServer, bob.SessionToken, "POST", "/user/me/out-of-office", map[string]interface{}{
+		"start": at(11, 30).Format(time.RFC3339), "end": at(13, 0).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, status, response.Error)
+	require.Equal(t, []string{"10:00-10:30", "11:00-11:30"}, findSlots(30))
+
+	// Les disponibilités de Bob n'exposent pas le titre de la réunion
+	status, response = testutils.DoRequest(t, testServer, alice.SessionToken, "GET", "/availability/free-busy?user_ids="+strconv.Itoa(bob.User.UserID)+"&start="+day+"&end="+day, nil)
+	require.Equal(t, http.StatusOK, status, response.Error)
+	require.NotContains(t, string(response.Data), "Réunion")
+	var freeBusy common.FreeBusyResponse
+	require.NoError(t, json.Unmarshal(response.Data, &freeBusy))
+	require.Len(t, freeBusy.Users, 1)
+	require.Equal(t, []string{"10:00-17:00"}, ranges(freeBusy.Users[0].WorkingHours))
+	require.Equal(t, []string{"10:30-11:00"}, ranges(freeBusy.Users[0].Busy))
+	require.Equal(t, []string{"11:30-13:00"}, ranges(freeBusy.Users[0].OutOfOffice))
+
+	// Utilisateur inconnu et période trop longue
+	status, response = testutils.DoRequest(t, testServer, alice.SessionToken, "GET", "/availability/free-busy?user_ids=999999999&start="+day+"&end="+day, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, common.ErrUserNotFound, response.Error)
+	status, response = testutils.DoRequest(t, testServer, alice.SessionToken, "GET", "/availability/free-busy?start="+day+"&end="+monday.AddDate(0, 0, 40).Format("2006-01-02"), nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, common.ErrInvalidFreeBusyRange, response.Error)
+
+	testutils.PurgeAllTestUsers()
+}
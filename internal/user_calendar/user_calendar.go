@@ -337,7 +337,7 @@ func (UserCalendarStruct) List(c *gin.Context) {
 
 	rows, err := common.DB.Query(`
 		SELECT uc.user_calendar_id, uc.user_id, uc.calendar_id, uc.created_at, uc.updated_at, uc.deleted_at,
-		       c.title, c.description
+		       c.title, c.description, c.color
 		FROM user_calendar uc
 		INNER JOIN calendar c ON uc.calendar_id = c.calendar_id
 		WHERE uc.user_id = ? AND uc.deleted_at IS NULL AND c.deleted_at IS NULL
@@ -365,6 +365,7 @@ func (UserCalendarStruct) List(c *gin.Context) {
 			&userCalendar.DeletedAt,
 			&userCalendar.Title,
 			&userCalendar.Description,
+			&userCalendar.Color,
 		)
 		if err != nil {
 			slog.Error(common.LogUserCalendarList + " - erreur lors de la lecture des données : " + err.Error())
@@ -396,7 +397,7 @@ func (UserCalendarStruct) GetByUser(c *gin.Context) {
 
 	rows, err := common.DB.Query(`
 		SELECT uc.user_calendar_id, uc.user_id, uc.calendar_id, uc.created_at, uc.updated_at, uc.deleted_at,
-		       c.title, c.description
+		       c.title, c.description, c.color
 		FROM user_calendar uc
 		INNER JOIN calendar c ON uc.calendar_id = c.calendar_id
 		WHERE uc.user_id = ? AND uc.deleted_at IS NULL AND c.deleted_at IS NULL
@@ -424,6 +425,7 @@ func (UserCalendarStruct) GetByUser(c *gin.Context) {
 			&userCalendar.DeletedAt,
 			&userCalendar.Title,
 			&userCalendar.Description,
+			&userCalendar.Color,
 		)
 		if err != nil {
 			slog.Error(common.LogUserCalendarList + " - erreur lors de la lecture des données : " + err.Error())
@@ -462,7 +464,7 @@ func (UserCalendarStruct) ListMine(c *gin.Context) {
 
 	rows, err := common.DB.Query(`
 		SELECT uc.user_calendar_id, uc.user_id, uc.calendar_id, uc.created_at, uc.updated_at, uc.deleted_at,
-		       c.title, c.description
+		       c.title, c.description, c.color
 		FROM user_calendar uc
 		INNER JOIN calendar c ON uc.calendar_id = c.calendar_id
 		WHERE uc.user_id = ? AND uc.deleted_at IS NULL AND c.deleted_at IS NULL
@@ -490,6 +492,7 @@ func (UserCalendarStruct) ListMine(c *gin.Context) {
 			&userCalendar.DeletedAt,
 			&userCalendar.Title,
 			&userCalendar.Description,
+			&userCalendar.Color,
 		)
 		if err != nil {
 			slog.Error(common.LogUserCalendarList + " - erreur lors de la lecture des données : " + err.Error())
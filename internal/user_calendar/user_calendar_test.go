@@ -248,8 +248,8 @@ func TestGetUserCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible avec calendrier
@@ -649,8 +649,8 @@ func TestGetUserCalendarListRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible avec calendrier
@@ -1305,8 +1305,8 @@ func TestPostUserCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible sans calendrier
@@ -1748,8 +1748,8 @@ func TestPutUserCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible avec calendrier
@@ -2198,8 +2198,8 @@ func TestDeleteUserCalendarRoute(t *testing.T) {
 				_, err = common.DB.Exec(`
 					UPDATE user_session 
 					SET is_active = FALSE 
-					WHERE session_token = ?
-				`, admin.SessionToken)
+					WHERE session_token_hash = ?
+				`, common.HashToken(admin.SessionToken))
 				require.NoError(t, err)
 
 				// Créer un utilisateur cible avec calendrier
@@ -0,0 +1,132 @@
+// Package webauthn internal/webauthn/cbor.go
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// maxCBORDepth limite l'imbrication acceptée, les structures WebAuthn n'en dépassent pas trois niveaux
+const maxCBORDepth = 8
+
+// errCBOR est retournée pour un encodage CBOR invalide ou non pris en charge
+var errCBOR = errors.New("encodage CBOR invalide")
+
+// decodeCBOR décode le premier élément CBOR (RFC 8949) de data et retourne les octets restants.
+// Seul le sous-ensemble utilisé par WebAuthn est pris en charge : entiers, chaînes d'octets et de texte,
+// tableaux, maps (clés entières ou texte), booléens et null, tous de longueur définie.
+// Les entiers sont retournés en int64, les chaînes d'octets en []byte, les maps en map[interface{}]interface{}.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+// decodeCBORItem décode un élément à la profondeur depth
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCBORDepth || len(data) == 0 {
+		return nil, nil, errCBOR
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		}
+		return nil, nil, errCBOR
+	}
+
+	length, data, err := readCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if length > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return int64(length), data, nil
+	case 1:
+		if length > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return -1 - int64(length), data, nil
+	case 2, 3:
+		if length > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		value := data[:length]
+		if major == 3 {
+			return string(value), data[length:], nil
+		}
+		return append([]byte(nil), value...), data[length:], nil
+	case 4:
+		// Chaque élément occupe au moins un octet
+		if length > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		items := make([]interface{}, 0, length)
+		for i := uint64(0); i < length; i++ {
+			var item interface{}
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if length > uint64(len(data))/2 {
+			return nil, nil, errCBOR
+		}
+		entries := make(map[interface{}]interface{}, length)
+		for i := uint64(0); i < length; i++ {
+			var key, value interface{}
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBOR
+			}
+			if _, exists := entries[key]; exists {
+				return nil, nil, errCBOR
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+		}
+		return entries, data, nil
+	}
+	// Étiquettes (6) : absentes des structures WebAuthn
+	return nil, nil, errCBOR
+}
+
+// readCBORArgument lit l'argument (valeur ou longueur) codé par les 5 bits de poids faible de l'octet initial
+func readCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	// Longueurs indéfinies (31) et valeurs réservées
+	return 0, nil, errCBOR
+}
@@ -0,0 +1,112 @@
+// Package webauthn internal/webauthn/cose.go
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// Algorithmes COSE (RFC 9053) acceptés, par ordre de préférence
+const (
+	AlgES256 = -7   // ECDSA P-256 avec SHA-256
+	AlgEdDSA = -8   // Ed25519
+	AlgRS256 = -257 // RSASSA-PKCS1-v1_5 avec SHA-256
+)
+
+// Algorithms est la liste transmise au navigateur dans pubKeyCredParams
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// Paramètres des clés COSE utilisés
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseCrv    = -1 // OKP et EC2
+	coseX      = -2 // OKP et EC2
+	coseY      = -3 // EC2
+	coseRSAN   = -1
+	coseRSAE   = -2
+	ktyOKP     = 1
+	ktyEC2     = 2
+	ktyRSA     = 3
+	crvP256    = 1
+	crvEd25519 = 6
+	// minRSABits est la taille minimale acceptée pour les clés RSA
+	minRSABits = 2048
+)
+
+// errUnsupportedKey est retournée pour une clé COSE invalide ou d'un algorithme non accepté
+var errUnsupportedKey = errors.New("clé publique COSE invalide ou algorithme non pris en charge")
+
+// parsePublicKey décode une clé publique COSE : ES256, EdDSA (Ed25519) ou RS256
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := item.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, errUnsupportedKey
+	}
+	kty, _ := key[int64(coseKty)].(int64)
+	alg, _ := key[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := key[int64(coseCrv)].(int64)
+		x, _ := key[int64(coseX)].([]byte)
+		y, _ := key[int64(coseY)].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errUnsupportedKey
+		}
+		// La validation crypto/ecdh rejette les points hors de la courbe
+		point := append(append([]byte{0x04}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, errUnsupportedKey
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := key[int64(coseCrv)].(int64)
+		x, _ := key[int64(coseX)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := key[int64(coseRSAN)].([]byte)
+		e, _ := key[int64(coseRSAE)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, errUnsupportedKey
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSABits || exponent < 3 || exponent%2 == 0 {
+			return nil, errUnsupportedKey
+		}
+		return &rsa.PublicKey{N: modulus, E: exponent}, nil
+	}
+	return nil, errUnsupportedKey
+}
+
+// verifySignature vérifie la signature de data par la clé publique
+func verifySignature(key crypto.PublicKey, data, signature []byte) bool {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+// Package webauthn internal/webauthn/webauthn.go
+// Vérification des cérémonies WebAuthn (Level 2) d'enregistrement et d'authentification par passkey.
+// Les attestations ne sont pas vérifiées (conveyance "none") : l'origine des authentificateurs n'est pas contrôlée.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Timeout est le délai laissé à l'utilisateur pour une cérémonie, transmis au navigateur
+	Timeout = 5 * time.Minute
+	// challengeSize est la taille des challenges, en octets (16 au minimum selon la spécification)
+	challengeSize = 32
+	// MaxCredentialIDLength est la taille maximale d'un identifiant de credential, en octets
+	MaxCredentialIDLength = 1023
+
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredential = 0x40
+	flagExtensions         = 0x80
+
+	// Types de clientDataJSON
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+)
+
+// Encoding est l'encodage base64url sans remplissage des données binaires échangées avec le navigateur
+var Encoding = base64.RawURLEncoding
+
+// ErrInvalidResponse est retournée pour une réponse d'authentificateur invalide ou ne correspondant pas à la cérémonie
+var ErrInvalidResponse = errors.New("réponse WebAuthn invalide")
+
+// ErrSignCount est retournée quand le compteur de signatures n'augmente pas : l'authentificateur a peut-être été cloné
+var ErrSignCount = errors.New("compteur de signatures en recul, authentificateur possiblement cloné")
+
+// RelyingParty est la partie de confiance à laquelle les passkeys sont rattachées
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// Default est la partie de confiance de l'application, initialisée par Init
+var Default *RelyingParty
+
+// Init valide la configuration et initialise la partie de confiance par défaut
+func Init(cfg common.WebAuthnConfig) error {
+	if cfg.RPID == "" || strings.ContainsAny(cfg.RPID, ":/") {
+		return fmt.Errorf("WEBAUTHN_RP_ID doit être un nom de domaine : %q", cfg.RPID)
+	}
+	if len(cfg.Origins) == 0 {
+		return errors.New("WEBAUTHN_ORIGINS est vide")
+	}
+	for _, origin := range cfg.Origins {
+		parsed, err := url.Parse(origin)
+		if err != nil {
+			return fmt.Errorf("origine invalide %q : %v", origin, err)
+		}
+		host := parsed.Hostname()
+		if parsed.Scheme != "https" && !(parsed.Scheme == "http" && host == "localhost") {
+			return fmt.Errorf("origine %q : https est obligatoire hors de localhost", origin)
+		}
+		if host != cfg.RPID && !strings.HasSuffix(host, "."+cfg.RPID) {
+			return fmt.Errorf("origine %q hors du domaine %q", origin, cfg.RPID)
+		}
+	}
+	Default = &RelyingParty{ID: cfg.RPID, Name: cfg.RPName, Origins: cfg.Origins}
+	return nil
+}
+
+// NewChallenge génère un challenge aléatoire, encodé en base64url
+func NewChallenge() (string, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	return Encoding.EncodeToString(challenge), nil
+}
+
+// CredentialDescriptor désigne une passkey, identifiant en base64url
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// CredentialParameter est un algorithme de clé accepté
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// RelyingPartyEntity identifie la partie de confiance auprès de l'authentificateur
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifie le compte auquel la passkey est rattachée, identifiant en base64url
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// AuthenticatorSelection décrit les exigences envers l'authentificateur
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions sont les options de navigator.credentials.create(), champs binaires en base64url
+// (format de PublicKeyCredential.parseCreationOptionsFromJSON())
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions sont les options de navigator.credentials.get(), champs binaires en base64url
+// (format de PublicKeyCredential.parseRequestOptionsFromJSON())
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions construit les options d'enregistrement d'une passkey découvrable, avec vérification de
+// l'utilisateur. Les passkeys exclude, déjà enregistrées, ne peuvent pas être enregistrées une seconde fois.
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name, displayName string, exclude [][]byte) CreationOptions {
+	params := make([]CredentialParameter, 0, len(Algorithms))
+	for _, alg := range Algorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	return CreationOptions{
+		Challenge:          challenge,
+		RP:                 RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User:               UserEntity{ID: Encoding.EncodeToString(userHandle), Name: name, DisplayName: displayName},
+		PubKeyCredParams:   params,
+		Timeout:            Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions construit les options de connexion. La liste des passkeys autorisées est vide :
+// l'authentificateur propose les passkeys découvrables du domaine, sans que l'adresse e-mail soit demandée.
+func (rp *RelyingParty) RequestOptions(challenge string) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          Timeout.Milliseconds(),
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: "required",
+	}
+}
+
+// descriptors convertit des identifiants de passkeys en descripteurs
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	result := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, CredentialDescriptor{Type: "public-key", ID: Encoding.EncodeToString(id)})
+	}
+	return result
+}
+
+// clientData est le contenu de clientDataJSON
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge retourne le challenge signé dans clientDataJSON, pour retrouver la cérémonie correspondante
+func Challenge(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidResponse
+	}
+	return data.Challenge, nil
+}
+
+// Credential est une passkey enregistrée
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // Clé publique COSE, telle que fournie par l'authentificateur
+	SignCount uint32
+}
+
+// authenticatorData est le contenu décodé de authenticatorData
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// VerifyRegistration vérifie la réponse à navigator.credentials.create() et retourne la passkey à enregistrer
+func (rp *RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, typeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	item, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w : attestationObject illisible", ErrInvalidResponse)
+	}
+	attestation, _ := item.(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if _, ok := attestation["fmt"].(string); !ok || rawAuthData == nil {
+		return nil, fmt.Errorf("%w : attestationObject incomplet", ErrInvalidResponse)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.Flags&flagAttestedCredential == 0 {
+		return nil, fmt.Errorf("%w : aucune clé publique fournie", ErrInvalidResponse)
+	}
+	if _, err := parsePublicKey(authData.PublicKey); err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidResponse, err)
+	}
+	return &Credential{
+		ID:        authData.CredentialID,
+		PublicKey: authData.PublicKey,
+		SignCount: authData.SignCount,
+	}, nil
+}
+
+// VerifyAssertion vérifie la réponse à navigator.credentials.get() pour la passkey enregistrée
+// et retourne la nouvelle valeur du compteur de signatures, à conserver
+func (rp *RelyingParty) VerifyAssertion(challenge string, credential Credential, clientDataJSON, rawAuthData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, typeGet, challenge); err != nil {
+		return 0, err
+	}
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+	if !verifySignature(key, signed, signature) {
+		return 0, fmt.Errorf("%w : signature incorrecte", ErrInvalidResponse)
+	}
+
+	// Un authentificateur sans compteur renvoie toujours 0 ; sinon le compteur doit augmenter
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return 0, ErrSignCount
+	}
+	return authData.SignCount, nil
+}
+
+// verifyClientData vérifie le type de cérémonie, le challenge et l'origine de clientDataJSON
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("%w : clientDataJSON illisible", ErrInvalidResponse)
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("%w : type de cérémonie %q", ErrInvalidResponse, data.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w : challenge inattendu", ErrInvalidResponse)
+	}
+	if data.CrossOrigin {
+		return fmt.Errorf("%w : appel depuis une iframe d'une autre origine", ErrInvalidResponse)
+	}
+	for _, origin := range rp.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w : origine %q non autorisée", ErrInvalidResponse, data.Origin)
+}
+
+// verifyAuthenticatorData décode authenticatorData et vérifie le domaine ainsi que la présence
+// et la vérification de l'utilisateur (code, biométrie), qui font de la passkey un facteur suffisant
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w : authenticatorData trop court", ErrInvalidResponse)
+	}
+	data := &authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data.RPIDHash, rpIDHash[:]) {
+		return nil, fmt.Errorf("%w : domaine inattendu", ErrInvalidResponse)
+	}
+	if data.Flags&flagUserPresent == 0 || data.Flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w : utilisateur non vérifié", ErrInvalidResponse)
+	}
+
+	rest := raw[37:]
+	if data.Flags&flagAttestedCredential != 0 {
+		// AAGUID (16 octets), longueur de l'identifiant (2 octets), identifiant, clé publique COSE
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w : données de credential tronquées", ErrInvalidResponse)
+		}
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if length == 0 || length > MaxCredentialIDLength || length > len(rest) {
+			return nil, fmt.Errorf("%w : identifiant de credential invalide", ErrInvalidResponse)
+		}
+		data.CredentialID = append([]byte(nil), rest[:length]...)
+		rest = rest[length:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w : clé publique illisible", ErrInvalidResponse)
+		}
+		data.PublicKey = append([]byte(nil), rest[:len(rest)-len(after)]...)
+		rest = after
+	}
+	if data.Flags&flagExtensions != 0 {
+		var err error
+		if _, rest, err = decodeCBOR(rest); err != nil {
+			return nil, fmt.Errorf("%w : extensions illisibles", ErrInvalidResponse)
+		}
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w : octets inattendus dans authenticatorData", ErrInvalidResponse)
+	}
+	return data, nil
+}
@@ -0,0 +1,149 @@
+package webauthn_test
+
+import (
+	"go-averroes/internal/common"
+	"go-averroes/internal/webauthn"
+	"go-averroes/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// relyingParty est la partie de confiance utilisée par les tests
+var relyingParty = &webauthn.RelyingParty{ID: "localhost", Name: "GoLendar", Origins: []string{"http://localhost:3000"}}
+
+// decode décode une valeur base64url produite par l'authentificateur logiciel
+func decode(t *testing.T, value string) []byte {
+	data, err := webauthn.Encoding.DecodeString(value)
+	require.NoError(t, err)
+	return data
+}
+
+// register enregistre la passkey de l'authentificateur et retourne le credential vérifié
+func register(t *testing.T, authenticator *testutils.SoftAuthenticator) webauthn.Credential {
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	clientDataJSON, attestationObject := authenticator.Register(challenge)
+	credential, err := relyingParty.VerifyRegistration(challenge, decode(t, clientDataJSON), decode(t, attestationObject))
+	require.NoError(t, err)
+	return *credential
+}
+
+// assert signe un challenge et vérifie l'assertion obtenue
+func assert(t *testing.T, authenticator *testutils.SoftAuthenticator, credential webauthn.Credential, challenge string) (uint32, error) {
+	clientDataJSON, authenticatorData, signature, err := authenticator.Assert(challenge)
+	require.NoError(t, err)
+	return relyingParty.VerifyAssertion(challenge, credential, decode(t, clientDataJSON), decode(t, authenticatorData), decode(t, signature))
+}
+
+// TestRegistrationAndAssertion vérifie les deux cérémonies avec un authentificateur logiciel
+func TestRegistrationAndAssertion(t *testing.T) {
+	authenticator, err := testutils.NewSoftAuthenticator("localhost", "http://localhost:3000")
+	require.NoError(t, err)
+
+	credential := register(t, authenticator)
+	require.Equal(t, authenticator.CredentialID(), webauthn.Encoding.EncodeToString(credential.ID))
+	require.Equal(t, uint32(1), credential.SignCount)
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	signCount, err := assert(t, authenticator, credential, challenge)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), signCount)
+
+	// Compteur qui n'augmente pas : authentificateur cloné
+	credential.SignCount = 5
+	_, err = assert(t, authenticator, credential, challenge)
+	require.ErrorIs(t, err, webauthn.ErrSignCount)
+
+	// Authentificateur sans compteur (toujours 0) : accepté
+	credential.SignCount = 0
+	authenticator.SignCount = ^uint32(0)
+	signCount, err = assert(t, authenticator, credential, challenge)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), signCount)
+}
+
+// TestAssertionRejected vérifie le refus des réponses ne correspondant pas à la cérémonie
+func TestAssertionRejected(t *testing.T) {
+	authenticator, err := testutils.NewSoftAuthenticator("localhost", "http://localhost:3000")
+	require.NoError(t, err)
+	credential := register(t, authenticator)
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	// Challenge différent de celui émis
+	other, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	clientDataJSON, authenticatorData, signature, err := authenticator.Assert(other)
+	require.NoError(t, err)
+	_, err = relyingParty.VerifyAssertion(challenge, credential, decode(t, clientDataJSON), decode(t, authenticatorData), decode(t, signature))
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+
+	// Signature altérée
+	clientDataJSON, authenticatorData, signature, err = authenticator.Assert(challenge)
+	require.NoError(t, err)
+	tampered := decode(t, signature)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = relyingParty.VerifyAssertion(challenge, credential, decode(t, clientDataJSON), decode(t, authenticatorData), tampered)
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+
+	// Réponse d'enregistrement présentée comme assertion
+	clientDataJSON, attestationObject := authenticator.Register(challenge)
+	_, err = relyingParty.VerifyAssertion(challenge, credential, decode(t, clientDataJSON), decode(t, attestationObject), decode(t, signature))
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+
+	// Utilisateur présent mais non vérifié (ni code ni biométrie)
+	authenticator.Flags = testutils.WebAuthnFlagUserPresent
+	_, err = assert(t, authenticator, credential, challenge)
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	authenticator.Flags |= testutils.WebAuthnFlagUserVerified
+
+	// Même passkey utilisée depuis une autre origine ou pour un autre domaine
+	authenticator.Origin = "http://localhost:4000"
+	_, err = assert(t, authenticator, credential, challenge)
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	authenticator.Origin = "http://localhost:3000"
+
+	authenticator.RPID = "example.com"
+	_, err = assert(t, authenticator, credential, challenge)
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	clientDataJSON, attestationObject = authenticator.Register(challenge)
+	_, err = relyingParty.VerifyRegistration(challenge, decode(t, clientDataJSON), decode(t, attestationObject))
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	authenticator.RPID = "localhost"
+
+	_, err = assert(t, authenticator, credential, challenge)
+	require.NoError(t, err)
+}
+
+// TestRegistrationMalformed vérifie le refus des attestationObject tronqués
+func TestRegistrationMalformed(t *testing.T) {
+	authenticator, err := testutils.NewSoftAuthenticator("localhost", "http://localhost:3000")
+	require.NoError(t, err)
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	clientDataJSON, attestationObject := authenticator.Register(challenge)
+
+	raw := decode(t, attestationObject)
+	for _, size := range []int{0, 1, 10, len(raw) / 2, len(raw) - 1} {
+		_, err = relyingParty.VerifyRegistration(challenge, decode(t, clientDataJSON), raw[:size])
+		require.ErrorIs(t, err, webauthn.ErrInvalidResponse, "taille %d", size)
+	}
+	_, err = relyingParty.VerifyRegistration(challenge, decode(t, clientDataJSON), append(raw, 0))
+	require.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+
+	found, err := webauthn.Challenge(decode(t, clientDataJSON))
+	require.NoError(t, err)
+	require.Equal(t, challenge, found)
+}
+
+// TestInit vérifie la validation de la configuration
+func TestInit(t *testing.T) {
+	require.NoError(t, webauthn.Init(common.WebAuthnConfig{RPID: "example.com", Origins: []string{"https://app.example.com"}}))
+	require.NoError(t, webauthn.Init(common.WebAuthnConfig{RPID: "localhost", Origins: []string{"http://localhost:3000"}}))
+	require.Error(t, webauthn.Init(common.WebAuthnConfig{RPID: "example.com", Origins: []string{"http://app.example.com"}}))
+	require.Error(t, webauthn.Init(common.WebAuthnConfig{RPID: "example.com", Origins: []string{"https://example.org"}}))
+	require.Error(t, webauthn.Init(common.WebAuthnConfig{RPID: "https://example.com", Origins: []string{"https://example.com"}}))
+	require.Error(t, webauthn.Init(common.WebAuthnConfig{RPID: "example.com"}))
+}
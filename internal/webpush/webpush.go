@@ -0,0 +1,348 @@
+// Package webpush internal/webpush/webpush.go
+// Envoi de notifications Web Push : chiffrement du contenu (RFC 8291, aes128gcm) et authentification VAPID (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-averroes/internal/common"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// recordSize est la taille d'enregistrement annoncée dans l'en-tête aes128gcm (un seul enregistrement)
+	recordSize = 4096
+	// MaxPayloadSize est la taille maximale du contenu en clair : 4096 octets chiffrés au plus,
+	// moins l'en-tête (86 octets), le délimiteur (1 octet) et l'étiquette GCM (16 octets)
+	MaxPayloadSize = 4096 - 86 - 1 - 16
+	// vapidTokenLifetime est la durée de validité des jetons VAPID (24 heures au plus selon RFC 8292)
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+// ErrSubscriptionGone est retournée quand le service de push indique que l'abonnement a expiré (404 ou 410)
+var ErrSubscriptionGone = errors.New("abonnement push expiré ou désinscrit")
+
+// ErrPayloadTooLarge est retournée quand le contenu dépasse MaxPayloadSize
+var ErrPayloadTooLarge = errors.New("contenu de la notification trop volumineux")
+
+// ErrInvalidSubscription est retournée quand l'URL ou les clés de l'abonnement sont invalides
+var ErrInvalidSubscription = errors.New("clés d'abonnement push invalides")
+
+// ErrForbiddenAddress est retournée quand le point de terminaison désigne une adresse non publique
+var ErrForbiddenAddress = errors.New("adresse du service de push non publique")
+
+// Subscription est un abonnement push du navigateur (PushSubscription.toJSON()), clés en base64url
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Message est une notification à envoyer
+type Message struct {
+	Payload []byte
+	TTL     time.Duration // Durée de conservation par le service de push si le navigateur est hors ligne
+	Urgency string        // very-low, low, normal ou high (vide : normal)
+	Topic   string        // Une notification en attente de même sujet est remplacée
+}
+
+// Client envoie des notifications signées avec une clé VAPID
+type Client struct {
+	HTTPClient *http.Client
+	PrivateKey *ecdsa.PrivateKey
+	Subject    string // Contact de l'exploitant (mailto: ou https:)
+}
+
+// Default est le client utilisé par l'application, initialisé par Init
+var Default *Client
+
+// Init initialise le client par défaut. Sans clé privée configurée, une clé éphémère est générée :
+// les abonnements existants deviennent alors inutilisables au redémarrage.
+func Init(cfg common.WebPushConfig) error {
+	var key *ecdsa.PrivateKey
+	var err error
+	if cfg.VAPIDPrivateKey == "" {
+		slog.Warn(common.LogWebPushEphemeralKey)
+		key, err = GenerateKey()
+	} else {
+		key, err = ParsePrivateKey(cfg.VAPIDPrivateKey)
+	}
+	if err != nil {
+		return err
+	}
+	Default = &Client{
+		HTTPClient: NewHTTPClient(),
+		PrivateKey: key,
+		Subject:    cfg.VAPIDSubject,
+	}
+	return nil
+}
+
+// NewHTTPClient retourne le client HTTP d'envoi : les points de terminaison étant fournis par les utilisateurs,
+// il refuse de se connecter à une adresse non publique (y compris après résolution DNS) et ne suit pas les redirections
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Sans proxy : l'adresse contrôlée doit être celle du service de push
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicIP indique si l'adresse est joignable sur Internet : boucle locale, réseaux privés, lien local
+// et adresses non spécifiées sont exclus
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// GenerateKey génère une clé privée VAPID (P-256)
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// EncodePrivateKey encode la clé privée VAPID en base64url (scalaire brut de 32 octets)
+func EncodePrivateKey(key *ecdsa.PrivateKey) string {
+	return base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32)))
+}
+
+// ParsePrivateKey lit une clé privée VAPID encodée par EncodePrivateKey
+func ParsePrivateKey(value string) (*ecdsa.PrivateKey, error) {
+	raw, err := decodeBase64(value)
+	if err != nil {
+		return nil, fmt.Errorf("clé privée VAPID invalide : %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("clé privée VAPID invalide : %w", err)
+	}
+	public := ecdhKey.PublicKey().Bytes()
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}, nil
+}
+
+// PublicKey retourne la clé publique VAPID (point non compressé en base64url), à passer au navigateur
+// comme applicationServerKey
+func (c *Client) PublicKey() string {
+	key, err := c.PrivateKey.PublicKey.ECDH()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(key.Bytes())
+}
+
+// Send chiffre et envoie la notification à l'abonnement.
+// Retourne ErrSubscriptionGone si l'abonnement doit être supprimé.
+func (c *Client) Send(ctx context.Context, sub Subscription, msg Message) error {
+	body, err := Encrypt(sub, msg.Payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(msg.TTL.Seconds())))
+	req.Header.Set("Authorization", authorization)
+	if msg.Urgency != "" {
+		req.Header.Set("Urgency", msg.Urgency)
+	}
+	if msg.Topic != "" {
+		req.Header.Set("Topic", msg.Topic)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("service de push : statut %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateSubscription vérifie l'URL et les clés d'un abonnement. Le point de terminaison doit être en https
+// et ne pas désigner la machine elle-même ou un réseau interne (boucle locale, réseaux privés, lien local).
+func ValidateSubscription(sub Subscription) error {
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" {
+		return ErrInvalidSubscription
+	}
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidSubscription
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrInvalidSubscription
+	}
+	public, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return ErrInvalidSubscription
+	}
+	if _, err := ecdh.P256().NewPublicKey(public); err != nil {
+		return ErrInvalidSubscription
+	}
+	if auth, err := decodeBase64(sub.Auth); err != nil || len(auth) != 16 {
+		return ErrInvalidSubscription
+	}
+	return nil
+}
+
+// Encrypt chiffre le contenu pour l'abonnement selon RFC 8291 (codage aes128gcm de RFC 8188)
+func Encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(sub, payload, salt, serverKey)
+}
+
+// encrypt chiffre avec le sel et la clé éphémère du serveur donnés
+func encrypt(sub Subscription, payload, salt []byte, serverKey *ecdh.PrivateKey) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	if err := ValidateSubscription(sub); err != nil {
+		return nil, err
+	}
+	userAgentRaw, _ := decodeBase64(sub.P256dh)
+	authSecret, _ := decodeBase64(sub.Auth)
+	userAgentKey, _ := ecdh.P256().NewPublicKey(userAgentRaw)
+
+	sharedSecret, err := serverKey.ECDH(userAgentKey)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), userAgentRaw...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// Enregistrement unique : contenu suivi du délimiteur de dernier enregistrement (0x02)
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	// En-tête : sel (16) || taille d'enregistrement (4) || longueur de l'identifiant (1) || clé publique du serveur (65)
+	header := make([]byte, 0, 86)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// authorization construit l'en-tête Authorization VAPID pour l'origine du point de terminaison
+func (c *Client) authorization(endpoint string, now time.Time) (string, error) {
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"aud": target.Scheme + "://" + target.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": c.Subject,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, c.PrivateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + c.PublicKey(), nil
+}
+
+// decodeBase64 décode du base64url, avec ou sans remplissage (les navigateurs varient)
+func decodeBase64(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,232 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// b64 décode une valeur base64url des vecteurs de test
+func b64(t *testing.T, value string) []byte {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	require.NoError(t, err)
+	return decoded
+}
+
+// decrypt déchiffre un contenu aes128gcm côté navigateur, comme le ferait le service worker
+func decrypt(t *testing.T, body []byte, userAgentKey *ecdh.PrivateKey, authSecret []byte) []byte {
+	require.Greater(t, len(body), 86)
+	salt := body[:16]
+	require.Equal(t, uint32(recordSize), binary.BigEndian.Uint32(body[16:20]))
+	keyIDLength := int(body[20])
+	serverRaw := body[21 : 21+keyIDLength]
+	serverKey, err := ecdh.P256().NewPublicKey(serverRaw)
+	require.NoError(t, err)
+
+	sharedSecret, err := userAgentKey.ECDH(serverKey)
+	require.NoError(t, err)
+	keyInfo := append([]byte("WebPush: info\x00"), userAgentKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, serverRaw...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	require.NoError(t, err)
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	require.NoError(t, err)
+	contentKey, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(contentKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, body[21+keyIDLength:], nil)
+	require.NoError(t, err)
+	plaintext = []byte(strings.TrimRight(string(plaintext), "\x00"))
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1], "délimiteur de dernier enregistrement attendu")
+	return plaintext[:len(plaintext)-1]
+}
+
+// TestEncryptRFC8291Vector vérifie le chiffrement avec l'exemple de l'annexe A de RFC 8291
+func TestEncryptRFC8291Vector(t *testing.T) {
+	serverKey, err := ecdh.P256().NewPrivateKey(b64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	require.NoError(t, err)
+	sub := Subscription{
+		Endpoint: "https://push.example.net/push/JzLQ3raZJfFBR0aqvOMsLrt54w4rJUsV",
+		P256dh:   "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+		Auth:     "BTBZMqHH6r4Tts7J_aSIgg",
+	}
+
+	body, err := encrypt(sub, []byte("When I grow up, I want to be a watermelon"), b64(t, "DGv6ra1nlYgDCS1FRnbzlw"), serverKey)
+	require.NoError(t, err)
+	require.Equal(t, "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN",
+		base64.RawURLEncoding.EncodeToString(body))
+
+	// Le navigateur retrouve le contenu avec sa clé privée
+	userAgentKey, err := ecdh.P256().NewPrivateKey(b64(t, "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	require.NoError(t, err)
+	require.Equal(t, "When I grow up, I want to be a watermelon", string(decrypt(t, body, userAgentKey, b64(t, sub.Auth))))
+}
+
+// TestSendToFakePushService vérifie l'envoi vers un service de push local : en-têtes, jeton VAPID,
+// contenu déchiffrable et détection des abonnements expirés
+func TestSendToFakePushService(t *testing.T) {
+	userAgentKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authSecret := make([]byte, 16)
+	_, err = rand.Read(authSecret)
+	require.NoError(t, err)
+
+	vapidKey, err := GenerateKey()
+	require.NoError(t, err)
+	parsed, err := ParsePrivateKey(EncodePrivateKey(vapidKey))
+	require.NoError(t, err)
+	require.True(t, parsed.Equal(vapidKey))
+	client := &Client{PrivateKey: parsed, Subject: "mailto:ops@example.com"}
+
+	var received []byte
+	service := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/gone/") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		require.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "3600", r.Header.Get("TTL"))
+		require.Equal(t, "high", r.Header.Get("Urgency"))
+
+		// Authorization: vapid t=<jeton>, k=<clé publique>
+		var token, publicKey string
+		for _, part := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "vapid "), ", ") {
+			if value, ok := strings.CutPrefix(part, "t="); ok {
+				token = value
+			}
+			if value, ok := strings.CutPrefix(part, "k="); ok {
+				publicKey = value
+			}
+		}
+		require.Equal(t, client.PublicKey(), publicKey)
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature := b64(t, parts[2])
+		require.Len(t, signature, 64)
+		require.True(t, ecdsa.Verify(&vapidKey.PublicKey, digest[:],
+			new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])), "signature VAPID invalide")
+		var claims struct {
+			Aud string `json:"aud"`
+			Exp int64  `json:"exp"`
+			Sub string `json:"sub"`
+		}
+		require.NoError(t, json.Unmarshal(b64(t, parts[1]), &claims))
+		require.Equal(t, "https://push.example.com", claims.Aud)
+		require.Equal(t, "mailto:ops@example.com", claims.Sub)
+		require.Greater(t, claims.Exp, time.Now().Unix())
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = decrypt(t, body, userAgentKey, authSecret)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+	client.HTTPClient = dialTo(service)
+
+	sub := Subscription{
+		Endpoint: "https://push.example.com/push/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(userAgentKey.PublicKey().Bytes()),
+		Auth:     base64.URLEncoding.EncodeToString(authSecret), // Avec remplissage, comme certains navigateurs
+	}
+	message := Message{Payload: []byte(`{"title":"Réunion dans 10 minutes"}`), TTL: time.Hour, Urgency: "high"}
+	require.NoError(t, client.Send(context.Background(), sub, message))
+	require.Equal(t, `{"title":"Réunion dans 10 minutes"}`, string(received))
+
+	// Un abonnement expiré est signalé pour suppression
+	sub.Endpoint = "https://push.example.com/gone/abc"
+	require.ErrorIs(t, client.Send(context.Background(), sub, message), ErrSubscriptionGone)
+
+	// Contenu trop volumineux et clés invalides
+	message.Payload = make([]byte, MaxPayloadSize+1)
+	require.ErrorIs(t, client.Send(context.Background(), sub, message), ErrPayloadTooLarge)
+	sub.Auth = "AAAA"
+	require.ErrorIs(t, ValidateSubscription(sub), ErrInvalidSubscription)
+}
+
+// dialTo retourne un client HTTP qui joint le service de test quel que soit le nom d'hôte demandé
+func dialTo(service *httptest.Server) *http.Client {
+	transport := service.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, service.Listener.Addr().String())
+	}
+	return &http.Client{Transport: transport}
+}
+
+// TestValidateSubscriptionEndpoint vérifie que seuls les points de terminaison https publics sont acceptés
+func TestValidateSubscriptionEndpoint(t *testing.T) {
+	sub := Subscription{
+		P256dh: "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+		Auth:   "BTBZMqHH6r4Tts7J_aSIgg",
+	}
+	for endpoint, valid := range map[string]bool{
+		"https://fcm.googleapis.com/fcm/send/abc":   true,
+		"https://updates.push.services.mozilla.com": true,
+		"https://8.8.8.8/send":                      true,
+		"http://fcm.googleapis.com/fcm/send/abc":    false,
+		"ftp://push.example.net/send":               false,
+		"https:///send":                             false,
+		"https://localhost/send":                    false,
+		"https://LOCALHOST./send":                   false,
+		"https://admin.localhost/send":              false,
+		"https://127.0.0.1:8080/send":               false,
+		"https://10.1.2.3/send":                     false,
+		"https://192.168.1.1/send":                  false,
+		"https://172.16.0.1/send":                   false,
+		"https://169.254.169.254/latest/meta-data":  false,
+		"https://0.0.0.0/send":                      false,
+		"https://[::1]/send":                        false,
+		"https://[fe80::1]/send":                    false,
+		"https://[fd00::1]/send":                    false,
+		"https://[::ffff:127.0.0.1]/send":           false,
+	} {
+		sub.Endpoint = endpoint
+		if valid {
+			require.NoError(t, ValidateSubscription(sub), endpoint)
+		} else {
+			require.ErrorIs(t, ValidateSubscription(sub), ErrInvalidSubscription, endpoint)
+		}
+	}
+}
+
+// TestHTTPClientRefusesPrivateAddresses vérifie que le client d'envoi ne se connecte pas à une adresse
+// non publique, même désignée par un nom d'hôte
+func TestHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	var reached bool
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer service.Close()
+
+	_, port, err := net.SplitHostPort(service.Listener.Addr().String())
+	require.NoError(t, err)
+	for _, target := range []string{service.URL, "http://localhost:" + port} {
+		_, err := NewHTTPClient().Post(target, "application/octet-stream", nil)
+		require.ErrorIs(t, err, ErrForbiddenAddress, target)
+	}
+	require.False(t, reached)
+}
@@ -0,0 +1,3 @@
+-- Migration 001 : index plein texte pour la recherche d'événements (GET /events/search)
+-- À appliquer sur les bases créées avant l'ajout de l'index dans schema.sql
+ALTER TABLE `event` ADD FULLTEXT KEY ft_event_title_description (title, description);
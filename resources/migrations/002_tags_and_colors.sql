@@ -0,0 +1,32 @@
+-- Migration 002 : couleur des calendriers, étiquettes et catégories d'événements
+-- À appliquer sur les bases créées avant l'ajout des tables tag et event_tag dans schema.sql
+ALTER TABLE `calendar` ADD COLUMN color VARCHAR(7) DEFAULT NULL AFTER description;
+
+-- Table : tag (étiquettes et catégories définies par l'utilisateur)
+CREATE TABLE IF NOT EXISTS `tag` (
+    tag_id       INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    kind         ENUM('tag', 'category') NOT NULL DEFAULT 'tag',
+    color        VARCHAR(7) DEFAULT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_tag_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_tag
+CREATE TABLE IF NOT EXISTS `event_tag` (
+    event_tag_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id     INT NOT NULL,
+    tag_id       INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT uc_event_tag UNIQUE (event_id, tag_id),
+    CONSTRAINT fk_event_tag_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_tag_tag FOREIGN KEY (tag_id) REFERENCES `tag`(tag_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,7 @@
+-- Migration 003 : lieu structuré et lien de visioconférence des événements
+-- À appliquer sur les bases créées avant l'ajout des colonnes dans schema.sql
+ALTER TABLE `event`
+    ADD COLUMN location    VARCHAR(500) DEFAULT NULL AFTER canceled,
+    ADD COLUMN latitude    DECIMAL(9,6) DEFAULT NULL AFTER location,
+    ADD COLUMN longitude   DECIMAL(9,6) DEFAULT NULL AFTER latitude,
+    ADD COLUMN meeting_url VARCHAR(2048) DEFAULT NULL AFTER longitude;
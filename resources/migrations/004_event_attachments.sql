@@ -0,0 +1,19 @@
+-- Migration 004 : pièces jointes des événements
+-- À appliquer sur les bases créées avant l'ajout de la table attachment dans schema.sql
+-- Table : attachment (le contenu est conservé par le backend de stockage, seule la clé est en base)
+CREATE TABLE IF NOT EXISTS `attachment` (
+    attachment_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id      INT NOT NULL,
+    user_id       INT NOT NULL,
+    filename      VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(255) NOT NULL,
+    size          BIGINT NOT NULL,
+    storage_key   VARCHAR(500) NOT NULL UNIQUE,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at    DATETIME DEFAULT NULL,
+    CONSTRAINT fk_attachment_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_attachment_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
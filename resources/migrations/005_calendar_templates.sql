@@ -0,0 +1,32 @@
+-- Migration 005 : modèles de calendriers
+-- À appliquer sur les bases créées avant l'ajout des tables calendar_template et calendar_template_event dans schema.sql
+-- Table : calendar_template (modèles de calendriers publiés par les administrateurs)
+CREATE TABLE IF NOT EXISTS `calendar_template` (
+    template_id  INT AUTO_INCREMENT PRIMARY KEY,
+    title        VARCHAR(200) NOT NULL,
+    description  TEXT,
+    color        VARCHAR(7) DEFAULT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_calendar_template_user FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : calendar_template_event (offset_minutes est relatif à la date de début choisie à l'instanciation)
+CREATE TABLE IF NOT EXISTS `calendar_template_event` (
+    template_event_id INT AUTO_INCREMENT PRIMARY KEY,
+    template_id       INT NOT NULL,
+    title             VARCHAR(200) NOT NULL,
+    description       TEXT,
+    offset_minutes    INT NOT NULL,
+    duration          INT NOT NULL,
+    location          VARCHAR(500) DEFAULT NULL,
+    meeting_url       VARCHAR(2048) DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at        DATETIME DEFAULT NULL,
+    CONSTRAINT fk_calendar_template_event_template FOREIGN KEY (template_id) REFERENCES `calendar_template`(template_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,28 @@
+-- Migration 006 : tâches (VTODO)
+-- À appliquer sur les bases créées avant l'ajout de la table task dans schema.sql
+-- Table : task (tâche VTODO rattachée à un calendrier, éventuellement liée à un événement)
+CREATE TABLE IF NOT EXISTS `task` (
+    task_id      INT AUTO_INCREMENT PRIMARY KEY,
+    calendar_id  INT NOT NULL,
+    event_id     INT DEFAULT NULL,
+    title        VARCHAR(200) NOT NULL,
+    description  TEXT,
+    due_date     DATETIME DEFAULT NULL,
+    completed    BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at DATETIME DEFAULT NULL,
+    priority     TINYINT NOT NULL DEFAULT 0,
+    assignee_id  INT DEFAULT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    INDEX idx_task_calendar_due (calendar_id, due_date),
+    CONSTRAINT fk_task_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_task_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_task_assignee FOREIGN KEY (assignee_id) REFERENCES `user`(user_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_task_creator FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
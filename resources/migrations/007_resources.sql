@@ -0,0 +1,41 @@
+-- Migration 007 : ressources réservables
+-- À appliquer sur les bases créées avant l'ajout des tables resource et event_resource et du rôle resource_admin dans schema.sql
+-- Table : resource (salle ou équipement réservable ; calendar_id est son calendrier de disponibilité)
+CREATE TABLE IF NOT EXISTS `resource` (
+    resource_id  INT AUTO_INCREMENT PRIMARY KEY,
+    name         VARCHAR(200) NOT NULL,
+    kind         VARCHAR(20) NOT NULL,
+    description  TEXT,
+    capacity     INT DEFAULT NULL,
+    location     VARCHAR(500) DEFAULT NULL,
+    calendar_id  INT NOT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_resource_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_resource_user FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_resource (réservation d'une ressource par un événement, sur le créneau de l'événement)
+CREATE TABLE IF NOT EXISTS `event_resource` (
+    event_resource_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id          INT NOT NULL,
+    resource_id       INT NOT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at        DATETIME DEFAULT NULL,
+    UNIQUE KEY uq_event_resource (event_id, resource_id),
+    INDEX idx_event_resource_resource (resource_id),
+    CONSTRAINT fk_event_resource_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_resource_resource FOREIGN KEY (resource_id) REFERENCES `resource`(resource_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Rôle administrateur des ressources (gestion des salles et équipements réservables)
+INSERT INTO `roles` (name, description, created_at) VALUES 
+('resource_admin', 'Administrateur des ressources réservables (salles, équipements)', NOW())
+ON DUPLICATE KEY UPDATE updated_at = NOW();
@@ -0,0 +1,60 @@
+-- Migration 008 : pages de réservation publiques
+-- À appliquer sur les bases créées avant l'ajout des tables booking_page, booking_page_rule et booking dans schema.sql
+-- Table : booking_page (page publique de prise de rendez-vous ; durées et tampons en minutes)
+CREATE TABLE IF NOT EXISTS `booking_page` (
+    booking_page_id  INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    calendar_id      INT NOT NULL,
+    slug             VARCHAR(64) NOT NULL UNIQUE,
+    title            VARCHAR(200) NOT NULL,
+    description      TEXT,
+    duration         INT NOT NULL,
+    buffer_before    INT NOT NULL DEFAULT 0,
+    buffer_after     INT NOT NULL DEFAULT 0,
+    max_per_day      INT DEFAULT NULL,
+    min_notice       INT NOT NULL DEFAULT 0,
+    max_advance_days INT NOT NULL DEFAULT 60,
+    timezone         VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    active           BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    CONSTRAINT fk_booking_page_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_page_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : booking_page_rule (plage hebdomadaire ; weekday 0 = dimanche, minutes depuis minuit dans le fuseau de la page)
+CREATE TABLE IF NOT EXISTS `booking_page_rule` (
+    booking_page_rule_id INT AUTO_INCREMENT PRIMARY KEY,
+    booking_page_id      INT NOT NULL,
+    weekday              TINYINT NOT NULL,
+    start_minute         SMALLINT NOT NULL,
+    end_minute           SMALLINT NOT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at           DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at           DATETIME DEFAULT NULL,
+    CONSTRAINT fk_booking_page_rule_page FOREIGN KEY (booking_page_id) REFERENCES `booking_page`(booking_page_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : booking (rendez-vous pris par un visiteur ; seule l'empreinte SHA-256 du jeton d'annulation est conservée)
+CREATE TABLE IF NOT EXISTS `booking` (
+    booking_id        INT AUTO_INCREMENT PRIMARY KEY,
+    booking_page_id   INT NOT NULL,
+    event_id          INT NOT NULL,
+    guest_name        VARCHAR(200) NOT NULL,
+    guest_email       VARCHAR(255) NOT NULL,
+    notes             TEXT,
+    start             DATETIME NOT NULL,
+    end               DATETIME NOT NULL,
+    cancel_token_hash CHAR(64) NOT NULL UNIQUE,
+    canceled_at       DATETIME DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_booking_page_start (booking_page_id, start),
+    CONSTRAINT fk_booking_page FOREIGN KEY (booking_page_id) REFERENCES `booking_page`(booking_page_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,34 @@
+-- Migration 009 : fuseau horaire, heures de travail et absences des utilisateurs
+-- À appliquer sur les bases créées avant l'ajout de la colonne user.timezone et des tables user_working_hours et user_out_of_office dans schema.sql
+ALTER TABLE `user`
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC' AFTER email;
+
+-- Table : user_working_hours (heures de travail ; weekday 0 = dimanche, minutes depuis minuit dans le fuseau user.timezone)
+CREATE TABLE IF NOT EXISTS `user_working_hours` (
+    working_hours_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    weekday          TINYINT NOT NULL,
+    start_minute     SMALLINT NOT NULL,
+    end_minute       SMALLINT NOT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    INDEX idx_user_working_hours_user (user_id),
+    CONSTRAINT fk_user_working_hours_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_out_of_office (période d'absence d'un utilisateur)
+CREATE TABLE IF NOT EXISTS `user_out_of_office` (
+    out_of_office_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    start            DATETIME NOT NULL,
+    end              DATETIME NOT NULL,
+    message          VARCHAR(500) DEFAULT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    INDEX idx_user_out_of_office_user_start (user_id, start),
+    CONSTRAINT fk_user_out_of_office_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,14 @@
+-- Migration 010 : abonnements aux calendriers de jours fériés
+-- À appliquer sur les bases créées avant l'ajout de la table user_holiday_calendar dans schema.sql
+-- Table : user_holiday_calendar (abonnement d'un utilisateur à un calendrier de jours fériés calculé par l'application)
+CREATE TABLE IF NOT EXISTS `user_holiday_calendar` (
+    user_holiday_calendar_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                  INT NOT NULL,
+    country                  CHAR(2) NOT NULL,
+    created_at               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at               DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at               DATETIME DEFAULT NULL,
+    CONSTRAINT uc_user_holiday_calendar UNIQUE (user_id, country),
+    CONSTRAINT fk_user_holiday_calendar_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
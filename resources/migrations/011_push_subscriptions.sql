@@ -0,0 +1,18 @@
+-- Migration 011 : abonnements Web Push
+-- À appliquer sur les bases créées avant l'ajout de la table push_subscription dans schema.sql
+-- Table : push_subscription (abonnement Web Push d'un navigateur ; supprimé dès que le service de push le déclare expiré)
+CREATE TABLE IF NOT EXISTS `push_subscription` (
+    push_subscription_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id              INT NOT NULL,
+    endpoint             VARCHAR(2048) NOT NULL,
+    endpoint_hash        CHAR(64) NOT NULL UNIQUE,
+    p256dh               VARCHAR(128) NOT NULL,
+    auth                 VARCHAR(64) NOT NULL,
+    user_agent           VARCHAR(255) DEFAULT NULL,
+    expires_at           DATETIME DEFAULT NULL,
+    last_success_at      DATETIME DEFAULT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_push_subscription_user (user_id),
+    CONSTRAINT fk_push_subscription_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
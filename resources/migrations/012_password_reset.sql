@@ -0,0 +1,14 @@
+-- Migration 012 : réinitialisation du mot de passe
+-- À appliquer sur les bases créées avant l'ajout de la table password_reset_token dans schema.sql
+-- Table : password_reset_token (lien de réinitialisation du mot de passe ; seule l'empreinte SHA-256 du jeton est conservée)
+CREATE TABLE IF NOT EXISTS `password_reset_token` (
+    password_reset_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                 INT NOT NULL,
+    token_hash              CHAR(64) NOT NULL UNIQUE,
+    expires_at              DATETIME NOT NULL,
+    used_at                 DATETIME DEFAULT NULL,
+    created_at              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_password_reset_token_user (user_id, created_at),
+    CONSTRAINT fk_password_reset_token_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,19 @@
+-- Migration 013 : vérification des adresses e-mail
+-- À appliquer sur les bases créées avant l'ajout de la colonne user.email_verified_at dans schema.sql
+ALTER TABLE `user` ADD COLUMN email_verified_at DATETIME DEFAULT NULL AFTER timezone;
+
+-- Les comptes existants sont considérés comme vérifiés
+UPDATE `user` SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Table : email_verification_token (lien de vérification de l'adresse e-mail ; seule l'empreinte SHA-256 du jeton est conservée)
+CREATE TABLE IF NOT EXISTS `email_verification_token` (
+    email_verification_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                     INT NOT NULL,
+    token_hash                  CHAR(64) NOT NULL UNIQUE,
+    expires_at                  DATETIME NOT NULL,
+    used_at                     DATETIME DEFAULT NULL,
+    created_at                  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_email_verification_token_user (user_id, created_at),
+    CONSTRAINT fk_email_verification_token_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,37 @@
+-- Migration 014 : authentification à deux facteurs (TOTP)
+-- À appliquer sur les bases créées avant l'ajout des tables user_totp, user_recovery_code et login_challenge dans schema.sql
+-- Table : user_totp (secret TOTP de l'authentification à deux facteurs ; active une fois confirmed_at renseigné)
+CREATE TABLE IF NOT EXISTS `user_totp` (
+    user_id      INT PRIMARY KEY,
+    secret       VARCHAR(64) NOT NULL,
+    confirmed_at DATETIME DEFAULT NULL,
+    last_counter BIGINT DEFAULT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_totp_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_recovery_code (codes de récupération à usage unique ; seule leur empreinte SHA-256 est conservée)
+CREATE TABLE IF NOT EXISTS `user_recovery_code` (
+    user_recovery_code_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id               INT NOT NULL,
+    code_hash             CHAR(64) NOT NULL,
+    used_at               DATETIME DEFAULT NULL,
+    created_at            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_recovery_code_user (user_id, code_hash),
+    CONSTRAINT fk_user_recovery_code_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : login_challenge (étape intermédiaire d'une connexion avec second facteur)
+CREATE TABLE IF NOT EXISTS `login_challenge` (
+    login_challenge_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id            INT NOT NULL,
+    token_hash         CHAR(64) NOT NULL UNIQUE,
+    expires_at         DATETIME NOT NULL,
+    attempts           INT NOT NULL DEFAULT 0,
+    used_at            DATETIME DEFAULT NULL,
+    created_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_login_challenge_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
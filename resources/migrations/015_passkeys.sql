@@ -0,0 +1,29 @@
+-- Migration 015 : connexion par passkey (WebAuthn)
+-- À appliquer sur les bases créées avant l'ajout des tables passkey et passkey_challenge dans schema.sql
+-- Table : passkey (credentials WebAuthn enregistrés par les utilisateurs)
+CREATE TABLE IF NOT EXISTS `passkey` (
+    passkey_id    INT AUTO_INCREMENT PRIMARY KEY,
+    user_id       INT NOT NULL,
+    credential_id VARBINARY(1023) NOT NULL UNIQUE,
+    public_key    BLOB NOT NULL,
+    sign_count    INT UNSIGNED NOT NULL DEFAULT 0,
+    name          VARCHAR(100) NOT NULL,
+    last_used_at  DATETIME DEFAULT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_passkey_user (user_id),
+    CONSTRAINT fk_passkey_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : passkey_challenge (challenges des cérémonies WebAuthn en cours ; user_id est nul pour une connexion)
+CREATE TABLE IF NOT EXISTS `passkey_challenge` (
+    passkey_challenge_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id              INT DEFAULT NULL,
+    ceremony             ENUM('registration', 'authentication') NOT NULL,
+    challenge_hash       CHAR(64) NOT NULL UNIQUE,
+    expires_at           DATETIME NOT NULL,
+    used_at              DATETIME DEFAULT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_passkey_challenge_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
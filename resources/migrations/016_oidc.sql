@@ -0,0 +1,12 @@
+-- Migration 016 : connexion SSO OpenID Connect
+-- À appliquer sur les bases créées avant l'ajout de la table oidc_login_state dans schema.sql
+-- Table : oidc_login_state (connexions SSO en cours : empreinte du state, nonce et code verifier PKCE)
+CREATE TABLE IF NOT EXISTS `oidc_login_state` (
+    oidc_login_state_id INT AUTO_INCREMENT PRIMARY KEY,
+    state_hash          CHAR(64) NOT NULL UNIQUE,
+    nonce               VARCHAR(64) NOT NULL,
+    code_verifier       VARCHAR(128) NOT NULL,
+    expires_at          DATETIME NOT NULL,
+    used_at             DATETIME DEFAULT NULL,
+    created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
@@ -0,0 +1,16 @@
+-- Migration 017 : rotation des refresh tokens et détection de leur réutilisation
+-- À appliquer sur les bases créées avant l'ajout de user_session.refresh_expires_at et de la table used_refresh_token dans schema.sql
+-- Les sessions existantes reçoivent une échéance absolue de 30 jours à compter de leur création
+ALTER TABLE `user_session` ADD COLUMN refresh_expires_at DATETIME DEFAULT NULL AFTER expires_at;
+UPDATE `user_session` SET refresh_expires_at = DATE_ADD(created_at, INTERVAL 30 DAY) WHERE refresh_expires_at IS NULL;
+ALTER TABLE `user_session` MODIFY refresh_expires_at DATETIME NOT NULL;
+
+-- Table : used_refresh_token (refresh tokens déjà échangés ; leur réutilisation révoque la session)
+CREATE TABLE IF NOT EXISTS `used_refresh_token` (
+    used_refresh_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_session_id       INT NOT NULL,
+    refresh_token         VARCHAR(500) NOT NULL UNIQUE,
+    used_at               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_used_refresh_token_session FOREIGN KEY (user_session_id) REFERENCES `user_session`(user_session_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
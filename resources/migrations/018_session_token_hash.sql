@@ -0,0 +1,20 @@
+-- Migration 018 : conservation des tokens de session et des refresh tokens sous forme d'empreintes SHA-256
+-- À appliquer sur les bases créées avant le remplacement de session_token et refresh_token par leurs empreintes dans schema.sql
+-- Les sessions existantes restent valides : leurs tokens sont remplacés par leur empreinte, puis supprimés
+ALTER TABLE `user_session`
+    ADD COLUMN session_token_hash CHAR(64) DEFAULT NULL AFTER user_id,
+    ADD COLUMN refresh_token_hash CHAR(64) DEFAULT NULL AFTER session_token_hash;
+UPDATE `user_session` SET session_token_hash = SHA2(session_token, 256), refresh_token_hash = SHA2(refresh_token, 256);
+ALTER TABLE `user_session`
+    MODIFY session_token_hash CHAR(64) NOT NULL,
+    ADD UNIQUE KEY session_token_hash (session_token_hash),
+    ADD UNIQUE KEY refresh_token_hash (refresh_token_hash),
+    DROP COLUMN session_token,
+    DROP COLUMN refresh_token;
+
+ALTER TABLE `used_refresh_token` ADD COLUMN refresh_token_hash CHAR(64) DEFAULT NULL AFTER user_session_id;
+UPDATE `used_refresh_token` SET refresh_token_hash = SHA2(refresh_token, 256);
+ALTER TABLE `used_refresh_token`
+    MODIFY refresh_token_hash CHAR(64) NOT NULL,
+    ADD UNIQUE KEY refresh_token_hash (refresh_token_hash),
+    DROP COLUMN refresh_token;
@@ -0,0 +1,25 @@
+-- Migration 019 : protection de la connexion contre les attaques par force brute
+-- À appliquer sur les bases créées avant l'ajout des tables login_lockout et security_event dans schema.sql
+-- Table : login_lockout (échecs de connexion consécutifs et verrouillage temporaire, par adresse e-mail normalisée)
+CREATE TABLE IF NOT EXISTS `login_lockout` (
+    email          VARCHAR(255) PRIMARY KEY,
+    failed_count   INT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until   DATETIME DEFAULT NULL,
+    updated_at     DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+
+-- Table : security_event (journal des connexions, échecs, verrouillages et déverrouillages)
+CREATE TABLE IF NOT EXISTS `security_event` (
+    security_event_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_type        ENUM('login_failed', 'login_succeeded', 'account_locked', 'account_unlocked') NOT NULL,
+    user_id           INT DEFAULT NULL,
+    email             VARCHAR(255) NOT NULL,
+    ip_address        VARCHAR(45) NOT NULL,
+    details           VARCHAR(255) NOT NULL DEFAULT '',
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_security_event_email (email, created_at),
+    INDEX idx_security_event_ip (ip_address, event_type, created_at),
+    CONSTRAINT fk_security_event_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE SET NULL
+) ENGINE=InnoDB;
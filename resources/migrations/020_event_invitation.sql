@@ -0,0 +1,24 @@
+-- Migration 020 : invitations aux événements, déclinées automatiquement pendant les absences de l'invité
+-- À appliquer sur les bases créées avant l'ajout de la table event_invitation dans schema.sql
+-- Table : event_invitation (invitation d'un utilisateur à un événement ; auto_declined : refus dû à une absence)
+CREATE TABLE IF NOT EXISTS `event_invitation` (
+    invitation_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id      INT NOT NULL,
+    user_id       INT NOT NULL,
+    invited_by    INT NOT NULL,
+    status        ENUM('pending', 'accepted', 'declined', 'tentative') NOT NULL DEFAULT 'pending',
+    auto_declined BOOLEAN NOT NULL DEFAULT FALSE,
+    comment       VARCHAR(500) DEFAULT NULL,
+    responded_at  DATETIME DEFAULT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at    DATETIME DEFAULT NULL,
+    CONSTRAINT uc_event_invitation UNIQUE (event_id, user_id),
+    INDEX idx_event_invitation_user_status (user_id, status),
+    CONSTRAINT fk_event_invitation_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_invitation_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_invitation_invited_by FOREIGN KEY (invited_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
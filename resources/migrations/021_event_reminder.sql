@@ -0,0 +1,19 @@
+-- Migration 021 : rappels d'événements envoyés en notification Web Push
+-- À appliquer sur les bases créées avant l'ajout de la table event_reminder dans schema.sql
+-- Table : event_reminder (rappel Web Push d'un événement pour un utilisateur ; notified_start : début de l'événement lors de l'envoi, un déplacement de l'événement réarme le rappel)
+CREATE TABLE IF NOT EXISTS `event_reminder` (
+    event_reminder_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id          INT NOT NULL,
+    user_id           INT NOT NULL,
+    minutes_before    INT NOT NULL,
+    notified_start    DATETIME DEFAULT NULL,
+    notified_at       DATETIME DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL,
+    UNIQUE KEY uq_event_reminder (event_id, user_id),
+    INDEX idx_event_reminder_user (user_id),
+    CONSTRAINT fk_event_reminder_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_reminder_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
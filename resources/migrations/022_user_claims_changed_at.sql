@@ -0,0 +1,5 @@
+-- Migration 022 : invalidation des tokens d'accès signés lors d'un changement de rôles ou de vérification de l'adresse
+-- À appliquer sur les bases créées avant l'ajout de la colonne user.claims_changed_at dans schema.sql
+ALTER TABLE `user`
+    ADD COLUMN claims_changed_at DATETIME DEFAULT NULL AFTER email_verified_at,
+    ADD INDEX idx_user_claims_changed (claims_changed_at);
@@ -1,12 +1,16 @@
--- Table : user
+-- Table : user (claims_changed_at : dernier changement des rôles ou de la vérification de l'adresse, qui invalide les tokens d'accès signés émis auparavant)
 CREATE TABLE IF NOT EXISTS `user` (
     user_id      INT AUTO_INCREMENT PRIMARY KEY,
     lastname     VARCHAR(100) NOT NULL,
     firstname    VARCHAR(100) NOT NULL,
     email        VARCHAR(255) NOT NULL UNIQUE,
+    timezone     VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    email_verified_at DATETIME DEFAULT NULL,
+    claims_changed_at DATETIME DEFAULT NULL,
     created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
-    deleted_at   DATETIME DEFAULT NULL
+    deleted_at   DATETIME DEFAULT NULL,
+    INDEX idx_user_claims_changed (claims_changed_at)
 ) ENGINE=InnoDB; 
 
 -- Table : calendar
@@ -14,6 +18,7 @@ CREATE TABLE IF NOT EXISTS `calendar` (
     calendar_id  INT AUTO_INCREMENT PRIMARY KEY,
     title        VARCHAR(200) NOT NULL,
     description  TEXT,
+    color        VARCHAR(7) DEFAULT NULL,
     created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
     deleted_at   DATETIME DEFAULT NULL
@@ -42,9 +47,14 @@ CREATE TABLE IF NOT EXISTS `event` (
     start        DATETIME NOT NULL,
     duration     INT NOT NULL,
     canceled     BOOL NOT NULL DEFAULT FALSE,
+    location     VARCHAR(500) DEFAULT NULL,
+    latitude     DECIMAL(9,6) DEFAULT NULL,
+    longitude    DECIMAL(9,6) DEFAULT NULL,
+    meeting_url  VARCHAR(2048) DEFAULT NULL,
     created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
-    deleted_at   DATETIME DEFAULT NULL
+    deleted_at   DATETIME DEFAULT NULL,
+    FULLTEXT KEY ft_event_title_description (title, description)
 ) ENGINE=InnoDB;
 
 -- Table : calendar_event
@@ -99,24 +109,460 @@ CREATE TABLE IF NOT EXISTS `user_roles` (
         ON DELETE CASCADE
 ) ENGINE=InnoDB;
 
--- Table : user_session
+-- Table : user_session (seules les empreintes SHA-256 des tokens de session et des refresh tokens sont conservées)
 CREATE TABLE IF NOT EXISTS `user_session` (
-    user_session_id INT AUTO_INCREMENT PRIMARY KEY,
-    user_id         INT NOT NULL,
-    session_token   VARCHAR(500) NOT NULL UNIQUE,
-    refresh_token   VARCHAR(500) DEFAULT NULL,
-    expires_at      DATETIME NOT NULL,
-    device_info     VARCHAR(255) DEFAULT NULL,
-    ip_address      VARCHAR(45) DEFAULT NULL,
-    location        VARCHAR(255) DEFAULT NULL,
-    is_active       BOOL NOT NULL DEFAULT TRUE,
-    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at      DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
-    deleted_at      DATETIME DEFAULT NULL,
+    user_session_id    INT AUTO_INCREMENT PRIMARY KEY,
+    user_id            INT NOT NULL,
+    session_token_hash CHAR(64) NOT NULL UNIQUE,
+    refresh_token_hash CHAR(64) DEFAULT NULL UNIQUE,
+    expires_at         DATETIME NOT NULL,
+    refresh_expires_at DATETIME NOT NULL,
+    device_info        VARCHAR(255) DEFAULT NULL,
+    ip_address         VARCHAR(45) DEFAULT NULL,
+    location           VARCHAR(255) DEFAULT NULL,
+    is_active          BOOL NOT NULL DEFAULT TRUE,
+    created_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at         DATETIME DEFAULT NULL,
     CONSTRAINT fk_user_session_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
         ON DELETE CASCADE
 ) ENGINE=InnoDB;
 
+-- Table : used_refresh_token (refresh tokens déjà échangés ; leur réutilisation révoque la session)
+CREATE TABLE IF NOT EXISTS `used_refresh_token` (
+    used_refresh_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_session_id       INT NOT NULL,
+    refresh_token_hash    CHAR(64) NOT NULL UNIQUE,
+    used_at               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_used_refresh_token_session FOREIGN KEY (user_session_id) REFERENCES `user_session`(user_session_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : tag (étiquettes et catégories définies par l'utilisateur)
+CREATE TABLE IF NOT EXISTS `tag` (
+    tag_id       INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    kind         ENUM('tag', 'category') NOT NULL DEFAULT 'tag',
+    color        VARCHAR(7) DEFAULT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_tag_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_tag
+CREATE TABLE IF NOT EXISTS `event_tag` (
+    event_tag_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id     INT NOT NULL,
+    tag_id       INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT uc_event_tag UNIQUE (event_id, tag_id),
+    CONSTRAINT fk_event_tag_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_tag_tag FOREIGN KEY (tag_id) REFERENCES `tag`(tag_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : attachment (le contenu est conservé par le backend de stockage, seule la clé est en base)
+CREATE TABLE IF NOT EXISTS `attachment` (
+    attachment_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id      INT NOT NULL,
+    user_id       INT NOT NULL,
+    filename      VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(255) NOT NULL,
+    size          BIGINT NOT NULL,
+    storage_key   VARCHAR(500) NOT NULL UNIQUE,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at    DATETIME DEFAULT NULL,
+    CONSTRAINT fk_attachment_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_attachment_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : calendar_template (modèles de calendriers publiés par les administrateurs)
+CREATE TABLE IF NOT EXISTS `calendar_template` (
+    template_id  INT AUTO_INCREMENT PRIMARY KEY,
+    title        VARCHAR(200) NOT NULL,
+    description  TEXT,
+    color        VARCHAR(7) DEFAULT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_calendar_template_user FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : calendar_template_event (offset_minutes est relatif à la date de début choisie à l'instanciation)
+CREATE TABLE IF NOT EXISTS `calendar_template_event` (
+    template_event_id INT AUTO_INCREMENT PRIMARY KEY,
+    template_id       INT NOT NULL,
+    title             VARCHAR(200) NOT NULL,
+    description       TEXT,
+    offset_minutes    INT NOT NULL,
+    duration          INT NOT NULL,
+    location          VARCHAR(500) DEFAULT NULL,
+    meeting_url       VARCHAR(2048) DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at        DATETIME DEFAULT NULL,
+    CONSTRAINT fk_calendar_template_event_template FOREIGN KEY (template_id) REFERENCES `calendar_template`(template_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : task (tâche VTODO rattachée à un calendrier, éventuellement liée à un événement)
+CREATE TABLE IF NOT EXISTS `task` (
+    task_id      INT AUTO_INCREMENT PRIMARY KEY,
+    calendar_id  INT NOT NULL,
+    event_id     INT DEFAULT NULL,
+    title        VARCHAR(200) NOT NULL,
+    description  TEXT,
+    due_date     DATETIME DEFAULT NULL,
+    completed    BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at DATETIME DEFAULT NULL,
+    priority     TINYINT NOT NULL DEFAULT 0,
+    assignee_id  INT DEFAULT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    INDEX idx_task_calendar_due (calendar_id, due_date),
+    CONSTRAINT fk_task_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_task_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_task_assignee FOREIGN KEY (assignee_id) REFERENCES `user`(user_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_task_creator FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : resource (salle ou équipement réservable ; calendar_id est son calendrier de disponibilité)
+CREATE TABLE IF NOT EXISTS `resource` (
+    resource_id  INT AUTO_INCREMENT PRIMARY KEY,
+    name         VARCHAR(200) NOT NULL,
+    kind         VARCHAR(20) NOT NULL,
+    description  TEXT,
+    capacity     INT DEFAULT NULL,
+    location     VARCHAR(500) DEFAULT NULL,
+    calendar_id  INT NOT NULL,
+    created_by   INT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at   DATETIME DEFAULT NULL,
+    CONSTRAINT fk_resource_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_resource_user FOREIGN KEY (created_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_resource (réservation d'une ressource par un événement, sur le créneau de l'événement)
+CREATE TABLE IF NOT EXISTS `event_resource` (
+    event_resource_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id          INT NOT NULL,
+    resource_id       INT NOT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at        DATETIME DEFAULT NULL,
+    UNIQUE KEY uq_event_resource (event_id, resource_id),
+    INDEX idx_event_resource_resource (resource_id),
+    CONSTRAINT fk_event_resource_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_resource_resource FOREIGN KEY (resource_id) REFERENCES `resource`(resource_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : booking_page (page publique de prise de rendez-vous ; durées et tampons en minutes)
+CREATE TABLE IF NOT EXISTS `booking_page` (
+    booking_page_id  INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    calendar_id      INT NOT NULL,
+    slug             VARCHAR(64) NOT NULL UNIQUE,
+    title            VARCHAR(200) NOT NULL,
+    description      TEXT,
+    duration         INT NOT NULL,
+    buffer_before    INT NOT NULL DEFAULT 0,
+    buffer_after     INT NOT NULL DEFAULT 0,
+    max_per_day      INT DEFAULT NULL,
+    min_notice       INT NOT NULL DEFAULT 0,
+    max_advance_days INT NOT NULL DEFAULT 60,
+    timezone         VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    active           BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    CONSTRAINT fk_booking_page_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_page_calendar FOREIGN KEY (calendar_id) REFERENCES `calendar`(calendar_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : booking_page_rule (plage hebdomadaire ; weekday 0 = dimanche, minutes depuis minuit dans le fuseau de la page)
+CREATE TABLE IF NOT EXISTS `booking_page_rule` (
+    booking_page_rule_id INT AUTO_INCREMENT PRIMARY KEY,
+    booking_page_id      INT NOT NULL,
+    weekday              TINYINT NOT NULL,
+    start_minute         SMALLINT NOT NULL,
+    end_minute           SMALLINT NOT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at           DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at           DATETIME DEFAULT NULL,
+    CONSTRAINT fk_booking_page_rule_page FOREIGN KEY (booking_page_id) REFERENCES `booking_page`(booking_page_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : booking (rendez-vous pris par un visiteur ; seule l'empreinte SHA-256 du jeton d'annulation est conservée)
+CREATE TABLE IF NOT EXISTS `booking` (
+    booking_id        INT AUTO_INCREMENT PRIMARY KEY,
+    booking_page_id   INT NOT NULL,
+    event_id          INT NOT NULL,
+    guest_name        VARCHAR(200) NOT NULL,
+    guest_email       VARCHAR(255) NOT NULL,
+    notes             TEXT,
+    start             DATETIME NOT NULL,
+    end               DATETIME NOT NULL,
+    cancel_token_hash CHAR(64) NOT NULL UNIQUE,
+    canceled_at       DATETIME DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_booking_page_start (booking_page_id, start),
+    CONSTRAINT fk_booking_page FOREIGN KEY (booking_page_id) REFERENCES `booking_page`(booking_page_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_working_hours (heures de travail ; weekday 0 = dimanche, minutes depuis minuit dans le fuseau user.timezone)
+CREATE TABLE IF NOT EXISTS `user_working_hours` (
+    working_hours_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    weekday          TINYINT NOT NULL,
+    start_minute     SMALLINT NOT NULL,
+    end_minute       SMALLINT NOT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    INDEX idx_user_working_hours_user (user_id),
+    CONSTRAINT fk_user_working_hours_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_out_of_office (période d'absence d'un utilisateur)
+CREATE TABLE IF NOT EXISTS `user_out_of_office` (
+    out_of_office_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT NOT NULL,
+    start            DATETIME NOT NULL,
+    end              DATETIME NOT NULL,
+    message          VARCHAR(500) DEFAULT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at       DATETIME DEFAULT NULL,
+    INDEX idx_user_out_of_office_user_start (user_id, start),
+    CONSTRAINT fk_user_out_of_office_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_invitation (invitation d'un utilisateur à un événement ; auto_declined : refus dû à une absence)
+CREATE TABLE IF NOT EXISTS `event_invitation` (
+    invitation_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id      INT NOT NULL,
+    user_id       INT NOT NULL,
+    invited_by    INT NOT NULL,
+    status        ENUM('pending', 'accepted', 'declined', 'tentative') NOT NULL DEFAULT 'pending',
+    auto_declined BOOLEAN NOT NULL DEFAULT FALSE,
+    comment       VARCHAR(500) DEFAULT NULL,
+    responded_at  DATETIME DEFAULT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at    DATETIME DEFAULT NULL,
+    CONSTRAINT uc_event_invitation UNIQUE (event_id, user_id),
+    INDEX idx_event_invitation_user_status (user_id, status),
+    CONSTRAINT fk_event_invitation_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_invitation_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_invitation_invited_by FOREIGN KEY (invited_by) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_holiday_calendar (abonnement d'un utilisateur à un calendrier de jours fériés calculé par l'application)
+CREATE TABLE IF NOT EXISTS `user_holiday_calendar` (
+    user_holiday_calendar_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                  INT NOT NULL,
+    country                  CHAR(2) NOT NULL,
+    created_at               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at               DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at               DATETIME DEFAULT NULL,
+    CONSTRAINT uc_user_holiday_calendar UNIQUE (user_id, country),
+    CONSTRAINT fk_user_holiday_calendar_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : push_subscription (abonnement Web Push d'un navigateur ; supprimé dès que le service de push le déclare expiré)
+CREATE TABLE IF NOT EXISTS `push_subscription` (
+    push_subscription_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id              INT NOT NULL,
+    endpoint             VARCHAR(2048) NOT NULL,
+    endpoint_hash        CHAR(64) NOT NULL UNIQUE,
+    p256dh               VARCHAR(128) NOT NULL,
+    auth                 VARCHAR(64) NOT NULL,
+    user_agent           VARCHAR(255) DEFAULT NULL,
+    expires_at           DATETIME DEFAULT NULL,
+    last_success_at      DATETIME DEFAULT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_push_subscription_user (user_id),
+    CONSTRAINT fk_push_subscription_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : event_reminder (rappel Web Push d'un événement pour un utilisateur ; notified_start : début de l'événement lors de l'envoi, un déplacement de l'événement réarme le rappel)
+CREATE TABLE IF NOT EXISTS `event_reminder` (
+    event_reminder_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id          INT NOT NULL,
+    user_id           INT NOT NULL,
+    minutes_before    INT NOT NULL,
+    notified_start    DATETIME DEFAULT NULL,
+    notified_at       DATETIME DEFAULT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT NULL,
+    UNIQUE KEY uq_event_reminder (event_id, user_id),
+    INDEX idx_event_reminder_user (user_id),
+    CONSTRAINT fk_event_reminder_event FOREIGN KEY (event_id) REFERENCES `event`(event_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event_reminder_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : password_reset_token (lien de réinitialisation du mot de passe ; seule l'empreinte SHA-256 du jeton est conservée)
+CREATE TABLE IF NOT EXISTS `password_reset_token` (
+    password_reset_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                 INT NOT NULL,
+    token_hash              CHAR(64) NOT NULL UNIQUE,
+    expires_at              DATETIME NOT NULL,
+    used_at                 DATETIME DEFAULT NULL,
+    created_at              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_password_reset_token_user (user_id, created_at),
+    CONSTRAINT fk_password_reset_token_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : email_verification_token (lien de vérification de l'adresse e-mail ; seule l'empreinte SHA-256 du jeton est conservée)
+CREATE TABLE IF NOT EXISTS `email_verification_token` (
+    email_verification_token_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                     INT NOT NULL,
+    token_hash                  CHAR(64) NOT NULL UNIQUE,
+    expires_at                  DATETIME NOT NULL,
+    used_at                     DATETIME DEFAULT NULL,
+    created_at                  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_email_verification_token_user (user_id, created_at),
+    CONSTRAINT fk_email_verification_token_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_totp (secret TOTP de l'authentification à deux facteurs ; active une fois confirmed_at renseigné)
+CREATE TABLE IF NOT EXISTS `user_totp` (
+    user_id      INT PRIMARY KEY,
+    secret       VARCHAR(64) NOT NULL,
+    confirmed_at DATETIME DEFAULT NULL,
+    last_counter BIGINT DEFAULT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_totp_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : user_recovery_code (codes de récupération à usage unique ; seule leur empreinte SHA-256 est conservée)
+CREATE TABLE IF NOT EXISTS `user_recovery_code` (
+    user_recovery_code_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id               INT NOT NULL,
+    code_hash             CHAR(64) NOT NULL,
+    used_at               DATETIME DEFAULT NULL,
+    created_at            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_recovery_code_user (user_id, code_hash),
+    CONSTRAINT fk_user_recovery_code_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : login_challenge (étape intermédiaire d'une connexion avec second facteur)
+CREATE TABLE IF NOT EXISTS `login_challenge` (
+    login_challenge_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id            INT NOT NULL,
+    token_hash         CHAR(64) NOT NULL UNIQUE,
+    expires_at         DATETIME NOT NULL,
+    attempts           INT NOT NULL DEFAULT 0,
+    used_at            DATETIME DEFAULT NULL,
+    created_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_login_challenge_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : passkey (credentials WebAuthn enregistrés par les utilisateurs)
+CREATE TABLE IF NOT EXISTS `passkey` (
+    passkey_id    INT AUTO_INCREMENT PRIMARY KEY,
+    user_id       INT NOT NULL,
+    credential_id VARBINARY(1023) NOT NULL UNIQUE,
+    public_key    BLOB NOT NULL,
+    sign_count    INT UNSIGNED NOT NULL DEFAULT 0,
+    name          VARCHAR(100) NOT NULL,
+    last_used_at  DATETIME DEFAULT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_passkey_user (user_id),
+    CONSTRAINT fk_passkey_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : passkey_challenge (challenges des cérémonies WebAuthn en cours ; user_id est nul pour une connexion)
+CREATE TABLE IF NOT EXISTS `passkey_challenge` (
+    passkey_challenge_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id              INT DEFAULT NULL,
+    ceremony             ENUM('registration', 'authentication') NOT NULL,
+    challenge_hash       CHAR(64) NOT NULL UNIQUE,
+    expires_at           DATETIME NOT NULL,
+    used_at              DATETIME DEFAULT NULL,
+    created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_passkey_challenge_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Table : oidc_login_state (connexions SSO en cours : empreinte du state, nonce et code verifier PKCE)
+CREATE TABLE IF NOT EXISTS `oidc_login_state` (
+    oidc_login_state_id INT AUTO_INCREMENT PRIMARY KEY,
+    state_hash          CHAR(64) NOT NULL UNIQUE,
+    nonce               VARCHAR(64) NOT NULL,
+    code_verifier       VARCHAR(128) NOT NULL,
+    expires_at          DATETIME NOT NULL,
+    used_at             DATETIME DEFAULT NULL,
+    created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+
+-- Table : login_lockout (échecs de connexion consécutifs et verrouillage temporaire, par adresse e-mail normalisée)
+CREATE TABLE IF NOT EXISTS `login_lockout` (
+    email          VARCHAR(255) PRIMARY KEY,
+    failed_count   INT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until   DATETIME DEFAULT NULL,
+    updated_at     DATETIME DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+
+-- Table : security_event (journal des connexions, échecs, verrouillages et déverrouillages)
+CREATE TABLE IF NOT EXISTS `security_event` (
+    security_event_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_type        ENUM('login_failed', 'login_succeeded', 'account_locked', 'account_unlocked') NOT NULL,
+    user_id           INT DEFAULT NULL,
+    email             VARCHAR(255) NOT NULL,
+    ip_address        VARCHAR(45) NOT NULL,
+    details           VARCHAR(255) NOT NULL DEFAULT '',
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_security_event_email (email, created_at),
+    INDEX idx_security_event_ip (ip_address, event_type, created_at),
+    CONSTRAINT fk_security_event_user FOREIGN KEY (user_id) REFERENCES `user`(user_id)
+        ON DELETE SET NULL
+) ENGINE=InnoDB;
+
 -- ===== RÔLES PRÉVUS PAR L'APPLICATION =====
 
 -- Rôle administrateur (accès complet à toutes les fonctionnalités)
@@ -139,11 +585,16 @@ INSERT INTO `roles` (name, description, created_at) VALUES
 ('editor', 'Éditeur avec permissions de création et modification de contenu', NOW())
 ON DUPLICATE KEY UPDATE updated_at = NOW();
 
+-- Rôle administrateur des ressources (gestion des salles et équipements réservables)
+INSERT INTO `roles` (name, description, created_at) VALUES 
+('resource_admin', 'Administrateur des ressources réservables (salles, équipements)', NOW())
+ON DUPLICATE KEY UPDATE updated_at = NOW();
+
 -- ===== ADMINISTRATEUR PAR DÉFAUT =====
 
 -- Création de l'utilisateur administrateur par défaut
-INSERT INTO `user` (lastname, firstname, email, created_at) VALUES 
-('Administrateur', 'GoLendar', 'admin@golendar.com', NOW())
+INSERT INTO `user` (lastname, firstname, email, email_verified_at, created_at) VALUES 
+('Administrateur', 'GoLendar', 'admin@golendar.com', NOW(), NOW())
 ON DUPLICATE KEY UPDATE updated_at = NOW();
 
 -- Récupération de l'ID de l'utilisateur admin et du rôle admin
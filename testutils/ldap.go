@@ -0,0 +1,323 @@
+package testutils
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Codes de résultat LDAP renvoyés par l'annuaire de test
+const (
+	mockLDAPSuccess                  = 0
+	mockLDAPSizeLimitExceeded        = 4
+	mockLDAPInvalidCredentials       = 49
+	mockLDAPInsufficientAccessRights = 50
+)
+
+// MockLDAP est un annuaire LDAPv3 en mémoire, utilisé par les tests de l'authentification LDAP.
+// Il accepte les liaisons simples et les recherches (filtres &, |, = et présence) sur ldap://127.0.0.1.
+// Comme beaucoup d'annuaires, il accepte la liaison non authentifiée d'un DN avec un mot de passe vide.
+type MockLDAP struct {
+	listener net.Listener
+	mu       sync.Mutex
+	entries  []MockLDAPEntry
+	binds    []string
+}
+
+// MockLDAPEntry est une entrée de l'annuaire ; Password est le mot de passe de liaison, vide si l'entrée ne peut pas se lier
+type MockLDAPEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// mockLDAPElement est un élément BER décodé
+type mockLDAPElement struct {
+	tag     byte
+	content []byte
+}
+
+// NewMockLDAP démarre un annuaire vide
+func NewMockLDAP() (*MockLDAP, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	directory := &MockLDAP{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go directory.serve(conn)
+		}
+	}()
+	return directory, nil
+}
+
+// URL retourne l'adresse de l'annuaire
+func (m *MockLDAP) URL() string {
+	return "ldap://" + m.listener.Addr().String()
+}
+
+// Close arrête l'annuaire
+func (m *MockLDAP) Close() {
+	m.listener.Close()
+}
+
+// Add ajoute une entrée
+func (m *MockLDAP) Add(entry MockLDAPEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+}
+
+// Binds retourne les DN de toutes les liaisons demandées, réussies ou non
+func (m *MockLDAP) Binds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.binds...)
+}
+
+// serve traite les requêtes d'une connexion jusqu'à la déconnexion du client
+func (m *MockLDAP) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	bound := ""
+	for {
+		message, err := readMockLDAPElement(reader)
+		if err != nil {
+			return
+		}
+		parts, err := message.children()
+		if err != nil || len(parts) < 2 {
+			return
+		}
+		id := parts[0].content
+		op := parts[1]
+		fields, err := op.children()
+
+		switch op.tag {
+		case 0x60: // BindRequest
+			if err != nil || len(fields) != 3 || fields[2].tag != 0x80 {
+				return
+			}
+			dn, password := string(fields[1].content), string(fields[2].content)
+			code := m.bind(dn, password)
+			if code == mockLDAPSuccess {
+				bound = dn
+			}
+			conn.Write(mockLDAPMessage(id, mockLDAPEncode(0x61, mockLDAPResult(code)...)))
+		case 0x63: // SearchRequest
+			if err != nil || len(fields) != 8 {
+				return
+			}
+			if bound == "" {
+				conn.Write(mockLDAPMessage(id, mockLDAPEncode(0x65, mockLDAPResult(mockLDAPInsufficientAccessRights)...)))
+				continue
+			}
+			for _, response := range m.search(id, fields) {
+				conn.Write(response)
+			}
+		default: // UnbindRequest et opérations non prises en charge
+			return
+		}
+	}
+}
+
+// bind vérifie une liaison simple et retourne le code de résultat
+func (m *MockLDAP) bind(dn, password string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.binds = append(m.binds, dn)
+	if password == "" {
+		return mockLDAPSuccess
+	}
+	for _, entry := range m.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return mockLDAPSuccess
+		}
+	}
+	return mockLDAPInvalidCredentials
+}
+
+// search retourne les messages de réponse à une recherche : entrées puis résultat
+func (m *MockLDAP) search(id []byte, fields []mockLDAPElement) [][]byte {
+	base := strings.ToLower(string(fields[0].content))
+	sizeLimit := 0
+	for _, b := range fields[3].content {
+		sizeLimit = sizeLimit<<8 | int(b)
+	}
+	selection, _ := fields[7].children()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var responses [][]byte
+	code := mockLDAPSuccess
+	for _, entry := range m.entries {
+		dn := strings.ToLower(entry.DN)
+		if dn != base && !strings.HasSuffix(dn, ","+base) || !matchMockLDAPFilter(entry, fields[6]) {
+			continue
+		}
+		if sizeLimit > 0 && len(responses) == sizeLimit {
+			code = mockLDAPSizeLimitExceeded
+			break
+		}
+		var attributes [][]byte
+		for name, values := range entry.Attributes {
+			if !selectedMockLDAPAttribute(selection, name) {
+				continue
+			}
+			var encoded [][]byte
+			for _, value := range values {
+				encoded = append(encoded, mockLDAPEncode(0x04, []byte(value)))
+			}
+			attributes = append(attributes, mockLDAPEncode(0x30, mockLDAPEncode(0x04, []byte(name)), mockLDAPEncode(0x31, encoded...)))
+		}
+		responses = append(responses, mockLDAPMessage(id, mockLDAPEncode(0x64,
+			mockLDAPEncode(0x04, []byte(entry.DN)),
+			mockLDAPEncode(0x30, attributes...),
+		)))
+	}
+	return append(responses, mockLDAPMessage(id, mockLDAPEncode(0x65, mockLDAPResult(code)...)))
+}
+
+// matchMockLDAPFilter évalue un filtre sur une entrée ; valeurs et noms d'attributs sont comparés sans la casse
+func matchMockLDAPFilter(entry MockLDAPEntry, filter mockLDAPElement) bool {
+	switch filter.tag {
+	case 0xa0: // &
+		children, err := filter.children()
+		if err != nil {
+			return false
+		}
+		for _, child := range children {
+			if !matchMockLDAPFilter(entry, child) {
+				return false
+			}
+		}
+		return true
+	case 0xa1: // |
+		children, _ := filter.children()
+		for _, child := range children {
+			if matchMockLDAPFilter(entry, child) {
+				return true
+			}
+		}
+	case 0xa3: // égalité
+		fields, err := filter.children()
+		if err != nil || len(fields) != 2 {
+			return false
+		}
+		for _, value := range mockLDAPAttribute(entry, string(fields[0].content)) {
+			if strings.EqualFold(value, string(fields[1].content)) {
+				return true
+			}
+		}
+	case 0x87: // présence
+		return len(mockLDAPAttribute(entry, string(filter.content))) > 0
+	}
+	return false
+}
+
+// mockLDAPAttribute retourne les valeurs d'un attribut de l'entrée
+func mockLDAPAttribute(entry MockLDAPEntry, name string) []string {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// selectedMockLDAPAttribute indique si l'attribut fait partie de la sélection (tous les attributs si elle est vide)
+func selectedMockLDAPAttribute(selection []mockLDAPElement, name string) bool {
+	if len(selection) == 0 {
+		return true
+	}
+	for _, selected := range selection {
+		if strings.EqualFold(string(selected.content), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mockLDAPResult encode un LDAPResult sans DN ni message
+func mockLDAPResult(code int) [][]byte {
+	return [][]byte{mockLDAPEncode(0x0a, []byte{byte(code)}), mockLDAPEncode(0x04, nil), mockLDAPEncode(0x04, nil)}
+}
+
+// mockLDAPMessage encode un LDAPMessage
+func mockLDAPMessage(id []byte, op []byte) []byte {
+	return mockLDAPEncode(0x30, mockLDAPEncode(0x02, id), op)
+}
+
+// mockLDAPEncode encode un élément BER dont le contenu est la concaténation des parties
+func mockLDAPEncode(tag byte, parts ...[]byte) []byte {
+	var content []byte
+	for _, part := range parts {
+		content = append(content, part...)
+	}
+	encoded := []byte{tag}
+	switch length := len(content); {
+	case length < 0x80:
+		encoded = append(encoded, byte(length))
+	case length <= 0xff:
+		encoded = append(encoded, 0x81, byte(length))
+	default:
+		encoded = append(encoded, 0x82, byte(length>>8), byte(length))
+	}
+	return append(encoded, content...)
+}
+
+// children décode les éléments d'un élément construit
+func (e mockLDAPElement) children() ([]mockLDAPElement, error) {
+	var elements []mockLDAPElement
+	reader := bufio.NewReader(strings.NewReader(string(e.content)))
+	for {
+		element, err := readMockLDAPElement(reader)
+		if err == io.EOF {
+			return elements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+}
+
+// readMockLDAPElement lit un élément BER
+func readMockLDAPElement(reader *bufio.Reader) (mockLDAPElement, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return mockLDAPElement{}, err
+	}
+	first, err := reader.ReadByte()
+	if err != nil {
+		return mockLDAPElement{}, io.ErrUnexpectedEOF
+	}
+	length := int(first)
+	if first >= 0x80 {
+		size := int(first & 0x7f)
+		if size == 0 || size > 3 {
+			return mockLDAPElement{}, errors.New("longueur BER non prise en charge")
+		}
+		length = 0
+		for i := 0; i < size; i++ {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return mockLDAPElement{}, io.ErrUnexpectedEOF
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return mockLDAPElement{}, io.ErrUnexpectedEOF
+	}
+	return mockLDAPElement{tag: tag, content: content}, nil
+}
@@ -0,0 +1,203 @@
+package testutils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-averroes/internal/common"
+)
+
+// MockIdP est un fournisseur d'identité OpenID Connect en mémoire, utilisé par les tests de la connexion SSO.
+// Il publie son document de découverte et ses clés, et délivre des ID tokens RS256 pour les codes émis par Authorize.
+type MockIdP struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	key          *rsa.PrivateKey
+	mu           sync.Mutex
+	codes        map[string]mockAuthorization
+}
+
+// mockAuthorization est une autorisation accordée, en attente de l'échange du code
+type mockAuthorization struct {
+	claims        map[string]interface{}
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// NewMockIdP démarre un fournisseur d'identité pour le client indiqué
+func NewMockIdP(clientID, clientSecret string) (*MockIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	idp := &MockIdP{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        map[string]mockAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                idp.Issuer(),
+			"authorization_endpoint":                idp.Issuer() + "/authorize",
+			"token_endpoint":                        idp.Issuer() + "/token",
+			"jwks_uri":                              idp.Issuer() + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"code_challenge_methods_supported":      []string{"S256"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	return idp, nil
+}
+
+// Issuer retourne l'URL de l'émetteur
+func (idp *MockIdP) Issuer() string {
+	return idp.Server.URL
+}
+
+// Close arrête le fournisseur
+func (idp *MockIdP) Close() {
+	idp.Server.Close()
+}
+
+// Authorize simule la connexion de l'utilisateur chez le fournisseur : la requête d'autorisation est vérifiée
+// et le code et le state renvoyés au front-end sont retournés. Les claims sont ceux de l'ID token à délivrer.
+func (idp *MockIdP) Authorize(authorizationURL string, claims map[string]interface{}) (string, string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authorizationURL, idp.Issuer()+"/authorize?") ||
+		query.Get("response_type") != "code" ||
+		query.Get("client_id") != idp.ClientID ||
+		query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" ||
+		query.Get("state") == "" ||
+		!strings.Contains(" "+query.Get("scope")+" ", " openid ") {
+		return "", "", errors.New("requête d'autorisation invalide")
+	}
+
+	code, err := common.GenerateToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthorization{
+		claims:        claims,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+	return code, query.Get("state"), nil
+}
+
+// token échange un code d'autorisation contre un ID token après vérification du client et du code verifier PKCE
+func (idp *MockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if idp.ClientSecret != "" {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+		if !ok || clientID != idp.ClientID || clientSecret != idp.ClientSecret {
+			writeMockJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+	}
+
+	idp.mu.Lock()
+	authorization, found := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || authorization.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.codeChallenge {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	payload := map[string]interface{}{
+		"iss":   idp.Issuer(),
+		"aud":   idp.ClientID,
+		"sub":   "mock-subject",
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": authorization.nonce,
+	}
+	for name, value := range authorization.claims {
+		payload[name] = value
+	}
+	idToken, err := idp.Sign(payload)
+	if err != nil {
+		writeMockJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// Sign signe les claims en JWT RS256 avec la clé du fournisseur
+func (idp *MockIdP) Sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "mock"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// writeMockJSON écrit une réponse JSON du fournisseur
+func writeMockJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,27 @@
+package testutils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"go-averroes/internal/webpush"
+)
+
+// PushServiceURL est l'origine des points de terminaison servis par NewPushService : un nom public en https,
+// couvert par le certificat de httptest, que la validation des abonnements accepte
+const PushServiceURL = "https://push.example.com"
+
+// NewPushService démarre un service de push TLS local et y dirige les envois de webpush.Default,
+// quel que soit le nom d'hôte du point de terminaison. À fermer par le test.
+func NewPushService(handler http.Handler) *httptest.Server {
+	service := httptest.NewTLSServer(handler)
+	transport := service.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, service.Listener.Addr().String())
+	}
+	webpush.Default.HTTPClient = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return service
+}
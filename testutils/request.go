@@ -0,0 +1,53 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-averroes/internal/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+// APIResponse est une réponse JSON de l'API dont les données sont décodées à la demande.
+// Header et Raw conservent les en-têtes et le corps brut pour les tests qui les vérifient.
+type APIResponse struct {
+	common.JSONResponse
+	Data   json.RawMessage `json:"data"`
+	Header http.Header     `json:"-"`
+	Raw    string          `json:"-"`
+}
+
+// DoRequest exécute une requête JSON sur le serveur de test (authentifiée si token n'est pas vide)
+// et retourne le code HTTP et la réponse
+func DoRequest(t *testing.T, server *httptest.Server, token, method, url string, body interface{}) (int, APIResponse) {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, server.URL+url, bytes.NewReader(payload))
+	require.NoError(t, err, "Erreur lors de la création de la requête")
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err, "Erreur lors de l'exécution de la requête")
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var response APIResponse
+	require.NoError(t, json.Unmarshal(raw, &response), "Erreur lors du parsing de la réponse JSON")
+	response.Header = resp.Header
+	response.Raw = string(raw)
+	return resp.StatusCode, response
+}
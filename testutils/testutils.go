@@ -1,9 +1,7 @@
 package testutils
 
 import (
-	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
 	mathrand "math/rand"
 	"net/http"
@@ -11,14 +9,38 @@ import (
 	"strconv"
 	"time"
 
+	"go-averroes/internal/attachment"
+	"go-averroes/internal/booking_page"
 	"go-averroes/internal/calendar"
 	"go-averroes/internal/calendar_event"
+	"go-averroes/internal/calendar_template"
 	"go-averroes/internal/common"
+	"go-averroes/internal/email_verification"
+	"go-averroes/internal/event_batch"
+	"go-averroes/internal/event_invitation"
+	"go-averroes/internal/event_reminder"
+	"go-averroes/internal/event_search"
+	"go-averroes/internal/event_transfer"
+	"go-averroes/internal/holiday_calendar"
+	"go-averroes/internal/login_guard"
+	"go-averroes/internal/mailer"
 	"go-averroes/internal/middleware"
+	"go-averroes/internal/passkey"
+	"go-averroes/internal/password_reset"
+	"go-averroes/internal/push_subscription"
+	"go-averroes/internal/resource"
 	"go-averroes/internal/role"
 	"go-averroes/internal/session"
+	"go-averroes/internal/sso"
+	"go-averroes/internal/storage"
+	"go-averroes/internal/tag"
+	"go-averroes/internal/task"
+	"go-averroes/internal/two_factor"
 	"go-averroes/internal/user"
+	"go-averroes/internal/user_availability"
 	"go-averroes/internal/user_calendar"
+	"go-averroes/internal/webauthn"
+	"go-averroes/internal/webpush"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql" // Driver MySQL
@@ -42,6 +64,47 @@ func CreateTestRouter() *gin.Engine {
 	{
 		authGroup.POST("/login", func(c *gin.Context) { session.Session.Login(c) })
 		authGroup.POST("/refresh", func(c *gin.Context) { session.Session.RefreshToken(c) })
+		// Réinitialisation du mot de passe, limitée par adresse IP contre l'énumération et le spam
+		authGroup.POST("/password-reset/request",
+			middleware.RateLimitMiddleware(5, 15*time.Minute),
+			func(c *gin.Context) { password_reset.PasswordReset.Request(c) },
+		)
+		authGroup.POST("/password-reset/confirm",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { password_reset.PasswordReset.Confirm(c) },
+		)
+		// Vérification de l'adresse e-mail (lien envoyé à l'inscription)
+		authGroup.POST("/email-verification/resend",
+			middleware.RateLimitMiddleware(5, 15*time.Minute),
+			func(c *gin.Context) { email_verification.EmailVerification.Resend(c) },
+		)
+		authGroup.POST("/email-verification/confirm",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { email_verification.EmailVerification.Confirm(c) },
+		)
+		// Seconde étape de la connexion lorsque l'authentification à deux facteurs est activée
+		authGroup.POST("/2fa/verify",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.VerifyTwoFactor(c) },
+		)
+		// Connexion sans mot de passe par passkey (WebAuthn)
+		authGroup.POST("/passkeys/login/options",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { passkey.Passkey.LoginOptions(c) },
+		)
+		authGroup.POST("/passkeys/login",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.LoginWithPasskey(c) },
+		)
+		// Connexion SSO par le fournisseur d'identité OpenID Connect
+		authGroup.GET("/oidc/authorize",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { sso.SSO.Authorize(c) },
+		)
+		authGroup.POST("/oidc/callback",
+			middleware.RateLimitMiddleware(20, 15*time.Minute),
+			func(c *gin.Context) { session.Session.LoginWithOIDC(c) },
+		)
 	}
 
 	// ===== ROUTES D'AUTHENTIFICATION (protégées) =====
@@ -52,6 +115,18 @@ func CreateTestRouter() *gin.Engine {
 		authProtectedGroup.GET("/me", func(c *gin.Context) { user.User.GetAuthMe(c) })
 		authProtectedGroup.GET("/sessions", func(c *gin.Context) { session.Session.GetUserSessions(c) })
 		authProtectedGroup.DELETE("/sessions/:session_id", func(c *gin.Context) { session.Session.DeleteSession(c) })
+
+		// Authentification à deux facteurs (TOTP)
+		authProtectedGroup.GET("/2fa", func(c *gin.Context) { two_factor.TwoFactor.Status(c) })
+		authProtectedGroup.POST("/2fa/setup", func(c *gin.Context) { two_factor.TwoFactor.Setup(c) })
+		authProtectedGroup.POST("/2fa/confirm", func(c *gin.Context) { two_factor.TwoFactor.Confirm(c) })
+		authProtectedGroup.POST("/2fa/disable", func(c *gin.Context) { two_factor.TwoFactor.Disable(c) })
+
+		// Passkeys (WebAuthn)
+		authProtectedGroup.GET("/passkeys", func(c *gin.Context) { passkey.Passkey.List(c) })
+		authProtectedGroup.POST("/passkeys/register/options", func(c *gin.Context) { passkey.Passkey.RegisterOptions(c) })
+		authProtectedGroup.POST("/passkeys/register", func(c *gin.Context) { passkey.Passkey.Register(c) })
+		authProtectedGroup.DELETE("/passkeys/:passkey_id", func(c *gin.Context) { passkey.Passkey.Delete(c) })
 	}
 
 	// ===== ROUTES DE GESTION DES UTILISATEURS =====
@@ -68,6 +143,17 @@ func CreateTestRouter() *gin.Engine {
 			userProtectedGroup.GET("/me", func(c *gin.Context) { user.User.Get(c) })
 			userProtectedGroup.PUT("/me", func(c *gin.Context) { user.User.Update(c) })
 			userProtectedGroup.DELETE("/me", func(c *gin.Context) { user.User.Delete(c) })
+
+			// Heures de travail et absences de l'utilisateur connecté
+			userProtectedGroup.GET("/me/working-hours", func(c *gin.Context) { user_availability.UserAvailability.GetWorkingHours(c) })
+			userProtectedGroup.PUT("/me/working-hours", func(c *gin.Context) { user_availability.UserAvailability.UpdateWorkingHours(c) })
+			userProtectedGroup.GET("/me/out-of-office", func(c *gin.Context) { user_availability.UserAvailability.ListOutOfOffice(c) })
+			userProtectedGroup.POST("/me/out-of-office", func(c *gin.Context) { user_availability.UserAvailability.AddOutOfOffice(c) })
+			userProtectedGroup.DELETE("/me/out-of-office/:out_of_office_id", func(c *gin.Context) { user_availability.UserAvailability.DeleteOutOfOffice(c) })
+
+			// Invitations reçues par l'utilisateur connecté
+			userProtectedGroup.GET("/me/invitations", func(c *gin.Context) { event_invitation.EventInvitation.ListMine(c) })
+			userProtectedGroup.PUT("/me/invitations/:invitation_id", func(c *gin.Context) { event_invitation.EventInvitation.Respond(c) })
 		}
 
 		// Routes admin pour gérer tous les utilisateurs
@@ -78,6 +164,8 @@ func CreateTestRouter() *gin.Engine {
 			userAdminGroup.PUT("/:user_id", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.Update(c) })
 			userAdminGroup.DELETE("/:user_id", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.Delete(c) })
 			userAdminGroup.GET("/:user_id/with-roles", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { user.User.GetUserWithRoles(c) })
+			userAdminGroup.GET("/:user_id/security-events", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { login_guard.LoginGuard.ListEvents(c) })
+			userAdminGroup.DELETE("/:user_id/lockout", middleware.UserExistsMiddleware("user_id"), func(c *gin.Context) { login_guard.LoginGuard.Unlock(c) })
 		}
 	}
 
@@ -127,7 +215,7 @@ func CreateTestRouter() *gin.Engine {
 
 	// ===== ROUTES DE GESTION DES CALENDRERS =====
 	calendarGroup := router.Group("/calendar")
-	calendarGroup.Use(middleware.AuthMiddleware())
+	calendarGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
 	{
 		// L'utilisateur peut créer des calendriers
 		calendarGroup.POST("", func(c *gin.Context) { calendar.Calendar.Add(c) })
@@ -148,11 +236,16 @@ func CreateTestRouter() *gin.Engine {
 			middleware.UserCanAccessCalendarMiddleware(),
 			func(c *gin.Context) { calendar.Calendar.Delete(c) },
 		)
+		calendarGroup.POST("/:calendar_id/clone",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { calendar.Calendar.Clone(c) },
+		)
 	}
 
 	// ===== ROUTES DE GESTION DES ÉVÉNEMENTS =====
 	calendarEventGroup := router.Group("/calendar-event")
-	calendarEventGroup.Use(middleware.AuthMiddleware())
+	calendarEventGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
 	{
 		// Toutes les routes d'événements nécessitent l'accès au calendrier
 		calendarEventGroup.GET("/:calendar_id/:event_id",
@@ -193,6 +286,284 @@ func CreateTestRouter() *gin.Engine {
 			middleware.EventExistsMiddleware("event_id"),
 			func(c *gin.Context) { calendar_event.CalendarEvent.Delete(c) },
 		)
+
+		// Opérations groupées sur les événements du calendrier
+		calendarEventGroup.POST("/:calendar_id/batch",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_batch.EventBatch.Apply(c) },
+		)
+
+		// Partage d'un même événement entre plusieurs calendriers
+		calendarEventGroup.POST("/:calendar_id/:event_id/link",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { calendar_event.CalendarEvent.Link(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/link",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { calendar_event.CalendarEvent.Unlink(c) },
+		)
+
+		// Déplacement et copie vers un autre calendrier (accès requis aux deux calendriers)
+		calendarEventGroup.POST("/:calendar_id/move",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_transfer.EventTransfer.Move(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/move",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_transfer.EventTransfer.Move(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/copy",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { event_transfer.EventTransfer.Copy(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/copy",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_transfer.EventTransfer.Copy(c) },
+		)
+
+		// Pièces jointes : mêmes contrôles d'accès que l'événement
+		calendarEventGroup.POST("/:calendar_id/:event_id/attachments",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { attachment.Attachment.Upload(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/attachments",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { attachment.Attachment.List(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/attachments/:attachment_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			middleware.AttachmentExistsMiddleware("attachment_id"),
+			func(c *gin.Context) { attachment.Attachment.Download(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/attachments/:attachment_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			middleware.AttachmentExistsMiddleware("attachment_id"),
+			func(c *gin.Context) { attachment.Attachment.Delete(c) },
+		)
+		calendarEventGroup.GET("/:calendar_id/:event_id/resources",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.ListForEvent(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/resources",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.Book(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/resources/:resource_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { resource.Resource.Release(c) },
+		)
+		// Invitations : la disponibilité de chaque invité est calculée sur le créneau de l'événement
+		calendarEventGroup.GET("/:calendar_id/:event_id/invitations",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_invitation.EventInvitation.ListForEvent(c) },
+		)
+		calendarEventGroup.POST("/:calendar_id/:event_id/invitations",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_invitation.EventInvitation.Invite(c) },
+		)
+		// Rappel Web Push de l'utilisateur connecté
+		calendarEventGroup.GET("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Get(c) },
+		)
+		calendarEventGroup.PUT("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Set(c) },
+		)
+		calendarEventGroup.DELETE("/:calendar_id/:event_id/reminder",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.EventExistsMiddleware("event_id"),
+			func(c *gin.Context) { event_reminder.EventReminder.Delete(c) },
+		)
+	}
+
+	// ===== ROUTES DE RECHERCHE D'ÉVÉNEMENTS =====
+	eventsGroup := router.Group("/events")
+	eventsGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		// La recherche est restreinte aux calendriers accessibles via user_calendar
+		eventsGroup.GET("/search", func(c *gin.Context) { event_search.EventSearch.Search(c) })
+	}
+
+	// ===== ROUTES DE GESTION DES ÉTIQUETTES =====
+	tagGroup := router.Group("/tags")
+	tagGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		tagGroup.GET("", func(c *gin.Context) { tag.Tag.List(c) })
+		tagGroup.POST("", func(c *gin.Context) { tag.Tag.Add(c) })
+		// Une étiquette n'est visible que par son propriétaire
+		tagGroup.GET("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Get(c) })
+		tagGroup.PUT("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Update(c) })
+		tagGroup.DELETE("/:tag_id", middleware.TagExistsMiddleware("tag_id"), func(c *gin.Context) { tag.Tag.Delete(c) })
+	}
+
+	// ===== ROUTES DES MODÈLES DE CALENDRIER =====
+	templateGroup := router.Group("/templates")
+	templateGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		templateGroup.GET("", func(c *gin.Context) { calendar_template.CalendarTemplate.List(c) })
+		templateGroup.GET("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Get(c) })
+		templateGroup.POST("/:template_id/instantiate", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Instantiate(c) })
+
+		// La publication des modèles est réservée aux administrateurs
+		templateAdminGroup := templateGroup.Group("")
+		templateAdminGroup.Use(middleware.AdminMiddleware())
+		{
+			templateAdminGroup.POST("", func(c *gin.Context) { calendar_template.CalendarTemplate.Add(c) })
+			templateAdminGroup.PUT("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Update(c) })
+			templateAdminGroup.DELETE("/:template_id", middleware.TemplateExistsMiddleware("template_id"), func(c *gin.Context) { calendar_template.CalendarTemplate.Delete(c) })
+		}
+	}
+
+	// ===== ROUTES DE GESTION DES TÂCHES =====
+	taskGroup := router.Group("/calendar-task")
+	taskGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		taskGroup.GET("/:calendar_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { task.Task.List(c) },
+		)
+		taskGroup.POST("/:calendar_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			func(c *gin.Context) { task.Task.Add(c) },
+		)
+		taskGroup.GET("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Get(c) },
+		)
+		taskGroup.PUT("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Update(c) },
+		)
+		taskGroup.DELETE("/:calendar_id/:task_id",
+			middleware.CalendarExistsMiddleware("calendar_id"),
+			middleware.UserCanAccessCalendarMiddleware(),
+			middleware.TaskExistsMiddleware("task_id"),
+			func(c *gin.Context) { task.Task.Delete(c) },
+		)
+	}
+
+	// ===== ROUTES DES RESSOURCES RÉSERVABLES =====
+	resourceGroup := router.Group("/resources")
+	resourceGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		resourceGroup.GET("", func(c *gin.Context) { resource.Resource.List(c) })
+		resourceGroup.GET("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Get(c) })
+		resourceGroup.GET("/:resource_id/availability", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Availability(c) })
+
+		// La gestion des ressources est réservée à leurs administrateurs
+		resourceAdminGroup := resourceGroup.Group("")
+		resourceAdminGroup.Use(middleware.ResourceAdminMiddleware())
+		{
+			resourceAdminGroup.POST("", func(c *gin.Context) { resource.Resource.Add(c) })
+			resourceAdminGroup.PUT("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Update(c) })
+			resourceAdminGroup.DELETE("/:resource_id", middleware.ResourceExistsMiddleware("resource_id"), func(c *gin.Context) { resource.Resource.Delete(c) })
+		}
+	}
+
+	// ===== ROUTES DES DISPONIBILITÉS DES UTILISATEURS =====
+	availabilityGroup := router.Group("/availability")
+	availabilityGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		availabilityGroup.GET("/free-busy", func(c *gin.Context) { user_availability.UserAvailability.FreeBusy(c) })
+		availabilityGroup.GET("/slots", func(c *gin.Context) { user_availability.UserAvailability.FindSlots(c) })
+	}
+
+	// ===== ROUTES DES CALENDRIERS DE JOURS FÉRIÉS =====
+	holidayCalendarGroup := router.Group("/holiday-calendars")
+	holidayCalendarGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		holidayCalendarGroup.GET("", func(c *gin.Context) { holiday_calendar.HolidayCalendar.List(c) })
+		holidayCalendarGroup.GET("/:country", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Holidays(c) })
+		holidayCalendarGroup.POST("/:country/subscription", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Subscribe(c) })
+		holidayCalendarGroup.DELETE("/:country/subscription", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Unsubscribe(c) })
+	}
+
+	// ===== ROUTE DE L'AGENDA (événements et jours fériés) =====
+	agendaGroup := router.Group("/agenda")
+	agendaGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		agendaGroup.GET("", func(c *gin.Context) { holiday_calendar.HolidayCalendar.Agenda(c) })
+	}
+
+	// ===== ROUTES DES NOTIFICATIONS WEB PUSH =====
+	pushGroup := router.Group("/push")
+	pushGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		pushGroup.GET("/vapid-public-key", func(c *gin.Context) { push_subscription.PushSubscription.VAPIDPublicKey(c) })
+		pushGroup.GET("/subscriptions", func(c *gin.Context) { push_subscription.PushSubscription.List(c) })
+		pushGroup.POST("/subscriptions", func(c *gin.Context) { push_subscription.PushSubscription.Add(c) })
+		pushGroup.DELETE("/subscriptions/:push_subscription_id", func(c *gin.Context) { push_subscription.PushSubscription.Delete(c) })
+		pushGroup.POST("/test", func(c *gin.Context) { push_subscription.PushSubscription.Test(c) })
+	}
+
+	// ===== ROUTES DES PAGES DE RÉSERVATION =====
+	bookingPageGroup := router.Group("/booking-pages")
+	bookingPageGroup.Use(middleware.AuthMiddleware(), middleware.EmailVerifiedMiddleware())
+	{
+		bookingPageGroup.GET("", func(c *gin.Context) { booking_page.BookingPage.List(c) })
+		bookingPageGroup.POST("", func(c *gin.Context) { booking_page.BookingPage.Add(c) })
+		bookingPageGroup.GET("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Get(c) })
+		bookingPageGroup.PUT("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Update(c) })
+		bookingPageGroup.DELETE("/:booking_page_id", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.Delete(c) })
+		bookingPageGroup.GET("/:booking_page_id/bookings", middleware.BookingPageExistsMiddleware("booking_page_id"), func(c *gin.Context) { booking_page.BookingPage.ListBookings(c) })
+	}
+
+	// ===== ROUTES PUBLIQUES DE PRISE DE RENDEZ-VOUS (sans authentification) =====
+	publicBookingGroup := router.Group("/booking/:slug")
+	publicBookingGroup.Use(middleware.PublicBookingPageMiddleware("slug"))
+	{
+		publicBookingGroup.GET("", func(c *gin.Context) { booking_page.BookingPage.Public(c) })
+		publicBookingGroup.GET("/slots", func(c *gin.Context) { booking_page.BookingPage.Slots(c) })
+		// Réservation et annulation sans compte, limitées par adresse IP : elles créent et annulent des événements dans le calendrier du propriétaire
+		publicBookingGroup.POST("",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { booking_page.BookingPage.Book(c) },
+		)
+		publicBookingGroup.POST("/cancel",
+			middleware.RateLimitMiddleware(10, 15*time.Minute),
+			func(c *gin.Context) { booking_page.BookingPage.Cancel(c) },
+		)
 	}
 
 	return router
@@ -246,6 +617,38 @@ func SetupTestEnvironment() error {
 	common.DB.Exec("INSERT INTO `roles` (name, description, created_at) VALUES ('moderator', 'Modérateur avec permissions étendues de gestion', NOW()) ON DUPLICATE KEY UPDATE updated_at = NOW();")
 	common.DB.Exec("INSERT INTO `roles` (name, description, created_at) VALUES ('editor', 'Éditeur avec permissions de création et modification de contenu', NOW()) ON DUPLICATE KEY UPDATE updated_at = NOW();")
 
+	// Stockage des pièces jointes dans un répertoire temporaire
+	storageConfig := common.LoadStorageConfig()
+	storageConfig.Backend = "local"
+	storageDir, err := os.MkdirTemp("", "golendar-attachments-")
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création du répertoire de stockage de test: %v", err)
+	}
+	storageConfig.LocalDir = storageDir
+	if err := storage.Init(storageConfig); err != nil {
+		return fmt.Errorf("erreur lors de l'initialisation du stockage de test: %v", err)
+	}
+
+	// Clé VAPID éphémère pour les notifications Web Push
+	if err := webpush.Init(common.WebPushConfig{VAPIDSubject: "mailto:test@example.com"}); err != nil {
+		return fmt.Errorf("erreur lors de l'initialisation de Web Push: %v", err)
+	}
+
+	// E-mails conservés en mémoire pour être lus par les tests
+	if err := mailer.Init(common.MailConfig{Backend: "memory", From: "GoLendar <noreply@example.com>", AppURL: "http://localhost:3000"}); err != nil {
+		return fmt.Errorf("erreur lors de l'initialisation de l'envoi des e-mails: %v", err)
+	}
+
+	// Aucune restriction par défaut : les tests de la vérification d'adresse changent la politique
+	if err := email_verification.Init(common.EmailVerificationPolicyNone); err != nil {
+		return fmt.Errorf("erreur lors de l'initialisation de la vérification des adresses e-mail: %v", err)
+	}
+
+	// Partie de confiance WebAuthn du front-end de test, utilisée par l'authentificateur logiciel
+	if err := webauthn.Init(common.WebAuthnConfig{RPID: "localhost", RPName: "GoLendar", Origins: []string{"http://localhost:3000"}}); err != nil {
+		return fmt.Errorf("erreur lors de l'initialisation de WebAuthn: %v", err)
+	}
+
 	// Ici on pourrait ajouter d'autres initialisations (logger, etc.)
 	return nil
 }
@@ -319,6 +722,34 @@ func PurgeAllTestUsers() {
 		return
 	}
 	common.DB.Exec("SET FOREIGN_KEY_CHECKS=0;")
+	common.DB.Exec("TRUNCATE TABLE event_invitation")
+	common.DB.Exec("TRUNCATE TABLE security_event")
+	common.DB.Exec("TRUNCATE TABLE login_lockout")
+	common.DB.Exec("TRUNCATE TABLE used_refresh_token")
+	common.DB.Exec("TRUNCATE TABLE oidc_login_state")
+	common.DB.Exec("TRUNCATE TABLE passkey_challenge")
+	common.DB.Exec("TRUNCATE TABLE passkey")
+	common.DB.Exec("TRUNCATE TABLE login_challenge")
+	common.DB.Exec("TRUNCATE TABLE user_recovery_code")
+	common.DB.Exec("TRUNCATE TABLE user_totp")
+	common.DB.Exec("TRUNCATE TABLE email_verification_token")
+	common.DB.Exec("TRUNCATE TABLE password_reset_token")
+	common.DB.Exec("TRUNCATE TABLE event_reminder")
+	common.DB.Exec("TRUNCATE TABLE push_subscription")
+	common.DB.Exec("TRUNCATE TABLE user_holiday_calendar")
+	common.DB.Exec("TRUNCATE TABLE user_out_of_office")
+	common.DB.Exec("TRUNCATE TABLE user_working_hours")
+	common.DB.Exec("TRUNCATE TABLE booking")
+	common.DB.Exec("TRUNCATE TABLE booking_page_rule")
+	common.DB.Exec("TRUNCATE TABLE booking_page")
+	common.DB.Exec("TRUNCATE TABLE event_resource")
+	common.DB.Exec("TRUNCATE TABLE resource")
+	common.DB.Exec("TRUNCATE TABLE task")
+	common.DB.Exec("TRUNCATE TABLE calendar_template_event")
+	common.DB.Exec("TRUNCATE TABLE calendar_template")
+	common.DB.Exec("TRUNCATE TABLE attachment")
+	common.DB.Exec("TRUNCATE TABLE event_tag")
+	common.DB.Exec("TRUNCATE TABLE tag")
 	common.DB.Exec("TRUNCATE TABLE calendar_event")
 	common.DB.Exec("TRUNCATE TABLE event")
 	common.DB.Exec("TRUNCATE TABLE user_calendar")
@@ -411,10 +842,10 @@ func GenerateAuthenticatedAdmin(authenticated, saveToDB, hasCalendar, hasEvent b
 			// Récupérer les informations du calendrier créé
 			calendar = &common.Calendar{}
 			err = common.DB.QueryRow(`
-				SELECT calendar_id, title, description, created_at, updated_at, deleted_at 
+				SELECT calendar_id, title, description, color, created_at, updated_at, deleted_at 
 				FROM calendar 
 				WHERE calendar_id = ?
-			`, calendarID).Scan(&calendar.CalendarID, &calendar.Title, &calendar.Description, &calendar.CreatedAt, &calendar.UpdatedAt, &calendar.DeletedAt)
+			`, calendarID).Scan(&calendar.CalendarID, &calendar.Title, &calendar.Description, &calendar.Color, &calendar.CreatedAt, &calendar.UpdatedAt, &calendar.DeletedAt)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la récupération du calendrier: %v", err)
 			}
@@ -430,10 +861,10 @@ func GenerateAuthenticatedAdmin(authenticated, saveToDB, hasCalendar, hasEvent b
 			// Récupérer les informations de l'événement créé
 			event = &common.Event{}
 			err = common.DB.QueryRow(`
-				SELECT event_id, title, description, start, duration, canceled, created_at, updated_at, deleted_at 
+				SELECT event_id, title, description, start, duration, canceled, location, latitude, longitude, meeting_url, created_at, updated_at, deleted_at 
 				FROM event 
 				WHERE event_id = ?
-			`, eventID).Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
+			`, eventID).Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.Location, &event.Latitude, &event.Longitude, &event.MeetingURL, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la récupération de l'événement: %v", err)
 			}
@@ -463,12 +894,12 @@ func GenerateAuthenticatedAdmin(authenticated, saveToDB, hasCalendar, hasEvent b
 
 		// Si authentifié, générer des tokens en mémoire
 		if authenticated {
-			sessionToken, err = generateToken()
+			sessionToken, err = common.GenerateToken(32)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la génération du session token: %v", err)
 			}
 
-			refreshToken, err = generateToken()
+			refreshToken, err = common.GenerateToken(32)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la génération du refresh token: %v", err)
 			}
@@ -567,10 +998,10 @@ func GenerateAuthenticatedUser(authenticated, saveToDB, hasCalendar, hasEvent bo
 			// Récupérer les informations du calendrier créé
 			calendar = &common.Calendar{}
 			err = common.DB.QueryRow(`
-				SELECT calendar_id, title, description, created_at, updated_at, deleted_at 
+				SELECT calendar_id, title, description, color, created_at, updated_at, deleted_at 
 				FROM calendar 
 				WHERE calendar_id = ?
-			`, calendarID).Scan(&calendar.CalendarID, &calendar.Title, &calendar.Description, &calendar.CreatedAt, &calendar.UpdatedAt, &calendar.DeletedAt)
+			`, calendarID).Scan(&calendar.CalendarID, &calendar.Title, &calendar.Description, &calendar.Color, &calendar.CreatedAt, &calendar.UpdatedAt, &calendar.DeletedAt)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la récupération du calendrier: %v", err)
 			}
@@ -586,10 +1017,10 @@ func GenerateAuthenticatedUser(authenticated, saveToDB, hasCalendar, hasEvent bo
 			// Récupérer les informations de l'événement créé
 			event = &common.Event{}
 			err = common.DB.QueryRow(`
-				SELECT event_id, title, description, start, duration, canceled, created_at, updated_at, deleted_at 
+				SELECT event_id, title, description, start, duration, canceled, location, latitude, longitude, meeting_url, created_at, updated_at, deleted_at 
 				FROM event 
 				WHERE event_id = ?
-			`, eventID).Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
+			`, eventID).Scan(&event.EventID, &event.Title, &event.Description, &event.Start, &event.Duration, &event.Canceled, &event.Location, &event.Latitude, &event.Longitude, &event.MeetingURL, &event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la récupération de l'événement: %v", err)
 			}
@@ -611,12 +1042,12 @@ func GenerateAuthenticatedUser(authenticated, saveToDB, hasCalendar, hasEvent bo
 
 		// Si authentifié, générer des tokens en mémoire
 		if authenticated {
-			sessionToken, err = generateToken()
+			sessionToken, err = common.GenerateToken(32)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la génération du session token: %v", err)
 			}
 
-			refreshToken, err = generateToken()
+			refreshToken, err = common.GenerateToken(32)
 			if err != nil {
 				return nil, fmt.Errorf("erreur lors de la génération du refresh token: %v", err)
 			}
@@ -680,10 +1111,10 @@ func createUserWithPassword(lastname, firstname, email, password string) (*commo
 	}
 	defer tx.Rollback()
 
-	// Créer l'utilisateur
+	// Créer l'utilisateur (adresse e-mail considérée comme vérifiée)
 	result, err := tx.Exec(`
-		INSERT INTO user (lastname, firstname, email, created_at) 
-		VALUES (?, ?, ?, NOW())
+		INSERT INTO user (lastname, firstname, email, email_verified_at, created_at) 
+		VALUES (?, ?, ?, NOW(), NOW())
 	`, lastname, firstname, email)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la création de l'utilisateur: %v", err)
@@ -707,13 +1138,14 @@ func createUserWithPassword(lastname, firstname, email, password string) (*commo
 	// Récupérer l'utilisateur créé
 	var user common.User
 	err = common.DB.QueryRow(`
-		SELECT user_id, lastname, firstname, email, created_at, updated_at, deleted_at
+		SELECT user_id, lastname, firstname, email, email_verified_at, created_at, updated_at, deleted_at
 		FROM user WHERE user_id = ?
 	`, userID).Scan(
 		&user.UserID,
 		&user.Lastname,
 		&user.Firstname,
 		&user.Email,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -752,6 +1184,23 @@ func ensureAdminRoleExists() (int, error) {
 	return int(newRoleID), nil
 }
 
+// GrantRole attribue à l'utilisateur le rôle nommé, en le créant s'il n'existe pas encore
+func GrantRole(userID int, roleName string) error {
+	var roleID int
+	err := common.DB.QueryRow("SELECT role_id FROM roles WHERE name = ? AND deleted_at IS NULL", roleName).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		result, err := common.DB.Exec("INSERT INTO roles (name, description, created_at) VALUES (?, ?, NOW())", roleName, "Rôle de test "+roleName)
+		if err != nil {
+			return fmt.Errorf("erreur lors de la création du rôle %s: %v", roleName, err)
+		}
+		id, _ := result.LastInsertId()
+		roleID = int(id)
+	} else if err != nil {
+		return fmt.Errorf("erreur lors de la vérification du rôle %s: %v", roleName, err)
+	}
+	return assignRoleToUser(userID, roleID)
+}
+
 // assignRoleToUser assigne un rôle à un utilisateur
 func assignRoleToUser(userID, roleID int) error {
 	// Vérifier si l'attribution existe déjà
@@ -781,24 +1230,24 @@ func assignRoleToUser(userID, roleID int) error {
 // CreateUserSession crée une session pour un utilisateur avec une durée spécifiée
 func CreateUserSession(userID int, duration time.Duration) (string, string, time.Time, error) {
 	// Générer les tokens
-	sessionToken, err := generateToken()
+	sessionToken, err := common.GenerateToken(32)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("erreur lors de la génération du session token: %v", err)
 	}
 
-	refreshToken, err := generateToken()
+	refreshToken, err := common.GenerateToken(32)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("erreur lors de la génération du refresh token: %v", err)
 	}
 
-	// Définir l'expiration
+	// Définir l'expiration, commune au session token et au refresh token
 	expiresAt := time.Now().Add(duration)
 
 	// Créer la session en base
 	_, err = common.DB.Exec(`
-		INSERT INTO user_session (user_id, session_token, refresh_token, expires_at, device_info, ip_address, location, is_active, created_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, TRUE, NOW())
-	`, userID, sessionToken, refreshToken, expiresAt, "Test Device", "127.0.0.1", "Local")
+		INSERT INTO user_session (user_id, session_token_hash, refresh_token_hash, expires_at, refresh_expires_at, device_info, ip_address, location, is_active, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, TRUE, NOW())
+	`, userID, common.HashToken(sessionToken), common.HashToken(refreshToken), expiresAt, expiresAt, "Test Device", "127.0.0.1", "Local")
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("erreur lors de la création de la session: %v", err)
 	}
@@ -806,16 +1255,6 @@ func CreateUserSession(userID int, duration time.Duration) (string, string, time
 	return sessionToken, refreshToken, expiresAt, nil
 }
 
-// generateToken génère un token aléatoire (copié depuis session.go)
-func generateToken() (string, error) {
-	bytes := make([]byte, 32)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
-}
-
 // GetStringValue retourne la valeur d'un pointeur string ou "<nil>" si nil
 func GetStringValue(s *string) string {
 	if s == nil {
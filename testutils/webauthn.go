@@ -0,0 +1,155 @@
+package testutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+)
+
+// Flags de authenticatorData positionnés par l'authentificateur logiciel
+const (
+	WebAuthnFlagUserPresent  = 0x01
+	WebAuthnFlagUserVerified = 0x04
+	webAuthnFlagAttested     = 0x40
+)
+
+// SoftAuthenticator est un authentificateur WebAuthn logiciel (clé ES256) utilisé par les tests des passkeys
+type SoftAuthenticator struct {
+	RPID      string
+	Origin    string
+	Flags     byte   // Présence et vérification de l'utilisateur par défaut
+	SignCount uint32 // Incrémenté avant chaque signature
+	key       *ecdsa.PrivateKey
+	id        []byte
+}
+
+// NewSoftAuthenticator crée un authentificateur logiciel avec une nouvelle paire de clés
+func NewSoftAuthenticator(rpID, origin string) (*SoftAuthenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &SoftAuthenticator{
+		RPID:   rpID,
+		Origin: origin,
+		Flags:  WebAuthnFlagUserPresent | WebAuthnFlagUserVerified,
+		key:    key,
+		id:     id,
+	}, nil
+}
+
+// CredentialID retourne l'identifiant de la passkey en base64url
+func (a *SoftAuthenticator) CredentialID() string {
+	return base64.RawURLEncoding.EncodeToString(a.id)
+}
+
+// Register simule navigator.credentials.create() et retourne clientDataJSON et attestationObject
+// (attestation "none") en base64url
+func (a *SoftAuthenticator) Register(challenge string) (string, string) {
+	clientDataJSON := a.clientData("webauthn.create", challenge)
+
+	// AAGUID nul, longueur et identifiant du credential, clé publique COSE EC2
+	credential := make([]byte, 18)
+	binary.BigEndian.PutUint16(credential[16:], uint16(len(a.id)))
+	credential = append(credential, a.id...)
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	credential = append(credential, cborHead(5, 5)...)
+	credential = append(credential, cborInt(1)...)
+	credential = append(credential, cborInt(2)...) // kty : EC2
+	credential = append(credential, cborInt(3)...)
+	credential = append(credential, cborInt(-7)...) // alg : ES256
+	credential = append(credential, cborInt(-1)...)
+	credential = append(credential, cborInt(1)...) // crv : P-256
+	credential = append(credential, cborInt(-2)...)
+	credential = append(credential, cborBytes(x)...)
+	credential = append(credential, cborInt(-3)...)
+	credential = append(credential, cborBytes(y)...)
+
+	authData := a.authenticatorData(a.Flags|webAuthnFlagAttested, credential)
+	attestation := cborHead(5, 3)
+	attestation = append(attestation, cborText("fmt")...)
+	attestation = append(attestation, cborText("none")...)
+	attestation = append(attestation, cborText("attStmt")...)
+	attestation = append(attestation, cborHead(5, 0)...)
+	attestation = append(attestation, cborText("authData")...)
+	attestation = append(attestation, cborBytes(authData)...)
+
+	return base64.RawURLEncoding.EncodeToString(clientDataJSON), base64.RawURLEncoding.EncodeToString(attestation)
+}
+
+// Assert simule navigator.credentials.get() et retourne clientDataJSON, authenticatorData et la signature en base64url
+func (a *SoftAuthenticator) Assert(challenge string) (string, string, string, error) {
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	authData := a.authenticatorData(a.Flags, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return "", "", "", err
+	}
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(clientDataJSON), encoding.EncodeToString(authData), encoding.EncodeToString(signature), nil
+}
+
+// clientData construit clientDataJSON tel que le navigateur le signe
+func (a *SoftAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+// authenticatorData construit authenticatorData en incrémentant le compteur de signatures
+func (a *SoftAuthenticator) authenticatorData(flags byte, credential []byte) []byte {
+	a.SignCount++
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.SignCount)
+	return append(data, credential...)
+}
+
+// cborHead encode l'octet initial et l'argument d'un élément CBOR
+func cborHead(major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return []byte{major<<5 | byte(value)}
+	case value <= 0xff:
+		return []byte{major<<5 | 24, byte(value)}
+	case value <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(value))
+	case value <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(value))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, value)
+}
+
+// cborInt encode un entier CBOR
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+	return cborHead(0, uint64(value))
+}
+
+// cborBytes encode une chaîne d'octets CBOR
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+// cborText encode une chaîne de texte CBOR
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}